@@ -2,9 +2,10 @@ package command
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"io"
 	"os"
-	"strings"
 
 	"github.com/urfave/cli/v2"
 
@@ -12,12 +13,17 @@ import (
 	"github.com/peak/s5cmd/v2/log/stat"
 	"github.com/peak/s5cmd/v2/parallel"
 	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/strutil"
 )
 
 const (
 	defaultWorkerCount = 256
 	defaultRetryCount  = 10
 
+	// maxPageSize is the maximum number of keys in a page of the listings of
+	// S3, and of the other providers such as GCS and MinIO.
+	maxPageSize = 1000
+
 	appName = "s5cmd"
 )
 
@@ -27,28 +33,114 @@ var app = &cli.App{
 	EnableBashCompletion: true,
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
-			Name:  "json",
-			Usage: "enable JSON formatted output",
+			Name:    "json",
+			Usage:   "enable JSON formatted output",
+			EnvVars: []string{"S5CMD_JSON"},
 		},
 		&cli.IntFlag{
-			Name:  "numworkers",
-			Value: defaultWorkerCount,
-			Usage: "number of workers execute operation on each object",
+			Name:    "numworkers",
+			Value:   defaultWorkerCount,
+			Usage:   "number of workers execute operation on each object, i.e. the number of objects in flight",
+			EnvVars: []string{"S5CMD_NUMWORKERS"},
+		},
+		&cli.DurationFlag{
+			Name:        "concurrency-ramp",
+			Usage:       "ramp the number of the objects in flight up from one to --numworkers gradually over the given duration at the start of the run, so that S3 scales for a cold prefix without throttling the requests with SlowDown errors, e.g. 1m",
+			DefaultText: "disabled",
+			EnvVars:     []string{"S5CMD_CONCURRENCY_RAMP"},
+		},
+		&cli.BoolFlag{
+			Name:    "adaptive",
+			Usage:   "halve the number of the objects in flight when the requests are throttled or fail with 5xx errors, and increase it back gradually once they succeed",
+			EnvVars: []string{"S5CMD_ADAPTIVE"},
+		},
+		&cli.StringFlag{
+			Name:        "rate-limit",
+			Usage:       "limit the number of the bytes transferred per second by the uploads and downloads of the whole run, shared fairly between the files in flight, e.g. 10MB",
+			DefaultText: "unlimited",
+			EnvVars:     []string{"S5CMD_RATE_LIMIT"},
 		},
 		&cli.IntFlag{
 			Name:    "retry-count",
 			Aliases: []string{"r"},
 			Value:   defaultRetryCount,
 			Usage:   "number of times that a request will be retried for failures",
+			EnvVars: []string{"S5CMD_RETRY_COUNT"},
+		},
+		&cli.StringFlag{
+			Name:    "retry-on",
+			Value:   storage.DefaultRetryOn,
+			Usage:   "comma separated list of the error classes that a request will be retried for: (throttle, 5xx, network, timeout)",
+			EnvVars: []string{"S5CMD_RETRY_ON"},
+		},
+		&cli.IntFlag{
+			Name:        "retry-budget",
+			Usage:       "total number of times that the requests of the whole run will be retried for failures, after which the failed requests are not retried",
+			DefaultText: "unlimited",
+			EnvVars:     []string{"S5CMD_RETRY_BUDGET"},
 		},
 		&cli.StringFlag{
 			Name:    "endpoint-url",
-			Usage:   "override default S3 host for custom services",
-			EnvVars: []string{"S3_ENDPOINT_URL"},
+			Usage:   "override default S3 host for custom services; the scheme is inferred if it's missing, http for localhost and https for the others, and the paths of the requests are appended to its base path, e.g. https://host/s3",
+			EnvVars: []string{"S5CMD_ENDPOINT_URL", "S3_ENDPOINT_URL"},
+		},
+		&cli.GenericFlag{
+			Name: "endpoint-scheme",
+			Value: &EnumValue{
+				Enum: []string{storage.EndpointSchemeHTTP, storage.EndpointSchemeHTTPS},
+			},
+			Usage:   "scheme of the endpoint-url given without a scheme, instead of inferring it: (http, https)",
+			EnvVars: []string{"S5CMD_ENDPOINT_SCHEME"},
+		},
+		&cli.GenericFlag{
+			Name: "addressing-style",
+			Value: &EnumValue{
+				Enum:    []string{storage.AddressingStyleAuto, storage.AddressingStylePath, storage.AddressingStyleVirtual},
+				Default: storage.AddressingStyleAuto,
+			},
+			Usage:   "addressing style of the bucket names in request URLs: (auto, path, virtual)",
+			EnvVars: []string{"S5CMD_ADDRESSING_STYLE"},
+		},
+		&cli.GenericFlag{
+			Name: "backend",
+			Value: &EnumValue{
+				Enum:    []string{storage.BackendAuto, storage.BackendAWS, storage.BackendGCS, storage.BackendMinIO, storage.BackendR2},
+				Default: storage.BackendAuto,
+			},
+			Usage:   "storage backend which the default part size and part concurrency are tuned for, detected from the endpoint by default: (auto, aws, gcs, minio, r2)",
+			EnvVars: []string{"S5CMD_BACKEND"},
+		},
+		&cli.GenericFlag{
+			Name: "request-checksum-calculation",
+			Value: &EnumValue{
+				Enum:    []string{storage.RequestChecksumWhenSupported, storage.RequestChecksumWhenRequired},
+				Default: storage.RequestChecksumWhenSupported,
+			},
+			Usage:   "send the checksums of the requests whenever they are supported, or only when they are required by the operations, for the S3 compatible services which reject them: (when_supported, when_required)",
+			EnvVars: []string{"S5CMD_REQUEST_CHECKSUM_CALCULATION"},
+		},
+		&cli.GenericFlag{
+			Name: "checksum-validation",
+			Value: &EnumValue{
+				Enum: []string{checksumValidationStrict, checksumValidationWarn, checksumValidationOff},
+			},
+			Usage:   "verify the downloads of cp, mv, sync and cat commands against the additional checksums or the MD5 ETags of the objects, and fail or print a warning if they don't match or the objects can't be verified, e.g. objects uploaded by parts without an additional checksum: (strict, warn, off)",
+			EnvVars: []string{"S5CMD_CHECKSUM_VALIDATION"},
+		},
+		&cli.StringFlag{
+			Name:    "config",
+			Usage:   "path of the config file which defines the remotes (default: ~/.s5cmd/config.toml)",
+			EnvVars: []string{"S5CMD_CONFIG"},
+		},
+		&cli.StringFlag{
+			Name:    "remote",
+			Usage:   "use the endpoint, region, addressing style and credentials of the given remote from the config file",
+			EnvVars: []string{"S5CMD_REMOTE"},
 		},
 		&cli.BoolFlag{
-			Name:  "no-verify-ssl",
-			Usage: "disable SSL certificate verification",
+			Name:    "no-verify-ssl",
+			Usage:   "disable SSL certificate verification",
+			EnvVars: []string{"S5CMD_NO_VERIFY_SSL"},
 		},
 		&cli.GenericFlag{
 			Name: "log",
@@ -56,39 +148,99 @@ var app = &cli.App{
 				Enum:    []string{"trace", "debug", "info", "error"},
 				Default: "info",
 			},
-			Usage: "log level: (trace, debug, info, error)",
+			Usage:   "log level: (trace, debug, info, error)",
+			EnvVars: []string{"S5CMD_LOG_LEVEL"},
+		},
+		&cli.BoolFlag{
+			Name:    "log-requests",
+			Usage:   "print a line to standard error for each request sent to the remote storage with its operation, key, HTTP status, request ID, duration and retry count",
+			EnvVars: []string{"S5CMD_LOG_REQUESTS"},
+		},
+		&cli.Float64Flag{
+			Name:    "log-requests-sample",
+			Value:   1,
+			Usage:   "fraction of the requests printed by --log-requests flag, between 0 and 1, e.g. 0.1 for high-volume runs",
+			EnvVars: []string{"S5CMD_LOG_REQUESTS_SAMPLE"},
+		},
+		&cli.BoolFlag{
+			Name:    "only-show-errors",
+			Usage:   "print nothing but the errors, e.g. no progress, statistics, warnings or summary; overrides --log, --stat and --show-progress flags",
+			EnvVars: []string{"S5CMD_ONLY_SHOW_ERRORS"},
 		},
 		&cli.BoolFlag{
 			Name:  "install-completion",
 			Usage: "get completion installation instructions for your shell (only available for bash, pwsh, and zsh)",
 		},
 		&cli.BoolFlag{
-			Name:  "dry-run",
-			Usage: "fake run; show what commands will be executed without actually executing them",
+			Name:    "dry-run",
+			Usage:   "fake run; show what commands will be executed without actually executing them",
+			EnvVars: []string{"S5CMD_DRY_RUN"},
 		},
 		&cli.BoolFlag{
-			Name:  "stat",
-			Usage: "collect statistics of program execution and display it at the end",
+			Name:    "stat",
+			Usage:   "collect statistics of program execution and display it at the end",
+			EnvVars: []string{"S5CMD_STAT"},
 		},
 		&cli.BoolFlag{
-			Name:  "no-sign-request",
-			Usage: "do not sign requests: credentials will not be loaded if --no-sign-request is provided",
+			Name:    "no-sign-request",
+			Usage:   "do not sign requests: credentials will not be loaded if --no-sign-request is provided",
+			EnvVars: []string{"S5CMD_NO_SIGN_REQUEST"},
 		},
 		&cli.BoolFlag{
-			Name:  "use-list-objects-v1",
-			Usage: "use ListObjectsV1 API for services that don't support ListObjectsV2",
+			Name:    "use-list-objects-v1",
+			Usage:   "use ListObjectsV1 API for services that don't support ListObjectsV2",
+			EnvVars: []string{"S5CMD_USE_LIST_OBJECTS_V1"},
+		},
+		&cli.Int64Flag{
+			Name:        "page-size",
+			Usage:       fmt.Sprintf("maximum number of keys in a page of the listings, between 1 and %d", maxPageSize),
+			DefaultText: "provider default, 1000 for S3",
+			EnvVars:     []string{"S5CMD_PAGE_SIZE"},
+		},
+		&cli.StringFlag{
+			Name:    "request-payer",
+			Usage:   "who pays for request (access requester pays buckets)",
+			EnvVars: []string{"S5CMD_REQUEST_PAYER"},
+		},
+		&cli.StringFlag{
+			Name:    "expected-bucket-owner",
+			Usage:   "account ID of the expected bucket owner: requests to buckets owned by other accounts fail",
+			EnvVars: []string{"S5CMD_EXPECTED_BUCKET_OWNER"},
+		},
+		&cli.StringFlag{
+			Name:    "profile",
+			Usage:   "use the specified profile from the credentials file or the shared config file",
+			EnvVars: []string{"S5CMD_PROFILE"},
+		},
+		&cli.StringFlag{
+			Name:    "credentials-file",
+			Usage:   "use the specified credentials file instead of the default credentials file",
+			EnvVars: []string{"S5CMD_CREDENTIALS_FILE"},
 		},
 		&cli.StringFlag{
-			Name:  "request-payer",
-			Usage: "who pays for request (access requester pays buckets)",
+			Name:    "credential-process",
+			Usage:   "use the credentials printed in JSON format by the specified command, which is executed again before the credentials expire",
+			EnvVars: []string{"S5CMD_CREDENTIAL_PROCESS"},
 		},
 		&cli.StringFlag{
-			Name:  "profile",
-			Usage: "use the specified profile from the credentials file",
+			Name:    "web-identity-token-file",
+			Usage:   "assume the role of --assume-role-arn flag, or AWS_ROLE_ARN environment variable, with the web identity token in the specified file",
+			EnvVars: []string{"S5CMD_WEB_IDENTITY_TOKEN_FILE"},
 		},
 		&cli.StringFlag{
-			Name:  "credentials-file",
-			Usage: "use the specified credentials file instead of the default credentials file",
+			Name:    "assume-role-arn",
+			Usage:   "ARN of the role to assume with the web identity token of --web-identity-token-file flag, or AWS_WEB_IDENTITY_TOKEN_FILE environment variable",
+			EnvVars: []string{"S5CMD_ASSUME_ROLE_ARN"},
+		},
+		&cli.StringFlag{
+			Name:    "output-file",
+			Usage:   "write the output of the command, e.g. the listed objects, to the specified file instead of standard output; errors are still printed to standard error",
+			EnvVars: []string{"S5CMD_OUTPUT_FILE"},
+		},
+		&cli.BoolFlag{
+			Name:    "append",
+			Usage:   "append the output to the file of --output-file flag instead of truncating it",
+			EnvVars: []string{"S5CMD_APPEND"},
 		},
 	},
 	Before: func(c *cli.Context) error {
@@ -99,33 +251,131 @@ var app = &cli.App{
 		isStat := c.Bool("stat")
 		endpointURL := c.String("endpoint-url")
 
+		log.SetOnlyErrors(c.Bool("only-show-errors"))
 		log.Init(logLevel, printJSON)
-		parallel.Init(workerCount)
+		parallel.Init(workerCount, c.Duration("concurrency-ramp"), c.Bool("adaptive"))
+		if c.Bool("adaptive") {
+			storage.ObserveRequests(parallel.ReportRequest)
+		}
 
 		if retryCount < 0 {
 			err := fmt.Errorf("retry count cannot be a negative value")
-			printError(commandFromContext(c), c.Command.Name, err)
+			printError(c.Context, commandFromContext(c), c.Command.Name, err)
+			return err
+		}
+		if ramp := c.Duration("concurrency-ramp"); ramp < 0 {
+			err := fmt.Errorf("bad value for --concurrency-ramp %v: must be a positive duration", ramp)
+			printError(c.Context, commandFromContext(c), c.Command.Name, err)
+			return err
+		}
+		if rateLimit := c.String("rate-limit"); rateLimit != "" {
+			rate, err := strutil.ParseBytes(rateLimit)
+			if err != nil || rate == 0 {
+				err := fmt.Errorf("bad value for --rate-limit %q: must be a positive size per second, e.g. 10MB", rateLimit)
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
+				return err
+			}
+			parallel.LimitBandwidth(rate)
+		}
+		if retryBudget := c.Int("retry-budget"); c.IsSet("retry-budget") && retryBudget < 1 {
+			err := fmt.Errorf("bad value for --retry-budget %d: must be a positive number", retryBudget)
+			printError(c.Context, commandFromContext(c), c.Command.Name, err)
+			return err
+		}
+		if sample := c.Float64("log-requests-sample"); sample <= 0 || sample > 1 {
+			err := fmt.Errorf("bad value for --log-requests-sample %v: must be greater than 0 and at most 1", sample)
+			printError(c.Context, commandFromContext(c), c.Command.Name, err)
+			return err
+		}
+		if c.IsSet("log-requests-sample") && !c.Bool("log-requests") {
+			err := fmt.Errorf(`"log-requests-sample" flag can only be used with "log-requests" flag`)
+			printError(c.Context, commandFromContext(c), c.Command.Name, err)
+			return err
+		}
+		if c.IsSet("page-size") {
+			if pageSize := c.Int64("page-size"); pageSize < 1 || pageSize > maxPageSize {
+				err := fmt.Errorf("bad value for --page-size %d: must be between 1 and %d", pageSize, maxPageSize)
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
+				return err
+			}
+		}
+		if _, err := storage.ParseRetryOn(c.String("retry-on")); err != nil {
+			err := fmt.Errorf("bad value for --retry-on %q: %v", c.String("retry-on"), err)
+			printError(c.Context, commandFromContext(c), c.Command.Name, err)
 			return err
 		}
 		if c.Bool("no-sign-request") && c.String("profile") != "" {
 			err := fmt.Errorf(`"no-sign-request" and "profile" flags cannot be used together`)
-			printError(commandFromContext(c), c.Command.Name, err)
+			printError(c.Context, commandFromContext(c), c.Command.Name, err)
 			return err
 		}
 		if c.Bool("no-sign-request") && c.String("credentials-file") != "" {
 			err := fmt.Errorf(`"no-sign-request" and "credentials-file" flags cannot be used together`)
-			printError(commandFromContext(c), c.Command.Name, err)
+			printError(c.Context, commandFromContext(c), c.Command.Name, err)
+			return err
+		}
+		if c.String("credential-process") != "" {
+			for _, flag := range []string{"no-sign-request", "profile", "credentials-file"} {
+				if c.IsSet(flag) {
+					err := fmt.Errorf(`"credential-process" and %q flags cannot be used together`, flag)
+					printError(c.Context, commandFromContext(c), c.Command.Name, err)
+					return err
+				}
+			}
+		}
+		if err := validateWebIdentity(c); err != nil {
+			printError(c.Context, commandFromContext(c), c.Command.Name, err)
+			return err
+		}
+
+		if owner := c.String("expected-bucket-owner"); owner != "" && !isAccountID(owner) {
+			err := fmt.Errorf("bad value for --expected-bucket-owner %q: must be a 12-digit AWS account ID", owner)
+			printError(c.Context, commandFromContext(c), c.Command.Name, err)
 			return err
 		}
 
+		if name := c.String("remote"); name != "" {
+			if _, err := loadRemote(c.String("config"), name); err != nil {
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
+				return err
+			}
+		}
+
+		if c.Bool("append") && c.String("output-file") == "" {
+			err := fmt.Errorf(`"append" flag can only be used with "output-file" flag`)
+			printError(c.Context, commandFromContext(c), c.Command.Name, err)
+			return err
+		}
+		if path := c.String("output-file"); path != "" {
+			if err := openOutputFile(path, c.Bool("append")); err != nil {
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
+				return err
+			}
+		}
+
 		if isStat {
 			stat.InitStat()
 		}
 
+		if c.Bool("log-requests") {
+			storage.EnableRequestLog(c.Float64("log-requests-sample"))
+		}
+
+		if c.String("endpoint-scheme") != "" && endpointURL == "" {
+			err := fmt.Errorf(`"endpoint-scheme" flag can only be used with "endpoint-url" flag`)
+			printError(c.Context, commandFromContext(c), c.Command.Name, err)
+			return err
+		}
+
 		if endpointURL != "" {
-			if !strings.HasPrefix(endpointURL, "http") {
-				err := fmt.Errorf(`bad value for --endpoint-url %v: scheme is missing. Must be of the form http://<hostname>/ or https://<hostname>/`, endpointURL)
-				printError(commandFromContext(c), c.Command.Name, err)
+			endpoint, err := storage.NormalizeEndpoint(endpointURL, c.String("endpoint-scheme"))
+			if err != nil {
+				err := fmt.Errorf(`bad value for --endpoint-url %v: %v`, endpointURL, err)
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
+				return err
+			}
+			// the commands read the endpoint with the inferred scheme.
+			if err := c.Set("endpoint-url", endpoint); err != nil {
 				return err
 			}
 		}
@@ -171,26 +421,155 @@ var app = &cli.App{
 		}
 
 		parallel.Close()
+
+		// the summary of the operations is printed if the command is
+		// interrupted while running them.
+		if parallel.IsShutdown() && !log.OnlyErrors() {
+			completed, canceled := parallel.Summary()
+			log.Error(log.ErrorMessage{
+				Err: fmt.Sprintf("interrupted: %d operations completed, %d operations canceled", completed, canceled),
+			})
+		}
+
 		log.Close()
-		return nil
+		return closeOutputFile()
 	},
 }
 
+// outputFile is the file of --output-file flag which the output of the
+// command is written to.
+var outputFile *os.File
+
+// openOutputFile opens the file at the given path and redirects the output
+// to it. The file is truncated unless isAppend is set.
+func openOutputFile(path string, isAppend bool) error {
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if isAppend {
+		flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	f, err := os.OpenFile(path, flag, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open output file: %w", err)
+	}
+
+	outputFile = f
+	log.SetOutput(f)
+	return nil
+}
+
+// closeOutputFile closes the file of --output-file flag, if any. It must be
+// called after the logger is closed to not lose the pending messages.
+func closeOutputFile() error {
+	if outputFile == nil {
+		return nil
+	}
+
+	err := outputFile.Close()
+	outputFile = nil
+	log.SetOutput(os.Stdout)
+	return err
+}
+
 // NewStorageOpts creates storage.Options object from the given context.
 func NewStorageOpts(c *cli.Context) storage.Options {
-	return storage.Options{
+	opts := storage.Options{
 		DryRun:                 c.Bool("dry-run"),
 		Endpoint:               c.String("endpoint-url"),
+		AddressingStyle:        c.String("addressing-style"),
+		Backend:                c.String("backend"),
+		ChecksumCalculation:    c.String("request-checksum-calculation"),
 		MaxRetries:             c.Int("retry-count"),
+		RetryOn:                c.String("retry-on"),
+		RetryBudget:            c.Int("retry-budget"),
 		NoSignRequest:          c.Bool("no-sign-request"),
 		NoVerifySSL:            c.Bool("no-verify-ssl"),
 		RequestPayer:           c.String("request-payer"),
+		ExpectedBucketOwner:    c.String("expected-bucket-owner"),
 		UseListObjectsV1:       c.Bool("use-list-objects-v1"),
 		Profile:                c.String("profile"),
 		CredentialFile:         c.String("credentials-file"),
+		CredentialProcess:      c.String("credential-process"),
 		LogLevel:               log.LevelFromString(c.String("log")),
 		NoSuchUploadRetryCount: c.Int("no-such-upload-retry-count"),
+		PartRetryCount:         -1,
+		PageSize:               c.Int64("page-size"),
+	}
+
+	if c.IsSet("part-retry") {
+		opts.PartRetryCount = c.Int("part-retry")
 	}
+
+	opts.WebIdentityTokenFile, opts.AssumeRoleARN = webIdentity(c)
+
+	// the failed objects are not retried with the skip policy.
+	if c.String("on-error") == onErrorSkip {
+		opts.MaxRetries = 0
+	}
+
+	// the remote is validated before the commands are run.
+	if name := c.String("remote"); name != "" {
+		if remote, err := loadRemote(c.String("config"), name); err == nil {
+			remote.apply(&opts, c.IsSet)
+		}
+	}
+	return opts
+}
+
+// partConcurrency returns the value of the part-concurrency flag, or the
+// default part concurrency of the storage backend if the flag is not given.
+func partConcurrency(c *cli.Context, opts storage.Options) int {
+	if c.IsSet("part-concurrency") {
+		return c.Int("part-concurrency")
+	}
+	return opts.TransferDefaults().Concurrency
+}
+
+// partSizeBytes returns the value of the part-size flag in bytes, or the
+// default part size of the storage backend if the flag is not given.
+func partSizeBytes(c *cli.Context, opts storage.Options) int64 {
+	if c.IsSet("part-size") {
+		return c.Int64("part-size") * megabytes
+	}
+	return opts.TransferDefaults().PartSize * megabytes
+}
+
+// webIdentity returns the web identity token file and the role to assume
+// with it. The standard environment variables are used for the ones which
+// are not given, if any of the flags is given. Otherwise the environment
+// variables are handled by the SDK.
+func webIdentity(c *cli.Context) (tokenFile, roleARN string) {
+	tokenFile, roleARN = c.String("web-identity-token-file"), c.String("assume-role-arn")
+	if tokenFile == "" && roleARN == "" {
+		return "", ""
+	}
+	if tokenFile == "" {
+		tokenFile = os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	}
+	if roleARN == "" {
+		roleARN = os.Getenv("AWS_ROLE_ARN")
+	}
+	return tokenFile, roleARN
+}
+
+func validateWebIdentity(c *cli.Context) error {
+	tokenFile, roleARN := webIdentity(c)
+	if tokenFile == "" && roleARN == "" {
+		return nil
+	}
+	if tokenFile == "" {
+		return fmt.Errorf(`"assume-role-arn" flag requires "web-identity-token-file" flag or AWS_WEB_IDENTITY_TOKEN_FILE environment variable`)
+	}
+	if roleARN == "" {
+		return fmt.Errorf(`"web-identity-token-file" flag requires "assume-role-arn" flag or AWS_ROLE_ARN environment variable`)
+	}
+
+	for _, flag := range []string{"no-sign-request", "profile", "credentials-file", "credential-process"} {
+		if c.IsSet(flag) {
+			return fmt.Errorf(`"web-identity-token-file" and %q flags cannot be used together`, flag)
+		}
+	}
+	return nil
 }
 
 func Commands() []*cli.Command {
@@ -211,6 +590,8 @@ func Commands() []*cli.Command {
 		NewBucketVersionCommand(),
 		NewPresignCommand(),
 		NewHeadCommand(),
+		NewCleanMultipartCommand(),
+		NewVerifyCommand(),
 	}
 }
 
@@ -228,5 +609,37 @@ func AppCommand(name string) *cli.Command {
 func Main(ctx context.Context, args []string) error {
 	app.Commands = Commands()
 
+	// the invalid values of the environment variables of the flags fail the
+	// app before any of the handlers, which print the errors, is run.
+	if err := validateEnvFlags(app.Flags); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s %s\n", "Incorrect Usage:", err.Error())
+		_, _ = fmt.Fprintf(os.Stderr, "See 's5cmd --help' for usage\n")
+		return err
+	}
+
 	return app.RunContext(ctx, args)
 }
+
+// envFlagError is the error of an invalid value of the environment variable
+// of a flag.
+type envFlagError struct {
+	err error
+}
+
+func (e *envFlagError) Error() string { return e.err.Error() }
+
+func (e *envFlagError) Unwrap() error { return e.err }
+
+// validateEnvFlags validates the values of the environment variables of the
+// given flags by applying them to a flag set which is not used otherwise,
+// since only the environment variables are parsed by applying the flags.
+func validateEnvFlags(flags []cli.Flag) error {
+	set := flag.NewFlagSet(appName, flag.ContinueOnError)
+	set.SetOutput(io.Discard)
+	for _, f := range flags {
+		if err := f.Apply(set); err != nil {
+			return &envFlagError{err: err}
+		}
+	}
+	return nil
+}
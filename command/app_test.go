@@ -0,0 +1,28 @@
+package command
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestValidateEnvFlags(t *testing.T) {
+	flags := []cli.Flag{
+		&cli.IntFlag{
+			Name:    "workers",
+			EnvVars: []string{"S5CMD_TEST_WORKERS"},
+		},
+	}
+
+	t.Setenv("S5CMD_TEST_WORKERS", "4")
+	assert.NilError(t, validateEnvFlags(flags))
+
+	t.Setenv("S5CMD_TEST_WORKERS", "many")
+	err := validateEnvFlags(flags)
+
+	var envErr *envFlagError
+	assert.Assert(t, errors.As(err, &envErr))
+	assert.ErrorContains(t, err, `could not parse "many" as int value from environment variable "S5CMD_TEST_WORKERS" for flag workers`)
+}
@@ -52,7 +52,7 @@ func NewBucketVersionCommand() *cli.Command {
 		},
 		Before: func(ctx *cli.Context) error {
 			if err := checkNumberOfArguments(ctx, 1, 1); err != nil {
-				printError(commandFromContext(ctx), ctx.Command.Name, err)
+				printError(ctx.Context, commandFromContext(ctx), ctx.Command.Name, err)
 				return err
 			}
 			return nil
@@ -64,7 +64,7 @@ func NewBucketVersionCommand() *cli.Command {
 
 			bucket, err := url.New(c.Args().First())
 			if err != nil {
-				printError(fullCommand, c.Command.Name, err)
+				printError(c.Context, fullCommand, c.Command.Name, err)
 				return err
 			}
 
@@ -94,7 +94,7 @@ type BucketVersion struct {
 func (v BucketVersion) Run(ctx context.Context) error {
 	client, err := storage.NewRemoteClient(ctx, &url.URL{}, v.storageOpts)
 	if err != nil {
-		printError(v.fullCommand, v.op, err)
+		printError(ctx, v.fullCommand, v.op, err)
 		return err
 	}
 
@@ -103,7 +103,7 @@ func (v BucketVersion) Run(ctx context.Context) error {
 
 		err := client.SetBucketVersioning(ctx, v.status, v.src.Bucket)
 		if err != nil {
-			printError(v.fullCommand, v.op, err)
+			printError(ctx, v.fullCommand, v.op, err)
 			return err
 		}
 		msg := BucketVersionMessage{
@@ -111,13 +111,13 @@ func (v BucketVersion) Run(ctx context.Context) error {
 			Status: v.status,
 			isSet:  true,
 		}
-		log.Info(msg)
+		log.InfoContext(ctx, msg)
 		return nil
 	}
 
 	status, err := client.GetBucketVersioning(ctx, v.src.Bucket)
 	if err != nil {
-		printError(v.fullCommand, v.op, err)
+		printError(ctx, v.fullCommand, v.op, err)
 		return err
 	}
 
@@ -126,7 +126,7 @@ func (v BucketVersion) Run(ctx context.Context) error {
 		Status: status,
 		isSet:  false,
 	}
-	log.Info(msg)
+	log.InfoContext(ctx, msg)
 	return nil
 }
 
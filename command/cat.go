@@ -1,14 +1,25 @@
 package command
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/urfave/cli/v2"
 
+	errorpkg "github.com/peak/s5cmd/v2/error"
+	"github.com/peak/s5cmd/v2/log"
 	"github.com/peak/s5cmd/v2/log/stat"
 	"github.com/peak/s5cmd/v2/orderedwriter"
+	"github.com/peak/s5cmd/v2/parallel"
 	"github.com/peak/s5cmd/v2/storage"
 	"github.com/peak/s5cmd/v2/storage/url"
 )
@@ -31,6 +42,33 @@ Examples:
 
 	3. Concatenate multiple objects matching a prefix or wildcard and print to stdout
 		 > s5cmd {{.HelpName}} "s3://bucket/prefix/*"
+
+	4. Print a remote object's content only if it's modified since the given time
+		 > s5cmd {{.HelpName}} --if-modified-since 2024-10-01T20:30:00Z s3://bucket/prefix/object
+
+	5. Print a remote object's content only if it's not larger than 1GB
+		 > s5cmd {{.HelpName}} --max-object-size 1GB s3://bucket/prefix/object
+
+	6. Print only the lines of a remote object which contain "ERROR", along with their line numbers
+		 > s5cmd {{.HelpName}} --grep "ERROR" --line-numbers s3://bucket/prefix/object
+
+	7. Print the lines of multiple objects matching a wildcard, numbering the lines of each object from 1
+		 > s5cmd {{.HelpName}} --line-numbers --reset-line-numbers "s3://bucket/logs/*"
+
+	8. Print a binary object whose name contains glob characters byte by byte
+		 > s5cmd {{.HelpName}} --raw "s3://bucket/prefix/image[1].png" > image.png
+
+	9. Concatenate multiple objects matching a wildcard, separated by NUL characters
+		 > s5cmd {{.HelpName}} --null-separated "s3://bucket/records/*" | xargs -0 -n 1 ./process
+
+	10. Concatenate the byte ranges of multiple objects listed in a file, e.g. lines of "s3://bucket/object 0-1023" or "s3://bucket/object 1024-"
+		 > s5cmd {{.HelpName}} --ranges-from ranges.txt > dataset.bin
+
+	11. Print a remote object's content and exit with an error if the content doesn't match its checksum or MD5 ETag
+		 > s5cmd --checksum-validation strict {{.HelpName}} s3://bucket/prefix/object
+
+	12. Concatenate multiple objects matching a wildcard only if all of them still exist, without printing a partial output
+		 > s5cmd {{.HelpName}} --verify-first "s3://bucket/parts/*" > dataset.csv
 `
 
 func NewCatCommand() *cli.Command {
@@ -41,30 +79,66 @@ func NewCatCommand() *cli.Command {
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:  "raw",
-				Usage: "disable the wildcard operations, useful with filenames that contains glob characters",
+				Usage: "disable the wildcard operations, useful with filenames that contains glob characters, and print the content byte by byte without any line processing",
+			},
+			&cli.BoolFlag{
+				Name:  "null-separated",
+				Usage: "print a NUL character after the content of each object, so that the concatenated objects can be split",
 			},
 			&cli.StringFlag{
 				Name:  "version-id",
 				Usage: "use the specified version of an object",
 			},
 			&cli.IntFlag{
-				Name:    "concurrency",
-				Aliases: []string{"c"},
+				Name:    "part-concurrency",
+				Aliases: []string{"concurrency", "c"},
 				Value:   defaultCopyConcurrency,
-				Usage:   "number of concurrent parts transferred between host and remote server",
+				Usage:   "number of concurrent parts transferred between host and remote server for each file; the default depends on the storage backend",
 			},
 			&cli.IntFlag{
 				Name:    "part-size",
 				Aliases: []string{"p"},
 				Value:   defaultPartSize,
-				Usage:   "size of each part transferred between host and remote server, in MiB",
+				Usage:   "size of each part transferred between host and remote server, in MiB; the default depends on the storage backend",
+			},
+			&cli.StringFlag{
+				Name:  "if-modified-since",
+				Usage: "only print the object if it's modified since the given time (uses RFC3339 format), e.g. --if-modified-since '2024-10-01T20:30:00Z'",
+			},
+			&cli.StringFlag{
+				Name:  "if-none-match",
+				Usage: "only print the object if its ETag doesn't match the given ETag",
+			},
+			&cli.StringFlag{
+				Name:  "max-object-size",
+				Usage: "do not print the objects larger than the given size, e.g. --max-object-size 10GB",
+			},
+			&cli.StringFlag{
+				Name:  "grep",
+				Usage: "only print the lines matching the given regular expression",
+			},
+			&cli.BoolFlag{
+				Name:  "line-numbers",
+				Usage: "prefix each printed line with its line number",
+			},
+			&cli.BoolFlag{
+				Name:  "reset-line-numbers",
+				Usage: "number the lines of each object from 1 instead of continuing the numbers of the previous objects, with line-numbers flag",
+			},
+			&cli.StringFlag{
+				Name:  "ranges-from",
+				Usage: "print the byte ranges listed in the given file in order, each line is an object and an inclusive range, e.g. 's3://bucket/object 0-1023' or 's3://bucket/object 1024-' to the end of the object",
+			},
+			&cli.BoolFlag{
+				Name:  "verify-first",
+				Usage: "send HEAD requests of all the objects concurrently before printing any of them, so that a missing object fails the command without a partial output",
 			},
 		},
 		CustomHelpTemplate: catHelpTemplate,
 		Before: func(c *cli.Context) error {
 			err := validateCatCommand(c)
 			if err != nil {
-				printError(commandFromContext(c), c.Command.Name, err)
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
 			}
 			return err
 		},
@@ -74,21 +148,70 @@ func NewCatCommand() *cli.Command {
 			op := c.Command.Name
 			fullCommand := commandFromContext(c)
 
-			src, err := url.New(c.Args().Get(0), url.WithVersion(c.String("version-id")),
-				url.WithRaw(c.Bool("raw")))
+			var (
+				src    *url.URL
+				ranges []objectRange
+			)
+			if path := c.String("ranges-from"); path != "" {
+				ranges, err = readObjectRanges(path, c.Bool("raw"))
+				if err != nil {
+					printError(c.Context, fullCommand, op, err)
+					return err
+				}
+				src = ranges[0].url
+			} else {
+				src, err = url.New(c.Args().Get(0), url.WithVersion(c.String("version-id")),
+					url.WithRaw(c.Bool("raw")))
+				if err != nil {
+					printError(c.Context, fullCommand, op, err)
+					return err
+				}
+			}
+
+			ifModifiedSince, err := parseIfModifiedSince(c.String("if-modified-since"))
 			if err != nil {
-				printError(fullCommand, op, err)
+				printError(c.Context, fullCommand, op, err)
 				return err
 			}
 
+			maxObjectSize, err := parseMaxObjectSize(c.String("max-object-size"))
+			if err != nil {
+				printError(c.Context, fullCommand, op, err)
+				return err
+			}
+
+			var grep *regexp.Regexp
+			if expr := c.String("grep"); expr != "" {
+				grep, err = regexp.Compile(expr)
+				if err != nil {
+					printError(c.Context, fullCommand, op, err)
+					return err
+				}
+			}
+
+			storageOpts := NewStorageOpts(c)
+
 			return Cat{
 				src:         src,
+				ranges:      ranges,
 				op:          op,
 				fullCommand: fullCommand,
 
-				storageOpts: NewStorageOpts(c),
-				concurrency: c.Int("concurrency"),
-				partSize:    c.Int64("part-size") * megabytes,
+				storageOpts: storageOpts,
+				concurrency: partConcurrency(c, storageOpts),
+				partSize:    partSizeBytes(c, storageOpts),
+				conditions: storage.DownloadConditions{
+					IfModifiedSince: ifModifiedSince,
+					IfNoneMatch:     c.String("if-none-match"),
+				},
+				maxObjectSize:      maxObjectSize,
+				checksumValidation: checksumValidation(c),
+
+				grep:             grep,
+				lineNumbers:      c.Bool("line-numbers"),
+				resetLineNumbers: c.Bool("reset-line-numbers"),
+				nullSeparated:    c.Bool("null-separated"),
+				verifyFirst:      c.Bool("verify-first"),
 			}.Run(c.Context)
 		},
 	}
@@ -99,39 +222,75 @@ func NewCatCommand() *cli.Command {
 // Cat holds cat operation flags and states.
 type Cat struct {
 	src         *url.URL
+	ranges      []objectRange
 	op          string
 	fullCommand string
 
 	storageOpts storage.Options
 	concurrency int
 	partSize    int64
+	conditions  storage.DownloadConditions
+
+	maxObjectSize      int64
+	checksumValidation string
+
+	grep             *regexp.Regexp
+	lineNumbers      bool
+	resetLineNumbers bool
+	nullSeparated    bool
+	verifyFirst      bool
+
+	output *lineWriter
 }
 
 // Run prints content of given source to standard output.
 func (c Cat) Run(ctx context.Context) error {
 	client, err := storage.NewRemoteClient(ctx, c.src, c.storageOpts)
 	if err != nil {
-		printError(c.fullCommand, c.op, err)
+		printError(ctx, c.fullCommand, c.op, err)
 		return err
 	}
 
+	if c.grep != nil || c.lineNumbers {
+		c.output = newLineWriter(log.Output(), c.grep, c.lineNumbers)
+	}
+
+	if len(c.ranges) > 0 {
+		return c.processRanges(ctx)
+	}
+
 	if c.src.IsWildcard() || c.src.IsPrefix() || c.src.IsBucket() {
 		objectChan := client.List(ctx, c.src, false)
+		if c.verifyFirst {
+			objectChan, err = c.verifyObjects(ctx, objectChan)
+			if err != nil {
+				return err
+			}
+		}
 		return c.processObjects(ctx, client, objectChan)
 	}
 
-	_, err = client.Stat(ctx, c.src)
+	obj, err := client.Stat(ctx, c.src)
 	if err != nil {
-		printError(c.fullCommand, c.op, err)
+		printError(ctx, c.fullCommand, c.op, err)
+		return err
+	}
+
+	if err := checkMaxObjectSize(obj, c.maxObjectSize); err != nil {
+		printError(ctx, c.fullCommand, c.op, err)
+		return err
+	}
+	if err := c.processSingleObject(ctx, client, c.src); err != nil {
+		printError(ctx, c.fullCommand, c.op, err)
 		return err
 	}
-	return c.processSingleObject(ctx, client, c.src)
+	return nil
 }
 
 func (c Cat) processObjects(ctx context.Context, client *storage.S3, objectChan <-chan *storage.Object) error {
 	for obj := range objectChan {
 		if obj.Err != nil {
-			printError(c.fullCommand, c.op, obj.Err)
+			printError(ctx, c.fullCommand, c.op, obj.Err)
 			return obj.Err
 		}
 
@@ -139,9 +298,14 @@ func (c Cat) processObjects(ctx context.Context, client *storage.S3, objectChan
 			continue
 		}
 
+		if err := checkMaxObjectSize(obj, c.maxObjectSize); err != nil {
+			printError(ctx, c.fullCommand, c.op, err)
+			return err
+		}
+
 		err := c.processSingleObject(ctx, client, obj.URL)
 		if err != nil {
-			printError(c.fullCommand, c.op, err)
+			printError(ctx, c.fullCommand, c.op, err)
 			return err
 		}
 	}
@@ -149,12 +313,370 @@ func (c Cat) processObjects(ctx context.Context, client *storage.S3, objectChan
 }
 
 func (c Cat) processSingleObject(ctx context.Context, client *storage.S3, url *url.URL) error {
-	buf := orderedwriter.New(os.Stdout)
-	_, err := client.Get(ctx, url, buf, c.concurrency, c.partSize)
+	var output io.Writer = log.Output()
+	if c.output != nil {
+		if c.resetLineNumbers {
+			c.output.line = 0
+		}
+		output = c.output
+	}
+
+	if c.checksumValidation == "" {
+		_, err := client.Get(ctx, url, orderedwriter.New(output), c.concurrency, c.partSize, c.conditions)
+		return c.finishObject(url, err)
+	}
+
+	// the content is verified after it's printed, since it's streamed.
+	checksumWriter := storage.NewChecksumWriter()
+	buf := orderedwriter.New(io.MultiWriter(output, checksumWriter))
+	_, checksum, err := client.GetWithChecksum(ctx, url, buf, c.concurrency, c.partSize, c.conditions, true)
+	if err == nil && !c.storageOpts.DryRun {
+		if err := c.output.Flush(); err != nil {
+			return err
+		}
+		err = validateDownloadChecksum(c.checksumValidation, url, checksum, checksumWriter.Verify)
+		if err != nil {
+			return fmt.Errorf("%v: %w", url, err)
+		}
+	}
+	return c.finishObject(url, err)
+}
+
+// finishObject completes printing the given object after it's downloaded with
+// the given error.
+func (c Cat) finishObject(url *url.URL, err error) error {
+	if storage.IsNotModifiedError(err) {
+		printDebug(c.op, errorpkg.ErrObjectNotModified, url)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := c.output.Flush(); err != nil {
+		return err
+	}
+
+	if c.nullSeparated {
+		_, err = log.Output().Write([]byte{0})
+	}
+	return err
+}
+
+// verifyObjects lists all the objects of the given listing and sends their
+// HEAD requests before any of them is printed. The objects are returned in
+// the listing order if all of them exist. The errors are printed.
+func (c Cat) verifyObjects(ctx context.Context, objectChan <-chan *storage.Object) (<-chan *storage.Object, error) {
+	var objects []*storage.Object
+	for obj := range objectChan {
+		if obj.Err != nil {
+			printError(ctx, c.fullCommand, c.op, obj.Err)
+			return nil, obj.Err
+		}
+
+		if obj.Type.IsDir() {
+			continue
+		}
+
+		if err := checkMaxObjectSize(obj, c.maxObjectSize); err != nil {
+			printError(ctx, c.fullCommand, c.op, err)
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+
+	urls := make([]*url.URL, 0, len(objects))
+	for _, obj := range objects {
+		urls = append(urls, obj.URL)
+	}
+	if err := c.headObjects(ctx, urls); err != nil {
+		return nil, err
+	}
+
+	verified := make(chan *storage.Object, len(objects))
+	for _, obj := range objects {
+		verified <- obj
+	}
+	close(verified)
+	return verified, nil
+}
+
+// headObjects sends the HEAD requests of the given objects concurrently. The
+// errors of the missing or inaccessible objects are printed, and they are
+// returned together once all the requests are completed.
+func (c Cat) headObjects(ctx context.Context, urls []*url.URL) error {
+	var merror error
+
+	waiter := parallel.NewWaiter()
+	errDoneCh := make(chan struct{})
+	go func() {
+		defer close(errDoneCh)
+		for err := range waiter.Err() {
+			printError(ctx, c.fullCommand, c.op, err)
+			merror = multierror.Append(merror, err)
+		}
+	}()
+
+	// the objects of the byte ranges may be repeated.
+	seen := map[string]struct{}{}
+	for _, u := range urls {
+		u := u
+		if _, ok := seen[u.String()]; ok {
+			continue
+		}
+		seen[u.String()] = struct{}{}
+
+		task := func() error {
+			client, err := storage.NewRemoteClient(ctx, u, c.storageOpts)
+			if err != nil {
+				return err
+			}
+			_, err = client.Stat(ctx, u)
+			// the error of a missing object already has its url.
+			var notFound *storage.ErrGivenObjectNotFound
+			if err != nil && !errors.As(err, &notFound) {
+				return fmt.Errorf("%v: %w", u, err)
+			}
+			return err
+		}
+		parallel.Run(task, waiter)
+	}
+
+	waiter.Wait()
+	<-errDoneCh
+	return merror
+}
+
+// processRanges prints the byte ranges of the objects in the given order.
+func (c Cat) processRanges(ctx context.Context) error {
+	if c.verifyFirst {
+		urls := make([]*url.URL, 0, len(c.ranges))
+		for _, r := range c.ranges {
+			urls = append(urls, r.url)
+		}
+		if err := c.headObjects(ctx, urls); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range c.ranges {
+		client, err := storage.NewRemoteClient(ctx, r.url, c.storageOpts)
+		if err != nil {
+			printError(ctx, c.fullCommand, c.op, err)
+			return err
+		}
+
+		if err := c.processRange(ctx, client, r); err != nil {
+			printError(ctx, c.fullCommand, c.op, fmt.Errorf("%v %v: %w", r.url, r, err))
+			return err
+		}
+	}
+	return nil
+}
+
+func (c Cat) processRange(ctx context.Context, client *storage.S3, r objectRange) error {
+	var output io.Writer = log.Output()
+	if c.output != nil {
+		if c.resetLineNumbers {
+			c.output.line = 0
+		}
+		output = c.output
+	}
+
+	body, err := client.ReadRange(ctx, r.url, r.start, r.end)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if _, err := io.Copy(output, body); err != nil {
+		return err
+	}
+	// the byte ranges can't be verified against the checksums of the whole
+	// objects, they are rejected with the strict policy up front.
+	if c.checksumValidation == checksumValidationWarn {
+		fmt.Fprintf(log.Warnings(), "WARNING %v %v: byte ranges can not be verified\n", r.url, r)
+	}
+	if err := c.output.Flush(); err != nil {
+		return err
+	}
+
+	if c.nullSeparated {
+		_, err = log.Output().Write([]byte{0})
+	}
+	return err
+}
+
+// objectRange is an inclusive byte range of an object. The range extends to
+// the end of the object if end is negative.
+type objectRange struct {
+	url   *url.URL
+	start int64
+	end   int64
+}
+
+func (r objectRange) String() string {
+	if r.end < 0 {
+		return fmt.Sprintf("%d-", r.start)
+	}
+	return fmt.Sprintf("%d-%d", r.start, r.end)
+}
+
+// readObjectRanges reads the object ranges from the given file. Each line is
+// an object URL and a range separated by whitespace, e.g.
+//
+//	s3://bucket/object 0-1023
+//	s3://bucket/object 1024-
+//
+// The empty lines are skipped.
+func readObjectRanges(path string, raw bool) ([]objectRange, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ranges []objectRange
+	scanner := bufio.NewScanner(f)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		r, err := parseObjectRange(line, raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid line %d of ranges file %q: %w", lineno, path, err)
+		}
+		ranges = append(ranges, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("ranges file %q is empty", path)
+	}
+	return ranges, nil
+}
+
+// parseObjectRange parses a line of the ranges file. The range is the last
+// field of the line, so that the keys may contain whitespace.
+func parseObjectRange(line string, raw bool) (objectRange, error) {
+	i := strings.LastIndexAny(line, " \t")
+	if i < 0 {
+		return objectRange{}, fmt.Errorf("expected an object and a range, e.g. 's3://bucket/object 0-1023'")
+	}
+	object, byteRange := strings.TrimSpace(line[:i]), line[i+1:]
+
+	objurl, err := url.New(object, url.WithRaw(raw))
+	if err != nil {
+		return objectRange{}, err
+	}
+	if !objurl.IsRemote() || objurl.IsWildcard() || objurl.IsPrefix() || objurl.IsBucket() {
+		return objectRange{}, fmt.Errorf("%q is not a remote object", object)
+	}
+
+	first, last, ok := strings.Cut(byteRange, "-")
+	if !ok {
+		return objectRange{}, fmt.Errorf("invalid range %q: expected start-end or start-", byteRange)
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return objectRange{}, fmt.Errorf("invalid range %q: start must be a non-negative number", byteRange)
+	}
+
+	end := int64(-1)
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < 0 {
+			return objectRange{}, fmt.Errorf("invalid range %q: end must be a non-negative number", byteRange)
+		}
+		if end < start {
+			return objectRange{}, fmt.Errorf("invalid range %q: end must not be less than start", byteRange)
+		}
+	}
+
+	return objectRange{url: objurl, start: start, end: end}, nil
+}
+
+// lineWriter is a writer which prints the lines written to it as they are
+// completed. It prints only the lines matching the grep expression, if given,
+// and prefixes them with their line numbers if lineNumbers is set. The line
+// numbers continue across the objects written to it.
+type lineWriter struct {
+	w           io.Writer
+	grep        *regexp.Regexp
+	lineNumbers bool
+
+	// line is the number of the lines written so far.
+	line int64
+	// partial is the incomplete last line written so far.
+	partial []byte
+}
+
+func newLineWriter(w io.Writer, grep *regexp.Regexp, lineNumbers bool) *lineWriter {
+	return &lineWriter{w: w, grep: grep, lineNumbers: lineNumbers}
+}
+
+// Write prints the completed lines of p, and keeps the incomplete last line
+// until it's completed by the next writes or flushed.
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			lw.partial = append(lw.partial, p...)
+			break
+		}
+
+		line := p[:i]
+		if len(lw.partial) > 0 {
+			line = append(lw.partial, line...)
+		}
+		if err := lw.writeLine(line); err != nil {
+			return 0, err
+		}
+		lw.partial = lw.partial[:0]
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// Flush prints the incomplete last line as a complete line. It's called at
+// the end of each object, so that the last line of an object is not joined
+// with the first line of the next one.
+func (lw *lineWriter) Flush() error {
+	if lw == nil || len(lw.partial) == 0 {
+		return nil
+	}
+	err := lw.writeLine(lw.partial)
+	lw.partial = lw.partial[:0]
+	return err
+}
+
+func (lw *lineWriter) writeLine(line []byte) error {
+	lw.line++
+	if lw.grep != nil && !lw.grep.Match(line) {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if lw.lineNumbers {
+		fmt.Fprintf(&buf, "%6d\t", lw.line)
+	}
+	buf.Write(line)
+	buf.WriteByte('\n')
+
+	_, err := lw.w.Write(buf.Bytes())
 	return err
 }
 
 func validateCatCommand(c *cli.Context) error {
+	if path := c.String("ranges-from"); path != "" {
+		return validateCatRanges(c, path)
+	}
+
 	if c.Args().Len() != 1 {
 		return fmt.Errorf("expected only one argument")
 	}
@@ -179,5 +701,54 @@ func validateCatCommand(c *cli.Context) error {
 		}
 	}
 
+	if _, err := parseIfModifiedSince(c.String("if-modified-since")); err != nil {
+		return err
+	}
+
+	if _, err := parseMaxObjectSize(c.String("max-object-size")); err != nil {
+		return err
+	}
+
+	return validateCatLineFlags(c)
+}
+
+// validateCatLineFlags validates the flags which process the printed lines.
+func validateCatLineFlags(c *cli.Context) error {
+	if _, err := regexp.Compile(c.String("grep")); err != nil {
+		return fmt.Errorf("invalid grep expression: %w", err)
+	}
+
+	if c.Bool("reset-line-numbers") && !c.Bool("line-numbers") {
+		return fmt.Errorf(`"reset-line-numbers" flag can only be used with "line-numbers" flag`)
+	}
+
+	// the content is printed as is with raw flag.
+	if c.Bool("raw") && (c.String("grep") != "" || c.Bool("line-numbers")) {
+		return fmt.Errorf(`"raw" flag cannot be used with "grep" or "line-numbers" flags`)
+	}
+
 	return nil
 }
+
+// validateCatRanges validates printing the ranges listed in the given file.
+func validateCatRanges(c *cli.Context, path string) error {
+	if c.Args().Len() != 0 {
+		return fmt.Errorf(`source argument cannot be given with "ranges-from" flag`)
+	}
+
+	for _, flag := range []string{"version-id", "if-modified-since", "if-none-match", "max-object-size"} {
+		if c.IsSet(flag) {
+			return fmt.Errorf(`"ranges-from" flag cannot be used with %q flag`, flag)
+		}
+	}
+
+	if c.String("checksum-validation") == checksumValidationStrict {
+		return fmt.Errorf(`"ranges-from" flag cannot be used with "checksum-validation" flag strict, the byte ranges can not be verified`)
+	}
+
+	if _, err := readObjectRanges(path, c.Bool("raw")); err != nil {
+		return err
+	}
+
+	return validateCatLineFlags(c)
+}
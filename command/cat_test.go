@@ -0,0 +1,139 @@
+package command
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestLineWriter(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name        string
+		grep        string
+		lineNumbers bool
+		writes      []string
+		expected    string
+	}{
+		{
+			name:     "grep",
+			grep:     "ERROR",
+			writes:   []string{"INFO started\nERROR fail", "ed\nINFO done\nERROR again"},
+			expected: "ERROR failed\nERROR again\n",
+		},
+		{
+			name:        "line numbers",
+			lineNumbers: true,
+			writes:      []string{"first\nsec", "ond\n", "\nlast"},
+			expected:    "     1\tfirst\n     2\tsecond\n     3\t\n     4\tlast\n",
+		},
+		{
+			name:        "grep with line numbers",
+			grep:        "^E",
+			lineNumbers: true,
+			writes:      []string{"INFO\nERROR 1\nINFO\nERROR 2\n"},
+			expected:    "     2\tERROR 1\n     4\tERROR 2\n",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var grep *regexp.Regexp
+			if tc.grep != "" {
+				grep = regexp.MustCompile(tc.grep)
+			}
+
+			var buf bytes.Buffer
+			lw := newLineWriter(&buf, grep, tc.lineNumbers)
+			for _, p := range tc.writes {
+				n, err := lw.Write([]byte(p))
+				assert.NilError(t, err)
+				assert.Equal(t, n, len(p))
+			}
+			assert.NilError(t, lw.Flush())
+			assert.Equal(t, buf.String(), tc.expected)
+		})
+	}
+}
+
+func TestParseObjectRange(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		line          string
+		expectedURL   string
+		expectedStart int64
+		expectedEnd   int64
+		expectedErr   string
+	}{
+		{
+			line:          "s3://bucket/object 0-1023",
+			expectedURL:   "s3://bucket/object",
+			expectedStart: 0,
+			expectedEnd:   1023,
+		},
+		{
+			line:          "s3://bucket/object\t1024-",
+			expectedURL:   "s3://bucket/object",
+			expectedStart: 1024,
+			expectedEnd:   -1,
+		},
+		{
+			line:          "s3://bucket/key with spaces 5-5",
+			expectedURL:   "s3://bucket/key with spaces",
+			expectedStart: 5,
+			expectedEnd:   5,
+		},
+		{
+			line:        "s3://bucket/object",
+			expectedErr: `expected an object and a range, e.g. 's3://bucket/object 0-1023'`,
+		},
+		{
+			line:        "s3://bucket/object 1023",
+			expectedErr: `invalid range "1023": expected start-end or start-`,
+		},
+		{
+			line:        "s3://bucket/object 10-5",
+			expectedErr: `invalid range "10-5": end must not be less than start`,
+		},
+		{
+			line:        "s3://bucket/object -5",
+			expectedErr: `invalid range "-5": start must be a non-negative number`,
+		},
+		{
+			line:        "s3://bucket/object 0-x",
+			expectedErr: `invalid range "0-x": end must be a non-negative number`,
+		},
+		{
+			line:        "s3://bucket/prefix/* 0-10",
+			expectedErr: `"s3://bucket/prefix/*" is not a remote object`,
+		},
+		{
+			line:        "object 0-10",
+			expectedErr: `"object" is not a remote object`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.line, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := parseObjectRange(tc.line, false)
+			if tc.expectedErr != "" {
+				assert.Error(t, err, tc.expectedErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, r.url.String(), tc.expectedURL)
+			assert.Equal(t, r.start, tc.expectedStart)
+			assert.Equal(t, r.end, tc.expectedEnd)
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package command
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/peak/s5cmd/v2/storage"
+)
+
+const (
+	// checkpointFlushInterval is the interval of writing the completed items
+	// to the checkpoint file.
+	checkpointFlushInterval = 5 * time.Second
+
+	// checkpointMaxEntries is the maximum number of items to be recorded in
+	// the checkpoint file. The items completed after the limit is reached are
+	// synced again by the next run.
+	checkpointMaxEntries = 1_000_000
+)
+
+// checkpointEntry identifies a source object synced to its destination. The
+// size and modification time are recorded so that the objects changed since
+// the previous run are not skipped.
+type checkpointEntry struct {
+	Source  string `json:"source"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mod_time"`
+}
+
+func newCheckpointEntry(object *storage.Object) string {
+	entry := checkpointEntry{
+		Source: object.URL.String(),
+		Size:   object.Size,
+	}
+	if object.ModTime != nil {
+		entry.ModTime = object.ModTime.UnixNano()
+	}
+
+	b, _ := json.Marshal(entry)
+	return string(b)
+}
+
+// syncCheckpoint records the source objects which are synced successfully, so
+// that a re-run of an interrupted sync skips them. The records are
+// periodically written to the checkpoint file.
+type syncCheckpoint struct {
+	path string
+	// readOnly checkpoints only skip the completed items, e.g. in dry-run
+	// mode, and never modify the checkpoint file.
+	readOnly bool
+
+	mu        sync.Mutex
+	completed map[string]struct{}
+	// pending maps the generated commands to the entries of the objects they
+	// sync.
+	pending map[string]string
+	dirty   bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// openSyncCheckpoint loads the completed items from the given checkpoint file
+// and starts flushing the newly completed ones periodically. A non-existent
+// checkpoint file is considered empty.
+func openSyncCheckpoint(path string, readOnly bool) (*syncCheckpoint, error) {
+	cp := &syncCheckpoint{
+		path:      path,
+		readOnly:  readOnly,
+		completed: map[string]struct{}{},
+		pending:   map[string]string{},
+		done:      make(chan struct{}),
+	}
+
+	if err := cp.load(); err != nil {
+		return nil, err
+	}
+
+	if readOnly {
+		return cp, nil
+	}
+
+	cp.wg.Add(1)
+	go func() {
+		defer cp.wg.Done()
+
+		ticker := time.NewTicker(checkpointFlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-cp.done:
+				return
+			case <-ticker.C:
+				if err := cp.flush(); err != nil {
+					printDebug("sync", err)
+				}
+			}
+		}
+	}()
+
+	return cp, nil
+}
+
+func (cp *syncCheckpoint) load() error {
+	f, err := os.Open(cp.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		var entry checkpointEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("invalid checkpoint file %q (line: %v): %w", cp.path, lineno, err)
+		}
+		b, _ := json.Marshal(entry)
+		cp.completed[string(b)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// isCompleted checks if the given source object is synced by a previous run.
+func (cp *syncCheckpoint) isCompleted(object *storage.Object) bool {
+	if cp == nil {
+		return false
+	}
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	_, ok := cp.completed[newCheckpointEntry(object)]
+	return ok
+}
+
+// track associates the given command with the source object it syncs.
+func (cp *syncCheckpoint) track(command string, object *storage.Object) {
+	if cp == nil || cp.readOnly {
+		return
+	}
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	cp.pending[command] = newCheckpointEntry(object)
+}
+
+// complete marks the source object of the given command as synced.
+func (cp *syncCheckpoint) complete(command string) {
+	if cp == nil || cp.readOnly {
+		return
+	}
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	entry, ok := cp.pending[command]
+	if !ok {
+		return
+	}
+	delete(cp.pending, command)
+
+	if len(cp.completed) >= checkpointMaxEntries {
+		return
+	}
+
+	cp.completed[entry] = struct{}{}
+	cp.dirty = true
+}
+
+// flush atomically writes the completed items to the checkpoint file.
+func (cp *syncCheckpoint) flush() error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	if !cp.dirty {
+		return nil
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(cp.path), filepath.Base(cp.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+	for entry := range cp.completed {
+		fmt.Fprintln(w, entry)
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.Name(), cp.path); err != nil {
+		return err
+	}
+
+	cp.dirty = false
+	return nil
+}
+
+// Close stops flushing periodically. The checkpoint file is removed if the
+// sync is completed successfully, otherwise the completed items are written
+// to it for the next run.
+func (cp *syncCheckpoint) Close(success bool) error {
+	if cp == nil || cp.readOnly {
+		return nil
+	}
+
+	close(cp.done)
+	cp.wg.Wait()
+
+	if success {
+		err := os.Remove(cp.path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	return cp.flush()
+}
@@ -0,0 +1,59 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/storage/url"
+	"gotest.tools/v3/assert"
+)
+
+func TestSyncCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	newObject := func(key string, size int64) *storage.Object {
+		u, err := url.New("s3://bucket/" + key)
+		assert.NilError(t, err)
+
+		modTime := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+		return &storage.Object{URL: u, Size: size, ModTime: &modTime}
+	}
+
+	cp, err := openSyncCheckpoint(path, false)
+	assert.NilError(t, err)
+
+	cp.track("cp s3://bucket/a s3://dst/a", newObject("a", 1))
+	cp.track("cp s3://bucket/b s3://dst/b", newObject("b", 2))
+	cp.complete("cp s3://bucket/a s3://dst/a")
+
+	// the completed items are kept for the next run on failure.
+	assert.NilError(t, cp.Close(false))
+
+	cp, err = openSyncCheckpoint(path, false)
+	assert.NilError(t, err)
+
+	assert.Assert(t, cp.isCompleted(newObject("a", 1)))
+	assert.Assert(t, !cp.isCompleted(newObject("a", 10)))
+	assert.Assert(t, !cp.isCompleted(newObject("b", 2)))
+
+	// the checkpoint file is removed on success.
+	assert.NilError(t, cp.Close(true))
+
+	_, err = os.Stat(path)
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestSyncCheckpointInvalidFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	assert.NilError(t, os.WriteFile(path, []byte("{invalid\n"), 0644))
+
+	_, err := openSyncCheckpoint(path, false)
+	assert.ErrorContains(t, err, "invalid checkpoint file")
+}
@@ -0,0 +1,207 @@
+package command
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/peak/s5cmd/v2/storage"
+)
+
+// checksumCacheEntry is the MD5 of a local file, which is valid as long as
+// the size and the modification time of the file are not changed.
+type checksumCacheEntry struct {
+	Path    string `json:"path"`
+	Dev     uint64 `json:"dev,omitempty"`
+	Inode   uint64 `json:"inode,omitempty"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mod_time"`
+	MD5     string `json:"md5"`
+}
+
+// checksumCacheKey identifies a local file by its device and inode numbers,
+// so that the renamed files are not hashed again. The files are identified by
+// their paths on the platforms without inodes, e.g. Windows.
+type checksumCacheKey struct {
+	dev   uint64
+	inode uint64
+	path  string
+}
+
+func (e checksumCacheEntry) key() checksumCacheKey {
+	if e.Dev == 0 && e.Inode == 0 {
+		return checksumCacheKey{path: e.Path}
+	}
+	return checksumCacheKey{dev: e.Dev, inode: e.Inode}
+}
+
+// checksumCache caches the MD5 of the local files compared by the ETag
+// strategy of sync, so that the unchanged files are not hashed again by the
+// next run. The entries are written to the cache file when the sync is
+// completed, except the ones of the files which are not compared by the run,
+// e.g. the deleted files.
+type checksumCache struct {
+	path string
+	// readOnly caches only use the cached checksums, e.g. in dry-run mode,
+	// and never modify the cache file.
+	readOnly bool
+
+	mu      sync.Mutex
+	entries map[checksumCacheKey]checksumCacheEntry
+	seen    map[checksumCacheKey]struct{}
+	dirty   bool
+}
+
+// openChecksumCache loads the entries of the given cache file. A non-existent
+// cache file is considered empty.
+func openChecksumCache(path string, readOnly bool) (*checksumCache, error) {
+	cache := &checksumCache{
+		path:     path,
+		readOnly: readOnly,
+		entries:  map[checksumCacheKey]checksumCacheEntry{},
+		seen:     map[checksumCacheKey]struct{}{},
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return cache, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		var entry checksumCacheEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid checksum cache file %q (line: %v): %w", path, lineno, err)
+		}
+		cache.entries[entry.key()] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+// md5 returns the hex encoded MD5 of the content of the given object. The
+// MD5 of a local file is computed only if it's not cached, or the file is
+// changed since it's cached.
+func (c *checksumCache) md5(obj *storage.Object) (string, error) {
+	if c == nil || obj.URL.IsRemote() {
+		return objectMD5(obj)
+	}
+
+	path := obj.URL.Absolute()
+	var modTime int64
+	if obj.ModTime != nil {
+		modTime = obj.ModTime.UnixNano()
+	}
+
+	// the files whose device and inode can't be read are hashed as usual, so
+	// that the read error is reported.
+	dev, inode, err := fileID(path)
+	if err != nil {
+		return objectMD5(obj)
+	}
+
+	newEntry := checksumCacheEntry{
+		Path:    path,
+		Dev:     dev,
+		Inode:   inode,
+		Size:    obj.Size,
+		ModTime: modTime,
+	}
+	key := newEntry.key()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.seen[key] = struct{}{}
+	c.mu.Unlock()
+
+	if ok && entry.Size == obj.Size && entry.ModTime == modTime {
+		// the path of a renamed file is updated.
+		if entry.Path != path {
+			c.mu.Lock()
+			entry.Path = path
+			c.entries[key] = entry
+			c.dirty = true
+			c.mu.Unlock()
+		}
+		return entry.MD5, nil
+	}
+
+	sum, err := objectMD5(obj)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	newEntry.MD5 = sum
+	c.entries[key] = newEntry
+	c.dirty = true
+	return sum, nil
+}
+
+// Close atomically writes the entries to the cache file.
+func (c *checksumCache) Close() error {
+	if c == nil || c.readOnly {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// the entries of the files which are not compared by the run are
+	// dropped, so that the cache doesn't grow with the deleted files.
+	for key := range c.entries {
+		if _, ok := c.seen[key]; !ok {
+			delete(c.entries, key)
+			c.dirty = true
+		}
+	}
+
+	if !c.dirty {
+		return nil
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, entry := range c.entries {
+		if err := enc.Encode(entry); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.Name(), c.path); err != nil {
+		return err
+	}
+
+	c.dirty = false
+	return nil
+}
@@ -0,0 +1,198 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/storage/url"
+)
+
+func TestChecksumCache(t *testing.T) {
+	t.Parallel()
+
+	const (
+		contentMD5  = "9a0364b9e99bb480dd25e1f0284c8555" // md5("content")
+		modifiedMD5 = "8977dfac2f8e04cb96e66882235f5aba" // md5("changed")
+	)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+	cachePath := filepath.Join(dir, "checksums")
+
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeFile := func(content string, modTime time.Time) *storage.Object {
+		t.Helper()
+
+		assert.NilError(t, os.WriteFile(file, []byte(content), 0o644))
+		assert.NilError(t, os.Chtimes(file, modTime, modTime))
+
+		u, err := url.New(file)
+		assert.NilError(t, err)
+		return &storage.Object{URL: u, Size: int64(len(content)), ModTime: &modTime}
+	}
+
+	cache, err := openChecksumCache(cachePath, false)
+	assert.NilError(t, err)
+
+	obj := writeFile("content", modTime)
+	sum, err := cache.md5(obj)
+	assert.NilError(t, err)
+	assert.Equal(t, sum, contentMD5)
+	assert.NilError(t, cache.Close())
+
+	// the file is not hashed again by the next run if its size and
+	// modification time are not changed, even though its content is.
+	cache, err = openChecksumCache(cachePath, false)
+	assert.NilError(t, err)
+
+	obj = writeFile("changed", modTime)
+	sum, err = cache.md5(obj)
+	assert.NilError(t, err)
+	assert.Equal(t, sum, contentMD5)
+
+	// the entry is invalidated once the modification time is changed.
+	obj = writeFile("changed", modTime.Add(time.Second))
+	sum, err = cache.md5(obj)
+	assert.NilError(t, err)
+	assert.Equal(t, sum, modifiedMD5)
+	assert.NilError(t, cache.Close())
+
+	dev, inode, err := fileID(file)
+	assert.NilError(t, err)
+
+	cache, err = openChecksumCache(cachePath, false)
+	assert.NilError(t, err)
+	expected := checksumCacheEntry{
+		Path:    file,
+		Dev:     dev,
+		Inode:   inode,
+		Size:    7,
+		ModTime: modTime.Add(time.Second).UnixNano(),
+		MD5:     modifiedMD5,
+	}
+	assert.DeepEqual(t, cache.entries, map[checksumCacheKey]checksumCacheEntry{
+		expected.key(): expected,
+	})
+}
+
+func TestChecksumCacheRenamedFile(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("the files are identified by their paths on windows")
+	}
+
+	const contentMD5 = "9a0364b9e99bb480dd25e1f0284c8555" // md5("content")
+
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.txt")
+	newPath := filepath.Join(dir, "new.txt")
+	cachePath := filepath.Join(dir, "checksums")
+
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newObject := func(path string) *storage.Object {
+		u, err := url.New(path)
+		assert.NilError(t, err)
+		return &storage.Object{URL: u, Size: 7, ModTime: &modTime}
+	}
+
+	assert.NilError(t, os.WriteFile(oldPath, []byte("content"), 0o644))
+	assert.NilError(t, os.Chtimes(oldPath, modTime, modTime))
+
+	cache, err := openChecksumCache(cachePath, false)
+	assert.NilError(t, err)
+	_, err = cache.md5(newObject(oldPath))
+	assert.NilError(t, err)
+	assert.NilError(t, cache.Close())
+
+	// the content of the renamed file is changed in place without changing
+	// its size and modification time, so that it's only matched by its
+	// inode.
+	assert.NilError(t, os.Rename(oldPath, newPath))
+	assert.NilError(t, os.WriteFile(newPath, []byte("changed"), 0o644))
+	assert.NilError(t, os.Chtimes(newPath, modTime, modTime))
+
+	cache, err = openChecksumCache(cachePath, false)
+	assert.NilError(t, err)
+	sum, err := cache.md5(newObject(newPath))
+	assert.NilError(t, err)
+	assert.Equal(t, sum, contentMD5)
+	assert.NilError(t, cache.Close())
+
+	cache, err = openChecksumCache(cachePath, false)
+	assert.NilError(t, err)
+	assert.Equal(t, len(cache.entries), 1)
+	for _, entry := range cache.entries {
+		assert.Equal(t, entry.Path, newPath)
+	}
+}
+
+func TestChecksumCacheDropsUnseenEntries(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "checksums")
+
+	now := time.Now()
+	newObject := func(name string) *storage.Object {
+		path := filepath.Join(dir, name)
+		assert.NilError(t, os.WriteFile(path, []byte("content"), 0o644))
+		u, err := url.New(path)
+		assert.NilError(t, err)
+		return &storage.Object{URL: u, Size: 7, ModTime: &now}
+	}
+
+	kept, deleted := newObject("kept.txt"), newObject("deleted.txt")
+
+	cache, err := openChecksumCache(cachePath, false)
+	assert.NilError(t, err)
+	for _, obj := range []*storage.Object{kept, deleted} {
+		_, err := cache.md5(obj)
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, cache.Close())
+
+	// the entry of the file which is not compared by the next run is
+	// dropped.
+	cache, err = openChecksumCache(cachePath, false)
+	assert.NilError(t, err)
+	_, err = cache.md5(kept)
+	assert.NilError(t, err)
+	assert.NilError(t, cache.Close())
+
+	cache, err = openChecksumCache(cachePath, false)
+	assert.NilError(t, err)
+	assert.Equal(t, len(cache.entries), 1)
+	for _, entry := range cache.entries {
+		assert.Equal(t, entry.Path, kept.URL.Absolute())
+	}
+}
+
+func TestChecksumCacheReadOnly(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+	cachePath := filepath.Join(dir, "checksums")
+	assert.NilError(t, os.WriteFile(file, []byte("content"), 0o644))
+
+	u, err := url.New(file)
+	assert.NilError(t, err)
+	now := time.Now()
+
+	cache, err := openChecksumCache(cachePath, true)
+	assert.NilError(t, err)
+
+	_, err = cache.md5(&storage.Object{URL: u, Size: 7, ModTime: &now})
+	assert.NilError(t, err)
+	assert.NilError(t, cache.Close())
+
+	_, err = os.Stat(cachePath)
+	assert.Assert(t, os.IsNotExist(err))
+}
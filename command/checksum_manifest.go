@@ -0,0 +1,146 @@
+package command
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+const (
+	checksumManifestCSV  = "csv"
+	checksumManifestJSON = "json"
+)
+
+// checksumManifestEntry is a transferred object recorded in the checksum
+// manifest.
+type checksumManifestEntry struct {
+	Key      string `json:"key"`
+	Size     int64  `json:"size"`
+	Etag     string `json:"etag"`
+	Checksum string `json:"checksum"`
+}
+
+// checksumManifest appends the transferred objects to the checksum manifest
+// file as they complete. Each entry is written at once, so that the entries
+// of the completed objects are kept if the run is interrupted.
+type checksumManifest struct {
+	format string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+var (
+	checksumManifestsMu sync.Mutex
+	// checksumManifests holds the open checksum manifests by their paths. A
+	// manifest is shared by all the commands of the process, e.g. the copy
+	// commands generated by sync.
+	checksumManifests = map[string]*checksumManifest{}
+)
+
+// openChecksumManifest opens the given checksum manifest file to append the
+// entries in the given format, creating it if it doesn't exist.
+func openChecksumManifest(path, format string) (*checksumManifest, error) {
+	abspath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	checksumManifestsMu.Lock()
+	defer checksumManifestsMu.Unlock()
+
+	if m, ok := checksumManifests[abspath]; ok {
+		return m, nil
+	}
+
+	file, err := os.OpenFile(abspath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &checksumManifest{format: format, file: file}
+	if err := m.init(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	checksumManifests[abspath] = m
+	return m, nil
+}
+
+// init writes the CSV header to a new manifest, and completes the last line
+// of a manifest if the previous run is interrupted while writing it.
+func (m *checksumManifest) init() error {
+	info, err := m.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Size() == 0 {
+		if m.format == checksumManifestCSV {
+			_, err = m.file.Write([]byte("key,size,etag,checksum\n"))
+		}
+		return err
+	}
+
+	last := make([]byte, 1)
+	if _, err := m.file.ReadAt(last, info.Size()-1); err != nil {
+		return err
+	}
+	if last[0] != '\n' {
+		_, err = m.file.Write([]byte("\n"))
+	}
+	return err
+}
+
+// write appends the given entry to the manifest.
+func (m *checksumManifest) write(entry checksumManifestEntry) error {
+	if m == nil {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	switch m.format {
+	case checksumManifestJSON:
+		if err := json.NewEncoder(&buf).Encode(entry); err != nil {
+			return err
+		}
+	default:
+		w := csv.NewWriter(&buf)
+		record := []string{entry.Key, strconv.FormatInt(entry.Size, 10), entry.Etag, entry.Checksum}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := m.file.Write(buf.Bytes())
+	return err
+}
+
+// md5Checksum returns the MD5 checksum of the given file, in the format of
+// the checksums in the manifest.
+func md5Checksum(file *os.File) (string, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	h := md5.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return "MD5:" + base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
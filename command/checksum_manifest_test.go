@@ -0,0 +1,72 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestChecksumManifestWrite(t *testing.T) {
+	t.Parallel()
+
+	entry := checksumManifestEntry{
+		Key:      "dir/file, name.txt",
+		Size:     9,
+		Etag:     "25f9e794323b453885f5181f1b624d0b",
+		Checksum: "CRC32C:4waSgw==",
+	}
+
+	testcases := []struct {
+		name     string
+		format   string
+		content  string
+		expected string
+	}{
+		{
+			name:   "csv",
+			format: checksumManifestCSV,
+			expected: "key,size,etag,checksum\n" +
+				`"dir/file, name.txt",9,25f9e794323b453885f5181f1b624d0b,CRC32C:4waSgw==` + "\n",
+		},
+		{
+			name:     "json",
+			format:   checksumManifestJSON,
+			expected: `{"key":"dir/file, name.txt","size":9,"etag":"25f9e794323b453885f5181f1b624d0b","checksum":"CRC32C:4waSgw=="}` + "\n",
+		},
+		{
+			name:    "csv appended to interrupted manifest",
+			format:  checksumManifestCSV,
+			content: "key,size,etag,checksum\nfile.txt,4,etag,MD",
+			expected: "key,size,etag,checksum\nfile.txt,4,etag,MD\n" +
+				`"dir/file, name.txt",9,25f9e794323b453885f5181f1b624d0b,CRC32C:4waSgw==` + "\n",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "manifest")
+			if tc.content != "" {
+				assert.NilError(t, os.WriteFile(path, []byte(tc.content), 0o644))
+			}
+
+			manifest, err := openChecksumManifest(path, tc.format)
+			assert.NilError(t, err)
+			t.Cleanup(func() { manifest.file.Close() })
+			assert.NilError(t, manifest.write(entry))
+
+			// the manifest is shared by the commands of the process.
+			reopened, err := openChecksumManifest(path, tc.format)
+			assert.NilError(t, err)
+			assert.Equal(t, reopened, manifest)
+
+			content, err := os.ReadFile(path)
+			assert.NilError(t, err)
+			assert.Equal(t, string(content), tc.expected)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package command
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/peak/s5cmd/v2/storage"
+)
+
+// userMetadataHeaderPrefix is the prefix of the headers of the user-defined
+// metadata of the objects.
+const userMetadataHeaderPrefix = "x-amz-meta-"
+
+// normalizeChecksumMetadataKey returns the user metadata key of the value of
+// checksum-metadata-key flag, which may be given as a header, e.g.
+// x-amz-meta-sha256, or as a key, e.g. sha256.
+func normalizeChecksumMetadataKey(value string) string {
+	key := strings.ToLower(strings.TrimSpace(value))
+	return strings.TrimPrefix(key, userMetadataHeaderPrefix)
+}
+
+// validateChecksumMetadataKey validates the value of checksum-metadata-key
+// flag.
+func validateChecksumMetadataKey(value string) error {
+	if value == "" {
+		return nil
+	}
+	if normalizeChecksumMetadataKey(value) == "" {
+		return fmt.Errorf(`invalid value for "checksum-metadata-key" flag %q: expected a user metadata key, e.g. x-amz-meta-sha256`, value)
+	}
+	return nil
+}
+
+// checksumMetadata returns the digest stored in the given user metadata key.
+// The keys are compared case-insensitively since they may be returned
+// canonicalized.
+func checksumMetadata(userDefined map[string]string, key string) (string, bool) {
+	for k, v := range userDefined {
+		if strings.EqualFold(k, key) && v != "" {
+			return strings.ToLower(strings.TrimSpace(v)), true
+		}
+	}
+	return "", false
+}
+
+// setChecksumMetadata returns the given user metadata with the digest stored
+// in the given key, replacing the key if it's given in another case. The
+// given map is not modified.
+func setChecksumMetadata(userDefined map[string]string, key, digest string) map[string]string {
+	metadata := make(map[string]string, len(userDefined)+1)
+	for k, v := range userDefined {
+		if !strings.EqualFold(k, key) {
+			metadata[k] = v
+		}
+	}
+	metadata[key] = digest
+	return metadata
+}
+
+// sha256Digest returns the hex encoded SHA256 digest of the given file. The
+// file is rewound before and after it's read.
+func sha256Digest(file *os.File) (string, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// objectSHA256 returns the hex encoded SHA256 digest of the content of the
+// given local file.
+func objectSHA256(obj *storage.Object) (string, error) {
+	file, err := os.Open(obj.URL.Absolute())
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return sha256Digest(file)
+}
@@ -0,0 +1,33 @@
+package command
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestNormalizeChecksumMetadataKey(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, normalizeChecksumMetadataKey("x-amz-meta-sha256"), "sha256")
+	assert.Equal(t, normalizeChecksumMetadataKey("X-Amz-Meta-Content-SHA256"), "content-sha256")
+	assert.Equal(t, normalizeChecksumMetadataKey("sha256"), "sha256")
+
+	assert.NilError(t, validateChecksumMetadataKey("x-amz-meta-sha256"))
+	assert.ErrorContains(t, validateChecksumMetadataKey("x-amz-meta-"), `invalid value for "checksum-metadata-key" flag`)
+}
+
+func TestSetChecksumMetadata(t *testing.T) {
+	t.Parallel()
+
+	userDefined := map[string]string{"Sha256": "stale", "build": "42"}
+	got := setChecksumMetadata(userDefined, "sha256", "digest")
+
+	assert.DeepEqual(t, got, map[string]string{"sha256": "digest", "build": "42"})
+	// the given metadata is not modified.
+	assert.Equal(t, userDefined["Sha256"], "stale")
+
+	digest, ok := checksumMetadata(map[string]string{"Sha256": "ABC"}, "sha256")
+	assert.Assert(t, ok)
+	assert.Equal(t, digest, "abc")
+}
@@ -0,0 +1,88 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/v2/log"
+	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/storage/url"
+)
+
+// The policies of checksum-validation flag. The downloads are verified
+// against the additional checksums of the objects, or against their ETags if
+// they are the MD5 digests of the contents. An object is unverifiable if it
+// has neither, e.g. an object uploaded by parts without an additional
+// checksum.
+const (
+	// checksumValidationStrict fails the downloads of the objects which
+	// don't match their checksums or which can't be verified.
+	checksumValidationStrict = "strict"
+	// checksumValidationWarn prints a warning for the downloads of the
+	// objects which don't match their checksums or which can't be verified,
+	// and keeps the downloaded data.
+	checksumValidationWarn = "warn"
+	// checksumValidationOff doesn't verify the downloads.
+	checksumValidationOff = "off"
+)
+
+// errUnverifiable is the error of the downloads of the objects which can't be
+// verified.
+var errUnverifiable = errors.New("object can not be verified: it has neither an additional checksum nor an MD5 ETag")
+
+// checksumValidation returns the policy of checksum-validation flag, or an
+// empty string if the downloads are not verified by a policy, i.e. the flag
+// is not given or it's off.
+func checksumValidation(c *cli.Context) string {
+	policy := c.String("checksum-validation")
+	if policy == checksumValidationOff {
+		return ""
+	}
+	return policy
+}
+
+// validateChecksumValidation validates checksum-validation flag against the
+// per-command flags of the downloads.
+func validateChecksumValidation(c *cli.Context) error {
+	policy := c.String("checksum-validation")
+	if policy == "" {
+		return nil
+	}
+
+	if policy == checksumValidationOff && c.String("checksum-mode") != "" {
+		return fmt.Errorf(`"checksum-mode" flag cannot be used with "checksum-validation" flag off`)
+	}
+	if policy != checksumValidationOff && c.Bool("continue") {
+		return fmt.Errorf(`"continue" flag cannot be used with "checksum-validation" flag %v`, policy)
+	}
+	return nil
+}
+
+// validateDownloadChecksum verifies the downloaded data against the given
+// checksum of the object from the given source, by the given policy. verify
+// verifies the data against the checksum. A download without a checksum is
+// unverifiable, which is not an error without a policy, e.g. with
+// checksum-mode flag. The errors are printed as warnings rather than returned
+// with the warn policy.
+func validateDownloadChecksum(
+	policy string,
+	src *url.URL,
+	checksum *storage.Checksum,
+	verify func(storage.Checksum) error,
+) error {
+	var err error
+	switch {
+	case checksum != nil:
+		err = verify(*checksum)
+	case policy != "":
+		err = errUnverifiable
+	}
+
+	if err != nil && policy == checksumValidationWarn {
+		fmt.Fprintf(log.Warnings(), "WARNING %v: %v\n", src, err)
+		return nil
+	}
+	return err
+}
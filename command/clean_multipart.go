@@ -0,0 +1,283 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/urfave/cli/v2"
+
+	errorpkg "github.com/peak/s5cmd/v2/error"
+	"github.com/peak/s5cmd/v2/log"
+	"github.com/peak/s5cmd/v2/log/stat"
+	"github.com/peak/s5cmd/v2/parallel"
+	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/storage/url"
+	"github.com/peak/s5cmd/v2/strutil"
+)
+
+const defaultCleanMultipartOlderThan = 24 * time.Hour
+
+var cleanMultipartHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] argument
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Abort all multipart uploads in a bucket which are initiated more than a day ago
+		 > s5cmd {{.HelpName}} s3://bucket/
+
+	2. Abort multipart uploads under a prefix which are initiated more than an hour ago
+		 > s5cmd {{.HelpName}} --older-than 1h s3://bucket/prefix/
+
+	3. Abort multipart uploads of the keys that match a wildcard
+		 > s5cmd {{.HelpName}} "s3://bucket/prefix/*.tar"
+
+	4. Preview the multipart uploads to be aborted and the size of their uploaded parts
+		 > s5cmd --dry-run {{.HelpName}} --humanize s3://bucket/
+`
+
+func NewCleanMultipartCommand() *cli.Command {
+	cmd := &cli.Command{
+		Name:     "clean-multipart",
+		HelpName: "clean-multipart",
+		Usage:    "abort incomplete multipart uploads",
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "older-than",
+				Usage: "abort only the multipart uploads initiated before the given duration",
+				Value: defaultCleanMultipartOlderThan,
+			},
+			&cli.BoolFlag{
+				Name:    "humanize",
+				Aliases: []string{"H"},
+				Usage:   "human-readable output for part sizes",
+			},
+		},
+		CustomHelpTemplate: cleanMultipartHelpTemplate,
+		Before: func(c *cli.Context) error {
+			err := validateCleanMultipartCommand(c)
+			if err != nil {
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			fullCommand := commandFromContext(c)
+
+			srcurl, err := url.New(c.Args().First())
+			if err != nil {
+				printError(c.Context, fullCommand, c.Command.Name, err)
+				return err
+			}
+
+			return CleanMultipart{
+				src:         srcurl,
+				op:          c.Command.Name,
+				fullCommand: fullCommand,
+				// flags
+				olderThan: c.Duration("older-than"),
+				humanize:  c.Bool("humanize"),
+
+				storageOpts: NewStorageOpts(c),
+			}.Run(c.Context)
+		},
+	}
+
+	cmd.BashComplete = getBashCompleteFn(cmd, false, false)
+	return cmd
+}
+
+// CleanMultipart holds multipart upload cleanup operation flags and states.
+type CleanMultipart struct {
+	src         *url.URL
+	op          string
+	fullCommand string
+
+	// flags
+	olderThan time.Duration
+	humanize  bool
+
+	storageOpts storage.Options
+}
+
+// Run aborts the multipart uploads at given source which are initiated
+// before the given duration.
+func (cm CleanMultipart) Run(ctx context.Context) error {
+	client, err := storage.NewRemoteClient(ctx, cm.src, cm.storageOpts)
+	if err != nil {
+		printError(ctx, cm.fullCommand, cm.op, err)
+		return err
+	}
+
+	var (
+		merrorWaiter  error
+		merrorUploads error
+
+		mu    sync.Mutex
+		total sizeAndCount
+	)
+
+	waiter := parallel.NewWaiter()
+	errDoneCh := make(chan struct{})
+	go func() {
+		defer close(errDoneCh)
+		for err := range waiter.Err() {
+			printError(ctx, cm.fullCommand, cm.op, err)
+			merrorWaiter = multierror.Append(merrorWaiter, err)
+		}
+	}()
+
+	deadline := time.Now().Add(-cm.olderThan)
+	for upload := range client.ListMultipartUploads(ctx, cm.src) {
+		if errorpkg.IsCancelation(upload.Err) {
+			continue
+		}
+
+		if err := upload.Err; err != nil {
+			merrorUploads = multierror.Append(merrorUploads, err)
+			printError(ctx, cm.fullCommand, cm.op, err)
+			continue
+		}
+
+		if !upload.Initiated.Before(deadline) {
+			continue
+		}
+
+		upload := upload
+		task := func() error {
+			size, err := client.MultipartUploadSize(ctx, upload)
+			if err != nil {
+				return &errorpkg.Error{
+					Op:  cm.op,
+					Src: upload.URL,
+					Err: err,
+				}
+			}
+
+			if err := client.AbortMultipartUpload(ctx, upload); err != nil {
+				return &errorpkg.Error{
+					Op:  cm.op,
+					Src: upload.URL,
+					Err: err,
+				}
+			}
+
+			mu.Lock()
+			total.size += size
+			total.count++
+			mu.Unlock()
+
+			msg := CleanMultipartMessage{
+				Operation:     cm.op,
+				Source:        upload.URL,
+				UploadID:      upload.UploadID,
+				Initiated:     upload.Initiated,
+				Size:          size,
+				showHumanized: cm.humanize,
+			}
+			log.InfoContext(ctx, msg)
+			return nil
+		}
+		parallel.Run(task, waiter)
+	}
+
+	waiter.Wait()
+	<-errDoneCh
+
+	msg := CleanMultipartSummaryMessage{
+		Source:        cm.src.String(),
+		Count:         total.count,
+		Size:          total.size,
+		showHumanized: cm.humanize,
+	}
+	log.InfoContext(ctx, msg)
+
+	return multierror.Append(merrorWaiter, merrorUploads).ErrorOrNil()
+}
+
+// CleanMultipartMessage is a structure for logging aborted multipart uploads.
+type CleanMultipartMessage struct {
+	Operation string    `json:"operation"`
+	Success   bool      `json:"success"`
+	Source    *url.URL  `json:"source"`
+	UploadID  string    `json:"upload_id"`
+	Initiated time.Time `json:"initiated"`
+	Size      int64     `json:"size"`
+
+	showHumanized bool
+}
+
+// String returns the string representation of CleanMultipartMessage.
+func (m CleanMultipartMessage) String() string {
+	size := fmt.Sprintf("%d", m.Size)
+	if m.showHumanized {
+		size = strutil.HumanizeBytes(m.Size)
+	}
+	return fmt.Sprintf("%v %v %v %v %v",
+		m.Operation,
+		m.Initiated.Format(dateFormat),
+		size,
+		m.Source,
+		m.UploadID,
+	)
+}
+
+// JSON returns the JSON representation of CleanMultipartMessage.
+func (m CleanMultipartMessage) JSON() string {
+	m.Success = true
+	return strutil.JSON(m)
+}
+
+// CleanMultipartSummaryMessage is a structure for logging the number of
+// aborted multipart uploads and the total size of their uploaded parts.
+type CleanMultipartSummaryMessage struct {
+	Source string `json:"source"`
+	Count  int64  `json:"count"`
+	Size   int64  `json:"size"`
+
+	showHumanized bool
+}
+
+// String returns the string representation of CleanMultipartSummaryMessage.
+func (m CleanMultipartSummaryMessage) String() string {
+	size := fmt.Sprintf("%d", m.Size)
+	if m.showHumanized {
+		size = strutil.HumanizeBytes(m.Size)
+	}
+	return fmt.Sprintf("%s bytes in %d multipart uploads: %s", size, m.Count, m.Source)
+}
+
+// JSON returns the JSON representation of CleanMultipartSummaryMessage.
+func (m CleanMultipartSummaryMessage) JSON() string {
+	return strutil.JSON(m)
+}
+
+func validateCleanMultipartCommand(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("expected only 1 argument")
+	}
+
+	srcurl, err := url.New(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	if !srcurl.IsRemote() {
+		return fmt.Errorf("source must be remote")
+	}
+
+	if c.Duration("older-than") < 0 {
+		return fmt.Errorf("older-than flag must be a non-negative duration")
+	}
+
+	return nil
+}
@@ -62,6 +62,8 @@ func contextValue(c *cli.Context, flagname string) []string {
 }
 
 // generateCommand generates command string from given context, app command, default flags and urls.
+// The default flags override the flags of the context, and the ones with nil
+// values are not passed to the generated command at all.
 func generateCommand(c *cli.Context, cmd string, defaultFlags map[string]interface{}, urls ...*url.URL) (string, error) {
 	command := AppCommand(cmd)
 	flagset := flag.NewFlagSet(command.Name, flag.ContinueOnError)
@@ -73,6 +75,9 @@ func generateCommand(c *cli.Context, cmd string, defaultFlags map[string]interfa
 
 	flags := []string{}
 	for flagname, flagvalue := range defaultFlags {
+		if flagvalue == nil {
+			continue
+		}
 		flags = append(flags, fmt.Sprintf("--%s='%v'", flagname, flagvalue))
 	}
 
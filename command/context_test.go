@@ -100,7 +100,7 @@ func TestGenerateCommand(t *testing.T) {
 					Value: true,
 				},
 				&cli.IntFlag{
-					Name:  "concurrency",
+					Name:  "part-concurrency",
 					Value: 6,
 				},
 				// delete is not shared flag, will be ignored
@@ -118,7 +118,7 @@ func TestGenerateCommand(t *testing.T) {
 				mustNewURL(t, "s3://bucket/key1"),
 				mustNewURL(t, "s3://bucket/key2"),
 			},
-			expectedCommand: `cp --concurrency='6' --flatten='true' --force-glacier-transfer='true' --raw='true' "s3://bucket/key1" "s3://bucket/key2"`,
+			expectedCommand: `cp --flatten='true' --force-glacier-transfer='true' --part-concurrency='6' --raw='true' "s3://bucket/key1" "s3://bucket/key2"`,
 		},
 		{
 			name: "string-slice-flag",
@@ -135,6 +135,25 @@ func TestGenerateCommand(t *testing.T) {
 			},
 			expectedCommand: `cp --exclude='*.log' --exclude='*.txt' "/source/dir" "s3://bucket/prefix/"`,
 		},
+		{
+			name: "nil-default-flag-is-not-passed",
+			cmd:  "cp",
+			flags: []cli.Flag{
+				&cli.StringSliceFlag{
+					Name:  "exclude",
+					Value: cli.NewStringSlice("*.txt"),
+				},
+			},
+			defaultFlags: map[string]interface{}{
+				"raw":     true,
+				"exclude": nil,
+			},
+			urls: []*url.URL{
+				mustNewURL(t, "/source/dir/file.log"),
+				mustNewURL(t, "s3://bucket/prefix/"),
+			},
+			expectedCommand: `cp --raw='true' "/source/dir/file.log" "s3://bucket/prefix/"`,
+		},
 		{
 			name:  "command-with-multiple-args",
 			cmd:   "rm",
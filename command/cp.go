@@ -10,8 +10,12 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/urfave/cli/v2"
@@ -23,13 +27,31 @@ import (
 	"github.com/peak/s5cmd/v2/progressbar"
 	"github.com/peak/s5cmd/v2/storage"
 	"github.com/peak/s5cmd/v2/storage/url"
+	"github.com/peak/s5cmd/v2/strutil"
 )
 
 const (
 	defaultCopyConcurrency = 5
 	defaultPartSize        = 50 // MiB
+	partSizeAuto           = "auto"
 	megabytes              = 1024 * 1024
 	kilobytes              = 1024
+
+	// maxTotalConcurrency is the number of parts in flight above which a
+	// warning is printed.
+	maxTotalConcurrency = 10000
+
+	// maxSinglePartUploadSize is the maximum size of an object uploaded with
+	// a single request.
+	maxSinglePartUploadSize = 5 * 1024 * megabytes
+
+	// minUploadPartSize is the minimum size of the parts of a multipart
+	// upload, except the last one.
+	minUploadPartSize = 5 * megabytes
+
+	// maxUploadParts is the maximum number of the parts of a multipart
+	// upload.
+	maxUploadParts = 10000
 )
 
 const (
@@ -37,11 +59,40 @@ const (
 	metadataDirectiveReplace = "REPLACE"
 )
 
+const (
+	onConflictError     = "error"
+	onConflictSkip      = "skip"
+	onConflictOverwrite = "overwrite"
+	onConflictRename    = "rename"
+)
+
+// defaultRenameSuffix is the suffix of the renamed destinations with the
+// rename conflict policy, which is added before the extension of the
+// destination. {n} is replaced with the number of the rename.
+const defaultRenameSuffix = "-{n}"
+
+// progressJSONInterval is the interval of the progress events of
+// progress-json flag.
+const progressJSONInterval = time.Second
+
+const checksumModeEnabled = "enabled"
+
+const (
+	checksumTypeComposite  = "composite"
+	checksumTypeFullObject = "full_object"
+)
+
+const (
+	onErrorSkip  = "skip"
+	onErrorStop  = "stop"
+	onErrorRetry = "retry"
+)
+
 var copyHelpTemplate = `Name:
 	{{.HelpName}} - {{.Usage}}
 
 Usage:
-	{{.HelpName}} [options] source destination
+	{{.HelpName}} [options] source destination [destination ...]
 
 Options:
 	{{range .VisibleFlags}}{{.}}
@@ -118,6 +169,123 @@ Examples:
 
 	24. Pass arbitrary metadata to the object during upload or copy
 		 > s5cmd {{.HelpName}} --metadata "camera=Nixon D750" --metadata "imageSize=6032x4032" flowers.png s3://bucket/prefix/flowers.png
+
+	25. Copy all files from S3 bucket to another S3 bucket without listing the objects under the "logs/" prefix
+		 > s5cmd {{.HelpName}} --exclude-prefix "logs/" "s3://bucket/*" s3://destbucket
+
+	26. Upload a large file and continue from the uploaded parts if a previous attempt was interrupted
+		 > s5cmd {{.HelpName}} --resume --part-size 100 bigfile.tar s3://bucket/
+
+	27. Upload files with the content type and metadata of their destination keys from a JSON file, e.g. {"prefix/*.html": {"ContentType": "text/html", "Metadata": {"team": "web"}}}
+		 > s5cmd {{.HelpName}} --metadata-from-json metadata.json "dir/*" s3://bucket/prefix/
+
+	28. Upload a file to a time-partitioned prefix of the current date
+		 > s5cmd {{.HelpName}} --dereference-dates file.log "s3://bucket/logs/{YYYY}/{MM}/{DD}/"
+
+	29. Upload files to time-partitioned prefixes of their modification dates
+		 > s5cmd {{.HelpName}} --dereference-dates --dates-from-mtime "logs/*" "s3://bucket/logs/{YYYY}/{MM}/{DD}/"
+
+	30. Download all objects under a prefix of a huge bucket using its S3 Inventory report instead of listing it
+		 > s5cmd {{.HelpName}} --source-inventory s3://inventory-bucket/bucket/config/2023-01-01T00-00Z/manifest.json "s3://bucket/prefix/*" dir/
+
+	31. Upload files of various sizes with part sizes computed from the size of each file
+		 > s5cmd {{.HelpName}} --part-size auto "dir/*" s3://bucket/prefix/
+
+	32. Upload a file only if the object doesn't exist, and skip it if another writer created it
+		 > s5cmd {{.HelpName}} --if-none-match '*' --on-conflict skip lock.json s3://bucket/lock.json
+
+	33. Overwrite an object only if it's not modified since its ETag is read
+		 > s5cmd {{.HelpName}} --if-match 5d41402abc4b2a76b9719d911017c592 state.json s3://bucket/state.json
+
+	34. Download an object only if it's modified since the given time
+		 > s5cmd {{.HelpName}} --if-modified-since 2024-10-01T20:30:00Z s3://bucket/config.json .
+
+	35. Download all objects under a prefix except the ones larger than 10GB
+		 > s5cmd {{.HelpName}} --max-object-size 10GB "s3://bucket/prefix/*" dir/
+
+	36. Upload at most 10 files at a time with 20 parts in flight for each file, 200 parts in total
+		 > s5cmd --numworkers 10 {{.HelpName}} --part-concurrency 20 "dir/*" s3://bucket/prefix/
+
+	37. Download an object and verify it against the additional checksum of the object
+		 > s5cmd {{.HelpName}} --checksum-mode enabled s3://bucket/object.gz .
+
+	38. Upload all files in a directory and stop at the first failed file
+		 > s5cmd {{.HelpName}} --on-error stop "dir/*" s3://bucket/prefix/
+
+	39. Change the content type of an object while keeping its other metadata
+		 > s5cmd {{.HelpName}} --keep-metadata --content-type "text/html" s3://bucket/object s3://bucket/object
+
+	40. Upload all files in a directory, keeping the symbolic links as objects instead of following them
+		 > s5cmd {{.HelpName}} --symlink-as-object "dir/*" s3://bucket/prefix/
+
+	41. Download an object, continuing the download from where it was interrupted if it is run again
+		 > s5cmd {{.HelpName}} --continue s3://bucket/object.gz .
+
+	42. Upload a file, keeping the user metadata of the overwritten object which is not set by the upload
+		 > s5cmd {{.HelpName}} --metadata-merge --metadata "build=42" object.gz s3://bucket/
+
+	43. Upload all files in a directory, appending the key, size, ETag and checksum of each uploaded object to a manifest
+		 > s5cmd {{.HelpName}} --write-checksum-manifest transfers.csv "dir/*" s3://bucket/prefix/
+
+	44. Copy all files from a public S3 bucket to a private S3 bucket, sending the requests to the public bucket anonymously
+		 > s5cmd {{.HelpName}} --source-no-sign-request "s3://public-bucket/*" s3://bucket/prefix/
+
+	45. Upload all files in a directory, skipping the files whose content matches the existing objects
+		 > s5cmd {{.HelpName}} --skip-if-etag-matches "dir/*" s3://bucket/prefix/
+
+	46. Copy all objects to another bucket, keeping their storage classes
+		 > s5cmd {{.HelpName}} --preserve-storage-class "s3://bucket/*" s3://target-bucket/
+
+	47. Upload all files in a directory, uploading zero-byte directory marker objects, e.g. "empty/", for the empty directories
+		 > s5cmd {{.HelpName}} --create-dir-markers "dir/*" s3://bucket/prefix/
+
+	48. Upload all files in a directory and print the request latencies and a suggested number of workers at the end
+		 > s5cmd {{.HelpName}} --tune-report "dir/*" s3://bucket/prefix/
+
+	49. Upload a large file retrying each failed part up to 20 times, while the other requests are retried by the global retry count
+		 > s5cmd {{.HelpName}} --part-retry 20 large.bin s3://bucket/prefix/
+
+	50. Copy only the objects which are created or modified since the previous run
+		 > s5cmd {{.HelpName}} --since-manifest nightly.json "s3://bucket/logs/*" s3://backup/logs/
+
+	51. Flatten the files of all directories to a prefix, renaming the files with the same name, e.g. report-1.csv, instead of overwriting them
+		 > s5cmd {{.HelpName}} --flatten --on-conflict rename "dir/*" s3://bucket/reports/
+
+	52. Upload a file to multiple buckets concurrently
+		 > s5cmd {{.HelpName}} backup.tar.gz s3://bucket/backups/ s3://dr-bucket/backups/
+
+	53. Upload the files of a directory except the empty ones, e.g. placeholder files
+		 > s5cmd {{.HelpName}} --exclude-empty "dir/*" s3://bucket/prefix/
+
+	54. Upload the files smaller than 200MB with a single request instead of a multipart upload
+		 > s5cmd {{.HelpName}} --multipart-threshold 200MB "dir/*" s3://bucket/prefix/
+
+	55. Upload a large file with a full object CRC32C checksum, which can be verified against the checksum of the whole file
+		 > s5cmd {{.HelpName}} --checksum-algorithm CRC32C --checksum-type full_object file.iso s3://bucket/
+
+	56. Upload a file to S3 to be downloaded by the browsers with a file name, with a content-language header
+		 > s5cmd {{.HelpName}} --content-disposition 'attachment; filename="report.pdf"' --content-language en-US report-2024.pdf s3://bucket/
+
+	57. Upload all files in a directory, storing the SHA256 digest of each file in the "sha256" user metadata of its object
+		 > s5cmd {{.HelpName}} --checksum-metadata-key x-amz-meta-sha256 "dir/*" s3://bucket/prefix/
+
+	58. Upload all files in a directory to a prefix watched by a poller, which never sees a partially uploaded object
+		 > s5cmd {{.HelpName}} --upload-via-temp "dir/*" s3://bucket/incoming/
+
+	59. Copy all objects to a bucket of another account, reading with the credentials of "prod" profile and writing with the credentials of "backup" profile
+		 > s5cmd {{.HelpName}} --source-profile prod --dest-profile backup "s3://bucket/*" s3://backup-bucket/prefix/
+
+	60. Copy all objects to another prefix, into an "archive" directory under the parent path of each object, e.g. "logs/2020/app.log" to "logs-archive/2020/archive/app.log"
+		 > s5cmd {{.HelpName}} --dest-template "{dir}/archive/{base}" "s3://bucket/logs/*" s3://bucket/logs-archive/
+
+	61. Download all objects and fail the ones which don't match, or can't be verified against, their additional checksums or MD5 ETags
+		 > s5cmd --checksum-validation strict {{.HelpName}} "s3://bucket/dataset/*" dataset/
+
+	62. Upload a file to S3, granting read access to another account and full control to a user by email
+		 > s5cmd {{.HelpName}} --grant-read id=111122223333 --grant-full-control emailAddress=user@example.com report.pdf s3://bucket/
+
+	63. Upload all files in a directory, writing the progress of each file and the totals as JSON events to stderr
+		 > s5cmd {{.HelpName}} --progress-json "dir/*" s3://bucket/prefix/ 2> progress.jsonl
 `
 
 func NewSharedFlags() []cli.Flag {
@@ -131,16 +299,16 @@ func NewSharedFlags() []cli.Flag {
 			Usage: "set storage class for target ('STANDARD','REDUCED_REDUNDANCY','GLACIER','STANDARD_IA','ONEZONE_IA','INTELLIGENT_TIERING','DEEP_ARCHIVE')",
 		},
 		&cli.IntFlag{
-			Name:    "concurrency",
-			Aliases: []string{"c"},
+			Name:    "part-concurrency",
+			Aliases: []string{"concurrency", "c"},
 			Value:   defaultCopyConcurrency,
-			Usage:   "number of concurrent parts transferred between host and remote server",
+			Usage:   "number of concurrent parts transferred between host and remote server for each file; the default depends on the storage backend",
 		},
-		&cli.IntFlag{
+		&cli.StringFlag{
 			Name:    "part-size",
 			Aliases: []string{"p"},
-			Value:   defaultPartSize,
-			Usage:   "size of each part transferred between host and remote server, in MiB",
+			Value:   strconv.Itoa(defaultPartSize),
+			Usage:   "size of each part transferred between host and remote server, in MiB, or 'auto' to compute it from the size of each object; the default depends on the storage backend",
 		},
 		&MapFlag{
 			Name:  "metadata",
@@ -169,6 +337,22 @@ func NewSharedFlags() []cli.Flag {
 			Name:  "acl",
 			Usage: "set acl for target: defines granted accesses and their types on different accounts/groups, e.g. cp --acl 'public-read'",
 		},
+		&cli.StringFlag{
+			Name:  "grant-read",
+			Usage: "grant read access to the object and its metadata to the comma-separated grantees, e.g. cp --grant-read 'id=111122223333,uri=http://acs.amazonaws.com/groups/global/AllUsers'",
+		},
+		&cli.StringFlag{
+			Name:  "grant-read-acp",
+			Usage: "grant read access to the object acl to the comma-separated grantees, e.g. cp --grant-read-acp 'emailAddress=user@example.com'",
+		},
+		&cli.StringFlag{
+			Name:  "grant-write-acp",
+			Usage: "grant write access to the object acl to the comma-separated grantees, e.g. cp --grant-write-acp 'id=111122223333'",
+		},
+		&cli.StringFlag{
+			Name:  "grant-full-control",
+			Usage: "grant read, read acl and write acl access to the object to the comma-separated grantees, e.g. cp --grant-full-control 'id=111122223333'",
+		},
 		&cli.StringFlag{
 			Name:  "cache-control",
 			Usage: "set cache control for target: defines cache control header for object, e.g. cp --cache-control 'public, max-age=345600'",
@@ -193,6 +377,24 @@ func NewSharedFlags() []cli.Flag {
 			Name:  "destination-region",
 			Usage: "set the region of destination bucket: the region of the destination bucket will be automatically discovered if --destination-region is not specified",
 		},
+		&cli.BoolFlag{
+			Name:  "source-no-sign-request",
+			Usage: "do not sign the requests to the source, e.g. to read from a public bucket while writing to a private one",
+		},
+		&cli.BoolFlag{
+			Name:  "dest-no-sign-request",
+			Usage: "do not sign the requests to the destination",
+		},
+		&cli.StringFlag{
+			Name:    "source-profile",
+			Usage:   "use the specified profile from the credentials file for the requests to the source",
+			EnvVars: []string{"S5CMD_SOURCE_PROFILE"},
+		},
+		&cli.StringFlag{
+			Name:    "dest-profile",
+			Usage:   "use the specified profile from the credentials file for the requests to the destination",
+			EnvVars: []string{"S5CMD_DEST_PROFILE"},
+		},
 		&cli.StringSliceFlag{
 			Name:  "exclude",
 			Usage: "exclude objects with given pattern",
@@ -201,6 +403,14 @@ func NewSharedFlags() []cli.Flag {
 			Name:  "include",
 			Usage: "include objects with given pattern",
 		},
+		&cli.StringSliceFlag{
+			Name:  "exclude-prefix",
+			Usage: "do not descend into the prefixes, relative to the source, while listing",
+		},
+		&cli.BoolFlag{
+			Name:  "exclude-empty",
+			Usage: "do not copy the zero-byte files and objects, e.g. placeholders",
+		},
 		&cli.BoolFlag{
 			Name:  "raw",
 			Usage: "disable the wildcard operations, useful with filenames that contains glob characters",
@@ -217,6 +427,48 @@ func NewSharedFlags() []cli.Flag {
 			Name:  "content-disposition",
 			Usage: "set content disposition for target: defines content disposition header for object, e.g. --content-disposition 'attachment; filename=\"filename.jpg\"'",
 		},
+		&cli.StringFlag{
+			Name:  "content-language",
+			Usage: "set content language for target: defines content language header for object, e.g. --content-language en-US",
+		},
+		&cli.StringFlag{
+			Name:  "metadata-from-json",
+			Usage: "set content type and metadata of uploaded objects from a JSON file mapping destination keys or wildcards to metadata; the most specific match is used and the metadata flags take precedence over it",
+		},
+		&cli.StringFlag{
+			Name:  "write-checksum-manifest",
+			Usage: "append the key, size, ETag and checksum of each transferred object to the given file as the objects complete, at the cost of an extra HEAD request per object",
+		},
+		&cli.GenericFlag{
+			Name:  "checksum-manifest-format",
+			Usage: "format of the checksum manifest: (csv, json)",
+			Value: &EnumValue{
+				Enum:    []string{checksumManifestCSV, checksumManifestJSON},
+				Default: checksumManifestCSV,
+			},
+		},
+		&cli.BoolFlag{
+			Name:  "metadata-merge",
+			Usage: "keep the user metadata of the existing destination objects which is not set by the upload, at the cost of an extra HEAD request per uploaded object",
+		},
+		&cli.StringFlag{
+			Name:  "checksum-metadata-key",
+			Usage: "store the hex encoded SHA256 digest of the uploaded files in the given user metadata key, e.g. x-amz-meta-sha256, which is trusted by sync to compare the objects of the same size instead of their modification times",
+		},
+		&cli.BoolFlag{
+			Name:  "upload-via-temp",
+			Usage: "upload the files to a temporary object with .part suffix, and rename it to the destination with a server-side copy only when the upload completes, so that the partial objects are never visible at the destination; the copy is charged as an extra request, and the files larger than 5GiB can't be renamed",
+		},
+		newOnErrorFlag(),
+		&cli.BoolFlag{
+			Name:  "tune-report",
+			Usage: "print the latency distribution, the effective concurrency and the throttled requests at the end of the run, with a suggested number of workers",
+		},
+		&cli.IntFlag{
+			Name:        "part-retry",
+			Usage:       "number of times that each part of a multipart transfer is retried before the transfer fails",
+			DefaultText: "retry-count",
+		},
 		&cli.IntFlag{
 			Name:        "no-such-upload-retry-count",
 			Usage:       "number of times that a request will be retried on NoSuchUpload error; you should not use this unless you really know what you're doing",
@@ -226,6 +478,18 @@ func NewSharedFlags() []cli.Flag {
 	}
 }
 
+// newOnErrorFlag returns the flag of the action when an object fails.
+func newOnErrorFlag() cli.Flag {
+	return &cli.GenericFlag{
+		Name:  "on-error",
+		Usage: "action when an object fails: skip it without retrying, stop at the first failure, or retry it and skip it if it still fails: (skip, stop, retry)",
+		Value: &EnumValue{
+			Enum:    []string{onErrorSkip, onErrorStop, onErrorRetry},
+			Default: onErrorRetry,
+		},
+	}
+}
+
 func NewCopyCommandFlags() []cli.Flag {
 	copyFlags := []cli.Flag{
 		&cli.BoolFlag{
@@ -233,6 +497,10 @@ func NewCopyCommandFlags() []cli.Flag {
 			Aliases: []string{"f"},
 			Usage:   "flatten directory structure of source, starting from the first wildcard",
 		},
+		&cli.StringFlag{
+			Name:  "dest-template",
+			Usage: "name the destination objects by the given template of {base}, the name of the source object, and {dir}, its parent path relative to the source, e.g. 'archive/{dir}/{base}'",
+		},
 		&cli.BoolFlag{
 			Name:    "no-clobber",
 			Aliases: []string{"n"},
@@ -248,6 +516,10 @@ func NewCopyCommandFlags() []cli.Flag {
 			Aliases: []string{"u"},
 			Usage:   "only overwrite destination if source modtime is newer",
 		},
+		&cli.BoolFlag{
+			Name:  "skip-if-etag-matches",
+			Usage: "do not overwrite destination if its ETag matches the ETag of source, or their sizes match if either is uploaded in multiple parts",
+		},
 		&cli.StringFlag{
 			Name:  "version-id",
 			Usage: "use the specified version of an object",
@@ -257,6 +529,114 @@ func NewCopyCommandFlags() []cli.Flag {
 			Aliases: []string{"sp"},
 			Usage:   "show a progress bar",
 		},
+		&cli.BoolFlag{
+			Name:  "progress-json",
+			Usage: "write the progress of each file and the totals as JSON events to stderr periodically, instead of showing a progress bar",
+		},
+		&cli.BoolFlag{
+			Name:  "resume",
+			Usage: "resume the multipart uploads interrupted with the same flags by uploading only the missing parts, and keep the uploaded parts on failure",
+		},
+		&cli.BoolFlag{
+			Name:    "continue",
+			Aliases: []string{"append-to-local"},
+			Usage:   "continue interrupted downloads by downloading only the rest of the objects, and keep the downloaded part on failure",
+		},
+		&cli.StringFlag{
+			Name:  "source-inventory",
+			Usage: "read the source objects from the CSV or Parquet S3 Inventory report of the given manifest instead of listing them, e.g. s3://bucket/inventory/manifest.json",
+		},
+		&cli.StringFlag{
+			Name:  "since-manifest",
+			Usage: "copy only the objects modified at or after the latest modification time recorded in the given file by the previous run, and record the latest modification time of the copied objects when the copy succeeds",
+		},
+		&cli.BoolFlag{
+			Name:  "dereference-dates",
+			Usage: "expand {YYYY}, {MM}, {DD}, {HH} and {ts} tokens in the destination with the UTC time the command started; use '{{' and '}}' for literal braces",
+		},
+		&cli.BoolFlag{
+			Name:  "dates-from-mtime",
+			Usage: "use the modification time of each source object instead of the start time to expand the date tokens of --dereference-dates",
+		},
+		&cli.StringFlag{
+			Name:  "if-match",
+			Usage: "only overwrite the uploaded object if its ETag matches the given ETag",
+		},
+		&cli.StringFlag{
+			Name:  "if-none-match",
+			Usage: "only upload the object if it doesn't exist, atomically, when the value is '*'; or only download the object if its ETag doesn't match the given ETag",
+		},
+		&cli.StringFlag{
+			Name:  "if-modified-since",
+			Usage: "only download the object if it's modified since the given time (uses RFC3339 format), e.g. --if-modified-since '2024-10-01T20:30:00Z'",
+		},
+		&cli.StringFlag{
+			Name:  "max-object-size",
+			Usage: "do not transfer the objects larger than the given size, e.g. --max-object-size 10GB",
+		},
+		&cli.StringFlag{
+			Name:        "multipart-threshold",
+			Usage:       "upload the files smaller than the given size with a single request instead of a multipart upload, e.g. --multipart-threshold 100MB",
+			DefaultText: "part size",
+		},
+		&cli.GenericFlag{
+			Name:  "checksum-algorithm",
+			Usage: "compute the checksum of the uploaded files while they are uploaded, to be verified and stored by the remote storage: (CRC32C, CRC32, SHA256, SHA1)",
+			Value: &EnumValue{
+				Enum:              []string{"CRC32C", "CRC32", "SHA256", "SHA1", ""},
+				Default:           "",
+				ConditionFunction: strings.EqualFold,
+			},
+		},
+		&cli.GenericFlag{
+			Name:  "checksum-type",
+			Usage: "type of the checksum of --checksum-algorithm flag for the multipart uploads: the checksum of the checksums of the parts, or the checksum of the whole file which can be compared with the checksum of a single request upload or a download, only for CRC32C and CRC32: (composite, full_object)",
+			Value: &EnumValue{
+				Enum:              []string{checksumTypeComposite, checksumTypeFullObject},
+				Default:           checksumTypeComposite,
+				ConditionFunction: strings.EqualFold,
+			},
+		},
+		&cli.GenericFlag{
+			Name:  "on-conflict",
+			Usage: "action when the condition of --if-match or --if-none-match doesn't hold, or when the destination exists: fail if the condition doesn't hold and overwrite the existing destination, skip the object, overwrite the destination, or rename the destination with a numeric suffix: (error, skip, overwrite, rename)",
+			Value: &EnumValue{
+				Enum:    []string{onConflictError, onConflictSkip, onConflictOverwrite, onConflictRename},
+				Default: onConflictError,
+			},
+		},
+		&cli.StringFlag{
+			Name:  "rename-suffix",
+			Value: defaultRenameSuffix,
+			Usage: "suffix of the destinations renamed by --on-conflict rename, which is added before the extension, e.g. name-1.txt; {n} is replaced with the number of the rename",
+		},
+		&cli.BoolFlag{
+			Name:  "keep-metadata",
+			Usage: "keep the metadata of the source objects which are not set by the metadata flags when copying objects between remote storages",
+		},
+		&cli.BoolFlag{
+			Name:  "create-dir-markers",
+			Usage: "upload zero-byte directory marker objects, e.g. dir/, for the empty directories",
+		},
+		&cli.BoolFlag{
+			Name:  "preserve-storage-class",
+			Usage: "set the storage class of the source objects on the destination objects when copying objects between remote storages, unless storage-class flag is given",
+		},
+		&cli.BoolFlag{
+			Name:  "symlink-as-object",
+			Usage: "upload symbolic links as zero-byte objects which keep the link target in their metadata, and recreate the symbolic links when downloading such objects",
+		},
+		&cli.GenericFlag{
+			Name:  "checksum-mode",
+			Usage: "verify the downloaded objects against their additional checksums (CRC32, CRC32C, SHA1 or SHA256), if they have one: (enabled)",
+			Value: &EnumValue{
+				Enum:    []string{checksumModeEnabled, ""},
+				Default: "",
+				ConditionFunction: func(str, target string) bool {
+					return strings.EqualFold(target, str)
+				},
+			},
+		},
 	}
 	sharedFlags := NewSharedFlags()
 	return append(copyFlags, sharedFlags...)
@@ -272,12 +652,17 @@ func NewCopyCommand() *cli.Command {
 		Before: func(c *cli.Context) error {
 			err := validateCopyCommand(c)
 			if err != nil {
-				printError(commandFromContext(c), c.Command.Name, err)
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
 			}
 			return err
 		},
 		Action: func(c *cli.Context) (err error) {
 			defer stat.Collect(c.Command.FullName(), &err)()
+			defer tuneReport(c)()
+
+			if c.Args().Len() > 2 {
+				return copyToDestinations(c, c.Args().Slice()[1:])
+			}
 
 			// don't delete source
 			copy, err := NewCopy(c, false)
@@ -292,10 +677,46 @@ func NewCopyCommand() *cli.Command {
 	return cmd
 }
 
+// copyToDestinations copies the source to each of the given destinations
+// concurrently. The source is read again for each destination, so that large
+// sources are streamed rather than buffered in memory.
+func copyToDestinations(c *cli.Context, dsts []string) error {
+	copies := make([]*Copy, 0, len(dsts))
+	for _, dst := range dsts {
+		copy, err := newCopy(c, dst, false)
+		if err != nil {
+			return err
+		}
+		copies = append(copies, copy)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		merror error
+	)
+	for _, copy := range copies {
+		copy := copy
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := copy.Run(c.Context); err != nil {
+				mu.Lock()
+				merror = multierror.Append(merror, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return merror
+}
+
 // Copy holds copy operation flags and states.
 type Copy struct {
 	src         *url.URL
 	dst         *url.URL
+	dstTemplate string
 	op          string
 	fullCommand string
 
@@ -305,109 +726,307 @@ type Copy struct {
 	noClobber             bool
 	ifSizeDiffer          bool
 	ifSourceNewer         bool
+	skipIfEtagMatches     bool
 	flatten               bool
+	destKeyTemplate       string
 	followSymlinks        bool
 	storageClass          storage.StorageClass
 	encryptionMethod      string
 	encryptionKeyID       string
 	acl                   string
+	grants                storage.Grants
 	forceGlacierTransfer  bool
 	ignoreGlacierWarnings bool
 	exclude               []string
 	include               []string
+	excludeEmpty          bool
 	cacheControl          string
 	expires               string
 	contentType           string
 	contentEncoding       string
 	contentDisposition    string
+	contentLanguage       string
 	metadata              map[string]string
 	metadataDirective     string
+	metadataMapping       metadataMapping
+	sourceInventory       string
 	showProgress          bool
 	progressbar           progressbar.ProgressBar
+	resume                bool
+	ifMatch               string
+	ifNoneMatch           string
+	ifModifiedSince       *time.Time
+	onConflict            string
+	renameSuffix          string
+	maxObjectSize         int64
+	checksumMode          bool
+	checksumValidation    string
+	keepMetadata          bool
+	preserveStorageClass  bool
+	createDirMarkers      bool
+	metadataMerge         bool
+	uploadViaTemp         bool
+	symlinkAsObject       bool
+	continueDownload      bool
+	onError               string
+	verifyBeforeDelete    bool
+	verifyChecksum        bool
+	checksumManifest      *checksumManifest
+	sinceManifest         *sinceManifest
 
 	// patterns
 	excludePatterns []*regexp.Regexp
 	includePatterns []*regexp.Regexp
 
+	// dstObjects holds the objects under the destination prefix, keyed by
+	// their keys, if the destination is listed for the existence checks.
+	dstObjects map[string]*storage.Object
+
+	// skipped is the number of the objects which are not copied since they
+	// match the destination, with skip-if-etag-matches flag.
+	skipped *atomic.Int64
+
 	// region settings
 	srcRegion string
 	dstRegion string
 
+	srcNoSignRequest bool
+	dstNoSignRequest bool
+	srcProfile       string
+	dstProfile       string
+
 	// s3 options
-	concurrency int
-	partSize    int64
-	storageOpts storage.Options
+	concurrency        int
+	partSize           int64
+	autoPartSize       bool
+	fixedPartSize      bool
+	multipartThreshold int64
+	checksumAlgorithm  string
+	checksumType       string
+	// checksumMetadataKey is the user metadata key of the SHA256 digests
+	// of the uploaded files, if any.
+	checksumMetadataKey string
+	storageOpts         storage.Options
 }
 
 // NewCopy creates Copy from cli.Context.
 func NewCopy(c *cli.Context, deleteSource bool) (*Copy, error) {
+	return newCopy(c, c.Args().Get(1), deleteSource)
+}
+
+// newCopy creates Copy from cli.Context for the given destination argument.
+func newCopy(c *cli.Context, dstArg string, deleteSource bool) (*Copy, error) {
 	fullCommand := commandFromContext(c)
 
+	excludeDirs, err := createDirRegexFromWildcard(c.StringSlice("exclude"))
+	if err != nil {
+		printError(c.Context, fullCommand, c.Command.Name, err)
+		return nil, err
+	}
+
 	src, err := url.New(c.Args().Get(0), url.WithVersion(c.String("version-id")),
-		url.WithRaw(c.Bool("raw")))
+		url.WithRaw(c.Bool("raw")), url.WithExcludePrefixes(c.StringSlice("exclude-prefix")),
+		url.WithExcludeDirs(excludeDirs))
 	if err != nil {
-		printError(fullCommand, c.Command.Name, err)
+		printError(c.Context, fullCommand, c.Command.Name, err)
 		return nil, err
 	}
 
-	dst, err := url.New(c.Args().Get(1), url.WithRaw(c.Bool("raw")))
+	var dstTemplate string
+	if c.Bool("dereference-dates") {
+		if c.Bool("dates-from-mtime") {
+			dstTemplate = dstArg
+		}
+
+		dstArg, err = expandDateTemplate(dstArg, time.Now())
+		if err != nil {
+			printError(c.Context, fullCommand, c.Command.Name, err)
+			return nil, err
+		}
+	}
+
+	dst, err := url.New(dstArg, url.WithRaw(c.Bool("raw")))
 	if err != nil {
-		printError(fullCommand, c.Command.Name, err)
+		printError(c.Context, fullCommand, c.Command.Name, err)
 		return nil, err
 	}
 
 	var commandProgressBar progressbar.ProgressBar
 
-	if c.Bool("show-progress") && !(src.Type == dst.Type) {
+	// the progress bar is not shown if nothing but the errors are printed.
+	switch {
+	case src.Type == dst.Type || log.OnlyErrors():
+		commandProgressBar = &progressbar.NoOp{}
+	case c.Bool("show-progress"):
 		commandProgressBar = progressbar.New()
-	} else {
+	case c.Bool("progress-json"):
+		commandProgressBar = progressbar.NewJSON(os.Stderr, progressJSONInterval)
+	default:
 		commandProgressBar = &progressbar.NoOp{}
 	}
 
 	metadata, ok := c.Value("metadata").(MapValue)
 	if !ok {
 		err := errors.New("metadata flag is not a map")
-		printError(fullCommand, c.Command.Name, err)
+		printError(c.Context, fullCommand, c.Command.Name, err)
+		return nil, err
+	}
+
+	storageOpts := NewStorageOpts(c)
+
+	partSizeValue := c.String("part-size")
+	if !c.IsSet("part-size") {
+		partSizeValue = strconv.FormatInt(storageOpts.TransferDefaults().PartSize, 10)
+	}
+
+	partSize, autoPartSize, err := parsePartSize(partSizeValue)
+	if err != nil {
+		printError(c.Context, fullCommand, c.Command.Name, err)
+		return nil, err
+	}
+
+	ifModifiedSince, err := parseIfModifiedSince(c.String("if-modified-since"))
+	if err != nil {
+		printError(c.Context, fullCommand, c.Command.Name, err)
+		return nil, err
+	}
+
+	maxObjectSize, err := parseMaxObjectSize(c.String("max-object-size"))
+	if err != nil {
+		printError(c.Context, fullCommand, c.Command.Name, err)
+		return nil, err
+	}
+
+	multipartThreshold, err := parseMultipartThreshold(c.String("multipart-threshold"))
+	if err != nil {
+		printError(c.Context, fullCommand, c.Command.Name, err)
+		return nil, err
+	}
+
+	grants, err := parseGrants(c)
+	if err != nil {
+		printError(c.Context, fullCommand, c.Command.Name, err)
 		return nil, err
 	}
 
+	var mapping metadataMapping
+	if path := c.String("metadata-from-json"); path != "" {
+		mapping, err = loadMetadataMapping(path)
+		if err != nil {
+			printError(c.Context, fullCommand, c.Command.Name, err)
+			return nil, err
+		}
+	}
+
+	// nothing is transferred in dry-run mode, so the manifest is not
+	// created either.
+	var manifest *checksumManifest
+	if path := c.String("write-checksum-manifest"); path != "" && !c.Bool("dry-run") {
+		manifest, err = openChecksumManifest(path, c.String("checksum-manifest-format"))
+		if err != nil {
+			printError(c.Context, fullCommand, c.Command.Name, err)
+			return nil, err
+		}
+	}
+
+	var since *sinceManifest
+	if path := c.String("since-manifest"); path != "" {
+		since, err = openSinceManifest(path, c.Bool("dry-run"))
+		if err != nil {
+			printError(c.Context, fullCommand, c.Command.Name, err)
+			return nil, err
+		}
+	}
+
+	concurrency := partConcurrency(c, storageOpts)
+
+	warnTotalConcurrency(c, concurrency)
+	warnSymlinkAsObject(c)
+
+	// keep the uploaded parts of failed multipart uploads so that they can
+	// be resumed later on.
+	storageOpts.LeavePartsOnError = c.Bool("resume")
+	storageOpts.SymlinksAsObjects = c.Bool("symlink-as-object")
+	storageOpts.EmptyDirs = c.Bool("create-dir-markers")
+
 	return &Copy{
 		src:          src,
 		dst:          dst,
+		dstTemplate:  dstTemplate,
 		op:           c.Command.Name,
 		fullCommand:  fullCommand,
 		deleteSource: deleteSource,
 		// flags
-		noClobber:             c.Bool("no-clobber"),
+		noClobber:             c.Bool("no-clobber") || isSkipOnConflict(c),
 		ifSizeDiffer:          c.Bool("if-size-differ"),
 		ifSourceNewer:         c.Bool("if-source-newer"),
+		skipIfEtagMatches:     c.Bool("skip-if-etag-matches"),
+		skipped:               &atomic.Int64{},
 		flatten:               c.Bool("flatten"),
+		destKeyTemplate:       c.String("dest-template"),
 		followSymlinks:        !c.Bool("no-follow-symlinks"),
 		storageClass:          storage.StorageClass(c.String("storage-class")),
-		concurrency:           c.Int("concurrency"),
-		partSize:              c.Int64("part-size") * megabytes,
+		concurrency:           concurrency,
+		partSize:              partSize,
+		autoPartSize:          autoPartSize,
+		fixedPartSize:         c.IsSet("part-size") && !autoPartSize,
+		multipartThreshold:    multipartThreshold,
+		checksumAlgorithm:     strings.ToUpper(c.String("checksum-algorithm")),
+		checksumType:          checksumTypeHeader(c.String("checksum-type")),
+		checksumMetadataKey:   normalizeChecksumMetadataKey(c.String("checksum-metadata-key")),
 		encryptionMethod:      c.String("sse"),
 		encryptionKeyID:       c.String("sse-kms-key-id"),
 		acl:                   c.String("acl"),
+		grants:                grants,
 		forceGlacierTransfer:  c.Bool("force-glacier-transfer"),
 		ignoreGlacierWarnings: c.Bool("ignore-glacier-warnings"),
 		exclude:               c.StringSlice("exclude"),
 		include:               c.StringSlice("include"),
+		excludeEmpty:          c.Bool("exclude-empty"),
 		cacheControl:          c.String("cache-control"),
 		expires:               c.String("expires"),
 		contentType:           c.String("content-type"),
 		contentEncoding:       c.String("content-encoding"),
 		contentDisposition:    c.String("content-disposition"),
+		contentLanguage:       c.String("content-language"),
 		metadata:              metadata,
 		metadataDirective:     c.String("metadata-directive"),
+		metadataMapping:       mapping,
+		sourceInventory:       c.String("source-inventory"),
+		ifMatch:               c.String("if-match"),
+		ifNoneMatch:           c.String("if-none-match"),
+		ifModifiedSince:       ifModifiedSince,
+		maxObjectSize:         maxObjectSize,
+		onConflict:            c.String("on-conflict"),
+		renameSuffix:          c.String("rename-suffix"),
 		showProgress:          c.Bool("show-progress"),
 		progressbar:           commandProgressBar,
+		resume:                c.Bool("resume"),
+		checksumMode:          strings.EqualFold(c.String("checksum-mode"), checksumModeEnabled),
+		checksumValidation:    checksumValidation(c),
+		keepMetadata:          c.Bool("keep-metadata"),
+		verifyBeforeDelete:    c.Bool("verify-before-delete"),
+		verifyChecksum:        c.Bool("verify-checksum"),
+		preserveStorageClass:  c.Bool("preserve-storage-class"),
+		createDirMarkers:      c.Bool("create-dir-markers"),
+		metadataMerge:         c.Bool("metadata-merge"),
+		uploadViaTemp:         c.Bool("upload-via-temp"),
+		symlinkAsObject:       c.Bool("symlink-as-object"),
+		continueDownload:      c.Bool("continue"),
+		onError:               c.String("on-error"),
+		checksumManifest:      manifest,
+		sinceManifest:         since,
 
 		// region settings
 		srcRegion: c.String("source-region"),
 		dstRegion: c.String("destination-region"),
 
-		storageOpts: NewStorageOpts(c),
+		srcNoSignRequest: c.Bool("source-no-sign-request"),
+		dstNoSignRequest: c.Bool("dest-no-sign-request"),
+		srcProfile:       c.String("source-profile"),
+		dstProfile:       c.String("dest-profile"),
+
+		storageOpts: storageOpts,
 	}, nil
 }
 
@@ -417,26 +1036,112 @@ increase the open file limit or try to decrease the number of workers with
 '-numworkers' parameter.
 `
 
-// Run starts copying given source objects to destination.
-func (c Copy) Run(ctx context.Context) error {
-	// override source region if set
-	if c.srcRegion != "" {
-		c.storageOpts.SetRegion(c.srcRegion)
+const totalConcurrencyWarning = `
+WARNING: up to %d parts (%d workers, %d parts per file) may be transferred
+concurrently. Consider decreasing '-numworkers' or '--part-concurrency'
+parameters if s5cmd runs out of memory or the requests are throttled.
+`
+
+var totalConcurrencyWarningOnce sync.Once
+
+// warnTotalConcurrency warns once if the number of parts in flight, which is
+// the number of workers times the number of parts per file, is too large.
+func warnTotalConcurrency(c *cli.Context, partConcurrency int) {
+	workers := c.Int("numworkers")
+	if total := workers * partConcurrency; total > maxTotalConcurrency {
+		totalConcurrencyWarningOnce.Do(func() {
+			fmt.Fprintf(log.Warnings(), strings.TrimSpace(totalConcurrencyWarning)+"\n", total, workers, partConcurrency)
+		})
 	}
+}
 
-	client, err := storage.NewClient(ctx, c.src, c.storageOpts)
-	if err != nil {
-		printError(c.fullCommand, c.op, err)
-		return err
+const symlinkAsObjectWarning = `
+WARNING: symbolic links are not supported on Windows, the symbolic links and
+the objects which keep a link target are skipped with '--symlink-as-object'.
+`
+
+var symlinkAsObjectWarningOnce sync.Once
+
+// warnSymlinkAsObject warns once if symbolic links are asked to be copied as
+// objects on Windows.
+func warnSymlinkAsObject(c *cli.Context) {
+	if c.Bool("symlink-as-object") && runtime.GOOS == "windows" {
+		symlinkAsObjectWarningOnce.Do(func() {
+			fmt.Fprintln(log.Warnings(), strings.TrimSpace(symlinkAsObjectWarning))
+		})
 	}
+}
 
-	objch, err := expandSource(ctx, client, c.followSymlinks, c.src)
-	if err != nil {
-		printError(c.fullCommand, c.op, err)
-		return err
+// srcStorageOpts returns the storage options of the source clients.
+func (c Copy) srcStorageOpts() storage.Options {
+	opts := c.storageOpts
+	if c.srcNoSignRequest {
+		opts.NoSignRequest = true
+	}
+	if c.srcProfile != "" {
+		opts.Profile = c.srcProfile
 	}
+	return opts
+}
 
-	c.progressbar.Start()
+// dstStorageOpts returns the storage options of the destination clients.
+func (c Copy) dstStorageOpts() storage.Options {
+	opts := c.storageOpts
+	if c.dstNoSignRequest {
+		opts.NoSignRequest = true
+	}
+	if c.dstProfile != "" {
+		opts.Profile = c.dstProfile
+	}
+	return opts
+}
+
+// crossAccount reports whether the source and the destination are accessed
+// with the credentials of different profiles. The destination can't read the
+// source in a server-side copy then, the objects are streamed instead.
+func (c Copy) crossAccount() bool {
+	srcOpts, dstOpts := c.srcStorageOpts(), c.dstStorageOpts()
+	if srcOpts.NoSignRequest || dstOpts.NoSignRequest {
+		return false
+	}
+	return srcOpts.Profile != dstOpts.Profile
+}
+
+// Run starts copying given source objects to destination.
+func (c Copy) Run(ctx context.Context) error {
+	// the remaining objects are not copied when an object fails with the
+	// stop policy.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// override source region if set
+	if c.srcRegion != "" {
+		c.storageOpts.SetRegion(c.srcRegion)
+	}
+
+	client, err := storage.NewClient(ctx, c.src, c.srcStorageOpts())
+	if err != nil {
+		printError(ctx, c.fullCommand, c.op, err)
+		return err
+	}
+
+	// the listing is stopped on the first interrupt, while the running
+	// transfers may finish.
+	listCtx, cancelList := parallel.ShutdownContext(ctx)
+	defer cancelList()
+
+	var objch <-chan *storage.Object
+	if c.sourceInventory != "" {
+		objch, err = expandInventory(listCtx, c.srcStorageOpts(), c.sourceInventory, c.src)
+	} else {
+		objch, err = expandSource(listCtx, client, c.followSymlinks, c.src)
+	}
+	if err != nil {
+		printError(ctx, c.fullCommand, c.op, err)
+		return err
+	}
+
+	c.progressbar.Start()
 	defer c.progressbar.Finish()
 	waiter := parallel.NewWaiter()
 
@@ -455,8 +1160,11 @@ func (c Copy) Run(ctx context.Context) error {
 
 				os.Exit(1)
 			}
-			printError(c.fullCommand, c.op, err)
+			printError(ctx, c.fullCommand, c.op, err)
 			merrorWaiter = multierror.Append(merrorWaiter, err)
+			if c.onError == onErrorStop && !errorpkg.IsCancelation(err) {
+				cancel()
+			}
 		}
 	}()
 
@@ -464,7 +1172,7 @@ func (c Copy) Run(ctx context.Context) error {
 	if !isBatch && !c.src.IsRemote() {
 		obj, err := client.Stat(ctx, c.src)
 		if err != nil {
-			printError(c.fullCommand, c.op, err)
+			printError(ctx, c.fullCommand, c.op, err)
 			return err
 		}
 
@@ -473,31 +1181,49 @@ func (c Copy) Run(ctx context.Context) error {
 
 	c.excludePatterns, err = createRegexFromWildcard(c.exclude)
 	if err != nil {
-		printError(c.fullCommand, c.op, err)
+		printError(ctx, c.fullCommand, c.op, err)
 		return err
 	}
 
 	c.includePatterns, err = createRegexFromWildcard(c.include)
 	if err != nil {
-		printError(c.fullCommand, c.op, err)
+		printError(ctx, c.fullCommand, c.op, err)
 		return err
 	}
 
+	c.dstObjects = c.listDestination(ctx, isBatch)
+
+	// claimed holds the destinations of the objects renamed with the rename
+	// conflict policy.
+	claimed := map[string]bool{}
+
 	for object := range objch {
-		if errorpkg.IsCancelation(object.Err) || object.Type.IsDir() {
+		if c.onError == onErrorStop && merrorObjects != nil {
+			cancel()
+		}
+		if ctx.Err() != nil {
+			// the listing is drained to let it finish.
+			go func() {
+				for range objch {
+				}
+			}()
+			break
+		}
+
+		if errorpkg.IsCancelation(object.Err) || (object.Type.IsDir() && !c.isEmptyDir(object)) {
 			continue
 		}
 
-		if !object.Type.IsRegular() {
+		if !object.Type.IsRegular() && !(c.symlinkAsObject && object.Type.IsSymlink()) && !c.isEmptyDir(object) {
 			err := fmt.Errorf("object '%v' is not a regular file", object)
 			merrorObjects = multierror.Append(merrorObjects, err)
-			printError(c.fullCommand, c.op, err)
+			printError(ctx, c.fullCommand, c.op, err)
 			continue
 		}
 
 		if err := object.Err; err != nil {
 			merrorObjects = multierror.Append(merrorObjects, err)
-			printError(c.fullCommand, c.op, err)
+			printError(ctx, c.fullCommand, c.op, err)
 			continue
 		}
 
@@ -505,28 +1231,67 @@ func (c Copy) Run(ctx context.Context) error {
 			if !c.ignoreGlacierWarnings {
 				err := fmt.Errorf("object '%v' is on Glacier storage", object)
 				merrorObjects = multierror.Append(merrorObjects, err)
-				printError(c.fullCommand, c.op, err)
+				printError(ctx, c.fullCommand, c.op, err)
 			}
 			continue
 		}
 
-		isExcluded, err := isObjectExcluded(object, c.excludePatterns, c.includePatterns, c.src.Prefix)
+		isExcluded, err := isObjectExcluded(object, c.excludePatterns, c.includePatterns, c.src)
 		if err != nil {
-			printError(c.fullCommand, c.op, err)
+			printError(ctx, c.fullCommand, c.op, err)
 		}
 		if isExcluded {
 			continue
 		}
 
+		if c.sinceManifest.skip(object) {
+			continue
+		}
+
 		srcurl := object.URL
 		var task parallel.Task
 
-		if object.Size == 0 && !(srcurl.Type == c.dst.Type) {
+		dsturl := c.dst
+		if c.dstTemplate != "" {
+			dsturl, err = c.dereferenceDestination(object)
+			if err != nil {
+				merrorObjects = multierror.Append(merrorObjects, err)
+				printError(ctx, c.fullCommand, c.op, err)
+				continue
+			}
+		}
+
+		if object.Size == 0 && (!(srcurl.Type == c.dst.Type) || c.maxObjectSize > 0) && !c.isEmptyDir(object) {
 			obj, err := client.Stat(ctx, srcurl)
 			if err == nil {
 				object.Size = obj.Size
 			}
 		}
+
+		if err := checkMaxObjectSize(object, c.maxObjectSize); err != nil {
+			merrorObjects = multierror.Append(merrorObjects, err)
+			printError(ctx, c.fullCommand, c.op, err)
+			continue
+		}
+
+		if c.excludeEmpty && object.Size == 0 && !c.isEmptyDir(object) {
+			printDebug(c.op, errorpkg.ErrObjectEmpty, srcurl)
+			continue
+		}
+		// the destinations are renamed in the order of the source objects,
+		// before they are copied concurrently, so that the renames are
+		// deterministic.
+		objIsBatch := isBatch
+		if c.onConflict == onConflictRename && !c.isEmptyDir(object) {
+			dsturl, err = c.renameDestination(ctx, srcurl, dsturl, isBatch, claimed)
+			if err != nil {
+				merrorObjects = multierror.Append(merrorObjects, err)
+				printError(ctx, c.fullCommand, c.op, err)
+				continue
+			}
+			objIsBatch = false
+		}
+
 		c.progressbar.AddTotalBytes(object.Size)
 		c.progressbar.IncrementTotalObjects()
 
@@ -540,32 +1305,76 @@ func (c Copy) Run(ctx context.Context) error {
 					c.metadataDirective = metadataDirectiveReplace
 				}
 			}
-			task = c.prepareCopyTask(ctx, srcurl, c.dst, isBatch, c.metadata)
+			task = c.prepareCopyTask(ctx, srcurl, dsturl, objIsBatch, c.metadata, object.StorageClass)
 		case srcurl.IsRemote(): // remote->local
 			if c.metadataDirective != "" {
 				err := fmt.Errorf("metadata directive is not supported for download")
 				merrorObjects = multierror.Append(merrorObjects, err)
-				printError(c.fullCommand, c.op, err)
+				printError(ctx, c.fullCommand, c.op, err)
 				continue
 			}
-			task = c.prepareDownloadTask(ctx, srcurl, c.dst, isBatch)
+			task = c.prepareDownloadTask(ctx, srcurl, dsturl, objIsBatch)
 		case c.dst.IsRemote(): // local->remote
 			if c.metadataDirective != "" {
 				err := fmt.Errorf("metadata directive is not supported for upload")
 				merrorObjects = multierror.Append(merrorObjects, err)
-				printError(c.fullCommand, c.op, err)
+				printError(ctx, c.fullCommand, c.op, err)
 				continue
 			}
-			task = c.prepareUploadTask(ctx, srcurl, c.dst, isBatch, c.metadata)
+			task = c.prepareUploadTask(ctx, srcurl, dsturl, objIsBatch, c.metadata)
 		default:
 			panic("unexpected src-dst pair")
 		}
-		parallel.Run(task, waiter)
+		parallel.Run(c.trackFileProgress(srcurl, object.Size, task), waiter)
 	}
 	waiter.Wait()
 	<-errDoneCh
 
-	return multierror.Append(merrorWaiter, merrorObjects).ErrorOrNil()
+	if c.skipIfEtagMatches {
+		log.InfoContext(ctx, SkipSummaryMessage{Operation: c.op, Skipped: c.skipped.Load()})
+	}
+
+	err = multierror.Append(merrorWaiter, merrorObjects).ErrorOrNil()
+	if err != nil {
+		return err
+	}
+
+	// the objects of a failed run are copied again by the next run.
+	if err := c.sinceManifest.Close(); err != nil {
+		printError(ctx, c.fullCommand, c.op, err)
+		return err
+	}
+	return nil
+}
+
+// trackFileProgress wraps the given task of the given source to track its
+// progress, if the progress of each file is shown.
+func (c Copy) trackFileProgress(srcurl *url.URL, size int64, task func() error) func() error {
+	pb, ok := c.progressbar.(progressbar.FileProgressBar)
+	if !ok {
+		return task
+	}
+
+	return func() error {
+		pb.StartFile(srcurl.String(), size)
+		defer pb.FinishFile(srcurl.String())
+		return task()
+	}
+}
+
+// dereferenceDestination expands the date tokens of the destination with the
+// modification time of the given object.
+func (c Copy) dereferenceDestination(object *storage.Object) (*url.URL, error) {
+	if object.ModTime == nil {
+		return c.dst, nil
+	}
+
+	dst, err := expandDateTemplate(c.dstTemplate, *object.ModTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return url.New(dst, url.WithRaw(c.dst.IsRaw()))
 }
 
 func (c Copy) prepareCopyTask(
@@ -574,10 +1383,14 @@ func (c Copy) prepareCopyTask(
 	dsturl *url.URL,
 	isBatch bool,
 	metadata map[string]string,
+	srcStorageClass storage.StorageClass,
 ) func() error {
 	return func() error {
-		dsturl = prepareRemoteDestination(srcurl, dsturl, c.flatten, isBatch)
-		err := c.doCopy(ctx, srcurl, dsturl, metadata)
+		dsturl, err := prepareRemoteDestination(srcurl, dsturl, c.flatten, isBatch, c.destKeyTemplate)
+		if err != nil {
+			return err
+		}
+		err = c.doCopy(ctx, srcurl, dsturl, metadata, srcStorageClass)
 		if err != nil {
 			return &errorpkg.Error{
 				Op:  c.op,
@@ -598,7 +1411,7 @@ func (c Copy) prepareDownloadTask(
 	isBatch bool,
 ) func() error {
 	return func() error {
-		dsturl, err := prepareLocalDestination(ctx, srcurl, dsturl, c.flatten, isBatch, c.storageOpts)
+		dsturl, err := prepareLocalDestination(ctx, srcurl, dsturl, c.flatten, isBatch, c.destKeyTemplate, c.storageOpts)
 		if err != nil {
 			return err
 		}
@@ -624,8 +1437,11 @@ func (c Copy) prepareUploadTask(
 	metadata map[string]string,
 ) func() error {
 	return func() error {
-		dsturl = prepareRemoteDestination(srcurl, dsturl, c.flatten, isBatch)
-		err := c.doUpload(ctx, srcurl, dsturl, metadata)
+		dsturl, err := prepareRemoteDestination(srcurl, dsturl, c.flatten, isBatch, c.destKeyTemplate)
+		if err != nil {
+			return err
+		}
+		err = c.doUpload(ctx, srcurl, dsturl, metadata)
 		if err != nil {
 			return &errorpkg.Error{
 				Op:  c.op,
@@ -641,7 +1457,7 @@ func (c Copy) prepareUploadTask(
 
 // doDownload is used to fetch a remote object and save as a local object.
 func (c Copy) doDownload(ctx context.Context, srcurl *url.URL, dsturl *url.URL) error {
-	srcClient, err := storage.NewRemoteClient(ctx, srcurl, c.storageOpts)
+	srcClient, err := storage.NewRemoteClient(ctx, srcurl, c.srcStorageOpts())
 	if err != nil {
 		return err
 	}
@@ -657,21 +1473,104 @@ func (c Copy) doDownload(ctx context.Context, srcurl *url.URL, dsturl *url.URL)
 		return err
 	}
 
-	dstPath := filepath.Dir(dsturl.Absolute())
-	dstFile := filepath.Base(dsturl.Absolute())
-	file, err := dstClient.CreateTemp(dstPath, dstFile)
-	if err != nil {
-		return err
+	if c.symlinkAsObject {
+		_, metadata, err := srcClient.HeadObject(ctx, srcurl)
+		if err != nil {
+			return err
+		}
+		if target, ok := symlinkTarget(metadata.UserDefined); ok {
+			return c.doDownloadSymlink(ctx, srcClient, dstClient, srcurl, dsturl, target)
+		}
+	}
+
+	conditions := storage.DownloadConditions{
+		IfModifiedSince: c.ifModifiedSince,
+		IfNoneMatch:     c.ifNoneMatch,
+	}
+
+	var (
+		file        *os.File
+		offset      int64
+		concurrency = c.concurrency
+		srcObject   *storage.Object
+	)
+	if c.continueDownload {
+		srcObject, err = srcClient.Stat(ctx, srcurl)
+		if err != nil {
+			return err
+		}
+
+		file, offset, err = openPartialDownload(dstClient, dsturl, srcObject)
+		if err != nil {
+			return err
+		}
+
+		// the parts are written in order, so that the size of the partial
+		// file is the size of the downloaded part if the download is
+		// interrupted. The appended part must belong to the same object.
+		concurrency = 1
+		conditions.IfMatch = srcObject.Etag
+	} else {
+		dstPath := filepath.Dir(dsturl.Absolute())
+		dstFile := filepath.Base(dsturl.Absolute())
+		file, err = dstClient.CreateTemp(dstPath, dstFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	partSize := c.partSize
+	if c.autoPartSize {
+		if srcObject == nil {
+			srcObject, err = srcClient.Stat(ctx, srcurl)
+			if err != nil {
+				file.Close()
+				return err
+			}
+		}
+		partSize = objectPartSize(srcurl, srcObject.Size)
 	}
 
-	writer := newCountingReaderWriter(file, c.progressbar)
-	size, err := srcClient.Get(ctx, srcurl, writer, c.concurrency, c.partSize)
+	writer := newCountingReaderWriter(file, c.progressbar, srcurl)
+	defer writer.done()
+	writer.addCompletedBytes(offset)
+
+	var (
+		size     int64
+		checksum *storage.Checksum
+	)
+	switch {
+	case c.checksumMode || c.checksumValidation != "":
+		size, checksum, err = srcClient.GetWithChecksum(ctx, srcurl, writer, c.concurrency, partSize, conditions, c.checksumValidation != "")
+		if err == nil && !c.storageOpts.DryRun {
+			err = validateDownloadChecksum(c.checksumValidation, srcurl, checksum, func(checksum storage.Checksum) error {
+				return verifyDownload(file, checksum)
+			})
+		}
+	case offset > 0 && offset == srcObject.Size:
+		// the object is downloaded completely before.
+		size = offset
+	default:
+		size, err = srcClient.GetFrom(ctx, srcurl, writer, offset, concurrency, partSize, conditions)
+		size += offset
+	}
 	file.Close()
 
 	if err != nil {
-		dErr := dstClient.Delete(ctx, &url.URL{Path: file.Name(), Type: dsturl.Type})
-		if dErr != nil {
-			printDebug(c.op, dErr, srcurl, dsturl)
+		// the partial file is kept to continue the download later on, unless
+		// the object is changed since or it is not to be downloaded.
+		keepPartial := c.continueDownload &&
+			!storage.IsPreconditionFailedError(err) &&
+			!storage.IsNotModifiedError(err)
+		if !keepPartial {
+			dErr := dstClient.Delete(ctx, &url.URL{Path: file.Name(), Type: dsturl.Type})
+			if dErr != nil {
+				printDebug(c.op, dErr, srcurl, dsturl)
+			}
+		}
+		if storage.IsNotModifiedError(err) {
+			printDebug(c.op, errorpkg.ErrObjectNotModified, srcurl, dsturl)
+			return nil
 		}
 		return err
 	}
@@ -685,6 +1584,10 @@ func (c Copy) doDownload(ctx context.Context, srcurl *url.URL, dsturl *url.URL)
 		return err
 	}
 
+	if err := c.writeChecksumManifest(ctx, srcClient, srcurl, ""); err != nil {
+		return err
+	}
+
 	if !c.showProgress {
 		msg := log.InfoMessage{
 			Operation:   c.op,
@@ -694,15 +1597,107 @@ func (c Copy) doDownload(ctx context.Context, srcurl *url.URL, dsturl *url.URL)
 				Size: size,
 			},
 		}
-		log.Info(msg)
+		log.InfoContext(ctx, msg)
+	}
+
+	return nil
+}
+
+// doDownloadSymlink recreates the symbolic link kept by the given object
+// instead of downloading it.
+func (c Copy) doDownloadSymlink(
+	ctx context.Context,
+	srcClient *storage.S3,
+	dstClient *storage.Filesystem,
+	srcurl, dsturl *url.URL,
+	target string,
+) error {
+	if runtime.GOOS == "windows" {
+		return nil
 	}
 
+	if err := dstClient.Symlink(target, dsturl.Absolute()); err != nil {
+		return err
+	}
+
+	if c.deleteSource {
+		_ = srcClient.Delete(ctx, srcurl)
+	}
+
+	if !c.showProgress {
+		msg := log.InfoMessage{
+			Operation:   c.op,
+			Source:      srcurl,
+			Destination: dsturl,
+			Object:      &storage.Object{},
+		}
+		log.InfoContext(ctx, msg)
+	}
 	return nil
 }
 
+// partialDownloadSuffix is the suffix of the files which hold the downloaded
+// part of the objects with the continue flag.
+const partialDownloadSuffix = ".s5cmd-partial"
+
+// partialDownloadPath returns the path of the partial file of the given
+// object which is downloaded to the given path. The path contains the ETag of
+// the object, so that the parts of different versions of an object are not
+// mixed.
+func partialDownloadPath(path, etag string) string {
+	return path + "." + strings.Trim(etag, `"`) + partialDownloadSuffix
+}
+
+// openPartialDownload opens the partial file of the given object to continue
+// its download, and returns the size of the downloaded part. The partial
+// file is created if there is none.
+func openPartialDownload(
+	dstClient *storage.Filesystem,
+	dsturl *url.URL,
+	obj *storage.Object,
+) (*os.File, int64, error) {
+	file, offset, err := dstClient.OpenPartial(partialDownloadPath(dsturl.Absolute(), obj.Etag))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// a partial file larger than the object can't be a part of it, start
+	// over.
+	if offset > obj.Size {
+		if err := file.Truncate(0); err != nil {
+			file.Close()
+			return nil, 0, err
+		}
+		offset = 0
+	}
+	return file, offset, nil
+}
+
+// verifyDownload verifies the data written to the given file against the
+// given checksum of the downloaded object.
+func verifyDownload(file *os.File, checksum storage.Checksum) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return checksum.Verify(file)
+}
+
 func (c Copy) doUpload(ctx context.Context, srcurl *url.URL, dsturl *url.URL, extradata map[string]string) error {
 	srcClient := storage.NewLocalClient(c.storageOpts)
 
+	if c.symlinkAsObject || c.createDirMarkers {
+		obj, err := srcClient.Stat(ctx, srcurl)
+		if err != nil {
+			return err
+		}
+		if c.symlinkAsObject && obj.Type.IsSymlink() {
+			return c.doUploadSymlink(ctx, srcClient, srcurl, dsturl, extradata)
+		}
+		if c.createDirMarkers && obj.Type.IsDir() {
+			return c.doUploadDirMarker(ctx, srcurl, dsturl, extradata)
+		}
+	}
+
 	file, err := srcClient.Open(srcurl.Absolute())
 	if err != nil {
 		return err
@@ -722,7 +1717,7 @@ func (c Copy) doUpload(ctx context.Context, srcurl *url.URL, dsturl *url.URL, ex
 	if c.dstRegion != "" {
 		c.storageOpts.SetRegion(c.dstRegion)
 	}
-	dstClient, err := storage.NewRemoteClient(ctx, dsturl, c.storageOpts)
+	dstClient, err := storage.NewRemoteClient(ctx, dsturl, c.dstStorageOpts())
 	if err != nil {
 		return err
 	}
@@ -730,26 +1725,50 @@ func (c Copy) doUpload(ctx context.Context, srcurl *url.URL, dsturl *url.URL, ex
 	metadata := storage.Metadata{
 		UserDefined:        extradata,
 		ACL:                c.acl,
+		Grants:             c.grants,
 		CacheControl:       c.cacheControl,
 		Expires:            c.expires,
 		StorageClass:       string(c.storageClass),
 		ContentEncoding:    c.contentEncoding,
 		ContentDisposition: c.contentDisposition,
+		ContentLanguage:    c.contentLanguage,
 		EncryptionMethod:   c.encryptionMethod,
 		EncryptionKeyID:    c.encryptionKeyID,
+		IfMatch:            c.ifMatch,
+		IfNoneMatch:        c.ifNoneMatch,
+		ChecksumAlgorithm:  c.checksumAlgorithm,
+		ChecksumType:       c.checksumType,
 	}
 
-	if c.contentType != "" {
-		metadata.ContentType = c.contentType
-	} else {
+	metadata.ContentType = c.contentType
+
+	// the metadata flags take precedence over the metadata mapping file, and
+	// the content type is guessed only if none of them specifies it.
+	c.metadataMapping.apply(dsturl.Path, &metadata)
+
+	if metadata.ContentType == "" {
 		metadata.ContentType = guessContentType(file)
 	}
 
-	reader := newCountingReaderWriter(file, c.progressbar)
-	err = dstClient.Put(ctx, reader, dsturl, metadata, c.concurrency, c.partSize)
+	if c.checksumMetadataKey != "" {
+		digest, err := sha256Digest(file)
+		if err != nil {
+			return err
+		}
+		metadata.UserDefined = setChecksumMetadata(metadata.UserDefined, c.checksumMetadataKey, digest)
+	}
 
-	if err != nil {
-		return err
+	// the user metadata of the destination object, e.g. set by other
+	// processes, is kept unless it is overwritten by the uploaded one.
+	if c.metadataMerge {
+		_, dstMetadata, err := dstClient.HeadObject(ctx, dsturl)
+		var objNotFound *storage.ErrGivenObjectNotFound
+		if err != nil && !errors.As(err, &objNotFound) {
+			return err
+		}
+		if err == nil {
+			metadata.UserDefined = mergeUserMetadata(dstMetadata.UserDefined, metadata.UserDefined)
+		}
 	}
 
 	obj, err := srcClient.Stat(ctx, srcurl)
@@ -757,9 +1776,79 @@ func (c Copy) doUpload(ctx context.Context, srcurl *url.URL, dsturl *url.URL, ex
 		return err
 	}
 
+	partSize := c.partSize
+	if c.autoPartSize {
+		partSize = objectPartSize(srcurl, obj.Size)
+	}
+
+	// the part size given by the user is used as is. Otherwise it's
+	// increased as needed to keep the upload under the maximum number of
+	// parts.
+	if c.fixedPartSize {
+		if err := validateUploadPartCount(obj.Size, partSize); err != nil {
+			return err
+		}
+	}
+
+	// the file is uploaded to a temporary object which is renamed to the
+	// destination once the upload is completed.
+	uploadurl := dsturl
+	if c.uploadViaTemp {
+		if obj.Size > maxSinglePartUploadSize {
+			return fmt.Errorf("%v of %d bytes can't be renamed with a server-side copy since it's larger than 5GiB, upload it without \"upload-via-temp\" flag", srcurl, obj.Size)
+		}
+		uploadurl = tempUploadURL(dsturl)
+	}
+
+	reader := newCountingReaderWriter(file, c.progressbar, srcurl)
+	defer reader.done()
+
+	var resumed bool
+	if c.resume {
+		metadata.ResumeSource = obj
+		resumed, err = dstClient.ResumeUpload(ctx, reader, obj, dsturl, metadata, c.concurrency, partSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !resumed {
+		partSize = uploadPartSize(srcurl, obj.Size, partSize, c.multipartThreshold)
+		err = dstClient.Put(ctx, reader, uploadurl, metadata, c.concurrency, partSize)
+		if storage.IsPreconditionFailedError(err) && c.onConflict == onConflictSkip {
+			printDebug(c.op, errorpkg.ErrObjectConflict, srcurl, dsturl)
+			return nil
+		}
+		if err != nil {
+			if c.uploadViaTemp {
+				deleteTempUpload(dstClient, uploadurl)
+			}
+			return err
+		}
+	}
+
+	if c.uploadViaTemp {
+		if err := renameTempUpload(ctx, dstClient, uploadurl, dsturl, metadata); err != nil {
+			return err
+		}
+	}
+
+	if c.checksumManifest != nil {
+		checksum, err := md5Checksum(file)
+		if err != nil {
+			return err
+		}
+		if err := c.writeChecksumManifest(ctx, dstClient, dsturl, checksum); err != nil {
+			return err
+		}
+	}
+
 	if c.deleteSource {
 		// close the file before deleting
 		file.Close()
+		if err := c.verifyDestination(ctx, dstClient, dsturl, obj); err != nil {
+			return err
+		}
 		if err := srcClient.Delete(ctx, srcurl); err != nil {
 			return err
 		}
@@ -775,55 +1864,374 @@ func (c Copy) doUpload(ctx context.Context, srcurl *url.URL, dsturl *url.URL, ex
 				StorageClass: c.storageClass,
 			},
 		}
-		log.Info(msg)
+		log.InfoContext(ctx, msg)
 	}
 
 	return nil
 }
 
-func (c Copy) doCopy(ctx context.Context, srcurl, dsturl *url.URL, extradata map[string]string) error {
-	// override destination region if set
-	if c.dstRegion != "" {
-		c.storageOpts.SetRegion(c.dstRegion)
-	}
-	dstClient, err := storage.NewClient(ctx, dsturl, c.storageOpts)
-	if err != nil {
+// tempUploadSuffix is the suffix of the temporary objects of the uploads with
+// upload-via-temp flag.
+const tempUploadSuffix = ".part"
+
+// tempUploadURL returns the URL of the temporary object which the given
+// destination is uploaded to with upload-via-temp flag.
+func tempUploadURL(dsturl *url.URL) *url.URL {
+	tempurl := dsturl.Clone()
+	tempurl.Path = dsturl.Path + tempUploadSuffix
+	return tempurl
+}
+
+// renameTempUpload renames the temporary object of an upload to its
+// destination with a server-side copy, and deletes the temporary object. The
+// storage class, the ACL and the encryption are not copied with the object,
+// so the metadata of the upload is given again as a whole.
+func renameTempUpload(ctx context.Context, client *storage.S3, tempurl, dsturl *url.URL, metadata storage.Metadata) error {
+	metadata.Directive = metadataDirectiveReplace
+	if err := client.Copy(ctx, tempurl, dsturl, metadata); err != nil {
+		deleteTempUpload(client, tempurl)
 		return err
 	}
+	return client.Delete(ctx, tempurl)
+}
 
-	metadata := storage.Metadata{
-		UserDefined:        extradata,
-		ACL:                c.acl,
-		CacheControl:       c.cacheControl,
-		Expires:            c.expires,
-		StorageClass:       string(c.storageClass),
-		ContentType:        c.contentType,
-		ContentEncoding:    c.contentEncoding,
-		ContentDisposition: c.contentDisposition,
-		EncryptionMethod:   c.encryptionMethod,
-		EncryptionKeyID:    c.encryptionKeyID,
-		Directive:          c.metadataDirective,
+// deleteTempUpload deletes the temporary object of a failed upload. The
+// object may not exist, e.g. if the upload failed before it's completed, and
+// the error of the deletion doesn't hide the error of the upload.
+func deleteTempUpload(client *storage.S3, tempurl *url.URL) {
+	// the context of the upload may be canceled already.
+	if err := client.Delete(context.Background(), tempurl); err != nil {
+		printDebug("rm", err, tempurl)
 	}
+}
 
-	err = c.shouldOverride(ctx, srcurl, dsturl)
-	if err != nil {
-		if errorpkg.IsWarning(err) {
-			printDebug(c.op, err, srcurl, dsturl)
-			return nil
-		}
-		return err
+// isEmptyDir reports whether the given object is an empty local directory
+// which is uploaded as a directory marker with create-dir-markers flag. The
+// empty directories are listed only if the flag is given.
+func (c Copy) isEmptyDir(object *storage.Object) bool {
+	return c.createDirMarkers && object.Type.IsDir() && !object.URL.IsRemote()
+}
+
+// verifyDestination verifies that the destination of a move exists with the
+// size of the given source object, and with its MD5 if verify-checksum flag
+// is given, before the source is deleted. An endpoint may report an upload as
+// succeeded although the object is missing, and the source is lost if it's
+// deleted then. Only the existence is verified if the source is nil. It's a
+// no-op unless verify-before-delete flag is given, or in dry run mode where
+// nothing is written.
+func (c Copy) verifyDestination(ctx context.Context, dstClient storage.Storage, dsturl *url.URL, src *storage.Object) error {
+	if !c.verifyBeforeDelete || c.storageOpts.DryRun {
+		return nil
 	}
 
-	err = dstClient.Copy(ctx, srcurl, dsturl, metadata)
+	dst, err := dstClient.Stat(ctx, dsturl)
 	if err != nil {
-		return err
+		var objNotFound *storage.ErrGivenObjectNotFound
+		if errors.As(err, &objNotFound) {
+			return fmt.Errorf("%v is not found after it's written, the source is not deleted", dsturl)
+		}
+		return fmt.Errorf("%v can not be verified, the source is not deleted: %w", dsturl, err)
+	}
+
+	if src == nil {
+		return nil
+	}
+
+	if dst.Size != src.Size {
+		return fmt.Errorf("%v is %d bytes instead of %d bytes of the source, the source is not deleted", dsturl, dst.Size, src.Size)
+	}
+
+	if !c.verifyChecksum {
+		return nil
+	}
+
+	// the objects uploaded by parts, or encrypted with KMS or a customer
+	// provided key, are verified by their sizes only.
+	if !etagComparable(src, dst) {
+		fmt.Fprintf(log.Warnings(), "WARNING %v: %v\n", dsturl, errEtagNotVerifiable)
+		return nil
+	}
+
+	srcMD5, err := objectMD5(src)
+	if err != nil {
+		return err
+	}
+	dstMD5, _ := objectMD5(dst)
+	if srcMD5 != dstMD5 {
+		return fmt.Errorf("checksum of %v differs from the source, the source is not deleted", dsturl)
+	}
+	return nil
+}
+
+// doUploadDirMarker uploads a zero-byte directory marker object, whose key
+// ends with "/", for the given empty directory. The destination is not
+// checked for existence since a marker has no content to overwrite.
+func (c Copy) doUploadDirMarker(ctx context.Context, srcurl, dsturl *url.URL, extradata map[string]string) error {
+	markerurl := dsturl.Clone()
+	if !strings.HasSuffix(markerurl.Path, "/") {
+		markerurl.Path += "/"
+	}
+
+	// override destination region if set
+	if c.dstRegion != "" {
+		c.storageOpts.SetRegion(c.dstRegion)
+	}
+	dstClient, err := storage.NewRemoteClient(ctx, markerurl, c.dstStorageOpts())
+	if err != nil {
+		return err
+	}
+
+	metadata := storage.Metadata{
+		UserDefined:      extradata,
+		ACL:              c.acl,
+		Grants:           c.grants,
+		CacheControl:     c.cacheControl,
+		Expires:          c.expires,
+		StorageClass:     string(c.storageClass),
+		ContentType:      c.contentType,
+		EncryptionMethod: c.encryptionMethod,
+		EncryptionKeyID:  c.encryptionKeyID,
+	}
+
+	err = dstClient.Put(ctx, strings.NewReader(""), markerurl, metadata, c.concurrency, c.partSize)
+	if err != nil {
+		return err
 	}
 
 	if c.deleteSource {
-		srcClient, err := storage.NewClient(ctx, srcurl, c.storageOpts)
+		if err := c.verifyDestination(ctx, dstClient, markerurl, nil); err != nil {
+			return err
+		}
+		if err := os.Remove(srcurl.Absolute()); err != nil {
+			return err
+		}
+	}
+
+	if !c.showProgress {
+		msg := log.InfoMessage{
+			Operation:   c.op,
+			Source:      srcurl,
+			Destination: markerurl,
+			Object: &storage.Object{
+				StorageClass: c.storageClass,
+			},
+		}
+		log.InfoContext(ctx, msg)
+	}
+	return nil
+}
+
+// symlinkTargetMetadataKey is the user-defined metadata key of the objects
+// uploaded for symbolic links with the symlink-as-object flag. Its value is
+// the target of the link as returned by readlink, e.g. relative targets are
+// kept relative. The object itself has no content.
+const symlinkTargetMetadataKey = "s5cmd-symlink-target"
+
+// symlinkTarget returns the target of the symbolic link kept by an object
+// with the given user-defined metadata, if any. The metadata keys are
+// compared case-insensitively since the keys may be returned canonicalized.
+func symlinkTarget(userDefined map[string]string) (string, bool) {
+	for k, v := range userDefined {
+		if strings.EqualFold(k, symlinkTargetMetadataKey) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// doUploadSymlink uploads the given symbolic link as a zero-byte object which
+// keeps the link target in its user-defined metadata.
+func (c Copy) doUploadSymlink(
+	ctx context.Context,
+	srcClient *storage.Filesystem,
+	srcurl, dsturl *url.URL,
+	extradata map[string]string,
+) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	target, err := os.Readlink(srcurl.Absolute())
+	if err != nil {
+		return err
+	}
+
+	err = c.shouldOverride(ctx, srcurl, dsturl)
+	if err != nil {
+		if errorpkg.IsWarning(err) {
+			printDebug(c.op, err, srcurl, dsturl)
+			return nil
+		}
+		return err
+	}
+
+	// override destination region if set
+	if c.dstRegion != "" {
+		c.storageOpts.SetRegion(c.dstRegion)
+	}
+	dstClient, err := storage.NewRemoteClient(ctx, dsturl, c.dstStorageOpts())
+	if err != nil {
+		return err
+	}
+
+	userDefined := make(map[string]string, len(extradata)+1)
+	for k, v := range extradata {
+		userDefined[k] = v
+	}
+	userDefined[symlinkTargetMetadataKey] = target
+
+	metadata := storage.Metadata{
+		UserDefined:      userDefined,
+		ACL:              c.acl,
+		Grants:           c.grants,
+		CacheControl:     c.cacheControl,
+		Expires:          c.expires,
+		StorageClass:     string(c.storageClass),
+		EncryptionMethod: c.encryptionMethod,
+		EncryptionKeyID:  c.encryptionKeyID,
+		IfMatch:          c.ifMatch,
+		IfNoneMatch:      c.ifNoneMatch,
+	}
+
+	err = dstClient.Put(ctx, strings.NewReader(""), dsturl, metadata, c.concurrency, c.partSize)
+	if storage.IsPreconditionFailedError(err) && c.onConflict == onConflictSkip {
+		printDebug(c.op, errorpkg.ErrObjectConflict, srcurl, dsturl)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.deleteSource {
+		// the symbolic link is uploaded as an empty object, only its
+		// existence is verified.
+		if err := c.verifyDestination(ctx, dstClient, dsturl, nil); err != nil {
+			return err
+		}
+		if err := srcClient.Delete(ctx, srcurl); err != nil {
+			return err
+		}
+	}
+
+	if !c.showProgress {
+		msg := log.InfoMessage{
+			Operation:   c.op,
+			Source:      srcurl,
+			Destination: dsturl,
+			Object: &storage.Object{
+				StorageClass: c.storageClass,
+			},
+		}
+		log.InfoContext(ctx, msg)
+	}
+	return nil
+}
+
+// doCopy copies the given object. srcStorageClass is the storage class of the
+// source object, if it's known from the listing.
+func (c Copy) doCopy(ctx context.Context, srcurl, dsturl *url.URL, extradata map[string]string, srcStorageClass storage.StorageClass) error {
+	srcOpts := c.srcStorageOpts()
+
+	// override destination region if set
+	if c.dstRegion != "" {
+		c.storageOpts.SetRegion(c.dstRegion)
+	}
+	dstClient, err := storage.NewClient(ctx, dsturl, c.dstStorageOpts())
+	if err != nil {
+		return err
+	}
+
+	storageClass := c.storageClass
+	if c.preserveStorageClass && storageClass == "" {
+		storageClass, err = c.sourceStorageClass(ctx, srcurl, srcStorageClass)
 		if err != nil {
 			return err
 		}
+	}
+
+	metadata := storage.Metadata{
+		UserDefined:        extradata,
+		ACL:                c.acl,
+		Grants:             c.grants,
+		CacheControl:       c.cacheControl,
+		Expires:            c.expires,
+		StorageClass:       string(storageClass),
+		ContentType:        c.contentType,
+		ContentEncoding:    c.contentEncoding,
+		ContentDisposition: c.contentDisposition,
+		ContentLanguage:    c.contentLanguage,
+		EncryptionMethod:   c.encryptionMethod,
+		EncryptionKeyID:    c.encryptionKeyID,
+		Directive:          c.metadataDirective,
+	}
+
+	// the metadata of the source object is replaced with only the given
+	// fields, the others are kept as they are.
+	if c.keepMetadata {
+		srcClient, err := storage.NewRemoteClient(ctx, srcurl, c.srcStorageOpts())
+		if err != nil {
+			return err
+		}
+		_, srcMetadata, err := srcClient.HeadObject(ctx, srcurl)
+		if err != nil {
+			return err
+		}
+		metadata = mergeMetadata(*srcMetadata, metadata)
+	}
+
+	// an object copied onto itself only has its metadata replaced by the
+	// remote storage, its content is not copied.
+	inPlace := isSameObject(srcurl, dsturl)
+	if inPlace {
+		metadata.Directive = metadataDirectiveReplace
+	} else {
+		err = c.shouldOverride(ctx, srcurl, dsturl)
+		if err != nil {
+			if errorpkg.IsWarning(err) {
+				printDebug(c.op, err, srcurl, dsturl)
+				return nil
+			}
+			return err
+		}
+	}
+
+	if !inPlace && c.crossAccount() {
+		err = c.streamCopy(ctx, srcurl, dsturl, srcOpts, metadata)
+	} else {
+		err = dstClient.Copy(ctx, srcurl, dsturl, metadata)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.checksumManifest != nil {
+		dstRemoteClient, err := storage.NewRemoteClient(ctx, dsturl, c.dstStorageOpts())
+		if err != nil {
+			return err
+		}
+		if err := c.writeChecksumManifest(ctx, dstRemoteClient, dsturl, ""); err != nil {
+			return err
+		}
+	}
+
+	// the source of an in-place update is the destination itself, it's not
+	// deleted.
+	if c.deleteSource && !inPlace {
+		srcClient, err := storage.NewClient(ctx, srcurl, c.srcStorageOpts())
+		if err != nil {
+			return err
+		}
+
+		if c.verifyBeforeDelete {
+			srcObj, err := srcClient.Stat(ctx, srcurl)
+			if err != nil {
+				return err
+			}
+			if err := c.verifyDestination(ctx, dstClient, dsturl, srcObj); err != nil {
+				return err
+			}
+		}
+
 		if err := srcClient.Delete(ctx, srcurl); err != nil {
 			return err
 		}
@@ -835,14 +2243,141 @@ func (c Copy) doCopy(ctx context.Context, srcurl, dsturl *url.URL, extradata map
 		Destination: dsturl,
 		Object: &storage.Object{
 			URL:          dsturl,
-			StorageClass: c.storageClass,
+			StorageClass: storageClass,
 		},
 	}
-	log.Info(msg)
+	if inPlace {
+		msg.Reason = copyReasonInPlace
+	}
+	log.InfoContext(ctx, msg)
 
 	return nil
 }
 
+// streamCopy copies the given object by downloading it with the source
+// credentials and uploading it with the destination credentials. The metadata
+// of the source object is kept unless it's replaced, as in a server-side copy.
+func (c Copy) streamCopy(ctx context.Context, srcurl, dsturl *url.URL, srcOpts storage.Options, metadata storage.Metadata) error {
+	srcClient, err := storage.NewRemoteClient(ctx, srcurl, srcOpts)
+	if err != nil {
+		return err
+	}
+
+	if metadata.Directive != metadataDirectiveReplace {
+		_, srcMetadata, err := srcClient.HeadObject(ctx, srcurl)
+		if err != nil {
+			return err
+		}
+		metadata = mergeMetadata(*srcMetadata, metadata)
+	}
+
+	reader, err := srcClient.Read(ctx, srcurl)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	dstClient, err := storage.NewRemoteClient(ctx, dsturl, c.dstStorageOpts())
+	if err != nil {
+		return err
+	}
+	return dstClient.Put(ctx, reader, dsturl, metadata, c.concurrency, c.partSize)
+}
+
+// copyReasonInPlace is the reason of the copies which update the metadata of
+// an object in place.
+const copyReasonInPlace = "metadata-updated-in-place"
+
+// isSameObject reports whether the given source and destination are the same
+// remote object. A specific version of an object is not the same object as its
+// latest version, copying it onto the object restores the version.
+func isSameObject(srcurl, dsturl *url.URL) bool {
+	return srcurl.IsRemote() && dsturl.IsRemote() &&
+		!srcurl.IsWildcard() && !dsturl.IsPrefix() &&
+		srcurl.VersionID == "" &&
+		srcurl.Bucket == dsturl.Bucket && srcurl.Path == dsturl.Path
+}
+
+// sourceStorageClass returns the storage class of the given source object.
+// The object is requested if its storage class is not known from the
+// listing, e.g. the source is not a wildcard.
+func (c Copy) sourceStorageClass(ctx context.Context, srcurl *url.URL, storageClass storage.StorageClass) (storage.StorageClass, error) {
+	if storageClass != "" {
+		return storageClass, nil
+	}
+
+	srcClient, err := storage.NewRemoteClient(ctx, srcurl, c.srcStorageOpts())
+	if err != nil {
+		return "", err
+	}
+	obj, _, err := srcClient.HeadObject(ctx, srcurl)
+	if err != nil {
+		return "", err
+	}
+	return obj.StorageClass, nil
+}
+
+// mergeMetadata returns the given metadata whose empty fields are set from
+// the metadata of the source object. The user defined metadata of the source
+// object is kept unless a key is overridden, and the keys are lowercased.
+func mergeMetadata(src, metadata storage.Metadata) storage.Metadata {
+	keep := func(value *string, srcValue string) {
+		if *value == "" {
+			*value = srcValue
+		}
+	}
+	keep(&metadata.CacheControl, src.CacheControl)
+	keep(&metadata.Expires, src.Expires)
+	keep(&metadata.ContentType, src.ContentType)
+	keep(&metadata.ContentEncoding, src.ContentEncoding)
+	keep(&metadata.ContentDisposition, src.ContentDisposition)
+	keep(&metadata.ContentLanguage, src.ContentLanguage)
+
+	metadata.UserDefined = mergeUserMetadata(src.UserDefined, metadata.UserDefined)
+	metadata.Directive = metadataDirectiveReplace
+
+	return metadata
+}
+
+// writeChecksumManifest appends the given transferred remote object to the
+// checksum manifest. The given checksum, e.g. computed while uploading, takes
+// precedence over the additional checksum of the object.
+func (c Copy) writeChecksumManifest(ctx context.Context, client *storage.S3, objurl *url.URL, checksum string) error {
+	if c.checksumManifest == nil {
+		return nil
+	}
+
+	obj, storedChecksum, err := client.StatWithChecksum(ctx, objurl)
+	if err != nil {
+		return err
+	}
+
+	if checksum == "" && storedChecksum != nil {
+		checksum = storedChecksum.String()
+	}
+
+	return c.checksumManifest.write(checksumManifestEntry{
+		Key:      objurl.Path,
+		Size:     obj.Size,
+		Etag:     obj.Etag,
+		Checksum: checksum,
+	})
+}
+
+// mergeUserMetadata merges the user metadata maps, the values of the given
+// metadata take precedence over the ones of the existing metadata. The keys
+// are case insensitive, e.g. HeadObject returns them in canonical form.
+func mergeUserMetadata(existing, metadata map[string]string) map[string]string {
+	userDefined := make(map[string]string, len(existing)+len(metadata))
+	for k, v := range existing {
+		userDefined[strings.ToLower(k)] = v
+	}
+	for k, v := range metadata {
+		userDefined[strings.ToLower(k)] = v
+	}
+	return userDefined
+}
+
 // shouldOverride function checks if the destination should be overridden if
 // the source-destination pair and given copy flags conform to the
 // override criteria. For example; "cp -n -s <src> <dst>" should not override
@@ -850,11 +2385,11 @@ func (c Copy) doCopy(ctx context.Context, srcurl, dsturl *url.URL, extradata map
 // differs.
 func (c Copy) shouldOverride(ctx context.Context, srcurl *url.URL, dsturl *url.URL) error {
 	// if not asked to override, ignore.
-	if !c.noClobber && !c.ifSizeDiffer && !c.ifSourceNewer {
+	if !c.noClobber && !c.ifSizeDiffer && !c.ifSourceNewer && !c.skipIfEtagMatches {
 		return nil
 	}
 
-	srcClient, err := storage.NewClient(ctx, srcurl, c.storageOpts)
+	srcClient, err := storage.NewClient(ctx, srcurl, c.srcStorageOpts())
 	if err != nil {
 		return err
 	}
@@ -864,14 +2399,19 @@ func (c Copy) shouldOverride(ctx context.Context, srcurl *url.URL, dsturl *url.U
 		return err
 	}
 
-	dstClient, err := storage.NewClient(ctx, dsturl, c.storageOpts)
-	if err != nil {
-		return err
-	}
+	var dstObj *storage.Object
+	if c.dstObjects != nil {
+		dstObj = c.dstObjects[dsturl.Path]
+	} else {
+		dstClient, err := storage.NewClient(ctx, dsturl, c.dstStorageOpts())
+		if err != nil {
+			return err
+		}
 
-	dstObj, err := statObject(ctx, dsturl, dstClient)
-	if err != nil {
-		return err
+		dstObj, err = statObject(ctx, dsturl, dstClient)
+		if err != nil {
+			return err
+		}
 	}
 
 	// if destination not exists, no conditions apply.
@@ -902,9 +2442,231 @@ func (c Copy) shouldOverride(ctx context.Context, srcurl *url.URL, dsturl *url.U
 		}
 	}
 
+	if c.skipIfEtagMatches {
+		if err := c.skipIfMatches(ctx, srcObj, dstObj, srcurl, dsturl); err != nil {
+			return err
+		}
+	}
+
 	return stickyErr
 }
 
+// The reasons of the objects skipped by skip-if-etag-matches flag.
+const (
+	skipReasonEtagMatches = "etag-matches"
+	skipReasonSizeMatches = "size-matches"
+)
+
+// errEtagNotComparableSize is the debug note of the objects which are
+// compared by their sizes instead of their ETags.
+var errEtagNotComparableSize = fmt.Errorf("object etag is not the MD5 of its content, e.g. of a multipart upload, comparing size instead")
+
+// skipIfMatches prints a skip line and returns a warning if the source object
+// matches the destination object. The objects match if their ETags, i.e. the
+// MD5 of their content, are equal, or if their sizes are equal when the ETag
+// of either is not comparable.
+func (c Copy) skipIfMatches(ctx context.Context, srcObj, dstObj *storage.Object, srcurl, dsturl *url.URL) error {
+	if srcObj.Size != dstObj.Size {
+		return nil
+	}
+
+	reason, warning := skipReasonSizeMatches, errorpkg.ErrObjectSizesMatch
+	if etagComparable(srcObj, dstObj) {
+		// the objects whose MD5 can't be computed are copied, so that the
+		// copy reports the error.
+		srcMD5, err := objectMD5(srcObj)
+		if err != nil {
+			return nil
+		}
+		dstMD5, err := objectMD5(dstObj)
+		if err != nil || srcMD5 != dstMD5 {
+			return nil
+		}
+		reason, warning = skipReasonEtagMatches, errorpkg.ErrObjectEtagsMatch
+	} else {
+		printDebug(c.op, errEtagNotComparableSize, srcurl, dsturl)
+	}
+
+	c.skipped.Add(1)
+	log.InfoContext(ctx, log.InfoMessage{
+		Operation:   "skip",
+		Source:      srcurl,
+		Destination: dsturl,
+		Reason:      reason,
+	})
+	return warning
+}
+
+// SkipSummaryMessage is a structure for logging the number of the objects
+// skipped by skip-if-etag-matches flag.
+type SkipSummaryMessage struct {
+	Operation string `json:"operation"`
+	Skipped   int64  `json:"skipped"`
+}
+
+// String returns the string representation of SkipSummaryMessage.
+func (m SkipSummaryMessage) String() string {
+	return fmt.Sprintf("%s: %d objects skipped", m.Operation, m.Skipped)
+}
+
+// JSON returns the JSON representation of SkipSummaryMessage.
+func (m SkipSummaryMessage) JSON() string {
+	return strutil.JSON(m)
+}
+
+// listDestination lists the objects under the remote destination prefix of a
+// batch copy once, so that the existence checks of --no-clobber,
+// --if-size-differ, --if-source-newer, --skip-if-etag-matches and
+// --on-conflict rename do not send a HEAD request for each object. It
+// returns nil if the objects should be checked one by one, e.g. the
+// destination is not a prefix or it can not be listed.
+func (c Copy) listDestination(ctx context.Context, isBatch bool) map[string]*storage.Object {
+	if !c.noClobber && !c.ifSizeDiffer && !c.ifSourceNewer && !c.skipIfEtagMatches && c.onConflict != onConflictRename {
+		return nil
+	}
+
+	// the destination keys are not known beforehand if the destination has
+	// date tokens, and the prefix can not be listed as is if it has glob
+	// characters.
+	if !isBatch || !c.dst.IsRemote() || c.dstTemplate != "" || c.dst.IsWildcard() {
+		return nil
+	}
+	if !c.dst.IsPrefix() && !c.dst.IsBucket() {
+		return nil
+	}
+
+	dsturl, err := url.New(c.dst.String() + "*")
+	if err != nil {
+		printDebug(c.op, err, c.dst)
+		return nil
+	}
+
+	storageOpts := c.dstStorageOpts()
+	if c.dstRegion != "" {
+		storageOpts.SetRegion(c.dstRegion)
+	}
+	client, err := storage.NewRemoteClient(ctx, dsturl, storageOpts)
+	if err != nil {
+		printDebug(c.op, err, c.dst)
+		return nil
+	}
+
+	var listErr error
+	objects := map[string]*storage.Object{}
+	for object := range client.List(ctx, dsturl, false) {
+		if errors.Is(object.Err, storage.ErrNoObjectFound) {
+			continue
+		}
+		if err := object.Err; err != nil {
+			listErr = err
+			continue
+		}
+		objects[object.URL.Path] = object
+	}
+
+	if listErr != nil {
+		// fall back to checking the objects one by one.
+		printDebug(c.op, listErr, c.dst)
+		return nil
+	}
+	return objects
+}
+
+// isSkipOnConflict reports whether the existing destinations are skipped by
+// the skip conflict policy, i.e. no condition is given to be checked by the
+// remote storage.
+func isSkipOnConflict(c *cli.Context) bool {
+	return c.String("on-conflict") == onConflictSkip && c.String("if-match") == "" && c.String("if-none-match") == ""
+}
+
+// renameDestination returns the destination of the given source object. If
+// the destination exists, or it's claimed by a previous object of the copy,
+// e.g. when the sources are flattened, it's renamed with the first numeric
+// suffix which is available.
+func (c Copy) renameDestination(
+	ctx context.Context,
+	srcurl *url.URL,
+	dsturl *url.URL,
+	isBatch bool,
+	claimed map[string]bool,
+) (*url.URL, error) {
+	var err error
+	if dsturl.IsRemote() {
+		dsturl, err = prepareRemoteDestination(srcurl, dsturl, c.flatten, isBatch, c.destKeyTemplate)
+	} else {
+		dsturl, err = prepareLocalDestination(ctx, srcurl, dsturl, c.flatten, isBatch, c.destKeyTemplate, c.storageOpts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	storageOpts := c.dstStorageOpts()
+	if c.dstRegion != "" {
+		storageOpts.SetRegion(c.dstRegion)
+	}
+	client, err := storage.NewClient(ctx, dsturl, storageOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	renamed := dsturl
+	for n := 1; ; n++ {
+		exists := claimed[renamed.Absolute()]
+		if !exists && c.dstObjects != nil {
+			exists = c.dstObjects[renamed.Path] != nil
+		} else if !exists {
+			obj, err := statObject(ctx, renamed, client)
+			if err != nil {
+				return nil, err
+			}
+			exists = obj != nil
+		}
+
+		if !exists {
+			break
+		}
+		renamed = renameURL(dsturl, c.renameSuffix, n)
+	}
+
+	claimed[renamed.Absolute()] = true
+	return renamed, nil
+}
+
+// renameURL adds the given suffix, with its {n} replaced with the given
+// number, to the name of the given URL before its extension, e.g.
+// "dir/name.txt" is renamed to "dir/name-1.txt".
+func renameURL(u *url.URL, suffix string, n int) *url.URL {
+	base := u.Base()
+	ext := filepath.Ext(base)
+	if ext == base {
+		// dotfiles, e.g. ".env", don't have an extension.
+		ext = ""
+	}
+
+	renamed := u.Clone()
+	renamed.Path = strings.TrimSuffix(u.Path, ext) + strings.ReplaceAll(suffix, "{n}", strconv.Itoa(n)) + ext
+	return renamed
+}
+
+// destinationName returns the name of the destination of the given source
+// object, relative to the destination. The directory structure of the source
+// is kept for batch operations unless it's flattened, or the name is given by
+// the destination template.
+func destinationName(srcurl *url.URL, flatten, isBatch bool, destKeyTemplate string) (string, error) {
+	relative := srcurl.Base()
+	if isBatch {
+		relative = srcurl.Relative()
+	}
+
+	if destKeyTemplate != "" {
+		return expandDestTemplate(destKeyTemplate, relative)
+	}
+	if isBatch && !flatten {
+		return relative, nil
+	}
+	return srcurl.Base(), nil
+}
+
 // prepareRemoteDestination will return a new destination URL for
 // remote->remote and local->remote copy operations.
 func prepareRemoteDestination(
@@ -912,16 +2674,17 @@ func prepareRemoteDestination(
 	dsturl *url.URL,
 	flatten bool,
 	isBatch bool,
-) *url.URL {
-	objname := srcurl.Base()
-	if isBatch && !flatten {
-		objname = srcurl.Relative()
+	destKeyTemplate string,
+) (*url.URL, error) {
+	objname, err := destinationName(srcurl, flatten, isBatch, destKeyTemplate)
+	if err != nil {
+		return nil, err
 	}
 
 	if dsturl.IsPrefix() || dsturl.IsBucket() {
 		dsturl = dsturl.Join(objname)
 	}
-	return dsturl
+	return dsturl, nil
 }
 
 // prepareDownloadDestination will return a new destination URL for
@@ -932,13 +2695,17 @@ func prepareLocalDestination(
 	dsturl *url.URL,
 	flatten bool,
 	isBatch bool,
+	destKeyTemplate string,
 	storageOpts storage.Options,
 ) (*url.URL, error) {
-	objname := srcurl.Base()
-	if isBatch && !flatten {
-		objname = srcurl.Relative()
+	objname, err := destinationName(srcurl, flatten, isBatch, destKeyTemplate)
+	if err != nil {
+		return nil, err
 	}
 
+	// the templated names may be nested like the batch operations.
+	nested := destKeyTemplate != "" || (isBatch && !flatten)
+
 	client := storage.NewLocalClient(storageOpts)
 
 	if isBatch {
@@ -956,7 +2723,7 @@ func prepareLocalDestination(
 		}
 	}
 
-	if isBatch && !flatten {
+	if nested {
 		dsturl = dsturl.Join(objname)
 		err := client.MkdirAll(dsturl.Dir())
 		if err != nil {
@@ -993,14 +2760,344 @@ func statObject(ctx context.Context, url *url.URL, client storage.Storage) (*sto
 	return obj, err
 }
 
+// parsePartSize parses the value of the part-size flag, which is either a
+// size in MiB or "auto".
+func parsePartSize(value string) (partSize int64, auto bool, err error) {
+	if strings.EqualFold(value, partSizeAuto) {
+		return 0, true, nil
+	}
+
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		return 0, false, fmt.Errorf("invalid part size %q: expected a size in MiB or %q", value, partSizeAuto)
+	}
+	return size * megabytes, false, nil
+}
+
+// objectPartSize returns the part size computed from the size of the given
+// object for the "auto" part size.
+func objectPartSize(srcurl *url.URL, size int64) int64 {
+	partSize := storage.AutoPartSize(size)
+
+	msg := log.DebugMessage{Err: fmt.Sprintf("Using part size of %d bytes for %v of %d bytes", partSize, srcurl, size)}
+	log.Debug(msg)
+
+	return partSize
+}
+
+// uploadPartSize returns the part size to upload an object of the given size.
+// The objects smaller than the multipart threshold are uploaded with a single
+// request, by using a part as large as the object.
+func uploadPartSize(srcurl *url.URL, size, partSize, multipartThreshold int64) int64 {
+	if size <= partSize || size >= multipartThreshold {
+		return partSize
+	}
+
+	msg := log.DebugMessage{Err: fmt.Sprintf("Uploading %v of %d bytes with a single request", srcurl, size)}
+	log.Debug(msg)
+
+	return size
+}
+
+// validateUploadPartSize validates the part size given by the user for the
+// multipart uploads.
+func validateUploadPartSize(partSize int64) error {
+	if partSize < minUploadPartSize {
+		return fmt.Errorf("invalid part size %d MiB: the parts of the multipart uploads must be at least %d MiB, use --part-size %d or larger", partSize/megabytes, minUploadPartSize/megabytes, minUploadPartSize/megabytes)
+	}
+	return nil
+}
+
+// validateUploadPartCount validates that an object of the given size can be
+// uploaded with the part size given by the user, without exceeding the
+// maximum number of parts.
+func validateUploadPartCount(size, partSize int64) error {
+	parts := (size + partSize - 1) / partSize
+	if parts <= maxUploadParts {
+		return nil
+	}
+
+	minPartSize := (storage.AutoPartSize(size) + megabytes - 1) / megabytes
+	return fmt.Errorf("part size %d MiB is too small for an object of %d bytes: it needs %d parts but at most %d parts are allowed, use --part-size %d or larger, or --part-size auto", partSize/megabytes, size, parts, maxUploadParts, minPartSize)
+}
+
+// parseMultipartThreshold parses the value of the multipart-threshold flag.
+// Zero means the objects larger than the part size are uploaded in multiple
+// parts.
+func parseMultipartThreshold(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	size, err := strutil.ParseBytes(value)
+	if err != nil || size > maxSinglePartUploadSize {
+		return 0, fmt.Errorf(`invalid value for "multipart-threshold" flag %q: expected a size up to 5GiB, e.g. 100MB`, value)
+	}
+	return size, nil
+}
+
+// checksumTypeHeader returns the value of the checksum type header for the
+// given value of the checksum-type flag. Composite checksums are the default,
+// so the header is not sent for them.
+func checksumTypeHeader(value string) string {
+	if strings.EqualFold(value, checksumTypeFullObject) {
+		return storage.ChecksumTypeFullObject
+	}
+	return ""
+}
+
+// validateChecksumAlgorithm validates the flags of the additional checksums
+// of the uploads.
+func validateChecksumAlgorithm(c *cli.Context, srcurl, dsturl *url.URL) error {
+	algorithm := strings.ToUpper(c.String("checksum-algorithm"))
+	if algorithm == "" {
+		if checksumTypeHeader(c.String("checksum-type")) != "" {
+			return fmt.Errorf(`"checksum-type" flag can only be used with "checksum-algorithm" flag`)
+		}
+		return nil
+	}
+
+	if srcurl.IsRemote() || !dsturl.IsRemote() {
+		return fmt.Errorf(`"checksum-algorithm" flag can only be used for uploads`)
+	}
+	if c.Bool("resume") {
+		return fmt.Errorf(`"checksum-algorithm" and "resume" flags cannot be used together`)
+	}
+
+	isCRC := algorithm == "CRC32C" || algorithm == "CRC32"
+	if checksumTypeHeader(c.String("checksum-type")) != "" && !isCRC {
+		return fmt.Errorf(`"checksum-type" flag can be full_object only with CRC32C and CRC32 checksum algorithms`)
+	}
+	return nil
+}
+
+// validateContentDisposition loosely validates the value of the
+// content-disposition flag, which is a disposition type, e.g. inline or
+// attachment, optionally followed by parameters.
+func validateContentDisposition(value string) error {
+	if value == "" {
+		return nil
+	}
+	if _, _, err := mime.ParseMediaType(value); err != nil {
+		return fmt.Errorf(`invalid value for "content-disposition" flag %q: expected a disposition type with optional parameters, e.g. 'attachment; filename="file.jpg"'`, value)
+	}
+	return nil
+}
+
+// parseMaxObjectSize parses the value of the max-object-size flag. Zero means
+// there is no limit.
+func parseMaxObjectSize(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	size, err := strutil.ParseBytes(value)
+	if err != nil || size == 0 {
+		return 0, fmt.Errorf(`invalid value for "max-object-size" flag %q: expected a positive size, e.g. 10GB`, value)
+	}
+	return size, nil
+}
+
+// checkMaxObjectSize checks if the given object is within the maximum object
+// size, if there is one.
+func checkMaxObjectSize(object *storage.Object, maxObjectSize int64) error {
+	if maxObjectSize > 0 && object.Size > maxObjectSize {
+		return fmt.Errorf("object '%v' is larger than the maximum object size: %v > %v",
+			object.URL, strutil.HumanizeBytes(object.Size), strutil.HumanizeBytes(maxObjectSize))
+	}
+	return nil
+}
+
+// parseIfModifiedSince parses the value of the if-modified-since flag.
+func parseIfModifiedSince(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf(`invalid value for "if-modified-since" flag %q: expected RFC3339 format, e.g. 2024-10-01T20:30:00Z`, value)
+	}
+	return &t, nil
+}
+
+// validateConditionalCopy validates the flags of the conditional uploads and
+// downloads.
+func validateConditionalCopy(c *cli.Context, srcurl, dsturl *url.URL) error {
+	isUpload := !srcurl.IsRemote() && dsturl.IsRemote()
+	isDownload := srcurl.IsRemote() && !dsturl.IsRemote()
+
+	ifModifiedSince, err := parseIfModifiedSince(c.String("if-modified-since"))
+	if err != nil {
+		return err
+	}
+	if ifModifiedSince != nil && !isDownload {
+		return fmt.Errorf(`"if-modified-since" flag can only be used for downloads`)
+	}
+
+	if c.String("checksum-mode") != "" && !isDownload {
+		return fmt.Errorf(`"checksum-mode" flag can only be used for downloads`)
+	}
+
+	onConflict := c.String("on-conflict")
+	if onConflict == onConflictOverwrite || onConflict == onConflictRename {
+		if c.Bool("no-clobber") || c.Bool("if-size-differ") || c.Bool("if-source-newer") || c.Bool("skip-if-etag-matches") {
+			return fmt.Errorf(`"on-conflict" flag cannot be %v with "no-clobber", "if-size-differ", "if-source-newer" or "skip-if-etag-matches" flags`, onConflict)
+		}
+	}
+
+	if onConflict == onConflictRename && !strings.Contains(c.String("rename-suffix"), "{n}") {
+		return fmt.Errorf(`"rename-suffix" flag must contain "{n}"`)
+	}
+
+	if strings.ContainsAny(c.String("rename-suffix"), `/\`) {
+		return fmt.Errorf(`"rename-suffix" flag cannot contain path separators`)
+	}
+
+	ifMatch, ifNoneMatch := c.String("if-match"), c.String("if-none-match")
+	if ifMatch == "" && ifNoneMatch == "" {
+		return nil
+	}
+
+	if onConflict == onConflictOverwrite || onConflict == onConflictRename {
+		return fmt.Errorf(`"on-conflict" flag cannot be %v with "if-match" or "if-none-match" flags`, onConflict)
+	}
+
+	if ifMatch != "" && ifNoneMatch != "" {
+		return fmt.Errorf(`"if-match" and "if-none-match" flags cannot be used together`)
+	}
+
+	switch {
+	case isUpload:
+		if ifNoneMatch != "" && ifNoneMatch != "*" {
+			return fmt.Errorf(`invalid value for "if-none-match" flag %q: the only allowed value for uploads is "*"`, ifNoneMatch)
+		}
+		if c.Bool("resume") {
+			return fmt.Errorf(`"if-match" and "if-none-match" flags cannot be used with "resume" flag`)
+		}
+		return nil
+	case isDownload && ifMatch == "":
+		return nil
+	case ifMatch != "":
+		return fmt.Errorf(`"if-match" flag can only be used for uploads`)
+	default:
+		return fmt.Errorf(`"if-none-match" flag can only be used for uploads and downloads`)
+	}
+}
+
+// validateUploadViaTemp validates the upload-via-temp flag.
+func validateUploadViaTemp(c *cli.Context, srcurl, dsturl *url.URL) error {
+	if !c.Bool("upload-via-temp") {
+		return nil
+	}
+
+	if srcurl.IsRemote() || !dsturl.IsRemote() {
+		return fmt.Errorf(`"upload-via-temp" flag can only be used for uploads`)
+	}
+	if c.Bool("resume") {
+		return fmt.Errorf(`"upload-via-temp" and "resume" flags cannot be used together`)
+	}
+	if c.String("if-match") != "" || c.String("if-none-match") != "" {
+		return fmt.Errorf(`"upload-via-temp" flag cannot be used with "if-match" or "if-none-match" flags`)
+	}
+	if c.String("checksum-algorithm") != "" {
+		return fmt.Errorf(`"upload-via-temp" and "checksum-algorithm" flags cannot be used together`)
+	}
+	return nil
+}
+
 func validateCopyCommand(c *cli.Context) error {
-	if c.Args().Len() != 2 {
+	if c.Args().Len() < 2 || (c.Args().Len() > 2 && c.Command.Name != "cp") {
 		return fmt.Errorf("expected source and destination arguments")
 	}
 
+	src := c.Args().First()
+	dsts := c.Args().Slice()[1:]
+
+	if len(dsts) > 1 {
+		if err := validateMultipleDestinations(c, src, dsts); err != nil {
+			return err
+		}
+	}
+
+	for _, dst := range dsts {
+		if err := validateCopyArguments(c, src, dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateDestTemplate validates the value of dest-template flag. The names
+// given by the template are relative to the destination, so the remote
+// destination must be a bucket or a prefix.
+func validateDestTemplate(c *cli.Context, dsturl *url.URL) error {
+	template := c.String("dest-template")
+	if template == "" {
+		return nil
+	}
+
+	if c.Bool("flatten") {
+		return fmt.Errorf(`"dest-template" and "flatten" flags cannot be used together`)
+	}
+
+	if dsturl.IsRemote() && !dsturl.IsPrefix() && !dsturl.IsBucket() {
+		return fmt.Errorf(`"dest-template" flag can only be used with a bucket or a prefix destination`)
+	}
+
+	// the tokens are validated by expanding the template with arbitrary
+	// sources. The templates without {base} token name all the objects of a
+	// directory the same, so that they overwrite each other.
+	name, err := expandDestTemplate(template, "dir/object")
+	if err != nil {
+		return fmt.Errorf(`invalid value for "dest-template" flag: %w`, err)
+	}
+	other, err := expandDestTemplate(template, "dir/other")
+	if err != nil {
+		return fmt.Errorf(`invalid value for "dest-template" flag: %w`, err)
+	}
+	if name == other {
+		return fmt.Errorf(`invalid value for "dest-template" flag: destination template %q must have {base} token to name each object differently`, template)
+	}
+	return nil
+}
+
+// validateMultipleDestinations validates copying a single source to multiple
+// destinations.
+func validateMultipleDestinations(c *cli.Context, src string, dsts []string) error {
+	srcurl, err := url.New(src, url.WithRaw(c.Bool("raw")))
+	if err != nil {
+		return err
+	}
+
+	if srcurl.IsWildcard() || c.String("source-inventory") != "" {
+		return fmt.Errorf("source must be a single object to copy it to multiple destinations")
+	}
+
+	for _, flag := range []string{"show-progress", "progress-json"} {
+		if c.Bool(flag) {
+			return fmt.Errorf(`%q flag cannot be used with multiple destinations`, flag)
+		}
+	}
+
+	if c.String("since-manifest") != "" {
+		return fmt.Errorf(`"since-manifest" flag cannot be used with multiple destinations`)
+	}
+
+	seen := map[string]bool{}
+	for _, dst := range dsts {
+		if seen[dst] {
+			return fmt.Errorf("destination %q is given more than once", dst)
+		}
+		seen[dst] = true
+	}
+	return nil
+}
+
+// validateCopyArguments validates copying the given source to the given
+// destination.
+func validateCopyArguments(c *cli.Context, src, dst string) error {
 	ctx := c.Context
-	src := c.Args().Get(0)
-	dst := c.Args().Get(1)
 
 	srcurl, err := url.New(src, url.WithVersion(c.String("version-id")),
 		url.WithRaw(c.Bool("raw")))
@@ -1037,11 +3134,165 @@ func validateCopyCommand(c *cli.Context) error {
 		return err
 	}
 
+	if err := validateSourceInventory(c, false, srcurl); err != nil {
+		return err
+	}
+
+	partSize, autoPartSize, err := parsePartSize(c.String("part-size"))
+	if err != nil {
+		return err
+	}
+
+	// the part size of the downloads and the server-side copies is not
+	// limited.
+	if c.IsSet("part-size") && !autoPartSize && !srcurl.IsRemote() && dsturl.IsRemote() {
+		if err := validateUploadPartSize(partSize); err != nil {
+			return err
+		}
+	}
+
+	if err := validateConditionalCopy(c, srcurl, dsturl); err != nil {
+		return err
+	}
+
+	if err := validateChecksumValidation(c); err != nil {
+		return err
+	}
+
+	if err := validateGrants(c); err != nil {
+		return err
+	}
+
+	if c.Bool("show-progress") && c.Bool("progress-json") {
+		return fmt.Errorf(`"show-progress" flag cannot be used with "progress-json" flag`)
+	}
+
+	if _, err := parseMaxObjectSize(c.String("max-object-size")); err != nil {
+		return err
+	}
+
+	if _, err := parseMultipartThreshold(c.String("multipart-threshold")); err != nil {
+		return err
+	}
+
+	if err := validateContentDisposition(c.String("content-disposition")); err != nil {
+		return err
+	}
+
+	if err := validateChecksumMetadataKey(c.String("checksum-metadata-key")); err != nil {
+		return err
+	}
+
+	if err := validateUploadViaTemp(c, srcurl, dsturl); err != nil {
+		return err
+	}
+
+	if c.Bool("dates-from-mtime") && !c.Bool("dereference-dates") {
+		return fmt.Errorf("dates-from-mtime flag can only be used with dereference-dates flag")
+	}
+
+	if c.Bool("dereference-dates") {
+		if _, err := expandDateTemplate(dst, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	if err := validateDestTemplate(c, dsturl); err != nil {
+		return err
+	}
+
+	if c.Bool("resume") && (srcurl.IsRemote() || !dsturl.IsRemote()) {
+		return fmt.Errorf("resume flag can only be used with uploads")
+	}
+
+	if err := validateChecksumAlgorithm(c, srcurl, dsturl); err != nil {
+		return err
+	}
+
+	if c.Bool("continue") {
+		if !srcurl.IsRemote() || dsturl.IsRemote() {
+			return fmt.Errorf(`"continue" flag can only be used for downloads`)
+		}
+		if c.String("checksum-mode") != "" {
+			return fmt.Errorf(`"continue" and "checksum-mode" flags cannot be used together`)
+		}
+	}
+
+	if c.Bool("keep-metadata") {
+		if !srcurl.IsRemote() || !dsturl.IsRemote() {
+			return fmt.Errorf(`"keep-metadata" flag can only be used for copies between remote storages`)
+		}
+		if c.String("metadata-directive") == metadataDirectiveCopy {
+			return fmt.Errorf(`"keep-metadata" flag cannot be used with "metadata-directive" COPY`)
+		}
+	}
+
+	if c.Int("part-retry") < 0 {
+		return fmt.Errorf(`"part-retry" flag must be a non-negative number`)
+	}
+
+	if isSameObject(srcurl, dsturl) && c.String("metadata-directive") == metadataDirectiveCopy {
+		return fmt.Errorf(`"metadata-directive" flag cannot be COPY when an object is copied onto itself`)
+	}
+
+	if c.Bool("preserve-storage-class") && (!srcurl.IsRemote() || !dsturl.IsRemote()) {
+		return fmt.Errorf(`"preserve-storage-class" flag can only be used for copies between remote storages`)
+	}
+
+	if c.IsSet("checksum-manifest-format") && c.String("write-checksum-manifest") == "" {
+		return fmt.Errorf(`"checksum-manifest-format" flag can only be used with "write-checksum-manifest" flag`)
+	}
+
+	if c.Bool("source-no-sign-request") && !srcurl.IsRemote() {
+		return fmt.Errorf(`"source-no-sign-request" flag can only be used with remote sources`)
+	}
+
+	if c.Bool("dest-no-sign-request") && !dsturl.IsRemote() {
+		return fmt.Errorf(`"dest-no-sign-request" flag can only be used with remote destinations`)
+	}
+
+	if c.String("source-profile") != "" {
+		if !srcurl.IsRemote() {
+			return fmt.Errorf(`"source-profile" flag can only be used with remote sources`)
+		}
+		if c.Bool("source-no-sign-request") {
+			return fmt.Errorf(`"source-profile" and "source-no-sign-request" flags cannot be used together`)
+		}
+	}
+
+	if c.String("dest-profile") != "" {
+		if !dsturl.IsRemote() {
+			return fmt.Errorf(`"dest-profile" flag can only be used with remote destinations`)
+		}
+		if c.Bool("dest-no-sign-request") {
+			return fmt.Errorf(`"dest-profile" and "dest-no-sign-request" flags cannot be used together`)
+		}
+	}
+
+	if c.Bool("metadata-merge") && (srcurl.IsRemote() || !dsturl.IsRemote()) {
+		return fmt.Errorf(`"metadata-merge" flag can only be used for uploads`)
+	}
+
+	if c.Bool("create-dir-markers") && (srcurl.IsRemote() || !dsturl.IsRemote()) {
+		return fmt.Errorf(`"create-dir-markers" flag can only be used for uploads`)
+	}
+
+	if c.Bool("symlink-as-object") {
+		if srcurl.Type == dsturl.Type {
+			return fmt.Errorf(`"symlink-as-object" flag can only be used with uploads and downloads`)
+		}
+		if c.Bool("no-follow-symlinks") {
+			return fmt.Errorf(`"symlink-as-object" and "no-follow-symlinks" flags cannot be used together`)
+		}
+	}
+
 	switch {
 	case srcurl.Type == dsturl.Type:
 		return validateCopy(srcurl, dsturl)
 	case dsturl.IsRemote():
-		return validateUpload(ctx, srcurl, dsturl, NewStorageOpts(c))
+		storageOpts := NewStorageOpts(c)
+		storageOpts.SymlinksAsObjects = c.Bool("symlink-as-object")
+		return validateUpload(ctx, srcurl, dsturl, storageOpts)
 	default:
 		return nil
 	}
@@ -1099,25 +3350,54 @@ type countingReaderWriter struct {
 	fp      *os.File
 	signMap map[int64]struct{}
 	mu      sync.Mutex
+
+	// filepb tracks the progress of the file of the source, if the progress
+	// of each file is shown.
+	filepb progressbar.FileProgressBar
+	src    string
+
+	// transfer limits the bandwidth of the file, if --rate-limit is set.
+	transfer *parallel.Transfer
 }
 
-func newCountingReaderWriter(file *os.File, pb progressbar.ProgressBar) *countingReaderWriter {
+func newCountingReaderWriter(file *os.File, pb progressbar.ProgressBar, src *url.URL) *countingReaderWriter {
+	filepb, _ := pb.(progressbar.FileProgressBar)
 	return &countingReaderWriter{
 		pb:      pb,
 		fp:      file,
 		signMap: map[int64]struct{}{},
+		filepb:  filepb,
+		src:     src.String(),
+		// each file gets an equal share of the bandwidth, regardless of its
+		// size or the number of its parts in flight.
+		transfer: parallel.StartTransfer(1),
+	}
+}
+
+// done finishes the transfer of the file, so that its share of the bandwidth
+// is shared by the other files.
+func (r *countingReaderWriter) done() {
+	r.transfer.Done()
+}
+
+func (r *countingReaderWriter) addCompletedBytes(n int64) {
+	r.pb.AddCompletedBytes(n)
+	if r.filepb != nil {
+		r.filepb.AddFileBytes(r.src, n)
 	}
 }
 
 func (r *countingReaderWriter) WriteAt(p []byte, off int64) (int, error) {
+	r.transfer.Wait(int64(len(p)))
 	n, err := r.fp.WriteAt(p, off)
-	r.pb.AddCompletedBytes(int64(n))
+	r.addCompletedBytes(int64(n))
 	return n, err
 }
 
 func (r *countingReaderWriter) Read(p []byte) (int, error) {
 	n, err := r.fp.Read(p)
-	r.pb.AddCompletedBytes(int64(n))
+	r.transfer.Wait(int64(n))
+	r.addCompletedBytes(int64(n))
 	return n, err
 }
 
@@ -1125,13 +3405,17 @@ func (r *countingReaderWriter) ReadAt(p []byte, off int64) (int, error) {
 	n, err := r.fp.ReadAt(p, off)
 	r.mu.Lock()
 	// Ignore the first signature call
-	if _, ok := r.signMap[off]; ok {
-		// Got the length have read (or means has uploaded)
-		r.pb.AddCompletedBytes(int64(n))
-	} else {
+	_, uploading := r.signMap[off]
+	if !uploading {
 		r.signMap[off] = struct{}{}
 	}
 	r.mu.Unlock()
+
+	if uploading {
+		// Got the length have read (or means has uploaded)
+		r.transfer.Wait(int64(n))
+		r.addCompletedBytes(int64(n))
+	}
 	return n, err
 }
 
@@ -1,11 +1,20 @@
 package command
 
 import (
+	"context"
 	"io"
 	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
 
+	"go.uber.org/mock/gomock"
 	"gotest.tools/v3/assert"
+
+	errorpkg "github.com/peak/s5cmd/v2/error"
+	"github.com/peak/s5cmd/v2/log"
+	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/storage/url"
 )
 
 func TestGuessContentType(t *testing.T) {
@@ -77,3 +86,460 @@ func TestGuessContentType(t *testing.T) {
 		os.Remove(f.Name())
 	}
 }
+
+func TestMergeMetadata(t *testing.T) {
+	t.Parallel()
+
+	src := storage.Metadata{
+		CacheControl:       "public, max-age=345600",
+		Expires:            "2024-10-01T20:30:00Z",
+		ContentType:        "text/plain",
+		ContentEncoding:    "gzip",
+		ContentDisposition: "inline",
+		ContentLanguage:    "en-US",
+		EncryptionMethod:   "AES256",
+		UserDefined:        map[string]string{"Key1": "value1", "Key2": "value2"},
+	}
+
+	metadata := storage.Metadata{
+		ContentType:  "text/html",
+		StorageClass: "STANDARD_IA",
+		UserDefined:  map[string]string{"key2": "foo"},
+		Directive:    metadataDirectiveReplace,
+	}
+
+	expected := storage.Metadata{
+		CacheControl:       "public, max-age=345600",
+		Expires:            "2024-10-01T20:30:00Z",
+		ContentType:        "text/html",
+		ContentEncoding:    "gzip",
+		ContentDisposition: "inline",
+		ContentLanguage:    "en-US",
+		StorageClass:       "STANDARD_IA",
+		UserDefined:        map[string]string{"key1": "value1", "key2": "foo"},
+		Directive:          metadataDirectiveReplace,
+	}
+
+	assert.DeepEqual(t, mergeMetadata(src, metadata), expected)
+}
+
+func TestValidateContentDisposition(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		value   string
+		wantErr bool
+	}{
+		{value: ""},
+		{value: "inline"},
+		{value: "attachment"},
+		{value: `attachment; filename="file name.jpg"`},
+		{value: "attachment; filename*=UTF-8''n%C3%A4ive.txt"},
+		{value: "attachment;"},
+		{value: "; filename=file.jpg", wantErr: true},
+		{value: `attachment; filename="file.jpg`, wantErr: true},
+		{value: "attachment filename=file.jpg", wantErr: true},
+	}
+
+	for _, tc := range testcases {
+		err := validateContentDisposition(tc.value)
+		if tc.wantErr {
+			assert.ErrorContains(t, err, `invalid value for "content-disposition" flag`, tc.value)
+			continue
+		}
+		assert.NilError(t, err, tc.value)
+	}
+}
+
+func TestMergeUserMetadata(t *testing.T) {
+	t.Parallel()
+
+	existing := map[string]string{"Owner": "team-a", "Revision": "1"}
+	metadata := map[string]string{"revision": "2", "source": "local"}
+
+	expected := map[string]string{"owner": "team-a", "revision": "2", "source": "local"}
+
+	assert.DeepEqual(t, mergeUserMetadata(existing, metadata), expected)
+	assert.DeepEqual(t, mergeUserMetadata(nil, nil), map[string]string{})
+}
+
+func TestSymlinkTarget(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name        string
+		userDefined map[string]string
+		expected    string
+		expectedOk  bool
+	}{
+		{
+			name:        "lowercase key",
+			userDefined: map[string]string{"s5cmd-symlink-target": "../file.txt"},
+			expected:    "../file.txt",
+			expectedOk:  true,
+		},
+		{
+			name:        "canonical key",
+			userDefined: map[string]string{"S5cmd-Symlink-Target": "file.txt", "Key": "value"},
+			expected:    "file.txt",
+			expectedOk:  true,
+		},
+		{
+			name:        "no key",
+			userDefined: map[string]string{"Key": "value"},
+		},
+		{
+			name: "no metadata",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			target, ok := symlinkTarget(tc.userDefined)
+			assert.Equal(t, ok, tc.expectedOk)
+			assert.Equal(t, target, tc.expected)
+		})
+	}
+}
+
+func TestCopySkipIfMatches(t *testing.T) {
+	// the skipped objects are logged.
+	log.Init("error", false)
+
+	// the MD5 of "content".
+	const contentMD5 = "9a0364b9e99bb480dd25e1f0284c8555"
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	assert.NilError(t, os.WriteFile(path, []byte("content"), 0o644))
+
+	localURL, err := url.New(path)
+	assert.NilError(t, err)
+	remoteURL, err := url.New("s3://bucket/file.txt")
+	assert.NilError(t, err)
+
+	testcases := []struct {
+		name            string
+		src             *storage.Object
+		dst             *storage.Object
+		expected        error
+		expectedSkipped int64
+	}{
+		{
+			name:            "etag matches",
+			src:             &storage.Object{URL: localURL, Size: 7},
+			dst:             &storage.Object{URL: remoteURL, Size: 7, Etag: `"` + contentMD5 + `"`},
+			expected:        errorpkg.ErrObjectEtagsMatch,
+			expectedSkipped: 1,
+		},
+		{
+			name: "etag does not match",
+			src:  &storage.Object{URL: localURL, Size: 7},
+			dst:  &storage.Object{URL: remoteURL, Size: 7, Etag: "0f343b0931126a20f133d67c2b018a3b"},
+		},
+		{
+			name: "sizes are different",
+			src:  &storage.Object{URL: localURL, Size: 7},
+			dst:  &storage.Object{URL: remoteURL, Size: 5, Etag: contentMD5},
+		},
+		{
+			name:            "multipart destination, sizes match",
+			src:             &storage.Object{URL: localURL, Size: 7},
+			dst:             &storage.Object{URL: remoteURL, Size: 7, Etag: `"d41d8cd98f00b204e9800998ecf8427e-2"`},
+			expected:        errorpkg.ErrObjectSizesMatch,
+			expectedSkipped: 1,
+		},
+		{
+			name: "multipart destination, sizes are different",
+			src:  &storage.Object{URL: localURL, Size: 7},
+			dst:  &storage.Object{URL: remoteURL, Size: 8, Etag: "d41d8cd98f00b204e9800998ecf8427e-2"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := Copy{op: "cp", skipIfEtagMatches: true, skipped: &atomic.Int64{}}
+
+			err := c.skipIfMatches(context.Background(), tc.src, tc.dst, tc.src.URL, tc.dst.URL)
+			assert.Equal(t, err, tc.expected)
+			assert.Equal(t, c.skipped.Load(), tc.expectedSkipped)
+		})
+	}
+}
+
+func TestRenameURL(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		url      string
+		suffix   string
+		n        int
+		expected string
+	}{
+		{
+			name:     "remote object with extension",
+			url:      "s3://bucket/dir/name.txt",
+			suffix:   defaultRenameSuffix,
+			n:        1,
+			expected: "s3://bucket/dir/name-1.txt",
+		},
+		{
+			name:     "remote object with multiple extensions",
+			url:      "s3://bucket/archive.tar.gz",
+			suffix:   defaultRenameSuffix,
+			n:        2,
+			expected: "s3://bucket/archive.tar-2.gz",
+		},
+		{
+			name:     "remote object without extension",
+			url:      "s3://bucket/name",
+			suffix:   " ({n})",
+			n:        3,
+			expected: "s3://bucket/name (3)",
+		},
+		{
+			name:     "dotfile",
+			url:      "s3://bucket/dir/.env",
+			suffix:   ".{n}",
+			n:        1,
+			expected: "s3://bucket/dir/.env.1",
+		},
+		{
+			name:     "local file",
+			url:      filepath.Join("dir", "name.txt"),
+			suffix:   "_copy{n}",
+			n:        1,
+			expected: filepath.Join("dir", "name_copy1.txt"),
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := url.New(tc.url)
+			assert.NilError(t, err)
+
+			assert.Equal(t, renameURL(u, tc.suffix, tc.n).String(), tc.expected)
+		})
+	}
+}
+
+func TestUploadPartSize(t *testing.T) {
+	log.Init("error", false)
+
+	const partSize = 5 * megabytes
+
+	srcurl, err := url.New("file.bin")
+	assert.NilError(t, err)
+
+	testcases := []struct {
+		name      string
+		size      int64
+		threshold int64
+		expected  int64
+	}{
+		{name: "no threshold", size: 8 * megabytes, expected: partSize},
+		{name: "smaller than part size", size: megabytes, threshold: 10 * megabytes, expected: partSize},
+		{name: "just under threshold", size: 10*megabytes - 1, threshold: 10 * megabytes, expected: 10*megabytes - 1},
+		{name: "at threshold", size: 10 * megabytes, threshold: 10 * megabytes, expected: partSize},
+		{name: "over threshold", size: 10*megabytes + 1, threshold: 10 * megabytes, expected: partSize},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, uploadPartSize(srcurl, tc.size, partSize, tc.threshold), tc.expected)
+		})
+	}
+}
+
+func TestValidateUploadPartSize(t *testing.T) {
+	t.Parallel()
+
+	assert.NilError(t, validateUploadPartSize(5*megabytes))
+	assert.NilError(t, validateUploadPartSize(50*megabytes))
+
+	for _, partSize := range []int64{0, megabytes, 5*megabytes - 1} {
+		err := validateUploadPartSize(partSize)
+		assert.ErrorContains(t, err, "the parts of the multipart uploads must be at least 5 MiB, use --part-size 5 or larger")
+	}
+}
+
+func TestValidateUploadPartCount(t *testing.T) {
+	t.Parallel()
+
+	assert.NilError(t, validateUploadPartCount(0, 5*megabytes))
+	assert.NilError(t, validateUploadPartCount(10000*5*megabytes, 5*megabytes))
+
+	err := validateUploadPartCount(10000*5*megabytes+1, 5*megabytes)
+	assert.Error(t, err, "part size 5 MiB is too small for an object of 52428800001 bytes: it needs 10001 parts but at most 10000 parts are allowed, use --part-size 6 or larger, or --part-size auto")
+
+	err = validateUploadPartCount(1024*1024*megabytes, 50*megabytes)
+	assert.ErrorContains(t, err, "it needs 20972 parts but at most 10000 parts are allowed, use --part-size 105 or larger")
+}
+
+func TestCopyCrossAccount(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		copy     Copy
+		expected bool
+	}{
+		{
+			name:     "same credentials",
+			copy:     Copy{storageOpts: storage.Options{Profile: "p1"}},
+			expected: false,
+		},
+		{
+			name:     "same profile on both sides",
+			copy:     Copy{storageOpts: storage.Options{Profile: "p1"}, srcProfile: "p1"},
+			expected: false,
+		},
+		{
+			name:     "source profile",
+			copy:     Copy{srcProfile: "source"},
+			expected: true,
+		},
+		{
+			name:     "different profiles",
+			copy:     Copy{srcProfile: "source", dstProfile: "destination"},
+			expected: true,
+		},
+		{
+			name:     "anonymous source",
+			copy:     Copy{srcNoSignRequest: true, dstProfile: "destination"},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.copy.crossAccount(), tc.expected)
+		})
+	}
+}
+
+func TestCopyVerifyDestination(t *testing.T) {
+	log.Init("error", false)
+
+	srcurl, _ := url.New("s3://bucket/source")
+	dsturl, _ := url.New("s3://bucket/destination")
+
+	const (
+		md5a = "0cc175b9c0f1b6a831c399e269772661"
+		md5b = "92eb5ffee6ae2fec3ad71c777531578f"
+	)
+
+	testcases := []struct {
+		name           string
+		verifyChecksum bool
+		src            *storage.Object
+		dst            *storage.Object
+		statErr        error
+		expectedErr    string
+	}{
+		{
+			name:        "missing destination",
+			src:         &storage.Object{URL: srcurl, Size: 10},
+			statErr:     &storage.ErrGivenObjectNotFound{ObjectAbsPath: dsturl.Absolute()},
+			expectedErr: "s3://bucket/destination is not found after it's written, the source is not deleted",
+		},
+		{
+			name:        "size differs",
+			src:         &storage.Object{URL: srcurl, Size: 10},
+			dst:         &storage.Object{URL: dsturl, Size: 5},
+			expectedErr: "s3://bucket/destination is 5 bytes instead of 10 bytes of the source, the source is not deleted",
+		},
+		{
+			name: "size matches",
+			src:  &storage.Object{URL: srcurl, Size: 10, Etag: md5a},
+			dst:  &storage.Object{URL: dsturl, Size: 10, Etag: md5b},
+		},
+		{
+			name:           "checksum differs",
+			verifyChecksum: true,
+			src:            &storage.Object{URL: srcurl, Size: 10, Etag: md5a},
+			dst:            &storage.Object{URL: dsturl, Size: 10, Etag: md5b},
+			expectedErr:    "checksum of s3://bucket/destination differs from the source, the source is not deleted",
+		},
+		{
+			name:           "checksum matches",
+			verifyChecksum: true,
+			src:            &storage.Object{URL: srcurl, Size: 10, Etag: md5a},
+			dst:            &storage.Object{URL: dsturl, Size: 10, Etag: md5a},
+		},
+		{
+			name:           "multipart upload is verified by size",
+			verifyChecksum: true,
+			src:            &storage.Object{URL: srcurl, Size: 10, Etag: md5a},
+			dst:            &storage.Object{URL: dsturl, Size: 10, Etag: md5b + "-2"},
+		},
+		{
+			name: "existence only",
+			dst:  &storage.Object{URL: dsturl},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			client := storage.NewMockStorage(ctrl)
+			client.EXPECT().Stat(gomock.Any(), dsturl).Times(1).Return(tc.dst, tc.statErr)
+
+			c := Copy{
+				op:                 "mv",
+				verifyBeforeDelete: true,
+				verifyChecksum:     tc.verifyChecksum,
+			}
+
+			err := c.verifyDestination(context.Background(), client, dsturl, tc.src)
+			if tc.expectedErr != "" {
+				assert.Error(t, err, tc.expectedErr)
+				return
+			}
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestCopyVerifyDestinationDisabled(t *testing.T) {
+	dsturl, _ := url.New("s3://bucket/destination")
+
+	// the destination is not read unless verify-before-delete flag is given,
+	// or in dry run mode.
+	for _, c := range []Copy{
+		{},
+		{verifyBeforeDelete: true, storageOpts: storage.Options{DryRun: true}},
+	} {
+		ctrl := gomock.NewController(t)
+		client := storage.NewMockStorage(ctrl)
+
+		assert.NilError(t, c.verifyDestination(context.Background(), client, dsturl, nil))
+	}
+}
+
+func TestParseMultipartThreshold(t *testing.T) {
+	t.Parallel()
+
+	size, err := parseMultipartThreshold("")
+	assert.NilError(t, err)
+	assert.Equal(t, size, int64(0))
+
+	size, err = parseMultipartThreshold("100MB")
+	assert.NilError(t, err)
+	assert.Equal(t, size, int64(100*megabytes))
+
+	for _, value := range []string{"6GB", "-1", "ten"} {
+		_, err := parseMultipartThreshold(value)
+		assert.ErrorContains(t, err, `invalid value for "multipart-threshold" flag`)
+	}
+}
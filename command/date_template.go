@@ -0,0 +1,70 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateTokens are the tokens that can be used in destination URLs when
+// --dereference-dates flag is set. The time is formatted in UTC.
+var dateTokens = map[string]func(t time.Time) string{
+	"YYYY": func(t time.Time) string { return t.Format("2006") },
+	"MM":   func(t time.Time) string { return t.Format("01") },
+	"DD":   func(t time.Time) string { return t.Format("02") },
+	"HH":   func(t time.Time) string { return t.Format("15") },
+	"ts":   func(t time.Time) string { return strconv.FormatInt(t.Unix(), 10) },
+}
+
+// expandDateTemplate replaces the date tokens, e.g. {YYYY}, in the given
+// template with the corresponding values of the given time. Literal braces
+// are escaped by doubling them, e.g. "{{" and "}}".
+func expandDateTemplate(template string, t time.Time) (string, error) {
+	t = t.UTC()
+
+	return expandTokens(template, "date", "{YYYY}, {MM}, {DD}, {HH} and {ts}", func(token string) (string, bool) {
+		format, ok := dateTokens[token]
+		if !ok {
+			return "", false
+		}
+		return format(t), true
+	})
+}
+
+// expandTokens replaces the tokens in braces, e.g. {token}, in the given
+// template with the values returned by the given function, which reports
+// whether the token is known. Literal braces are escaped by doubling them.
+// kind and valid are used to describe the tokens in the errors.
+func expandTokens(template, kind, valid string, value func(token string) (string, bool)) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(template); i++ {
+		ch := template[i]
+		switch {
+		case ch == '{' && strings.HasPrefix(template[i:], "{{"):
+			sb.WriteByte('{')
+			i++
+		case ch == '}' && strings.HasPrefix(template[i:], "}}"):
+			sb.WriteByte('}')
+			i++
+		case ch == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated %s token in %q", kind, template)
+			}
+
+			token := template[i+1 : i+end]
+			v, ok := value(token)
+			if !ok {
+				return "", fmt.Errorf("unknown %s token %q in %q: valid tokens are %s", kind, token, template, valid)
+			}
+			sb.WriteString(v)
+			i += end
+		case ch == '}':
+			return "", fmt.Errorf("unexpected '}' in %q: use '}}' for a literal brace", template)
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,77 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestExpandDateTemplate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, time.March, 7, 9, 30, 15, 0, time.UTC)
+
+	testcases := []struct {
+		name     string
+		template string
+		expected string
+		err      string
+	}{
+		{
+			name:     "no tokens",
+			template: "s3://bucket/logs/",
+			expected: "s3://bucket/logs/",
+		},
+		{
+			name:     "all tokens",
+			template: "s3://bucket/logs/{YYYY}/{MM}/{DD}/{HH}/{ts}.log",
+			expected: "s3://bucket/logs/2023/03/07/09/1678181415.log",
+		},
+		{
+			name:     "escaped braces",
+			template: "s3://bucket/{{YYYY}}/{YYYY}}}",
+			expected: "s3://bucket/{YYYY}/2023}",
+		},
+		{
+			name:     "unknown token",
+			template: "s3://bucket/{yyyy}/",
+			err:      `unknown date token "yyyy"`,
+		},
+		{
+			name:     "unterminated token",
+			template: "s3://bucket/{YYYY/",
+			err:      "unterminated date token",
+		},
+		{
+			name:     "unescaped closing brace",
+			template: "s3://bucket/YYYY}/",
+			err:      "unexpected '}'",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := expandDateTemplate(tc.template, now)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, got, tc.expected)
+		})
+	}
+}
+
+func TestExpandDateTemplateUsesUTC(t *testing.T) {
+	t.Parallel()
+
+	local := time.Date(2023, time.March, 7, 1, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60))
+
+	got, err := expandDateTemplate("{DD}/{HH}", local)
+	assert.NilError(t, err)
+	assert.Equal(t, got, "06/22")
+}
@@ -0,0 +1,56 @@
+package command
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// destTemplateTokens are the tokens that can be used in the value of
+// --dest-template flag, which are replaced with the parts of the name of the
+// source object relative to the source, e.g. "2020/01/app.log".
+var destTemplateTokens = map[string]func(relative string) string{
+	// base is the name of the source object.
+	"base": path.Base,
+	// dir is the parent path of the source object. It's empty for the
+	// objects directly under the source prefix.
+	"dir": func(relative string) string {
+		dir := path.Dir(relative)
+		if dir == "." || dir == "/" {
+			return ""
+		}
+		return dir
+	},
+}
+
+// expandDestTemplate returns the name of the destination object of the source
+// object with the given relative name by replacing the {base} and {dir} tokens
+// of the given template. The empty path segments are removed, e.g.
+// "archive/{dir}/{base}" is expanded to "archive/a.txt" for the objects
+// directly under the source prefix.
+func expandDestTemplate(template, relative string) (string, error) {
+	relative = filepath.ToSlash(relative)
+
+	name, err := expandTokens(template, "destination template", "{base} and {dir}", func(token string) (string, bool) {
+		value, ok := destTemplateTokens[token]
+		if !ok {
+			return "", false
+		}
+		return value(relative), true
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(name, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) == 0 {
+		return "", fmt.Errorf("destination template %q expands to an empty name for %q", template, relative)
+	}
+	return strings.Join(segments, "/"), nil
+}
@@ -0,0 +1,83 @@
+package command
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestExpandDestTemplate(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		template string
+		relative string
+		expected string
+		err      string
+	}{
+		{
+			name:     "nested object",
+			template: "archive/{dir}/{base}",
+			relative: "2020/01/app.log",
+			expected: "archive/2020/01/app.log",
+		},
+		{
+			name:     "object directly under the source",
+			template: "archive/{dir}/{base}",
+			relative: "app.log",
+			expected: "archive/app.log",
+		},
+		{
+			name:     "base before dir",
+			template: "{base}/{dir}.copy",
+			relative: "2020/app.log",
+			expected: "app.log/2020.copy",
+		},
+		{
+			name:     "no tokens",
+			template: "archive/latest.log",
+			relative: "2020/app.log",
+			expected: "archive/latest.log",
+		},
+		{
+			name:     "escaped braces",
+			template: "{{dir}}/{base}",
+			relative: "2020/app.log",
+			expected: "{dir}/app.log",
+		},
+		{
+			name:     "unknown token",
+			template: "{dir}/{name}",
+			relative: "2020/app.log",
+			err:      `unknown destination template token "name"`,
+		},
+		{
+			name:     "unterminated token",
+			template: "{dir}/{base",
+			relative: "2020/app.log",
+			err:      "unterminated destination template token",
+		},
+		{
+			name:     "empty name",
+			template: "/{dir}/",
+			relative: "app.log",
+			err:      "expands to an empty name",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := expandDestTemplate(tc.template, tc.relative)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, got, tc.expected)
+		})
+	}
+}
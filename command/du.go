@@ -1,8 +1,15 @@
 package command
 
 import (
+	"container/heap"
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
 
 	urlpkg "net/url"
 
@@ -21,7 +28,7 @@ var sizeHelpTemplate = `Name:
 	{{.HelpName}} - {{.Usage}}
 
 Usage:
-	{{.HelpName}} [options] argument
+	{{.HelpName}} [options] argument [argument ...]
 
 Options:
 	{{range .VisibleFlags}}{{.}}
@@ -47,6 +54,24 @@ Examples:
 
 	7. Show disk usage of a specific version of an object in the bucket
 		 > s5cmd {{.HelpName}} --version-id VERSION_ID s3://bucket/object
+
+	8. Show disk usage of all objects in a huge bucket using its S3 Inventory report instead of listing it
+		 > s5cmd {{.HelpName}} --source-inventory s3://inventory-bucket/bucket/config/2023-01-01T00-00Z/manifest.json "s3://bucket/*"
+
+	9. Estimate disk usage of all objects in a huge bucket by listing only the first 10 pages (10000 objects)
+		 > s5cmd {{.HelpName}} --sample 10 "s3://bucket/*"
+
+	10. Show disk usage of each group of objects whose keys are delimited by "|" under a prefix
+		 > s5cmd {{.HelpName}} --delimiter "|" "s3://bucket/logs|"
+
+	11. Show the 20 largest objects under a prefix along with the disk usage of all objects
+		 > s5cmd {{.HelpName}} --top 20 "s3://bucket/prefix/*"
+
+	12. Show the number and size of the objects of each storage class under a prefix in a table
+		 > s5cmd {{.HelpName}} --by-storage-class --humanize "s3://bucket/prefix/*"
+
+	13. Show disk usage of the objects under each of the given prefixes, along with their grand total
+		 > s5cmd {{.HelpName}} "s3://bucket/a/*" "s3://bucket/b/*" "s3://bucket/c/*"
 `
 
 func NewSizeCommand() *cli.Command {
@@ -78,11 +103,31 @@ func NewSizeCommand() *cli.Command {
 				Name:  "version-id",
 				Usage: "use the specified version of an object",
 			},
+			&cli.StringFlag{
+				Name:  "source-inventory",
+				Usage: "read the source objects from the CSV or Parquet S3 Inventory report of the given manifest instead of listing them, e.g. s3://bucket/inventory/manifest.json",
+			},
+			&cli.IntFlag{
+				Name:  "sample",
+				Usage: "estimate disk usage by listing only the first given number of pages (1000 objects each, or --page-size) instead of all objects",
+			},
+			&cli.StringFlag{
+				Name:  "delimiter",
+				Usage: "show disk usage of each common prefix of the keys which ends with the given delimiter, e.g. --delimiter '/'",
+			},
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "list the given number of largest objects along with the disk usage",
+			},
+			&cli.BoolFlag{
+				Name:  "by-storage-class",
+				Usage: "show the number and size of the objects of each storage class in a table, along with the totals",
+			},
 		},
 		Before: func(c *cli.Context) error {
 			err := validateDUCommand(c)
 			if err != nil {
-				printError(commandFromContext(c), c.Command.Name, err)
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
 			}
 			return err
 		},
@@ -91,22 +136,37 @@ func NewSizeCommand() *cli.Command {
 
 			fullCommand := commandFromContext(c)
 
-			srcurl, err := url.New(c.Args().First(),
-				url.WithAllVersions(c.Bool("all-versions")),
-				url.WithVersion(c.String("version-id")))
-			if err != nil {
-				printError(fullCommand, c.Command.Name, err)
-				return err
+			var srcurls []*url.URL
+			for _, arg := range c.Args().Slice() {
+				srcurl, err := url.New(arg,
+					url.WithAllVersions(c.Bool("all-versions")),
+					url.WithVersion(c.String("version-id")))
+				if err != nil {
+					printError(c.Context, fullCommand, c.Command.Name, err)
+					return err
+				}
+				srcurls = append(srcurls, srcurl)
+			}
+
+			var sources []*url.URL
+			if len(srcurls) > 1 {
+				sources = srcurls
 			}
 
 			return Size{
-				src:         srcurl,
+				src:         srcurls[0],
+				sources:     sources,
 				op:          c.Command.Name,
 				fullCommand: fullCommand,
 				// flags
-				groupByClass: c.Bool("group"),
-				humanize:     c.Bool("humanize"),
-				exclude:      c.StringSlice("exclude"),
+				groupByClass:    c.Bool("group"),
+				humanize:        c.Bool("humanize"),
+				exclude:         c.StringSlice("exclude"),
+				sourceInventory: c.String("source-inventory"),
+				sample:          c.Int("sample"),
+				delimiter:       c.String("delimiter"),
+				top:             c.Int("top"),
+				byStorageClass:  c.Bool("by-storage-class"),
 
 				storageOpts: NewStorageOpts(c),
 			}.Run(c.Context)
@@ -119,108 +179,488 @@ func NewSizeCommand() *cli.Command {
 
 // Size holds disk usage (du) operation flags and states.
 type Size struct {
-	src         *url.URL
+	src *url.URL
+	// sources are all the sources if more than one is given. Their totals
+	// are printed along with their grand total.
+	sources     []*url.URL
 	op          string
 	fullCommand string
 
 	// flags
-	groupByClass bool
-	humanize     bool
-	exclude      []string
+	groupByClass    bool
+	humanize        bool
+	exclude         []string
+	sourceInventory string
+	sample          int
+	delimiter       string
+	top             int
+	byStorageClass  bool
 
 	storageOpts storage.Options
 }
 
 // Run calculates disk usage of given source.
 func (sz Size) Run(ctx context.Context) error {
+	if len(sz.sources) > 0 {
+		return sz.runMultiple(ctx)
+	}
+
+	if sz.sample > 0 {
+		return sz.runSample(ctx)
+	}
+
 	client, err := storage.NewClient(ctx, sz.src, sz.storageOpts)
 	if err != nil {
-		printError(sz.fullCommand, sz.op, err)
+		printError(ctx, sz.fullCommand, sz.op, err)
 		return err
 	}
 
-	storageTotal := map[string]sizeAndCount{}
-	total := sizeAndCount{}
+	// the totals of the objects keyed by their common prefixes, or only
+	// the totals of the source if no delimiter is given.
+	groups := map[string]*sizeTotals{}
 
 	var merror error
 
 	excludePatterns, err := createRegexFromWildcard(sz.exclude)
 	if err != nil {
-		printError(sz.fullCommand, sz.op, err)
+		printError(ctx, sz.fullCommand, sz.op, err)
 		return err
 	}
 
-	for object := range client.List(ctx, sz.src, false) {
+	srcurl := sz.src
+	if sz.delimiter != "" {
+		// all objects under the prefix are listed to total the groups.
+		srcurl = sz.src.Clone()
+		srcurl.Delimiter = ""
+	}
+
+	var top *topObjects
+	if sz.top > 0 {
+		top = newTopObjects(sz.top)
+	}
+
+	var objch <-chan *storage.Object
+	if sz.sourceInventory != "" {
+		objch, err = expandInventory(ctx, sz.storageOpts, sz.sourceInventory, srcurl)
+		if err != nil {
+			printError(ctx, sz.fullCommand, sz.op, err)
+			return err
+		}
+	} else {
+		objch = client.List(ctx, srcurl, false)
+	}
+
+	for object := range objch {
 		if object.Type.IsDir() || errorpkg.IsCancelation(object.Err) {
 			continue
 		}
 
 		if err := object.Err; err != nil {
 			merror = multierror.Append(merror, err)
-			printError(sz.fullCommand, sz.op, err)
+			printError(ctx, sz.fullCommand, sz.op, err)
 			continue
 		}
 
-		if isURLMatched(excludePatterns, object.URL.Path, sz.src.Prefix) {
+		if isURLMatched(excludePatterns, object.URL.Path, sz.src) {
 			continue
 		}
 
-		storageClass := string(object.StorageClass)
-		s := storageTotal[storageClass]
-		s.addObject(object)
-		storageTotal[storageClass] = s
+		var group string
+		if sz.delimiter != "" {
+			group = commonPrefix(sz.src.Prefix, object.URL.Path, sz.delimiter)
+		}
 
-		total.addObject(object)
+		totals, ok := groups[group]
+		if !ok {
+			totals = newSizeTotals()
+			groups[group] = totals
+		}
+		totals.addObject(object)
+		top.add(object)
 	}
 
-	if !sz.groupByClass {
-		msg := SizeMessage{
+	if len(groups) == 0 {
+		groups[""] = newSizeTotals()
+	}
+
+	if top != nil {
+		totals := groups[""]
+		log.InfoContext(ctx, SizeMessage{
 			Source:        sz.src.String(),
-			Count:         total.count,
-			Size:          total.size,
+			Count:         totals.total.count,
+			Size:          totals.total.size,
+			Top:           top.sorted(),
 			showHumanized: sz.humanize,
+		})
+		return merror
+	}
+
+	prefixes := make([]string, 0, len(groups))
+	for prefix := range groups {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	for _, prefix := range prefixes {
+		// the objects which are not under a common prefix are totaled
+		// under the source.
+		source := sz.src.String()
+		if prefix != "" {
+			groupurl := sz.src.Clone()
+			groupurl.Path = prefix
+			source = groupurl.String()
 		}
-		log.Info(msg)
+		sz.printTotals(ctx, source, groups[prefix])
+	}
+
+	if !sz.groupByClass {
 		return nil
 	}
+	return merror
+}
 
-	for k, v := range storageTotal {
+// printTotals prints the totals of the given source, by storage class if
+// asked.
+func (sz Size) printTotals(ctx context.Context, source string, totals *sizeTotals) {
+	if sz.byStorageClass {
+		log.InfoContext(ctx, newStorageClassSizeMessage(source, totals, sz.humanize))
+		return
+	}
+
+	if !sz.groupByClass {
 		msg := SizeMessage{
-			Source:        sz.src.String(),
+			Source:        source,
+			Count:         totals.total.count,
+			Size:          totals.total.size,
+			showHumanized: sz.humanize,
+		}
+		log.InfoContext(ctx, msg)
+		return
+	}
+
+	for k, v := range totals.storageTotal {
+		msg := SizeMessage{
+			Source:        source,
 			StorageClass:  k,
 			Count:         v.count,
 			Size:          v.size,
 			showHumanized: sz.humanize,
 		}
-		log.Info(msg)
+		log.InfoContext(ctx, msg)
+	}
+}
+
+// runMultiple calculates disk usage of each of the given sources, which are
+// listed concurrently, and prints their totals along with the grand total.
+func (sz Size) runMultiple(ctx context.Context) error {
+	excludePatterns, err := createRegexFromWildcard(sz.exclude)
+	if err != nil {
+		printError(ctx, sz.fullCommand, sz.op, err)
+		return err
+	}
+
+	totals := make([]sizeAndCount, len(sz.sources))
+	errs := make([]error, len(sz.sources))
+
+	var wg sync.WaitGroup
+	for i, src := range sz.sources {
+		wg.Add(1)
+		go func(i int, src *url.URL) {
+			defer wg.Done()
+			totals[i], errs[i] = sz.total(ctx, src, excludePatterns)
+		}(i, src)
+	}
+	wg.Wait()
+
+	msg := MultipleSizeMessage{
+		Sources:       make([]SizeMessage, 0, len(sz.sources)),
+		showHumanized: sz.humanize,
+	}
+	var merror error
+	for i, src := range sz.sources {
+		if errs[i] != nil {
+			merror = multierror.Append(merror, errs[i])
+		}
+		msg.Sources = append(msg.Sources, SizeMessage{
+			Source:        src.String(),
+			Count:         totals[i].count,
+			Size:          totals[i].size,
+			showHumanized: sz.humanize,
+		})
+		msg.Count += totals[i].count
+		msg.Size += totals[i].size
+	}
+
+	log.InfoContext(ctx, msg)
+	return merror
+}
+
+// total returns the total size and count of the objects of the given source.
+// A source without any objects is totaled as zero. The listing errors are
+// printed as they occur, and they are returned together once the listing is
+// done.
+func (sz Size) total(ctx context.Context, src *url.URL, excludePatterns []*regexp.Regexp) (sizeAndCount, error) {
+	var total sizeAndCount
+
+	client, err := storage.NewClient(ctx, src, sz.storageOpts)
+	if err != nil {
+		printError(ctx, sz.fullCommand, sz.op, err)
+		return total, err
+	}
+
+	var merror error
+	for object := range client.List(ctx, src, false) {
+		if object.Type.IsDir() || errorpkg.IsCancelation(object.Err) || errors.Is(object.Err, storage.ErrNoObjectFound) {
+			continue
+		}
+
+		if err := object.Err; err != nil {
+			merror = multierror.Append(merror, err)
+			printError(ctx, sz.fullCommand, sz.op, err)
+			continue
+		}
+
+		if isURLMatched(excludePatterns, object.URL.Path, src) {
+			continue
+		}
+
+		total.addObject(object)
+	}
+	return total, merror
+}
+
+// commonPrefix returns the prefix of the given key up to and including the
+// first delimiter after the given prefix. It returns an empty string if the
+// rest of the key doesn't contain the delimiter.
+func commonPrefix(prefix, key, delimiter string) string {
+	rest := strings.TrimPrefix(key, prefix)
+	i := strings.Index(rest, delimiter)
+	if i < 0 {
+		return ""
+	}
+	return key[:len(key)-len(rest)+i+len(delimiter)]
+}
+
+// runSample estimates disk usage of given source by listing only the first
+// pages of it. The totals of the sampled objects are extrapolated by the
+// share of the keyspace they cover.
+func (sz Size) runSample(ctx context.Context) error {
+	client, err := storage.NewRemoteClient(ctx, sz.src, sz.storageOpts)
+	if err != nil {
+		printError(ctx, sz.fullCommand, sz.op, err)
+		return err
+	}
+
+	excludePatterns, err := createRegexFromWildcard(sz.exclude)
+	if err != nil {
+		printError(ctx, sz.fullCommand, sz.op, err)
+		return err
+	}
+
+	storageTotal := map[string]sizeAndCount{}
+	total := sizeAndCount{}
+	sample := newKeySample(sz.src.Prefix)
+	truncated := false
+
+	var merror error
+	for object := range client.ListPages(ctx, sz.src, sz.sample) {
+		if object.Err == storage.ErrListTruncated {
+			truncated = true
+			continue
+		}
+
+		if object.Type.IsDir() || errorpkg.IsCancelation(object.Err) {
+			continue
+		}
+
+		if err := object.Err; err != nil {
+			merror = multierror.Append(merror, err)
+			printError(ctx, sz.fullCommand, sz.op, err)
+			continue
+		}
+
+		sample.add(object.URL.Path)
+
+		if isURLMatched(excludePatterns, object.URL.Path, sz.src) {
+			continue
+		}
+
+		storageClass := string(object.StorageClass)
+		s := storageTotal[storageClass]
+		s.addObject(object)
+		storageTotal[storageClass] = s
+
+		total.addObject(object)
+	}
+
+	// the whole source is listed, so the totals are exact.
+	if !truncated {
+		sample = nil
+	}
+
+	if !sz.groupByClass {
+		log.InfoContext(ctx, newSizeMessage(sz.src.String(), "", total, sample, sz.humanize))
+		return merror
+	}
+
+	for k, v := range storageTotal {
+		log.InfoContext(ctx, newSizeMessage(sz.src.String(), k, v, sample, sz.humanize))
 	}
 	return merror
 }
 
+// newSizeMessage returns the message of the given totals, which are
+// extrapolated if they are of a key sample.
+func newSizeMessage(source, storageClass string, total sizeAndCount, sample *keySample, humanize bool) SizeMessage {
+	msg := SizeMessage{
+		Source:        source,
+		StorageClass:  storageClass,
+		Count:         total.count,
+		Size:          total.size,
+		showHumanized: humanize,
+	}
+	if sample == nil {
+		return msg
+	}
+
+	scale := sample.scale()
+	msg.Count = int64(math.Round(float64(total.count) * scale))
+	msg.Size = int64(math.Round(float64(total.size) * scale))
+	msg.Estimate = true
+	msg.SampleCount = sample.count
+	return msg
+}
+
+// keySampleDepth is the number of the characters of the keys which are used
+// to find their positions in the keyspace.
+const keySampleDepth = 8
+
+// keySample tracks the keys of the first pages of a listing to estimate the
+// share of the keyspace they cover. Keys are assumed to be made of the
+// characters seen in the sample and to be evenly distributed over the
+// keyspace, which is rarely exactly true, so the estimates are rough.
+type keySample struct {
+	prefix      string
+	first, last string
+	count       int64
+	alphabet    [256]bool
+}
+
+func newKeySample(prefix string) *keySample {
+	return &keySample{prefix: prefix}
+}
+
+// add adds the given key to the sample. The keys are added in the listing
+// order, which is lexicographical.
+func (k *keySample) add(key string) {
+	key = strings.TrimPrefix(key, k.prefix)
+	if k.count == 0 {
+		k.first = key
+	}
+	k.last = key
+	k.count++
+
+	for i := 0; i < len(key); i++ {
+		k.alphabet[key[i]] = true
+	}
+}
+
+// position returns the position of the given key in the keyspace, in [0, 1).
+// Each character is a digit whose value is its rank in the alphabet, and the
+// missing characters of the short keys sort before all others.
+func (k *keySample) position(key string) float64 {
+	var ranks [256]int
+	base := 1
+	for c, ok := range k.alphabet {
+		if ok {
+			ranks[c] = base
+			base++
+		}
+	}
+
+	pos, unit := 0.0, 1.0
+	for i := 0; i < len(key) && i < keySampleDepth; i++ {
+		unit /= float64(base)
+		pos += float64(ranks[key[i]]) * unit
+	}
+	return pos
+}
+
+// scale returns the factor to extrapolate the totals of the sample to the
+// whole keyspace. The keys after the last one are assumed to extend up to
+// the end of the keyspace.
+func (k *keySample) scale() float64 {
+	start := k.position(k.first)
+	covered := (k.position(k.last) - start) / (1 - start)
+	if covered <= 0 || covered > 1 {
+		return 1
+	}
+	return 1 / covered
+}
+
 // SizeMessage is the structure for logging disk usage.
 type SizeMessage struct {
 	Source       string `json:"source"`
 	StorageClass string `json:"storage_class,omitempty"`
 	Count        int64  `json:"count"`
 	Size         int64  `json:"size"`
+	Estimate     bool   `json:"estimate,omitempty"`
+	SampleCount  int64  `json:"sample_count,omitempty"`
+
+	Top []TopObject `json:"top,omitempty"`
 
 	showHumanized bool
 }
 
+// TopObject is one of the largest objects listed by du command.
+type TopObject struct {
+	Key          string `json:"key"`
+	Size         int64  `json:"size"`
+	StorageClass string `json:"storage_class,omitempty"`
+	VersionID    string `json:"version_id,omitempty"`
+}
+
 // humanize is a helper method to humanize bytes.
 func (s SizeMessage) humanize() string {
+	return s.humanizeSize(s.Size)
+}
+
+func (s SizeMessage) humanizeSize(size int64) string {
 	if s.showHumanized {
-		return strutil.HumanizeBytes(s.Size)
+		return strutil.HumanizeBytes(size)
 	}
-	return fmt.Sprintf("%d", s.Size)
+	return fmt.Sprintf("%d", size)
 }
 
-// String returns the string representation of SizeMessage.
+// String returns the string representation of SizeMessage. The largest
+// objects, if any, are listed before the totals.
 func (s SizeMessage) String() string {
+	var top strings.Builder
+	for _, object := range s.Top {
+		fmt.Fprintf(&top, "%s bytes: %s", s.humanizeSize(object.Size), object.Key)
+		if object.VersionID != "" {
+			fmt.Fprintf(&top, " %s", object.VersionID)
+		}
+		top.WriteString("\n")
+	}
+	return top.String() + s.totals()
+}
+
+func (s SizeMessage) totals() string {
 	var storageCls string
 	if s.StorageClass != "" {
 		storageCls = fmt.Sprintf(" [%s]", s.StorageClass)
 	}
+	if s.Estimate {
+		return fmt.Sprintf(
+			"~%s bytes in ~%d objects: %s%s (estimated from %d sampled objects assuming evenly distributed keys, actual usage may differ significantly)",
+			s.humanize(),
+			s.Count,
+			s.Source,
+			storageCls,
+			s.SampleCount,
+		)
+	}
 	return fmt.Sprintf(
 		"%s bytes in %d objects: %s%s",
 		s.humanize(),
@@ -235,6 +675,114 @@ func (s SizeMessage) JSON() string {
 	return strutil.JSON(s)
 }
 
+// MultipleSizeMessage is the structure for logging disk usage of multiple
+// sources along with their grand total.
+type MultipleSizeMessage struct {
+	Sources []SizeMessage `json:"sources"`
+	Count   int64         `json:"count"`
+	Size    int64         `json:"size"`
+
+	showHumanized bool
+}
+
+// String returns the string representation of MultipleSizeMessage, which is
+// the totals of each source followed by the grand total.
+func (s MultipleSizeMessage) String() string {
+	var lines strings.Builder
+	for _, source := range s.Sources {
+		fmt.Fprintf(&lines, "%s\n", source)
+	}
+	total := SizeMessage{
+		Source:        "total",
+		Count:         s.Count,
+		Size:          s.Size,
+		showHumanized: s.showHumanized,
+	}
+	return lines.String() + total.String()
+}
+
+// JSON returns the JSON representation of MultipleSizeMessage.
+func (s MultipleSizeMessage) JSON() string {
+	return strutil.JSON(s)
+}
+
+// StorageClassSizeMessage is the structure for logging disk usage by storage
+// class as a table.
+type StorageClassSizeMessage struct {
+	Source         string             `json:"source"`
+	StorageClasses []StorageClassSize `json:"storage_classes"`
+	Count          int64              `json:"count"`
+	Size           int64              `json:"size"`
+
+	showHumanized bool
+}
+
+// StorageClassSize is the number and size of the objects of a storage class.
+type StorageClassSize struct {
+	StorageClass string `json:"storage_class"`
+	Count        int64  `json:"count"`
+	Size         int64  `json:"size"`
+}
+
+// newStorageClassSizeMessage returns the message of the given totals. The
+// storage classes are sorted by their sizes, the largest first.
+func newStorageClassSizeMessage(source string, totals *sizeTotals, humanize bool) StorageClassSizeMessage {
+	classes := make([]StorageClassSize, 0, len(totals.storageTotal))
+	for class, total := range totals.storageTotal {
+		classes = append(classes, StorageClassSize{
+			StorageClass: class,
+			Count:        total.count,
+			Size:         total.size,
+		})
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		if classes[i].Size != classes[j].Size {
+			return classes[i].Size > classes[j].Size
+		}
+		return classes[i].StorageClass < classes[j].StorageClass
+	})
+
+	return StorageClassSizeMessage{
+		Source:         source,
+		StorageClasses: classes,
+		Count:          totals.total.count,
+		Size:           totals.total.size,
+		showHumanized:  humanize,
+	}
+}
+
+const storageClassSizeFormat = "%-20s %12v %12v"
+
+// String returns the string representation of StorageClassSizeMessage, which
+// is a table of the storage classes and their totals under the source.
+func (s StorageClassSizeMessage) String() string {
+	size := func(size int64) string {
+		if s.showHumanized {
+			return strutil.HumanizeBytes(size)
+		}
+		return fmt.Sprintf("%d", size)
+	}
+
+	var table strings.Builder
+	fmt.Fprintf(&table, "%s\n", s.Source)
+	fmt.Fprintf(&table, storageClassSizeFormat+"\n", "STORAGE CLASS", "OBJECTS", "SIZE")
+	for _, class := range s.StorageClasses {
+		// the local files don't have a storage class.
+		name := class.StorageClass
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(&table, storageClassSizeFormat+"\n", name, class.Count, size(class.Size))
+	}
+	fmt.Fprintf(&table, storageClassSizeFormat, "TOTAL", s.Count, size(s.Size))
+	return table.String()
+}
+
+// JSON returns the JSON representation of StorageClassSizeMessage.
+func (s StorageClassSizeMessage) JSON() string {
+	return strutil.JSON(s)
+}
+
 type sizeAndCount struct {
 	size  int64
 	count int64
@@ -245,25 +793,167 @@ func (s *sizeAndCount) addObject(obj *storage.Object) {
 	s.count++
 }
 
+// sizeTotals holds the total size and count of objects, both in total and by
+// storage class.
+type sizeTotals struct {
+	total        sizeAndCount
+	storageTotal map[string]sizeAndCount
+}
+
+func newSizeTotals() *sizeTotals {
+	return &sizeTotals{storageTotal: map[string]sizeAndCount{}}
+}
+
+func (t *sizeTotals) addObject(obj *storage.Object) {
+	storageClass := string(obj.StorageClass)
+	s := t.storageTotal[storageClass]
+	s.addObject(obj)
+	t.storageTotal[storageClass] = s
+
+	t.total.addObject(obj)
+}
+
+// topObjects keeps the largest objects added to it in a min-heap whose size
+// is bounded by the number of objects to keep, so that the whole listing is
+// not kept in memory.
+type topObjects struct {
+	n       int
+	objects []TopObject
+}
+
+func newTopObjects(n int) *topObjects {
+	return &topObjects{n: n, objects: make([]TopObject, 0, n)}
+}
+
+// add adds the given object if it's one of the largest objects added so far.
+func (t *topObjects) add(obj *storage.Object) {
+	if t == nil {
+		return
+	}
+
+	object := TopObject{
+		Key:          obj.URL.String(),
+		Size:         obj.Size,
+		StorageClass: string(obj.StorageClass),
+		VersionID:    obj.URL.VersionID,
+	}
+
+	if len(t.objects) < t.n {
+		heap.Push(t, object)
+		return
+	}
+
+	// the smallest of the kept objects is replaced.
+	if smallerObject(t.objects[0], object) {
+		t.objects[0] = object
+		heap.Fix(t, 0)
+	}
+}
+
+// sorted returns the kept objects, the largest first.
+func (t *topObjects) sorted() []TopObject {
+	objects := make([]TopObject, len(t.objects))
+	copy(objects, t.objects)
+	sort.Slice(objects, func(i, j int) bool {
+		return smallerObject(objects[j], objects[i])
+	})
+	return objects
+}
+
+// smallerObject reports whether a is smaller than b. The objects of the same
+// size are ordered by their keys, so that the ones with the smaller keys are
+// kept.
+func smallerObject(a, b TopObject) bool {
+	if a.Size != b.Size {
+		return a.Size < b.Size
+	}
+	return a.Key > b.Key
+}
+
+func (t *topObjects) Len() int           { return len(t.objects) }
+func (t *topObjects) Less(i, j int) bool { return smallerObject(t.objects[i], t.objects[j]) }
+func (t *topObjects) Swap(i, j int)      { t.objects[i], t.objects[j] = t.objects[j], t.objects[i] }
+
+func (t *topObjects) Push(x interface{}) {
+	t.objects = append(t.objects, x.(TopObject))
+}
+
+func (t *topObjects) Pop() interface{} {
+	n := len(t.objects)
+	object := t.objects[n-1]
+	t.objects = t.objects[:n-1]
+	return object
+}
+
 func validateDUCommand(c *cli.Context) error {
-	if c.Args().Len() != 1 {
-		return fmt.Errorf("expected only 1 argument")
+	if c.Args().Len() < 1 {
+		return fmt.Errorf("expected at least 1 argument")
 	}
 
 	if err := checkVersioningFlagCompatibility(c); err != nil {
 		return err
 	}
 
-	srcurl, err := url.New(c.Args().First(),
-		url.WithAllVersions(c.Bool("all-versions")))
-	if err != nil {
+	if c.Args().Len() > 1 {
+		for _, flag := range []string{"version-id", "source-inventory", "sample", "delimiter", "top", "group", "by-storage-class"} {
+			if c.IsSet(flag) {
+				return fmt.Errorf("%s flag can not be used with multiple sources", flag)
+			}
+		}
+	}
+
+	var srcurl *url.URL
+	for _, arg := range c.Args().Slice() {
+		var err error
+		srcurl, err = url.New(arg, url.WithAllVersions(c.Bool("all-versions")))
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersinoningURLRemote(srcurl); err != nil {
+			return err
+		}
+	}
+
+	if err := validateSourceInventory(c, false, srcurl); err != nil {
 		return err
 	}
 
-	if err := checkVersinoningURLRemote(srcurl); err != nil {
+	if err := validateSample(c, srcurl); err != nil {
 		return err
 	}
 
+	if c.IsSet("delimiter") {
+		if c.String("delimiter") == "" {
+			return fmt.Errorf("delimiter flag must be non-empty")
+		}
+		if !srcurl.IsRemote() {
+			return fmt.Errorf("delimiter flag can only be used with remote sources")
+		}
+		if c.IsSet("sample") {
+			return fmt.Errorf("delimiter flag can not be used with sample flag")
+		}
+	}
+
+	if c.IsSet("top") {
+		if c.Int("top") < 1 {
+			return fmt.Errorf("top flag must be a positive number of objects")
+		}
+		for _, flag := range []string{"group", "delimiter", "sample"} {
+			if c.IsSet(flag) {
+				return fmt.Errorf("top flag can not be used with %s flag", flag)
+			}
+		}
+	}
+
+	if c.Bool("by-storage-class") {
+		for _, flag := range []string{"group", "sample", "top"} {
+			if c.IsSet(flag) {
+				return fmt.Errorf("by-storage-class flag can not be used with %s flag", flag)
+			}
+		}
+	}
+
 	// the "all-versions" flag of du command works with GCS, because it does not
 	// depend on the generation numbers.
 	endpoint, err := urlpkg.Parse(c.String("endpoint-url"))
@@ -273,3 +963,31 @@ func validateDUCommand(c *cli.Context) error {
 
 	return nil
 }
+
+func validateSample(c *cli.Context, srcurl *url.URL) error {
+	if !c.IsSet("sample") {
+		return nil
+	}
+
+	if c.Int("sample") < 1 {
+		return fmt.Errorf("sample flag must be a positive number of pages")
+	}
+
+	if !srcurl.IsRemote() {
+		return fmt.Errorf("sample flag can only be used with remote sources")
+	}
+
+	if c.Bool("all-versions") || c.String("version-id") != "" {
+		return fmt.Errorf("sample flag can not be used with version flags")
+	}
+
+	if c.String("source-inventory") != "" {
+		return fmt.Errorf("sample flag can not be used with source-inventory flag")
+	}
+
+	if c.Bool("use-list-objects-v1") {
+		return fmt.Errorf("sample flag can not be used with use-list-objects-v1 flag")
+	}
+
+	return nil
+}
@@ -0,0 +1,265 @@
+package command
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/storage/url"
+)
+
+func TestKeySampleScale(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		prefix   string
+		keys     []string
+		total    int
+		expected float64
+	}{
+		{
+			name:     "numbered keys",
+			keys:     numberedKeys("", 1000),
+			total:    10000,
+			expected: 10000,
+		},
+		{
+			name:     "numbered keys with prefix",
+			prefix:   "logs/",
+			keys:     numberedKeys("logs/", 2500),
+			total:    10000,
+			expected: 10000,
+		},
+		{
+			name:     "single key",
+			keys:     []string{"key"},
+			total:    1,
+			expected: 1,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			sample := newKeySample(tc.prefix)
+			for _, key := range tc.keys {
+				sample.add(key)
+			}
+
+			got := float64(len(tc.keys)) * sample.scale()
+			// the estimates are rough, so only the order of magnitude is
+			// checked.
+			assert.Assert(t, math.Abs(got-tc.expected) <= tc.expected*0.25, "got %v, expected %v", got, tc.expected)
+		})
+	}
+}
+
+func TestNewSizeMessageEstimate(t *testing.T) {
+	t.Parallel()
+
+	sample := newKeySample("")
+	for _, key := range numberedKeys("", 1000) {
+		sample.add(key)
+	}
+
+	total := sizeAndCount{size: 1000 * 10, count: 1000}
+
+	msg := newSizeMessage("s3://bucket/*", "", total, nil, false)
+	assert.Equal(t, msg.String(), "10000 bytes in 1000 objects: s3://bucket/*")
+	assert.Equal(t, msg.JSON(), `{"source":"s3://bucket/*","count":1000,"size":10000}`)
+
+	msg = newSizeMessage("s3://bucket/*", "", total, sample, false)
+	assert.Assert(t, msg.Estimate)
+	assert.Equal(t, msg.SampleCount, int64(1000))
+	assert.Assert(t, msg.Count > 1000)
+	assert.Equal(t, msg.String(), fmt.Sprintf("~%d bytes in ~%d objects: s3://bucket/* (estimated from 1000 sampled objects assuming evenly distributed keys, actual usage may differ significantly)", msg.Size, msg.Count))
+}
+
+// numberedKeys returns the first n of the keys from 0000 to 9999 with the
+// given prefix.
+func numberedKeys(prefix string, n int) []string {
+	keys := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		keys = append(keys, fmt.Sprintf("%v%04d", prefix, i))
+	}
+	return keys
+}
+
+func TestCommonPrefix(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name      string
+		prefix    string
+		key       string
+		delimiter string
+		expected  string
+	}{
+		{
+			name:      "key under a common prefix",
+			key:       "logs|2024|a.txt",
+			delimiter: "|",
+			expected:  "logs|",
+		},
+		{
+			name:      "key under a common prefix relative to the prefix",
+			prefix:    "logs|",
+			key:       "logs|2024|a.txt",
+			delimiter: "|",
+			expected:  "logs|2024|",
+		},
+		{
+			name:      "key without delimiter after the prefix",
+			prefix:    "logs|",
+			key:       "logs|a.txt",
+			delimiter: "|",
+			expected:  "",
+		},
+		{
+			name:      "multi character delimiter",
+			prefix:    "lo",
+			key:       "logs--2024--a.txt",
+			delimiter: "--",
+			expected:  "logs--",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := commonPrefix(tc.prefix, tc.key, tc.delimiter)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestTopObjects(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		n        int
+		sizes    map[string]int64
+		expected []string
+	}{
+		{
+			name: "largest objects",
+			n:    3,
+			sizes: map[string]int64{
+				"a": 5, "b": 100, "c": 1, "d": 42, "e": 7, "f": 99, "g": 0,
+			},
+			expected: []string{"b", "f", "d"},
+		},
+		{
+			name: "objects of the same size",
+			n:    2,
+			sizes: map[string]int64{
+				"d": 10, "b": 10, "c": 10, "a": 1,
+			},
+			expected: []string{"b", "c"},
+		},
+		{
+			name: "fewer objects than the top",
+			n:    5,
+			sizes: map[string]int64{
+				"a": 1, "b": 2,
+			},
+			expected: []string{"b", "a"},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			top := newTopObjects(tc.n)
+			// the map is iterated in random order.
+			for key, size := range tc.sizes {
+				top.add(&storage.Object{URL: &url.URL{Scheme: "s3", Bucket: "bucket", Path: key}, Size: size})
+			}
+
+			var keys []string
+			for _, object := range top.sorted() {
+				keys = append(keys, strings.TrimPrefix(object.Key, "s3://bucket/"))
+				assert.Equal(t, object.Size, tc.sizes[keys[len(keys)-1]])
+			}
+			assert.DeepEqual(t, keys, tc.expected)
+		})
+	}
+}
+
+func TestNewStorageClassSizeMessage(t *testing.T) {
+	t.Parallel()
+
+	objects := []struct {
+		storageClass storage.StorageClass
+		size         int64
+	}{
+		{"STANDARD", 3},
+		{"GLACIER", 10},
+		{"STANDARD", 5},
+		{"STANDARD_IA", 18},
+		{"DEEP_ARCHIVE", 10},
+	}
+
+	totals := newSizeTotals()
+	for _, object := range objects {
+		totals.addObject(&storage.Object{StorageClass: object.storageClass, Size: object.size})
+	}
+
+	msg := newStorageClassSizeMessage("s3://bucket/*", totals, false)
+
+	// the classes of the same size are sorted by their names.
+	assert.DeepEqual(t, msg.StorageClasses, []StorageClassSize{
+		{StorageClass: "STANDARD_IA", Count: 1, Size: 18},
+		{StorageClass: "DEEP_ARCHIVE", Count: 1, Size: 10},
+		{StorageClass: "GLACIER", Count: 1, Size: 10},
+		{StorageClass: "STANDARD", Count: 2, Size: 8},
+	})
+	assert.Equal(t, msg.Count, int64(5))
+	assert.Equal(t, msg.Size, int64(46))
+
+	expected := strings.Join([]string{
+		"s3://bucket/*",
+		"STORAGE CLASS             OBJECTS         SIZE",
+		"STANDARD_IA                     1           18",
+		"DEEP_ARCHIVE                    1           10",
+		"GLACIER                         1           10",
+		"STANDARD                        2            8",
+		"TOTAL                           5           46",
+	}, "\n")
+	assert.Equal(t, msg.String(), expected)
+}
+
+func TestMultipleSizeMessage(t *testing.T) {
+	t.Parallel()
+
+	msg := MultipleSizeMessage{
+		Sources: []SizeMessage{
+			{Source: "s3://bucket/a/*", Count: 2, Size: 2048, showHumanized: true},
+			{Source: "s3://bucket/b/*", Count: 1, Size: 1000, showHumanized: true},
+		},
+		Count:         3,
+		Size:          3048,
+		showHumanized: true,
+	}
+
+	expected := strings.Join([]string{
+		"2.0K bytes in 2 objects: s3://bucket/a/*",
+		"1000 bytes in 1 objects: s3://bucket/b/*",
+		"3.0K bytes in 3 objects: total",
+	}, "\n")
+	assert.Equal(t, msg.String(), expected)
+
+	assert.Equal(t, msg.JSON(), `{"sources":[{"source":"s3://bucket/a/*","count":2,"size":2048},{"source":"s3://bucket/b/*","count":1,"size":1000}],"count":3,"size":3048}`)
+}
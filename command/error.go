@@ -1,6 +1,7 @@
 package command
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -28,7 +29,7 @@ func printDebug(op string, err error, urls ...*url.URL) {
 }
 
 // printError is the helper function to log error messages.
-func printError(command, op string, err error) {
+func printError(ctx context.Context, command, op string, err error) {
 	// dont print cancelation errors
 	if errorpkg.IsCancelation(err) {
 		return
@@ -43,7 +44,7 @@ func printError(command, op string, err error) {
 				Command:   cerr.FullCommand(),
 				Operation: cerr.Op,
 			}
-			log.Error(msg)
+			log.ErrorContext(ctx, msg)
 			return
 		}
 	}
@@ -60,7 +61,7 @@ func printError(command, op string, err error) {
 						Command:   customErr.FullCommand(),
 						Operation: customErr.Op,
 					}
-					log.Error(msg)
+					log.ErrorContext(ctx, msg)
 					continue
 				}
 
@@ -70,7 +71,7 @@ func printError(command, op string, err error) {
 					Operation: op,
 				}
 
-				log.Error(msg)
+				log.ErrorContext(ctx, msg)
 			}
 			return
 		}
@@ -82,7 +83,7 @@ func printError(command, op string, err error) {
 		Command:   command,
 		Operation: op,
 	}
-	log.Error(msg)
+	log.ErrorContext(ctx, msg)
 }
 
 // cleanupError converts multiline messages into
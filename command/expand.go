@@ -3,9 +3,12 @@ package command
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 
+	"github.com/urfave/cli/v2"
+
 	"github.com/peak/s5cmd/v2/storage"
 	"github.com/peak/s5cmd/v2/storage/url"
 )
@@ -94,3 +97,59 @@ func expandSources(
 
 	return ch
 }
+
+// expandInventory returns the objects matching the given source URLs from the
+// S3 Inventory report of the given manifest, instead of listing the sources.
+func expandInventory(
+	ctx context.Context,
+	storageOpts storage.Options,
+	manifest string,
+	srcurls ...*url.URL,
+) (<-chan *storage.Object, error) {
+	manifestURL, err := url.New(manifest, url.WithRaw(true))
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := storage.NewRemoteClient(ctx, manifestURL, storageOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.ListInventory(ctx, manifestURL, srcurls...)
+}
+
+// validateSourceInventory validates the source-inventory flag and the
+// sources to be read from the inventory. The versions in the report are only
+// read with all-versions flag if allowAllVersions is set.
+func validateSourceInventory(c *cli.Context, allowAllVersions bool, srcurls ...*url.URL) error {
+	manifest := c.String("source-inventory")
+	if manifest == "" {
+		return nil
+	}
+
+	manifestURL, err := url.New(manifest, url.WithRaw(true))
+	if err != nil {
+		return err
+	}
+
+	if !manifestURL.IsRemote() {
+		return fmt.Errorf("source-inventory flag must be a remote inventory manifest")
+	}
+
+	// all versions in the report are used with all-versions flag.
+	if c.String("version-id") != "" {
+		return fmt.Errorf("source-inventory flag can not be used with version-id flag")
+	}
+	if c.Bool("all-versions") && !allowAllVersions {
+		return fmt.Errorf("source-inventory flag can not be used with all-versions flag")
+	}
+
+	for _, srcurl := range srcurls {
+		if !srcurl.IsRemote() {
+			return fmt.Errorf("source-inventory flag can only be used with remote sources")
+		}
+	}
+
+	return nil
+}
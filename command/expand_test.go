@@ -131,7 +131,7 @@ func TestExpandSources(t *testing.T) {
 	for _, tc := range tests {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			srcurls, err := newURLs(false, "", false, keys(tc.src)...)
+			srcurls, err := newURLs(false, "", false, nil, keys(tc.src)...)
 			if err != nil {
 				t.Errorf("unexpected error: %v", err)
 				return
@@ -0,0 +1,84 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/v2/storage"
+)
+
+// grantFlags are the flags of the explicit grants of the permissions on the
+// uploaded and copied objects.
+var grantFlags = []string{
+	"grant-read",
+	"grant-read-acp",
+	"grant-write-acp",
+	"grant-full-control",
+}
+
+// granteeTypes maps the lower-cased types of the grantees to the types in the
+// grant headers.
+var granteeTypes = map[string]string{
+	"id":           "id",
+	"emailaddress": "emailAddress",
+	"uri":          "uri",
+}
+
+// parseGrantees parses the comma-separated grantees of a grant flag, e.g.
+// "id=111122223333,uri=http://acs.amazonaws.com/groups/global/AllUsers", to
+// the value of the grant header, e.g. `id="111122223333",
+// uri="http://acs.amazonaws.com/groups/global/AllUsers"`. An empty value is
+// returned as is.
+func parseGrantees(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	var grantees []string
+	for _, grantee := range strings.Split(value, ",") {
+		typ, id, ok := strings.Cut(strings.TrimSpace(grantee), "=")
+		granteeType, known := granteeTypes[strings.ToLower(typ)]
+		id = strings.Trim(id, `"`)
+		if !ok || !known || id == "" {
+			return "", fmt.Errorf("invalid grantee %q: must be in the form of id=ID, emailAddress=EMAIL or uri=URI", grantee)
+		}
+		grantees = append(grantees, fmt.Sprintf("%v=%q", granteeType, id))
+	}
+	return strings.Join(grantees, ", "), nil
+}
+
+// parseGrants parses the grant flags to the grants of the objects.
+func parseGrants(c *cli.Context) (storage.Grants, error) {
+	var (
+		grants storage.Grants
+		err    error
+	)
+	fields := []*string{&grants.Read, &grants.ReadACP, &grants.WriteACP, &grants.FullControl}
+	for i, flag := range grantFlags {
+		*fields[i], err = parseGrantees(c.String(flag))
+		if err != nil {
+			return storage.Grants{}, fmt.Errorf("%q flag: %w", flag, err)
+		}
+	}
+	return grants, nil
+}
+
+// validateGrants validates the grant flags. Canned and explicit ACLs can't be
+// used in the same request.
+func validateGrants(c *cli.Context) error {
+	if _, err := parseGrants(c); err != nil {
+		return err
+	}
+
+	if c.String("acl") == "" {
+		return nil
+	}
+	for _, flag := range grantFlags {
+		if c.String(flag) != "" {
+			return fmt.Errorf(`"acl" flag cannot be used with %q flag`, flag)
+		}
+	}
+	return nil
+}
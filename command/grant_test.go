@@ -0,0 +1,67 @@
+package command
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseGrantees(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name        string
+		value       string
+		expected    string
+		expectedErr string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:     "single grantee",
+			value:    "id=111122223333",
+			expected: `id="111122223333"`,
+		},
+		{
+			name:     "multiple grantees",
+			value:    "id=111122223333, emailAddress=user@example.com,uri=http://acs.amazonaws.com/groups/global/AllUsers",
+			expected: `id="111122223333", emailAddress="user@example.com", uri="http://acs.amazonaws.com/groups/global/AllUsers"`,
+		},
+		{
+			name:     "quoted grantee with case insensitive type",
+			value:    `EmailAddress="user@example.com"`,
+			expected: `emailAddress="user@example.com"`,
+		},
+		{
+			name:        "unknown type",
+			value:       "id=111122223333,account=444455556666",
+			expectedErr: `invalid grantee "account=444455556666"`,
+		},
+		{
+			name:        "missing value",
+			value:       "id=",
+			expectedErr: `invalid grantee "id="`,
+		},
+		{
+			name:        "missing type",
+			value:       "111122223333",
+			expectedErr: `invalid grantee "111122223333"`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseGrantees(tc.value)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, got, tc.expected)
+		})
+	}
+}
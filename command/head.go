@@ -58,7 +58,7 @@ func NewHeadCommand() *cli.Command {
 		Before: func(c *cli.Context) error {
 			err := validateHeadCommand(c)
 			if err != nil {
-				printError(commandFromContext(c), c.Command.Name, err)
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
 			}
 			return err
 		},
@@ -70,7 +70,7 @@ func NewHeadCommand() *cli.Command {
 			src, err := url.New(c.Args().Get(0), url.WithVersion(c.String("version-id")),
 				url.WithRaw(c.Bool("raw")))
 			if err != nil {
-				printError(fullCommand, op, err)
+				printError(c.Context, fullCommand, op, err)
 				return err
 			}
 
@@ -96,14 +96,14 @@ type Head struct {
 func (h Head) Run(ctx context.Context) error {
 	client, err := storage.NewRemoteClient(ctx, h.src, h.storageOpts)
 	if err != nil {
-		printError(h.fullCommand, h.op, err)
+		printError(ctx, h.fullCommand, h.op, err)
 		return err
 	}
 
 	if h.src.IsBucket() {
 		err := client.HeadBucket(ctx, h.src)
 		if err != nil {
-			printError(h.fullCommand, h.op, err)
+			printError(ctx, h.fullCommand, h.op, err)
 			return err
 		}
 
@@ -111,14 +111,14 @@ func (h Head) Run(ctx context.Context) error {
 			Bucket: h.src.String(),
 		}
 
-		log.Info(msg)
+		log.InfoContext(ctx, msg)
 
 		return nil
 	}
 
 	object, metadata, err := client.HeadObject(ctx, h.src)
 	if err != nil {
-		printError(h.fullCommand, h.op, err)
+		printError(ctx, h.fullCommand, h.op, err)
 		return err
 	}
 
@@ -134,7 +134,7 @@ func (h Head) Run(ctx context.Context) error {
 		Metadata:             metadata.UserDefined,
 	}
 
-	log.Info(msg)
+	log.InfoContext(ctx, msg)
 
 	return nil
 }
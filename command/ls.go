@@ -3,6 +3,8 @@ package command
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/urfave/cli/v2"
@@ -58,6 +60,33 @@ Examples:
 	11. List all files with their fullpaths
 		 > s5cmd {{.HelpName}} --show-fullpath "s3://bucket/*"
 
+	12. List all objects in a bucket but do not descend into the prefix abc/
+		 > s5cmd {{.HelpName}} --exclude-prefix "abc/" "s3://bucket/*"
+
+	13. List all objects in a bucket with their owners in JSON format
+		 > s5cmd --json {{.HelpName}} --fetch-owner "s3://bucket/*"
+
+	14. List objects and common prefixes under a prefix whose keys are delimited by "|"
+		 > s5cmd {{.HelpName}} --delimiter "|" "s3://bucket/logs|"
+
+	15. List all objects in a bucket, skipping the zero-byte directory marker objects, e.g. "dir/"
+		 > s5cmd {{.HelpName}} --ignore-dir-markers "s3://bucket/*"
+
+	16. List all objects in a bucket which are modified in the last 7 days
+		 > s5cmd {{.HelpName}} --newer-than 7d "s3://bucket/*"
+
+	17. List all log files under a prefix which are modified before 2024-10-01
+		 > s5cmd {{.HelpName}} --older-than 2024-10-01T00:00:00Z "s3://bucket/logs/*.log"
+
+	18. List all objects in a bucket followed by their total count and size
+		 > s5cmd {{.HelpName}} --summarize --humanize "s3://bucket/*"
+
+	19. List the parquet files under a prefix and its sub-prefixes
+		 > s5cmd {{.HelpName}} --match "*.parquet" "s3://bucket/prefix/*"
+
+	20. List all objects in a bucket with the algorithms of their additional checksums, e.g. CRC32C
+		 > s5cmd {{.HelpName}} --show-checksum-algorithm "s3://bucket/*"
+
 `
 
 func NewListCommand() *cli.Command {
@@ -86,6 +115,14 @@ func NewListCommand() *cli.Command {
 				Name:  "exclude",
 				Usage: "exclude objects with given pattern",
 			},
+			&cli.StringSliceFlag{
+				Name:  "exclude-prefix",
+				Usage: "do not descend into the prefixes, relative to the source, while listing",
+			},
+			&cli.StringSliceFlag{
+				Name:  "match",
+				Usage: "list only the objects and prefixes whose names, i.e. the last elements of their keys, match any of the given patterns, e.g. --match '*.parquet'",
+			},
 			&cli.BoolFlag{
 				Name:  "all-versions",
 				Usage: "list all versions of object(s)",
@@ -94,11 +131,40 @@ func NewListCommand() *cli.Command {
 				Name:  "show-fullpath",
 				Usage: "shows only the fullpath names of the object(s)",
 			},
+			&cli.BoolFlag{
+				Name:  "fetch-owner",
+				Usage: "fetch the owner of each object, shown in the JSON output",
+			},
+			&cli.StringFlag{
+				Name:  "delimiter",
+				Value: "/",
+				Usage: "group the keys into common prefixes with the given delimiter",
+			},
+			&cli.BoolFlag{
+				Name:  "ignore-dir-markers",
+				Usage: "do not list the zero-byte directory marker objects whose keys end with /",
+			},
+			&cli.StringFlag{
+				Name:  "newer-than",
+				Usage: "list only the objects modified after the given time, either a duration before now, e.g. 24h or 7d, or a time in RFC3339 format, e.g. 2024-10-01T20:30:00Z",
+			},
+			&cli.StringFlag{
+				Name:  "older-than",
+				Usage: "list only the objects modified before the given time, either a duration before now, e.g. 24h or 7d, or a time in RFC3339 format, e.g. 2024-10-01T20:30:00Z",
+			},
+			&cli.BoolFlag{
+				Name:  "summarize",
+				Usage: "print the total number and size of the listed objects after the listing",
+			},
+			&cli.BoolFlag{
+				Name:  "show-checksum-algorithm",
+				Usage: "show the algorithm of the additional checksum of each object, which is returned by the listing without extra requests",
+			},
 		},
 		Before: func(c *cli.Context) error {
 			err := validateLSCommand(c)
 			if err != nil {
-				printError(commandFromContext(c), c.Command.Name, err)
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
 			}
 			return err
 		},
@@ -107,19 +173,31 @@ func NewListCommand() *cli.Command {
 			if !c.Args().Present() {
 				err := ListBuckets(c.Context, NewStorageOpts(c))
 				if err != nil {
-					printError(commandFromContext(c), c.Command.Name, err)
+					printError(c.Context, commandFromContext(c), c.Command.Name, err)
 				}
 				return err
 			}
 
 			fullCommand := commandFromContext(c)
 
+			storageOpts := NewStorageOpts(c)
+			storageOpts.FetchOwner = c.Bool("fetch-owner")
+
 			srcurl, err := url.New(c.Args().First(),
-				url.WithAllVersions(c.Bool("all-versions")))
+				url.WithAllVersions(c.Bool("all-versions")),
+				url.WithExcludePrefixes(c.StringSlice("exclude-prefix")),
+				url.WithDelimiter(c.String("delimiter")))
 			if err != nil {
-				printError(fullCommand, c.Command.Name, err)
+				printError(c.Context, fullCommand, c.Command.Name, err)
 				return err
 			}
+
+			timeFilter, err := newTimeFilter(c.String("newer-than"), c.String("older-than"), time.Now())
+			if err != nil {
+				printError(c.Context, fullCommand, c.Command.Name, err)
+				return err
+			}
+
 			return List{
 				src:         srcurl,
 				op:          c.Command.Name,
@@ -129,9 +207,15 @@ func NewListCommand() *cli.Command {
 				humanize:         c.Bool("humanize"),
 				showStorageClass: c.Bool("storage-class"),
 				exclude:          c.StringSlice("exclude"),
+				match:            c.StringSlice("match"),
 				showFullPath:     c.Bool("show-fullpath"),
+				ignoreDirMarkers: c.Bool("ignore-dir-markers"),
+				summarize:        c.Bool("summarize"),
+				timeFilter:       timeFilter,
+
+				showChecksumAlgorithm: c.Bool("show-checksum-algorithm"),
 
-				storageOpts: NewStorageOpts(c),
+				storageOpts: storageOpts,
 			}.Run(c.Context)
 		},
 	}
@@ -151,7 +235,13 @@ type List struct {
 	humanize         bool
 	showStorageClass bool
 	showFullPath     bool
+	ignoreDirMarkers bool
+	summarize        bool
 	exclude          []string
+	match            []string
+	timeFilter       timeFilter
+
+	showChecksumAlgorithm bool
 
 	storageOpts storage.Options
 }
@@ -171,7 +261,7 @@ func ListBuckets(ctx context.Context, storageOpts storage.Options) error {
 	}
 
 	for _, bucket := range buckets {
-		log.Info(bucket)
+		log.InfoContext(ctx, bucket)
 	}
 
 	return nil
@@ -182,15 +272,28 @@ func (l List) Run(ctx context.Context) error {
 
 	client, err := storage.NewClient(ctx, l.src, l.storageOpts)
 	if err != nil {
-		printError(l.fullCommand, l.op, err)
+		printError(ctx, l.fullCommand, l.op, err)
 		return err
 	}
 
-	var merror error
+	var (
+		merror error
+		total  sizeAndCount
+
+		// hasChecksumAlgorithm is set if any of the listed objects has a
+		// checksum algorithm.
+		hasChecksumAlgorithm bool
+	)
 
 	excludePatterns, err := createRegexFromWildcard(l.exclude)
 	if err != nil {
-		printError(l.fullCommand, l.op, err)
+		printError(ctx, l.fullCommand, l.op, err)
+		return err
+	}
+
+	matchPatterns, err := createRegexFromWildcard(l.match)
+	if err != nil {
+		printError(ctx, l.fullCommand, l.op, err)
 		return err
 	}
 
@@ -201,36 +304,89 @@ func (l List) Run(ctx context.Context) error {
 
 		if err := object.Err; err != nil {
 			merror = multierror.Append(merror, err)
-			printError(l.fullCommand, l.op, err)
+			printError(ctx, l.fullCommand, l.op, err)
+			continue
+		}
+
+		if isURLMatched(excludePatterns, object.URL.Path, l.src) {
+			continue
+		}
+
+		if !isNameMatched(matchPatterns, object.URL.Base()) {
+			continue
+		}
+
+		if l.ignoreDirMarkers && object.IsDirMarker() {
 			continue
 		}
 
-		if isURLMatched(excludePatterns, object.URL.Path, l.src.Prefix) {
+		if !l.timeFilter.match(object) {
 			continue
 		}
 
+		if !object.Type.IsDir() {
+			total.addObject(object)
+		}
+		if object.ChecksumAlgorithm != "" {
+			hasChecksumAlgorithm = true
+		}
+
 		msg := ListMessage{
-			Object:           object,
-			showEtag:         l.showEtag,
-			showHumanized:    l.humanize,
-			showStorageClass: l.showStorageClass,
-			showFullPath:     l.showFullPath,
+			Object:                object,
+			showEtag:              l.showEtag,
+			showHumanized:         l.humanize,
+			showStorageClass:      l.showStorageClass,
+			showFullPath:          l.showFullPath,
+			showChecksumAlgorithm: l.showChecksumAlgorithm,
+		}
+
+		log.InfoContext(ctx, msg)
+	}
+
+	// the services which don't support the additional checksums don't return
+	// the checksum algorithms in the listings.
+	if l.showChecksumAlgorithm && total.count > 0 && !hasChecksumAlgorithm {
+		msg := log.DebugMessage{
+			Err: fmt.Sprintf("no checksum algorithm is returned for the objects of %v: the objects may be uploaded without an additional checksum, or the endpoint may not support it", l.src),
 		}
+		log.Debug(msg)
+	}
 
-		log.Info(msg)
+	if l.summarize {
+		log.InfoContext(ctx, ListSummaryMessage{
+			Source:        l.src.String(),
+			Count:         total.count,
+			Size:          total.size,
+			showHumanized: l.humanize,
+		})
 	}
 
 	return merror
 }
 
+// isNameMatched reports whether the given name of an object matches any of
+// the patterns. All names match if there are no patterns.
+func isNameMatched(patterns []*regexp.Regexp, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
 // ListMessage is a structure for logging ls results.
 type ListMessage struct {
 	Object *storage.Object `json:"object"`
 
-	showEtag         bool
-	showHumanized    bool
-	showStorageClass bool
-	showFullPath     bool
+	showEtag              bool
+	showHumanized         bool
+	showStorageClass      bool
+	showFullPath          bool
+	showChecksumAlgorithm bool
 }
 
 // humanize is a helper function to humanize bytes.
@@ -246,6 +402,10 @@ func (l ListMessage) humanize() string {
 
 const (
 	dateFormat = "2006/01/02 15:04:05"
+
+	// listSchemaVersion is the version of the JSON output of ls. It is
+	// incremented when a field is removed or its meaning is changed.
+	listSchemaVersion = 1
 )
 
 // String returns the string representation of ListMessage.
@@ -265,6 +425,18 @@ func (l ListMessage) String() string {
 		listFormat = listFormat + " %-1s"
 	}
 
+	// align checksum algorithm
+	var checksumAlgorithm string
+	if l.showChecksumAlgorithm {
+		checksumAlgorithm = l.Object.ChecksumAlgorithm
+		if checksumAlgorithm == "" && !l.Object.Type.IsDir() {
+			checksumAlgorithm = "-"
+		}
+		listFormat = listFormat + " %-9s"
+	} else {
+		listFormat = listFormat + "%s"
+	}
+
 	// format file size
 	listFormat = listFormat + " %12s "
 	// format key and version ID
@@ -281,6 +453,7 @@ func (l ListMessage) String() string {
 			"",
 			"",
 			"",
+			checksumAlgorithm,
 			"DIR",
 			l.Object.URL.Relative(),
 			"",
@@ -305,6 +478,7 @@ func (l ListMessage) String() string {
 		l.Object.ModTime.Format(dateFormat),
 		stclass,
 		etag,
+		checksumAlgorithm,
 		l.humanize(),
 		path,
 		l.Object.URL.VersionID,
@@ -315,7 +489,45 @@ func (l ListMessage) String() string {
 
 // JSON returns the JSON representation of ListMessage.
 func (l ListMessage) JSON() string {
-	return strutil.JSON(l.Object)
+	return strutil.JSON(struct {
+		*storage.Object
+		SchemaVersion int `json:"schema_version"`
+	}{
+		Object:        l.Object,
+		SchemaVersion: listSchemaVersion,
+	})
+}
+
+// ListSummaryMessage is a structure for logging the total number and size
+// of the listed objects.
+type ListSummaryMessage struct {
+	Source string `json:"source"`
+	Count  int64  `json:"count"`
+	Size   int64  `json:"size"`
+
+	showHumanized bool
+}
+
+// String returns the string representation of ListSummaryMessage.
+func (m ListSummaryMessage) String() string {
+	size := fmt.Sprintf("%d", m.Size)
+	if m.showHumanized {
+		size = strutil.HumanizeBytes(m.Size)
+	}
+	return fmt.Sprintf("Total Objects: %d\n   Total Size: %s", m.Count, size)
+}
+
+// JSON returns the JSON representation of ListSummaryMessage.
+func (m ListSummaryMessage) JSON() string {
+	return strutil.JSON(struct {
+		ListSummaryMessage
+		Summary       bool `json:"summary"`
+		SchemaVersion int  `json:"schema_version"`
+	}{
+		ListSummaryMessage: m,
+		Summary:            true,
+		SchemaVersion:      listSchemaVersion,
+	})
 }
 
 func validateLSCommand(c *cli.Context) error {
@@ -323,6 +535,10 @@ func validateLSCommand(c *cli.Context) error {
 		return fmt.Errorf("expected only 1 argument")
 	}
 
+	if c.Bool("summarize") && !c.Args().Present() {
+		return fmt.Errorf(`"summarize" flag can only be used with objects`)
+	}
+
 	srcurl, err := url.New(c.Args().First(),
 		url.WithAllVersions(c.Bool("all-versions")))
 	if err != nil {
@@ -337,5 +553,21 @@ func validateLSCommand(c *cli.Context) error {
 		return err
 	}
 
+	if _, err := newTimeFilter(c.String("newer-than"), c.String("older-than"), time.Now()); err != nil {
+		return err
+	}
+
+	if c.IsSet("delimiter") {
+		if c.String("delimiter") == "" {
+			return fmt.Errorf("delimiter flag must be non-empty")
+		}
+		if !srcurl.IsRemote() {
+			return fmt.Errorf("delimiter flag can only be used with remote objects")
+		}
+		if srcurl.IsWildcard() {
+			return fmt.Errorf("delimiter flag can not be used with wildcards")
+		}
+	}
+
 	return nil
 }
@@ -38,7 +38,7 @@ func NewMakeBucketCommand() *cli.Command {
 		Before: func(c *cli.Context) error {
 			err := validateMBCommand(c)
 			if err != nil {
-				printError(commandFromContext(c), c.Command.Name, err)
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
 			}
 			return err
 		},
@@ -80,18 +80,18 @@ type MakeBucket struct {
 func (b MakeBucket) Run(ctx context.Context) error {
 	bucket, err := url.New(b.src)
 	if err != nil {
-		printError(b.fullCommand, b.op, err)
+		printError(ctx, b.fullCommand, b.op, err)
 		return err
 	}
 
 	client, err := storage.NewRemoteClient(ctx, &url.URL{}, b.storageOpts)
 	if err != nil {
-		printError(b.fullCommand, b.op, err)
+		printError(ctx, b.fullCommand, b.op, err)
 		return err
 	}
 
 	if err := client.MakeBucket(ctx, bucket.Bucket); err != nil {
-		printError(b.fullCommand, b.op, err)
+		printError(ctx, b.fullCommand, b.op, err)
 		return err
 	}
 
@@ -99,7 +99,7 @@ func (b MakeBucket) Run(ctx context.Context) error {
 		Operation: b.op,
 		Source:    bucket,
 	}
-	log.Info(msg)
+	log.InfoContext(ctx, msg)
 
 	return nil
 }
@@ -117,6 +117,9 @@ func validateMBCommand(c *cli.Context) error {
 	if !bucket.IsBucket() {
 		return fmt.Errorf("invalid s3 bucket")
 	}
+	if bucket.IsAccessPoint() {
+		return fmt.Errorf("access point ARNs cannot be used in place of bucket names for this command")
+	}
 
 	return nil
 }
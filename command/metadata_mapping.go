@@ -0,0 +1,178 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/strutil"
+)
+
+// metadataMappingValue is the metadata to be applied to the objects whose
+// destination keys match a pattern of the metadata mapping file.
+type metadataMappingValue struct {
+	ContentType        string            `json:"ContentType"`
+	ContentEncoding    string            `json:"ContentEncoding"`
+	ContentDisposition string            `json:"ContentDisposition"`
+	ContentLanguage    string            `json:"ContentLanguage"`
+	CacheControl       string            `json:"CacheControl"`
+	Metadata           map[string]string `json:"Metadata"`
+}
+
+type metadataMappingEntry struct {
+	pattern string
+	regex   *regexp.Regexp
+	value   metadataMappingValue
+}
+
+// metadataMapping maps destination keys, or wildcard patterns of them, to
+// object metadata. Entries are sorted from the most specific to the least
+// specific, so that the first matching entry wins.
+type metadataMapping []metadataMappingEntry
+
+var (
+	metadataMappingsMu sync.Mutex
+	metadataMappings   = map[string]metadataMapping{}
+)
+
+// loadMetadataMapping reads the metadata mapping from the given JSON file.
+// The mappings are cached since the generated commands of sync and run load
+// the same file for each object.
+func loadMetadataMapping(path string) (metadataMapping, error) {
+	metadataMappingsMu.Lock()
+	defer metadataMappingsMu.Unlock()
+
+	if mapping, ok := metadataMappings[path]; ok {
+		return mapping, nil
+	}
+
+	mapping, err := readMetadataMapping(path)
+	if err != nil {
+		return nil, err
+	}
+
+	metadataMappings[path] = mapping
+	return mapping, nil
+}
+
+func readMetadataMapping(path string) (metadataMapping, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+
+	var values map[string]metadataMappingValue
+	if err := decoder.Decode(&values); err != nil {
+		return nil, fmt.Errorf("invalid metadata mapping file %q: %w", path, err)
+	}
+
+	mapping := make(metadataMapping, 0, len(values))
+	for pattern, value := range values {
+		if pattern == "" {
+			return nil, fmt.Errorf("invalid metadata mapping file %q: empty key", path)
+		}
+
+		regex := strutil.WildCardToRegexp(pattern)
+		regex = strutil.MatchFromStartToEnd(regex)
+		regex = strutil.AddNewLineFlag(regex)
+		compiled, err := regexp.Compile(regex)
+		if err != nil {
+			return nil, err
+		}
+
+		mapping = append(mapping, metadataMappingEntry{
+			pattern: pattern,
+			regex:   compiled,
+			value:   value,
+		})
+	}
+
+	sort.Slice(mapping, func(i, j int) bool {
+		return mapping[i].moreSpecific(mapping[j])
+	})
+
+	return mapping, nil
+}
+
+// moreSpecific reports whether e is more specific than other. Exact keys are
+// more specific than wildcard patterns, and a pattern with more literal
+// characters is more specific than the one with fewer.
+func (e metadataMappingEntry) moreSpecific(other metadataMappingEntry) bool {
+	isExact, isOtherExact := !hasGlobCharacter(e.pattern), !hasGlobCharacter(other.pattern)
+	if isExact != isOtherExact {
+		return isExact
+	}
+
+	literals, otherLiterals := literalLength(e.pattern), literalLength(other.pattern)
+	if literals != otherLiterals {
+		return literals > otherLiterals
+	}
+
+	return e.pattern < other.pattern
+}
+
+// lookup returns the most specific metadata matching the given key.
+func (m metadataMapping) lookup(key string) (metadataMappingValue, bool) {
+	for _, entry := range m {
+		if entry.regex.MatchString(key) {
+			return entry.value, true
+		}
+	}
+	return metadataMappingValue{}, false
+}
+
+// apply fills the metadata fields which are not already set from the most
+// specific entry matching the given key. User defined metadata is merged, and
+// the existing keys take precedence over the ones in the mapping.
+func (m metadataMapping) apply(key string, metadata *storage.Metadata) {
+	value, ok := m.lookup(key)
+	if !ok {
+		return
+	}
+
+	if metadata.ContentType == "" {
+		metadata.ContentType = value.ContentType
+	}
+	if metadata.ContentEncoding == "" {
+		metadata.ContentEncoding = value.ContentEncoding
+	}
+	if metadata.ContentDisposition == "" {
+		metadata.ContentDisposition = value.ContentDisposition
+	}
+	if metadata.ContentLanguage == "" {
+		metadata.ContentLanguage = value.ContentLanguage
+	}
+	if metadata.CacheControl == "" {
+		metadata.CacheControl = value.CacheControl
+	}
+
+	if len(value.Metadata) == 0 {
+		return
+	}
+
+	userDefined := make(map[string]string, len(value.Metadata)+len(metadata.UserDefined))
+	for k, v := range value.Metadata {
+		userDefined[k] = v
+	}
+	for k, v := range metadata.UserDefined {
+		userDefined[k] = v
+	}
+	metadata.UserDefined = userDefined
+}
+
+func hasGlobCharacter(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?")
+}
+
+func literalLength(pattern string) int {
+	return len(pattern) - strings.Count(pattern, "*") - strings.Count(pattern, "?")
+}
@@ -0,0 +1,112 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/peak/s5cmd/v2/storage"
+	"gotest.tools/v3/assert"
+)
+
+func writeMetadataMapping(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "metadata.json")
+	assert.NilError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestMetadataMappingApply(t *testing.T) {
+	t.Parallel()
+
+	path := writeMetadataMapping(t, `{
+		"*": {"ContentType": "application/octet-stream"},
+		"*.html": {"ContentType": "text/html", "Metadata": {"team": "web", "cache": "no"}},
+		"site/*.html": {"ContentType": "text/html; charset=utf-8", "CacheControl": "no-cache"},
+		"site/index.html": {"Metadata": {"page": "index"}}
+	}`)
+
+	mapping, err := loadMetadataMapping(path)
+	assert.NilError(t, err)
+
+	testcases := []struct {
+		name     string
+		key      string
+		metadata storage.Metadata
+		expected storage.Metadata
+	}{
+		{
+			name:     "exact key wins",
+			key:      "site/index.html",
+			expected: storage.Metadata{UserDefined: map[string]string{"page": "index"}},
+		},
+		{
+			name: "more literal characters win",
+			key:  "site/about.html",
+			expected: storage.Metadata{
+				ContentType:  "text/html; charset=utf-8",
+				CacheControl: "no-cache",
+			},
+		},
+		{
+			name: "wildcard matches across directories",
+			key:  "docs/guide/intro.html",
+			expected: storage.Metadata{
+				ContentType: "text/html",
+				UserDefined: map[string]string{"team": "web", "cache": "no"},
+			},
+		},
+		{
+			name:     "catch all",
+			key:      "image.png",
+			expected: storage.Metadata{ContentType: "application/octet-stream"},
+		},
+		{
+			name: "flags take precedence",
+			key:  "index.html",
+			metadata: storage.Metadata{
+				ContentType: "text/plain",
+				UserDefined: map[string]string{"cache": "yes"},
+			},
+			expected: storage.Metadata{
+				ContentType: "text/plain",
+				UserDefined: map[string]string{"team": "web", "cache": "yes"},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			metadata := tc.metadata
+			mapping.apply(tc.key, &metadata)
+			assert.DeepEqual(t, metadata, tc.expected)
+		})
+	}
+}
+
+func TestLoadMetadataMappingInvalidFile(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name    string
+		content string
+	}{
+		{name: "invalid json", content: `{"*.html": `},
+		{name: "unknown field", content: `{"*.html": {"ContentTyp": "text/html"}}`},
+		{name: "empty key", content: `{"": {"ContentType": "text/html"}}`},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := loadMetadataMapping(writeMetadataMapping(t, tc.content))
+			assert.ErrorContains(t, err, "invalid metadata mapping file")
+		})
+	}
+}
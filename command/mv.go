@@ -1,7 +1,10 @@
 package command
 
 import (
+	"fmt"
+
 	"github.com/peak/s5cmd/v2/log/stat"
+	"github.com/peak/s5cmd/v2/storage/url"
 
 	"github.com/urfave/cli/v2"
 )
@@ -36,6 +39,9 @@ Examples:
 
 	7. Move all files from S3 bucket to another S3 bucket but exclude the ones starts with log
 		 > s5cmd {{.HelpName}} --exclude "log*" "s3://bucket/*" s3://destbucket
+
+	8. Move a directory to S3 bucket, deleting each file only after its object is verified to exist with the same size and MD5
+		 > s5cmd {{.HelpName}} --verify-before-delete --verify-checksum dir/ s3://bucket/
 `
 
 func NewMoveCommand() *cli.Command {
@@ -43,13 +49,18 @@ func NewMoveCommand() *cli.Command {
 		Name:               "mv",
 		HelpName:           "mv",
 		Usage:              "move/rename objects",
-		Flags:              NewCopyCommandFlags(), // move and copy commands share the same flags
+		Flags:              NewMoveCommandFlags(),
 		CustomHelpTemplate: moveHelpTemplate,
 		Before: func(c *cli.Context) error {
+			if err := validateMoveCommand(c); err != nil {
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
+				return err
+			}
 			return NewCopyCommand().Before(c)
 		},
 		Action: func(c *cli.Context) (err error) {
 			defer stat.Collect(c.Command.FullName(), &err)()
+			defer tuneReport(c)()
 
 			// delete source
 			copy, err := NewCopy(c, true)
@@ -63,3 +74,42 @@ func NewMoveCommand() *cli.Command {
 	cmd.BashComplete = getBashCompleteFn(cmd, false, false)
 	return cmd
 }
+
+// NewMoveCommandFlags returns the flags of the move command, which are the
+// flags of the copy command and the ones of deleting the sources.
+func NewMoveCommandFlags() []cli.Flag {
+	moveFlags := []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "verify-before-delete",
+			Usage: "verify that the destination exists with the size of the source before deleting the source",
+		},
+		&cli.BoolFlag{
+			Name:  "verify-checksum",
+			Usage: "compare the MD5 of the source with the ETag of the destination as well, requires verify-before-delete flag; the objects uploaded in multiple parts are compared by their sizes only",
+		},
+	}
+
+	// move and copy commands share the same flags
+	return append(NewCopyCommandFlags(), moveFlags...)
+}
+
+// validateMoveCommand validates the flags of the move command which are not
+// shared with the copy command.
+func validateMoveCommand(c *cli.Context) error {
+	if c.Bool("verify-checksum") && !c.Bool("verify-before-delete") {
+		return fmt.Errorf(`"verify-checksum" flag can only be used with "verify-before-delete" flag`)
+	}
+
+	if !c.Bool("verify-before-delete") || c.Args().Len() < 2 {
+		return nil
+	}
+
+	dsturl, err := url.New(c.Args().Get(1), url.WithRaw(c.Bool("raw")))
+	if err != nil {
+		return err
+	}
+	if !dsturl.IsRemote() {
+		return fmt.Errorf(`"verify-before-delete" flag can only be used with remote destinations`)
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"mime"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/urfave/cli/v2"
 
@@ -35,6 +36,8 @@ Examples:
 		> curl https://github.com/peak/s5cmd/ | s5cmd {{.HelpName}} s3://bucket/s5cmd.html
 	04. Compress an object and stream it to a bucket
 		> gzip -c file | s5cmd {{.HelpName}} s3://bucket/file.gz
+	05. Stream stdin to an object, computing its CRC32C checksum on the fly to be verified and stored by S3
+		> tar -cf - dir | s5cmd {{.HelpName}} --checksum-algorithm CRC32C s3://bucket/dir.tar
 `
 
 func NewPipeCommandFlags() []cli.Flag {
@@ -44,16 +47,16 @@ func NewPipeCommandFlags() []cli.Flag {
 			Usage: "set storage class for target ('STANDARD','REDUCED_REDUNDANCY','GLACIER','STANDARD_IA','ONEZONE_IA','INTELLIGENT_TIERING','DEEP_ARCHIVE')",
 		},
 		&cli.IntFlag{
-			Name:    "concurrency",
-			Aliases: []string{"c"},
+			Name:    "part-concurrency",
+			Aliases: []string{"concurrency", "c"},
 			Value:   defaultCopyConcurrency,
-			Usage:   "number of concurrent parts transferred between host and remote server",
+			Usage:   "number of concurrent parts transferred between host and remote server for each file; the default depends on the storage backend",
 		},
 		&cli.IntFlag{
 			Name:    "part-size",
 			Aliases: []string{"p"},
 			Value:   defaultPartSize,
-			Usage:   "size of each part transferred between host and remote server, in MiB",
+			Usage:   "size of each part transferred between host and remote server, in MiB; the default depends on the storage backend",
 		},
 		&MapFlag{
 			Name:  "metadata",
@@ -95,11 +98,24 @@ func NewPipeCommandFlags() []cli.Flag {
 			Name:  "content-disposition",
 			Usage: "set content disposition for target: defines content disposition header for object, e.g. --content-disposition 'attachment; filename=\"filename.jpg\"'",
 		},
+		&cli.StringFlag{
+			Name:  "content-language",
+			Usage: "set content language for target: defines content language header for object, e.g. --content-language en-US",
+		},
 		&cli.BoolFlag{
 			Name:    "no-clobber",
 			Aliases: []string{"n"},
 			Usage:   "do not overwrite destination if already exists",
 		},
+		&cli.GenericFlag{
+			Name:  "checksum-algorithm",
+			Usage: "compute the checksum of the streamed data while it is uploaded, to be verified and stored by the remote storage: (CRC32C, CRC32, SHA256, SHA1)",
+			Value: &EnumValue{
+				Enum:              []string{"CRC32C", "CRC32", "SHA256", "SHA1", ""},
+				Default:           "",
+				ConditionFunction: strings.EqualFold,
+			},
+		},
 	}
 	return pipeFlags
 }
@@ -114,7 +130,7 @@ func NewPipeCommand() *cli.Command {
 		Before: func(c *cli.Context) error {
 			err := validatePipeCommand(c)
 			if err != nil {
-				printError(commandFromContext(c), c.Command.Name, err)
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
 			}
 			return err
 		},
@@ -152,6 +168,8 @@ type Pipe struct {
 	contentType        string
 	contentEncoding    string
 	contentDisposition string
+	contentLanguage    string
+	checksumAlgorithm  string
 	metadata           map[string]string
 
 	// s3 options
@@ -166,17 +184,19 @@ func NewPipe(c *cli.Context, deleteSource bool) (*Pipe, error) {
 
 	dst, err := url.New(c.Args().Get(0), url.WithRaw(c.Bool("raw")))
 	if err != nil {
-		printError(fullCommand, c.Command.Name, err)
+		printError(c.Context, fullCommand, c.Command.Name, err)
 		return nil, err
 	}
 
 	metadata, ok := c.Value("metadata").(MapValue)
 	if !ok {
 		err := errors.New("metadata flag is not a map")
-		printError(fullCommand, c.Command.Name, err)
+		printError(c.Context, fullCommand, c.Command.Name, err)
 		return nil, err
 	}
 
+	storageOpts := NewStorageOpts(c)
+
 	return &Pipe{
 		dst:          dst,
 		op:           c.Command.Name,
@@ -185,8 +205,8 @@ func NewPipe(c *cli.Context, deleteSource bool) (*Pipe, error) {
 		// flags
 		noClobber:          c.Bool("no-clobber"),
 		storageClass:       storage.StorageClass(c.String("storage-class")),
-		concurrency:        c.Int("concurrency"),
-		partSize:           c.Int64("part-size") * megabytes,
+		concurrency:        partConcurrency(c, storageOpts),
+		partSize:           partSizeBytes(c, storageOpts),
 		encryptionMethod:   c.String("sse"),
 		encryptionKeyID:    c.String("sse-kms-key-id"),
 		acl:                c.String("acl"),
@@ -195,9 +215,11 @@ func NewPipe(c *cli.Context, deleteSource bool) (*Pipe, error) {
 		contentType:        c.String("content-type"),
 		contentEncoding:    c.String("content-encoding"),
 		contentDisposition: c.String("content-disposition"),
+		contentLanguage:    c.String("content-language"),
+		checksumAlgorithm:  strings.ToUpper(c.String("checksum-algorithm")),
 		metadata:           metadata,
 		// s3 options
-		storageOpts: NewStorageOpts(c),
+		storageOpts: storageOpts,
 	}, nil
 }
 
@@ -229,8 +251,10 @@ func (c Pipe) Run(ctx context.Context) error {
 		StorageClass:       string(c.storageClass),
 		ContentEncoding:    c.contentEncoding,
 		ContentDisposition: c.contentDisposition,
+		ContentLanguage:    c.contentLanguage,
 		EncryptionMethod:   c.encryptionMethod,
 		EncryptionKeyID:    c.encryptionKeyID,
+		ChecksumAlgorithm:  c.checksumAlgorithm,
 	}
 
 	if c.contentType != "" {
@@ -252,7 +276,7 @@ func (c Pipe) Run(ctx context.Context) error {
 			StorageClass: c.storageClass,
 		},
 	}
-	log.Info(msg)
+	log.InfoContext(ctx, msg)
 
 	return nil
 }
@@ -313,6 +337,17 @@ func validatePipeCommand(c *cli.Context) error {
 		return fmt.Errorf("target %q can not contain glob characters", dst)
 	}
 
+	if err := validateContentDisposition(c.String("content-disposition")); err != nil {
+		return err
+	}
+
+	// the size of the input is unknown, so only the part size is validated.
+	if c.IsSet("part-size") {
+		if err := validateUploadPartSize(c.Int64("part-size") * megabytes); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -7,6 +7,7 @@ import (
 
 	"github.com/urfave/cli/v2"
 
+	"github.com/peak/s5cmd/v2/log"
 	"github.com/peak/s5cmd/v2/log/stat"
 	"github.com/peak/s5cmd/v2/storage"
 	"github.com/peak/s5cmd/v2/storage/url"
@@ -27,6 +28,9 @@ Examples:
 
 	2. Print a remote object url with a specific expiration time to stdout
 		 > s5cmd {{.HelpName}} --expire 24h s3://bucket/prefix/object
+
+	3. Print a url of a specific version of an object on a MinIO server, addressed in path style
+		 > s5cmd --endpoint-url https://minio.example.com --addressing-style path {{.HelpName}} --version-id VERSION_ID s3://bucket/prefix/object
 `
 
 func NewPresignCommand() *cli.Command {
@@ -49,7 +53,7 @@ func NewPresignCommand() *cli.Command {
 		Before: func(c *cli.Context) error {
 			err := validatePresignCommand(c)
 			if err != nil {
-				printError(commandFromContext(c), c.Command.Name, err)
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
 			}
 			return err
 		},
@@ -61,7 +65,7 @@ func NewPresignCommand() *cli.Command {
 
 			src, err := url.New(c.Args().Get(0), url.WithVersion(c.String("version-id")))
 			if err != nil {
-				printError(fullCommand, op, err)
+				printError(c.Context, fullCommand, op, err)
 				return err
 			}
 
@@ -91,16 +95,16 @@ type Presign struct {
 func (c Presign) Run(ctx context.Context) error {
 	client, err := storage.NewRemoteClient(ctx, c.src, c.storageOpts)
 	if err != nil {
-		printError(c.fullCommand, c.op, err)
+		printError(ctx, c.fullCommand, c.op, err)
 		return err
 	}
 
 	url, err := client.Presign(ctx, c.src, c.expire)
 	if err != nil {
-		printError(c.fullCommand, c.op, err)
+		printError(ctx, c.fullCommand, c.op, err)
 		return err
 	}
-	fmt.Println(url)
+	fmt.Fprintln(log.Output(), url)
 	return nil
 }
 
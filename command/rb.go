@@ -34,7 +34,7 @@ func NewRemoveBucketCommand() *cli.Command {
 		Before: func(c *cli.Context) error {
 			err := validateMBCommand(c) // uses same validation function with make bucket command.
 			if err != nil {
-				printError(commandFromContext(c), c.Command.Name, err)
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
 			}
 			return err
 		},
@@ -68,18 +68,18 @@ type RemoveBucket struct {
 func (b RemoveBucket) Run(ctx context.Context) error {
 	bucket, err := url.New(b.src)
 	if err != nil {
-		printError(b.fullCommand, b.op, err)
+		printError(ctx, b.fullCommand, b.op, err)
 		return err
 	}
 
 	client, err := storage.NewRemoteClient(ctx, &url.URL{}, b.storageOpts)
 	if err != nil {
-		printError(b.fullCommand, b.op, err)
+		printError(ctx, b.fullCommand, b.op, err)
 		return err
 	}
 
 	if err := client.RemoveBucket(ctx, bucket.Bucket); err != nil {
-		printError(b.fullCommand, b.op, err)
+		printError(ctx, b.fullCommand, b.op, err)
 		return err
 	}
 
@@ -87,7 +87,7 @@ func (b RemoveBucket) Run(ctx context.Context) error {
 		Operation: b.op,
 		Source:    bucket,
 	}
-	log.Info(msg)
+	log.InfoContext(ctx, msg)
 
 	return nil
 }
@@ -0,0 +1,150 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/peak/s5cmd/v2/storage"
+)
+
+// defaultConfigFile is the path of the config file relative to the home
+// directory.
+var defaultConfigFile = filepath.Join(".s5cmd", "config.toml")
+
+// remote is a named set of storage settings defined in the config file, e.g.
+//
+//	[remotes.minio]
+//	endpoint = "http://localhost:9000"
+//	region = "us-east-1"
+//	addressing_style = "path"
+//	profile = "minio"
+type remote struct {
+	Endpoint        string `toml:"endpoint"`
+	Region          string `toml:"region"`
+	AddressingStyle string `toml:"addressing_style"`
+	Profile         string `toml:"profile"`
+	CredentialsFile string `toml:"credentials_file"`
+	NoSignRequest   bool   `toml:"no_sign_request"`
+}
+
+type config struct {
+	Remotes map[string]remote `toml:"remotes"`
+}
+
+var (
+	remotesMu sync.Mutex
+	remotes   = map[string]remote{}
+)
+
+// loadRemote reads the remote of the given name from the config file. The
+// default config file is used if the path is empty. The remotes are cached
+// since the storage options are created for each command of sync and run.
+func loadRemote(path, name string) (remote, error) {
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return remote{}, err
+		}
+		path = filepath.Join(home, defaultConfigFile)
+	}
+
+	remotesMu.Lock()
+	defer remotesMu.Unlock()
+
+	key := path + "\x00" + name
+	if r, ok := remotes[key]; ok {
+		return r, nil
+	}
+
+	var cfg config
+	md, err := toml.DecodeFile(path, &cfg)
+	if err != nil {
+		return remote{}, fmt.Errorf("invalid config file %q: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return remote{}, fmt.Errorf("invalid config file %q: unknown key %q", path, undecoded[0].String())
+	}
+
+	r, ok := cfg.Remotes[name]
+	if !ok {
+		names := make([]string, 0, len(cfg.Remotes))
+		for name := range cfg.Remotes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return remote{}, fmt.Errorf("remote %q is not defined in config file %q: defined remotes are %v", name, path, names)
+	}
+
+	if err := r.validate(); err != nil {
+		return remote{}, fmt.Errorf("invalid remote %q in config file %q: %w", name, path, err)
+	}
+
+	// the scheme of the endpoint is inferred if it's missing.
+	r.Endpoint, _ = storage.NormalizeEndpoint(r.Endpoint, "")
+
+	remotes[key] = r
+	return r, nil
+}
+
+func (r remote) validate() error {
+	if _, err := storage.NormalizeEndpoint(r.Endpoint, ""); err != nil {
+		return fmt.Errorf("bad value for endpoint %q: %w", r.Endpoint, err)
+	}
+
+	switch r.AddressingStyle {
+	case "", storage.AddressingStyleAuto, storage.AddressingStylePath, storage.AddressingStyleVirtual:
+	default:
+		return fmt.Errorf("unknown addressing style %q: (auto, path, virtual)", r.AddressingStyle)
+	}
+
+	if r.NoSignRequest && (r.Profile != "" || r.CredentialsFile != "") {
+		return fmt.Errorf("no_sign_request can not be used with profile or credentials_file")
+	}
+	return nil
+}
+
+// apply sets the storage options which are not set by the flags, or by the
+// environment variables, from the remote. isSet reports whether the given
+// flag is set, either on the command line or via its environment variable.
+func (r remote) apply(opts *storage.Options, isSet func(flag string) bool) {
+	isEnvSet := func(names ...string) bool {
+		for _, name := range names {
+			if os.Getenv(name) != "" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if r.Endpoint != "" && !isSet("endpoint-url") {
+		opts.Endpoint = r.Endpoint
+	}
+	if r.AddressingStyle != "" && !isSet("addressing-style") {
+		opts.AddressingStyle = r.AddressingStyle
+	}
+	if r.Region != "" && !isEnvSet("AWS_REGION", "AWS_DEFAULT_REGION") {
+		opts.SetRegion(r.Region)
+	}
+
+	// credentials of the remote are not used if any of the credential
+	// flags is set.
+	if isSet("no-sign-request") || isSet("profile") || isSet("credentials-file") ||
+		isSet("credential-process") || isSet("web-identity-token-file") || isSet("assume-role-arn") {
+		return
+	}
+	if r.NoSignRequest {
+		opts.NoSignRequest = true
+		return
+	}
+	if r.Profile != "" && !isEnvSet("AWS_PROFILE") {
+		opts.Profile = r.Profile
+	}
+	if r.CredentialsFile != "" && !isEnvSet("AWS_SHARED_CREDENTIALS_FILE") {
+		opts.CredentialFile = r.CredentialsFile
+	}
+}
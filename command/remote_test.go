@@ -0,0 +1,164 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/peak/s5cmd/v2/storage"
+	"gotest.tools/v3/assert"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	assert.NilError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadRemote(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfig(t, `
+[remotes.minio]
+endpoint = "http://localhost:9000"
+region = "us-east-1"
+addressing_style = "path"
+profile = "minio"
+
+[remotes.public]
+no_sign_request = true
+`)
+
+	r, err := loadRemote(path, "minio")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, r, remote{
+		Endpoint:        "http://localhost:9000",
+		Region:          "us-east-1",
+		AddressingStyle: "path",
+		Profile:         "minio",
+	})
+
+	_, err = loadRemote(path, "unknown")
+	assert.ErrorContains(t, err, `remote "unknown" is not defined in config file`)
+	assert.ErrorContains(t, err, "defined remotes are [minio public]")
+}
+
+func TestLoadRemoteEndpointWithoutScheme(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfig(t, `
+[remotes.minio]
+endpoint = "localhost:9000"
+
+[remotes.cloud]
+endpoint = "storage.example.com"
+`)
+
+	r, err := loadRemote(path, "minio")
+	assert.NilError(t, err)
+	assert.Equal(t, r.Endpoint, "http://localhost:9000")
+
+	r, err = loadRemote(path, "cloud")
+	assert.NilError(t, err)
+	assert.Equal(t, r.Endpoint, "https://storage.example.com")
+}
+
+func TestLoadRemoteInvalidFile(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "invalid toml",
+			content:  `[remotes.minio`,
+			expected: "invalid config file",
+		},
+		{
+			name:     "unknown key",
+			content:  "[remotes.minio]\nendpoint_url = \"http://localhost:9000\"",
+			expected: `unknown key "remotes.minio.endpoint_url"`,
+		},
+		{
+			name:     "endpoint with unsupported scheme",
+			content:  "[remotes.minio]\nendpoint = \"ftp://localhost:9000\"",
+			expected: `unsupported scheme "ftp"`,
+		},
+		{
+			name:     "unknown addressing style",
+			content:  "[remotes.minio]\naddressing_style = \"host\"",
+			expected: `unknown addressing style "host"`,
+		},
+		{
+			name:     "no sign request with profile",
+			content:  "[remotes.minio]\nno_sign_request = true\nprofile = \"minio\"",
+			expected: "no_sign_request can not be used with profile or credentials_file",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := loadRemote(writeConfig(t, tc.content), "minio")
+			assert.ErrorContains(t, err, tc.expected)
+		})
+	}
+}
+
+func TestRemoteApply(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "")
+
+	r := remote{
+		Endpoint:        "http://localhost:9000",
+		AddressingStyle: "virtual",
+		Profile:         "minio",
+		CredentialsFile: "credentials",
+	}
+
+	isSet := func(flags ...string) func(string) bool {
+		return func(flag string) bool {
+			for _, f := range flags {
+				if f == flag {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	// the remote fills the options which are not set.
+	opts := storage.Options{AddressingStyle: "auto"}
+	r.apply(&opts, isSet())
+	assert.DeepEqual(t, opts, storage.Options{
+		Endpoint:        "http://localhost:9000",
+		AddressingStyle: "virtual",
+		Profile:         "minio",
+		CredentialFile:  "credentials",
+	}, cmp.AllowUnexported(storage.Options{}))
+
+	// the flags take precedence over the remote.
+	opts = storage.Options{Endpoint: "http://localhost:8000", AddressingStyle: "path", NoSignRequest: true}
+	r.apply(&opts, isSet("endpoint-url", "addressing-style", "no-sign-request"))
+	assert.DeepEqual(t, opts, storage.Options{
+		Endpoint:        "http://localhost:8000",
+		AddressingStyle: "path",
+		NoSignRequest:   true,
+	}, cmp.AllowUnexported(storage.Options{}))
+
+	// the environment variables take precedence over the remote.
+	t.Setenv("AWS_PROFILE", "default")
+	opts = storage.Options{}
+	r.apply(&opts, isSet())
+	assert.Equal(t, opts.Profile, "")
+	assert.Equal(t, opts.CredentialFile, "credentials")
+}
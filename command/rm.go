@@ -3,7 +3,9 @@ package command
 import (
 	"context"
 	"fmt"
+	urlpkg "net/url"
 	"regexp"
+	"sync"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/urfave/cli/v2"
@@ -11,8 +13,11 @@ import (
 	errorpkg "github.com/peak/s5cmd/v2/error"
 	"github.com/peak/s5cmd/v2/log"
 	"github.com/peak/s5cmd/v2/log/stat"
+	"github.com/peak/s5cmd/v2/parallel"
+	"github.com/peak/s5cmd/v2/progressbar"
 	"github.com/peak/s5cmd/v2/storage"
 	"github.com/peak/s5cmd/v2/storage/url"
+	"github.com/peak/s5cmd/v2/strutil"
 )
 
 var deleteHelpTemplate = `Name:
@@ -54,6 +59,27 @@ Examples:
 
 	10. Delete all versions of all objects in the bucket
 		 > s5cmd {{.HelpName}} --all-versions "s3://bucket/*"
+
+	11. Delete all matching objects but do not list the ones under "prefix/logs/"
+		 > s5cmd {{.HelpName}} --exclude-prefix "logs/" "s3://bucketname/prefix/*"
+
+	12. Delete all matching objects of a huge bucket using its S3 Inventory report instead of listing it
+		 > s5cmd {{.HelpName}} --source-inventory s3://inventory-bucket/bucketname/config/2023-01-01T00-00Z/manifest.json "s3://bucketname/prefix/*.tmp"
+
+	13. Delete all matching objects and stop at the first failed object
+		 > s5cmd {{.HelpName}} --on-error stop "s3://bucketname/prefix/*"
+
+	14. Delete all versions of the matching objects in the S3 Inventory report of a versioned bucket, showing the progress
+		 > s5cmd {{.HelpName}} --all-versions --show-progress --source-inventory s3://inventory-bucket/bucketname/config/2023-01-01T00-00Z/manifest.json "s3://bucketname/*"
+
+	15. Delete all versions and delete markers of the objects under a prefix, e.g. to purge the data of a user
+		 > s5cmd {{.HelpName}} --all-versions "s3://bucket/users/1234/*"
+
+	16. Delete all matching objects of a versioned bucket and report the number of the delete markers created
+		 > s5cmd {{.HelpName}} --report-delete-markers "s3://bucket/prefix/*"
+
+	17. Delete all matching objects of a versioned bucket and remove the delete markers created in the same run
+		 > s5cmd {{.HelpName}} --report-delete-markers --remove-created-delete-markers "s3://bucket/prefix/*"
 `
 
 func NewDeleteCommand() *cli.Command {
@@ -74,6 +100,10 @@ func NewDeleteCommand() *cli.Command {
 				Name:  "include",
 				Usage: "include objects with given pattern",
 			},
+			&cli.StringSliceFlag{
+				Name:  "exclude-prefix",
+				Usage: "do not descend into the prefixes, relative to the source, while listing",
+			},
 			&cli.BoolFlag{
 				Name:  "all-versions",
 				Usage: "list all versions of object(s)",
@@ -82,12 +112,30 @@ func NewDeleteCommand() *cli.Command {
 				Name:  "version-id",
 				Usage: "use the specified version of an object",
 			},
+			&cli.StringFlag{
+				Name:  "source-inventory",
+				Usage: "read the source objects from the CSV or Parquet S3 Inventory report of the given manifest instead of listing them, e.g. s3://bucket/inventory/manifest.json",
+			},
+			&cli.BoolFlag{
+				Name:    "show-progress",
+				Aliases: []string{"sp"},
+				Usage:   "show a progress bar of the deleted objects",
+			},
+			&cli.BoolFlag{
+				Name:  "report-delete-markers",
+				Usage: "report the number of the delete markers created by deleting the objects of a versioned bucket",
+			},
+			&cli.BoolFlag{
+				Name:  "remove-created-delete-markers",
+				Usage: "remove the delete markers created by deleting the objects, requires report-delete-markers flag",
+			},
+			newOnErrorFlag(),
 		},
 		CustomHelpTemplate: deleteHelpTemplate,
 		Before: func(c *cli.Context) error {
 			err := validateRMCommand(c)
 			if err != nil {
-				printError(commandFromContext(c), c.Command.Name, err)
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
 			}
 			return err
 		},
@@ -96,32 +144,46 @@ func NewDeleteCommand() *cli.Command {
 			fullCommand := commandFromContext(c)
 
 			sources := c.Args().Slice()
-			srcUrls, err := newURLs(c.Bool("raw"), c.String("version-id"), c.Bool("all-versions"), sources...)
+			srcUrls, err := newURLs(c.Bool("raw"), c.String("version-id"), c.Bool("all-versions"), c.StringSlice("exclude-prefix"), sources...)
 			if err != nil {
-				printError(fullCommand, c.Command.Name, err)
+				printError(c.Context, fullCommand, c.Command.Name, err)
 				return err
 			}
 
 			excludePatterns, err := createRegexFromWildcard(c.StringSlice("exclude"))
 			if err != nil {
-				printError(fullCommand, c.Command.Name, err)
+				printError(c.Context, fullCommand, c.Command.Name, err)
 				return err
 			}
 
 			includePatterns, err := createRegexFromWildcard(c.StringSlice("include"))
 			if err != nil {
-				printError(fullCommand, c.Command.Name, err)
+				printError(c.Context, fullCommand, c.Command.Name, err)
 				return err
 			}
 
+			// the progress bar is not shown if nothing but the errors are
+			// printed.
+			var commandProgressBar progressbar.ProgressBar = &progressbar.NoOp{}
+			if c.Bool("show-progress") && !log.OnlyErrors() {
+				commandProgressBar = progressbar.NewObjectProgressBar()
+			}
+
 			return Delete{
 				src:         srcUrls,
 				op:          c.Command.Name,
 				fullCommand: fullCommand,
 
 				// flags
-				exclude: c.StringSlice("exclude"),
-				include: c.StringSlice("include"),
+				exclude:         c.StringSlice("exclude"),
+				include:         c.StringSlice("include"),
+				sourceInventory: c.String("source-inventory"),
+				onError:         c.String("on-error"),
+				showProgress:    c.Bool("show-progress"),
+				progressbar:     commandProgressBar,
+
+				reportDeleteMarkers:        c.Bool("report-delete-markers"),
+				removeCreatedDeleteMarkers: c.Bool("remove-created-delete-markers"),
 
 				// patterns
 				excludePatterns: excludePatterns,
@@ -143,8 +205,15 @@ type Delete struct {
 	fullCommand string
 
 	// flag options
-	exclude []string
-	include []string
+	exclude         []string
+	include         []string
+	sourceInventory string
+	onError         string
+	showProgress    bool
+	progressbar     progressbar.ProgressBar
+
+	reportDeleteMarkers        bool
+	removeCreatedDeleteMarkers bool
 
 	// patterns
 	excludePatterns []*regexp.Regexp
@@ -156,46 +225,91 @@ type Delete struct {
 
 // Run remove given sources.
 func (d Delete) Run(ctx context.Context) error {
+	// the remaining objects are not deleted when an object fails with the
+	// stop policy.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	srcurl := d.src[0]
 
 	client, err := storage.NewClient(ctx, srcurl, d.storageOpts)
 	if err != nil {
-		printError(d.fullCommand, d.op, err)
+		printError(ctx, d.fullCommand, d.op, err)
 		return err
 	}
 
-	objch := expandSources(ctx, client, false, d.src...)
+	// no more objects are listed for deletion after the first interrupt.
+	listCtx, cancelList := parallel.ShutdownContext(ctx)
+	defer cancelList()
+
+	var objch <-chan *storage.Object
+	if d.sourceInventory != "" {
+		objch, err = expandInventory(listCtx, d.storageOpts, d.sourceInventory, d.src...)
+		if err != nil {
+			printError(ctx, d.fullCommand, d.op, err)
+			return err
+		}
+	} else {
+		objch = expandSources(listCtx, client, false, d.src...)
+	}
 
 	var (
 		merrorObjects error
 		merrorResult  error
 	)
 
+	// the delete markers are kept to report the number of the removed
+	// object versions and delete markers separately.
+	var (
+		deleteMarkers sync.Map
+		summary       = DeleteSummaryMessage{Operation: d.op}
+	)
+
+	// the objects deleted without a version ID are kept to report the delete
+	// markers created by deleting them.
+	var deleted []*storage.Object
+
+	d.progressbar.Start()
+	defer d.progressbar.Finish()
+
 	// do object->url transformation
 	urlch := make(chan *url.URL)
 	go func() {
 		defer close(urlch)
 
 		for object := range objch {
+			// the listing is drained without deleting the objects once
+			// the run is stopped.
+			if ctx.Err() != nil {
+				continue
+			}
+
 			if object.Type.IsDir() || errorpkg.IsCancelation(object.Err) {
 				continue
 			}
 
 			if err := object.Err; err != nil {
 				merrorObjects = multierror.Append(merrorObjects, err)
-				printError(d.fullCommand, d.op, err)
+				printError(ctx, d.fullCommand, d.op, err)
+				if d.onError == onErrorStop {
+					cancel()
+				}
 				continue
 			}
 
-			isExcluded, err := isObjectExcluded(object, d.excludePatterns, d.includePatterns, srcurl.Prefix)
+			isExcluded, err := isObjectExcluded(object, d.excludePatterns, d.includePatterns, srcurl)
 			if err != nil {
-				printError(d.fullCommand, d.op, err)
+				printError(ctx, d.fullCommand, d.op, err)
 			}
 			if isExcluded {
 				continue
 			}
 
+			if object.IsDeleteMarker {
+				deleteMarkers.Store(versionedKey(object.URL), struct{}{})
+			}
+
+			d.progressbar.IncrementTotalObjects()
 			urlch <- object.URL
 		}
 	}()
@@ -209,7 +323,25 @@ func (d Delete) Run(ctx context.Context) error {
 			}
 
 			merrorResult = multierror.Append(merrorResult, obj.Err)
-			printError(d.fullCommand, d.op, obj.Err)
+			printError(ctx, d.fullCommand, d.op, obj.Err)
+			if d.onError == onErrorStop {
+				cancel()
+			}
+			continue
+		}
+
+		if _, ok := deleteMarkers.Load(versionedKey(obj.URL)); ok {
+			summary.DeleteMarkers++
+		} else {
+			summary.Versions++
+		}
+
+		if d.reportDeleteMarkers && obj.URL.VersionID == "" {
+			deleted = append(deleted, obj)
+		}
+
+		d.progressbar.IncrementCompletedObjects()
+		if d.showProgress {
 			continue
 		}
 
@@ -217,18 +349,171 @@ func (d Delete) Run(ctx context.Context) error {
 			Operation: d.op,
 			Source:    obj.URL,
 		}
-		log.Info(msg)
+		log.InfoContext(ctx, msg)
+	}
+
+	if srcurl.AllVersions {
+		log.InfoContext(ctx, summary)
+	}
+
+	if d.reportDeleteMarkers && ctx.Err() == nil {
+		if err := d.reportCreatedDeleteMarkers(ctx, deleted); err != nil {
+			merrorResult = multierror.Append(merrorResult, err)
+		}
 	}
 
 	return multierror.Append(merrorResult, merrorObjects).ErrorOrNil()
 }
 
+// reportCreatedDeleteMarkers reports the number of the delete markers created
+// by deleting the given objects, and removes them if it's requested.
+func (d Delete) reportCreatedDeleteMarkers(ctx context.Context, deleted []*storage.Object) error {
+	client, err := storage.NewRemoteClient(ctx, d.src[0], d.storageOpts)
+	if err != nil {
+		printError(ctx, d.fullCommand, d.op, err)
+		return err
+	}
+
+	markers, err := createdDeleteMarkers(ctx, client, d.src[0].Bucket, deleted)
+	if err != nil {
+		printError(ctx, d.fullCommand, d.op, err)
+		return err
+	}
+
+	msg := DeleteMarkerSummaryMessage{
+		Operation: d.op,
+		Created:   int64(len(markers)),
+	}
+
+	var merror error
+	if d.removeCreatedDeleteMarkers && len(markers) > 0 {
+		urlch := make(chan *url.URL, len(markers))
+		for _, marker := range markers {
+			urlch <- marker
+		}
+		close(urlch)
+
+		for obj := range client.MultiDelete(ctx, urlch) {
+			if err := obj.Err; err != nil {
+				if errorpkg.IsCancelation(err) {
+					continue
+				}
+				merror = multierror.Append(merror, err)
+				printError(ctx, d.fullCommand, d.op, err)
+				continue
+			}
+
+			msg.Removed++
+			if !d.showProgress {
+				log.InfoContext(ctx, log.InfoMessage{
+					Operation: d.op,
+					Source:    obj.URL,
+				})
+			}
+		}
+	}
+
+	log.InfoContext(ctx, msg)
+	return merror
+}
+
+// createdDeleteMarkers returns the URLs of the delete markers created by
+// deleting the given objects without a version ID. The delete markers which
+// are not returned by the delete operation are looked up by listing the
+// versions of the objects, unless the bucket is not versioned.
+func createdDeleteMarkers(ctx context.Context, client *storage.S3, bucket string, deleted []*storage.Object) ([]*url.URL, error) {
+	var (
+		markers  []*url.URL
+		lookups  []*url.URL
+		markerOf = func(u *url.URL, versionID string) *url.URL {
+			marker := u.Clone()
+			marker.VersionID = versionID
+			return marker
+		}
+	)
+
+	for _, obj := range deleted {
+		if obj.DeleteMarkerVersionID != "" {
+			markers = append(markers, markerOf(obj.URL, obj.DeleteMarkerVersionID))
+			continue
+		}
+		lookups = append(lookups, obj.URL)
+	}
+
+	if len(lookups) == 0 {
+		return markers, nil
+	}
+
+	// no delete markers are created if the versioning has never been enabled
+	// for the bucket.
+	status, err := client.GetBucketVersioning(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	if status == "" {
+		return markers, nil
+	}
+
+	for _, u := range lookups {
+		versionID, err := client.LatestDeleteMarker(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		if versionID != "" {
+			markers = append(markers, markerOf(u, versionID))
+		}
+	}
+	return markers, nil
+}
+
+// versionedKey returns the key of the given object URL with its version.
+func versionedKey(u *url.URL) string {
+	return u.Path + " " + u.VersionID
+}
+
+// DeleteSummaryMessage is a structure for logging the number of the object
+// versions and the delete markers removed by all-versions flag.
+type DeleteSummaryMessage struct {
+	Operation     string `json:"operation"`
+	Versions      int64  `json:"versions"`
+	DeleteMarkers int64  `json:"delete_markers"`
+}
+
+// String returns the string representation of DeleteSummaryMessage.
+func (m DeleteSummaryMessage) String() string {
+	return fmt.Sprintf("%s: removed object versions: %d, delete markers: %d", m.Operation, m.Versions, m.DeleteMarkers)
+}
+
+// JSON returns the JSON representation of DeleteSummaryMessage.
+func (m DeleteSummaryMessage) JSON() string {
+	return strutil.JSON(m)
+}
+
+// DeleteMarkerSummaryMessage is a structure for logging the number of the
+// delete markers created by deleting the objects of a versioned bucket, and
+// the ones removed by remove-created-delete-markers flag.
+type DeleteMarkerSummaryMessage struct {
+	Operation string `json:"operation"`
+	Created   int64  `json:"created_delete_markers"`
+	Removed   int64  `json:"removed_delete_markers"`
+}
+
+// String returns the string representation of DeleteMarkerSummaryMessage.
+func (m DeleteMarkerSummaryMessage) String() string {
+	return fmt.Sprintf("%s: %d delete markers created, %d removed", m.Operation, m.Created, m.Removed)
+}
+
+// JSON returns the JSON representation of DeleteMarkerSummaryMessage.
+func (m DeleteMarkerSummaryMessage) JSON() string {
+	return strutil.JSON(m)
+}
+
 // newSources creates object URL list from given sources.
-func newURLs(isRaw bool, versionID string, isAllVersions bool, sources ...string) ([]*url.URL, error) {
+func newURLs(isRaw bool, versionID string, isAllVersions bool, excludePrefixes []string, sources ...string) ([]*url.URL, error) {
 	var urls []*url.URL
 	for _, src := range sources {
 		srcurl, err := url.New(src, url.WithRaw(isRaw), url.WithVersion(versionID),
-			url.WithAllVersions(isAllVersions))
+			url.WithAllVersions(isAllVersions), url.WithExcludePrefixes(excludePrefixes))
 		if err != nil {
 			return nil, err
 		}
@@ -260,7 +545,7 @@ func validateRMCommand(c *cli.Context) error {
 		return fmt.Errorf("version-id flag can only be used with single source object")
 	}
 
-	srcurls, err := newURLs(c.Bool("raw"), c.String("version-id"), c.Bool("all-versions"), c.Args().Slice()...)
+	srcurls, err := newURLs(c.Bool("raw"), c.String("version-id"), c.Bool("all-versions"), nil, c.Args().Slice()...)
 	if err != nil {
 		return err
 	}
@@ -269,6 +554,14 @@ func validateRMCommand(c *cli.Context) error {
 		return err
 	}
 
+	if err := validateSourceInventory(c, true, srcurls...); err != nil {
+		return err
+	}
+
+	if err := validateDeleteMarkerFlags(c, srcurls...); err != nil {
+		return err
+	}
+
 	var (
 		firstBucket         string
 		hasRemote, hasLocal bool
@@ -299,3 +592,40 @@ func validateRMCommand(c *cli.Context) error {
 
 	return nil
 }
+
+// validateDeleteMarkerFlags validates the flags of reporting and removing the
+// delete markers created by the command.
+func validateDeleteMarkerFlags(c *cli.Context, srcurls ...*url.URL) error {
+	if c.Bool("remove-created-delete-markers") && !c.Bool("report-delete-markers") {
+		return fmt.Errorf(`"remove-created-delete-markers" flag can only be used with "report-delete-markers" flag`)
+	}
+
+	if !c.Bool("report-delete-markers") {
+		return nil
+	}
+
+	// the delete markers are not created when the versions are deleted.
+	if c.Bool(allVersionsFlagName) {
+		return fmt.Errorf(`"report-delete-markers" flag cannot be used with %q flag`, allVersionsFlagName)
+	}
+	if c.String(versionIDFlagName) != "" {
+		return fmt.Errorf(`"report-delete-markers" flag cannot be used with %q flag`, versionIDFlagName)
+	}
+
+	for _, srcurl := range srcurls {
+		if !srcurl.IsRemote() {
+			return fmt.Errorf(`"report-delete-markers" flag can only be used with remote objects`)
+		}
+	}
+
+	if endpoint := c.String("endpoint-url"); endpoint != "" {
+		u, err := urlpkg.Parse(endpoint)
+		if err != nil {
+			return err
+		}
+		if storage.IsGoogleEndpoint(*u) {
+			return fmt.Errorf(versioningNotSupportedWarning, endpoint)
+		}
+	}
+	return nil
+}
@@ -3,18 +3,25 @@ package command
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/kballard/go-shellquote"
 	"github.com/urfave/cli/v2"
 
+	"github.com/peak/s5cmd/v2/log"
+	"github.com/peak/s5cmd/v2/orderedwriter"
 	"github.com/peak/s5cmd/v2/parallel"
+	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/storage/url"
 )
 
 var runHelpTemplate = `Name:
@@ -32,6 +39,22 @@ Examples:
 
 	2. Read commands from standard input and execute in parallel.
 		 > cat commands.txt | s5cmd {{.HelpName}}
+
+	3. Run the commands declared as JSON objects, one per line, in "commands.jsonl" file
+	   e.g. {"op": "cp", "args": ["s3://bucket/my file.txt", "dir/"], "flags": {"acl": "private"}}
+		 > s5cmd {{.HelpName}} --jsonl commands.jsonl
+
+	4. Run the valid commands in "commands.jsonl" file and skip the malformed lines
+		 > s5cmd {{.HelpName}} --jsonl --continue-on-error commands.jsonl
+
+	5. Run the commands declared in "commands.txt" object in parallel, reading them as the object is downloaded
+		 > s5cmd {{.HelpName}} s3://bucket/commands.txt
+
+	6. Run the commands declared in "commands.txt" file, running at most 4 commands concurrently for each bucket
+		 > s5cmd {{.HelpName}} --concurrency-per-host 4 commands.txt
+
+	7. Run the commands declared in "commands.txt" file, prefixing the output and the errors of each command with its line number
+		 > s5cmd {{.HelpName}} --tag-output commands.txt
 `
 
 func NewRunCommand() *cli.Command {
@@ -40,19 +63,37 @@ func NewRunCommand() *cli.Command {
 		HelpName:           "run",
 		Usage:              "run commands in batch",
 		CustomHelpTemplate: runHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "jsonl",
+				Usage: "read commands as JSON objects with op, args and flags fields, one per line",
+			},
+			&cli.BoolFlag{
+				Name:  "continue-on-error",
+				Usage: "skip the lines that can not be parsed instead of stopping",
+			},
+			&cli.IntFlag{
+				Name:  "concurrency-per-host",
+				Usage: "maximum number of commands run concurrently for each bucket, 0 means unlimited",
+			},
+			&cli.BoolFlag{
+				Name:  "tag-output",
+				Usage: "prefix the output and the errors of each command with its line number, counting from 0, or add it as command_id field with --json flag",
+			},
+		},
 		Before: func(c *cli.Context) error {
 			err := validateRunCommand(c)
 			if err != nil {
-				printError(commandFromContext(c), c.Command.Name, err)
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
 			}
 			return err
 		},
 		Action: func(c *cli.Context) error {
-			reader := os.Stdin
+			var reader io.Reader = os.Stdin
 			if c.Args().Len() == 1 {
-				f, err := os.Open(c.Args().First())
+				f, err := openCommandFile(c, c.Args().First())
 				if err != nil {
-					printError(commandFromContext(c), c.Command.Name, err)
+					printError(c.Context, commandFromContext(c), c.Command.Name, err)
 					return err
 				}
 				defer f.Close()
@@ -65,19 +106,71 @@ func NewRunCommand() *cli.Command {
 	}
 }
 
+// openCommandFile opens the given command file. The content of a remote
+// object is streamed as it's downloaded, so that the commands start running
+// before the whole object is read.
+func openCommandFile(c *cli.Context, path string) (io.ReadCloser, error) {
+	src, err := url.New(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !src.IsRemote() {
+		return os.Open(path)
+	}
+
+	ctx := c.Context
+	storageOpts := NewStorageOpts(c)
+	client, err := storage.NewRemoteClient(ctx, src, storageOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := client.Stat(ctx, src); err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		// the parts are written to the pipe in order, as in cat command. The
+		// download is canceled when the reader is closed before reading all
+		// the commands.
+		buf := orderedwriter.New(pw)
+		_, err := client.Get(ctx, src, buf, partConcurrency(c, storageOpts),
+			partSizeBytes(c, storageOpts), storage.DownloadConditions{})
+		pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
+
 type Run struct {
 	c      *cli.Context
 	reader io.Reader
 
 	// flags
-	numWorkers int
+	numWorkers         int
+	jsonl              bool
+	continueOnError    bool
+	concurrencyPerHost int
+	tagOutput          bool
+
+	// onSuccess is called with the lines of the commands that are completed
+	// without any error.
+	onSuccess func(line string)
+
+	// onError is called with the errors of the commands.
+	onError func(err error)
 }
 
 func NewRun(c *cli.Context, r io.Reader) Run {
 	return Run{
-		c:          c,
-		reader:     r,
-		numWorkers: c.Int("numworkers"),
+		c:                  c,
+		reader:             r,
+		numWorkers:         c.Int("numworkers"),
+		jsonl:              c.Bool("jsonl"),
+		continueOnError:    c.Bool("continue-on-error"),
+		concurrencyPerHost: c.Int("concurrency-per-host"),
+		tagOutput:          c.Bool("tag-output"),
 	}
 }
 
@@ -87,12 +180,20 @@ func (r Run) Run(ctx context.Context) error {
 
 	waiter := parallel.NewWaiter()
 
+	var limiter *parallel.HostLimiter
+	if r.concurrencyPerHost > 0 {
+		limiter = parallel.NewHostLimiter(pm, r.concurrencyPerHost)
+	}
+
 	var errDoneCh = make(chan struct{})
-	var merrorWaiter error
+	var merrorWaiter, merrorLines error
 	go func() {
 		defer close(errDoneCh)
 		for err := range waiter.Err() {
 			merrorWaiter = multierror.Append(merrorWaiter, err)
+			if r.onError != nil {
+				r.onError(err)
+			}
 		}
 	}()
 
@@ -112,9 +213,15 @@ func (r Run) Run(ctx context.Context) error {
 			continue
 		}
 
-		fields, err := shellquote.Split(line)
+		fields, err := r.parseLine(line)
 		if err != nil {
-			return err
+			err := fmt.Errorf("invalid command (line: %v): %w", lineno, err)
+			printError(r.c.Context, commandFromContext(r.c), r.c.Command.Name, err)
+			merrorLines = multierror.Append(merrorLines, err)
+			if r.continueOnError {
+				continue
+			}
+			break
 		}
 
 		if len(fields) == 0 {
@@ -123,28 +230,46 @@ func (r Run) Run(ctx context.Context) error {
 
 		if fields[0] == "run" {
 			err := fmt.Errorf("%q command (line: %v) is not permitted in run-mode", "run", lineno)
-			printError(commandFromContext(r.c), r.c.Command.Name, err)
+			printError(r.c.Context, commandFromContext(r.c), r.c.Command.Name, err)
 			continue
 		}
 
+		line, lineno := line, lineno
 		fn := func() error {
 			subcmd := fields[0]
 
 			cmd := AppCommand(subcmd)
 			if cmd == nil {
 				err := fmt.Errorf("%q command (line: %v) not found", subcmd, lineno)
-				printError(commandFromContext(r.c), r.c.Command.Name, err)
+				printError(r.c.Context, commandFromContext(r.c), r.c.Command.Name, err)
 				return nil
 			}
 
 			flagset := flag.NewFlagSet(subcmd, flag.ExitOnError)
 			if err := flagset.Parse(fields); err != nil {
-				printError(commandFromContext(r.c), r.c.Command.Name, err)
+				printError(r.c.Context, commandFromContext(r.c), r.c.Command.Name, err)
 				return nil
 			}
 
 			ctx := cli.NewContext(app, flagset, r.c)
-			return cmd.Run(ctx)
+			if r.tagOutput {
+				ctx.Context = log.WithCommandID(r.c.Context, lineno)
+			}
+			if err := cmd.Run(ctx); err != nil {
+				return err
+			}
+
+			if r.onSuccess != nil {
+				r.onSuccess(line)
+			}
+			return nil
+		}
+
+		if limiter != nil {
+			if host, ok := commandHost(fields); ok {
+				limiter.Run(host, fn, waiter)
+				continue
+			}
 		}
 
 		pm.Run(fn, waiter)
@@ -154,10 +279,120 @@ func (r Run) Run(ctx context.Context) error {
 	<-errDoneCh
 
 	if reader.Err() != nil {
-		printError(commandFromContext(r.c), r.c.Command.Name, reader.Err())
+		printError(r.c.Context, commandFromContext(r.c), r.c.Command.Name, reader.Err())
 	}
 
-	return multierror.Append(merrorWaiter, reader.Err()).ErrorOrNil()
+	return multierror.Append(merrorWaiter, merrorLines, reader.Err()).ErrorOrNil()
+}
+
+// parseLine splits the given line into the command name, its flags and
+// arguments.
+func (r Run) parseLine(line string) ([]string, error) {
+	if !r.jsonl {
+		return shellquote.Split(line)
+	}
+	return parseJSONLine(line)
+}
+
+// commandHost returns the bucket of the first remote argument of the given
+// command, which is the host of its requests with virtual-host style
+// addressing. The commands of a bucket are limited by concurrency-per-host
+// flag.
+func commandHost(fields []string) (string, bool) {
+	for _, field := range fields[1:] {
+		if strings.HasPrefix(field, "-") {
+			continue
+		}
+		u, err := url.New(field)
+		if err != nil || !u.IsRemote() {
+			continue
+		}
+		return u.Bucket, true
+	}
+	return "", false
+}
+
+// jsonCommand is a command declared as a JSON object.
+type jsonCommand struct {
+	Op    string                 `json:"op"`
+	Args  []string               `json:"args"`
+	Flags map[string]interface{} `json:"flags"`
+}
+
+// parseJSONLine decodes the given line into the command name, its flags and
+// arguments. Since the arguments are given explicitly, they can contain any
+// character, e.g. spaces and quotes.
+func parseJSONLine(line string) ([]string, error) {
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+
+	var cmd jsonCommand
+	if err := decoder.Decode(&cmd); err != nil {
+		return nil, err
+	}
+
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after the command")
+	}
+
+	if cmd.Op == "" {
+		return nil, fmt.Errorf("op field is required")
+	}
+
+	names := make([]string, 0, len(cmd.Flags))
+	for name := range cmd.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := []string{cmd.Op}
+	for _, name := range names {
+		values, err := jsonFlagValues(cmd.Flags[name])
+		if err != nil {
+			return nil, fmt.Errorf("flag %q: %w", name, err)
+		}
+
+		for _, value := range values {
+			fields = append(fields, fmt.Sprintf("--%v=%v", name, value))
+		}
+	}
+
+	// arguments may start with a dash, do not let them be parsed as flags.
+	if len(cmd.Args) > 0 {
+		fields = append(fields, "--")
+		fields = append(fields, cmd.Args...)
+	}
+
+	return fields, nil
+}
+
+// jsonFlagValues returns the values of a flag. Arrays are used for the flags
+// that can be given multiple times, e.g. exclude.
+func jsonFlagValues(v interface{}) ([]string, error) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case json.Number:
+		return []string{v.String()}, nil
+	case []interface{}:
+		var values []string
+		for _, elem := range v {
+			if _, ok := elem.([]interface{}); ok {
+				return nil, fmt.Errorf("nested arrays are not supported")
+			}
+			elemValues, err := jsonFlagValues(elem)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, elemValues...)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported value %v", v)
+	}
 }
 
 // Reader is a cancelable reader.
@@ -223,5 +458,19 @@ func validateRunCommand(c *cli.Context) error {
 	if c.Args().Len() > 1 {
 		return fmt.Errorf("expected only 1 file")
 	}
+
+	if c.Int("concurrency-per-host") < 0 {
+		return fmt.Errorf(`"concurrency-per-host" flag must be a positive number`)
+	}
+
+	if c.Args().Len() == 1 {
+		src, err := url.New(c.Args().First())
+		if err != nil {
+			return err
+		}
+		if src.IsRemote() && (src.IsWildcard() || src.IsPrefix() || src.IsBucket()) {
+			return fmt.Errorf("remote file must be an object")
+		}
+	}
 	return nil
 }
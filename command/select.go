@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -12,6 +15,7 @@ import (
 	"github.com/urfave/cli/v2"
 
 	errorpkg "github.com/peak/s5cmd/v2/error"
+	"github.com/peak/s5cmd/v2/log"
 	"github.com/peak/s5cmd/v2/log/stat"
 	"github.com/peak/s5cmd/v2/parallel"
 	"github.com/peak/s5cmd/v2/storage"
@@ -39,12 +43,24 @@ Examples:
 
 	04. Query files that contain lines of JSON objects
 		 > s5cmd select json --query "SELECT s.id FROM s3object s WHERE s.lineNumber = 1"
+
+	05. Run the same query on all CSV files under a prefix and concatenate the results
+		 > s5cmd select csv --use-header USE --query "SELECT s.id FROM s3object s" "s3://bucket/prefix/*.csv"
+
+	06. Run the same query on all objects of a prefix
+		 > s5cmd select json --query "SELECT s.id FROM s3object s" "s3://bucket/prefix/"
+
+	07. Select the given columns of the first 10 matching rows without writing the query
+		 > s5cmd select csv --use-header USE --columns avg_price,quantity --where "s.item='avocado'" --limit 10 "s3://bucket/prices.csv"
+
+	08. Run the same query on all CSV files under a prefix and upload the results to an object
+		 > s5cmd select csv --use-header USE --query "SELECT s.id FROM s3object s" --output "s3://bucket/result.csv" "s3://bucket/prefix/*.csv"
 `
 
 func beforeFunc(c *cli.Context) error {
 	err := validateSelectCommand(c)
 	if err != nil {
-		printError(commandFromContext(c), c.Command.Name, err)
+		printError(c.Context, commandFromContext(c), c.Command.Name, err)
 	}
 	return err
 }
@@ -62,7 +78,7 @@ func buildSelect(c *cli.Context, inputFormat string, inputStructure *string) (cm
 	)
 
 	if err != nil {
-		printError(fullCommand, c.Command.Name, err)
+		printError(c.Context, fullCommand, c.Command.Name, err)
 		return nil, err
 	}
 
@@ -71,6 +87,11 @@ func buildSelect(c *cli.Context, inputFormat string, inputStructure *string) (cm
 		outputFormat = inputFormat
 	}
 
+	query := c.String("query")
+	if query == "" {
+		query = buildSelectQuery(splitColumns(c.String("columns")), c.String("where"), c.Int("limit"))
+	}
+
 	cmd = &Select{
 		src:         src,
 		op:          c.Command.Name,
@@ -78,7 +99,7 @@ func buildSelect(c *cli.Context, inputFormat string, inputStructure *string) (cm
 		// flags
 		inputFormat:           inputFormat,
 		outputFormat:          outputFormat,
-		query:                 c.String("query"),
+		query:                 query,
 		compressionType:       c.String("compression"),
 		exclude:               c.StringSlice("exclude"),
 		forceGlacierTransfer:  c.Bool("force-glacier-transfer"),
@@ -87,6 +108,14 @@ func buildSelect(c *cli.Context, inputFormat string, inputStructure *string) (cm
 		storageOpts: NewStorageOpts(c),
 	}
 
+	if output := c.String("output"); output != "" {
+		cmd.output, err = url.New(output, url.WithRaw(true))
+		if err != nil {
+			printError(c.Context, fullCommand, c.Command.Name, err)
+			return nil, err
+		}
+	}
+
 	// parquet files don't have an input structure
 	if inputStructure != nil {
 		cmd.inputStructure = *inputStructure
@@ -101,10 +130,26 @@ func NewSelectCommand() *cli.Command {
 			Aliases: []string{"e"},
 			Usage:   "SQL expression to use to select from the objects",
 		},
+		&cli.StringFlag{
+			Name:  "columns",
+			Usage: "comma separated list of the columns to select, used to build the query if --query is not given (default: all columns)",
+		},
+		&cli.StringFlag{
+			Name:  "where",
+			Usage: "condition of the rows to select, used to build the query if --query is not given, e.g. \"s.item='avocado'\"",
+		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "maximum number of rows to select from each object, used to build the query if --query is not given",
+		},
 		&cli.StringFlag{
 			Name:  "output-format",
 			Usage: "output format of the result (options: json, csv)",
 		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "write the result to the given local file or remote object instead of standard output",
+		},
 		&cli.StringSliceFlag{
 			Name:  "exclude",
 			Usage: "exclude objects with given pattern",
@@ -165,7 +210,7 @@ func NewSelectCommand() *cli.Command {
 					quotedDelimiter, err := strconv.Unquote(`"` + delimiter + `"`)
 
 					if err != nil {
-						printError("select csv", c.Command.Name, err)
+						printError(c.Context, "select csv", c.Command.Name, err)
 						return err
 					}
 
@@ -183,7 +228,7 @@ func NewSelectCommand() *cli.Command {
 					// that AWS does not support.
 					cmd.fileHeaderInfo = c.String("use-header")
 					if err != nil {
-						printError(cmd.fullCommand, c.Command.Name, err)
+						printError(c.Context, cmd.fullCommand, c.Command.Name, err)
 						return err
 					}
 					return cmd.Run(c.Context)
@@ -215,7 +260,7 @@ func NewSelectCommand() *cli.Command {
 					structure := c.String("structure")
 					cmd, err := buildSelect(c, "json", &structure)
 					if err != nil {
-						printError(cmd.fullCommand, c.Command.Name, err)
+						printError(c.Context, cmd.fullCommand, c.Command.Name, err)
 						return err
 					}
 					return cmd.Run(c.Context)
@@ -230,7 +275,7 @@ func NewSelectCommand() *cli.Command {
 				Action: func(c *cli.Context) (err error) {
 					cmd, err := buildSelect(c, "parquet", nil)
 					if err != nil {
-						printError(cmd.fullCommand, c.Command.Name, err)
+						printError(c.Context, cmd.fullCommand, c.Command.Name, err)
 						return err
 					}
 					return cmd.Run(c.Context)
@@ -246,7 +291,7 @@ func NewSelectCommand() *cli.Command {
 		Before: func(c *cli.Context) (err error) {
 			if c.Args().Len() == 0 {
 				err = fmt.Errorf("expected source argument")
-				printError(commandFromContext(c), c.Command.Name, err)
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
 				return err
 			}
 			return nil
@@ -256,7 +301,7 @@ func NewSelectCommand() *cli.Command {
 			structure := "lines"
 			cmd, err := buildSelect(c, "json", &structure)
 			if err != nil {
-				printError(cmd.fullCommand, c.Command.Name, err)
+				printError(c.Context, cmd.fullCommand, c.Command.Name, err)
 				return err
 			}
 			return cmd.Run(c.Context)
@@ -283,6 +328,10 @@ type Select struct {
 	forceGlacierTransfer  bool
 	ignoreGlacierWarnings bool
 
+	// output is the file or the object the result is written to, instead of
+	// the standard output.
+	output *url.URL
+
 	// s3 options
 	storageOpts storage.Options
 }
@@ -291,17 +340,24 @@ type Select struct {
 func (s Select) Run(ctx context.Context) error {
 	client, err := storage.NewRemoteClient(ctx, s.src, s.storageOpts)
 	if err != nil {
-		printError(s.fullCommand, s.op, err)
+		printError(ctx, s.fullCommand, s.op, err)
 		return err
 	}
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	objch, err := expandSource(ctx, client, false, s.src)
-	if err != nil {
-		printError(s.fullCommand, s.op, err)
-		return err
+	// the objects of a prefix or a bucket are listed like cat command,
+	// otherwise the source is expanded if it is a wildcard.
+	var objch <-chan *storage.Object
+	if s.src.IsPrefix() || s.src.IsBucket() {
+		objch = client.List(ctx, s.src, false)
+	} else {
+		objch, err = expandSource(ctx, client, false, s.src)
+		if err != nil {
+			printError(ctx, s.fullCommand, s.op, err)
+			return err
+		}
 	}
 
 	var (
@@ -309,6 +365,18 @@ func (s Select) Run(ctx context.Context) error {
 		merrorObjects error
 	)
 
+	excludePatterns, err := createRegexFromWildcard(s.exclude)
+	if err != nil {
+		printError(ctx, s.fullCommand, s.op, err)
+		return err
+	}
+
+	output, err := s.openOutput(ctx)
+	if err != nil {
+		printError(ctx, s.fullCommand, s.op, err)
+		return err
+	}
+
 	waiter := parallel.NewWaiter()
 	errDoneCh := make(chan struct{})
 	writeDoneCh := make(chan struct{})
@@ -317,7 +385,7 @@ func (s Select) Run(ctx context.Context) error {
 	go func() {
 		defer close(errDoneCh)
 		for err := range waiter.Err() {
-			printError(s.fullCommand, s.op, err)
+			printError(ctx, s.fullCommand, s.op, err)
 			merrorWaiter = multierror.Append(merrorWaiter, err)
 		}
 	}()
@@ -334,21 +402,15 @@ func (s Select) Run(ctx context.Context) error {
 				// Drain the channel.
 				continue
 			}
-			if _, err := os.Stdout.Write(append(record, '\n')); err != nil {
+			if _, err := output.Write(append(record, '\n')); err != nil {
 				// Stop reading upstream. Notably useful for EPIPE.
 				cancel()
-				printError(s.fullCommand, s.op, err)
+				printError(ctx, s.fullCommand, s.op, err)
 				fatalError = err
 			}
 		}
 	}()
 
-	excludePatterns, err := createRegexFromWildcard(s.exclude)
-	if err != nil {
-		printError(s.fullCommand, s.op, err)
-		return err
-	}
-
 	for object := range objch {
 		if object.Type.IsDir() || errorpkg.IsCancelation(object.Err) {
 			continue
@@ -356,7 +418,7 @@ func (s Select) Run(ctx context.Context) error {
 
 		if err := object.Err; err != nil {
 			merrorObjects = multierror.Append(merrorObjects, err)
-			printError(s.fullCommand, s.op, err)
+			printError(ctx, s.fullCommand, s.op, err)
 			continue
 		}
 
@@ -364,18 +426,17 @@ func (s Select) Run(ctx context.Context) error {
 			if !s.ignoreGlacierWarnings {
 				err := fmt.Errorf("object '%v' is on Glacier storage", object)
 				merrorObjects = multierror.Append(merrorObjects, err)
-				printError(s.fullCommand, s.op, err)
+				printError(ctx, s.fullCommand, s.op, err)
 			}
 			continue
 		}
 
-		if isURLMatched(excludePatterns, object.URL.Path, s.src.Prefix) {
+		if isURLMatched(excludePatterns, object.URL.Path, s.src) {
 			continue
 		}
 
 		task := s.prepareTask(ctx, client, object.URL, resultCh)
 		parallel.Run(task, waiter)
-
 	}
 
 	waiter.Wait()
@@ -383,9 +444,68 @@ func (s Select) Run(ctx context.Context) error {
 	<-errDoneCh
 	<-writeDoneCh
 
-	return multierror.Append(merrorWaiter, merrorObjects).ErrorOrNil()
+	var merrorOutput error
+	if err := output.Close(); err != nil {
+		printError(ctx, s.fullCommand, s.op, err)
+		merrorOutput = err
+	}
+
+	return multierror.Append(merrorWaiter, merrorObjects, merrorOutput).ErrorOrNil()
+}
+
+// openOutput opens the destination of the result. The result is uploaded as
+// it's written if the output is a remote object, in multiple parts if it's
+// large.
+func (s Select) openOutput(ctx context.Context) (io.WriteCloser, error) {
+	if s.output == nil {
+		return nopWriteCloser{log.Output()}, nil
+	}
+
+	if !s.output.IsRemote() {
+		if err := os.MkdirAll(filepath.Dir(s.output.Absolute()), os.ModePerm); err != nil {
+			return nil, err
+		}
+		return os.Create(s.output.Absolute())
+	}
+
+	client, err := storage.NewRemoteClient(ctx, s.output, s.storageOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	out := &remoteOutput{PipeWriter: pw, done: make(chan error, 1)}
+	go func() {
+		defaults := s.storageOpts.TransferDefaults()
+		err := client.Put(ctx, pr, s.output, storage.Metadata{}, defaults.Concurrency, defaults.PartSize*megabytes)
+		// the writes fail if the upload is failed.
+		pr.CloseWithError(err)
+		out.done <- err
+	}()
+	return out, nil
+}
+
+// remoteOutput is the writer of a remote output, which waits for the upload
+// to complete when it's closed.
+type remoteOutput struct {
+	*io.PipeWriter
+	done chan error
+}
+
+// Close completes the upload and returns its error, if any.
+func (o *remoteOutput) Close() error {
+	o.PipeWriter.Close()
+	return <-o.done
+}
+
+// nopWriteCloser is a writer whose Close method does nothing, e.g. for the
+// standard output.
+type nopWriteCloser struct {
+	io.Writer
 }
 
+func (nopWriteCloser) Close() error { return nil }
+
 func (s Select) prepareTask(ctx context.Context, client *storage.S3, url *url.URL, resultCh chan<- json.RawMessage) func() error {
 	return func() error {
 		query := &storage.SelectQuery{
@@ -398,10 +518,24 @@ func (s Select) prepareTask(ctx context.Context, client *storage.S3, url *url.UR
 			CompressionType:       s.compressionType,
 		}
 
-		return client.Select(ctx, url, query, resultCh)
+		err := client.Select(ctx, url, query, resultCh)
+		if err != nil && s.isBatch() {
+			// the failed object is reported if there are multiple objects.
+			return &errorpkg.Error{
+				Op:  s.op,
+				Src: url,
+				Err: err,
+			}
+		}
+		return err
 	}
 }
 
+// isBatch reports whether the source may match multiple objects.
+func (s Select) isBatch() bool {
+	return s.src.IsWildcard() || s.src.IsPrefix() || s.src.IsBucket() || s.src.AllVersions
+}
+
 func validateSelectCommand(c *cli.Context) error {
 	if c.Args().Len() != 1 {
 		return fmt.Errorf("expected source argument")
@@ -430,9 +564,81 @@ func validateSelectCommand(c *cli.Context) error {
 		return fmt.Errorf("source must be remote")
 	}
 
-	if c.String("query") == "" {
+	if srcurl.IsWildcard() || srcurl.IsPrefix() || srcurl.IsBucket() {
+		if c.String("version-id") != "" {
+			return fmt.Errorf("wildcard/prefix operations are disabled with --version-id flag")
+		}
+	}
+
+	hasQueryHelpers := c.IsSet("columns") || c.IsSet("where") || c.IsSet("limit")
+	if c.String("query") != "" && hasQueryHelpers {
+		return fmt.Errorf(`"query" flag cannot be used with "columns", "where" and "limit" flags`)
+	}
+
+	if c.String("query") == "" && !hasQueryHelpers {
 		return fmt.Errorf("query must be non-empty")
 	}
 
+	if c.IsSet("columns") && len(splitColumns(c.String("columns"))) == 0 {
+		return fmt.Errorf("columns must be non-empty")
+	}
+
+	if c.IsSet("limit") && c.Int("limit") <= 0 {
+		return fmt.Errorf("limit must be a positive integer")
+	}
+
+	if output := c.String("output"); output != "" {
+		dsturl, err := url.New(output, url.WithRaw(true))
+		if err != nil {
+			return err
+		}
+		if dsturl.IsRemote() && (dsturl.IsPrefix() || dsturl.IsBucket()) {
+			return fmt.Errorf("output must be a file or an object")
+		}
+	}
+
 	return nil
 }
+
+// identifierRe matches the column names which can be used without quotes.
+var identifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// splitColumns returns the non-empty column names of the given comma
+// separated list.
+func splitColumns(columns string) []string {
+	var result []string
+	for _, column := range strings.Split(columns, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			result = append(result, column)
+		}
+	}
+	return result
+}
+
+// buildSelectQuery builds the SQL expression of --columns, --where and
+// --limit flags. All columns are selected if no columns are given, and the
+// limit is omitted if it is zero.
+func buildSelectQuery(columns []string, where string, limit int) string {
+	projection := "*"
+	if len(columns) > 0 {
+		quoted := make([]string, 0, len(columns))
+		for _, column := range columns {
+			// the column names which are not identifiers, e.g. which
+			// contain spaces, must be quoted.
+			if !identifierRe.MatchString(column) {
+				column = `"` + strings.ReplaceAll(column, `"`, `""`) + `"`
+			}
+			quoted = append(quoted, "s."+column)
+		}
+		projection = strings.Join(quoted, ", ")
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM s3object s", projection)
+	if where != "" {
+		query += " WHERE " + where
+	}
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	return query
+}
@@ -0,0 +1,85 @@
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/v2/storage/url"
+)
+
+func TestBuildSelectQuery(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		columns  string
+		where    string
+		limit    int
+		expected string
+	}{
+		{
+			name:     "all columns",
+			expected: "SELECT * FROM s3object s",
+		},
+		{
+			name:     "columns",
+			columns:  "id,name, price ",
+			expected: "SELECT s.id, s.name, s.price FROM s3object s",
+		},
+		{
+			name:     "columns that are not identifiers",
+			columns:  `avg price,"quoted",_1`,
+			expected: `SELECT s."avg price", s."""quoted""", s._1 FROM s3object s`,
+		},
+		{
+			name:     "where",
+			where:    "s.item='avocado'",
+			expected: "SELECT * FROM s3object s WHERE s.item='avocado'",
+		},
+		{
+			name:     "limit",
+			limit:    10,
+			expected: "SELECT * FROM s3object s LIMIT 10",
+		},
+		{
+			name:     "columns, where and limit",
+			columns:  "avg_price,quantity",
+			where:    "s.item='avocado'",
+			limit:    5,
+			expected: "SELECT s.avg_price, s.quantity FROM s3object s WHERE s.item='avocado' LIMIT 5",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := buildSelectQuery(splitColumns(tc.columns), tc.where, tc.limit)
+			assert.Equal(t, got, tc.expected)
+		})
+	}
+}
+
+func TestSelectOpenLocalOutput(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "results", "result.json")
+	output, err := url.New(path)
+	assert.NilError(t, err)
+
+	w, err := Select{output: output}.openOutput(context.Background())
+	assert.NilError(t, err)
+
+	_, err = w.Write([]byte(`{"id":"id1"}` + "\n"))
+	assert.NilError(t, err)
+	assert.NilError(t, w.Close())
+
+	content, err := os.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), `{"id":"id1"}`+"\n")
+}
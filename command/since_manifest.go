@@ -0,0 +1,118 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/peak/s5cmd/v2/storage"
+)
+
+// sinceManifestContent is the content of the since manifest file.
+type sinceManifestContent struct {
+	LastModified time.Time `json:"last_modified"`
+}
+
+// sinceManifest keeps the latest modification time of the source objects of
+// an incremental copy, so that the next run copies only the objects which
+// are modified after it. The manifest is updated when the copy is completed
+// without any errors, so the failed objects are copied again by the next
+// run.
+type sinceManifest struct {
+	path string
+	// readOnly manifests only filter the objects, e.g. in dry-run mode, and
+	// never modify the manifest file.
+	readOnly bool
+	// since is the latest modification time recorded by the previous run.
+	since *time.Time
+
+	mu     sync.Mutex
+	latest *time.Time
+}
+
+// openSinceManifest reads the given since manifest file. All objects are
+// copied if the manifest doesn't exist yet.
+func openSinceManifest(path string, readOnly bool) (*sinceManifest, error) {
+	m := &sinceManifest{path: path, readOnly: readOnly}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return m, nil
+		}
+		return nil, err
+	}
+
+	var content sinceManifestContent
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("invalid since manifest file %q: %w", path, err)
+	}
+	m.since = &content.LastModified
+	return m, nil
+}
+
+// skip reports whether the given object is modified before the latest
+// modification time recorded by the previous run. The objects modified at
+// the recorded time are not skipped, since the modification times have a
+// resolution of a second and another object may be written in the same
+// second after the previous run. Otherwise, its modification time is taken
+// into account for the next run.
+func (m *sinceManifest) skip(object *storage.Object) bool {
+	if m == nil || object.ModTime == nil {
+		return false
+	}
+
+	if m.since != nil && object.ModTime.Before(*m.since) {
+		return true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.latest == nil || object.ModTime.After(*m.latest) {
+		modTime := *object.ModTime
+		m.latest = &modTime
+	}
+	return false
+}
+
+// Close atomically writes the latest modification time of the copied
+// objects to the manifest file, if there is any.
+func (m *sinceManifest) Close() error {
+	if m == nil || m.readOnly {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.latest == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(sinceManifestContent{LastModified: m.latest.UTC()})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(m.path), filepath.Base(m.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), m.path)
+}
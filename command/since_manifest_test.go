@@ -0,0 +1,91 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/v2/storage"
+)
+
+func TestSinceManifest(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	now := time.Date(2024, 10, 10, 12, 0, 0, 0, time.UTC)
+
+	modTime := func(d time.Duration) *storage.Object {
+		t := now.Add(d)
+		return &storage.Object{ModTime: &t}
+	}
+
+	// all objects are copied by the first run.
+	manifest, err := openSinceManifest(path, false)
+	assert.NilError(t, err)
+	assert.Assert(t, !manifest.skip(modTime(-2*time.Hour)))
+	assert.Assert(t, !manifest.skip(modTime(-time.Hour)))
+	assert.NilError(t, manifest.Close())
+
+	manifest, err = openSinceManifest(path, false)
+	assert.NilError(t, err)
+	assert.Assert(t, manifest.skip(modTime(-2*time.Hour)))
+	// the objects modified at the recorded time are copied again.
+	assert.Assert(t, !manifest.skip(modTime(-time.Hour)))
+	assert.Assert(t, !manifest.skip(modTime(0)))
+	// directories don't have a modification time.
+	assert.Assert(t, !manifest.skip(&storage.Object{}))
+	assert.NilError(t, manifest.Close())
+
+	manifest, err = openSinceManifest(path, false)
+	assert.NilError(t, err)
+	assert.Assert(t, manifest.since.Equal(now))
+}
+
+func TestSinceManifestEqualModTime(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	now := time.Date(2024, 10, 10, 12, 0, 0, 0, time.UTC)
+
+	manifest, err := openSinceManifest(path, false)
+	assert.NilError(t, err)
+	assert.Assert(t, !manifest.skip(&storage.Object{ModTime: &now}))
+	assert.NilError(t, manifest.Close())
+
+	// an object written in the same second as the previous run has the same
+	// modification time, and it's not skipped.
+	manifest, err = openSinceManifest(path, false)
+	assert.NilError(t, err)
+	sameSecond := now
+	assert.Assert(t, !manifest.skip(&storage.Object{ModTime: &sameSecond}))
+	before := now.Add(-time.Second)
+	assert.Assert(t, manifest.skip(&storage.Object{ModTime: &before}))
+}
+
+func TestSinceManifestReadOnly(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	now := time.Now()
+
+	manifest, err := openSinceManifest(path, true)
+	assert.NilError(t, err)
+	assert.Assert(t, !manifest.skip(&storage.Object{ModTime: &now}))
+	assert.NilError(t, manifest.Close())
+
+	_, err = os.Stat(path)
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestSinceManifestInvalid(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	assert.NilError(t, os.WriteFile(path, []byte("2024-10-10"), 0o644))
+
+	_, err := openSinceManifest(path, false)
+	assert.ErrorContains(t, err, "invalid since manifest file")
+}
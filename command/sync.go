@@ -1,11 +1,13 @@
 package command
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -69,6 +71,54 @@ Examples:
 
 	11. Sync all files to S3 bucket but include the only ones with txt and gz extension
 		 > s5cmd {{.HelpName}} --include "*.txt" --include "*.gz" dir/ s3://bucket
+
+	12. Sync all files to S3 bucket but do not walk the "node_modules/" directory
+		 > s5cmd {{.HelpName}} --exclude-prefix "node_modules/" dir/ s3://bucket
+
+	13. Sync a large folder to S3 bucket and skip the files synced before an interruption when re-run
+		 > s5cmd {{.HelpName}} --checkpoint sync.checkpoint folder/ s3://bucket/
+
+	14. Sync local folder and S3 bucket in both directions, and copy the newer one of the objects changed on both sides
+		 > s5cmd {{.HelpName}} --bidirectional --state-file sync.state folder/ "s3://bucket/*"
+
+	15. Sync local folder and S3 bucket in both directions, but fail on the objects changed on both sides
+		 > s5cmd {{.HelpName}} --bidirectional --conflict error --state-file sync.state folder/ "s3://bucket/*"
+
+	16. Sync local folder to S3 bucket, skipping the files whose MD5 matches the ETag of the object regardless of their modification times
+		 > s5cmd {{.HelpName}} --compare etag folder/ s3://bucket/
+
+	17. Sync local folder to S3 bucket, keeping the user metadata of the overwritten objects which is not set by the upload
+		 > s5cmd {{.HelpName}} --metadata-merge --metadata "build=42" folder/ s3://bucket/
+
+	18. Sync local folder to S3 bucket except the log files, deleting the log files in S3 bucket as well
+		 > s5cmd {{.HelpName}} --delete --delete-excluded --exclude "*.log" folder/ s3://bucket/
+
+	19. Sync local folder to S3 bucket and print the request latencies and a suggested number of workers at the end
+		 > s5cmd {{.HelpName}} --tune-report folder/ s3://bucket/
+
+	20. Sync local folder to S3 bucket comparing the MD5 of the files, without hashing the unchanged files again
+		 > s5cmd {{.HelpName}} --compare etag --checksum-cache checksums.cache folder/ s3://bucket/
+
+	21. Sync local folder to S3 bucket retrying each failed part of the large files up to 20 times
+		 > s5cmd {{.HelpName}} --part-retry 20 folder/ s3://bucket/
+
+	22. Sync local folder to S3 bucket except the empty files
+		 > s5cmd {{.HelpName}} --exclude-empty folder/ s3://bucket/
+
+	23. Sync local folder to S3 bucket without walking the "node_modules" directories at any depth
+		 > s5cmd {{.HelpName}} --exclude "**/node_modules/**" folder/ s3://bucket/
+
+	24. Sync local folder to S3 bucket and delete the objects S3 bucket has but local does not have only after all the files are uploaded successfully
+		 > s5cmd {{.HelpName}} --delete --delete-after folder/ s3://bucket/
+
+	25. Sync local folder to S3 bucket, storing the SHA256 digest of the uploaded files in their metadata and skipping the files whose digest matches the stored one
+		 > s5cmd {{.HelpName}} --checksum-metadata-key x-amz-meta-sha256 folder/ s3://bucket/
+
+	26. Sync S3 bucket to a bucket of another account, reading with the credentials of "prod" profile and writing with the credentials of "backup" profile
+		 > s5cmd {{.HelpName}} --source-profile prod --dest-profile backup s3://bucket/ s3://backup-bucket/
+
+	27. Sync local folder to S3 bucket, moving the objects of the files moved in local folder with a server-side copy instead of uploading them again
+		 > s5cmd {{.HelpName}} --delete --track-renames folder/ s3://bucket/
 `
 
 func NewSyncCommandFlags() []cli.Flag {
@@ -77,14 +127,58 @@ func NewSyncCommandFlags() []cli.Flag {
 			Name:  "delete",
 			Usage: "delete objects in destination but not in source",
 		},
+		&cli.BoolFlag{
+			Name:  "delete-excluded",
+			Usage: "delete the objects in destination which are excluded by exclude and include flags as well, with delete flag",
+		},
+		&cli.BoolFlag{
+			Name:  "delete-after",
+			Usage: "delete the objects in destination only after all the objects are synced, and do not delete them if any object fails to sync, with delete flag",
+		},
 		&cli.BoolFlag{
 			Name:  "size-only",
 			Usage: "make size of object only criteria to decide whether an object should be synced",
 		},
+		&cli.GenericFlag{
+			Name:  "compare",
+			Usage: "compare the ETags of the remote objects with the MD5 of the local files, or of the other remote objects, to decide whether the objects of the same size should be synced; the objects uploaded in multiple parts are compared by their modification times: (etag)",
+			Value: &EnumValue{
+				Enum:    []string{compareEtag, ""},
+				Default: "",
+			},
+		},
+		&cli.BoolFlag{
+			Name:  "track-renames",
+			Usage: "move the objects only in destination whose ETag matches the MD5 of a file only in source to the destination of the file with a server-side copy, instead of uploading the file again; the objects are copied instead of moved without delete flag, and the objects uploaded in multiple parts are not matched",
+		},
+		&cli.StringFlag{
+			Name:  "checksum-cache",
+			Usage: "cache the MD5 of the local files compared with compare or track-renames flags in the given file, so that the files whose size and modification time are not changed are not hashed again by the next run",
+		},
 		&cli.BoolFlag{
 			Name:  "exit-on-error",
 			Usage: "stops the sync process if an error is received",
 		},
+		&cli.StringFlag{
+			Name:  "checkpoint",
+			Usage: "periodically record the synced objects to the given file, so that a re-run of an interrupted sync skips them; the file is removed when the sync completes",
+		},
+		&cli.BoolFlag{
+			Name:  "bidirectional",
+			Usage: "sync in both directions: copy the changed objects of each side, and the objects which exist on only one side, to the other",
+		},
+		&cli.GenericFlag{
+			Name:  "conflict",
+			Usage: "resolution of the objects changed on both sides in bidirectional sync: (newer, larger, skip, error); without a state file, the objects of different sizes are treated as conflicts",
+			Value: &EnumValue{
+				Enum:    []string{conflictNewer, conflictLarger, conflictSkip, conflictError},
+				Default: conflictNewer,
+			},
+		},
+		&cli.StringFlag{
+			Name:  "state-file",
+			Usage: "record the state of the last bidirectional sync to the given file to detect which side of an object is changed",
+		},
 	}
 	sharedFlags := NewSharedFlags()
 	return append(syncFlags, sharedFlags...)
@@ -99,14 +193,27 @@ func NewSyncCommand() *cli.Command {
 		CustomHelpTemplate: syncHelpTemplate,
 		Before: func(c *cli.Context) error {
 			// sync command share same validation method as copy command
-			err := validateCopyCommand(c)
+			err := validateBidirectionalSync(c)
+			if err == nil {
+				err = validateSyncDelete(c)
+			}
+			if err == nil {
+				err = validateSyncCompare(c)
+			}
+			if err == nil {
+				err = validateSyncTrackRenames(c)
+			}
+			if err == nil {
+				err = validateCopyCommand(c)
+			}
 			if err != nil {
-				printError(commandFromContext(c), c.Command.Name, err)
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
 			}
 			return err
 		},
 		Action: func(c *cli.Context) (err error) {
 			defer stat.Collect(c.Command.FullName(), &err)()
+			defer tuneReport(c)()
 
 			return NewSync(c).Run(c)
 		},
@@ -128,19 +235,48 @@ type Sync struct {
 	fullCommand string
 
 	// flags
-	delete      bool
-	sizeOnly    bool
-	exitOnError bool
+	delete         bool
+	deleteExcluded bool
+	deleteAfter    bool
+	sizeOnly       bool
+	compare        string
+	trackRenames   bool
+	checksumCache  string
+	checksumKey    string
+	exitOnError    bool
+	onError        string
+	checkpoint     string
+	dryRun         bool
+
+	// bidirectional sync
+	bidirectional bool
+	conflict      string
+	stateFile     string
 
 	// s3 options
 	storageOpts storage.Options
 
-	followSymlinks bool
-	storageClass   storage.StorageClass
-	raw            bool
+	followSymlinks  bool
+	storageClass    storage.StorageClass
+	raw             bool
+	exclude         []string
+	include         []string
+	excludePrefixes []string
+
+	excludePatterns []*regexp.Regexp
+	includePatterns []*regexp.Regexp
 
 	srcRegion string
 	dstRegion string
+
+	srcNoSignRequest bool
+	dstNoSignRequest bool
+	srcProfile       string
+	dstProfile       string
+
+	// listGlacier lists the objects on Glacier storage too, which can't be
+	// synced, e.g. to be verified.
+	listGlacier bool
 }
 
 // NewSync creates Sync from cli.Context
@@ -152,25 +288,54 @@ func NewSync(c *cli.Context) Sync {
 		fullCommand: commandFromContext(c),
 
 		// flags
-		delete:      c.Bool("delete"),
-		sizeOnly:    c.Bool("size-only"),
-		exitOnError: c.Bool("exit-on-error"),
+		delete:         c.Bool("delete"),
+		deleteExcluded: c.Bool("delete-excluded"),
+		deleteAfter:    c.Bool("delete-after"),
+		sizeOnly:       c.Bool("size-only"),
+		compare:        c.String("compare"),
+		trackRenames:   c.Bool("track-renames"),
+		checksumCache:  c.String("checksum-cache"),
+		checksumKey:    normalizeChecksumMetadataKey(c.String("checksum-metadata-key")),
+		exitOnError:    c.Bool("exit-on-error"),
+		onError:        c.String("on-error"),
+		checkpoint:     c.String("checkpoint"),
+		dryRun:         c.Bool("dry-run"),
+
+		// bidirectional sync
+		bidirectional: c.Bool("bidirectional"),
+		conflict:      c.String("conflict"),
+		stateFile:     c.String("state-file"),
 
 		// flags
-		followSymlinks: !c.Bool("no-follow-symlinks"),
-		storageClass:   storage.StorageClass(c.String("storage-class")),
-		raw:            c.Bool("raw"),
+		followSymlinks:  !c.Bool("no-follow-symlinks"),
+		storageClass:    storage.StorageClass(c.String("storage-class")),
+		raw:             c.Bool("raw"),
+		exclude:         c.StringSlice("exclude"),
+		include:         c.StringSlice("include"),
+		excludePrefixes: c.StringSlice("exclude-prefix"),
 		// region settings
 		srcRegion:   c.String("source-region"),
 		dstRegion:   c.String("destination-region"),
 		storageOpts: NewStorageOpts(c),
+
+		srcNoSignRequest: c.Bool("source-no-sign-request"),
+		dstNoSignRequest: c.Bool("dest-no-sign-request"),
+		srcProfile:       c.String("source-profile"),
+		dstProfile:       c.String("dest-profile"),
 	}
 }
 
 // Run compares files, plans necessary s5cmd commands to execute
 // and executes them in order to sync source to destination.
 func (s Sync) Run(c *cli.Context) error {
-	srcurl, err := url.New(s.src, url.WithRaw(s.raw))
+	excludeDirs, err := createDirRegexFromWildcard(s.exclude)
+	if err != nil {
+		printError(c.Context, s.fullCommand, s.op, err)
+		return err
+	}
+
+	srcurl, err := url.New(s.src, url.WithRaw(s.raw), url.WithExcludePrefixes(s.excludePrefixes),
+		url.WithExcludeDirs(excludeDirs))
 	if err != nil {
 		return err
 	}
@@ -180,17 +345,34 @@ func (s Sync) Run(c *cli.Context) error {
 		return err
 	}
 
+	s.excludePatterns, err = createRegexFromWildcard(s.exclude)
+	if err != nil {
+		printError(c.Context, s.fullCommand, s.op, err)
+		return err
+	}
+
+	s.includePatterns, err = createRegexFromWildcard(s.include)
+	if err != nil {
+		printError(c.Context, s.fullCommand, s.op, err)
+		return err
+	}
+
 	ctx, cancel := context.WithCancel(c.Context)
 
-	sourceObjects, destObjects, err := s.getSourceAndDestinationObjects(ctx, cancel, srcurl, dsturl)
+	// the source and destination are not listed any further after the first
+	// interrupt, since the planned operations are not run anymore.
+	listCtx, cancelList := parallel.ShutdownContext(ctx)
+	defer cancelList()
+
+	sourceObjects, destObjects, err := s.getSourceAndDestinationObjects(listCtx, cancel, srcurl, dsturl)
 	if err != nil {
-		printError(s.fullCommand, s.op, err)
+		printError(ctx, s.fullCommand, s.op, err)
 		return err
 	}
 
 	isBatch := srcurl.IsWildcard()
 	if !isBatch && !srcurl.IsRemote() {
-		sourceClient, err := storage.NewClient(ctx, srcurl, s.storageOpts)
+		sourceClient, err := storage.NewClient(ctx, srcurl, s.srcStorageOpts())
 		if err != nil {
 			return err
 		}
@@ -203,6 +385,41 @@ func (s Sync) Run(c *cli.Context) error {
 		isBatch = obj != nil && obj.Type.IsDir()
 	}
 
+	var checkpoint *syncCheckpoint
+	if s.checkpoint != "" {
+		checkpoint, err = openSyncCheckpoint(s.checkpoint, s.dryRun)
+		if err != nil {
+			printError(ctx, s.fullCommand, s.op, err)
+			return err
+		}
+	}
+
+	var cache *checksumCache
+	if s.checksumCache != "" {
+		cache, err = openChecksumCache(s.checksumCache, s.dryRun)
+		if err != nil {
+			printError(ctx, s.fullCommand, s.op, err)
+			return err
+		}
+	}
+
+	var state *syncState
+	if s.bidirectional {
+		if !isBatch {
+			err := fmt.Errorf("bidirectional sync requires the source to be a directory or a prefix")
+			printError(ctx, s.fullCommand, s.op, err)
+			return err
+		}
+
+		if s.stateFile != "" {
+			state, err = openSyncState(s.stateFile, s.dryRun)
+			if err != nil {
+				printError(ctx, s.fullCommand, s.op, err)
+				return err
+			}
+		}
+	}
+
 	onlySource, onlyDest, commonObjects := compareObjects(sourceObjects, destObjects, isBatch)
 
 	sourceObjects = nil
@@ -223,29 +440,135 @@ func (s Sync) Run(c *cli.Context) error {
 
 				os.Exit(1)
 			}
-			printError(s.fullCommand, s.op, err)
+			printError(ctx, s.fullCommand, s.op, err)
 			merrorWaiter = multierror.Append(merrorWaiter, err)
 		}
 	}()
 
-	strategy := NewStrategy(s.sizeOnly) // create comparison strategy.
+	strategy := NewStrategy(s.sizeOnly, s.compare, cache) // create comparison strategy.
+	if s.checksumKey != "" {
+		strategy = &ChecksumMetadataStrategy{
+			key: s.checksumKey,
+			metadata: func(u *url.URL) (*storage.Metadata, error) {
+				opts := s.dstStorageOpts()
+				if u.Bucket == srcurl.Bucket {
+					opts = s.srcStorageOpts()
+				}
+				client, err := storage.NewRemoteClient(ctx, u, opts)
+				if err != nil {
+					return nil, err
+				}
+				_, metadata, err := client.HeadObject(ctx, u)
+				return metadata, err
+			},
+		}
+	}
 	pipeReader, pipeWriter := io.Pipe() // create a reader, writer pipe to pass commands to run
 
+	// the delete commands are run with the other commands, unless they are
+	// deferred until all the objects are synced.
+	var (
+		deferredDeletes bytes.Buffer
+		deleteWriter    io.Writer = pipeWriter
+	)
+	if s.deleteAfter {
+		deleteWriter = &deferredDeletes
+	}
+
+	// conflicts of bidirectional sync which fail the sync.
+	conflictErrCh := make(chan error, 1)
+
+	// planDone is closed once the commands are planned, so that the deferred
+	// deletes are read only after they are written.
+	planDone := make(chan struct{})
+
 	// Create commands in background.
-	go s.planRun(c, onlySource, onlyDest, commonObjects, dsturl, strategy, pipeWriter, isBatch)
+	if s.bidirectional {
+		// the objects only in destination are copied to the source directory.
+		srcdir := srcurl
+		if srcurl.IsWildcard() {
+			srcdir, err = url.New(strings.TrimSuffix(s.src, "*"))
+			if err != nil {
+				return err
+			}
+		}
+		go func() {
+			defer close(planDone)
+			s.planBidirectionalRun(c, onlySource, onlyDest, commonObjects, srcdir, dsturl, pipeWriter, state, conflictErrCh)
+		}()
+	} else {
+		close(conflictErrCh)
+		go func() {
+			defer close(planDone)
+			s.planRun(c, onlySource, onlyDest, commonObjects, dsturl, strategy, cache, pipeWriter, deleteWriter, isBatch, checkpoint)
+		}()
+	}
+
+	run := NewRun(c, pipeReader)
+	if s.onError == onErrorStop {
+		// the remaining commands are not run once a command fails.
+		run.onError = func(err error) {
+			if !errorpkg.IsCancelation(err) {
+				cancel()
+			}
+		}
+	}
+	if checkpoint != nil {
+		run.onSuccess = checkpoint.complete
+	}
+	if state != nil {
+		run.onSuccess = func(line string) {
+			state.complete(line, func(u *url.URL) (*storage.Object, error) {
+				client, err := storage.NewClient(ctx, u, s.storageOpts)
+				if err != nil {
+					return nil, err
+				}
+				return client.Stat(ctx, u)
+			})
+		}
+	}
+
+	err = run.Run(ctx)
+
+	// the run may return before reading all the commands, e.g. when it's
+	// canceled. The pipe is closed so that the planning is not blocked.
+	pipeReader.Close()
+	<-planDone
+	err = multierror.Append(err, merrorWaiter, <-conflictErrCh).ErrorOrNil()
+
+	// the deletions are run once all the objects are synced, and only if
+	// none of them failed.
+	if deferredDeletes.Len() > 0 {
+		if err != nil {
+			printError(ctx, s.fullCommand, s.op, errDeleteAfterFailure)
+		} else {
+			err = NewRun(c, &deferredDeletes).Run(ctx)
+		}
+	}
 
-	err = NewRun(c, pipeReader).Run(ctx)
-	return multierror.Append(err, merrorWaiter).ErrorOrNil()
+	if cerr := checkpoint.Close(err == nil); cerr != nil {
+		printError(ctx, s.fullCommand, s.op, cerr)
+		err = multierror.Append(err, cerr)
+	}
+	if serr := state.Close(); serr != nil {
+		printError(ctx, s.fullCommand, s.op, serr)
+		err = multierror.Append(err, serr)
+	}
+	if cerr := cache.Close(); cerr != nil {
+		printError(ctx, s.fullCommand, s.op, cerr)
+		err = multierror.Append(err, cerr)
+	}
+	return err
 }
 
 // compareObjects compares source and destination objects. It assumes that
 // sourceObjects and destObjects channels are already sorted in ascending order.
 // Returns objects those in only source, only destination
 // and both.
-func compareObjects(sourceObjects, destObjects chan *storage.Object, isSrcBatch bool) (chan *url.URL, chan *url.URL, chan *ObjectPair) {
+func compareObjects(sourceObjects, destObjects chan *storage.Object, isSrcBatch bool) (chan *storage.Object, chan *storage.Object, chan *ObjectPair) {
 	var (
-		srcOnly   = make(chan *url.URL, extsortChannelBufferSize)
-		dstOnly   = make(chan *url.URL, extsortChannelBufferSize)
+		srcOnly   = make(chan *storage.Object, extsortChannelBufferSize)
+		dstOnly   = make(chan *storage.Object, extsortChannelBufferSize)
 		commonObj = make(chan *ObjectPair, extsortChannelBufferSize)
 		srcName   string
 		dstName   string
@@ -272,21 +595,21 @@ func compareObjects(sourceObjects, destObjects chan *storage.Object, isSrcBatch
 
 			if srcOk && dstOk {
 				if srcName < dstName {
-					srcOnly <- src.URL
+					srcOnly <- src
 					src, srcOk = <-sourceObjects
 				} else if srcName == dstName { // if there is a match.
 					commonObj <- &ObjectPair{src: src, dst: dst}
 					src, srcOk = <-sourceObjects
 					dst, dstOk = <-destObjects
 				} else {
-					dstOnly <- dst.URL
+					dstOnly <- dst
 					dst, dstOk = <-destObjects
 				}
 			} else if srcOk {
-				srcOnly <- src.URL
+				srcOnly <- src
 				src, srcOk = <-sourceObjects
 			} else if dstOk {
-				dstOnly <- dst.URL
+				dstOnly <- dst
 				dst, dstOk = <-destObjects
 			} else /* if !srcOK && !dstOk */ {
 				break
@@ -297,16 +620,40 @@ func compareObjects(sourceObjects, destObjects chan *storage.Object, isSrcBatch
 	return srcOnly, dstOnly, commonObj
 }
 
+// srcStorageOpts returns the storage options of the source clients.
+func (s Sync) srcStorageOpts() storage.Options {
+	opts := s.storageOpts
+	if s.srcNoSignRequest {
+		opts.NoSignRequest = true
+	}
+	if s.srcProfile != "" {
+		opts.Profile = s.srcProfile
+	}
+	return opts
+}
+
+// dstStorageOpts returns the storage options of the destination clients.
+func (s Sync) dstStorageOpts() storage.Options {
+	opts := s.storageOpts
+	if s.dstNoSignRequest {
+		opts.NoSignRequest = true
+	}
+	if s.dstProfile != "" {
+		opts.Profile = s.dstProfile
+	}
+	return opts
+}
+
 // getSourceAndDestinationObjects returns source and destination objects from
 // given URLs. The returned channels gives objects sorted in ascending order
 // with respect to their url.Relative path. See also storage.Less.
 func (s Sync) getSourceAndDestinationObjects(ctx context.Context, cancel context.CancelFunc, srcurl, dsturl *url.URL) (chan *storage.Object, chan *storage.Object, error) {
-	sourceClient, err := storage.NewClient(ctx, srcurl, s.storageOpts)
+	sourceClient, err := storage.NewClient(ctx, srcurl, s.srcStorageOpts())
 	if err != nil {
 		return nil, nil, err
 	}
 
-	destClient, err := storage.NewClient(ctx, dsturl, s.storageOpts)
+	destClient, err := storage.NewClient(ctx, dsturl, s.dstStorageOpts())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -319,7 +666,7 @@ func (s Sync) getSourceAndDestinationObjects(ctx context.Context, cancel context
 		destinationURLPath = s.dst + "/*"
 	}
 
-	destObjectsURL, err := url.New(destinationURLPath)
+	destObjectsURL, err := url.New(destinationURLPath, url.WithExcludePrefixes(s.excludePrefixes))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -354,10 +701,10 @@ func (s Sync) getSourceAndDestinationObjects(ctx context.Context, cancel context
 						Command:   s.fullCommand,
 						Operation: s.op,
 					}
-					log.Error(msg)
+					log.ErrorContext(ctx, msg)
 					cancel()
 				}
-				if s.shouldSkipObject(st, true) {
+				if s.shouldSkipObject(ctx, st, true) || s.isExcluded(st, srcurl) {
 					continue
 				}
 				filteredSrcObjectChannel <- *st
@@ -380,7 +727,7 @@ func (s Sync) getSourceAndDestinationObjects(ctx context.Context, cancel context
 		// read and print the external sort errors
 		go func() {
 			for err := range srcErrCh {
-				printError(s.fullCommand, s.op, err)
+				printError(ctx, s.fullCommand, s.op, err)
 			}
 		}()
 	}()
@@ -401,10 +748,17 @@ func (s Sync) getSourceAndDestinationObjects(ctx context.Context, cancel context
 						Command:   s.fullCommand,
 						Operation: s.op,
 					}
-					log.Error(msg)
+					log.ErrorContext(ctx, msg)
 					cancel()
 				}
-				if s.shouldSkipObject(dt, false) {
+				if s.shouldSkipObject(ctx, dt, false) {
+					continue
+				}
+				// the excluded objects of the destination are kept out of
+				// the sync, unless they are to be deleted. They are
+				// excluded from the source as well, so that they are
+				// deleted as the objects only in destination.
+				if !s.deleteExcluded && s.isExcluded(dt, destObjectsURL) {
 					continue
 				}
 				filteredDstObjectChannel <- *dt
@@ -427,7 +781,7 @@ func (s Sync) getSourceAndDestinationObjects(ctx context.Context, cancel context
 		// read and print the external sort errors
 		go func() {
 			for err := range dstErrCh {
-				printError(s.fullCommand, s.op, err)
+				printError(ctx, s.fullCommand, s.op, err)
 			}
 		}()
 	}()
@@ -438,37 +792,65 @@ func (s Sync) getSourceAndDestinationObjects(ctx context.Context, cancel context
 // planRun prepares the commands and writes them to writer 'w'.
 func (s Sync) planRun(
 	c *cli.Context,
-	onlySource, onlyDest chan *url.URL,
+	onlySource chan *storage.Object,
+	onlyDest chan *storage.Object,
 	common chan *ObjectPair,
 	dsturl *url.URL,
 	strategy SyncStrategy,
+	cache *checksumCache,
 	w io.WriteCloser,
+	deleteWriter io.Writer,
 	isBatch bool,
+	checkpoint *syncCheckpoint,
 ) {
 	defer w.Close()
 
 	// Always use raw mode since sync command generates commands
 	// from raw S3 objects. Otherwise, generated copy command will
-	// try to expand given source.
+	// try to expand given source. The objects are already filtered by
+	// exclude and include flags while listing, so the flags are not
+	// passed to the generated commands. The tune report is printed once
+	// for the whole sync.
 	defaultFlags := map[string]interface{}{
-		"raw": true,
+		"raw":         true,
+		"exclude":     nil,
+		"include":     nil,
+		"tune-report": nil,
 	}
 
 	// it should wait until both of the child goroutines for onlySource and common channels
 	// are completed before closing the WriteCloser w to ensure that all URLs are processed.
 	var wg sync.WaitGroup
 
+	// the files moved in source are moved in destination, and the rest of
+	// the objects are copied and deleted as usual.
+	if s.trackRenames {
+		onlySource, onlyDest = s.planRenames(c, onlySource, onlyDest, dsturl, cache, defaultFlags, w, isBatch, checkpoint)
+	}
+
 	// only in source
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		for srcurl := range onlySource {
+		for srcObject := range onlySource {
+			srcurl := srcObject.URL
 			curDestURL := generateDestinationURL(srcurl, dsturl, isBatch)
+			if checkpoint.isCompleted(srcObject) {
+				printDebug(s.op, errorpkg.ErrObjectCheckpointed, srcurl, curDestURL)
+				continue
+			}
+
+			if s.dryRun {
+				printSyncDryRun(c.Context, "cp", syncReasonNew, srcurl, curDestURL)
+				continue
+			}
+
 			command, err := generateCommand(c, "cp", defaultFlags, srcurl, curDestURL)
 			if err != nil {
 				printDebug(s.op, err, srcurl, curDestURL)
 				continue
 			}
+			checkpoint.track(command, srcObject)
 			fmt.Fprintln(w, command)
 		}
 	}()
@@ -480,17 +862,28 @@ func (s Sync) planRun(
 		for commonObject := range common {
 			sourceObject, destObject := commonObject.src, commonObject.dst
 			curSourceURL, curDestURL := sourceObject.URL, destObject.URL
-			err := strategy.ShouldSync(sourceObject, destObject) // check if object should be copied.
+			if checkpoint.isCompleted(sourceObject) {
+				printDebug(s.op, errorpkg.ErrObjectCheckpointed, curSourceURL, curDestURL)
+				continue
+			}
+
+			reason, err := shouldSync(strategy, sourceObject, destObject) // check if object should be copied.
 			if err != nil {
 				printDebug(s.op, err, curSourceURL, curDestURL)
 				continue
 			}
 
+			if s.dryRun {
+				printSyncDryRun(c.Context, "cp", reason, curSourceURL, curDestURL)
+				continue
+			}
+
 			command, err := generateCommand(c, "cp", defaultFlags, curSourceURL, curDestURL)
 			if err != nil {
 				printDebug(s.op, err, curSourceURL, curDestURL)
 				continue
 			}
+			checkpoint.track(command, sourceObject)
 			fmt.Fprintln(w, command)
 		}
 	}()
@@ -505,7 +898,11 @@ func (s Sync) planRun(
 			dstURLs := make([]*url.URL, 0, extsortChunkSize)
 
 			for d := range onlyDest {
-				dstURLs = append(dstURLs, d)
+				if s.dryRun {
+					printSyncDryRun(c.Context, "rm", syncReasonExtraDelete, d.URL, nil)
+					continue
+				}
+				dstURLs = append(dstURLs, d.URL)
 			}
 
 			if len(dstURLs) == 0 {
@@ -517,7 +914,7 @@ func (s Sync) planRun(
 				printDebug(s.op, err, dstURLs...)
 				return
 			}
-			fmt.Fprintln(w, command)
+			fmt.Fprintln(deleteWriter, command)
 		} else {
 			// we only need  to consume them from the channel so that rest of the objects
 			// can be sent to channel.
@@ -530,6 +927,91 @@ func (s Sync) planRun(
 	wg.Wait()
 }
 
+// The reasons of the operations of sync which are printed in dry-run mode.
+const (
+	syncReasonNew             = "new"
+	syncReasonSizeDiffers     = "size-differs"
+	syncReasonEtagDiffers     = "etag-differs"
+	syncReasonNewer           = "newer"
+	syncReasonExtraDelete     = "extra-delete"
+	syncReasonRenamed         = "renamed"
+	syncReasonChecksumDiffers = "checksum-differs"
+)
+
+// errDeleteAfterFailure is printed when the objects only in destination are
+// not deleted by delete-after flag, since some objects failed to sync.
+var errDeleteAfterFailure = fmt.Errorf("the objects only in destination are not deleted since some objects failed to sync")
+
+// shouldSync checks whether the source object which exists in the destination
+// too should be synced by the given strategy, and returns the reason of
+// syncing it.
+func shouldSync(strategy SyncStrategy, srcObject, dstObject *storage.Object) (string, error) {
+	if s, ok := strategy.(reasonedStrategy); ok {
+		return s.shouldSync(srcObject, dstObject)
+	}
+	if err := strategy.ShouldSync(srcObject, dstObject); err != nil {
+		return "", err
+	}
+	if srcObject.Size != dstObject.Size {
+		return syncReasonSizeDiffers, nil
+	}
+	return syncReasonNewer, nil
+}
+
+// compareEtag is the value of the compare flag to compare the objects by
+// their ETags.
+const compareEtag = "etag"
+
+// validateSyncDelete validates the flags of sync which can only be used with
+// delete flag.
+func validateSyncDelete(c *cli.Context) error {
+	if c.Bool("delete-excluded") && !c.Bool("delete") {
+		return fmt.Errorf(`"delete-excluded" flag can only be used with "delete" flag`)
+	}
+	if c.Bool("delete-after") && !c.Bool("delete") {
+		return fmt.Errorf(`"delete-after" flag can only be used with "delete" flag`)
+	}
+	return nil
+}
+
+// validateSyncCompare validates the compare flag of sync.
+func validateSyncCompare(c *cli.Context) error {
+	if c.String("checksum-metadata-key") != "" {
+		if c.String("compare") != "" || c.Bool("size-only") {
+			return fmt.Errorf(`"checksum-metadata-key" flag cannot be used with "compare" or "size-only" flags`)
+		}
+		if c.Bool("bidirectional") {
+			return fmt.Errorf(`"checksum-metadata-key" flag cannot be used with "bidirectional" flag`)
+		}
+	}
+
+	if c.String("compare") == "" {
+		if c.IsSet("checksum-cache") && !c.Bool("track-renames") {
+			return fmt.Errorf(`"checksum-cache" flag can only be used with "compare" or "track-renames" flags`)
+		}
+		return nil
+	}
+
+	if c.Bool("size-only") {
+		return fmt.Errorf(`"compare" and "size-only" flags cannot be used together`)
+	}
+	if c.Bool("bidirectional") {
+		return fmt.Errorf(`"compare" flag cannot be used with "bidirectional" flag`)
+	}
+	return nil
+}
+
+// printSyncDryRun prints the operation which would be run by sync with its
+// reason, instead of running it in dry-run mode.
+func printSyncDryRun(ctx context.Context, op, reason string, srcurl, dsturl *url.URL) {
+	log.InfoContext(ctx, log.InfoMessage{
+		Operation:   op,
+		Source:      srcurl,
+		Destination: dsturl,
+		Reason:      reason,
+	})
+}
+
 // generateDestinationURL generates destination url for given
 // source url if it would have been in destination.
 func generateDestinationURL(srcurl, dsturl *url.URL, isBatch bool) *url.URL {
@@ -549,23 +1031,31 @@ func generateDestinationURL(srcurl, dsturl *url.URL, isBatch bool) *url.URL {
 	return dsturl.Join(objname)
 }
 
+// isExcluded checks if the object is excluded by the exclude and include
+// flags. The patterns are matched against the key of the object relative to
+// the root of the given source.
+func (s Sync) isExcluded(object *storage.Object, src *url.URL) bool {
+	excluded, _ := isObjectExcluded(object, s.excludePatterns, s.includePatterns, src)
+	return excluded
+}
+
 // shouldSkipObject checks is object should be skipped.
-func (s Sync) shouldSkipObject(object *storage.Object, verbose bool) bool {
+func (s Sync) shouldSkipObject(ctx context.Context, object *storage.Object, verbose bool) bool {
 	if object.Type.IsDir() || errorpkg.IsCancelation(object.Err) {
 		return true
 	}
 
 	if err := object.Err; err != nil {
 		if verbose {
-			printError(s.fullCommand, s.op, err)
+			printError(ctx, s.fullCommand, s.op, err)
 		}
 		return true
 	}
 
-	if object.StorageClass.IsGlacier() {
+	if object.StorageClass.IsGlacier() && !s.listGlacier {
 		if verbose {
 			err := fmt.Errorf("object '%v' is on Glacier storage", object)
-			printError(s.fullCommand, s.op, err)
+			printError(ctx, s.fullCommand, s.op, err)
 		}
 		return true
 	}
@@ -574,7 +1064,7 @@ func (s Sync) shouldSkipObject(object *storage.Object, verbose bool) bool {
 
 // shouldStopSync determines whether a sync process should be stopped or not.
 func (s Sync) shouldStopSync(err error) bool {
-	if err == storage.ErrNoObjectFound {
+	if err == storage.ErrNoObjectFound || errorpkg.IsCancelation(err) {
 		return false
 	}
 	if awsErr, ok := err.(awserr.Error); ok {
@@ -583,5 +1073,5 @@ func (s Sync) shouldStopSync(err error) bool {
 			return true
 		}
 	}
-	return s.exitOnError
+	return s.exitOnError || s.onError == onErrorStop
 }
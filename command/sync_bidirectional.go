@@ -0,0 +1,463 @@
+package command
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/urfave/cli/v2"
+
+	errorpkg "github.com/peak/s5cmd/v2/error"
+	"github.com/peak/s5cmd/v2/log"
+	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/storage/url"
+)
+
+const (
+	conflictNewer  = "newer"
+	conflictLarger = "larger"
+	conflictSkip   = "skip"
+	conflictError  = "error"
+)
+
+// syncDirection is the direction an object of a bidirectional sync is copied.
+type syncDirection int
+
+const (
+	syncNone syncDirection = iota
+	syncToDestination
+	syncToSource
+)
+
+// syncStateEntry records an object of a bidirectional sync as it was on both
+// sides after the last sync. Modification times are recorded in seconds, the
+// precision of the S3 object metadata.
+type syncStateEntry struct {
+	Key                string `json:"key"`
+	SourceSize         int64  `json:"source_size"`
+	SourceModTime      int64  `json:"source_mod_time"`
+	DestinationSize    int64  `json:"destination_size"`
+	DestinationModTime int64  `json:"destination_mod_time"`
+}
+
+func newSyncStateEntry(key string, src, dst *storage.Object) syncStateEntry {
+	entry := syncStateEntry{
+		Key:             key,
+		SourceSize:      src.Size,
+		DestinationSize: dst.Size,
+	}
+	if src.ModTime != nil {
+		entry.SourceModTime = src.ModTime.Unix()
+	}
+	if dst.ModTime != nil {
+		entry.DestinationModTime = dst.ModTime.Unix()
+	}
+	return entry
+}
+
+// isChanged checks if the given object differs from the recorded size and
+// modification time.
+func isChanged(object *storage.Object, size, modTime int64, sizeOnly bool) bool {
+	if object.Size != size {
+		return true
+	}
+	if sizeOnly {
+		return false
+	}
+
+	var mod int64
+	if object.ModTime != nil {
+		mod = object.ModTime.Unix()
+	}
+	return mod != modTime
+}
+
+// syncStateCopy is a copy of a bidirectional sync whose result is recorded to
+// the state file once it is completed.
+type syncStateCopy struct {
+	key     string
+	from    *storage.Object
+	to      *url.URL
+	reverse bool
+}
+
+// syncState is the last-sync state of a bidirectional sync. It is used to
+// detect the side of an object which is changed since the last sync. The
+// state of the objects synced by the current run is written to the state
+// file when the sync is completed.
+type syncState struct {
+	path string
+	// readOnly states are never written to the state file, e.g. in dry-run
+	// mode.
+	readOnly bool
+
+	mu   sync.Mutex
+	last map[string]syncStateEntry
+	next map[string]syncStateEntry
+	// pending maps the generated commands to the copies they perform.
+	pending map[string]syncStateCopy
+}
+
+// openSyncState loads the state of the last sync from the given file. A
+// non-existent state file is considered empty.
+func openSyncState(path string, readOnly bool) (*syncState, error) {
+	state := &syncState{
+		path:     path,
+		readOnly: readOnly,
+		last:     map[string]syncStateEntry{},
+		next:     map[string]syncStateEntry{},
+		pending:  map[string]syncStateCopy{},
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return state, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		var entry syncStateEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid state file %q (line: %v): %w", path, lineno, err)
+		}
+		state.last[entry.Key] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// lookup returns the state of the given key after the last sync, or nil if
+// there is none.
+func (st *syncState) lookup(key string) *syncStateEntry {
+	if st == nil {
+		return nil
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	entry, ok := st.last[key]
+	if !ok {
+		return nil
+	}
+	return &entry
+}
+
+// keep carries the last state of the given key over to the next sync, e.g.
+// for the conflicts which are not resolved.
+func (st *syncState) keep(key string) {
+	if st == nil {
+		return
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if entry, ok := st.last[key]; ok {
+		st.next[key] = entry
+	}
+}
+
+// record records the given objects, which are in sync, as the state of the
+// given key.
+func (st *syncState) record(key string, src, dst *storage.Object) {
+	if st == nil {
+		return
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.next[key] = newSyncStateEntry(key, src, dst)
+}
+
+// track associates the given command with the copy it performs. The last
+// state of the key is kept until the copy is completed.
+func (st *syncState) track(command string, pending syncStateCopy) {
+	if st == nil || st.readOnly {
+		return
+	}
+
+	st.keep(pending.key)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.pending[command] = pending
+}
+
+// complete records the state of the copy of the given command. The copied
+// object is looked up with the given stat function since its modification
+// time changes with the copy.
+func (st *syncState) complete(command string, stat func(*url.URL) (*storage.Object, error)) {
+	if st == nil || st.readOnly {
+		return
+	}
+
+	st.mu.Lock()
+	pending, ok := st.pending[command]
+	delete(st.pending, command)
+	st.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	copied, err := stat(pending.to)
+	if err != nil {
+		printDebug("sync", err, pending.to)
+		return
+	}
+
+	if pending.reverse {
+		st.record(pending.key, copied, pending.from)
+	} else {
+		st.record(pending.key, pending.from, copied)
+	}
+}
+
+// Close atomically writes the state of the synced objects to the state file.
+func (st *syncState) Close() error {
+	if st == nil || st.readOnly {
+		return nil
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	f, err := os.CreateTemp(filepath.Dir(st.path), filepath.Base(st.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+	for _, entry := range st.next {
+		b, _ := json.Marshal(entry)
+		fmt.Fprintln(w, string(b))
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), st.path)
+}
+
+// resolveBidirectional decides the direction of an object which exists on
+// both sides of a bidirectional sync. The side which is changed since the last
+// sync is copied to the other. If there is no last state of the object,
+// objects of the same size are considered in sync and the others are
+// conflicts, since it's not known which side is changed. Conflicts are
+// resolved according to the given policy, and reported with the returned
+// boolean.
+func resolveBidirectional(src, dst *storage.Object, last *syncStateEntry, policy string, sizeOnly bool) (syncDirection, bool, error) {
+	if last == nil {
+		if src.Size == dst.Size {
+			return syncNone, false, errorpkg.ErrObjectSizesMatch
+		}
+	} else {
+		srcChanged := isChanged(src, last.SourceSize, last.SourceModTime, sizeOnly)
+		dstChanged := isChanged(dst, last.DestinationSize, last.DestinationModTime, sizeOnly)
+		switch {
+		case !srcChanged && !dstChanged:
+			return syncNone, false, errorpkg.ErrObjectUnchanged
+		case !dstChanged:
+			return syncToDestination, false, nil
+		case !srcChanged:
+			return syncToSource, false, nil
+		}
+	}
+
+	switch policy {
+	case conflictSkip:
+		return syncNone, true, errorpkg.ErrObjectChangedOnBothSides
+	case conflictError:
+		return syncNone, true, fmt.Errorf("object '%v' is changed on both sides", filepath.ToSlash(src.URL.Relative()))
+	case conflictLarger:
+		if src.Size > dst.Size {
+			return syncToDestination, true, nil
+		}
+		if src.Size < dst.Size {
+			return syncToSource, true, nil
+		}
+	}
+
+	// source wins the ties.
+	if src.ModTime != nil && dst.ModTime != nil && dst.ModTime.After(*src.ModTime) {
+		return syncToSource, true, nil
+	}
+	return syncToDestination, true, nil
+}
+
+// validateBidirectionalSync validates the flags of a bidirectional sync.
+func validateBidirectionalSync(c *cli.Context) error {
+	if !c.Bool("bidirectional") {
+		if c.IsSet("conflict") || c.IsSet("state-file") {
+			return fmt.Errorf("conflict and state-file flags can only be used with bidirectional flag")
+		}
+		return nil
+	}
+
+	if c.Bool("delete") {
+		return fmt.Errorf("delete flag can not be used with bidirectional flag")
+	}
+	if c.IsSet("checkpoint") {
+		return fmt.Errorf("checkpoint flag can not be used with bidirectional flag")
+	}
+
+	src := c.Args().Get(0)
+	if c.Bool("raw") {
+		return nil
+	}
+	if base := strings.TrimSuffix(src, "*"); base != src && !strings.HasSuffix(base, "/") || hasGlobCharacter(base) {
+		return fmt.Errorf("bidirectional sync requires the source to be a directory or a prefix, e.g. s3://bucket/prefix/*")
+	}
+	return nil
+}
+
+// planBidirectionalRun prepares the commands of a bidirectional sync and
+// writes them to writer 'w'. Objects which exist on only one side are copied
+// to the other, i.e. deletions are not propagated. The conflicts which fail
+// the sync are sent to errCh.
+func (s Sync) planBidirectionalRun(
+	c *cli.Context,
+	onlySource, onlyDest chan *storage.Object,
+	common chan *ObjectPair,
+	srcurl, dsturl *url.URL,
+	w io.WriteCloser,
+	state *syncState,
+	errCh chan<- error,
+) {
+	defer w.Close()
+
+	// Always use raw mode since sync command generates commands
+	// from raw S3 objects. Otherwise, generated copy command will
+	// try to expand given source. The objects are already filtered by
+	// exclude and include flags while listing.
+	defaultFlags := map[string]interface{}{
+		"raw":         true,
+		"exclude":     nil,
+		"include":     nil,
+		"tune-report": nil,
+	}
+
+	// the regions are swapped for the copies from destination to source.
+	reverseFlags := map[string]interface{}{
+		"raw":         true,
+		"exclude":     nil,
+		"include":     nil,
+		"tune-report": nil,
+	}
+	if c.IsSet("source-region") || c.IsSet("destination-region") {
+		reverseFlags["source-region"] = s.dstRegion
+		reverseFlags["destination-region"] = s.srcRegion
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		merr error
+	)
+
+	copyObject := func(key string, from *storage.Object, to *url.URL, reverse bool) {
+		flags := defaultFlags
+		if reverse {
+			flags = reverseFlags
+		}
+
+		command, err := generateCommand(c, "cp", flags, from.URL, to)
+		if err != nil {
+			printDebug(s.op, err, from.URL, to)
+			return
+		}
+		state.track(command, syncStateCopy{key: key, from: from, to: to, reverse: reverse})
+		fmt.Fprintln(w, command)
+	}
+
+	// only in source
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for srcObject := range onlySource {
+			key := filepath.ToSlash(srcObject.URL.Relative())
+			copyObject(key, srcObject, generateDestinationURL(srcObject.URL, dsturl, true), false)
+		}
+	}()
+
+	// only in destination
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for dstObject := range onlyDest {
+			key := filepath.ToSlash(dstObject.URL.Relative())
+			copyObject(key, dstObject, generateDestinationURL(dstObject.URL, srcurl, true), true)
+		}
+	}()
+
+	// both in source and destination
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for commonObject := range common {
+			src, dst := commonObject.src, commonObject.dst
+			key := filepath.ToSlash(src.URL.Relative())
+
+			last := state.lookup(key)
+			direction, conflict, err := resolveBidirectional(src, dst, last, s.conflict, s.sizeOnly)
+			if err != nil {
+				switch {
+				case conflict && !errorpkg.IsWarning(err):
+					state.keep(key)
+					printError(c.Context, s.fullCommand, s.op, err)
+					mu.Lock()
+					merr = multierror.Append(merr, err)
+					mu.Unlock()
+				case conflict:
+					state.keep(key)
+					printDebug(s.op, err, src.URL, dst.URL)
+				default:
+					state.record(key, src, dst)
+					printDebug(s.op, err, src.URL, dst.URL)
+				}
+				continue
+			}
+
+			if conflict {
+				log.Debug(log.DebugMessage{
+					Operation: s.op,
+					Err:       fmt.Sprintf("object '%v' is changed on both sides, resolving the conflict by %v", key, s.conflict),
+				})
+			}
+
+			if direction == syncToSource {
+				copyObject(key, dst, src.URL, true)
+			} else {
+				copyObject(key, src, dst.URL, false)
+			}
+		}
+	}()
+
+	wg.Wait()
+	errCh <- merr
+}
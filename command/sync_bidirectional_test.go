@@ -0,0 +1,202 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	errorpkg "github.com/peak/s5cmd/v2/error"
+	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/storage/url"
+	"gotest.tools/v3/assert"
+)
+
+func TestResolveBidirectional(t *testing.T) {
+	t.Parallel()
+
+	ft := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	newObject := func(size int64, modTime time.Time) *storage.Object {
+		u, _ := url.New("s3://bucket/key")
+		return &storage.Object{URL: u, Size: size, ModTime: &modTime}
+	}
+	last := &syncStateEntry{
+		Key:                "key",
+		SourceSize:         10,
+		SourceModTime:      ft.Unix(),
+		DestinationSize:    10,
+		DestinationModTime: ft.Add(time.Minute).Unix(),
+	}
+
+	testcases := []struct {
+		name              string
+		src, dst          *storage.Object
+		last              *syncStateEntry
+		policy            string
+		expectedDirection syncDirection
+		expectedConflict  bool
+		expectedErr       error
+	}{
+		{
+			name:        "no state, sizes are same",
+			src:         newObject(10, ft),
+			dst:         newObject(10, ft.Add(time.Hour)),
+			policy:      conflictNewer,
+			expectedErr: errorpkg.ErrObjectSizesMatch,
+		},
+		{
+			name:              "no state, destination is newer",
+			src:               newObject(10, ft),
+			dst:               newObject(5, ft.Add(time.Hour)),
+			policy:            conflictNewer,
+			expectedDirection: syncToSource,
+			expectedConflict:  true,
+		},
+		{
+			name:              "no state, source is larger",
+			src:               newObject(10, ft),
+			dst:               newObject(5, ft.Add(time.Hour)),
+			policy:            conflictLarger,
+			expectedDirection: syncToDestination,
+			expectedConflict:  true,
+		},
+		{
+			name:        "not changed",
+			src:         newObject(10, ft),
+			dst:         newObject(10, ft.Add(time.Minute)),
+			last:        last,
+			policy:      conflictError,
+			expectedErr: errorpkg.ErrObjectUnchanged,
+		},
+		{
+			name:              "source is changed",
+			src:               newObject(10, ft.Add(time.Hour)),
+			dst:               newObject(10, ft.Add(time.Minute)),
+			last:              last,
+			policy:            conflictError,
+			expectedDirection: syncToDestination,
+		},
+		{
+			name:              "destination is changed",
+			src:               newObject(10, ft),
+			dst:               newObject(20, ft.Add(time.Minute)),
+			last:              last,
+			policy:            conflictError,
+			expectedDirection: syncToSource,
+		},
+		{
+			name:              "both are changed, newer wins",
+			src:               newObject(20, ft.Add(2*time.Hour)),
+			dst:               newObject(30, ft.Add(time.Hour)),
+			last:              last,
+			policy:            conflictNewer,
+			expectedDirection: syncToDestination,
+			expectedConflict:  true,
+		},
+		{
+			name:              "both are changed, larger wins",
+			src:               newObject(20, ft.Add(2*time.Hour)),
+			dst:               newObject(30, ft.Add(time.Hour)),
+			last:              last,
+			policy:            conflictLarger,
+			expectedDirection: syncToSource,
+			expectedConflict:  true,
+		},
+		{
+			name:              "both are changed, same sizes fall back to newer",
+			src:               newObject(20, ft.Add(time.Hour)),
+			dst:               newObject(20, ft.Add(2*time.Hour)),
+			last:              last,
+			policy:            conflictLarger,
+			expectedDirection: syncToSource,
+			expectedConflict:  true,
+		},
+		{
+			name:             "both are changed, skip",
+			src:              newObject(20, ft.Add(time.Hour)),
+			dst:              newObject(30, ft.Add(time.Hour)),
+			last:             last,
+			policy:           conflictSkip,
+			expectedConflict: true,
+			expectedErr:      errorpkg.ErrObjectChangedOnBothSides,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			direction, conflict, err := resolveBidirectional(tc.src, tc.dst, tc.last, tc.policy, false)
+			assert.Equal(t, err, tc.expectedErr)
+			assert.Equal(t, direction, tc.expectedDirection)
+			assert.Equal(t, conflict, tc.expectedConflict)
+		})
+	}
+
+	t.Run("both are changed, error", func(t *testing.T) {
+		t.Parallel()
+
+		src, dst := newObject(20, ft.Add(time.Hour)), newObject(30, ft.Add(time.Hour))
+		_, conflict, err := resolveBidirectional(src, dst, last, conflictError, false)
+		assert.Assert(t, conflict)
+		assert.ErrorContains(t, err, "is changed on both sides")
+		assert.Assert(t, !errorpkg.IsWarning(err))
+	})
+}
+
+func TestSyncState(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "sync.state")
+
+	newObject := func(rawurl string, size int64, modTime time.Time) *storage.Object {
+		u, err := url.New(rawurl)
+		assert.NilError(t, err)
+		return &storage.Object{URL: u, Size: size, ModTime: &modTime}
+	}
+	ft := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	state, err := openSyncState(path, false)
+	assert.NilError(t, err)
+	assert.Assert(t, state.lookup("a") == nil)
+
+	state.record("a", newObject("dir/a", 1, ft), newObject("s3://bucket/a", 1, ft))
+
+	copied := newObject("s3://bucket/b", 2, ft.Add(time.Hour))
+	state.track("cp dir/b s3://bucket/b", syncStateCopy{key: "b", from: newObject("dir/b", 2, ft), to: copied.URL})
+	state.track("cp s3://bucket/c dir/c", syncStateCopy{key: "c", from: newObject("s3://bucket/c", 3, ft), to: newObject("dir/c", 3, ft).URL, reverse: true})
+
+	state.complete("cp dir/b s3://bucket/b", func(*url.URL) (*storage.Object, error) { return copied, nil })
+	assert.NilError(t, state.Close())
+
+	state, err = openSyncState(path, false)
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, state.lookup("a"), &syncStateEntry{
+		Key:                "a",
+		SourceSize:         1,
+		SourceModTime:      ft.Unix(),
+		DestinationSize:    1,
+		DestinationModTime: ft.Unix(),
+	})
+	assert.DeepEqual(t, state.lookup("b"), &syncStateEntry{
+		Key:                "b",
+		SourceSize:         2,
+		SourceModTime:      ft.Unix(),
+		DestinationSize:    2,
+		DestinationModTime: ft.Add(time.Hour).Unix(),
+	})
+	// the copies which are not completed are not recorded.
+	assert.Assert(t, state.lookup("c") == nil)
+}
+
+func TestSyncStateInvalidFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "sync.state")
+	assert.NilError(t, os.WriteFile(path, []byte("{invalid\n"), 0644))
+
+	_, err := openSyncState(path, false)
+	assert.ErrorContains(t, err, "invalid state file")
+}
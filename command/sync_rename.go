@@ -0,0 +1,199 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/storage/url"
+)
+
+// renameKey identifies the content of an object by its size and MD5.
+type renameKey struct {
+	size int64
+	md5  string
+}
+
+// renameIndex indexes the objects only in destination by their sizes and
+// MD5, so that the files moved in source can be matched with the objects at
+// their old keys. The objects whose ETag is not the MD5 of their content,
+// e.g. of a multipart upload, are not indexed.
+type renameIndex struct {
+	// cache is the cache of the MD5 of the local files, if any.
+	cache *checksumCache
+
+	objects map[renameKey][]*storage.Object
+	// sizes is the number of the indexed objects of each size, so that only
+	// the source files of the same size as an indexed object are hashed.
+	sizes   map[int64]int
+	matched map[*storage.Object]struct{}
+}
+
+func newRenameIndex(dstObjects []*storage.Object, cache *checksumCache) *renameIndex {
+	index := &renameIndex{
+		cache:   cache,
+		objects: map[renameKey][]*storage.Object{},
+		sizes:   map[int64]int{},
+		matched: map[*storage.Object]struct{}{},
+	}
+	for _, obj := range dstObjects {
+		if !etagComparable(obj) {
+			continue
+		}
+		key := renameKey{size: obj.Size, md5: strings.Trim(obj.Etag, `"`)}
+		index.objects[key] = append(index.objects[key], obj)
+		index.sizes[obj.Size]++
+	}
+	return index
+}
+
+// match returns an indexed object of the same size and MD5 as the given
+// source object, and removes it from the index. It returns nil if there is
+// no such object.
+func (r *renameIndex) match(srcObject *storage.Object) *storage.Object {
+	if r.sizes[srcObject.Size] == 0 {
+		return nil
+	}
+	if srcObject.URL.IsRemote() && !etagComparable(srcObject) {
+		return nil
+	}
+
+	// the source objects whose MD5 can't be computed are copied as usual,
+	// so that the copy reports the error.
+	md5, err := r.cache.md5(srcObject)
+	if err != nil {
+		return nil
+	}
+
+	key := renameKey{size: srcObject.Size, md5: md5}
+	objects := r.objects[key]
+	if len(objects) == 0 {
+		return nil
+	}
+
+	obj := objects[0]
+	r.objects[key] = objects[1:]
+	r.sizes[obj.Size]--
+	r.matched[obj] = struct{}{}
+	return obj
+}
+
+// isMatched reports whether the given object is matched with a source
+// object.
+func (r *renameIndex) isMatched(obj *storage.Object) bool {
+	_, ok := r.matched[obj]
+	return ok
+}
+
+// planRenames matches the objects only in source with the objects only in
+// destination of the same content, and writes the commands to move the
+// matched objects to the destinations of the source objects, or to copy them
+// without delete flag. The unmatched objects are returned to be copied and
+// deleted as usual. All the objects only in source and only in destination
+// are read into memory to build the index.
+func (s Sync) planRenames(
+	c *cli.Context,
+	onlySource chan *storage.Object,
+	onlyDest chan *storage.Object,
+	dsturl *url.URL,
+	cache *checksumCache,
+	defaultFlags map[string]interface{},
+	w io.Writer,
+	isBatch bool,
+	checkpoint *syncCheckpoint,
+) (chan *storage.Object, chan *storage.Object) {
+	var (
+		srcOnly = make(chan *storage.Object, extsortChannelBufferSize)
+		dstOnly = make(chan *storage.Object, extsortChannelBufferSize)
+	)
+
+	op := "cp"
+	if s.delete {
+		op = "mv"
+	}
+
+	go func() {
+		defer close(srcOnly)
+		defer close(dstOnly)
+
+		// both channels are read concurrently, so that the comparison of
+		// the objects is not blocked by either of them.
+		var (
+			srcObjects, dstObjects []*storage.Object
+			wg                     sync.WaitGroup
+		)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for obj := range onlySource {
+				srcObjects = append(srcObjects, obj)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for obj := range onlyDest {
+				dstObjects = append(dstObjects, obj)
+			}
+		}()
+		wg.Wait()
+
+		index := newRenameIndex(dstObjects, cache)
+		for _, srcObject := range srcObjects {
+			if checkpoint.isCompleted(srcObject) {
+				srcOnly <- srcObject
+				continue
+			}
+
+			dstObject := index.match(srcObject)
+			if dstObject == nil {
+				srcOnly <- srcObject
+				continue
+			}
+
+			curDestURL := generateDestinationURL(srcObject.URL, dsturl, isBatch)
+			if s.dryRun {
+				printSyncDryRun(c.Context, op, syncReasonRenamed, dstObject.URL, curDestURL)
+				continue
+			}
+
+			command, err := generateCommand(c, op, defaultFlags, dstObject.URL, curDestURL)
+			if err != nil {
+				printDebug(s.op, err, dstObject.URL, curDestURL)
+				continue
+			}
+			checkpoint.track(command, srcObject)
+			fmt.Fprintln(w, command)
+		}
+
+		for _, dstObject := range dstObjects {
+			if !index.isMatched(dstObject) {
+				dstOnly <- dstObject
+			}
+		}
+	}()
+
+	return srcOnly, dstOnly
+}
+
+// validateSyncTrackRenames validates the track-renames flag of sync.
+func validateSyncTrackRenames(c *cli.Context) error {
+	if !c.Bool("track-renames") {
+		return nil
+	}
+	if c.Bool("bidirectional") {
+		return fmt.Errorf(`"track-renames" flag cannot be used with "bidirectional" flag`)
+	}
+
+	dsturl, err := url.New(c.Args().Get(1), url.WithRaw(c.Bool("raw")))
+	if err != nil {
+		return err
+	}
+	if !dsturl.IsRemote() {
+		return fmt.Errorf(`"track-renames" flag requires the destination to be remote`)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/storage/url"
+)
+
+func TestRenameIndex(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	newLocal := func(name, content string) *storage.Object {
+		path := filepath.Join(dir, name)
+		assert.NilError(t, os.WriteFile(path, []byte(content), 0644))
+		u, err := url.New(path)
+		assert.NilError(t, err)
+		return &storage.Object{URL: u, Size: int64(len(content))}
+	}
+	newRemote := func(key string, size int64, etag string) *storage.Object {
+		u, err := url.New("s3://bucket/" + key)
+		assert.NilError(t, err)
+		return &storage.Object{URL: u, Size: size, Etag: etag}
+	}
+
+	const (
+		contentMD5 = "9a0364b9e99bb480dd25e1f0284c8555" // MD5 of "content".
+		anotherMD5 = "0b7d7e0b0e7ee2a4b2a5a2e6f4c7d4d4"
+	)
+
+	old := newRemote("old.txt", 7, `"`+contentMD5+`"`)
+	duplicate := newRemote("duplicate.txt", 7, contentMD5)
+	multipart := newRemote("multipart.txt", 7, contentMD5+"-2")
+	other := newRemote("other.txt", 7, anotherMD5)
+
+	index := newRenameIndex([]*storage.Object{old, duplicate, multipart, other}, nil)
+
+	// each object is matched with a single source.
+	assert.Equal(t, index.match(newLocal("new.txt", "content")), old)
+	assert.Equal(t, index.match(newLocal("copy.txt", "content")), duplicate)
+	assert.Assert(t, index.match(newLocal("third.txt", "content")) == nil)
+
+	// the files of different sizes are not matched.
+	assert.Assert(t, index.match(newLocal("size.txt", "different content")) == nil)
+
+	// the remote sources are matched by their ETags, unless they are
+	// uploaded in multiple parts.
+	assert.Assert(t, index.match(newRemote("src/multipart.txt", 7, anotherMD5+"-2")) == nil)
+	assert.Equal(t, index.match(newRemote("src/other.txt", 7, anotherMD5)), other)
+
+	assert.Assert(t, index.isMatched(old))
+	assert.Assert(t, index.isMatched(duplicate))
+	assert.Assert(t, index.isMatched(other))
+	assert.Assert(t, !index.isMatched(multipart))
+}
@@ -1,8 +1,16 @@
 package command
 
 import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
 	errorpkg "github.com/peak/s5cmd/v2/error"
 	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/storage/url"
 )
 
 // SyncStrategy is the interface to make decision whether given source object should be synced
@@ -11,7 +19,16 @@ type SyncStrategy interface {
 	ShouldSync(srcObject, dstObject *storage.Object) error
 }
 
-func NewStrategy(sizeOnly bool) SyncStrategy {
+// reasonedStrategy is implemented by the strategies which also tell the
+// reason of syncing the objects, which is printed in dry-run mode.
+type reasonedStrategy interface {
+	shouldSync(srcObject, dstObject *storage.Object) (string, error)
+}
+
+func NewStrategy(sizeOnly bool, compare string, cache *checksumCache) SyncStrategy {
+	if compare == compareEtag {
+		return &EtagStrategy{cache: cache}
+	}
 	if sizeOnly {
 		return &SizeOnlyStrategy{}
 	} else {
@@ -23,10 +40,15 @@ func NewStrategy(sizeOnly bool) SyncStrategy {
 type SizeOnlyStrategy struct{}
 
 func (s *SizeOnlyStrategy) ShouldSync(srcObj, dstObj *storage.Object) error {
+	_, err := s.shouldSync(srcObj, dstObj)
+	return err
+}
+
+func (s *SizeOnlyStrategy) shouldSync(srcObj, dstObj *storage.Object) (string, error) {
 	if srcObj.Size == dstObj.Size {
-		return errorpkg.ErrObjectSizesMatch
+		return "", errorpkg.ErrObjectSizesMatch
 	}
-	return nil
+	return syncReasonSizeDiffers, nil
 }
 
 // SizeAndModificationStrategy determines to sync based on objects' both sizes and modification times.
@@ -39,14 +61,169 @@ func (s *SizeOnlyStrategy) ShouldSync(srcObj, dstObj *storage.Object) error {
 type SizeAndModificationStrategy struct{}
 
 func (sm *SizeAndModificationStrategy) ShouldSync(srcObj, dstObj *storage.Object) error {
+	_, err := sm.shouldSync(srcObj, dstObj)
+	return err
+}
+
+func (sm *SizeAndModificationStrategy) shouldSync(srcObj, dstObj *storage.Object) (string, error) {
+	if srcObj.Size != dstObj.Size {
+		return syncReasonSizeDiffers, nil
+	}
+
 	srcMod, dstMod := srcObj.ModTime, dstObj.ModTime
 	if srcMod.After(*dstMod) {
-		return nil
+		return syncReasonNewer, nil
+	}
+
+	return "", errorpkg.ErrObjectIsNewerAndSizesMatch
+}
+
+// errEtagNotComparable is the debug note of the objects which are compared by
+// their sizes and modification times instead of their ETags.
+var errEtagNotComparable = fmt.Errorf("object etag is not the MD5 of its content, e.g. of a multipart upload, comparing size and modification time instead")
+
+// EtagStrategy determines to sync based on objects' sizes and MD5 checksums.
+// The MD5 of a remote object is its ETag, and the MD5 of a local file is
+// computed from its content. The ETags of the objects uploaded in multiple
+// parts are not their MD5, such objects are compared by
+// SizeAndModificationStrategy instead.
+type EtagStrategy struct {
+	// cache is the cache of the MD5 of the local files, if any.
+	cache *checksumCache
+}
+
+func (e *EtagStrategy) ShouldSync(srcObj, dstObj *storage.Object) error {
+	_, err := e.shouldSync(srcObj, dstObj)
+	return err
+}
+
+func (e *EtagStrategy) shouldSync(srcObj, dstObj *storage.Object) (string, error) {
+	if srcObj.Size != dstObj.Size {
+		return syncReasonSizeDiffers, nil
+	}
+
+	if !etagComparable(srcObj, dstObj) {
+		printDebug("sync", errEtagNotComparable, srcObj.URL, dstObj.URL)
+		return (&SizeAndModificationStrategy{}).shouldSync(srcObj, dstObj)
+	}
+
+	// the objects whose MD5 can't be computed are synced, so that the
+	// copy reports the error.
+	srcMD5, err := e.cache.md5(srcObj)
+	if err != nil {
+		return syncReasonEtagDiffers, nil
+	}
+	dstMD5, err := e.cache.md5(dstObj)
+	if err != nil {
+		return syncReasonEtagDiffers, nil
+	}
+
+	if srcMD5 == dstMD5 {
+		return "", errorpkg.ErrObjectEtagsMatch
 	}
+	return syncReasonEtagDiffers, nil
+}
+
+// errChecksumMetadataNotFound is the debug note of the objects which are
+// compared by their sizes and modification times instead of the digests
+// stored in their metadata.
+var errChecksumMetadataNotFound = fmt.Errorf("object has no checksum in its metadata, comparing size and modification time instead")
+
+// ChecksumMetadataStrategy determines to sync based on objects' sizes and
+// SHA256 digests. The digest of a remote object is trusted as stored in the
+// given user metadata key, and the digest of a local file is computed from
+// its content. The objects without a stored digest are compared by
+// SizeAndModificationStrategy instead.
+type ChecksumMetadataStrategy struct {
+	// key is the user metadata key of the digests.
+	key string
+	// metadata returns the metadata of the given remote object.
+	metadata func(*url.URL) (*storage.Metadata, error)
+}
 
+func (m *ChecksumMetadataStrategy) ShouldSync(srcObj, dstObj *storage.Object) error {
+	_, err := m.shouldSync(srcObj, dstObj)
+	return err
+}
+
+func (m *ChecksumMetadataStrategy) shouldSync(srcObj, dstObj *storage.Object) (string, error) {
 	if srcObj.Size != dstObj.Size {
-		return nil
+		return syncReasonSizeDiffers, nil
+	}
+
+	// the objects whose digest can't be read are synced, so that the copy
+	// reports the error.
+	srcDigest, ok, err := m.digest(srcObj)
+	if err != nil {
+		return syncReasonChecksumDiffers, nil
+	}
+	if !ok {
+		printDebug("sync", errChecksumMetadataNotFound, srcObj.URL, dstObj.URL)
+		return (&SizeAndModificationStrategy{}).shouldSync(srcObj, dstObj)
+	}
+
+	dstDigest, ok, err := m.digest(dstObj)
+	if err != nil {
+		return syncReasonChecksumDiffers, nil
+	}
+	if !ok {
+		printDebug("sync", errChecksumMetadataNotFound, srcObj.URL, dstObj.URL)
+		return (&SizeAndModificationStrategy{}).shouldSync(srcObj, dstObj)
+	}
+
+	if srcDigest == dstDigest {
+		return "", errorpkg.ErrObjectChecksumsMatch
+	}
+	return syncReasonChecksumDiffers, nil
+}
+
+// digest returns the SHA256 digest of the given object, and whether the
+// remote object has one in its metadata.
+func (m *ChecksumMetadataStrategy) digest(obj *storage.Object) (string, bool, error) {
+	if !obj.URL.IsRemote() {
+		digest, err := objectSHA256(obj)
+		return digest, err == nil, err
+	}
+
+	metadata, err := m.metadata(obj.URL)
+	if err != nil {
+		return "", false, err
+	}
+	digest, ok := checksumMetadata(metadata.UserDefined, m.key)
+	return digest, ok, nil
+}
+
+// etagComparable reports whether the MD5 of the given objects can be
+// compared, i.e. the ETags of the remote ones are the MD5 of their content.
+func etagComparable(objs ...*storage.Object) bool {
+	var hasRemote bool
+	for _, obj := range objs {
+		if !obj.URL.IsRemote() {
+			continue
+		}
+		if !obj.EtagIsMD5() {
+			return false
+		}
+		hasRemote = true
+	}
+	return hasRemote
+}
+
+// objectMD5 returns the hex encoded MD5 of the content of the given object.
+func objectMD5(obj *storage.Object) (string, error) {
+	if obj.URL.IsRemote() {
+		return strings.Trim(obj.Etag, `"`), nil
 	}
 
-	return errorpkg.ErrObjectIsNewerAndSizesMatch
+	file, err := os.Open(obj.URL.Absolute())
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
@@ -1,11 +1,16 @@
 package command
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	errorpkg "github.com/peak/s5cmd/v2/error"
+	"github.com/peak/s5cmd/v2/log"
 	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/storage/url"
 )
 
 func TestSizeAndModificationStrategy_ShouldSync(t *testing.T) {
@@ -147,3 +152,181 @@ func TestSizeOnlyStrategy_ShouldSync(t *testing.T) {
 		})
 	}
 }
+
+func TestEtagStrategy_ShouldSync(t *testing.T) {
+	// the objects which are not compared by etag are logged.
+	log.Init("error", false)
+
+	ft := time.Now()
+	timePtr := func(tt time.Time) *time.Time {
+		return &tt
+	}
+
+	// the MD5 of "content".
+	const contentMD5 = "9a0364b9e99bb480dd25e1f0284c8555"
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mustURL := func(s string) *url.URL {
+		u, err := url.New(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	localURL := mustURL(path)
+	remoteURL := mustURL("s3://bucket/file.txt")
+
+	testcases := []struct {
+		name     string
+		src      *storage.Object
+		dst      *storage.Object
+		expected error
+	}{
+		{
+			name:     "single part object, sizes are different",
+			src:      &storage.Object{URL: localURL, ModTime: timePtr(ft), Size: 7},
+			dst:      &storage.Object{URL: remoteURL, ModTime: timePtr(ft.Add(time.Minute)), Size: 5, Etag: contentMD5},
+			expected: nil,
+		},
+		{
+			name:     "single part object, source is newer, etag matches",
+			src:      &storage.Object{URL: localURL, ModTime: timePtr(ft.Add(time.Minute)), Size: 7},
+			dst:      &storage.Object{URL: remoteURL, ModTime: timePtr(ft), Size: 7, Etag: `"` + contentMD5 + `"`},
+			expected: errorpkg.ErrObjectEtagsMatch,
+		},
+		{
+			name:     "single part object, source is older, etag differs",
+			src:      &storage.Object{URL: localURL, ModTime: timePtr(ft), Size: 7},
+			dst:      &storage.Object{URL: remoteURL, ModTime: timePtr(ft.Add(time.Minute)), Size: 7, Etag: "0cc175b9c0f1b6a831c399e269772661"},
+			expected: nil,
+		},
+		{
+			name:     "single part object as source, etag matches",
+			src:      &storage.Object{URL: remoteURL, ModTime: timePtr(ft.Add(time.Minute)), Size: 7, Etag: contentMD5},
+			dst:      &storage.Object{URL: localURL, ModTime: timePtr(ft), Size: 7},
+			expected: errorpkg.ErrObjectEtagsMatch,
+		},
+		{
+			name:     "single part remote objects, etags match",
+			src:      &storage.Object{URL: remoteURL, ModTime: timePtr(ft.Add(time.Minute)), Size: 7, Etag: contentMD5},
+			dst:      &storage.Object{URL: mustURL("s3://bucket2/file.txt"), ModTime: timePtr(ft), Size: 7, Etag: contentMD5},
+			expected: errorpkg.ErrObjectEtagsMatch,
+		},
+		{
+			name:     "multipart object, source is newer",
+			src:      &storage.Object{URL: localURL, ModTime: timePtr(ft.Add(time.Minute)), Size: 7},
+			dst:      &storage.Object{URL: remoteURL, ModTime: timePtr(ft), Size: 7, Etag: "d41d8cd98f00b204e9800998ecf8427e-2"},
+			expected: nil,
+		},
+		{
+			name:     "multipart object, source is older",
+			src:      &storage.Object{URL: localURL, ModTime: timePtr(ft), Size: 7},
+			dst:      &storage.Object{URL: remoteURL, ModTime: timePtr(ft.Add(time.Minute)), Size: 7, Etag: "d41d8cd98f00b204e9800998ecf8427e-2"},
+			expected: errorpkg.ErrObjectIsNewerAndSizesMatch,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			strategy := &EtagStrategy{}
+			if got := strategy.ShouldSync(tc.src, tc.dst); got != tc.expected {
+				t.Fatalf("expected: %q(%T), got: %q(%T)", tc.expected, tc.expected, got, got)
+			}
+		})
+	}
+}
+
+func TestChecksumMetadataStrategy_ShouldSync(t *testing.T) {
+	// the objects which are not compared by checksum are logged.
+	log.Init("error", false)
+
+	ft := time.Now()
+	timePtr := func(tt time.Time) *time.Time {
+		return &tt
+	}
+
+	// the SHA256 of "content".
+	const contentSHA256 = "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73"
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mustURL := func(s string) *url.URL {
+		u, err := url.New(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	localURL := mustURL(path)
+
+	// the keys of the user metadata are returned canonicalized.
+	metadata := map[string]map[string]string{
+		"s3://bucket/matching.txt":  {"Sha256": contentSHA256},
+		"s3://bucket/differing.txt": {"Sha256": "0cc175b9c0f1b6a831c399e269772661"},
+		"s3://bucket/missing.txt":   {"Build": "42"},
+		"s3://bucket2/copy.txt":     {"Sha256": strings.ToUpper(contentSHA256)},
+	}
+	strategy := &ChecksumMetadataStrategy{
+		key: "sha256",
+		metadata: func(u *url.URL) (*storage.Metadata, error) {
+			return &storage.Metadata{UserDefined: metadata[u.String()]}, nil
+		},
+	}
+
+	testcases := []struct {
+		name     string
+		src      *storage.Object
+		dst      *storage.Object
+		expected error
+	}{
+		{
+			name:     "sizes are different",
+			src:      &storage.Object{URL: localURL, ModTime: timePtr(ft), Size: 7},
+			dst:      &storage.Object{URL: mustURL("s3://bucket/matching.txt"), ModTime: timePtr(ft.Add(time.Minute)), Size: 5},
+			expected: nil,
+		},
+		{
+			name:     "source is newer, checksum matches",
+			src:      &storage.Object{URL: localURL, ModTime: timePtr(ft.Add(time.Minute)), Size: 7},
+			dst:      &storage.Object{URL: mustURL("s3://bucket/matching.txt"), ModTime: timePtr(ft), Size: 7},
+			expected: errorpkg.ErrObjectChecksumsMatch,
+		},
+		{
+			name:     "source is older, checksum differs",
+			src:      &storage.Object{URL: localURL, ModTime: timePtr(ft), Size: 7},
+			dst:      &storage.Object{URL: mustURL("s3://bucket/differing.txt"), ModTime: timePtr(ft.Add(time.Minute)), Size: 7},
+			expected: nil,
+		},
+		{
+			name:     "remote objects, checksums match",
+			src:      &storage.Object{URL: mustURL("s3://bucket/matching.txt"), ModTime: timePtr(ft.Add(time.Minute)), Size: 7},
+			dst:      &storage.Object{URL: mustURL("s3://bucket2/copy.txt"), ModTime: timePtr(ft), Size: 7},
+			expected: errorpkg.ErrObjectChecksumsMatch,
+		},
+		{
+			name:     "no checksum, source is newer",
+			src:      &storage.Object{URL: localURL, ModTime: timePtr(ft.Add(time.Minute)), Size: 7},
+			dst:      &storage.Object{URL: mustURL("s3://bucket/missing.txt"), ModTime: timePtr(ft), Size: 7},
+			expected: nil,
+		},
+		{
+			name:     "no checksum, source is older",
+			src:      &storage.Object{URL: localURL, ModTime: timePtr(ft), Size: 7},
+			dst:      &storage.Object{URL: mustURL("s3://bucket/missing.txt"), ModTime: timePtr(ft.Add(time.Minute)), Size: 7},
+			expected: errorpkg.ErrObjectIsNewerAndSizesMatch,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := strategy.ShouldSync(tc.src, tc.dst); got != tc.expected {
+				t.Fatalf("expected: %q(%T), got: %q(%T)", tc.expected, tc.expected, got, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peak/s5cmd/v2/storage"
+)
+
+// timeFilter filters the objects by their modification times.
+type timeFilter struct {
+	newerThan *time.Time
+	olderThan *time.Time
+}
+
+// newTimeFilter creates a timeFilter from the values of newer-than and
+// older-than flags, relative to the given time.
+func newTimeFilter(newerThan, olderThan string, now time.Time) (timeFilter, error) {
+	var (
+		filter timeFilter
+		err    error
+	)
+
+	filter.newerThan, err = parseTimeFilter("newer-than", newerThan, now)
+	if err != nil {
+		return filter, err
+	}
+
+	filter.olderThan, err = parseTimeFilter("older-than", olderThan, now)
+	if err != nil {
+		return filter, err
+	}
+
+	if filter.newerThan != nil && filter.olderThan != nil && !filter.newerThan.Before(*filter.olderThan) {
+		return filter, fmt.Errorf(`"newer-than" and "older-than" flags don't match any time`)
+	}
+	return filter, nil
+}
+
+// parseTimeFilter parses the value of a time filter flag, which is either a
+// duration relative to the given time, e.g. 24h or 7d, or an absolute time
+// in RFC3339 format.
+func parseTimeFilter(flag, value string, now time.Time) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	d, err := parseDuration(value)
+	if err != nil || d < 0 {
+		return nil, fmt.Errorf(`invalid value for %q flag %q: expected a duration, e.g. 24h or 7d, or RFC3339 format, e.g. 2024-10-01T20:30:00Z`, flag, value)
+	}
+
+	t := now.Add(-d)
+	return &t, nil
+}
+
+// parseDuration parses a duration in the format of time.ParseDuration, or a
+// number of days with "d" suffix.
+func parseDuration(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		n, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// match reports whether the modification time of the given object is in the
+// range of the filter. The objects without a modification time, e.g.
+// directories, always match.
+func (f timeFilter) match(object *storage.Object) bool {
+	if object.ModTime == nil {
+		return true
+	}
+
+	if f.newerThan != nil && !object.ModTime.After(*f.newerThan) {
+		return false
+	}
+	if f.olderThan != nil && !object.ModTime.Before(*f.olderThan) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/v2/storage"
+)
+
+func TestParseTimeFilter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 10, 10, 12, 0, 0, 0, time.UTC)
+
+	testcases := []struct {
+		value    string
+		expected time.Time
+		wantErr  bool
+	}{
+		{value: "90m", expected: now.Add(-90 * time.Minute)},
+		{value: "24h", expected: now.Add(-24 * time.Hour)},
+		{value: "7d", expected: now.Add(-7 * 24 * time.Hour)},
+		{value: "2024-10-01T20:30:00Z", expected: time.Date(2024, 10, 1, 20, 30, 0, 0, time.UTC)},
+		{value: "7days", wantErr: true},
+		{value: "-1h", wantErr: true},
+		{value: "2024-10-01", wantErr: true},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.value, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseTimeFilter("newer-than", tc.value, now)
+			if tc.wantErr {
+				assert.ErrorContains(t, err, `invalid value for "newer-than" flag`)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Assert(t, got.Equal(tc.expected), "expected %v, got %v", tc.expected, got)
+		})
+	}
+}
+
+func TestTimeFilterMatch(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 10, 10, 12, 0, 0, 0, time.UTC)
+	filter, err := newTimeFilter("7d", "1d", now)
+	assert.NilError(t, err)
+
+	modTime := func(d time.Duration) *storage.Object {
+		t := now.Add(-d)
+		return &storage.Object{ModTime: &t}
+	}
+
+	assert.Assert(t, !filter.match(modTime(8*24*time.Hour)))
+	assert.Assert(t, filter.match(modTime(2*24*time.Hour)))
+	assert.Assert(t, !filter.match(modTime(time.Hour)))
+	// directories don't have a modification time.
+	assert.Assert(t, filter.match(&storage.Object{}))
+}
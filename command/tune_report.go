@@ -0,0 +1,171 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/v2/log"
+	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/strutil"
+)
+
+const (
+	// the ratio of the throttled requests above which fewer workers are
+	// suggested.
+	tuneThrottleRatio = 0.01
+	// the ratio of the busy workers above which more workers are suggested.
+	tuneSaturationRatio = 0.8
+)
+
+// tuneReport enables the collection of the request metrics if tune-report
+// flag is set, and returns a function which prints the report of the
+// collected metrics.
+func tuneReport(c *cli.Context) func() {
+	if !c.Bool("tune-report") {
+		return func() {}
+	}
+
+	storage.EnableRequestMetrics()
+	start := time.Now()
+
+	return func() {
+		msg := newTuneReportMessage(
+			c.Command.Name,
+			c.Int("numworkers"),
+			time.Since(start),
+			storage.CollectRequestMetrics(),
+		)
+		log.Stat(msg)
+	}
+}
+
+// TuneReportMessage is a structure for logging the observed performance of
+// the requests of a command and the suggested number of workers.
+type TuneReportMessage struct {
+	Operation            string             `json:"operation"`
+	Elapsed              float64            `json:"elapsed_seconds"`
+	Requests             int64              `json:"requests"`
+	Throttled            int64              `json:"throttled"`
+	Workers              int                `json:"numworkers"`
+	MaxInFlight          int64              `json:"max_in_flight"`
+	EffectiveConcurrency float64            `json:"effective_concurrency"`
+	Latency              tuneReportLatency  `json:"latency"`
+	Histogram            []tuneReportBucket `json:"histogram"`
+	Suggestion           string             `json:"suggestion"`
+	SuggestedWorkers     int                `json:"suggested_numworkers"`
+}
+
+// tuneReportLatency is the latency distribution of the requests, in
+// milliseconds.
+type tuneReportLatency struct {
+	Min  float64 `json:"min_ms"`
+	Mean float64 `json:"mean_ms"`
+	P50  float64 `json:"p50_ms"`
+	P90  float64 `json:"p90_ms"`
+	P99  float64 `json:"p99_ms"`
+	Max  float64 `json:"max_ms"`
+}
+
+// tuneReportBucket is the number of the requests whose latency is at most
+// the given bound.
+type tuneReportBucket struct {
+	Bound string `json:"le"`
+	Count int64  `json:"count"`
+}
+
+func newTuneReportMessage(op string, workers int, elapsed time.Duration, m storage.RequestMetrics) TuneReportMessage {
+	msg := TuneReportMessage{
+		Operation:   op,
+		Elapsed:     elapsed.Seconds(),
+		Requests:    m.Requests,
+		Throttled:   m.Throttled,
+		Workers:     workers,
+		MaxInFlight: m.MaxInFlight,
+	}
+
+	if elapsed > 0 {
+		// the average number of the requests in flight during the run.
+		concurrency := float64(m.TotalLatency) / float64(elapsed)
+		msg.EffectiveConcurrency = math.Round(concurrency*10) / 10
+	}
+
+	if m.Requests > 0 {
+		msg.Latency = tuneReportLatency{
+			Min:  milliseconds(m.MinLatency),
+			Mean: milliseconds(m.TotalLatency / time.Duration(m.Requests)),
+			P50:  milliseconds(m.Percentile(50)),
+			P90:  milliseconds(m.Percentile(90)),
+			P99:  milliseconds(m.Percentile(99)),
+			Max:  milliseconds(m.MaxLatency),
+		}
+	}
+
+	for i, count := range m.Histogram {
+		bound := "+Inf"
+		if i < len(storage.LatencyBuckets) {
+			bound = storage.LatencyBuckets[i].String()
+		}
+		msg.Histogram = append(msg.Histogram, tuneReportBucket{Bound: bound, Count: count})
+	}
+
+	msg.SuggestedWorkers, msg.Suggestion = suggestWorkers(workers, msg.EffectiveConcurrency, m)
+	return msg
+}
+
+// suggestWorkers suggests the number of workers from the observed requests.
+// The workers are decreased if the remote storage throttles the requests,
+// and increased if the workers are saturated.
+func suggestWorkers(workers int, concurrency float64, m storage.RequestMetrics) (int, string) {
+	switch {
+	case m.Requests == 0:
+		return workers, "no requests are sent to the remote storage"
+	case float64(m.Throttled) > tuneThrottleRatio*float64(m.Requests):
+		suggested := workers / 2
+		if suggested < 1 {
+			suggested = 1
+		}
+		return suggested, fmt.Sprintf("requests are throttled, decrease --numworkers to %d", suggested)
+	case concurrency >= tuneSaturationRatio*float64(workers):
+		suggested := workers * 2
+		return suggested, fmt.Sprintf("workers are saturated, increase --numworkers to %d", suggested)
+	default:
+		return workers, fmt.Sprintf("workers are not saturated, keep --numworkers at %d", workers)
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*100) / 100
+}
+
+// String returns the string representation of TuneReportMessage.
+func (m TuneReportMessage) String() string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "\n%s: tune report\n", m.Operation)
+
+	w := tabwriter.NewWriter(&buf, 0, 8, 1, ' ', 0)
+	fmt.Fprintf(w, "Elapsed\t%.2fs\n", m.Elapsed)
+	fmt.Fprintf(w, "Requests\t%d\n", m.Requests)
+	fmt.Fprintf(w, "Throttled\t%d\n", m.Throttled)
+	fmt.Fprintf(w, "Effective concurrency\t%.1f of %d workers (max in flight: %d)\n",
+		m.EffectiveConcurrency, m.Workers, m.MaxInFlight)
+	fmt.Fprintf(w, "Latency\tmin %.2fms, mean %.2fms, p50 %.2fms, p90 %.2fms, p99 %.2fms, max %.2fms\n",
+		m.Latency.Min, m.Latency.Mean, m.Latency.P50, m.Latency.P90, m.Latency.P99, m.Latency.Max)
+	for _, bucket := range m.Histogram {
+		fmt.Fprintf(w, "\t<= %s\t%d\n", bucket.Bound, bucket.Count)
+	}
+	fmt.Fprintf(w, "Suggestion\t%s\n", m.Suggestion)
+	w.Flush()
+
+	return buf.String()
+}
+
+// JSON returns the JSON representation of TuneReportMessage.
+func (m TuneReportMessage) JSON() string {
+	return strutil.JSON(m)
+}
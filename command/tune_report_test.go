@@ -0,0 +1,104 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/v2/storage"
+)
+
+func TestSuggestWorkers(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name        string
+		workers     int
+		concurrency float64
+		metrics     storage.RequestMetrics
+		expected    int
+		suggestion  string
+	}{
+		{
+			name:       "no requests",
+			workers:    256,
+			expected:   256,
+			suggestion: "no requests are sent to the remote storage",
+		},
+		{
+			name:        "throttled",
+			workers:     256,
+			concurrency: 250,
+			metrics:     storage.RequestMetrics{Requests: 1000, Throttled: 50},
+			expected:    128,
+			suggestion:  "requests are throttled, decrease --numworkers to 128",
+		},
+		{
+			name:       "throttled with a single worker",
+			workers:    1,
+			metrics:    storage.RequestMetrics{Requests: 10, Throttled: 5},
+			expected:   1,
+			suggestion: "requests are throttled, decrease --numworkers to 1",
+		},
+		{
+			name:        "saturated",
+			workers:     10,
+			concurrency: 9.5,
+			metrics:     storage.RequestMetrics{Requests: 1000, Throttled: 1},
+			expected:    20,
+			suggestion:  "workers are saturated, increase --numworkers to 20",
+		},
+		{
+			name:        "not saturated",
+			workers:     256,
+			concurrency: 12.3,
+			metrics:     storage.RequestMetrics{Requests: 1000},
+			expected:    256,
+			suggestion:  "workers are not saturated, keep --numworkers at 256",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			workers, suggestion := suggestWorkers(tc.workers, tc.concurrency, tc.metrics)
+			assert.Equal(t, workers, tc.expected)
+			assert.Equal(t, suggestion, tc.suggestion)
+		})
+	}
+}
+
+func TestNewTuneReportMessage(t *testing.T) {
+	t.Parallel()
+
+	histogram := make([]int64, len(storage.LatencyBuckets)+1)
+	histogram[0] = 8                           // <= 10ms
+	histogram[3] = 1                           // <= 100ms
+	histogram[len(storage.LatencyBuckets)] = 1 // > 10s
+	metrics := storage.RequestMetrics{
+		Requests:     10,
+		MaxInFlight:  4,
+		TotalLatency: 20 * time.Second,
+		MinLatency:   time.Millisecond,
+		MaxLatency:   15 * time.Second,
+		Histogram:    histogram,
+	}
+
+	msg := newTuneReportMessage("cp", 4, 10*time.Second, metrics)
+
+	assert.Equal(t, msg.EffectiveConcurrency, 2.0)
+	assert.Equal(t, msg.Latency, tuneReportLatency{
+		Min:  1,
+		Mean: 2000,
+		P50:  10,
+		P90:  100,
+		P99:  15000,
+		Max:  15000,
+	})
+	assert.Equal(t, msg.Histogram[0], tuneReportBucket{Bound: "10ms", Count: 8})
+	assert.Equal(t, msg.Histogram[len(msg.Histogram)-1], tuneReportBucket{Bound: "+Inf", Count: 1})
+	assert.Equal(t, msg.Suggestion, "workers are not saturated, keep --numworkers at 4")
+}
@@ -73,3 +73,16 @@ func checkNumberOfArguments(ctx *cli.Context, min, max int) error {
 	}
 	return nil
 }
+
+// isAccountID checks if the given value is a 12-digit AWS account ID.
+func isAccountID(value string) bool {
+	if len(value) != 12 {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
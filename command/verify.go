@@ -0,0 +1,430 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/v2/log"
+	"github.com/peak/s5cmd/v2/log/stat"
+	"github.com/peak/s5cmd/v2/parallel"
+	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/storage/url"
+	"github.com/peak/s5cmd/v2/strutil"
+)
+
+var verifyHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] source destination
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Verify that all files in a local folder exist in S3 bucket with the same sizes
+		 > s5cmd {{.HelpName}} folder/ s3://bucket/backup/
+
+	2. Verify the content of the files by comparing their MD5 with the ETags of the objects
+		 > s5cmd {{.HelpName}} --checksum folder/ s3://bucket/backup/
+
+	3. Verify the content of the files by the SHA256 digests stored in the metadata of the objects
+		 > s5cmd {{.HelpName}} --checksum --checksum-metadata-key x-amz-meta-sha256 folder/ s3://bucket/backup/
+
+	4. Verify all files except the log files, and print the discrepancies in JSON
+		 > s5cmd --json {{.HelpName}} --exclude "*.log" folder/ s3://bucket/backup/
+`
+
+func NewVerifyCommand() *cli.Command {
+	cmd := &cli.Command{
+		Name:     "verify",
+		HelpName: "verify",
+		Usage:    "verify that local files exist in remote destination with the same content",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "checksum",
+				Usage: "compare the MD5 of the files with the ETags of the objects, or with the SHA256 digests stored in checksum-metadata-key if it's given, in addition to their sizes; the objects uploaded in multiple parts are compared by their sizes only, unless checksum-metadata-key is given",
+			},
+			&cli.StringFlag{
+				Name:  "checksum-metadata-key",
+				Usage: "compare the SHA256 digests of the files with the hex encoded digests stored in the given user metadata key of the objects, e.g. x-amz-meta-sha256, at the cost of an extra HEAD request per object",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude",
+				Usage: "exclude files with given pattern",
+			},
+			&cli.StringSliceFlag{
+				Name:  "include",
+				Usage: "include only files with given pattern",
+			},
+			&cli.BoolFlag{
+				Name:  "no-follow-symlinks",
+				Usage: "do not follow symbolic links",
+			},
+		},
+		CustomHelpTemplate: verifyHelpTemplate,
+		Before: func(c *cli.Context) error {
+			err := validateVerifyCommand(c)
+			if err != nil {
+				printError(c.Context, commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			return NewVerify(c).Run(c.Context)
+		},
+	}
+
+	cmd.BashComplete = getBashCompleteFn(cmd, false, false)
+	return cmd
+}
+
+// Verify holds verify operation flags and states.
+type Verify struct {
+	src         string
+	dst         string
+	op          string
+	fullCommand string
+
+	// flags
+	checksum       bool
+	checksumKey    string
+	exclude        []string
+	include        []string
+	followSymlinks bool
+
+	storageOpts storage.Options
+}
+
+// NewVerify creates Verify from cli.Context.
+func NewVerify(c *cli.Context) Verify {
+	return Verify{
+		src:         c.Args().Get(0),
+		dst:         c.Args().Get(1),
+		op:          c.Command.Name,
+		fullCommand: commandFromContext(c),
+
+		checksum:       c.Bool("checksum"),
+		checksumKey:    normalizeChecksumMetadataKey(c.String("checksum-metadata-key")),
+		exclude:        c.StringSlice("exclude"),
+		include:        c.StringSlice("include"),
+		followSymlinks: !c.Bool("no-follow-symlinks"),
+
+		storageOpts: NewStorageOpts(c),
+	}
+}
+
+// The reasons of the discrepancies reported by verify.
+const (
+	verifyReasonMissing         = "missing"
+	verifyReasonChecksumDiffers = "checksum-differs"
+	verifyReasonChecksumMissing = "checksum-missing"
+)
+
+// errEtagNotVerifiable is the debug note of the objects which are verified
+// by their sizes only.
+var errEtagNotVerifiable = fmt.Errorf("object etag is not the MD5 of its content, e.g. of a multipart upload or an object encrypted with KMS, comparing size only")
+
+// Run compares the local files with the remote objects, and reports the
+// files which don't exist in the destination or whose objects differ. The
+// objects are listed in the same way as sync, but nothing is transferred.
+func (v Verify) Run(ctx context.Context) error {
+	// the objects are listed and matched by sync.
+	s := Sync{
+		src:            v.src,
+		dst:            v.dst,
+		op:             v.op,
+		fullCommand:    v.fullCommand,
+		followSymlinks: v.followSymlinks,
+		exclude:        v.exclude,
+		include:        v.include,
+		storageOpts:    v.storageOpts,
+		listGlacier:    true,
+	}
+
+	excludeDirs, err := createDirRegexFromWildcard(v.exclude)
+	if err != nil {
+		printError(ctx, v.fullCommand, v.op, err)
+		return err
+	}
+
+	srcurl, err := url.New(v.src, url.WithExcludeDirs(excludeDirs))
+	if err != nil {
+		printError(ctx, v.fullCommand, v.op, err)
+		return err
+	}
+
+	// the destination is always a prefix, so that the missing objects are
+	// reported with their keys.
+	dsturl, err := url.New(strings.TrimSuffix(v.dst, "/") + "/")
+	if err != nil {
+		printError(ctx, v.fullCommand, v.op, err)
+		return err
+	}
+
+	obj, err := storage.NewLocalClient(v.storageOpts).Stat(ctx, srcurl)
+	if err != nil {
+		printError(ctx, v.fullCommand, v.op, err)
+		return err
+	}
+	if !obj.Type.IsDir() {
+		err := fmt.Errorf("source must be a local directory")
+		printError(ctx, v.fullCommand, v.op, err)
+		return err
+	}
+
+	s.excludePatterns, err = createRegexFromWildcard(v.exclude)
+	if err != nil {
+		printError(ctx, v.fullCommand, v.op, err)
+		return err
+	}
+
+	s.includePatterns, err = createRegexFromWildcard(v.include)
+	if err != nil {
+		printError(ctx, v.fullCommand, v.op, err)
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sourceObjects, destObjects, err := s.getSourceAndDestinationObjects(ctx, cancel, srcurl, dsturl)
+	if err != nil {
+		printError(ctx, v.fullCommand, v.op, err)
+		return err
+	}
+
+	onlySource, onlyDest, commonObjects := compareObjects(sourceObjects, destObjects, true)
+
+	var metadataStrategy *ChecksumMetadataStrategy
+	if v.checksumKey != "" {
+		metadataStrategy = &ChecksumMetadataStrategy{
+			key: v.checksumKey,
+			metadata: func(u *url.URL) (*storage.Metadata, error) {
+				client, err := storage.NewRemoteClient(ctx, u, v.storageOpts)
+				if err != nil {
+					return nil, err
+				}
+				_, metadata, err := client.HeadObject(ctx, u)
+				return metadata, err
+			},
+		}
+	}
+
+	var (
+		merrorWaiter error
+		verified     atomic.Int64
+		discrepancy  atomic.Int64
+	)
+
+	waiter := parallel.NewWaiter()
+	errDoneCh := make(chan struct{})
+	go func() {
+		defer close(errDoneCh)
+		for err := range waiter.Err() {
+			printError(ctx, v.fullCommand, v.op, err)
+			merrorWaiter = multierror.Append(merrorWaiter, err)
+		}
+	}()
+
+	report := func(src, dst *storage.Object, reason string) {
+		if reason == "" {
+			verified.Add(1)
+			return
+		}
+		discrepancy.Add(1)
+
+		msg := VerifyMessage{
+			Operation:   v.op,
+			Source:      src.URL,
+			Destination: dsturl.Join(filepath.ToSlash(src.URL.Relative())),
+			Reason:      reason,
+			SourceSize:  src.Size,
+		}
+		if dst != nil {
+			msg.Destination = dst.URL
+			msg.DestinationSize = &dst.Size
+		}
+		log.InfoContext(ctx, msg)
+	}
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for src := range onlySource {
+			report(src, nil, verifyReasonMissing)
+		}
+	}()
+
+	// the objects only in destination are not verified.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range onlyDest {
+		}
+	}()
+
+	for pair := range commonObjects {
+		pair := pair
+		task := func() error {
+			reason, err := v.compare(pair.src, pair.dst, metadataStrategy)
+			if err != nil {
+				return err
+			}
+			report(pair.src, pair.dst, reason)
+			return nil
+		}
+		parallel.Run(task, waiter)
+	}
+
+	wg.Wait()
+	waiter.Wait()
+	<-errDoneCh
+
+	msg := VerifySummaryMessage{
+		Source:        v.src,
+		Destination:   v.dst,
+		Verified:      verified.Load(),
+		Discrepancies: discrepancy.Load(),
+	}
+	log.InfoContext(ctx, msg)
+
+	if merrorWaiter != nil {
+		return merrorWaiter
+	}
+
+	if n := discrepancy.Load(); n > 0 {
+		err := fmt.Errorf("%d objects failed verification", n)
+		printError(ctx, v.fullCommand, v.op, err)
+		return err
+	}
+	return nil
+}
+
+// compare returns the reason of the discrepancy between the given local file
+// and remote object, if any.
+func (v Verify) compare(src, dst *storage.Object, metadataStrategy *ChecksumMetadataStrategy) (string, error) {
+	if src.Size != dst.Size {
+		return syncReasonSizeDiffers, nil
+	}
+
+	if !v.checksum {
+		return "", nil
+	}
+
+	if metadataStrategy != nil {
+		srcDigest, _, err := metadataStrategy.digest(src)
+		if err != nil {
+			return "", err
+		}
+		dstDigest, ok, err := metadataStrategy.digest(dst)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return verifyReasonChecksumMissing, nil
+		}
+		if srcDigest != dstDigest {
+			return verifyReasonChecksumDiffers, nil
+		}
+		return "", nil
+	}
+
+	if !etagComparable(dst) {
+		printDebug(v.op, errEtagNotVerifiable, src.URL, dst.URL)
+		return "", nil
+	}
+
+	srcMD5, err := objectMD5(src)
+	if err != nil {
+		return "", err
+	}
+	dstMD5, _ := objectMD5(dst)
+	if srcMD5 != dstMD5 {
+		return syncReasonEtagDiffers, nil
+	}
+	return "", nil
+}
+
+// VerifyMessage is a structure for logging the discrepancies found by verify.
+type VerifyMessage struct {
+	Operation       string   `json:"operation"`
+	Source          *url.URL `json:"source"`
+	Destination     *url.URL `json:"destination"`
+	Reason          string   `json:"reason"`
+	SourceSize      int64    `json:"source_size"`
+	DestinationSize *int64   `json:"destination_size,omitempty"`
+}
+
+// String returns the string representation of VerifyMessage.
+func (m VerifyMessage) String() string {
+	return fmt.Sprintf("%v %v %v  # %v", m.Operation, m.Source, m.Destination, m.Reason)
+}
+
+// JSON returns the JSON representation of VerifyMessage.
+func (m VerifyMessage) JSON() string {
+	return strutil.JSON(m)
+}
+
+// VerifySummaryMessage is a structure for logging the number of verified
+// files and the discrepancies.
+type VerifySummaryMessage struct {
+	Source        string `json:"source"`
+	Destination   string `json:"destination"`
+	Verified      int64  `json:"verified"`
+	Discrepancies int64  `json:"discrepancies"`
+}
+
+// String returns the string representation of VerifySummaryMessage.
+func (m VerifySummaryMessage) String() string {
+	return fmt.Sprintf("verify: %d objects verified, %d discrepancies found", m.Verified, m.Discrepancies)
+}
+
+// JSON returns the JSON representation of VerifySummaryMessage.
+func (m VerifySummaryMessage) JSON() string {
+	return strutil.JSON(m)
+}
+
+func validateVerifyCommand(c *cli.Context) error {
+	if c.Args().Len() != 2 {
+		return fmt.Errorf("expected source and destination arguments")
+	}
+
+	srcurl, err := url.New(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	dsturl, err := url.New(c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	if srcurl.IsRemote() || srcurl.IsWildcard() {
+		return fmt.Errorf("source must be a local directory")
+	}
+
+	if !dsturl.IsRemote() || dsturl.IsWildcard() {
+		return fmt.Errorf("destination must be a bucket or a prefix")
+	}
+
+	if err := validateChecksumMetadataKey(c.String("checksum-metadata-key")); err != nil {
+		return err
+	}
+
+	if c.String("checksum-metadata-key") != "" && !c.Bool("checksum") {
+		return fmt.Errorf(`"checksum-metadata-key" flag can only be used with "checksum" flag`)
+	}
+	return nil
+}
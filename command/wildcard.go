@@ -1,11 +1,11 @@
 package command
 
 import (
-	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/peak/s5cmd/v2/storage"
+	"github.com/peak/s5cmd/v2/storage/url"
 	"github.com/peak/s5cmd/v2/strutil"
 )
 
@@ -14,7 +14,7 @@ func createRegexFromWildcard(wildcards []string) ([]*regexp.Regexp, error) {
 	var result []*regexp.Regexp
 	for _, input := range wildcards {
 		if input != "" {
-			regex := strutil.WildCardToRegexp(input)
+			regex := wildcardToRegexp(input)
 			regex = strutil.MatchFromStartToEnd(regex)
 			regex = strutil.AddNewLineFlag(regex)
 			regexpCompiled, err := regexp.Compile(regex)
@@ -27,31 +27,63 @@ func createRegexFromWildcard(wildcards []string) ([]*regexp.Regexp, error) {
 	return result, nil
 }
 
-func isURLMatched(regexPatterns []*regexp.Regexp, urlPath, sourcePrefix string) bool {
+// createDirRegexFromWildcard creates the regexes matching the directories
+// whose contents are excluded as a whole by the given wildcards, e.g.
+// "node_modules/*" or "**/node_modules/**". Only the wildcards ending with
+// "*" exclude whole directories: if the rest of the wildcard matches the
+// beginning of a directory path, the trailing "*" matches all paths under it.
+func createDirRegexFromWildcard(wildcards []string) ([]*regexp.Regexp, error) {
+	var result []*regexp.Regexp
+	for _, input := range wildcards {
+		if !strings.HasSuffix(input, "*") {
+			continue
+		}
+		regex := "^" + wildcardToRegexp(strings.TrimRight(input, "*"))
+		regex = strutil.AddNewLineFlag(regex)
+		regexpCompiled, err := regexp.Compile(regex)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, regexpCompiled)
+	}
+	return result, nil
+}
+
+// wildcardToRegexp converts the wildcard to a regular expression, where
+// "**/" matches any number of directories, including none.
+func wildcardToRegexp(wildcard string) string {
+	parts := strings.Split(wildcard, "**/")
+	for i, part := range parts {
+		parts[i] = strutil.WildCardToRegexp(part)
+	}
+	return strings.Join(parts, "(.*/)?")
+}
+
+// isURLMatched reports whether the given key or path matches any of the
+// patterns. The patterns are matched against the path relative to the root
+// of the source.
+func isURLMatched(regexPatterns []*regexp.Regexp, urlPath string, src *url.URL) bool {
 	if len(regexPatterns) == 0 {
 		return false
 	}
-	if !strings.HasSuffix(sourcePrefix, "/") {
-		sourcePrefix += "/"
-	}
-	sourcePrefix = filepath.ToSlash(sourcePrefix)
+	relPath := src.RelativeKey(urlPath)
 	for _, regexPattern := range regexPatterns {
-		if regexPattern.MatchString(strings.TrimPrefix(urlPath, sourcePrefix)) {
+		if regexPattern.MatchString(relPath) {
 			return true
 		}
 	}
 	return false
 }
 
-func isObjectExcluded(object *storage.Object, excludePatterns []*regexp.Regexp, includePatterns []*regexp.Regexp, prefix string) (bool, error) {
+func isObjectExcluded(object *storage.Object, excludePatterns []*regexp.Regexp, includePatterns []*regexp.Regexp, src *url.URL) (bool, error) {
 	if err := object.Err; err != nil {
 		return true, err
 	}
-	if len(excludePatterns) > 0 && isURLMatched(excludePatterns, object.URL.Path, prefix) {
+	if len(excludePatterns) > 0 && isURLMatched(excludePatterns, object.URL.Path, src) {
 		return true, nil
 	}
 	if len(includePatterns) > 0 {
-		return !isURLMatched(includePatterns, object.URL.Path, prefix), nil
+		return !isURLMatched(includePatterns, object.URL.Path, src), nil
 	}
 	return false, nil
 }
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/peak/s5cmd/v2/storage"
@@ -55,6 +56,9 @@ func TestIsObjectExcluded(t *testing.T) {
 		},
 	}
 
+	src, err := url.New("s3://bucket/*")
+	assert.NilError(t, err)
+
 	for _, tc := range testcases {
 		tc := tc
 
@@ -71,7 +75,7 @@ func TestIsObjectExcluded(t *testing.T) {
 		var filteredObjects []string
 
 		for _, object := range tc.objects {
-			skip, err := isObjectExcluded(&storage.Object{URL: &url.URL{Path: object}}, excludeRegex, includeRegex, "")
+			skip, err := isObjectExcluded(&storage.Object{URL: &url.URL{Path: object}}, excludeRegex, includeRegex, src)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -84,3 +88,73 @@ func TestIsObjectExcluded(t *testing.T) {
 		assert.DeepEqual(t, tc.filteredObjects, filteredObjects)
 	}
 }
+
+func TestIsURLMatchedRelativeToSource(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		src      string
+		pattern  string
+		key      string
+		expected bool
+	}{
+		{name: "local directory", src: "project", pattern: "node_modules/*", key: filepath.Join("project", "node_modules", "a.js"), expected: true},
+		{name: "local directory with dot", src: "./project", pattern: "node_modules/*", key: filepath.Join("project", "node_modules", "a.js"), expected: true},
+		{name: "local directory with slash", src: "project/", pattern: "node_modules/*", key: filepath.Join("project", "node_modules", "a.js"), expected: true},
+		{name: "local nested directory", src: "project", pattern: "node_modules/*", key: filepath.Join("project", "web", "node_modules", "a.js"), expected: false},
+		{name: "local wildcard", src: "project/*.js", pattern: "lib/*", key: filepath.Join("project", "lib", "a.js"), expected: true},
+		{name: "local any depth", src: "project", pattern: "**/node_modules/**", key: filepath.Join("project", "web", "node_modules", "a.js"), expected: true},
+		{name: "local any depth at root", src: "project", pattern: "**/node_modules/**", key: filepath.Join("project", "node_modules", "a.js"), expected: true},
+		{name: "local single file", src: "a.log", pattern: "*.log", key: "a.log", expected: true},
+		{name: "remote prefix", src: "s3://bucket/backup/", pattern: "node_modules/*", key: "backup/node_modules/a.js", expected: true},
+		{name: "remote prefix without slash", src: "s3://bucket/backup", pattern: "node_modules/*", key: "backup/node_modules/a.js", expected: true},
+		{name: "remote wildcard", src: "s3://bucket/backup/2024*", pattern: "2024-01/*", key: "backup/2024-01/a.js", expected: true},
+		{name: "remote any depth", src: "s3://bucket/backup/*", pattern: "**/node_modules/**", key: "backup/web/node_modules/a.js", expected: true},
+		{name: "remote similar name", src: "s3://bucket/backup/*", pattern: "**/node_modules/**", key: "backup/web/node_modules.js", expected: false},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			src, err := url.New(tc.src)
+			assert.NilError(t, err)
+
+			patterns, err := createRegexFromWildcard([]string{tc.pattern})
+			assert.NilError(t, err)
+
+			assert.Equal(t, isURLMatched(patterns, filepath.ToSlash(tc.key), src), tc.expected)
+		})
+	}
+}
+
+func TestCreateDirRegexFromWildcard(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		pattern  string
+		dir      string
+		expected bool
+	}{
+		{pattern: "node_modules/*", dir: "node_modules/", expected: true},
+		{pattern: "node_modules/*", dir: "web/node_modules/", expected: false},
+		{pattern: "**/node_modules/**", dir: "node_modules/", expected: true},
+		{pattern: "**/node_modules/**", dir: "web/app/node_modules/", expected: true},
+		{pattern: "**/node_modules/**", dir: "web/", expected: false},
+		{pattern: "tmp*", dir: "tmp-1/", expected: true},
+		{pattern: "*.log", dir: "logs.log/", expected: false},
+	}
+
+	for _, tc := range testcases {
+		patterns, err := createDirRegexFromWildcard([]string{tc.pattern})
+		assert.NilError(t, err)
+
+		matched := false
+		for _, pattern := range patterns {
+			matched = matched || pattern.MatchString(tc.dir)
+		}
+		assert.Equal(t, matched, tc.expected, "pattern %q, dir %q", tc.pattern, tc.dir)
+	}
+}
@@ -1,16 +1,25 @@
 package e2e
 
 import (
+	jsonpkg "encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/peak/s5cmd/v2/command"
 
 	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
 	"gotest.tools/v3/icmd"
 )
 
@@ -260,31 +269,287 @@ func TestInvalidLoglevel(t *testing.T) {
 	})
 }
 
-func TestAppEndpointShouldHaveScheme(t *testing.T) {
+func TestInvalidBackend(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd := setup(t)
+
+	cmd := s5cmd("--backend", "azure", "ls")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`Incorrect Usage: invalid value "azure" for flag -backend: allowed values: [auto, aws, gcs, minio, r2]`),
+		1: equals("See 's5cmd --help' for usage"),
+	})
+}
+
+// --backend <backend> cp file s3://bucket/file
+func TestAppBackendTransferDefaults(t *testing.T) {
+	t.Parallel()
+
+	for _, backend := range []string{"auto", "aws", "gcs", "minio", "r2"} {
+		backend := backend
+		t.Run(backend, func(t *testing.T) {
+			t.Parallel()
+
+			s3client, s5cmd := setup(t)
+
+			bucket := s3BucketFromTestName(t)
+			createBucket(t, s3client, bucket)
+
+			const (
+				filename = "testfile.txt"
+				content  = "this is a file content"
+			)
+
+			workdir := fs.NewDir(t, bucket, fs.WithFile(filename, content))
+			defer workdir.Remove()
+
+			srcpath := filepath.ToSlash(workdir.Join(filename))
+			dstpath := fmt.Sprintf("s3://%v/%v", bucket, filename)
+
+			cmd := s5cmd("--backend", backend, "cp", srcpath, dstpath)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Success)
+			assert.Assert(t, ensureS3Object(s3client, bucket, filename, content))
+
+			// the backend is read from the environment variable too, and the
+			// explicit flags override the defaults of the backend.
+			cmd = s5cmd("cat", "--part-size", "5", "-c", "2", dstpath)
+			result = icmd.RunCmd(cmd, withEnv("S5CMD_BACKEND", backend))
+
+			result.Assert(t, icmd.Success)
+			assert.Equal(t, result.Stdout(), content)
+		})
+	}
+}
+
+func TestAppEndpointScheme(t *testing.T) {
 	t.Parallel()
 
 	testcases := []struct {
 		name             string
-		endpointURL      string
+		args             []string
 		expectedError    error
 		expectedExitCode int
 	}{
 		{
 			name:             "endpoint_with_http_scheme",
-			endpointURL:      "http://storage.googleapis.com",
+			args:             []string{"--endpoint-url", "http://storage.googleapis.com"},
 			expectedError:    nil,
 			expectedExitCode: 0,
 		},
 		{
 			name:             "endpoint_with_https_scheme",
-			endpointURL:      "https://storage.googleapis.com",
+			args:             []string{"--endpoint-url", "https://storage.googleapis.com"},
 			expectedError:    nil,
 			expectedExitCode: 0,
 		},
 		{
 			name:             "endpoint_with_no_scheme",
-			endpointURL:      "storage.googleapis.com",
-			expectedError:    fmt.Errorf(`ERROR bad value for --endpoint-url storage.googleapis.com: scheme is missing. Must be of the form http://<hostname>/ or https://<hostname>/`),
+			args:             []string{"--endpoint-url", "storage.googleapis.com"},
+			expectedError:    nil,
+			expectedExitCode: 0,
+		},
+		{
+			name:             "endpoint_with_no_scheme_and_endpoint_scheme",
+			args:             []string{"--endpoint-url", "storage.googleapis.com", "--endpoint-scheme", "http"},
+			expectedError:    nil,
+			expectedExitCode: 0,
+		},
+		{
+			name:             "endpoint_with_unsupported_scheme",
+			args:             []string{"--endpoint-url", "ftp://storage.googleapis.com"},
+			expectedError:    fmt.Errorf(`ERROR bad value for --endpoint-url ftp://storage.googleapis.com: unsupported scheme "ftp". Must be of the form http://<hostname>/ or https://<hostname>/`),
+			expectedExitCode: 1,
+		},
+		{
+			name:             "endpoint_with_query",
+			args:             []string{"--endpoint-url", "https://storage.googleapis.com/s3?region=eu"},
+			expectedError:    fmt.Errorf(`ERROR bad value for --endpoint-url https://storage.googleapis.com/s3?region=eu: endpoint can't have a query or a fragment, only a base path, e.g. https://<hostname>/<path>`),
+			expectedExitCode: 1,
+		},
+		{
+			name:             "endpoint_with_conflicting_endpoint_scheme",
+			args:             []string{"--endpoint-url", "http://storage.googleapis.com", "--endpoint-scheme", "https"},
+			expectedError:    fmt.Errorf(`ERROR bad value for --endpoint-url http://storage.googleapis.com: scheme "http" conflicts with the endpoint scheme "https"`),
+			expectedExitCode: 1,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: tc.expectedExitCode})
+
+			if tc.expectedError == nil && result.Stderr() == "" {
+				return
+			}
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals("%v", tc.expectedError),
+			})
+
+		})
+	}
+}
+
+// ls with a schemeless endpoint of the local server, which is served over
+// http.
+func TestAppEndpointWithoutSchemeLocalhost(t *testing.T) {
+	t.Parallel()
+
+	if isEndpointFromEnv() {
+		t.Skip("the endpoint is not served on localhost")
+	}
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "file.txt", "content")
+
+	endpoint, err := url.Parse(aws.StringValue(s3client.Config.Endpoint))
+	assert.NilError(t, err)
+
+	// the host of the local server is replaced with localhost to infer http
+	// scheme.
+	schemeless := "localhost:" + endpoint.Port()
+
+	cmd := s5cmd("--endpoint-url", schemeless, "ls", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("file.txt"),
+	})
+}
+
+// cp, ls and cat with an endpoint which has a base path, e.g. of an S3
+// compatible service behind a reverse proxy.
+func TestAppEndpointWithPathPrefix(t *testing.T) {
+	t.Parallel()
+
+	if isEndpointFromEnv() {
+		t.Skip("the endpoint is not served under a path prefix")
+	}
+
+	// the server responds with 404 to the requests outside of the prefix.
+	s3client, s5cmd := setup(t, withEndpointPathPrefix("/s3"))
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, t.Name(), fs.WithFile("file.txt", "content"))
+	defer workdir.Remove()
+
+	dst := fmt.Sprintf("s3://%v/prefix/file.txt", bucket)
+
+	cmd := s5cmd("cp", "file.txt", dst)
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+	assert.Assert(t, ensureS3Object(s3client, bucket, "prefix/file.txt", "content"))
+
+	cmd = s5cmd("ls", fmt.Sprintf("s3://%v/*", bucket))
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("prefix/file.txt"),
+	})
+
+	// the trailing slash of the base path is removed.
+	endpoint := aws.StringValue(s3client.Config.Endpoint) + "/"
+
+	cmd = s5cmd("--endpoint-url", endpoint, "cat", dst)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+	assert.Equal(t, result.Stdout(), "content")
+}
+
+func TestAppRetryOn(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name             string
+		retryOn          string
+		expectedError    error
+		expectedExitCode int
+	}{
+		{
+			name:             "valid_classes",
+			retryOn:          "throttle,5xx",
+			expectedExitCode: 0,
+		},
+		{
+			name:             "unknown_class",
+			retryOn:          "throttle,4xx",
+			expectedError:    fmt.Errorf(`ERROR bad value for --retry-on "throttle,4xx": unknown error class "4xx": valid classes are throttle, 5xx, network and timeout`),
+			expectedExitCode: 1,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			cmd := s5cmd("--retry-on", tc.retryOn)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: tc.expectedExitCode})
+
+			if tc.expectedError == nil && result.Stderr() == "" {
+				return
+			}
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals("%v", tc.expectedError),
+			})
+		})
+	}
+}
+
+func TestAppExpectedBucketOwnerShouldBeAccountID(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name             string
+		owner            string
+		expectedError    error
+		expectedExitCode int
+	}{
+		{
+			name:             "account_id",
+			owner:            "111122223333",
+			expectedExitCode: 0,
+		},
+		{
+			name:             "short_account_id",
+			owner:            "1111",
+			expectedError:    fmt.Errorf(`ERROR bad value for --expected-bucket-owner "1111": must be a 12-digit AWS account ID`),
+			expectedExitCode: 1,
+		},
+		{
+			name:             "non_numeric_account_id",
+			owner:            "my-account-1",
+			expectedError:    fmt.Errorf(`ERROR bad value for --expected-bucket-owner "my-account-1": must be a 12-digit AWS account ID`),
 			expectedExitCode: 1,
 		},
 	}
@@ -296,7 +561,7 @@ func TestAppEndpointShouldHaveScheme(t *testing.T) {
 
 			_, s5cmd := setup(t)
 
-			cmd := s5cmd("--endpoint-url", tc.endpointURL)
+			cmd := s5cmd("--expected-bucket-owner", tc.owner)
 			result := icmd.RunCmd(cmd)
 
 			result.Assert(t, icmd.Expected{ExitCode: tc.expectedExitCode})
@@ -308,7 +573,714 @@ func TestAppEndpointShouldHaveScheme(t *testing.T) {
 			assertLines(t, result.Stderr(), map[int]compareFunc{
 				0: equals("%v", tc.expectedError),
 			})
+		})
+	}
+}
+
+func TestAppExpectedBucketOwner(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile.txt", "content")
+
+	cmd := s5cmd("--expected-bucket-owner", "111122223333", "ls", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("7 testfile.txt"),
+	})
+}
+
+func TestAppRemote(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile.txt", "content")
+
+	// the endpoint is passed with the first two arguments.
+	endpoint := s5cmd().Command[2]
+
+	workdir := fs.NewDir(t, "remote", fs.WithFile("config.toml", fmt.Sprintf(`
+[remotes.local]
+endpoint = %q
+addressing_style = "path"
+
+[remotes.unreachable]
+endpoint = "http://127.0.0.1:1"
+`, endpoint)))
+	defer workdir.Remove()
+
+	config := workdir.Join("config.toml")
+
+	// endpoint of the remote is used if --endpoint-url flag is not set.
+	cmd := s5cmd("--config", config, "--remote", "local", "ls", "s3://"+bucket)
+	cmd.Command = append(cmd.Command[:1], cmd.Command[3:]...)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("7 testfile.txt"),
+	})
+
+	// --endpoint-url flag takes precedence over the remote.
+	cmd = s5cmd("--config", config, "--remote", "unreachable", "ls", "s3://"+bucket)
+	result = icmd.RunCmd(cmd)
 
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("7 testfile.txt"),
+	})
+}
+
+func TestAppRemoteNotDefined(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd := setup(t)
+
+	workdir := fs.NewDir(t, "remote", fs.WithFile("config.toml", "[remotes.minio]\nendpoint = \"http://localhost:9000\"\n"))
+	defer workdir.Remove()
+
+	config := workdir.Join("config.toml")
+	cmd := s5cmd("--config", config, "--remote", "aws")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR remote "aws" is not defined in config file %q: defined remotes are [minio]`, config),
+	})
+}
+
+func TestAppCredentialProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip()
+	}
+
+	t.Parallel()
+
+	const (
+		validProcess   = "#!/bin/sh\necho '{\"Version\": 1, \"AccessKeyId\": \"key-id\", \"SecretAccessKey\": \"secret\"}'\n"
+		failingProcess = "#!/bin/sh\necho 'not authorized' >&2\nexit 2\n"
+	)
+
+	workdir := fs.NewDir(t, t.Name(),
+		fs.WithFile("valid.sh", validProcess, fs.WithMode(0755)),
+		fs.WithFile("failing.sh", failingProcess, fs.WithMode(0755)),
+	)
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+
+		s3client, s5cmd := setup(t)
+
+		bucket := s3BucketFromTestName(t)
+		createBucket(t, s3client, bucket)
+		putFile(t, s3client, bucket, "testfile.txt", "content")
+
+		cmd := s5cmd("--credential-process", workdir.Join("valid.sh"), "ls", "s3://"+bucket+"/")
+		result := icmd.RunCmd(cmd)
+
+		result.Assert(t, icmd.Success)
+
+		assertLines(t, result.Stdout(), map[int]compareFunc{
+			0: suffix("7 testfile.txt"),
+		})
+	})
+
+	t.Run("failing", func(t *testing.T) {
+		t.Parallel()
+
+		s3client, s5cmd := setup(t)
+
+		bucket := s3BucketFromTestName(t)
+		createBucket(t, s3client, bucket)
+
+		process := workdir.Join("failing.sh")
+		cmd := s5cmd("--credential-process", process, "ls", "s3://"+bucket+"/")
+		result := icmd.RunCmd(cmd)
+
+		result.Assert(t, icmd.Expected{ExitCode: 1})
+
+		assertLines(t, result.Stderr(), map[int]compareFunc{
+			0: equals("not authorized"),
+			1: contains(`credential process %q failed: exit status 2`, process),
+		}, strictLineCheck(false))
+	})
+
+	t.Run("with profile", func(t *testing.T) {
+		t.Parallel()
+
+		_, s5cmd := setup(t)
+
+		cmd := s5cmd("--credential-process", workdir.Join("valid.sh"), "--profile", "p1")
+		result := icmd.RunCmd(cmd)
+
+		result.Assert(t, icmd.Expected{ExitCode: 1})
+
+		assertLines(t, result.Stderr(), map[int]compareFunc{
+			0: equals(`ERROR "credential-process" and "profile" flags cannot be used together`),
 		})
+	})
+}
+
+func TestAppWebIdentityValidation(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name          string
+		args          []string
+		env           []string
+		expectedError string
+	}{
+		{
+			name:          "token file without role",
+			args:          []string{"--web-identity-token-file", "token"},
+			expectedError: `ERROR "web-identity-token-file" flag requires "assume-role-arn" flag or AWS_ROLE_ARN environment variable`,
+		},
+		{
+			name:          "role without token file",
+			args:          []string{"--assume-role-arn", "arn:aws:iam::123456789012:role/s5cmd"},
+			expectedError: `ERROR "assume-role-arn" flag requires "web-identity-token-file" flag or AWS_WEB_IDENTITY_TOKEN_FILE environment variable`,
+		},
+		{
+			name:          "role from environment with profile",
+			args:          []string{"--web-identity-token-file", "token", "--profile", "p1"},
+			env:           []string{"AWS_ROLE_ARN=arn:aws:iam::123456789012:role/s5cmd"},
+			expectedError: `ERROR "web-identity-token-file" and "profile" flags cannot be used together`,
+		},
 	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd, icmd.WithEnv(append(os.Environ(), tc.env...)...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals("%v", tc.expectedError),
+			})
+		})
+	}
+}
+
+func TestAppAppendWithoutOutputFile(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd := setup(t)
+
+	cmd := s5cmd("--append")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "append" flag can only be used with "output-file" flag`),
+	})
+}
+
+func TestAppOnlyShowErrors(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithFile("file1.txt", "content"),
+		fs.WithFile("file2.txt", "content"),
+	)
+	defer workdir.Remove()
+
+	// the progress bar, the statistics, the debug messages and the warning
+	// of the total concurrency are not printed.
+	cmd := s5cmd(
+		"--only-show-errors", "--stat", "--log", "debug", "--numworkers", "1000",
+		"cp", "--show-progress", "--part-concurrency", "20", workdir.Path()+"/", "s3://"+bucket+"/",
+	)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assert.Equal(t, "", result.Stdout())
+	assert.Equal(t, "", result.Stderr())
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "file1.txt", "content"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "file2.txt", "content"))
+
+	cmd = s5cmd("--only-show-errors", "cp", "s3://"+bucket+"/missing.txt", ".")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assert.Equal(t, "", result.Stdout())
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`ERROR "cp s3://%v/missing.txt missing.txt"`, bucket),
+	})
+}
+
+func TestAppPageSizeValidation(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name          string
+		pageSize      string
+		expectedError string
+	}{
+		{
+			name:          "zero",
+			pageSize:      "0",
+			expectedError: `ERROR bad value for --page-size 0: must be between 1 and 1000`,
+		},
+		{
+			name:          "above the maximum",
+			pageSize:      "1001",
+			expectedError: `ERROR bad value for --page-size 1001: must be between 1 and 1000`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			cmd := s5cmd("--page-size", tc.pageSize)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals("%v", tc.expectedError),
+			})
+		})
+	}
+}
+
+func TestAppRetryBudgetValidation(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd := setup(t)
+
+	cmd := s5cmd("--retry-budget", "0")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR bad value for --retry-budget 0: must be a positive number`),
+	})
+}
+
+func TestAppRetryBudget(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int64
+	// a backend which always fails.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, s5cmd := setup(t, withEndpointURL(server.URL))
+
+	cmd := s5cmd("--retry-count", "10", "--retry-budget", "2", "ls", "s3://bucket/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals("WARNING: the retry budget of 2 retries is exhausted, the failed requests are not retried anymore."),
+		1: prefix("ERROR session: fetching region failed: InternalServerError"),
+		3: prefix(`ERROR "ls s3://bucket/*": InternalServerError`),
+	}, strictLineCheck(false))
+
+	// the region request spends the budget, and the list request is not
+	// retried at all.
+	assert.Equal(t, requests.Load(), int64(1+2+1))
+}
+
+func TestAppLogRequests(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "file.txt", "content")
+
+	cmd := s5cmd("--log-requests", "cat", "s3://"+bucket+"/file.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	// the requests are never printed to standard output.
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals("content"),
+	})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: match(fmt.Sprintf(`^DEBUG request HeadBucket s3://%v/ status=200 request_id=\S+ duration=\S+ retries=0$`, bucket)),
+		1: match(fmt.Sprintf(`^DEBUG request HeadObject s3://%v/file.txt status=200 request_id=\S+ duration=\S+ retries=0$`, bucket)),
+		2: match(fmt.Sprintf(`^DEBUG request GetObject s3://%v/file.txt status=200 request_id=\S+ duration=\S+ retries=0$`, bucket)),
+	})
+}
+
+func TestAppLogRequestsRetries(t *testing.T) {
+	t.Parallel()
+
+	// a backend which always fails.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, s5cmd := setup(t, withEndpointURL(server.URL))
+
+	cmd := s5cmd("--log-requests", "--retry-count", "1", "ls", "s3://bucket/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	// each attempt of the requests is printed with its retry count.
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: match(`^DEBUG request HeadBucket s3://bucket/ status=500 request_id=- duration=\S+ retries=0 error=InternalServerError$`),
+		1: match(`^DEBUG request HeadBucket s3://bucket/ status=500 request_id=- duration=\S+ retries=1 error=InternalServerError$`),
+		4: match(`^DEBUG request ListObjectsV2 s3://bucket/ status=500 request_id=- duration=\S+ retries=0 error=InternalServerError$`),
+		5: match(`^DEBUG request ListObjectsV2 s3://bucket/ status=500 request_id=- duration=\S+ retries=1 error=InternalServerError$`),
+	}, strictLineCheck(false))
+}
+
+func TestAppLogRequestsSample(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "file.txt", "content")
+
+	cmd := s5cmd("--log-requests", "--log-requests-sample", "0.000001", "cat", "s3://"+bucket+"/file.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals("content"),
+	})
+	assertLines(t, result.Stderr(), map[int]compareFunc{})
+}
+
+func TestAppLogRequestsValidation(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "sample out of range",
+			args:     []string{"--log-requests", "--log-requests-sample", "1.5"},
+			expected: `ERROR bad value for --log-requests-sample 1.5: must be greater than 0 and at most 1`,
+		},
+		{
+			name:     "sample without log requests",
+			args:     []string{"--log-requests-sample", "0.1"},
+			expected: `ERROR "log-requests-sample" flag can only be used with "log-requests" flag`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
+
+// --concurrency-ramp 1s --numworkers 4 cp dir/* s3://bucket/
+func TestAppConcurrencyRamp(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, t.Name(),
+		fs.WithFile("file1.txt", "content1"),
+		fs.WithFile("file2.txt", "content2"),
+		fs.WithFile("file3.txt", "content3"),
+	)
+	defer workdir.Remove()
+
+	cmd := s5cmd("--concurrency-ramp", "1s", "--numworkers", "4", "cp", "*", "s3://"+bucket+"/")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp file1.txt s3://%v/file1.txt`, bucket),
+		1: equals(`cp file2.txt s3://%v/file2.txt`, bucket),
+		2: equals(`cp file3.txt s3://%v/file3.txt`, bucket),
+	}, sortInput(true))
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "file1.txt", "content1"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "file2.txt", "content2"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "file3.txt", "content3"))
+}
+
+// --adaptive --numworkers 4 cp dir/* s3://bucket/
+func TestAppAdaptive(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, t.Name(),
+		fs.WithFile("file1.txt", "content1"),
+		fs.WithFile("file2.txt", "content2"),
+	)
+	defer workdir.Remove()
+
+	cmd := s5cmd("--adaptive", "--numworkers", "4", "cp", "*", "s3://"+bucket+"/")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp file1.txt s3://%v/file1.txt`, bucket),
+		1: equals(`cp file2.txt s3://%v/file2.txt`, bucket),
+	}, sortInput(true))
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "file1.txt", "content1"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "file2.txt", "content2"))
+}
+
+// --rate-limit 256KB cp --progress-json dir/* s3://bucket/
+func TestAppRateLimit(t *testing.T) {
+	t.Parallel()
+
+	const (
+		rate      = 256 * 1024
+		largeSize = 512 * 1024
+		smallSize = 16 * 1024
+	)
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	files := map[string]string{
+		"large.bin":  randomString(largeSize),
+		"small1.bin": randomString(smallSize),
+		"small2.bin": randomString(smallSize),
+		"small3.bin": randomString(smallSize),
+	}
+
+	var ops []fs.PathOp
+	for name, content := range files {
+		ops = append(ops, fs.WithFile(name, content))
+	}
+	workdir := fs.NewDir(t, t.Name(), ops...)
+	defer workdir.Remove()
+
+	start := time.Now()
+	cmd := s5cmd("--rate-limit", "256KB", "cp", "--progress-json", "*", "s3://"+bucket+"/")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+	elapsed := time.Since(start)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp large.bin s3://%v/large.bin`, bucket),
+		1: equals(`cp small1.bin s3://%v/small1.bin`, bucket),
+		2: equals(`cp small2.bin s3://%v/small2.bin`, bucket),
+		3: equals(`cp small3.bin s3://%v/small3.bin`, bucket),
+	}, sortInput(true))
+
+	// each of the small files makes progress with its share of the bandwidth
+	// while the large file is uploaded, so they are done before it.
+	var done []string
+	for _, line := range strings.Split(strings.TrimSpace(result.Stderr()), "\n") {
+		var event struct {
+			Type string `json:"type"`
+			File string `json:"file"`
+			Done bool   `json:"done"`
+		}
+		assert.NilError(t, jsonpkg.Unmarshal([]byte(line), &event), line)
+		if event.Type == "file" && event.Done {
+			done = append(done, event.File)
+		}
+	}
+	assert.Equal(t, len(done), len(files))
+	assert.Equal(t, done[len(done)-1], "large.bin")
+
+	total := largeSize + 3*smallSize
+	assert.Assert(t, elapsed >= time.Duration(total/rate)*time.Second, "expected the bandwidth to be limited, took %v", elapsed)
+
+	for name, content := range files {
+		assert.Assert(t, ensureS3Object(s3client, bucket, name, content))
+	}
+}
+
+func TestAppRateLimitValidation(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd := setup(t)
+
+	cmd := s5cmd("--rate-limit", "fast")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR bad value for --rate-limit "fast": must be a positive size per second, e.g. 10MB`),
+	})
+}
+
+func TestAppConcurrencyRampValidation(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd := setup(t)
+
+	cmd := s5cmd("--concurrency-ramp", "-1s")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR bad value for --concurrency-ramp -1s: must be a positive duration`),
+	})
+}
+
+func TestAppPageSize(t *testing.T) {
+	t.Parallel()
+
+	// bolt backend does not paginate the listings.
+	s3client, s5cmd := setup(t, withS3Backend("mem"))
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	for i := 0; i < 5; i++ {
+		putFile(t, s3client, bucket, fmt.Sprintf("file%d.txt", i), "content")
+	}
+
+	cmd := s5cmd("--page-size", "2", "ls", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("file0.txt"),
+		1: suffix("file1.txt"),
+		2: suffix("file2.txt"),
+		3: suffix("file3.txt"),
+		4: suffix("file4.txt"),
+	})
+
+	// the sampled pages have as many objects as the page size.
+	cmd = s5cmd("--json", "--page-size", "2", "du", "--sample", "2", "s3://"+bucket+"/*")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: contains(`"sample_count":4`),
+	})
+}
+
+func TestAppFlagsFromEnvironment(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "file.txt", "content")
+
+	// the flags are set by the environment variables.
+	cmd := s5cmd("ls", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd, withEnv("S5CMD_JSON", "true"))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: contains(`"key":"s3://%v/file.txt"`, bucket),
+	})
+
+	// the explicit flags take precedence over the environment variables.
+	cmd = s5cmd("--json=false", "ls", "s3://"+bucket+"/*")
+	result = icmd.RunCmd(cmd, withEnv("S5CMD_JSON", "true"))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("file.txt"),
+	})
+
+	workdir := fs.NewDir(t, t.Name(), fs.WithFile("new.txt", "content"))
+	defer workdir.Remove()
+
+	cmd = s5cmd("cp", workdir.Join("new.txt"), "s3://"+bucket+"/")
+	result = icmd.RunCmd(cmd, withEnv("S5CMD_DRY_RUN", "true"), withEnv("S5CMD_NUMWORKERS", "2"))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v s3://%v/new.txt`, workdir.Join("new.txt"), bucket),
+	})
+
+	assertError(t, ensureS3Object(s3client, bucket, "new.txt", "content"), errS3NoSuchKey)
+}
+
+func TestAppFlagsFromEnvironmentInvalidValue(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd := setup(t)
+
+	cmd := s5cmd("ls")
+	result := icmd.RunCmd(cmd, withEnv("S5CMD_LOG_LEVEL", "verbose"))
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`could not parse "verbose" from environment variable "S5CMD_LOG_LEVEL" as value for flag log`),
+		1: equals(`See 's5cmd --help' for usage`),
+	})
 }
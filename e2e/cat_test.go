@@ -2,11 +2,17 @@ package e2e
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
 	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/google/go-cmp/cmp"
 	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
 	"gotest.tools/v3/icmd"
 )
 
@@ -548,3 +554,528 @@ func TestPrefixWildcardFail(t *testing.T) {
 		})
 	}
 }
+
+func TestCatS3ObjectIfModifiedSince(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "file.txt", "content")
+
+	cmd := s5cmd("cat", "--if-modified-since", "2006-01-02T15:04:05Z", "s3://"+bucket+"/file.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals("content"),
+	})
+}
+
+func TestCatS3ObjectInvalidIfModifiedSince(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	cmd := s5cmd("cat", "--if-modified-since", "yesterday", "s3://"+bucket+"/file.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`invalid value for "if-modified-since" flag "yesterday": expected RFC3339 format`),
+	})
+}
+
+func TestCatS3ObjectWithMaxObjectSize(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "file.txt", "this is a large file")
+
+	cmd := s5cmd("cat", "--max-object-size", "10", "s3://"+bucket+"/file.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{})
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`object 's3://%v/file.txt' is larger than the maximum object size: 20 > 10`, bucket),
+	})
+
+	cmd = s5cmd("cat", "--max-object-size", "1K", "s3://"+bucket+"/file.txt")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals("this is a large file"),
+	})
+}
+
+func TestCatS3ObjectWithOutputFile(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	const content = "this is a file content"
+	putFile(t, s3client, bucket, "file.txt", content)
+
+	workdir := fs.NewDir(t, t.Name())
+	defer workdir.Remove()
+
+	cmd := s5cmd("--output-file", "output.txt", "cat", "s3://"+bucket+"/file.txt")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	assert.Equal(t, result.Stdout(), "")
+
+	expected := fs.Expected(t, fs.WithFile("output.txt", content, fs.WithMode(0644)))
+	assert.Assert(t, fs.Equal(workdir.Path(), expected))
+}
+
+func TestCatS3ObjectWithGrepAndLineNumbers(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "app.log", "INFO started\nERROR failed\nINFO retrying\nERROR failed again")
+
+	testcases := []struct {
+		name     string
+		flags    []string
+		expected string
+	}{
+		{
+			name:     "grep",
+			flags:    []string{"--grep", "ERROR"},
+			expected: "ERROR failed\nERROR failed again\n",
+		},
+		{
+			name:     "line numbers",
+			flags:    []string{"--line-numbers"},
+			expected: "     1\tINFO started\n     2\tERROR failed\n     3\tINFO retrying\n     4\tERROR failed again\n",
+		},
+		{
+			name:     "grep with line numbers",
+			flags:    []string{"--grep", "again$", "--line-numbers"},
+			expected: "     4\tERROR failed again\n",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			args := append([]string{"cat"}, tc.flags...)
+			cmd := s5cmd(append(args, "s3://"+bucket+"/app.log")...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Success)
+			assert.Equal(t, result.Stdout(), tc.expected)
+		})
+	}
+}
+
+func TestCatWildcardWithLineNumbers(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "log-1.txt", "ERROR a\nINFO b")
+	putFile(t, s3client, bucket, "log-2.txt", "INFO c\nERROR d\n")
+
+	testcases := []struct {
+		name     string
+		flags    []string
+		expected string
+	}{
+		{
+			name:     "continuous line numbers",
+			flags:    []string{"--line-numbers"},
+			expected: "     1\tERROR a\n     2\tINFO b\n     3\tINFO c\n     4\tERROR d\n",
+		},
+		{
+			name:     "reset line numbers",
+			flags:    []string{"--line-numbers", "--reset-line-numbers"},
+			expected: "     1\tERROR a\n     2\tINFO b\n     1\tINFO c\n     2\tERROR d\n",
+		},
+		{
+			name:     "grep with reset line numbers",
+			flags:    []string{"--grep", "ERROR", "--line-numbers", "--reset-line-numbers"},
+			expected: "     1\tERROR a\n     2\tERROR d\n",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			args := append([]string{"cat"}, tc.flags...)
+			cmd := s5cmd(append(args, "s3://"+bucket+"/log-*")...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Success)
+			assert.Equal(t, result.Stdout(), tc.expected)
+		})
+	}
+}
+
+func TestCatByVersionIDWithGrep(t *testing.T) {
+	skipTestIfGCS(t, "versioning is not supported in GCS")
+
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	// versioninng is only supported with in memory backend!
+	s3client, s5cmd := setup(t, withS3Backend("mem"))
+
+	const filename = "app.log"
+
+	contents := []string{
+		"INFO first\nERROR first version",
+		"ERROR second version\nINFO second",
+	}
+
+	createBucket(t, s3client, bucket)
+	setBucketVersioning(t, s3client, bucket, "Enabled")
+
+	putFile(t, s3client, bucket, filename, contents[0])
+	putFile(t, s3client, bucket, filename, contents[1])
+
+	cmd := s5cmd("ls", "--all-versions", "s3://"+bucket+"/"+filename)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	versionIDs := make([]string, 0)
+	for _, row := range strings.Split(result.Stdout(), "\n") {
+		if row != "" {
+			arr := strings.Split(row, " ")
+			versionIDs = append(versionIDs, arr[len(arr)-1])
+		}
+	}
+	assert.Equal(t, len(versionIDs), 2)
+
+	expected := []string{
+		"     2\tERROR first version\n",
+		"     1\tERROR second version\n",
+	}
+
+	for i, version := range versionIDs {
+		cmd = s5cmd("cat", "--version-id", version, "--grep", "ERROR", "--line-numbers",
+			fmt.Sprintf("s3://%v/%v", bucket, filename))
+		result = icmd.RunCmd(cmd)
+
+		result.Assert(t, icmd.Success)
+		assert.Equal(t, result.Stdout(), expected[i])
+	}
+
+	// the latest version is printed without version-id flag.
+	cmd = s5cmd("cat", "--grep", "INFO", "s3://"+bucket+"/"+filename)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+	assert.Equal(t, result.Stdout(), "INFO second\n")
+}
+
+// binaryContent returns a content with all the byte values, including NUL,
+// carriage return and new line characters, without a trailing new line.
+func binaryContent() string {
+	var b strings.Builder
+	for i := 0; i < 3; i++ {
+		for c := 0; c < 256; c++ {
+			b.WriteByte(byte(255 - c))
+		}
+	}
+	return b.String()
+}
+
+func TestCatS3ObjectRaw(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	// the key contains glob characters, which are not expanded with raw flag.
+	content := binaryContent()
+	putFile(t, s3client, bucket, "image[1].bin", content)
+	putFile(t, s3client, bucket, "image1.bin", "another content")
+
+	cmd := s5cmd("cat", "--raw", "s3://"+bucket+"/image[1].bin")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+	assert.Equal(t, result.Stdout(), content)
+}
+
+func TestCatWildcardNullSeparated(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	content := binaryContent()
+	putFile(t, s3client, bucket, "record-1.bin", content)
+	putFile(t, s3client, bucket, "record-2.bin", "second\nrecord")
+
+	testcases := []struct {
+		name     string
+		flags    []string
+		expected string
+	}{
+		{
+			name:     "null separated",
+			flags:    []string{"--null-separated"},
+			expected: content + "\x00" + "second\nrecord" + "\x00",
+		},
+		{
+			name:     "null separated with grep",
+			flags:    []string{"--null-separated", "--grep", "^record$"},
+			expected: "\x00" + "record\n" + "\x00",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			args := append([]string{"cat"}, tc.flags...)
+			cmd := s5cmd(append(args, "s3://"+bucket+"/record-*")...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Success)
+			assert.Equal(t, result.Stdout(), tc.expected)
+		})
+	}
+}
+
+// cat [--verify-first] s3://bucket/*
+func TestCatWildcardWithMissingObject(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name           string
+		flags          []string
+		expectedOutput string
+		expectedError  string
+	}{
+		{
+			name: "partial output without verify-first",
+			// the objects before the missing one are printed.
+			expectedOutput: "first",
+			expectedError:  "NoSuchKey",
+		},
+		{
+			name:          "no output with verify-first",
+			flags:         []string{"--verify-first"},
+			expectedError: "b.txt not found",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s3client, s5cmd := setup(t)
+
+			bucket := s3BucketFromTestName(t)
+			createBucket(t, s3client, bucket)
+
+			putFile(t, s3client, bucket, "a.txt", "first")
+			putFile(t, s3client, bucket, "b.txt", "second")
+			putFile(t, s3client, bucket, "c.txt", "third")
+
+			// the gateway lists the missing object, but it's deleted
+			// before it's read.
+			target, err := url.Parse(aws.StringValue(s3client.Config.Endpoint))
+			assert.NilError(t, err)
+			proxy := httputil.NewSingleHostReverseProxy(target)
+			gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, "/b.txt") {
+					w.WriteHeader(http.StatusNotFound)
+					if r.Method == http.MethodGet {
+						fmt.Fprint(w, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+					}
+					return
+				}
+				proxy.ServeHTTP(w, r)
+			}))
+			defer gateway.Close()
+
+			src := fmt.Sprintf("s3://%v/*", bucket)
+			args := append([]string{"--endpoint-url", gateway.URL, "cat"}, tc.flags...)
+			cmd := s5cmd(append(args, src)...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+			assert.Equal(t, result.Stdout(), tc.expectedOutput)
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: contains(tc.expectedError),
+			})
+		})
+	}
+}
+
+func TestCatWithInvalidLineFlags(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	testcases := []struct {
+		name     string
+		flags    []string
+		expected string
+	}{
+		{
+			name:     "invalid grep expression",
+			flags:    []string{"--grep", "ERROR("},
+			expected: `ERROR "cat --grep=ERROR( s3://%v/file.txt": invalid grep expression: error parsing regexp: missing closing ): ` + "`ERROR(`",
+		},
+		{
+			name:     "reset line numbers without line numbers",
+			flags:    []string{"--reset-line-numbers"},
+			expected: `ERROR "cat --reset-line-numbers=true s3://%v/file.txt": "reset-line-numbers" flag can only be used with "line-numbers" flag`,
+		},
+		{
+			name:     "raw with grep",
+			flags:    []string{"--raw", "--grep", "ERROR"},
+			expected: `ERROR "cat --raw=true --grep=ERROR s3://%v/file.txt": "raw" flag cannot be used with "grep" or "line-numbers" flags`,
+		},
+		{
+			name:     "raw with line numbers",
+			flags:    []string{"--raw", "--line-numbers"},
+			expected: `ERROR "cat --raw=true --line-numbers=true s3://%v/file.txt": "raw" flag cannot be used with "grep" or "line-numbers" flags`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			args := append([]string{"cat"}, tc.flags...)
+			cmd := s5cmd(append(args, "s3://"+bucket+"/file.txt")...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected, bucket),
+			})
+		})
+	}
+}
+
+// cat --ranges-from ranges.txt
+func TestCatRangesFrom(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	// the original file is split into two objects with some padding around
+	// the halves.
+	const (
+		first  = "the first half of the file, "
+		second = "and the second half of it.\n"
+	)
+	putFile(t, s3client, bucket, "part-1.bin", "HEADER"+first+"FOOTER")
+	putFile(t, s3client, bucket, "part-2.bin", "HDR"+second)
+
+	ranges := fmt.Sprintf("s3://%v/part-1.bin 6-%d\n\ns3://%v/part-2.bin 3-\n", bucket, 6+len(first)-1, bucket)
+	workdir := fs.NewDir(t, bucket, fs.WithFile("ranges.txt", ranges))
+	defer workdir.Remove()
+
+	cmd := s5cmd("cat", "--ranges-from", workdir.Join("ranges.txt"))
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+	assert.Equal(t, result.Stdout(), first+second)
+}
+
+func TestCatRangesFromValidation(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket,
+		fs.WithFile("ranges.txt", fmt.Sprintf("s3://%v/object 0-9\n", bucket)),
+		fs.WithFile("invalid.txt", fmt.Sprintf("s3://%v/object 0-9\ns3://%v/object 9-0\n", bucket, bucket)),
+		fs.WithFile("empty.txt", "\n"),
+	)
+	defer workdir.Remove()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "source argument",
+			args:     []string{"--ranges-from", workdir.Join("ranges.txt"), "s3://" + bucket + "/object"},
+			expected: `source argument cannot be given with "ranges-from" flag`,
+		},
+		{
+			name:     "version id",
+			args:     []string{"--ranges-from", workdir.Join("ranges.txt"), "--version-id", "1"},
+			expected: `"ranges-from" flag cannot be used with "version-id" flag`,
+		},
+		{
+			name:     "invalid range",
+			args:     []string{"--ranges-from", workdir.Join("invalid.txt")},
+			expected: fmt.Sprintf(`invalid line 2 of ranges file %q: invalid range "9-0": end must not be less than start`, workdir.Join("invalid.txt")),
+		},
+		{
+			name:     "empty file",
+			args:     []string{"--ranges-from", workdir.Join("empty.txt")},
+			expected: fmt.Sprintf(`ranges file %q is empty`, workdir.Join("empty.txt")),
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := s5cmd(append([]string{"cat"}, tc.args...)...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: contains(tc.expected),
+			})
+		})
+	}
+}
@@ -0,0 +1,339 @@
+package e2e
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/icmd"
+)
+
+const unverifiableError = "object can not be verified: it has neither an additional checksum nor an MD5 ETag"
+
+// The objects returned by the gateway of newChecksumGateway.
+const (
+	// objectVerifiable is returned as is, its ETag is the MD5 digest of its
+	// content.
+	objectVerifiable = "verifiable"
+	// objectUnverifiable is returned with the ETag of a multipart upload.
+	objectUnverifiable = "unverifiable"
+	// objectCorrupted is returned with a content which doesn't match its
+	// ETag.
+	objectCorrupted = "corrupted"
+)
+
+// newChecksumGateway returns a gateway to the storage of the given client
+// which modifies the downloads of the objects by the given kind.
+func newChecksumGateway(t *testing.T, s3client *s3.S3, kind string) *httptest.Server {
+	t.Helper()
+
+	target, err := url.Parse(aws.StringValue(s3client.Config.Endpoint))
+	assert.NilError(t, err)
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		req := resp.Request
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			return nil
+		}
+		etag := resp.Header.Get("ETag")
+		if etag == "" {
+			return nil
+		}
+
+		switch kind {
+		case objectUnverifiable:
+			resp.Header.Set("ETag", strings.TrimSuffix(etag, `"`)+`-2"`)
+		case objectCorrupted:
+			if req.Method != http.MethodGet {
+				return nil
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			// the size of the content is not changed.
+			body = bytes.ToUpper(body)
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		return nil
+	}
+
+	gateway := httptest.NewServer(proxy)
+	t.Cleanup(gateway.Close)
+	return gateway
+}
+
+// --checksum-validation {strict,warn,off} cp s3://bucket/object .
+func TestChecksumValidationCopyS3ObjectToLocal(t *testing.T) {
+	t.Parallel()
+
+	const (
+		filename = "file.txt"
+		content  = "this is a file content"
+	)
+
+	testcases := []struct {
+		name            string
+		policy          string
+		object          string
+		expectedError   string
+		expectedWarning string
+		expectedContent string
+	}{
+		{
+			name:            "strict verifiable",
+			policy:          "strict",
+			object:          objectVerifiable,
+			expectedContent: content,
+		},
+		{
+			name:          "strict unverifiable",
+			policy:        "strict",
+			object:        objectUnverifiable,
+			expectedError: unverifiableError,
+		},
+		{
+			name:          "strict corrupted",
+			policy:        "strict",
+			object:        objectCorrupted,
+			expectedError: "MD5 checksum mismatch",
+		},
+		{
+			name:            "warn unverifiable",
+			policy:          "warn",
+			object:          objectUnverifiable,
+			expectedWarning: unverifiableError,
+			expectedContent: content,
+		},
+		{
+			name:            "warn corrupted",
+			policy:          "warn",
+			object:          objectCorrupted,
+			expectedWarning: "MD5 checksum mismatch",
+			expectedContent: strings.ToUpper(content),
+		},
+		{
+			name:            "off corrupted",
+			policy:          "off",
+			object:          objectCorrupted,
+			expectedContent: strings.ToUpper(content),
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s3client, s5cmd := setup(t)
+
+			bucket := s3BucketFromTestName(t)
+			createBucket(t, s3client, bucket)
+			putFile(t, s3client, bucket, filename, content)
+
+			gateway := newChecksumGateway(t, s3client, tc.object)
+			workdir := fs.NewDir(t, t.Name())
+
+			src := fmt.Sprintf("s3://%v/%v", bucket, filename)
+			cmd := s5cmd("--endpoint-url", gateway.URL, "--checksum-validation", tc.policy, "cp", src, ".")
+			result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+			if tc.expectedError != "" {
+				result.Assert(t, icmd.Expected{ExitCode: 1})
+
+				assertLines(t, result.Stderr(), map[int]compareFunc{
+					0: match(fmt.Sprintf(`^ERROR "cp %v %v": .*%v`, src, filename, tc.expectedError)),
+				})
+
+				// the downloaded data is discarded.
+				assert.Assert(t, fs.Equal(workdir.Path(), fs.Expected(t)))
+				return
+			}
+
+			result.Assert(t, icmd.Success)
+
+			assertLines(t, result.Stdout(), map[int]compareFunc{
+				0: equals(`cp %v %v`, src, filename),
+			})
+
+			if tc.expectedWarning != "" {
+				assertLines(t, result.Stderr(), map[int]compareFunc{
+					0: match(fmt.Sprintf(`^WARNING %v: .*%v`, src, tc.expectedWarning)),
+				})
+			} else {
+				assertLines(t, result.Stderr(), map[int]compareFunc{})
+			}
+
+			expected := fs.Expected(t, fs.WithFile(filename, tc.expectedContent, fs.WithMode(0644)))
+			assert.Assert(t, fs.Equal(workdir.Path(), expected))
+		})
+	}
+}
+
+// --checksum-validation {strict,warn} cat s3://bucket/object
+func TestChecksumValidationCat(t *testing.T) {
+	t.Parallel()
+
+	const (
+		filename = "file.txt"
+		content  = "this is a file content"
+	)
+
+	testcases := []struct {
+		name            string
+		policy          string
+		object          string
+		expectedError   string
+		expectedWarning string
+		expectedContent string
+	}{
+		{
+			name:            "strict verifiable",
+			policy:          "strict",
+			object:          objectVerifiable,
+			expectedContent: content,
+		},
+		{
+			name:   "strict unverifiable",
+			policy: "strict",
+			object: objectUnverifiable,
+			// the content is verified after it's printed.
+			expectedContent: content,
+			expectedError:   unverifiableError,
+		},
+		{
+			name:            "strict corrupted",
+			policy:          "strict",
+			object:          objectCorrupted,
+			expectedContent: strings.ToUpper(content),
+			expectedError:   "MD5 checksum mismatch",
+		},
+		{
+			name:            "warn corrupted",
+			policy:          "warn",
+			object:          objectCorrupted,
+			expectedContent: strings.ToUpper(content),
+			expectedWarning: "MD5 checksum mismatch",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s3client, s5cmd := setup(t)
+
+			bucket := s3BucketFromTestName(t)
+			createBucket(t, s3client, bucket)
+			putFile(t, s3client, bucket, filename, content)
+
+			gateway := newChecksumGateway(t, s3client, tc.object)
+
+			src := fmt.Sprintf("s3://%v/%v", bucket, filename)
+			cmd := s5cmd("--endpoint-url", gateway.URL, "--checksum-validation", tc.policy, "cat", src)
+			result := icmd.RunCmd(cmd)
+
+			assert.Equal(t, result.Stdout(), tc.expectedContent)
+
+			switch {
+			case tc.expectedError != "":
+				result.Assert(t, icmd.Expected{ExitCode: 1})
+
+				assertLines(t, result.Stderr(), map[int]compareFunc{
+					0: match(fmt.Sprintf(`^ERROR "cat %v": %v: .*%v`, src, src, tc.expectedError)),
+				})
+			case tc.expectedWarning != "":
+				result.Assert(t, icmd.Success)
+
+				assertLines(t, result.Stderr(), map[int]compareFunc{
+					0: match(fmt.Sprintf(`^WARNING %v: .*%v`, src, tc.expectedWarning)),
+				})
+			default:
+				result.Assert(t, icmd.Success)
+			}
+		})
+	}
+}
+
+// --checksum-validation strict sync s3://bucket/* dir/
+func TestChecksumValidationSyncS3BucketToLocal(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "file.txt", "this is a file content")
+
+	gateway := newChecksumGateway(t, s3client, objectUnverifiable)
+	workdir := fs.NewDir(t, t.Name())
+
+	src := fmt.Sprintf("s3://%v/*", bucket)
+	cmd := s5cmd("--endpoint-url", gateway.URL, "--checksum-validation", "strict", "sync", src, ".")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(unverifiableError),
+	})
+
+	assert.Assert(t, fs.Equal(workdir.Path(), fs.Expected(t)))
+}
+
+func TestChecksumValidationValidation(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "checksum-mode with off",
+			args:     []string{"--checksum-validation", "off", "cp", "--checksum-mode", "enabled", "s3://bucket/file.txt", "."},
+			expected: `"checksum-mode" flag cannot be used with "checksum-validation" flag off`,
+		},
+		{
+			name:     "continue with strict",
+			args:     []string{"--checksum-validation", "strict", "cp", "--continue", "s3://bucket/file.txt", "."},
+			expected: `"continue" flag cannot be used with "checksum-validation" flag strict`,
+		},
+		{
+			name:     "ranges-from with strict",
+			args:     []string{"--checksum-validation", "strict", "cat", "--ranges-from", "ranges.txt"},
+			expected: `"ranges-from" flag cannot be used with "checksum-validation" flag strict`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: contains(tc.expected),
+			})
+		})
+	}
+}
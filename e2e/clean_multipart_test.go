@@ -0,0 +1,190 @@
+package e2e
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/icmd"
+)
+
+// createMultipartUpload initiates a multipart upload for the given key and
+// uploads the given parts.
+func createMultipartUpload(t *testing.T, s3client *s3.S3, bucket, key string, parts ...string) string {
+	t.Helper()
+
+	upload, err := s3client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	assert.NilError(t, err)
+
+	for i, part := range parts {
+		_, err := s3client.UploadPart(&s3.UploadPartInput{
+			Bucket:     aws.String(bucket),
+			Key:        aws.String(key),
+			UploadId:   upload.UploadId,
+			PartNumber: aws.Int64(int64(i + 1)),
+			Body:       strings.NewReader(part),
+		})
+		assert.NilError(t, err)
+	}
+
+	return aws.StringValue(upload.UploadId)
+}
+
+func listMultipartUploadKeys(t *testing.T, s3client *s3.S3, bucket string) []string {
+	t.Helper()
+
+	out, err := s3client.ListMultipartUploads(&s3.ListMultipartUploadsInput{
+		Bucket: aws.String(bucket),
+	})
+	assert.NilError(t, err)
+
+	var keys []string
+	for _, upload := range out.Uploads {
+		keys = append(keys, aws.StringValue(upload.Key))
+	}
+	return keys
+}
+
+func TestCleanMultipart(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	firstID := createMultipartUpload(t, s3client, bucket, "dir/a.txt", "content123")
+	secondID := createMultipartUpload(t, s3client, bucket, "dir/b.txt", "other", "content")
+
+	src := fmt.Sprintf("s3://%v/", bucket)
+	cmd := s5cmd("clean-multipart", "--older-than", "0s", src)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`22 bytes in 2 multipart uploads: s3://%v`, bucket),
+		1: match(fmt.Sprintf(`^clean-multipart .* 10 %vdir/a.txt %v$`, src, firstID)),
+		2: match(fmt.Sprintf(`^clean-multipart .* 12 %vdir/b.txt %v$`, src, secondID)),
+	}, sortInput(true))
+
+	assert.Equal(t, len(listMultipartUploadKeys(t, s3client, bucket)), 0)
+}
+
+func TestCleanMultipartJSON(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	uploadID := createMultipartUpload(t, s3client, bucket, "a.txt", "content")
+
+	src := fmt.Sprintf("s3://%v/", bucket)
+	cmd := s5cmd("--json", "clean-multipart", "--older-than", "0s", src)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	stdout := result.Stdout()
+	assert.Assert(t, strings.Contains(stdout, `"operation":"clean-multipart"`))
+	assert.Assert(t, strings.Contains(stdout, fmt.Sprintf(`"upload_id":%q`, uploadID)))
+	assert.Assert(t, strings.Contains(stdout, fmt.Sprintf(`{"source":"s3://%v","count":1,"size":7}`, bucket)))
+
+	assert.Equal(t, len(listMultipartUploadKeys(t, s3client, bucket)), 0)
+}
+
+func TestCleanMultipartDryRun(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	uploadID := createMultipartUpload(t, s3client, bucket, "a.txt", "content")
+
+	src := fmt.Sprintf("s3://%v/", bucket)
+	cmd := s5cmd("--dry-run", "clean-multipart", "--older-than", "0s", src)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`7 bytes in 1 multipart uploads: s3://%v`, bucket),
+		1: match(fmt.Sprintf(`^clean-multipart .* 7 %va.txt %v$`, src, uploadID)),
+	}, sortInput(true))
+
+	assert.DeepEqual(t, listMultipartUploadKeys(t, s3client, bucket), []string{"a.txt"})
+}
+
+func TestCleanMultipartSkipsRecentUploads(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	createMultipartUpload(t, s3client, bucket, "a.txt", "content")
+
+	src := fmt.Sprintf("s3://%v/", bucket)
+	cmd := s5cmd("clean-multipart", src)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`0 bytes in 0 multipart uploads: s3://%v`, bucket),
+	})
+
+	assert.DeepEqual(t, listMultipartUploadKeys(t, s3client, bucket), []string{"a.txt"})
+}
+
+func TestCleanMultipartWildcard(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	uploadID := createMultipartUpload(t, s3client, bucket, "dir/a.tar", "content")
+	createMultipartUpload(t, s3client, bucket, "dir/b.txt", "content")
+
+	src := fmt.Sprintf("s3://%v/dir/*.tar", bucket)
+	cmd := s5cmd("clean-multipart", "--older-than", "0s", src)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`7 bytes in 1 multipart uploads: %v`, src),
+		1: match(fmt.Sprintf(`^clean-multipart .* 7 s3://%v/dir/a.tar %v$`, bucket, uploadID)),
+	}, sortInput(true))
+
+	assert.DeepEqual(t, listMultipartUploadKeys(t, s3client, bucket), []string{"dir/b.txt"})
+}
+
+func TestCleanMultipartLocalSource(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd := setup(t)
+
+	cmd := s5cmd("clean-multipart", "dir/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "clean-multipart dir/": source must be remote`),
+	})
+}
@@ -318,3 +318,528 @@ func TestDiskUsageEmptyBucket(t *testing.T) {
 		0: suffix(`0 bytes in 0 objects: s3://%v`, bucket),
 	})
 }
+
+func TestDiskUsageWithSourceInventory(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	manifest := putInventory(t, s3client, bucket, bucket, map[string]int64{
+		"a/file1.txt":        10,
+		"a/file2.txt":        20,
+		"a/nested/file3.txt": 30,
+		"b/file4.txt":        40,
+		"a/file with spaces": 50,
+	})
+
+	cmd := s5cmd("du", "--source-inventory", "s3://"+bucket+"/"+manifest, "s3://"+bucket+"/a/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix(`80 bytes in 3 objects: s3://%v/a/`, bucket),
+	})
+
+	cmd = s5cmd("du", "--source-inventory", "s3://"+bucket+"/"+manifest, "s3://"+bucket+"/*.txt")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix(`100 bytes in 4 objects: s3://%v/*.txt`, bucket),
+	})
+}
+
+// du --all-versions --source-inventory s3://bucket/manifest.json s3://bucket/*
+func TestDiskUsageAllVersionsWithSourceInventory(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd := setup(t)
+
+	cmd := s5cmd("du", "--all-versions", "--source-inventory", "s3://bucket/manifest.json", "s3://bucket/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "du --all-versions=true --source-inventory=s3://bucket/manifest.json s3://bucket/*": source-inventory flag can not be used with all-versions flag`),
+	})
+}
+
+func TestDiskUsageWithSourceInventoryOfAnotherBucket(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	manifest := putInventory(t, s3client, bucket, "another-bucket", map[string]int64{
+		"file1.txt": 10,
+	})
+
+	cmd := s5cmd("du", "--source-inventory", "s3://"+bucket+"/"+manifest, "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`inventory of bucket "another-bucket" can not be used for "s3://%v/*"`, bucket),
+	})
+}
+
+func TestDiskUsageWithSample(t *testing.T) {
+	t.Parallel()
+
+	// bolt backend does not paginate the listings.
+	s3client, s5cmd := setup(t, withS3Backend("mem"))
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	// a page has 1000 objects.
+	for i := 0; i < 1200; i++ {
+		putFile(t, s3client, bucket, fmt.Sprintf("file%04d.txt", i), "content")
+	}
+
+	cmd := s5cmd("du", "--sample", "1", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: match(fmt.Sprintf(`^~\d+ bytes in ~\d+ objects: s3://%v/\* \(estimated from 1000 sampled objects assuming evenly distributed keys, actual usage may differ significantly\)$`, bucket)),
+	})
+
+	// the whole bucket fits in the sampled pages, so the result is exact.
+	cmd = s5cmd("--json", "du", "--sample", "2", "s3://"+bucket+"/*")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: json(`
+			{
+				"source": "s3://%v/*",
+				"count":1200,
+				"size":8400
+			}
+		`, bucket),
+	})
+}
+
+func TestDiskUsageWithSampleInvalidFlags(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "not positive",
+			args:     []string{"du", "--sample", "0", "s3://bucket/*"},
+			expected: "sample flag must be a positive number of pages",
+		},
+		{
+			name:     "local source",
+			args:     []string{"du", "--sample", "1", "dir/"},
+			expected: "sample flag can only be used with remote sources",
+		},
+		{
+			name:     "all versions",
+			args:     []string{"du", "--sample", "1", "--all-versions", "s3://bucket/*"},
+			expected: "sample flag can not be used with version flags",
+		},
+		{
+			name:     "source inventory",
+			args:     []string{"du", "--sample", "1", "--source-inventory", "s3://bucket/manifest.json", "s3://bucket/*"},
+			expected: "sample flag can not be used with source-inventory flag",
+		},
+		{
+			name:     "list objects v1",
+			args:     []string{"--use-list-objects-v1", "du", "--sample", "1", "s3://bucket/*"},
+			expected: "sample flag can not be used with use-list-objects-v1 flag",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			result := icmd.RunCmd(s5cmd(tc.args...))
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: contains(tc.expected),
+			})
+		})
+	}
+}
+
+func TestDiskUsageWithDelimiter(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "logs|2023|a.txt", "content")
+	putFile(t, s3client, bucket, "logs|2024|a.txt", "content")
+	putFile(t, s3client, bucket, "logs|2024|b.txt", "content")
+	putFile(t, s3client, bucket, "logs|c.txt", "content")
+	putFile(t, s3client, bucket, "readme.txt", "content")
+
+	cmd := s5cmd("du", "--delimiter", "|", "s3://"+bucket+"/logs|")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`7 bytes in 1 objects: s3://%v/logs|`, bucket),
+		1: equals(`7 bytes in 1 objects: s3://%v/logs|2023|`, bucket),
+		2: equals(`14 bytes in 2 objects: s3://%v/logs|2024|`, bucket),
+	})
+}
+
+func TestDiskUsageWithDelimiterInvalidFlags(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "empty delimiter",
+			args:     []string{"du", "--delimiter", "", "s3://bucket/"},
+			expected: "delimiter flag must be non-empty",
+		},
+		{
+			name:     "local source",
+			args:     []string{"du", "--delimiter", "|", "dir/"},
+			expected: "delimiter flag can only be used with remote sources",
+		},
+		{
+			name:     "sample",
+			args:     []string{"du", "--delimiter", "|", "--sample", "1", "s3://bucket/*"},
+			expected: "delimiter flag can not be used with sample flag",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			result := icmd.RunCmd(s5cmd(tc.args...))
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: contains(tc.expected),
+			})
+		})
+	}
+}
+
+func TestDiskUsageWithTop(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "logs/a.txt", "1")
+	putFile(t, s3client, bucket, "logs/b.txt", "12345")
+	putFile(t, s3client, bucket, "logs/c.txt", "123")
+	putFile(t, s3client, bucket, "logs/d.txt", "1234567")
+	putFile(t, s3client, bucket, "readme.txt", "123456789")
+
+	cmd := s5cmd("du", "--top", "2", "s3://"+bucket+"/logs/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`7 bytes: s3://%v/logs/d.txt`, bucket),
+		1: equals(`5 bytes: s3://%v/logs/b.txt`, bucket),
+		2: equals(`16 bytes in 4 objects: s3://%v/logs/*`, bucket),
+	})
+
+	cmd = s5cmd("--json", "du", "--top", "2", "s3://"+bucket+"/logs/*")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: json(`
+			{
+				"source": "s3://%v/logs/*",
+				"count": 4,
+				"size": 16,
+				"top": [
+					{"key": "s3://%v/logs/d.txt", "size": 7},
+					{"key": "s3://%v/logs/b.txt", "size": 5}
+				]
+			}
+		`, bucket, bucket, bucket),
+	})
+}
+
+// du --top 2 s3://bucket/nonexistent/*
+func TestDiskUsageWithTopListingError(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "file.txt", "content")
+
+	cmd := s5cmd("du", "--top", "2", "s3://"+bucket+"/nonexistent/*")
+	result := icmd.RunCmd(cmd)
+
+	// the listing errors fail the command.
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "du --top=2 s3://%v/nonexistent/*": no object found`, bucket),
+	})
+}
+
+func TestDiskUsageWithTopInvalidFlags(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "non-positive top",
+			args:     []string{"du", "--top", "0", "s3://bucket/*"},
+			expected: "top flag must be a positive number of objects",
+		},
+		{
+			name:     "group",
+			args:     []string{"du", "--top", "10", "--group", "s3://bucket/*"},
+			expected: "top flag can not be used with group flag",
+		},
+		{
+			name:     "delimiter",
+			args:     []string{"du", "--top", "10", "--delimiter", "/", "s3://bucket/"},
+			expected: "top flag can not be used with delimiter flag",
+		},
+		{
+			name:     "sample",
+			args:     []string{"du", "--top", "10", "--sample", "1", "s3://bucket/*"},
+			expected: "top flag can not be used with sample flag",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			result := icmd.RunCmd(s5cmd(tc.args...))
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: contains(tc.expected),
+			})
+		})
+	}
+}
+
+func TestDiskUsageByStorageClass(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "a.txt", "foo")
+	putFile(t, s3client, bucket, "b.txt", "hello")
+	putFile(t, s3client, bucket, "logs/c.log", "this is a log line")
+
+	// the test server doesn't list the storage classes of the objects.
+	storageClass, name := "", "-"
+	if isEndpointFromEnv() {
+		storageClass, name = "STANDARD", "STANDARD"
+	}
+
+	cmd := s5cmd("du", "--by-storage-class", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals("s3://%v/*", bucket),
+		1: equals("STORAGE CLASS OBJECTS SIZE"),
+		2: equals("%v 3 26", name),
+		3: equals("TOTAL 3 26"),
+	})
+
+	cmd = s5cmd("--json", "du", "--by-storage-class", "s3://"+bucket+"/*")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: json(`
+			{
+				"source": "s3://%v/*",
+				"storage_classes": [
+					{"storage_class": "%v", "count": 3, "size": 26}
+				],
+				"count": 3,
+				"size": 26
+			}
+		`, bucket, storageClass),
+	})
+}
+
+func TestDiskUsageByStorageClassInvalidFlags(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "group",
+			args:     []string{"du", "--by-storage-class", "--group", "s3://bucket/*"},
+			expected: "by-storage-class flag can not be used with group flag",
+		},
+		{
+			name:     "sample",
+			args:     []string{"du", "--by-storage-class", "--sample", "1", "s3://bucket/*"},
+			expected: "by-storage-class flag can not be used with sample flag",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			result := icmd.RunCmd(s5cmd(tc.args...))
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: contains(tc.expected),
+			})
+		})
+	}
+}
+
+// du s3://bucket/a/* s3://bucket/b/* s3://bucket/c/*
+func TestDiskUsageMultipleSources(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "a/file1.txt", "foo")
+	putFile(t, s3client, bucket, "a/file2.txt", "hello")
+	putFile(t, s3client, bucket, "b/file3.txt", "this is a file content")
+	putFile(t, s3client, bucket, "b/file4.log", "not counted")
+	// the objects which are not under any of the sources are not counted.
+	putFile(t, s3client, bucket, "d/file5.txt", "not counted")
+
+	srcs := []string{
+		fmt.Sprintf("s3://%v/a/*", bucket),
+		fmt.Sprintf("s3://%v/b/*", bucket),
+		fmt.Sprintf("s3://%v/c/*", bucket),
+	}
+
+	cmd := s5cmd(append([]string{"du", "--exclude", "*.log"}, srcs...)...)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	// the sources are printed in the given order.
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`8 bytes in 2 objects: %v`, srcs[0]),
+		1: equals(`22 bytes in 1 objects: %v`, srcs[1]),
+		2: equals(`0 bytes in 0 objects: %v`, srcs[2]),
+		3: equals(`30 bytes in 3 objects: total`),
+	})
+
+	cmd = s5cmd(append([]string{"--json", "du", "--exclude", "*.log"}, srcs...)...)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: json(`
+			{
+				"sources": [
+					{"source": "%v", "count": 2, "size": 8},
+					{"source": "%v", "count": 1, "size": 22},
+					{"source": "%v", "count": 0, "size": 0}
+				],
+				"count": 3,
+				"size": 30
+			}
+		`, srcs[0], srcs[1], srcs[2]),
+	})
+}
+
+func TestDiskUsageMultipleSourcesInvalidFlags(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "top",
+			args:     []string{"du", "--top", "10", "s3://bucket/a/*", "s3://bucket/b/*"},
+			expected: "top flag can not be used with multiple sources",
+		},
+		{
+			name:     "group",
+			args:     []string{"du", "--group", "s3://bucket/a/*", "s3://bucket/b/*"},
+			expected: "group flag can not be used with multiple sources",
+		},
+		{
+			name:     "delimiter",
+			args:     []string{"du", "--delimiter", "/", "s3://bucket/a/", "s3://bucket/b/"},
+			expected: "delimiter flag can not be used with multiple sources",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			result := icmd.RunCmd(s5cmd(tc.args...))
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: contains(tc.expected),
+			})
+		})
+	}
+}
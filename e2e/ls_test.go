@@ -2,10 +2,13 @@ package e2e
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"gotest.tools/v3/assert"
 	"gotest.tools/v3/fs"
 	"gotest.tools/v3/icmd"
 )
@@ -120,6 +123,38 @@ func TestListSingleS3ObjectJSON(t *testing.T) {
 	}, jsonCheck(true))
 }
 
+// -json ls --fetch-owner bucket/*
+func TestListS3ObjectsJSONWithFetchOwner(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "testfile1.txt", "this is a file content")
+
+	cmd := s5cmd("--json", "ls", "--fetch-owner", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	expected := fmt.Sprintf(`^{"key":"s3://%v/testfile1.txt","etag":"[a-f0-9]+","last_modified":"[0-9-]+T[0-9:.]+Z","type":"file","size":22,"schema_version":1}$`, bucket)
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: match(expected),
+	}, jsonCheck(true))
+
+	// human readable output is not changed.
+	cmd = s5cmd("ls", "--fetch-owner", "s3://"+bucket+"/*")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("22 testfile1.txt"),
+	})
+}
+
 // ls bucket/*.ext
 func TestListSingleWildcardS3Object(t *testing.T) {
 	t.Parallel()
@@ -443,6 +478,45 @@ func TestListS3ObjectsWithDashH(t *testing.T) {
 	}, trimMatch(dateRe), alignment(true))
 }
 
+// ls --ignore-dir-markers bucket/*
+func TestListS3ObjectsWithIgnoreDirMarkers(t *testing.T) {
+	t.Parallel()
+
+	// the fake backend doesn't accept zero-byte objects.
+	if !isEndpointFromEnv() {
+		t.Skip("zero-byte objects can only be uploaded to a real endpoint")
+	}
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "dir/", "")
+	putFile(t, s3client, bucket, "dir/file.txt", "content")
+
+	src := fmt.Sprintf("s3://%v/*", bucket)
+
+	cmd := s5cmd("ls", src)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("DIR dir/"),
+		1: suffix("7 dir/file.txt"),
+	})
+
+	cmd = s5cmd("ls", "--ignore-dir-markers", src)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("7 dir/file.txt"),
+	})
+}
+
 // ls --exclude "*.txt" s3://bucket/*
 func TestListS3ObjectsWithExcludeFilter(t *testing.T) {
 	t.Parallel()
@@ -802,3 +876,463 @@ func TestEmptyBucket(t *testing.T) {
 
 	assertLines(t, result.Stdout(), nil)
 }
+
+func TestListS3ObjectsWithOutputFile(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "testfile1.txt", "this is a file content")
+	putFile(t, s3client, bucket, "testfile2.txt", "this is also a file content")
+
+	workdir := fs.NewDir(t, t.Name(), fs.WithFile("output.txt", "previous output\n"))
+	defer workdir.Remove()
+
+	cmd := s5cmd("--output-file", "output.txt", "ls", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), nil)
+
+	// the file is truncated by default.
+	output, err := os.ReadFile(workdir.Join("output.txt"))
+	assert.NilError(t, err)
+
+	assertLines(t, string(output), map[int]compareFunc{
+		0: suffix("22 testfile1.txt"),
+		1: suffix("27 testfile2.txt"),
+	})
+
+	cmd = s5cmd("--output-file", "output.txt", "--append", "ls", "s3://"+bucket+"/testfile1.txt")
+	result = icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	output, err = os.ReadFile(workdir.Join("output.txt"))
+	assert.NilError(t, err)
+
+	assertLines(t, string(output), map[int]compareFunc{
+		0: suffix("22 testfile1.txt"),
+		1: suffix("27 testfile2.txt"),
+		2: suffix("22 testfile1.txt"),
+	})
+}
+
+func TestListS3ObjectsWithOutputFileErrorOnStderr(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, t.Name())
+	defer workdir.Remove()
+
+	cmd := s5cmd("--output-file", "output.txt", "ls", "s3://"+bucket+"/nosuchobject")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "ls s3://%v/nosuchobject": no object found`, bucket),
+	})
+
+	output, err := os.ReadFile(workdir.Join("output.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(output), "")
+}
+
+func TestListS3ObjectsWithDelimiter(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "logs|2023|a.txt", "content")
+	putFile(t, s3client, bucket, "logs|2024|a.txt", "content")
+	putFile(t, s3client, bucket, "logs|c.txt", "content")
+	putFile(t, s3client, bucket, "readme.txt", "content")
+
+	cmd := s5cmd("ls", "--delimiter", "|", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("DIR logs|"),
+		1: suffix("7 readme.txt"),
+	}, alignment(true))
+
+	cmd = s5cmd("ls", "--delimiter", "|", "s3://"+bucket+"/logs|")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("DIR 2023|"),
+		1: suffix("DIR 2024|"),
+		2: suffix("7 c.txt"),
+	}, alignment(true))
+}
+
+func TestListS3ObjectsWithDelimiterInvalidFlags(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "empty delimiter",
+			args:     []string{"ls", "--delimiter", "", "s3://bucket/"},
+			expected: "delimiter flag must be non-empty",
+		},
+		{
+			name:     "local source",
+			args:     []string{"ls", "--delimiter", "|", "dir/"},
+			expected: "delimiter flag can only be used with remote objects",
+		},
+		{
+			name:     "wildcard",
+			args:     []string{"ls", "--delimiter", "|", "s3://bucket/*"},
+			expected: "delimiter flag can not be used with wildcards",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			result := icmd.RunCmd(s5cmd(tc.args...))
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: contains(tc.expected),
+			})
+		})
+	}
+}
+
+// ls --newer-than 1d s3://bucket/*
+// ls --older-than 1d s3://bucket/*
+func TestListS3ObjectsWithTimeFilters(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().UTC()
+	timeSource := newFixedTimeSource(now.Add(-10 * 24 * time.Hour))
+	s3client, s5cmd := setup(t, withTimeSource(timeSource))
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "old.txt", "old")
+	putFile(t, s3client, bucket, "logs/old.log", "old")
+
+	timeSource.Advance(10*24*time.Hour - time.Hour)
+	putFile(t, s3client, bucket, "new.txt", "new")
+	putFile(t, s3client, bucket, "logs/new.log", "new")
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected map[int]compareFunc
+	}{
+		{
+			name: "newer than duration",
+			args: []string{"--newer-than", "1d", fmt.Sprintf("s3://%v/*", bucket)},
+			expected: map[int]compareFunc{
+				0: suffix("3 logs/new.log"),
+				1: suffix("3 new.txt"),
+			},
+		},
+		{
+			name: "older than duration",
+			args: []string{"--older-than", "24h", fmt.Sprintf("s3://%v/*", bucket)},
+			expected: map[int]compareFunc{
+				0: suffix("3 logs/old.log"),
+				1: suffix("3 old.txt"),
+			},
+		},
+		{
+			name: "newer than timestamp with wildcard",
+			args: []string{
+				"--newer-than", now.Add(-2 * time.Hour).Format(time.RFC3339),
+				fmt.Sprintf("s3://%v/logs/*.log", bucket),
+			},
+			expected: map[int]compareFunc{
+				0: suffix("3 new.log"),
+			},
+		},
+		{
+			name: "newer than and older than",
+			args: []string{"--newer-than", "30d", "--older-than", "2d", fmt.Sprintf("s3://%v/*", bucket)},
+			expected: map[int]compareFunc{
+				0: suffix("3 logs/old.log"),
+				1: suffix("3 old.txt"),
+			},
+		},
+		{
+			name: "prefix",
+			args: []string{"--newer-than", "1d", fmt.Sprintf("s3://%v/", bucket)},
+			expected: map[int]compareFunc{
+				0: suffix("DIR logs/"),
+				1: suffix("3 new.txt"),
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := s5cmd(append([]string{"ls"}, tc.args...)...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Success)
+
+			assertLines(t, result.Stdout(), tc.expected)
+		})
+	}
+}
+
+func TestListWithInvalidTimeFilters(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "invalid value",
+			args:     []string{"--newer-than", "yesterday", "s3://bucket/*"},
+			expected: `ERROR "ls --newer-than=yesterday s3://bucket/*": invalid value for "newer-than" flag "yesterday": expected a duration, e.g. 24h or 7d, or RFC3339 format, e.g. 2024-10-01T20:30:00Z`,
+		},
+		{
+			name:     "negative duration",
+			args:     []string{"--older-than", "-1h", "s3://bucket/*"},
+			expected: `ERROR "ls --older-than=-1h s3://bucket/*": invalid value for "older-than" flag "-1h": expected a duration, e.g. 24h or 7d, or RFC3339 format, e.g. 2024-10-01T20:30:00Z`,
+		},
+		{
+			name:     "empty range",
+			args:     []string{"--newer-than", "1d", "--older-than", "2d", "s3://bucket/*"},
+			expected: `ERROR "ls --newer-than=1d --older-than=2d s3://bucket/*": "newer-than" and "older-than" flags don't match any time`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			cmd := s5cmd(append([]string{"ls"}, tc.args...)...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
+
+func TestListS3ObjectsWithSummarize(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "a.txt", "foo")
+	putFile(t, s3client, bucket, "b.txt", "hello")
+	putFile(t, s3client, bucket, "logs/c.log", "this is a log line")
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected map[int]compareFunc
+	}{
+		{
+			name: "wildcard",
+			args: []string{"ls", "--summarize", fmt.Sprintf("s3://%v/*", bucket)},
+			expected: map[int]compareFunc{
+				0: suffix("3 a.txt"),
+				1: suffix("5 b.txt"),
+				2: suffix("18 logs/c.log"),
+				3: equals("Total Objects: 3"),
+				4: suffix("Total Size: 26"),
+			},
+		},
+		{
+			name: "prefixes are not counted",
+			args: []string{"ls", "--summarize", fmt.Sprintf("s3://%v/", bucket)},
+			expected: map[int]compareFunc{
+				0: suffix("DIR logs/"),
+				1: suffix("3 a.txt"),
+				2: suffix("5 b.txt"),
+				3: equals("Total Objects: 2"),
+				4: suffix("Total Size: 8"),
+			},
+		},
+		{
+			name: "no matching objects",
+			args: []string{"ls", "--summarize", "--exclude", "*", fmt.Sprintf("s3://%v/*", bucket)},
+			expected: map[int]compareFunc{
+				0: equals("Total Objects: 0"),
+				1: suffix("Total Size: 0"),
+			},
+		},
+		{
+			name: "json",
+			args: []string{"--json", "ls", "--summarize", fmt.Sprintf("s3://%v/logs/*", bucket)},
+			expected: map[int]compareFunc{
+				0: prefix(`{"key":"s3://%v/logs/c.log",`, bucket),
+				1: equals(`{"source":"s3://%v/logs/*","count":1,"size":18,"summary":true,"schema_version":1}`, bucket),
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Success)
+
+			assertLines(t, result.Stdout(), tc.expected)
+		})
+	}
+}
+
+func TestListS3ObjectsWithMatch(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "data/a.parquet", "foo")
+	putFile(t, s3client, bucket, "data/a.csv", "hello")
+	putFile(t, s3client, bucket, "data/2024/b.parquet", "parquet")
+	putFile(t, s3client, bucket, "data/2024/b.parquet.tmp", "tmp")
+	putFile(t, s3client, bucket, "data/parquet/c.json", "{}")
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected map[int]compareFunc
+	}{
+		{
+			name: "prefix",
+			args: []string{"ls", "--match", "*.parquet", fmt.Sprintf("s3://%v/data/", bucket)},
+			expected: map[int]compareFunc{
+				0: suffix("3 a.parquet"),
+			},
+		},
+		{
+			name: "wildcard",
+			args: []string{"ls", "--match", "*.parquet", fmt.Sprintf("s3://%v/data/*", bucket)},
+			expected: map[int]compareFunc{
+				0: suffix("7 2024/b.parquet"),
+				1: suffix("3 a.parquet"),
+			},
+		},
+		{
+			name: "multiple patterns",
+			args: []string{"ls", "--match", "*.parquet", "--match", "*.csv", fmt.Sprintf("s3://%v/data/*", bucket)},
+			expected: map[int]compareFunc{
+				0: suffix("7 2024/b.parquet"),
+				1: suffix("5 a.csv"),
+				2: suffix("3 a.parquet"),
+			},
+		},
+		{
+			name: "prefixes are matched by their names",
+			args: []string{"ls", "--match", "parquet", fmt.Sprintf("s3://%v/data/", bucket)},
+			expected: map[int]compareFunc{
+				0: suffix("DIR parquet/"),
+			},
+		},
+		{
+			name: "with exclude and summarize",
+			args: []string{"ls", "--match", "b.*", "--exclude", "*.tmp", "--summarize", fmt.Sprintf("s3://%v/*", bucket)},
+			expected: map[int]compareFunc{
+				0: suffix("7 data/2024/b.parquet"),
+				1: equals("Total Objects: 1"),
+				2: suffix("Total Size: 7"),
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Success)
+
+			assertLines(t, result.Stdout(), tc.expected)
+		})
+	}
+}
+
+func TestListBucketsWithSummarize(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd := setup(t)
+
+	cmd := s5cmd("ls", "--summarize")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "ls --summarize=true": "summarize" flag can only be used with objects`),
+	})
+}
+
+// ls --show-checksum-algorithm bucket/*
+func TestListS3ObjectsWithChecksumAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "dir/testfile1.txt", "this is a file content")
+	putFile(t, s3client, bucket, "testfile2.txt", "content")
+
+	cmd := s5cmd("--log", "debug", "ls", "--show-checksum-algorithm", "s3://"+bucket+"/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	// the mock service doesn't return the checksum algorithms.
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: match(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} - 7 testfile2.txt$`),
+		1: equals(`DEBUG no checksum algorithm is returned for the objects of s3://%v: the objects may be uploaded without an additional checksum, or the endpoint may not support it`, bucket),
+		2: equals(`DIR dir/`),
+	}, sortInput(true))
+}
@@ -97,6 +97,23 @@ func TestMakeBucket_failure(t *testing.T) {
 	})
 }
 
+func TestMakeBucket_failure_access_point(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd := setup(t)
+
+	src := "s3://arn:aws:s3:us-west-2:123456789012:accesspoint/my-access-point"
+	cmd := s5cmd("mb", src)
+
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "mb %v": access point ARNs cannot be used in place of bucket names for this command`, src),
+	})
+}
+
 func TestMakeBucket_failure_json(t *testing.T) {
 	t.Parallel()
 
@@ -2,10 +2,19 @@ package e2e
 
 import (
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/fs"
 	"gotest.tools/v3/icmd"
@@ -214,6 +223,195 @@ func TestMoveSingleS3ObjectToS3(t *testing.T) {
 	assert.Assert(t, ensureS3Object(s3client, bucket, "dst/"+filename, content))
 }
 
+// mv --verify-before-delete --verify-checksum file s3://bucket/
+func TestMoveLocalFileToS3WithVerifyBeforeDelete(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	const (
+		filename = "testfile1.txt"
+		content  = "this is a file content"
+	)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile(filename, content))
+	defer workdir.Remove()
+
+	dst := fmt.Sprintf("s3://%v/%v", bucket, filename)
+
+	cmd := s5cmd("mv", "--verify-before-delete", "--verify-checksum", filename, dst)
+	cmd.Dir = workdir.Path()
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`mv %v %v`, filename, dst),
+	})
+
+	// expect the source file to be deleted once the object is verified.
+	expected := fs.Expected(t)
+	assert.Assert(t, fs.Equal(workdir.Path(), expected))
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, filename, content))
+}
+
+// mv --verify-before-delete file s3://bucket/ through a gateway which loses the
+// uploads.
+func TestMoveLocalFileToS3WithVerifyBeforeDeleteLostUpload(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name           string
+		verify         bool
+		expectedDelete bool
+	}{
+		{
+			name:           "without verification",
+			expectedDelete: true,
+		},
+		{
+			name:   "with verification",
+			verify: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			s3client, _ := setup(t)
+
+			bucket := s3BucketFromTestName(t)
+			createBucket(t, s3client, bucket)
+
+			const (
+				filename = "lost.txt"
+				content  = "this is a file content"
+			)
+
+			// the gateway acknowledges the uploads of the file without
+			// forwarding them to the storage.
+			target, err := url.Parse(aws.StringValue(s3client.Config.Endpoint))
+			assert.NilError(t, err)
+			proxy := httputil.NewSingleHostReverseProxy(target)
+			gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/"+filename) {
+					_, _ = io.Copy(io.Discard, r.Body)
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				proxy.ServeHTTP(w, r)
+			}))
+			defer gateway.Close()
+
+			workdir := fs.NewDir(t, bucket, fs.WithFile(filename, content))
+			defer workdir.Remove()
+
+			dst := fmt.Sprintf("s3://%v/%v", bucket, filename)
+
+			args := []string{"mv"}
+			if tc.verify {
+				args = append(args, "--verify-before-delete")
+			}
+			cmd := s5cmd(workdir.Path(), gateway.URL)(append(args, filename, dst)...)
+			result := icmd.RunCmd(cmd)
+
+			if tc.expectedDelete {
+				// the file is lost since the upload is reported as succeeded.
+				result.Assert(t, icmd.Success)
+			} else {
+				result.Assert(t, icmd.Expected{ExitCode: 1})
+
+				assertLines(t, result.Stderr(), map[int]compareFunc{
+					0: equals(`ERROR "mv %v %v": %v is not found after it's written, the source is not deleted`, filename, dst, dst),
+				})
+			}
+
+			_, err = os.Stat(workdir.Join(filename))
+			assert.Equal(t, os.IsNotExist(err), tc.expectedDelete)
+
+			err = ensureS3Object(s3client, bucket, filename, content)
+			assertError(t, err, errS3NoSuchKey)
+		})
+	}
+}
+
+// mv --verify-before-delete --verify-checksum s3://bucket/object s3://bucket/dst/object
+func TestMoveSingleS3ObjectToS3WithVerifyBeforeDelete(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	const (
+		filename = "testfile1.txt"
+		content  = "this is a file content"
+	)
+
+	src := fmt.Sprintf("s3://%v/%v", bucket, filename)
+	dst := fmt.Sprintf("s3://%v/dst/%v", bucket, filename)
+
+	putFile(t, s3client, bucket, filename, content)
+
+	cmd := s5cmd("mv", "--verify-before-delete", "--verify-checksum", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`mv %v %v`, src, dst),
+	})
+
+	err := ensureS3Object(s3client, bucket, filename, content)
+	assertError(t, err, errS3NoSuchKey)
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "dst/"+filename, content))
+}
+
+func TestMoveWithVerifyBeforeDeleteValidation(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "verify checksum without verify before delete",
+			args:     []string{"mv", "--verify-checksum", "file.txt", "s3://bucket/"},
+			expected: `"verify-checksum" flag can only be used with "verify-before-delete" flag`,
+		},
+		{
+			name:     "local destination",
+			args:     []string{"mv", "--verify-before-delete", "s3://bucket/object", "dir/"},
+			expected: `"verify-before-delete" flag can only be used with remote destinations`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: contains(tc.expected),
+			})
+		})
+	}
+}
+
 // mv s3://bucket/object s3://bucket2/object
 func TestMoveSingleS3ObjectIntoAnotherBucket(t *testing.T) {
 	t.Parallel()
@@ -2,13 +2,16 @@ package e2e
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
+	"hash/crc32"
 	"net/http"
 	"runtime"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/fs"
 	"gotest.tools/v3/icmd"
@@ -374,6 +377,69 @@ func TestUploadStdinToS3JSON(t *testing.T) {
 	assert.Assert(t, ensureS3Object(s3client, bucket, filename, content))
 }
 
+// pipe --checksum-algorithm CRC32C s3://bucket/object
+func TestUploadStdinToS3WithChecksumAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	const (
+		filename = "testfile1.txt"
+		content  = "this is a test file"
+	)
+
+	reader := bytes.NewBufferString(content)
+
+	dstpath := fmt.Sprintf("s3://%v/%v", bucket, filename)
+	cmd := s5cmd("pipe", "--checksum-algorithm", "crc32c", dstpath)
+	result := icmd.RunCmd(cmd, icmd.WithStdin(reader))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`pipe %v`, dstpath),
+	})
+
+	// assert S3
+	assert.Assert(t, ensureS3Object(s3client, bucket, filename, content))
+
+	// the fake S3 server doesn't store the checksums.
+	if !isEndpointFromEnv() {
+		return
+	}
+
+	output, err := s3client.HeadObject(&s3.HeadObjectInput{
+		Bucket:       aws.String(bucket),
+		Key:          aws.String(filename),
+		ChecksumMode: aws.String(s3.ChecksumModeEnabled),
+	})
+	assert.NilError(t, err)
+
+	h := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	h.Write([]byte(content))
+	assert.Equal(t, aws.StringValue(output.ChecksumCRC32C), base64.StdEncoding.EncodeToString(h.Sum(nil)))
+}
+
+// pipe --checksum-algorithm MD5 s3://bucket/object
+func TestUploadStdinToS3WithInvalidChecksumAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd := setup(t)
+
+	cmd := s5cmd("pipe", "--checksum-algorithm", "MD5", "s3://bucket/object")
+	result := icmd.RunCmd(cmd, icmd.WithStdin(bytes.NewBufferString("content")))
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	// invalid flag values are reported with the usage to stdout.
+	assertLines(t, result.Combined(), map[int]compareFunc{
+		0: contains(`invalid value "MD5" for flag -checksum-algorithm: allowed values: [CRC32C, CRC32, SHA256, SHA1, ]`),
+	}, strictLineCheck(false))
+}
+
 // cp dir/file s3://bucket/ --metadata key1=val1 --metadata key2=val2 ...
 func TestPipeToS3WithArbitraryMetadata(t *testing.T) {
 	t.Parallel()
@@ -427,6 +493,26 @@ func TestPipeToS3WithArbitraryMetadata(t *testing.T) {
 	assert.Assert(t, ensureS3Object(s3client, bucket, filename, content, ensureArbitraryMetadata(metadata)))
 }
 
+// pipe --part-size 1 s3://bucket/object
+func TestPipeToS3WithTooSmallPartSize(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	reader := bytes.NewBufferString("content")
+	cmd := s5cmd("pipe", "--part-size", "1", "s3://"+bucket+"/object")
+	result := icmd.RunCmd(cmd, icmd.WithStdin(reader))
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`invalid part size 1 MiB: the parts of the multipart uploads must be at least 5 MiB, use --part-size 5 or larger`),
+	})
+}
+
 // pipe --storage-class=GLACIER s3://bucket/object
 func TestUploadStdinToS3WithStorageClassGlacier(t *testing.T) {
 	t.Parallel()
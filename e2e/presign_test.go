@@ -2,8 +2,14 @@ package e2e
 
 import (
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
 	"gotest.tools/v3/icmd"
 )
 
@@ -33,3 +39,60 @@ func TestPresign(t *testing.T) {
 		0: contains(filename),
 	})
 }
+
+func TestPresignURLIsFetchable(t *testing.T) {
+	skipTestIfGCS(t, "versioning is not supported in GCS")
+
+	t.Parallel()
+
+	// versioning is only supported with in memory backend!
+	s3client, s5cmd := setup(t, withS3Backend("mem"))
+
+	bucket := s3BucketFromTestName(t)
+
+	createBucket(t, s3client, bucket)
+	setBucketVersioning(t, s3client, bucket, "Enabled")
+
+	const filename = "test.txt"
+	contents := []string{"first content", "second content"}
+
+	putFile(t, s3client, bucket, filename, contents[0])
+	putFile(t, s3client, bucket, filename, contents[1])
+
+	src := fmt.Sprintf("s3://%v/%v", bucket, filename)
+
+	fetch := func(args ...string) string {
+		t.Helper()
+
+		cmd := s5cmd(append([]string{"--addressing-style", "path", "presign"}, args...)...)
+		result := icmd.RunCmd(cmd)
+		result.Assert(t, icmd.Success)
+
+		resp, err := http.Get(strings.TrimSpace(result.Stdout()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, resp.StatusCode, http.StatusOK, string(body))
+		return string(body)
+	}
+
+	assert.Equal(t, fetch(src), contents[1])
+
+	objects, err := s3client.ListObjectVersions(&s3.ListObjectVersionsInput{
+		Bucket: aws.String(bucket),
+	})
+	assert.NilError(t, err)
+
+	for _, version := range objects.Versions {
+		if aws.BoolValue(version.IsLatest) {
+			continue
+		}
+		assert.Equal(t, fetch("--version-id", aws.StringValue(version.VersionId), src), contents[0])
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/fs"
 	"gotest.tools/v3/icmd"
@@ -1230,6 +1233,7 @@ func TestVersionedListAndRemove(t *testing.T) {
 		0: contains("rm s3://%v/%v", bucket, filename),
 		1: contains("rm s3://%v/%v", bucket, filename),
 		2: contains("rm s3://%v/%v", bucket, filename),
+		3: equals("rm: removed object versions: 2, delete markers: 1"),
 	})
 
 	// all versions are deleted so we don't expect to see any result
@@ -1302,6 +1306,192 @@ func TestRemoveByVersionID(t *testing.T) {
 	assert.Assert(t, result.Stdout() == "")
 }
 
+// rm --all-versions s3://bucket/prefix/*
+func TestRemoveAllVersionsWithDeleteMarkers(t *testing.T) {
+	skipTestIfGCS(t, "versioning is not supported in GCS")
+
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	// versioninng is only supported with in memory backend!
+	s3client, s5cmd := setup(t, withS3Backend("mem"))
+
+	createBucket(t, s3client, bucket)
+	setBucketVersioning(t, s3client, bucket, "Enabled")
+
+	putFile(t, s3client, bucket, "prefix/a.txt", "first content")
+	putFile(t, s3client, bucket, "prefix/a.txt", "second content")
+	putFile(t, s3client, bucket, "prefix/b.txt", "content")
+	putFile(t, s3client, bucket, "keep.txt", "content")
+
+	// add a delete marker to each object under the prefix.
+	for _, key := range []string{"prefix/a.txt", "prefix/b.txt"} {
+		_, err := s3client.DeleteObject(&s3.DeleteObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+		assert.NilError(t, err)
+	}
+
+	cmd := s5cmd("rm", "--all-versions", "s3://"+bucket+"/prefix/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: prefix("rm s3://%v/prefix/a.txt ", bucket),
+		1: prefix("rm s3://%v/prefix/a.txt ", bucket),
+		2: prefix("rm s3://%v/prefix/a.txt ", bucket),
+		3: prefix("rm s3://%v/prefix/b.txt ", bucket),
+		4: prefix("rm s3://%v/prefix/b.txt ", bucket),
+		5: equals("rm: removed object versions: 3, delete markers: 2"),
+	}, sortInput(true))
+
+	output, err := s3client.ListObjectVersions(&s3.ListObjectVersionsInput{
+		Bucket: aws.String(bucket),
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(output.Versions), 1)
+	assert.Equal(t, aws.StringValue(output.Versions[0].Key), "keep.txt")
+	assert.Equal(t, len(output.DeleteMarkers), 0)
+}
+
+// rm --report-delete-markers [--remove-created-delete-markers] s3://bucket/prefix/*
+func TestRemoveReportDeleteMarkers(t *testing.T) {
+	skipTestIfGCS(t, "versioning is not supported in GCS")
+
+	t.Parallel()
+
+	testcases := []struct {
+		name            string
+		versioning      string
+		remove          bool
+		expectedSummary string
+		expectedMarkers int
+	}{
+		{
+			name:            "versioned bucket",
+			versioning:      "Enabled",
+			expectedSummary: "rm: 2 delete markers created, 0 removed",
+			expectedMarkers: 2,
+		},
+		{
+			name:            "versioned bucket remove created delete markers",
+			versioning:      "Enabled",
+			remove:          true,
+			expectedSummary: "rm: 2 delete markers created, 2 removed",
+		},
+		{
+			name:            "unversioned bucket",
+			expectedSummary: "rm: 0 delete markers created, 0 removed",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			bucket := s3BucketFromTestName(t)
+
+			// versioninng is only supported with in memory backend!
+			s3client, s5cmd := setup(t, withS3Backend("mem"))
+
+			createBucket(t, s3client, bucket)
+			if tc.versioning != "" {
+				setBucketVersioning(t, s3client, bucket, tc.versioning)
+			}
+
+			putFile(t, s3client, bucket, "prefix/a.txt", "content")
+			putFile(t, s3client, bucket, "prefix/b.txt", "content")
+			putFile(t, s3client, bucket, "keep.txt", "content")
+
+			args := []string{"rm", "--report-delete-markers"}
+			if tc.remove {
+				args = append(args, "--remove-created-delete-markers")
+			}
+			cmd := s5cmd(append(args, "s3://"+bucket+"/prefix/*")...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Success)
+
+			expected := map[int]compareFunc{
+				0: equals("rm s3://%v/prefix/a.txt", bucket),
+				1: equals("rm s3://%v/prefix/b.txt", bucket),
+				2: equals(tc.expectedSummary),
+			}
+			if tc.remove {
+				// the created delete markers are removed by their versions.
+				expected = map[int]compareFunc{
+					0: equals("rm s3://%v/prefix/a.txt", bucket),
+					1: prefix("rm s3://%v/prefix/a.txt ", bucket),
+					2: equals("rm s3://%v/prefix/b.txt", bucket),
+					3: prefix("rm s3://%v/prefix/b.txt ", bucket),
+					4: equals(tc.expectedSummary),
+				}
+			}
+			assertLines(t, result.Stdout(), expected, sortInput(true))
+
+			output, err := s3client.ListObjectVersions(&s3.ListObjectVersionsInput{
+				Bucket: aws.String(bucket),
+				Prefix: aws.String("prefix/"),
+			})
+			assert.NilError(t, err)
+			assert.Equal(t, len(output.DeleteMarkers), tc.expectedMarkers)
+		})
+	}
+}
+
+func TestRemoveReportDeleteMarkersValidation(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "remove created delete markers without report",
+			args:     []string{"rm", "--remove-created-delete-markers", "s3://bucket/prefix/*"},
+			expected: `"remove-created-delete-markers" flag can only be used with "report-delete-markers" flag`,
+		},
+		{
+			name:     "all versions",
+			args:     []string{"rm", "--report-delete-markers", "--all-versions", "s3://bucket/prefix/*"},
+			expected: `"report-delete-markers" flag cannot be used with "all-versions" flag`,
+		},
+		{
+			name:     "version id",
+			args:     []string{"rm", "--report-delete-markers", "--version-id", "1", "s3://bucket/object"},
+			expected: `"report-delete-markers" flag cannot be used with "version-id" flag`,
+		},
+		{
+			name:     "local objects",
+			args:     []string{"rm", "--report-delete-markers", "dir/file.txt"},
+			expected: `"report-delete-markers" flag can only be used with remote objects`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: contains(tc.expected),
+			})
+		})
+	}
+}
+
 // rm --include "*.py" s3://bucket/
 func TestRemoveS3ObjectsWithIncludeFilter(t *testing.T) {
 	t.Parallel()
@@ -1453,3 +1643,186 @@ func TestRemoveS3ObjectsWithIncludeExcludeFilter2(t *testing.T) {
 		assert.Assert(t, ensureS3Object(s3client, bucket, f, fileContent))
 	}
 }
+
+func TestRemoveWithSourceInventory(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "testfile1.txt", "content")
+	putFile(t, s3client, bucket, "testfile2.txt", "content")
+	// not in the inventory report, e.g. uploaded after the report is created.
+	putFile(t, s3client, bucket, "testfile3.txt", "content")
+
+	manifest := putInventory(t, s3client, bucket, bucket, map[string]int64{
+		"testfile1.txt": 7,
+		"testfile2.txt": 7,
+	})
+
+	cmd := s5cmd("rm", "--source-inventory", "s3://"+bucket+"/"+manifest, "s3://"+bucket+"/*.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`rm s3://%v/testfile1.txt`, bucket),
+		1: equals(`rm s3://%v/testfile2.txt`, bucket),
+	}, sortInput(true))
+
+	assertError(t, ensureS3Object(s3client, bucket, "testfile1.txt", "content"), errS3NoSuchKey)
+	assertError(t, ensureS3Object(s3client, bucket, "testfile2.txt", "content"), errS3NoSuchKey)
+	assert.NilError(t, ensureS3Object(s3client, bucket, "testfile3.txt", "content"))
+}
+
+func TestRemoveWithSourceInventoryShowProgress(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "testfile1.txt", "content")
+	putFile(t, s3client, bucket, "testfile2.txt", "content")
+
+	manifest := putInventory(t, s3client, bucket, bucket, map[string]int64{
+		"testfile1.txt": 7,
+		"testfile2.txt": 7,
+	})
+
+	cmd := s5cmd("rm", "--show-progress", "--source-inventory", "s3://"+bucket+"/"+manifest, "s3://"+bucket+"/*.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	// the deleted objects are not printed with the progress bar.
+	assert.Equal(t, result.Stdout(), "")
+
+	assertError(t, ensureS3Object(s3client, bucket, "testfile1.txt", "content"), errS3NoSuchKey)
+	assertError(t, ensureS3Object(s3client, bucket, "testfile2.txt", "content"), errS3NoSuchKey)
+}
+
+func TestRemoveWithSourceInventoryDryRun(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "testfile1.txt", "content")
+
+	manifest := putInventory(t, s3client, bucket, bucket, map[string]int64{
+		"testfile1.txt": 7,
+	})
+
+	cmd := s5cmd("--dry-run", "rm", "--source-inventory", "s3://"+bucket+"/"+manifest, "s3://"+bucket+"/*.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`rm s3://%v/testfile1.txt`, bucket),
+	})
+
+	assert.NilError(t, ensureS3Object(s3client, bucket, "testfile1.txt", "content"))
+}
+
+func TestRemoveAllVersionsWithSourceInventory(t *testing.T) {
+	skipTestIfGCS(t, "versioning is not supported in GCS")
+
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	// versioninng is only supported with in memory backend!
+	s3client, s5cmd := setup(t, withS3Backend("mem"))
+
+	const filename = "testfile.txt"
+
+	createBucket(t, s3client, bucket)
+	setBucketVersioning(t, s3client, bucket, "Enabled")
+
+	putFile(t, s3client, bucket, filename, "first content")
+	putFile(t, s3client, bucket, filename, "second content")
+
+	output, err := s3client.ListObjectVersions(&s3.ListObjectVersionsInput{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(filename),
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(output.Versions), 2)
+
+	var (
+		records    [][]string
+		versionIDs []string
+	)
+	for _, v := range output.Versions {
+		versionIDs = append(versionIDs, aws.StringValue(v.VersionId))
+		records = append(records, []string{
+			bucket,
+			filename,
+			aws.StringValue(v.VersionId),
+			fmt.Sprint(aws.BoolValue(v.IsLatest)),
+			"false",
+			fmt.Sprint(aws.Int64Value(v.Size)),
+		})
+	}
+
+	sort.Strings(versionIDs)
+
+	manifest := putInventoryRecords(t, s3client, bucket, bucket, "Bucket, Key, VersionId, IsLatest, IsDeleteMarker, Size", records)
+
+	// not in the inventory report, e.g. uploaded after the report is created.
+	putFile(t, s3client, bucket, filename, "third content")
+
+	cmd := s5cmd("rm", "--all-versions", "--source-inventory", "s3://"+bucket+"/"+manifest, "s3://"+bucket+"/*.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`rm s3://%v/%v %v`, bucket, filename, versionIDs[0]),
+		1: equals(`rm s3://%v/%v %v`, bucket, filename, versionIDs[1]),
+		2: equals(`rm: removed object versions: 2, delete markers: 0`),
+	}, sortInput(true))
+
+	// only the latest version, which is not in the report, is kept.
+	output, err = s3client.ListObjectVersions(&s3.ListObjectVersionsInput{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(filename),
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(output.Versions), 1)
+	assert.Equal(t, len(output.DeleteMarkers), 0)
+	assert.NilError(t, ensureS3Object(s3client, bucket, filename, "third content"))
+}
+
+func TestRemoveAllVersionsWithSourceInventoryWithoutVersions(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "testfile1.txt", "content")
+
+	manifest := putInventory(t, s3client, bucket, bucket, map[string]int64{
+		"testfile1.txt": 7,
+	})
+
+	cmd := s5cmd("rm", "--all-versions", "--source-inventory", "s3://"+bucket+"/"+manifest, "s3://"+bucket+"/*.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`inventory file schema "Bucket, Key, Size" does not include VersionId field`),
+	})
+
+	assert.NilError(t, ensureS3Object(s3client, bucket, "testfile1.txt", "content"))
+}
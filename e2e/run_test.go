@@ -102,6 +102,83 @@ func TestRunFromStdinWithErrors(t *testing.T) {
 	}, sortInput(true))
 }
 
+func TestRunFromStdinWithTagOutput(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "file1.txt", "content")
+	putFile(t, s3client, bucket, "file2.txt", "content")
+
+	lines := []string{
+		"# this is a comment",
+		fmt.Sprintf("cp s3://%v/file1.txt s3://%v/copy/file1.txt", bucket, bucket),
+		"",
+		fmt.Sprintf("cp s3://%v/file2.txt s3://%v/copy/file2.txt", bucket, bucket),
+		fmt.Sprintf("cp s3://%v/nonexistentobject s3://%v/copy/", bucket, bucket),
+	}
+	input := strings.NewReader(strings.Join(lines, "\n"))
+
+	cmd := s5cmd("run", "--tag-output")
+	result := icmd.RunCmd(cmd, icmd.WithStdin(input))
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	// the tags are the line numbers of the commands, counting from 0.
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`[1] cp s3://%v/file1.txt s3://%v/copy/file1.txt`, bucket, bucket),
+		1: equals(`[3] cp s3://%v/file2.txt s3://%v/copy/file2.txt`, bucket, bucket),
+	}, sortInput(true))
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: prefix(`[4] ERROR "cp s3://%v/nonexistentobject s3://%v/copy/nonexistentobject":`, bucket, bucket),
+	})
+
+	// each tag maps back to the line of the command which printed it.
+	for _, output := range []string{result.Stdout(), result.Stderr()} {
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			var lineno int
+			_, err := fmt.Sscanf(line, "[%d]", &lineno)
+			assert.NilError(t, err)
+
+			// the source of the command.
+			source := strings.Fields(lines[lineno])[1]
+			assert.Assert(t, strings.Contains(line, source), "line %q is not printed by %q", line, lines[lineno])
+		}
+	}
+}
+
+func TestRunFromStdinWithTagOutputJSON(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "file1.txt", "content")
+
+	input := strings.NewReader(
+		strings.Join([]string{
+			fmt.Sprintf("cp s3://%v/nonexistentobject s3://%v/copy/", bucket, bucket),
+			fmt.Sprintf("cp s3://%v/file1.txt s3://%v/copy/", bucket, bucket),
+		}, "\n"),
+	)
+	cmd := s5cmd("--json", "run", "--tag-output")
+	result := icmd.RunCmd(cmd, icmd.WithStdin(input))
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: prefix(`{"command_id":1,"operation":"cp","success":true,"source":"s3://%v/file1.txt",`, bucket),
+	}, jsonCheck(true))
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: prefix(`{"command_id":0,"operation":"cp","command":"cp s3://%v/nonexistentobject s3://%v/copy/nonexistentobject",`, bucket, bucket),
+	}, jsonCheck(true))
+}
+
 func TestRunFromStdinJSON(t *testing.T) {
 	t.Parallel()
 
@@ -210,6 +287,121 @@ func TestRunFromFileThatDoesntExist(t *testing.T) {
 	})
 }
 
+func TestRunFromS3Object(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "file1.txt", "content")
+	putFile(t, s3client, bucket, "file2.txt", "content")
+
+	filecontent := strings.Join([]string{
+		"# this is a comment",
+		fmt.Sprintf("ls s3://%v/file1.txt", bucket),
+		fmt.Sprintf("cp s3://%v/file2.txt s3://%v/copy/file2.txt", bucket, bucket),
+	}, "\n")
+	putFile(t, s3client, bucket, "commands.txt", filecontent)
+
+	cmd := s5cmd("run", fmt.Sprintf("s3://%v/commands.txt", bucket))
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("file1.txt"),
+		1: equals("cp s3://%v/file2.txt s3://%v/copy/file2.txt", bucket, bucket),
+	}, sortInput(true))
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{})
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "copy/file2.txt", "content"))
+}
+
+func TestRunFromS3ObjectThatDoesntExist(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	src := fmt.Sprintf("s3://%v/commands.txt", bucket)
+	cmd := s5cmd("run", src)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "run %v": given object %v not found`, src, src),
+	})
+}
+
+func TestRunFromS3Prefix(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd := setup(t)
+
+	cmd := s5cmd("run", "s3://bucket/prefix/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "run s3://bucket/prefix/": remote file must be an object`),
+	})
+}
+
+func TestRunWithConcurrencyPerHost(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	var lines []string
+	for i := 0; i < 5; i++ {
+		putFile(t, s3client, bucket, fmt.Sprintf("file%v.txt", i), "content")
+		lines = append(lines, fmt.Sprintf("cp s3://%v/file%v.txt s3://%v/copy/file%v.txt", bucket, i, bucket, i))
+	}
+
+	cmd := s5cmd("run", "--concurrency-per-host", "2")
+	result := icmd.RunCmd(cmd, icmd.WithStdin(strings.NewReader(strings.Join(lines, "\n"))))
+
+	result.Assert(t, icmd.Success)
+
+	expected := map[int]compareFunc{}
+	for i, line := range lines {
+		expected[i] = equals("%v", line)
+	}
+	assertLines(t, result.Stdout(), expected, sortInput(true))
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{})
+
+	for i := 0; i < 5; i++ {
+		assert.Assert(t, ensureS3Object(s3client, bucket, fmt.Sprintf("copy/file%v.txt", i), "content"))
+	}
+}
+
+func TestRunWithInvalidConcurrencyPerHost(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd := setup(t)
+
+	cmd := s5cmd("run", "--concurrency-per-host", "-1")
+	result := icmd.RunCmd(cmd, icmd.WithStdin(strings.NewReader("")))
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "run --concurrency-per-host=-1": "concurrency-per-host" flag must be a positive number`),
+	})
+}
+
 func TestRunWildcardCountGreaterEqualThanWorkerCount(t *testing.T) {
 	t.Parallel()
 
@@ -350,3 +542,74 @@ func TestRunFixDataRace_Issue301(t *testing.T) {
 
 	assertLines(t, result.Stderr(), map[int]compareFunc{})
 }
+
+func TestRunJSONLinesFromStdin(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, `my "quoted" file.txt`, "content")
+	putFile(t, s3client, bucket, "dir/a.txt", "content")
+	putFile(t, s3client, bucket, "dir/b.log", "content")
+
+	input := strings.NewReader(
+		strings.Join([]string{
+			fmt.Sprintf(`{"op": "cp", "args": ["s3://%v/my \"quoted\" file.txt", "s3://%v/copy of file.txt"], "flags": {"acl": "private"}}`, bucket, bucket),
+			"",
+			fmt.Sprintf(`{"op": "rm", "args": ["s3://%v/dir/*"], "flags": {"exclude": ["*.log"]}}`, bucket),
+		}, "\n"),
+	)
+	cmd := s5cmd("run", "--jsonl")
+	result := icmd.RunCmd(cmd, icmd.WithStdin(input))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp s3://%v/my "quoted" file.txt s3://%v/copy of file.txt`, bucket, bucket),
+		1: equals(`rm s3://%v/dir/a.txt`, bucket),
+	}, sortInput(true))
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{})
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "copy of file.txt", "content"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "dir/b.log", "content"))
+}
+
+func TestRunJSONLinesMalformedLine(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "file.txt", "content")
+
+	input := strings.Join([]string{
+		`{"op": "ls", "args": [`,
+		fmt.Sprintf(`{"op": "ls", "args": ["s3://%v/file.txt"]}`, bucket),
+	}, "\n")
+
+	cmd := s5cmd("run", "--jsonl")
+	result := icmd.RunCmd(cmd, icmd.WithStdin(strings.NewReader(input)))
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{})
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`ERROR "run --jsonl=true": invalid command (line: 0):`),
+	})
+
+	cmd = s5cmd("run", "--jsonl", "--continue-on-error")
+	result = icmd.RunCmd(cmd, icmd.WithStdin(strings.NewReader(input)))
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("file.txt"),
+	})
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`ERROR "run --jsonl=true --continue-on-error=true": invalid command (line: 0):`),
+	})
+}
@@ -1,6 +1,7 @@
 package e2e
 
 import (
+	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
@@ -12,7 +13,7 @@ import (
 	"gotest.tools/v3/fs"
 )
 
-func s3ServerEndpoint(t *testing.T, testdir *fs.Dir, loglvl, backend string, timeSource gofakes3.TimeSource, enableProxy bool) string {
+func s3ServerEndpoint(t *testing.T, testdir *fs.Dir, loglvl, backend string, timeSource gofakes3.TimeSource, enableProxy bool, pathPrefix string) string {
 	var s3backend gofakes3.Backend
 	switch backend {
 	case "mem":
@@ -51,7 +52,14 @@ func s3ServerEndpoint(t *testing.T, testdir *fs.Dir, loglvl, backend string, tim
 		)
 	}
 	faker := gofakes3.New(s3backend, opts...)
-	s3srv := httptest.NewServer(faker.Server())
+
+	// the server is served under the given path prefix, as if it's behind
+	// a reverse proxy.
+	var handler http.Handler = faker.Server()
+	if pathPrefix != "" {
+		handler = http.StripPrefix(pathPrefix, handler)
+	}
+	s3srv := httptest.NewServer(handler)
 
 	t.Cleanup(func() {
 		s3srv.Close()
@@ -64,8 +72,8 @@ func s3ServerEndpoint(t *testing.T, testdir *fs.Dir, loglvl, backend string, tim
 		if err != nil {
 			t.Fatal(err)
 		}
-		proxyEnabledURL := "http://localhost.:" + parsedURL.Port()
+		proxyEnabledURL := "http://localhost.:" + parsedURL.Port() + pathPrefix
 		return proxyEnabledURL
 	}
-	return s3srv.URL
+	return s3srv.URL + pathPrefix
 }
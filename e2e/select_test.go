@@ -585,6 +585,260 @@ func TestSelectCommandEmptyBucket(t *testing.T) {
 	}
 }
 
+func TestSelectCommandMultipleObjects(t *testing.T) {
+	t.Parallel()
+
+	const (
+		region      = "us-east-1"
+		accessKeyID = "minioadmin"
+		secretKey   = "minioadmin"
+
+		query = "SELECT s.id FROM s3object s"
+	)
+
+	endpoint := os.Getenv(s5cmdTestEndpointEnv)
+	if endpoint == "" {
+		t.Skipf("skipping the test because %v environment variable is empty", s5cmdTestEndpointEnv)
+	}
+
+	testcases := []struct {
+		name string
+		src  string
+	}{
+		{
+			name: "wildcard",
+			src:  "prefix/*.csv",
+		},
+		{
+			name: "prefix",
+			src:  "prefix/",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			bucket := s3BucketFromTestName(t)
+
+			s3client, s5cmd := setup(t, withEndpointURL(endpoint), withRegion(region), withAccessKeyID(accessKeyID), withSecretKey(secretKey))
+			createBucket(t, s3client, bucket)
+
+			putFile(t, s3client, bucket, "prefix/file1.csv", "id,data\nid1,event 1\nid2,event 2\n")
+			putFile(t, s3client, bucket, "prefix/file2.csv", "id,data\nid3,event 3\n")
+			putFile(t, s3client, bucket, "prefix/file3.csv", "id,data\nid4,event 4\nid5,event 5\n")
+			putFile(t, s3client, bucket, "file4.csv", "id,data\nid6,event 6\n")
+
+			cmd := s5cmd(
+				"select", "csv",
+				"--use-header", "USE",
+				"--query", query,
+				fmt.Sprintf("s3://%s/%s", bucket, tc.src),
+			)
+			result := icmd.RunCmd(cmd, withEnv("AWS_ACCESS_KEY_ID", accessKeyID), withEnv("AWS_SECRET_ACCESS_KEY", secretKey))
+
+			result.Assert(t, icmd.Success)
+
+			// the results of the objects are concatenated in any order.
+			assertLines(t, result.Stdout(), map[int]compareFunc{
+				0: equals("id1"),
+				1: equals("id2"),
+				2: equals("id3"),
+				3: equals("id4"),
+				4: equals("id5"),
+			}, sortInput(true))
+		})
+	}
+}
+
+func TestSelectCommandWithQueryHelpers(t *testing.T) {
+	t.Parallel()
+
+	const (
+		region      = "us-east-1"
+		accessKeyID = "minioadmin"
+		secretKey   = "minioadmin"
+	)
+
+	endpoint := os.Getenv(s5cmdTestEndpointEnv)
+	if endpoint == "" {
+		t.Skipf("skipping the test because %v environment variable is empty", s5cmdTestEndpointEnv)
+	}
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "columns",
+			args:     []string{"--columns", "id,price"},
+			expected: "id1,10\nid2,20\nid3,30\n",
+		},
+		{
+			name:     "columns and where",
+			args:     []string{"--columns", "id", "--where", "s.item='avocado'"},
+			expected: "id1\nid3\n",
+		},
+		{
+			name:     "where and limit",
+			args:     []string{"--where", "s.item='avocado'", "--limit", "1"},
+			expected: "id1,avocado,10\n",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			bucket := s3BucketFromTestName(t)
+
+			s3client, s5cmd := setup(t, withEndpointURL(endpoint), withRegion(region), withAccessKeyID(accessKeyID), withSecretKey(secretKey))
+			createBucket(t, s3client, bucket)
+
+			putFile(t, s3client, bucket, "prices.csv", "id,item,price\nid1,avocado,10\nid2,tomato,20\nid3,avocado,30\n")
+
+			args := append([]string{"select", "csv", "--use-header", "USE"}, tc.args...)
+			args = append(args, fmt.Sprintf("s3://%s/prices.csv", bucket))
+
+			cmd := s5cmd(args...)
+			result := icmd.RunCmd(cmd, withEnv("AWS_ACCESS_KEY_ID", accessKeyID), withEnv("AWS_SECRET_ACCESS_KEY", secretKey))
+
+			result.Assert(t, icmd.Success)
+			assert.Equal(t, result.Stdout(), tc.expected)
+		})
+	}
+}
+
+func TestSelectCommandWithOutput(t *testing.T) {
+	t.Parallel()
+
+	const (
+		region      = "us-east-1"
+		accessKeyID = "minioadmin"
+		secretKey   = "minioadmin"
+
+		query    = "SELECT s.id FROM s3object s WHERE s.item='avocado'"
+		expected = "id1\nid3\n"
+	)
+
+	endpoint := os.Getenv(s5cmdTestEndpointEnv)
+	if endpoint == "" {
+		t.Skipf("skipping the test because %v environment variable is empty", s5cmdTestEndpointEnv)
+	}
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd := setup(t, withEndpointURL(endpoint), withRegion(region), withAccessKeyID(accessKeyID), withSecretKey(secretKey))
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "prices.csv", "id,item,price\nid1,avocado,10\nid2,tomato,20\nid3,avocado,30\n")
+
+	src := fmt.Sprintf("s3://%s/prices.csv", bucket)
+	localOutput := filepath.Join(t.TempDir(), "results", "result.csv")
+
+	testcases := []struct {
+		name   string
+		output string
+		check  func(t *testing.T)
+	}{
+		{
+			name:   "object",
+			output: fmt.Sprintf("s3://%s/results/result.csv", bucket),
+			check: func(t *testing.T) {
+				assert.Assert(t, ensureS3Object(s3client, bucket, "results/result.csv", expected))
+			},
+		},
+		{
+			name:   "file",
+			output: localOutput,
+			check: func(t *testing.T) {
+				content, err := os.ReadFile(localOutput)
+				assert.NilError(t, err)
+				assert.Equal(t, string(content), expected)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := s5cmd("select", "csv", "--use-header", "USE", "--query", query, "--output", tc.output, src)
+			result := icmd.RunCmd(cmd, withEnv("AWS_ACCESS_KEY_ID", accessKeyID), withEnv("AWS_SECRET_ACCESS_KEY", secretKey))
+
+			result.Assert(t, icmd.Success)
+
+			// the result is written to the output instead of stdout.
+			assert.Equal(t, result.Stdout(), "")
+			tc.check(t)
+		})
+	}
+}
+
+func TestSelectCommandQueryHelpersValidation(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "query with columns",
+			args:     []string{"--query", "SELECT * FROM s3object s", "--columns", "id"},
+			expected: `"query" flag cannot be used with "columns", "where" and "limit" flags`,
+		},
+		{
+			name:     "query with limit",
+			args:     []string{"--query", "SELECT * FROM s3object s", "--limit", "10"},
+			expected: `"query" flag cannot be used with "columns", "where" and "limit" flags`,
+		},
+		{
+			name:     "no query",
+			args:     []string{},
+			expected: "query must be non-empty",
+		},
+		{
+			name:     "empty columns",
+			args:     []string{"--columns", ","},
+			expected: "columns must be non-empty",
+		},
+		{
+			name:     "non-positive limit",
+			args:     []string{"--limit", "0"},
+			expected: "limit must be a positive integer",
+		},
+		{
+			name:     "prefix output",
+			args:     []string{"--query", "SELECT * FROM s3object s", "--output", "s3://bucket/results/"},
+			expected: "output must be a file or an object",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			args := append([]string{"select", "csv"}, tc.args...)
+			args = append(args, "s3://bucket/prices.csv")
+
+			cmd := s5cmd(args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: contains("%v", tc.expected),
+			})
+		})
+	}
+}
+
 func TestSelectWithParquet(t *testing.T) {
 	// NOTE(deniz): We are skipping this test until the image we use in the
 	// service container releases parquet support for select api.
@@ -1,14 +1,18 @@
 package e2e
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/fs"
 	"gotest.tools/v3/icmd"
@@ -1578,6 +1582,67 @@ func TestSyncS3BucketToS3BucketWithDelete(t *testing.T) {
 	}
 }
 
+// --log-requests sync --delete --size-only s3://bucket/* s3://destbucket/
+func TestSyncS3BucketToS3BucketServerSide(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	dstbucket := s3BucketFromTestNameWithPrefix(t, "dst")
+	createBucket(t, s3client, bucket)
+	createBucket(t, s3client, dstbucket)
+
+	putFile(t, s3client, bucket, "new.txt", "S: only in source")
+	putFile(t, s3client, bucket, "changed.txt", "S: changed in source")
+	putFile(t, s3client, bucket, "same.txt", "S: same content")
+
+	putFile(t, s3client, dstbucket, "changed.txt", "D: changed")
+	putFile(t, s3client, dstbucket, "same.txt", "S: same content")
+	putFile(t, s3client, dstbucket, "extra1.txt", "D: only in destination")
+	putFile(t, s3client, dstbucket, "extra2.txt", "D: only in destination")
+
+	src := fmt.Sprintf("s3://%v/", bucket)
+	dst := fmt.Sprintf("s3://%v/", dstbucket)
+
+	cmd := s5cmd("--log-requests", "sync", "--delete", "--size-only", src+"*", dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %vchanged.txt %vchanged.txt`, src, dst),
+		1: equals(`cp %vnew.txt %vnew.txt`, src, dst),
+		2: equals(`rm %vextra1.txt`, dst),
+		3: equals(`rm %vextra2.txt`, dst),
+	}, sortInput(true))
+
+	// the objects are copied and deleted on the server side, the data never
+	// passes through the client.
+	requests := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSpace(result.Stderr()), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != "DEBUG" || fields[1] != "request" {
+			t.Fatalf("unexpected line in stderr: %q", line)
+		}
+		requests[fields[2]]++
+	}
+
+	for _, op := range []string{"GetObject", "PutObject", "CreateMultipartUpload", "UploadPart", "DeleteObject"} {
+		assert.Equal(t, requests[op], 0, "unexpected %v requests", op)
+	}
+	assert.Equal(t, requests["CopyObject"], 2)
+	assert.Equal(t, requests["DeleteObjects"], 1)
+
+	assert.Assert(t, ensureS3Object(s3client, dstbucket, "new.txt", "S: only in source"))
+	assert.Assert(t, ensureS3Object(s3client, dstbucket, "changed.txt", "S: changed in source"))
+	assert.Assert(t, ensureS3Object(s3client, dstbucket, "same.txt", "S: same content"))
+	for _, key := range []string{"extra1.txt", "extra2.txt"} {
+		err := ensureS3Object(s3client, dstbucket, key, "D: only in destination")
+		assertError(t, err, errS3NoSuchKey)
+	}
+}
+
 // sync s3://bucket/*.txt folder/
 func TestSyncS3toLocalWithWildcard(t *testing.T) {
 	t.Parallel()
@@ -1851,6 +1916,111 @@ func TestSyncS3ObjectsIntoAnotherBucketWithExcludeFilters(t *testing.T) {
 	}
 }
 
+// sync --exclude "**/node_modules/**" ./project s3://bucket/backup/
+func TestSyncLocalDirectoryToS3WithNestedExcludeFilter(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, t.Name(),
+		fs.WithDir("project",
+			fs.WithFile("main.js", "main"),
+			fs.WithDir("node_modules", fs.WithFile("dep.js", "dep")),
+			fs.WithDir("web",
+				fs.WithFile("app.js", "app"),
+				fs.WithDir("node_modules", fs.WithFile("dep.js", "dep")),
+			),
+		),
+	)
+	defer workdir.Remove()
+
+	// the walk fails on the symlink loop unless the directory is pruned.
+	if err := os.Symlink("loop.js", workdir.Join("project", "web", "node_modules", "loop.js")); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := s5cmd("sync", "--exclude", "**/node_modules/**", "./project", "s3://"+bucket+"/backup/")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+	assertLines(t, result.Stderr(), map[int]compareFunc{})
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp project/main.js s3://%v/backup/project/main.js`, bucket),
+		1: equals(`cp project/web/app.js s3://%v/backup/project/web/app.js`, bucket),
+	}, sortInput(true))
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "backup/project/main.js", "main"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "backup/project/web/app.js", "app"))
+	for _, key := range []string{"backup/project/node_modules/dep.js", "backup/project/web/node_modules/dep.js"} {
+		err := ensureS3Object(s3client, bucket, key, "")
+		assertError(t, err, errS3NoSuchKey)
+	}
+}
+
+// sync --exclude "web/*" s3://bucket/project/* s3://bucket/backup/
+func TestSyncS3PrefixToS3WithExcludeFilterRelativeToPrefix(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "project/main.js", "main")
+	putFile(t, s3client, bucket, "project/web/app.js", "app")
+	putFile(t, s3client, bucket, "project/lib/web/util.js", "util")
+
+	cmd := s5cmd("sync", "--exclude", "web/*", "s3://"+bucket+"/project/*", "s3://"+bucket+"/backup/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp s3://%v/project/lib/web/util.js s3://%v/backup/lib/web/util.js`, bucket, bucket),
+		1: equals(`cp s3://%v/project/main.js s3://%v/backup/main.js`, bucket, bucket),
+	}, sortInput(true))
+
+	err := ensureS3Object(s3client, bucket, "backup/web/app.js", "")
+	assertError(t, err, errS3NoSuchKey)
+}
+
+// sync --exclude-empty dir/ s3://bucket/
+func TestSyncLocalDirectoryToS3WithExcludeEmpty(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithFile("file.txt", "content"),
+		fs.WithFile("empty.txt", ""),
+		fs.WithDir("a", fs.WithFile(".keep", "")),
+	)
+	defer workdir.Remove()
+
+	src := filepath.ToSlash(workdir.Path()) + "/"
+	cmd := s5cmd("sync", "--exclude-empty", src, "s3://"+bucket+"/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %vfile.txt s3://%v/file.txt`, src, bucket),
+	})
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "file.txt", "content"))
+	for _, key := range []string{"empty.txt", "a/.keep"} {
+		err := ensureS3Object(s3client, bucket, key, "")
+		assertError(t, err, errS3NoSuchKey)
+	}
+}
+
 // sync --exclude "*.gz" dir s3://bucket/
 // sync --exclude "*.gz" dir/ s3://bucket/
 // sync --exclude "*.gz" dir/* s3://bucket/
@@ -2252,3 +2422,1157 @@ func TestSyncS3ObjectsIntoAnotherBucketWithIncludeFilters(t *testing.T) {
 		assertError(t, err, errS3NoSuchKey)
 	}
 }
+
+// sync [--exclude|--include] [--delete [--delete-excluded]] folder/ s3://bucket/prefix/
+func TestSyncLocalToS3BucketWithFiltersAndDelete(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		flags    []string
+		expected []string
+		deleted  []string
+	}{
+		{
+			name:  "delete",
+			flags: []string{"--delete"},
+			expected: []string{
+				"cp a.txt",
+				"cp b.log",
+				"rm c.txt",
+				"rm d.log",
+			},
+			deleted: []string{"c.txt", "d.log"},
+		},
+		{
+			name:  "exclude",
+			flags: []string{"--exclude", "*.log"},
+			expected: []string{
+				"cp a.txt",
+			},
+		},
+		{
+			name:  "exclude with delete",
+			flags: []string{"--exclude", "*.log", "--delete"},
+			expected: []string{
+				"cp a.txt",
+				"rm c.txt",
+			},
+			deleted: []string{"c.txt"},
+		},
+		{
+			name:  "exclude with delete and delete-excluded",
+			flags: []string{"--exclude", "*.log", "--delete", "--delete-excluded"},
+			expected: []string{
+				"cp a.txt",
+				"rm b.log",
+				"rm c.txt",
+				"rm d.log",
+			},
+			deleted: []string{"b.log", "c.txt", "d.log"},
+		},
+		{
+			name:  "include",
+			flags: []string{"--include", "*.txt"},
+			expected: []string{
+				"cp a.txt",
+			},
+		},
+		{
+			name:  "include with delete",
+			flags: []string{"--include", "*.txt", "--delete"},
+			expected: []string{
+				"cp a.txt",
+				"rm c.txt",
+			},
+			deleted: []string{"c.txt"},
+		},
+		{
+			name:  "include with delete and delete-excluded",
+			flags: []string{"--include", "*.txt", "--delete", "--delete-excluded"},
+			expected: []string{
+				"cp a.txt",
+				"rm b.log",
+				"rm c.txt",
+				"rm d.log",
+			},
+			deleted: []string{"b.log", "c.txt", "d.log"},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s3client, s5cmd := setup(t)
+
+			bucket := s3BucketFromTestName(t)
+			createBucket(t, s3client, bucket)
+
+			workdir := fs.NewDir(t, "somedir",
+				fs.WithFile("a.txt", "S: this is a text file"),
+				fs.WithFile("b.log", "S: this is a log file"),
+			)
+			defer workdir.Remove()
+
+			S3Content := map[string]string{
+				"prefix/a.txt": "D: text",
+				"prefix/b.log": "D: log",
+				"prefix/c.txt": "D: extra text",
+				"prefix/d.log": "D: extra log",
+			}
+			for key, content := range S3Content {
+				putFile(t, s3client, bucket, key, content)
+			}
+
+			src := filepath.ToSlash(fmt.Sprintf("%v/", workdir.Path()))
+			dst := fmt.Sprintf("s3://%v/prefix/", bucket)
+
+			args := append([]string{"sync"}, tc.flags...)
+			cmd := s5cmd(append(args, src, dst)...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Success)
+
+			expected := make(map[int]compareFunc)
+			for i, line := range tc.expected {
+				op, name := line[:2], line[3:]
+				if op == "cp" {
+					expected[i] = equals("cp %v%v %v%v", src, name, dst, name)
+				} else {
+					expected[i] = equals("rm %v%v", dst, name)
+				}
+			}
+			assertLines(t, result.Stdout(), expected, sortInput(true))
+
+			deleted := make(map[string]bool)
+			for _, name := range tc.deleted {
+				deleted[name] = true
+				err := ensureS3Object(s3client, bucket, "prefix/"+name, S3Content["prefix/"+name])
+				assert.Assert(t, err != nil, "%v is not deleted", name)
+			}
+
+			// the copied objects are overwritten, and the rest are kept as
+			// they are.
+			assert.Assert(t, ensureS3Object(s3client, bucket, "prefix/a.txt", "S: this is a text file"))
+			for _, name := range []string{"b.log", "c.txt", "d.log"} {
+				if deleted[name] {
+					continue
+				}
+				content := S3Content["prefix/"+name]
+				if name == "b.log" && len(tc.flags) == 1 {
+					content = "S: this is a log file"
+				}
+				assert.Assert(t, ensureS3Object(s3client, bucket, "prefix/"+name, content))
+			}
+		})
+	}
+}
+
+// sync --delete-excluded folder/ s3://bucket/
+func TestSyncDeleteExcludedWithoutDelete(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, "somedir", fs.WithFile("a.txt", "content"))
+	defer workdir.Remove()
+
+	src := filepath.ToSlash(fmt.Sprintf("%v/", workdir.Path()))
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("sync", "--delete-excluded", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "sync --delete-excluded=true %v %v": "delete-excluded" flag can only be used with "delete" flag`, src, dst),
+	})
+}
+
+// sync --delete --delete-after s3://bucket/* folder/
+func TestSyncS3BucketToLocalWithDeleteAfter(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "a.txt", "S: a")
+	putFile(t, s3client, bucket, "b.txt", "S: b")
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithFile("a.txt", "D: old"),
+		fs.WithFile("extra.txt", "D: extra"),
+	)
+	defer workdir.Remove()
+
+	src := fmt.Sprintf("s3://%v/", bucket)
+	dst := filepath.ToSlash(fmt.Sprintf("%v/", workdir.Path()))
+
+	cmd := s5cmd("--numworkers", "1", "sync", "--delete", "--delete-after", src+"*", dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	// the objects only in destination are deleted after all the objects are
+	// synced.
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %va.txt %va.txt`, src, dst),
+		1: equals(`cp %vb.txt %vb.txt`, src, dst),
+		2: equals(`rm %vextra.txt`, dst),
+	}, sortInput(true))
+
+	lines := strings.Split(strings.TrimSpace(result.Stdout()), "\n")
+	assert.Equal(t, lines[len(lines)-1], fmt.Sprintf(`rm %vextra.txt`, dst))
+
+	expected := fs.Expected(t,
+		fs.WithFile("a.txt", "S: a"),
+		fs.WithFile("b.txt", "S: b"),
+	)
+	assert.Assert(t, fs.Equal(workdir.Path(), expected))
+}
+
+// sync --delete --delete-after s3://bucket/* folder/
+func TestSyncS3BucketToLocalWithDeleteAfterFailure(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "b.txt", "S: b")
+	putFile(t, s3client, bucket, "dir/a.txt", "S: a")
+
+	// dir/a.txt can not be downloaded, since there is a file with the name of
+	// its directory.
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithFile("dir", "D: dir"),
+		fs.WithFile("extra.txt", "D: extra"),
+	)
+	defer workdir.Remove()
+
+	src := fmt.Sprintf("s3://%v/", bucket)
+	dst := filepath.ToSlash(fmt.Sprintf("%v/", workdir.Path()))
+
+	cmd := s5cmd("sync", "--delete", "--delete-after", src+"*", dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %vb.txt %vb.txt`, src, dst),
+	})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`ERROR "cp --raw=true %vdir/a.txt %vdir/a.txt"`, src, dst),
+		1: equals(`ERROR "sync --delete=true --delete-after=true %v* %v": the objects only in destination are not deleted since some objects failed to sync`, src, dst),
+	})
+
+	// none of the objects only in destination are deleted.
+	expected := fs.Expected(t,
+		fs.WithFile("b.txt", "S: b"),
+		fs.WithFile("dir", "D: dir"),
+		fs.WithFile("extra.txt", "D: extra"),
+	)
+	assert.Assert(t, fs.Equal(workdir.Path(), expected))
+}
+
+// sync --delete-after folder/ s3://bucket/
+func TestSyncDeleteAfterWithoutDelete(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd := setup(t)
+
+	cmd := s5cmd("sync", "--delete-after", "folder/", "s3://bucket/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "sync --delete-after=true folder/ s3://bucket/": "delete-after" flag can only be used with "delete" flag`),
+	})
+}
+
+// sync --checksum-metadata-key x-amz-meta-sha256 folder/ s3://bucket/
+func TestSyncLocalFolderToS3WithChecksumMetadataKey(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	sha256sum := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+
+	// the objects are uploaded with their precomputed digests, except one.
+	putFile(t, s3client, bucket, "same.txt", "same content", putArbitraryMetadata(map[string]*string{
+		"Sha256": aws.String(sha256sum("same content")),
+	}))
+	putFile(t, s3client, bucket, "changed.txt", "old content", putArbitraryMetadata(map[string]*string{
+		"Sha256": aws.String(sha256sum("old content")),
+	}))
+	putFile(t, s3client, bucket, "nodigest.txt", "no digest")
+
+	// the local files are newer than the objects, so they would be synced by
+	// their modification times.
+	modTime := time.Now().Add(time.Hour)
+	timestamp := fs.WithTimestamps(modTime, modTime)
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithFile("same.txt", "same content", timestamp),
+		fs.WithFile("changed.txt", "new content", timestamp),
+		fs.WithFile("nodigest.txt", "no digest", timestamp),
+	)
+	defer workdir.Remove()
+
+	src := filepath.ToSlash(fmt.Sprintf("%v/", workdir.Path()))
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("--dry-run", "sync", "--checksum-metadata-key", "x-amz-meta-sha256", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %vchanged.txt %vchanged.txt # checksum-differs`, src, dst),
+		1: equals(`cp %vnodigest.txt %vnodigest.txt # newer`, src, dst),
+	}, sortInput(true))
+
+	cmd = s5cmd("--log", "debug", "sync", "--checksum-metadata-key", "x-amz-meta-sha256", src, dst)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`DEBUG "sync %vnodigest.txt %vnodigest.txt": object has no checksum in its metadata, comparing size and modification time instead`, src, dst),
+		1: equals(`DEBUG "sync %vsame.txt %vsame.txt": object checksum matches`, src, dst),
+		2: equals(`cp %vchanged.txt %vchanged.txt`, src, dst),
+		3: equals(`cp %vnodigest.txt %vnodigest.txt`, src, dst),
+	}, sortInput(true))
+
+	// the digests of the uploaded files are stored by the generated copy
+	// commands.
+	assert.Assert(t, ensureS3Object(s3client, bucket, "changed.txt", "new content", ensureArbitraryMetadata(map[string]*string{
+		"Sha256": aws.String(sha256sum("new content")),
+	})))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "nodigest.txt", "no digest", ensureArbitraryMetadata(map[string]*string{
+		"Sha256": aws.String(sha256sum("no digest")),
+	})))
+
+	// the next run trusts the stored digests, even if the files are touched.
+	newModTime := modTime.Add(time.Hour)
+	for _, name := range []string{"same.txt", "changed.txt", "nodigest.txt"} {
+		assert.NilError(t, os.Chtimes(workdir.Join(name), newModTime, newModTime))
+	}
+
+	cmd = s5cmd("--log", "debug", "sync", "--checksum-metadata-key", "x-amz-meta-sha256", src, dst)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`DEBUG "sync %vchanged.txt %vchanged.txt": object checksum matches`, src, dst),
+		1: equals(`DEBUG "sync %vnodigest.txt %vnodigest.txt": object checksum matches`, src, dst),
+		2: equals(`DEBUG "sync %vsame.txt %vsame.txt": object checksum matches`, src, dst),
+	}, sortInput(true))
+}
+
+func TestSyncChecksumMetadataKeyWithCompare(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd := setup(t)
+
+	cmd := s5cmd("sync", "--checksum-metadata-key", "x-amz-meta-sha256", "--compare", "etag", "folder/", "s3://bucket/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`"checksum-metadata-key" flag cannot be used with "compare" or "size-only" flags`),
+	})
+}
+
+// sync --content-disposition inline --content-language en-US folder/ s3://bucket/
+func TestSyncLocalFolderToS3WithContentDispositionAndLanguage(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, "somedir", fs.WithFile("index.html", "<html></html>"))
+	defer workdir.Remove()
+
+	src := filepath.ToSlash(fmt.Sprintf("%v/", workdir.Path()))
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("--log", "trace", "sync", "--content-disposition", "inline", "--content-language", "en-US", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	stdout := result.Stdout()
+	assert.Assert(t, strings.Contains(stdout, "Content-Disposition: inline"))
+	assert.Assert(t, strings.Contains(stdout, "Content-Language: en-US"))
+
+	opts := []ensureOption{ensureContentDisposition("inline")}
+	// gofakes3 doesn't store the content language of the objects.
+	if isEndpointFromEnv() {
+		opts = append(opts, ensureContentLanguage("en-US"))
+	}
+	assert.Assert(t, ensureS3Object(s3client, bucket, "index.html", "<html></html>", opts...))
+}
+
+// sync --checkpoint checkpoint.json dir/ s3://bucket/
+func TestSyncLocalFolderToS3WithCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	modTime := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	timestamp := fs.WithTimestamps(modTime, modTime)
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithDir("dir",
+			fs.WithFile("synced.txt", "synced by the previous run", timestamp),
+			fs.WithFile("changed.txt", "changed after the previous run", timestamp),
+			fs.WithFile("new.txt", "not synced yet", timestamp),
+		),
+	)
+	defer workdir.Remove()
+
+	// simulate an interrupted sync that completed two of the files. one of
+	// them is modified after the interruption.
+	entry := func(name string, size int) string {
+		return fmt.Sprintf(`{"source":%q,"size":%d,"mod_time":%d}`, filepath.ToSlash(workdir.Join("dir", name)), size, modTime.UnixNano())
+	}
+	checkpoint := workdir.Join("checkpoint.json")
+	err := os.WriteFile(checkpoint, []byte(entry("synced.txt", 26)+"\n"+entry("changed.txt", 10)+"\n"), 0644)
+	assert.NilError(t, err)
+
+	src := filepath.ToSlash(workdir.Join("dir")) + "/"
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("--log", "debug", "sync", "--checkpoint", checkpoint, src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`DEBUG "sync %vsynced.txt %vsynced.txt": object is synced by a previous run`, src, dst),
+		1: equals(`cp %vchanged.txt %vchanged.txt`, src, dst),
+		2: equals(`cp %vnew.txt %vnew.txt`, src, dst),
+	}, sortInput(true))
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "changed.txt", "changed after the previous run"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "new.txt", "not synced yet"))
+
+	err = ensureS3Object(s3client, bucket, "synced.txt", "synced by the previous run")
+	assertError(t, err, errS3NoSuchKey)
+
+	// checkpoint file is removed after a successful sync.
+	_, err = os.Stat(checkpoint)
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+// --dry-run sync --checkpoint checkpoint.json dir/ s3://bucket/
+func TestSyncLocalFolderToS3WithCheckpointDryRun(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithDir("dir", fs.WithFile("new.txt", "not synced yet")),
+		fs.WithFile("checkpoint.json", ""),
+	)
+	defer workdir.Remove()
+
+	checkpoint := workdir.Join("checkpoint.json")
+	src := filepath.ToSlash(workdir.Join("dir")) + "/"
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("--dry-run", "sync", "--checkpoint", checkpoint, src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %vnew.txt %vnew.txt # new`, src, dst),
+	})
+
+	// checkpoint file is not modified in dry-run mode.
+	_, err := os.Stat(checkpoint)
+	assert.NilError(t, err)
+}
+
+// sync --bidirectional --state-file sync.state dir/ s3://bucket/*
+func TestSyncBidirectionalLocalFolderAndS3(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithDir("dir",
+			fs.WithFile("local.txt", "only in local"),
+			fs.WithFile("common.txt", "in both"),
+		),
+	)
+	defer workdir.Remove()
+
+	putFile(t, s3client, bucket, "remote.txt", "only in remote")
+	putFile(t, s3client, bucket, "common.txt", "in both")
+
+	state := workdir.Join("sync.state")
+	src := filepath.ToSlash(workdir.Join("dir")) + "/"
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("--log", "debug", "sync", "--bidirectional", "--state-file", state, src+"*", dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`DEBUG "sync %vcommon.txt %vcommon.txt": object size matches`, src, dst),
+		1: equals(`cp %vlocal.txt %vlocal.txt`, src, dst),
+		2: equals(`cp %vremote.txt %vremote.txt`, dst, src),
+	}, sortInput(true))
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "local.txt", "only in local"))
+	assert.Assert(t, fs.Equal(workdir.Join("dir"), fs.Expected(t,
+		fs.WithMode(os.ModeDir|0755),
+		fs.WithFile("local.txt", "only in local"),
+		fs.WithFile("common.txt", "in both"),
+		fs.WithFile("remote.txt", "only in remote"),
+	)))
+
+	// change the objects on one side only. the changes are synced to the
+	// other side by the next run.
+	err := os.WriteFile(workdir.Join("dir", "common.txt"), []byte("changed in local"), 0644)
+	assert.NilError(t, err)
+	putFile(t, s3client, bucket, "remote.txt", "changed in remote")
+
+	cmd = s5cmd("--log", "debug", "sync", "--bidirectional", "--state-file", state, src+"*", dst)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`DEBUG "sync %vlocal.txt %vlocal.txt": object is not changed since the last sync`, src, dst),
+		1: equals(`cp %vcommon.txt %vcommon.txt`, src, dst),
+		2: equals(`cp %vremote.txt %vremote.txt`, dst, src),
+	}, sortInput(true))
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "common.txt", "changed in local"))
+	assert.Assert(t, fs.Equal(workdir.Join("dir"), fs.Expected(t,
+		fs.WithMode(os.ModeDir|0755),
+		fs.WithFile("local.txt", "only in local"),
+		fs.WithFile("common.txt", "changed in local"),
+		fs.WithFile("remote.txt", "changed in remote"),
+	)))
+}
+
+// sync --bidirectional --conflict error --state-file sync.state dir/ s3://bucket/*
+func TestSyncBidirectionalConflictError(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithDir("dir", fs.WithFile("common.txt", "in both")),
+	)
+	defer workdir.Remove()
+
+	putFile(t, s3client, bucket, "common.txt", "in both")
+
+	state := workdir.Join("sync.state")
+	src := filepath.ToSlash(workdir.Join("dir")) + "/"
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("sync", "--bidirectional", "--conflict", "error", "--state-file", state, src+"*", dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	// change the object on both sides.
+	err := os.WriteFile(workdir.Join("dir", "common.txt"), []byte("changed in local"), 0644)
+	assert.NilError(t, err)
+	putFile(t, s3client, bucket, "common.txt", "changed in remote")
+
+	cmd = s5cmd("sync", "--bidirectional", "--conflict", "error", "--state-file", state, src+"*", dst)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "sync --bidirectional=true --conflict=error --state-file=%v %v* %v": object 'common.txt' is changed on both sides`, state, src, dst),
+	})
+
+	// none of the sides is overwritten.
+	assert.Assert(t, ensureS3Object(s3client, bucket, "common.txt", "changed in remote"))
+	assert.Assert(t, fs.Equal(workdir.Join("dir"), fs.Expected(t,
+		fs.WithMode(os.ModeDir|0755),
+		fs.WithFile("common.txt", "changed in local"),
+	)))
+}
+
+func TestSyncBidirectionalValidation(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "delete",
+			args:     []string{"--bidirectional", "--delete"},
+			expected: "delete flag can not be used with bidirectional flag",
+		},
+		{
+			name:     "checkpoint",
+			args:     []string{"--bidirectional", "--checkpoint", "sync.checkpoint"},
+			expected: "checkpoint flag can not be used with bidirectional flag",
+		},
+		{
+			name:     "state file without bidirectional",
+			args:     []string{"--state-file", "sync.state"},
+			expected: "conflict and state-file flags can only be used with bidirectional flag",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			args := append([]string{"sync"}, tc.args...)
+			args = append(args, "dir/", "s3://bucket/")
+			cmd := s5cmd(args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: contains(tc.expected),
+			})
+		})
+	}
+}
+
+func TestSyncS3ObjectsToLocalWithOnErrorStop(t *testing.T) {
+	t.Parallel()
+
+	const numObjects = 20
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "dir/file.txt", "content")
+	for i := 0; i < numObjects; i++ {
+		putFile(t, s3client, bucket, fmt.Sprintf("file%02d.txt", i), "content")
+	}
+
+	// the first object can not be downloaded, since there is a file in place
+	// of its directory.
+	workdir := fs.NewDir(t, t.Name(), fs.WithFile("dir", ""))
+
+	cmd := s5cmd("--numworkers", "1", "sync", "--on-error", "stop", "s3://"+bucket+"/*", ".")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`ERROR "cp --raw=true --on-error=stop s3://%v/dir/file.txt dir/file.txt"`, bucket),
+	})
+
+	copied := 0
+	for _, line := range strings.Split(strings.TrimSpace(result.Stdout()), "\n") {
+		if strings.HasPrefix(line, "cp ") {
+			copied++
+		}
+	}
+	// the commands which are already running when the first one fails may
+	// still be completed.
+	assert.Assert(t, copied <= numObjects/2, "%v objects are copied", copied)
+}
+
+// --dry-run sync --delete dir/ s3://bucket/
+func TestSyncLocalToS3BucketDryRunWithReasons(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	timeSource := newFixedTimeSource(now)
+	s3client, s5cmd := setup(t, withTimeSource(timeSource))
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	newer := fs.WithTimestamps(now.Add(time.Minute), now.Add(time.Minute))
+	older := fs.WithTimestamps(now.Add(-time.Minute), now.Add(-time.Minute))
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithFile("new.txt", "S: new file"),
+		fs.WithFile("size.txt", "S: size differs", older),
+		fs.WithFile("newer.txt", "S: newer", newer),
+		fs.WithFile("same.txt", "S: same", older),
+	)
+	defer workdir.Remove()
+
+	putFile(t, s3client, bucket, "size.txt", "D: size")
+	putFile(t, s3client, bucket, "newer.txt", "D: newer")
+	putFile(t, s3client, bucket, "same.txt", "D: same")
+	putFile(t, s3client, bucket, "extra.txt", "D: extra")
+
+	src := filepath.ToSlash(workdir.Path()) + "/"
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("--dry-run", "sync", "--delete", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %vnew.txt %vnew.txt # new`, src, dst),
+		1: equals(`cp %vnewer.txt %vnewer.txt # newer`, src, dst),
+		2: equals(`cp %vsize.txt %vsize.txt # size-differs`, src, dst),
+		3: equals(`rm %vextra.txt # extra-delete`, dst),
+	}, sortInput(true))
+
+	cmd = s5cmd("--json", "--dry-run", "sync", "--delete", src, dst)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: contains(`"operation":"cp","success":true,"source":"%vnew.txt","destination":"%vnew.txt","reason":"new"`, src, dst),
+		1: contains(`"operation":"cp","success":true,"source":"%vnewer.txt","destination":"%vnewer.txt","reason":"newer"`, src, dst),
+		2: contains(`"operation":"cp","success":true,"source":"%vsize.txt","destination":"%vsize.txt","reason":"size-differs"`, src, dst),
+		3: contains(`"operation":"rm","success":true,"source":"%vextra.txt","reason":"extra-delete"`, dst),
+	}, sortInput(true))
+
+	// nothing is changed in dry-run mode.
+	for key, content := range map[string]string{
+		"size.txt":  "D: size",
+		"newer.txt": "D: newer",
+		"same.txt":  "D: same",
+		"extra.txt": "D: extra",
+	} {
+		assert.Assert(t, ensureS3Object(s3client, bucket, key, content))
+	}
+}
+
+// sync --compare etag dir/ s3://bucket/
+func TestSyncLocalFolderToS3BucketWithChecksumManifest(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithFile("a.txt", "content"),
+		fs.WithFile("b.txt", "content"),
+	)
+	defer workdir.Remove()
+
+	manifestdir := fs.NewDir(t, "manifest")
+	defer manifestdir.Remove()
+
+	manifest := manifestdir.Join("manifest.csv")
+
+	src := filepath.ToSlash(workdir.Path()) + "/"
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("sync", "--write-checksum-manifest", manifest, src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	got, err := os.ReadFile(manifest)
+	assert.NilError(t, err)
+
+	// the copy commands generated by sync write to the same manifest.
+	const row = "%v,7,9a0364b9e99bb480dd25e1f0284c8555,MD5:mgNkuembtIDdJeHwKEyFVQ=="
+	assertLines(t, string(got), map[int]compareFunc{
+		0: equals(row, "a.txt"),
+		1: equals(row, "b.txt"),
+		2: equals("key,size,etag,checksum"),
+	}, sortInput(true))
+}
+
+func TestSyncLocalFolderToS3BucketWithMetadataMerge(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	timeSource := newFixedTimeSource(now)
+	s3client, s5cmd := setup(t, withTimeSource(timeSource))
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	newer := fs.WithTimestamps(now.Add(time.Minute), now.Add(time.Minute))
+
+	workdir := fs.NewDir(t, "somedir", fs.WithFile("main.py", "updated content", newer))
+	defer workdir.Remove()
+
+	putFile(t, s3client, bucket, "main.py", "content", putArbitraryMetadata(map[string]*string{
+		"Reviewed-By": aws.String("someone"),
+	}))
+
+	src := filepath.ToSlash(workdir.Path()) + "/"
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("sync", "--metadata-merge", "--metadata", "Build=42", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %vmain.py %vmain.py`, src, dst),
+	})
+
+	// the content is updated and the metadata set by the others is kept.
+	assert.Assert(t, ensureS3Object(s3client, bucket, "main.py", "updated content",
+		ensureArbitraryMetadata(map[string]*string{
+			"Reviewed-By": aws.String("someone"),
+			"Build":       aws.String("42"),
+		}),
+	))
+}
+
+func TestSyncLocalFolderToS3BucketCompareEtag(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	timeSource := newFixedTimeSource(now)
+	s3client, s5cmd := setup(t, withTimeSource(timeSource))
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	newer := fs.WithTimestamps(now.Add(time.Minute), now.Add(time.Minute))
+	older := fs.WithTimestamps(now.Add(-time.Minute), now.Add(-time.Minute))
+
+	folderLayout := []fs.PathOp{
+		fs.WithFile("same.txt", "same content", newer),    // remote has it, same content.
+		fs.WithFile("differs.txt", "S: differs", older),   // remote has it, different content, same size.
+		fs.WithFile("size.txt", "S: size differs", older), // remote has it, different size.
+		fs.WithFile("new.txt", "S: new file", older),      // remote does not have it.
+	}
+
+	workdir := fs.NewDir(t, "somedir", folderLayout...)
+	defer workdir.Remove()
+
+	putFile(t, s3client, bucket, "same.txt", "same content")
+	putFile(t, s3client, bucket, "differs.txt", "D: differs")
+	putFile(t, s3client, bucket, "size.txt", "D: size")
+
+	src := filepath.ToSlash(workdir.Path()) + "/"
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("--dry-run", "sync", "--compare", "etag", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %vdiffers.txt %vdiffers.txt # etag-differs`, src, dst),
+		1: equals(`cp %vnew.txt %vnew.txt # new`, src, dst),
+		2: equals(`cp %vsize.txt %vsize.txt # size-differs`, src, dst),
+	}, sortInput(true))
+
+	cmd = s5cmd("--log", "debug", "sync", "--compare", "etag", src, dst)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`DEBUG "sync %vsame.txt %vsame.txt": object etag matches`, src, dst),
+		1: equals(`cp %vdiffers.txt %vdiffers.txt`, src, dst),
+		2: equals(`cp %vnew.txt %vnew.txt`, src, dst),
+		3: equals(`cp %vsize.txt %vsize.txt`, src, dst),
+	}, sortInput(true))
+
+	expectedS3Content := map[string]string{
+		"same.txt":    "same content",
+		"differs.txt": "S: differs",
+		"size.txt":    "S: size differs",
+		"new.txt":     "S: new file",
+	}
+	for key, content := range expectedS3Content {
+		assert.Assert(t, ensureS3Object(s3client, bucket, key, content))
+	}
+}
+
+// sync --compare etag --checksum-cache checksums dir/ s3://bucket/
+func TestSyncLocalFolderToS3BucketCompareEtagWithChecksumCache(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	timeSource := newFixedTimeSource(now)
+	s3client, s5cmd := setup(t, withTimeSource(timeSource))
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	newer := fs.WithTimestamps(now.Add(time.Minute), now.Add(time.Minute))
+
+	workdir := fs.NewDir(t, "somedir", fs.WithFile("same.txt", "same content", newer))
+	defer workdir.Remove()
+	cachedir := fs.NewDir(t, "cache")
+	defer cachedir.Remove()
+
+	putFile(t, s3client, bucket, "same.txt", "same content")
+
+	src := filepath.ToSlash(workdir.Path()) + "/"
+	dst := fmt.Sprintf("s3://%v/", bucket)
+	cache := filepath.Join(cachedir.Path(), "checksums")
+
+	cmd := s5cmd("--log", "debug", "sync", "--compare", "etag", "--checksum-cache", cache, src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`DEBUG "sync %vsame.txt %vsame.txt": object etag matches`, src, dst),
+	})
+
+	// the MD5 of the compared local file is cached.
+	content, err := os.ReadFile(cache)
+	assert.NilError(t, err)
+	assertLines(t, string(content), map[int]compareFunc{
+		0: match(`^{"path":".*same.txt",("dev":[0-9]+,"inode":[0-9]+,)?"size":12,"mod_time":[0-9]+,"md5":"793953ee398d864ec40252df9554c3e6"}$`),
+	})
+}
+
+func TestSyncCompareEtagValidation(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "size only",
+			args:     []string{"--compare", "etag", "--size-only", "dir/", "s3://bucket/"},
+			expected: `"compare" and "size-only" flags cannot be used together`,
+		},
+		{
+			name:     "bidirectional",
+			args:     []string{"--compare", "etag", "--bidirectional", "dir/", "s3://bucket/*"},
+			expected: `"compare" flag cannot be used with "bidirectional" flag`,
+		},
+		{
+			name:     "checksum cache without compare",
+			args:     []string{"--checksum-cache", "checksums", "dir/", "s3://bucket/"},
+			expected: `"checksum-cache" flag can only be used with "compare" or "track-renames" flags`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			cmd := s5cmd(append([]string{"sync"}, tc.args...)...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: contains("%v", tc.expected),
+			})
+		})
+	}
+}
+
+// sync --delete --track-renames dir/ s3://bucket/
+func TestSyncLocalFolderToS3BucketWithTrackRenames(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithDir("2023", fs.WithFile("report.txt", "report content")),
+		fs.WithFile("new.txt", "new content"),
+	)
+	defer workdir.Remove()
+
+	putFile(t, s3client, bucket, "report.txt", "report content")
+	putFile(t, s3client, bucket, "extra.txt", "extra content")
+
+	src := filepath.ToSlash(workdir.Path()) + "/"
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("--dry-run", "sync", "--delete", "--track-renames", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %vnew.txt %vnew.txt # new`, src, dst),
+		1: equals(`mv %vreport.txt %v2023/report.txt # renamed`, dst, dst),
+		2: equals(`rm %vextra.txt # extra-delete`, dst),
+	}, sortInput(true))
+
+	cmd = s5cmd("sync", "--delete", "--track-renames", src, dst)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	// the moved file is moved in the bucket with a server-side copy instead
+	// of being uploaded again.
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %vnew.txt %vnew.txt`, src, dst),
+		1: equals(`mv %vreport.txt %v2023/report.txt`, dst, dst),
+		2: equals(`rm %vextra.txt`, dst),
+	}, sortInput(true))
+
+	expectedS3Content := map[string]string{
+		"2023/report.txt": "report content",
+		"new.txt":         "new content",
+	}
+	for key, content := range expectedS3Content {
+		assert.Assert(t, ensureS3Object(s3client, bucket, key, content))
+	}
+
+	for _, key := range []string{"report.txt", "extra.txt"} {
+		assertError(t, ensureS3Object(s3client, bucket, key, ""), errS3NoSuchKey)
+	}
+}
+
+// sync --track-renames dir/ s3://bucket/
+func TestSyncLocalFolderToS3BucketWithTrackRenamesWithoutDelete(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, "somedir", fs.WithFile("renamed.txt", "content"))
+	defer workdir.Remove()
+
+	putFile(t, s3client, bucket, "original.txt", "content")
+
+	src := filepath.ToSlash(workdir.Path()) + "/"
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("sync", "--track-renames", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	// the object is copied instead of moved without delete flag.
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %voriginal.txt %vrenamed.txt`, dst, dst),
+	})
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "original.txt", "content"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "renamed.txt", "content"))
+}
+
+func TestSyncTrackRenamesValidation(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "local destination",
+			args:     []string{"--track-renames", "s3://bucket/*", "dir/"},
+			expected: `"track-renames" flag requires the destination to be remote`,
+		},
+		{
+			name:     "bidirectional",
+			args:     []string{"--track-renames", "--bidirectional", "dir/", "s3://bucket/*"},
+			expected: `"track-renames" flag cannot be used with "bidirectional" flag`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			cmd := s5cmd(append([]string{"sync"}, tc.args...)...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: contains("%v", tc.expected),
+			})
+		})
+	}
+}
+
+// --json sync --tune-report dir/ s3://bucket/
+func TestSyncLocalFolderToS3WithTuneReport(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd := setup(t)
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, t.Name(),
+		fs.WithFile("a.txt", "content"),
+		fs.WithFile("b.txt", "content"),
+	)
+	defer workdir.Remove()
+
+	src := fmt.Sprintf("%v/", workdir.Path())
+	dst := fmt.Sprintf("s3://%v/", bucket)
+	cmd := s5cmd("--json", "--numworkers", "4", "sync", "--tune-report", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	// the report is printed once for the whole sync.
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: prefix(`{"operation":"cp"`),
+		1: prefix(`{"operation":"cp"`),
+		2: match(`^{"operation":"sync","elapsed_seconds":[0-9.e-]+,"requests":[1-9][0-9]*,"throttled":0,"numworkers":4,.*"suggested_numworkers":4}$`),
+	}, sortInput(true))
+}
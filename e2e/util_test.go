@@ -4,6 +4,10 @@ package e2e
 
 import (
 	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/csv"
+	"encoding/hex"
 	jsonpkg "encoding/json"
 	"errors"
 	"flag"
@@ -39,6 +43,7 @@ import (
 	"github.com/igungor/gofakes3"
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/fs"
+	"gotest.tools/v3/golden"
 	"gotest.tools/v3/icmd"
 )
 
@@ -78,6 +83,7 @@ type setupOpts struct {
 	region      string
 	timeSource  gofakes3.TimeSource
 	enableProxy bool
+	pathPrefix  string
 }
 
 type option func(*setupOpts)
@@ -124,6 +130,14 @@ func withProxy() option {
 	}
 }
 
+// withEndpointPathPrefix serves the S3 server under the given path prefix,
+// e.g. "/s3", and appends it to the endpoint URL.
+func withEndpointPathPrefix(prefix string) option {
+	return func(opts *setupOpts) {
+		opts.pathPrefix = prefix
+	}
+}
+
 type credentialCfg struct {
 	AccessKeyID string
 	SecretKey   string
@@ -214,7 +228,7 @@ func server(t *testing.T, testdir *fs.Dir, opts *setupOpts) string {
 		s3LogLevel = "info" // aws has no level other than 'debug'
 	}
 
-	endpoint := s3ServerEndpoint(t, testdir, s3LogLevel, opts.s3backend, opts.timeSource, opts.enableProxy)
+	endpoint := s3ServerEndpoint(t, testdir, s3LogLevel, opts.s3backend, opts.timeSource, opts.enableProxy, opts.pathPrefix)
 
 	return endpoint
 }
@@ -573,6 +587,7 @@ type ensureOpts struct {
 	storageClass       *string
 	contentType        *string
 	contentDisposition *string
+	contentLanguage    *string
 	contentEncoding    *string
 	encryptionMethod   *string
 	encryptionKeyID    *string
@@ -611,6 +626,12 @@ func ensureContentDisposition(contentDisposition string) ensureOption {
 	}
 }
 
+func ensureContentLanguage(contentLanguage string) ensureOption {
+	return func(opts *ensureOpts) {
+		opts.contentLanguage = &contentLanguage
+	}
+}
+
 func ensureContentEncoding(contentEncoding string) ensureOption {
 	return func(opts *ensureOpts) {
 		opts.contentEncoding = &contentEncoding
@@ -702,6 +723,12 @@ func ensureS3Object(
 
 	}
 
+	if opts.contentLanguage != nil {
+		if diff := cmp.Diff(opts.contentLanguage, output.ContentLanguage); diff != "" {
+			return fmt.Errorf("content-language of %v/%v: (-want +got):\n%v", bucket, key, diff)
+		}
+	}
+
 	if opts.storageClass != nil {
 		if diff := cmp.Diff(opts.storageClass, output.StorageClass); diff != "" {
 			return fmt.Errorf("storage-class of %v/%v: (-want +got):\n%v", bucket, key, diff)
@@ -1194,3 +1221,92 @@ func indexSlice(slice []string, target string, fn func(str, target string) bool)
 	}
 	return -1
 }
+
+// putInventory creates a CSV S3 Inventory report of the given keys of the
+// source bucket and returns the key of its manifest.
+func putInventory(t *testing.T, client *s3.S3, bucket, sourceBucket string, keys map[string]int64) string {
+	t.Helper()
+
+	var records [][]string
+	for key, size := range keys {
+		records = append(records, []string{sourceBucket, urlpkg.QueryEscape(key), strconv.FormatInt(size, 10)})
+	}
+	return putInventoryRecords(t, client, bucket, sourceBucket, "Bucket, Key, Size", records)
+}
+
+// putInventoryRecords creates a CSV S3 Inventory report of the given records
+// in the given file schema and returns the key of its manifest.
+func putInventoryRecords(t *testing.T, client *s3.S3, bucket, sourceBucket, schema string, records [][]string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	csvw := csv.NewWriter(gzw)
+	for _, record := range records {
+		if err := csvw.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	csvw.Flush()
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	datakey := "inventory/data/report.csv.gz"
+	putFile(t, client, bucket, datakey, buf.String())
+
+	checksum := md5.Sum(buf.Bytes())
+	return putInventoryManifest(t, client, bucket, sourceBucket, "CSV", schema, datakey, buf.Len(), hex.EncodeToString(checksum[:]))
+}
+
+// parquetInventorySchema is the file schema of the Parquet S3 Inventory
+// report in testdata/inventory/report.parquet.
+const parquetInventorySchema = "message s3.inventory { required binary bucket (UTF8); required binary key (UTF8); optional binary version_id (UTF8); optional boolean is_latest; optional boolean is_delete_marker; optional int64 size; optional int64 last_modified_date (TIMESTAMP_MILLIS); optional binary e_tag (UTF8); optional binary storage_class (UTF8); }"
+
+// putParquetInventory creates the Parquet S3 Inventory report in
+// testdata/inventory/report.parquet and returns the key of its manifest. The
+// report has the records of "src/file1.txt", "src/dir/file 2.txt" and
+// "other/file3.txt", a noncurrent version of "src/old.txt" and a delete marker
+// of "src/deleted.txt". The MD5 checksum of the data file in the manifest is
+// computed if the given checksum is empty.
+func putParquetInventory(t *testing.T, client *s3.S3, bucket, sourceBucket, checksum string) string {
+	t.Helper()
+
+	data := golden.Get(t, filepath.Join("inventory", "report.parquet"))
+
+	datakey := "inventory/data/report.parquet"
+	putFile(t, client, bucket, datakey, string(data))
+
+	if checksum == "" {
+		sum := md5.Sum(data)
+		checksum = hex.EncodeToString(sum[:])
+	}
+	return putInventoryManifest(t, client, bucket, sourceBucket, "Parquet", parquetInventorySchema, datakey, len(data), checksum)
+}
+
+// putInventoryManifest creates the manifest of an S3 Inventory report of the
+// given data file and returns its key.
+func putInventoryManifest(t *testing.T, client *s3.S3, bucket, sourceBucket, format, schema, datakey string, size int, checksum string) string {
+	t.Helper()
+
+	manifest, err := jsonpkg.Marshal(map[string]interface{}{
+		"sourceBucket":      sourceBucket,
+		"destinationBucket": "arn:aws:s3:::" + bucket,
+		"fileFormat":        format,
+		"fileSchema":        schema,
+		"files": []map[string]interface{}{
+			{
+				"key":         datakey,
+				"size":        size,
+				"MD5checksum": checksum,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifestkey := "inventory/manifest.json"
+	putFile(t, client, bucket, manifestkey, string(manifest))
+	return manifestkey
+}
@@ -0,0 +1,215 @@
+package e2e
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/icmd"
+)
+
+// verify dir/ s3://bucket/prefix/
+func TestVerifyLocalFolderWithS3Prefix(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithFile("same.txt", "same content"),
+		fs.WithFile("resized.txt", "this file is resized"),
+		fs.WithFile("missing.txt", "not uploaded"),
+		fs.WithDir("nested",
+			fs.WithFile("same.txt", "nested content"),
+		),
+	)
+	defer workdir.Remove()
+
+	putFile(t, s3client, bucket, "backup/same.txt", "same content")
+	putFile(t, s3client, bucket, "backup/resized.txt", "resized")
+	putFile(t, s3client, bucket, "backup/nested/same.txt", "nested content")
+	// the objects only in destination are not verified.
+	putFile(t, s3client, bucket, "backup/extra.txt", "only in destination")
+
+	src := filepath.ToSlash(workdir.Path()) + "/"
+	dst := fmt.Sprintf("s3://%v/backup/", bucket)
+
+	cmd := s5cmd("verify", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`verify %vmissing.txt %vmissing.txt # missing`, src, dst),
+		1: equals(`verify %vresized.txt %vresized.txt # size-differs`, src, dst),
+		2: equals(`verify: 2 objects verified, 2 discrepancies found`),
+	}, sortInput(true))
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "verify %v %v": 2 objects failed verification`, src, dst),
+	})
+}
+
+// verify dir/ s3://bucket/prefix/
+func TestVerifyLocalFolderWithS3PrefixAllMatch(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithFile("a.txt", "content of a"),
+		fs.WithFile("b.log", "not uploaded"),
+	)
+	defer workdir.Remove()
+
+	putFile(t, s3client, bucket, "a.txt", "content of a")
+
+	src := filepath.ToSlash(workdir.Path()) + "/"
+
+	// the excluded files are not verified.
+	cmd := s5cmd("verify", "--checksum", "--exclude", "*.log", src, "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`verify: 1 objects verified, 0 discrepancies found`),
+	})
+}
+
+// --json verify --checksum dir/ s3://bucket/
+func TestVerifyLocalFolderWithS3PrefixChecksumJSON(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithFile("same.txt", "same content"),
+		fs.WithFile("changed.txt", "new content"),
+	)
+	defer workdir.Remove()
+
+	putFile(t, s3client, bucket, "same.txt", "same content")
+	// same size, different content.
+	putFile(t, s3client, bucket, "changed.txt", "old content")
+
+	src := filepath.ToSlash(workdir.Path()) + "/"
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	// the sizes match, the discrepancy is found only by the checksums.
+	cmd := s5cmd("verify", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	cmd = s5cmd("--json", "verify", "--checksum", src, dst)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`{"operation":"verify","source":"%vchanged.txt","destination":"%vchanged.txt","reason":"etag-differs","source_size":11,"destination_size":11}`, src, dst),
+		1: equals(`{"source":"%v","destination":"%v","verified":1,"discrepancies":1}`, src, dst),
+	}, jsonCheck(true))
+}
+
+// verify --checksum --checksum-metadata-key x-amz-meta-sha256 dir/ s3://bucket/
+func TestVerifyLocalFolderWithS3PrefixChecksumMetadataKey(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd := setup(t)
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	sha256sum := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithFile("same.txt", "same content"),
+		fs.WithFile("changed.txt", "new content"),
+		fs.WithFile("nodigest.txt", "no digest"),
+	)
+	defer workdir.Remove()
+
+	putFile(t, s3client, bucket, "same.txt", "same content", putArbitraryMetadata(map[string]*string{
+		"Sha256": aws.String(sha256sum("same content")),
+	}))
+	// the stored digest is trusted even if the content is the same.
+	putFile(t, s3client, bucket, "changed.txt", "new content", putArbitraryMetadata(map[string]*string{
+		"Sha256": aws.String(sha256sum("old content")),
+	}))
+	putFile(t, s3client, bucket, "nodigest.txt", "no digest")
+
+	src := filepath.ToSlash(workdir.Path()) + "/"
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("verify", "--checksum", "--checksum-metadata-key", "x-amz-meta-sha256", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`verify %vchanged.txt %vchanged.txt # checksum-differs`, src, dst),
+		1: equals(`verify %vnodigest.txt %vnodigest.txt # checksum-missing`, src, dst),
+		2: equals(`verify: 1 objects verified, 2 discrepancies found`),
+	}, sortInput(true))
+}
+
+func TestVerifyValidation(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "remote source",
+			args:     []string{"verify", "s3://bucket/prefix/", "s3://bucket/backup/"},
+			expected: `source must be a local directory`,
+		},
+		{
+			name:     "local destination",
+			args:     []string{"verify", "dir/", "backup/"},
+			expected: `destination must be a bucket or a prefix`,
+		},
+		{
+			name:     "checksum metadata key without checksum",
+			args:     []string{"verify", "--checksum-metadata-key", "x-amz-meta-sha256", "dir/", "s3://bucket/"},
+			expected: `"checksum-metadata-key" flag can only be used with "checksum" flag`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd := setup(t)
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: contains(tc.expected),
+			})
+		})
+	}
+}
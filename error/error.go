@@ -26,6 +26,9 @@ type Error struct {
 
 // FullCommand returns the command string that occurred at.
 func (e *Error) FullCommand() string {
+	if e.Dst == nil {
+		return fmt.Sprintf("%v %v", e.Op, e.Src)
+	}
 	return fmt.Sprintf("%v %v %v", e.Op, e.Src, e.Dst)
 }
 
@@ -79,13 +82,47 @@ var (
 
 	// ErrObjectIsNewerAndSizesMatch indicates the specified object is newer or same age and sizes of objects match.
 	ErrObjectIsNewerAndSizesMatch = fmt.Errorf("%v and %v", ErrObjectIsNewer, ErrObjectSizesMatch)
+
+	// ErrObjectEtagsMatch indicates the ETag of the object matches the MD5 of
+	// the other object.
+	ErrObjectEtagsMatch = fmt.Errorf("object etag matches")
+
+	// ErrObjectChecksumsMatch indicates the digest stored in the metadata of
+	// the object matches the digest of the other object.
+	ErrObjectChecksumsMatch = fmt.Errorf("object checksum matches")
+
+	// ErrObjectCheckpointed indicates the object is already synced by a
+	// previous run with the same checkpoint.
+	ErrObjectCheckpointed = fmt.Errorf("object is synced by a previous run")
+
+	// ErrObjectConflict indicates the destination object is created or
+	// modified by another writer, i.e. the write condition did not hold.
+	ErrObjectConflict = fmt.Errorf("object is created or modified by another writer")
+
+	// ErrObjectNotModified indicates the source object is not modified
+	// according to the download conditions.
+	ErrObjectNotModified = fmt.Errorf("object is not modified")
+
+	// ErrObjectUnchanged indicates the object is changed on neither side of a
+	// bidirectional sync since the last sync.
+	ErrObjectUnchanged = fmt.Errorf("object is not changed since the last sync")
+
+	// ErrObjectChangedOnBothSides indicates the object is changed on both
+	// sides of a bidirectional sync, and the conflict is skipped.
+	ErrObjectChangedOnBothSides = fmt.Errorf("object is changed on both sides")
+
+	// ErrObjectEmpty indicates the source object is skipped since it's
+	// empty.
+	ErrObjectEmpty = fmt.Errorf("object is empty")
 )
 
 // IsWarning checks if given error is either ErrObjectExists,
-// ErrObjectIsNewer or ErrObjectSizesMatch.
+// ErrObjectIsNewer, ErrObjectSizesMatch, ErrObjectEtagsMatch,
+// ErrObjectChecksumsMatch, ErrObjectCheckpointed, ErrObjectConflict, ErrObjectNotModified,
+// ErrObjectUnchanged, ErrObjectChangedOnBothSides or ErrObjectEmpty.
 func IsWarning(err error) bool {
 	switch err {
-	case ErrObjectExists, ErrObjectIsNewer, ErrObjectSizesMatch, ErrObjectIsNewerAndSizesMatch:
+	case ErrObjectExists, ErrObjectIsNewer, ErrObjectSizesMatch, ErrObjectIsNewerAndSizesMatch, ErrObjectEtagsMatch, ErrObjectChecksumsMatch, ErrObjectCheckpointed, ErrObjectConflict, ErrObjectNotModified, ErrObjectUnchanged, ErrObjectChangedOnBothSides, ErrObjectEmpty:
 		return true
 	}
 
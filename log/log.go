@@ -1,13 +1,17 @@
 package log
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
 )
 
 // output is an internal container for messages to be logged.
 type output struct {
-	std     *os.File
+	std     io.Writer
 	message string
 }
 
@@ -17,6 +21,44 @@ var outputCh = make(chan output, 10000)
 
 var global *Logger
 
+// stdout is the destination of the messages which are not errors. It is the
+// standard output unless it is redirected by SetOutput.
+var stdout io.Writer = os.Stdout
+
+// SetOutput redirects the messages which are not errors, e.g. the listed
+// objects, to the given writer. Errors are still printed to standard error.
+func SetOutput(w io.Writer) {
+	stdout = w
+}
+
+// Output returns the writer of the messages which are not errors.
+func Output() io.Writer {
+	return stdout
+}
+
+// onlyErrors is set if nothing but the errors are printed.
+var onlyErrors bool
+
+// SetOnlyErrors makes the logger print nothing but the errors regardless of
+// the log level, e.g. no statistics or warnings either.
+func SetOnlyErrors(b bool) {
+	onlyErrors = b
+}
+
+// OnlyErrors reports whether nothing but the errors are printed.
+func OnlyErrors() bool {
+	return onlyErrors
+}
+
+// Warnings returns the writer of the warnings. It is the standard error
+// unless only the errors are printed.
+func Warnings() io.Writer {
+	if onlyErrors {
+		return io.Discard
+	}
+	return os.Stderr
+}
+
 // Init inits global logger.
 func Init(level string, json bool) {
 	global = New(level, json)
@@ -24,23 +66,36 @@ func Init(level string, json bool) {
 
 // Trace prints message in trace mode.
 func Trace(msg Message) {
-	global.printf(LevelTrace, msg, os.Stdout)
+	global.printf(LevelTrace, msg, stdout)
 }
 
 // Debug prints message in debug mode.
 func Debug(msg Message) {
-	global.printf(LevelDebug, msg, os.Stdout)
+	global.printf(LevelDebug, msg, stdout)
 }
 
 // Info prints message in info mode.
 func Info(msg Message) {
-	global.printf(LevelInfo, msg, os.Stdout)
+	global.printf(LevelInfo, msg, stdout)
 }
 
 // Stat prints stat message regardless of the log level with info print formatting.
 // It uses printfHelper instead of printf to ignore the log level condition.
 func Stat(msg Message) {
-	global.printfHelper(LevelInfo, msg, os.Stdout)
+	if onlyErrors {
+		return
+	}
+	global.printfHelper(LevelInfo, msg, stdout)
+}
+
+// Request prints the trace of a request regardless of the log level with
+// debug formatting. It is printed to standard error, so that it never mixes
+// with the output of the command.
+func Request(msg Message) {
+	if onlyErrors {
+		return
+	}
+	global.printfHelper(LevelDebug, msg, os.Stderr)
 }
 
 // Error prints message in error mode.
@@ -48,6 +103,67 @@ func Error(msg Message) {
 	global.printf(LevelError, msg, os.Stderr)
 }
 
+// commandIDKey is the key of the command ID in the contexts.
+type commandIDKey struct{}
+
+// WithCommandID returns a copy of the given context which carries the given
+// command ID, e.g. the line number of a command run in a batch. The messages
+// printed with the context are prefixed with the command ID.
+func WithCommandID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, commandIDKey{}, id)
+}
+
+// DebugContext is like Debug, but the message is tagged with the command ID
+// of the given context, if there is one.
+func DebugContext(ctx context.Context, msg Message) {
+	Debug(withCommandID(ctx, msg))
+}
+
+// InfoContext is like Info, but the message is tagged with the command ID of
+// the given context, if there is one.
+func InfoContext(ctx context.Context, msg Message) {
+	Info(withCommandID(ctx, msg))
+}
+
+// ErrorContext is like Error, but the message is tagged with the command ID
+// of the given context, if there is one.
+func ErrorContext(ctx context.Context, msg Message) {
+	Error(withCommandID(ctx, msg))
+}
+
+// taggedMessage is a message of the command with the given ID.
+type taggedMessage struct {
+	Message
+	id int
+}
+
+// withCommandID returns the given message tagged with the command ID of the
+// given context, or the message itself if there is none.
+func withCommandID(ctx context.Context, msg Message) Message {
+	if ctx == nil {
+		return msg
+	}
+	id, ok := ctx.Value(commandIDKey{}).(int)
+	if !ok {
+		return msg
+	}
+	return taggedMessage{Message: msg, id: id}
+}
+
+// JSON is the JSON representation of the message with a command_id field.
+func (t taggedMessage) JSON() string {
+	s := t.Message.JSON()
+	if !strings.HasPrefix(s, "{") {
+		return s
+	}
+
+	field := `{"command_id":` + strconv.Itoa(t.id)
+	if strings.TrimSpace(s[1:]) == "}" {
+		return field + "}"
+	}
+	return field + "," + s[1:]
+}
+
 // Close closes logger and its channel.
 func Close() {
 	if global != nil {
@@ -76,22 +192,28 @@ func New(level string, json bool) *Logger {
 }
 
 // printf prints message according to the given level, message and std mode.
-func (l *Logger) printf(level LogLevel, message Message, std *os.File) {
-	if level < l.level {
+func (l *Logger) printf(level LogLevel, message Message, std io.Writer) {
+	if level < l.level || (onlyErrors && level < LevelError) {
 		return
 	}
 	l.printfHelper(level, message, std)
 }
 
-func (l *Logger) printfHelper(level LogLevel, message Message, std *os.File) {
+func (l *Logger) printfHelper(level LogLevel, message Message, std io.Writer) {
 	if l.json {
 		outputCh <- output{
 			message: message.JSON(),
 			std:     std,
 		}
 	} else {
+		// the command ID precedes the level, so that all the lines of a
+		// command start with the same prefix.
+		var prefix string
+		if t, ok := message.(taggedMessage); ok {
+			prefix = fmt.Sprintf("[%v] ", t.id)
+		}
 		outputCh <- output{
-			message: fmt.Sprintf("%v%v", level, message.String()),
+			message: fmt.Sprintf("%v%v%v", prefix, level, message.String()),
 			std:     std,
 		}
 	}
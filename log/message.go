@@ -2,6 +2,7 @@ package log
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/peak/s5cmd/v2/storage/url"
 	"github.com/peak/s5cmd/v2/strutil"
@@ -21,6 +22,9 @@ type InfoMessage struct {
 	Destination *url.URL `json:"destination,omitempty"`
 	Object      Message  `json:"object,omitempty"`
 
+	// Reason is the reason of the operation, e.g. why an object is synced.
+	Reason string `json:"reason,omitempty"`
+
 	// the VersionID field exist only for JSON Marshall, it must not be used for
 	// any other purpose.
 	VersionID string `json:"version_id,omitempty"`
@@ -28,16 +32,22 @@ type InfoMessage struct {
 
 // String is the string representation of InfoMessage.
 func (i InfoMessage) String() string {
-	if i.Source != nil && i.Destination != nil {
-		return fmt.Sprintf("%v %v %v", i.Operation, i.Source, i.Destination)
-	}
-	if i.Source != nil && i.Source.VersionID != "" {
-		return fmt.Sprintf("%v %-50v %v", i.Operation, i.Source, i.Source.VersionID)
+	var s string
+	switch {
+	case i.Source != nil && i.Destination != nil:
+		s = fmt.Sprintf("%v %v %v", i.Operation, i.Source, i.Destination)
+	case i.Source != nil && i.Source.VersionID != "":
+		s = fmt.Sprintf("%v %-50v %v", i.Operation, i.Source, i.Source.VersionID)
+	case i.Destination != nil:
+		s = fmt.Sprintf("%v %v", i.Operation, i.Destination)
+	default:
+		s = fmt.Sprintf("%v %v", i.Operation, i.Source)
 	}
-	if i.Destination != nil {
-		return fmt.Sprintf("%v %v", i.Operation, i.Destination)
+
+	if i.Reason != "" {
+		s += fmt.Sprintf("  # %v", i.Reason)
 	}
-	return fmt.Sprintf("%v %v", i.Operation, i.Source)
+	return s
 }
 
 // JSON is the JSON representation of InfoMessage.
@@ -100,3 +110,46 @@ func (d DebugMessage) String() string {
 func (d DebugMessage) JSON() string {
 	return strutil.JSON(d)
 }
+
+// RequestMessage is the trace of a request attempt sent to the remote
+// storage.
+type RequestMessage struct {
+	Operation string        `json:"operation"`
+	Bucket    string        `json:"bucket,omitempty"`
+	Key       string        `json:"key,omitempty"`
+	Status    int           `json:"status"`
+	RequestID string        `json:"request_id,omitempty"`
+	Duration  time.Duration `json:"-"`
+	Retries   int           `json:"retries"`
+	Err       string        `json:"error,omitempty"`
+
+	// the DurationMillis field exist only for JSON Marshall, it must not be
+	// used for any other purpose.
+	DurationMillis int64 `json:"duration_ms"`
+}
+
+// String is the string representation of RequestMessage.
+func (r RequestMessage) String() string {
+	target := "-"
+	if r.Bucket != "" {
+		target = fmt.Sprintf("s3://%v/%v", r.Bucket, r.Key)
+	}
+
+	requestID := r.RequestID
+	if requestID == "" {
+		requestID = "-"
+	}
+
+	s := fmt.Sprintf("request %v %v status=%v request_id=%v duration=%v retries=%v",
+		r.Operation, target, r.Status, requestID, r.Duration.Round(time.Millisecond), r.Retries)
+	if r.Err != "" {
+		s += fmt.Sprintf(" error=%v", r.Err)
+	}
+	return s
+}
+
+// JSON is the JSON representation of RequestMessage.
+func (r RequestMessage) JSON() string {
+	r.DurationMillis = r.Duration.Milliseconds()
+	return strutil.JSON(r)
+}
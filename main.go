@@ -2,18 +2,44 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/peak/s5cmd/v2/command"
+	"github.com/peak/s5cmd/v2/log"
+	"github.com/peak/s5cmd/v2/parallel"
 )
 
 func main() {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	go handleSignals(cancel)
+
 	if err := command.Main(ctx, os.Args); err != nil {
 		os.Exit(1)
 	}
 }
+
+// handleSignals shuts down the workers on the first interrupt signal, so that
+// the running transfers are finished but no new transfers are started. The
+// running transfers are canceled, and the incomplete multipart uploads are
+// aborted, on the second interrupt signal or on a termination signal.
+func handleSignals(cancel context.CancelFunc) {
+	sigch := make(chan os.Signal, 1)
+	signal.Notify(sigch, os.Interrupt, syscall.SIGTERM)
+
+	for sig := range sigch {
+		if sig == os.Interrupt && !parallel.IsShutdown() {
+			parallel.Shutdown()
+			_, _ = fmt.Fprintln(log.Warnings(), "interrupted: waiting for the running transfers to finish, interrupt again to cancel them")
+			continue
+		}
+
+		cancel()
+		signal.Stop(sigch)
+		return
+	}
+}
@@ -0,0 +1,128 @@
+package parallel
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// adaptiveWindow is the number of the request outcomes which are
+	// evaluated together.
+	adaptiveWindow = 20
+	// adaptiveMaxOverloaded is the number of the overloaded requests in a
+	// window which is tolerated, i.e. 10% of the window. The concurrency is
+	// reduced once it's exceeded.
+	adaptiveMaxOverloaded = adaptiveWindow / 10
+	// adaptiveRecoveryInterval is the interval of increasing the concurrency
+	// by one while the requests are not overloaded.
+	adaptiveRecoveryInterval = time.Second
+)
+
+// adaptive limits the number of the tasks which are run concurrently by a
+// Manager by the outcomes of the requests, in an AIMD fashion. The limit is
+// halved when too many requests of a window are throttled or failed with 5xx
+// errors, and then it's increased by one at each recovery interval until all
+// the workers are used again. Retrying at full concurrency during a partial
+// outage makes the throttling worse.
+type adaptive struct {
+	workers  int
+	interval time.Duration
+
+	mu    sync.Mutex
+	limit int
+	// changed is the time when the limit is last changed.
+	changed time.Time
+	// requests and overloaded are the number of the requests in the current
+	// window and the overloaded ones among them.
+	requests   int
+	overloaded int
+}
+
+// newAdaptive creates an adaptive limit which starts with all the given
+// workers.
+func newAdaptive(workers int, interval time.Duration) *adaptive {
+	return &adaptive{
+		workers:  workers,
+		interval: interval,
+		limit:    workers,
+	}
+}
+
+// record records the outcome of a request completed at the given time. The
+// limit is halved if too many requests of the current window are overloaded.
+func (a *adaptive) record(overloaded bool, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.recover(now)
+
+	a.requests++
+	if overloaded {
+		a.overloaded++
+	}
+
+	switch {
+	case a.overloaded > adaptiveMaxOverloaded:
+		a.limit /= 2
+		if a.limit < 1 {
+			a.limit = 1
+		}
+		a.changed = now
+		a.requests, a.overloaded = 0, 0
+	case a.requests >= adaptiveWindow:
+		a.requests, a.overloaded = 0, 0
+	}
+}
+
+// limitAt returns the number of the tasks which may run concurrently at the
+// given time.
+func (a *adaptive) limitAt(now time.Time) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.recover(now)
+	return a.limit
+}
+
+// recover increases the limit by one for each recovery interval elapsed since
+// the limit is last changed. The limit recovers by the time rather than by the
+// successful requests, so that it's increased even if no tasks are running.
+func (a *adaptive) recover(now time.Time) {
+	if a.limit >= a.workers {
+		return
+	}
+
+	steps := int(now.Sub(a.changed) / a.interval)
+	if steps <= 0 {
+		return
+	}
+
+	a.limit += steps
+	if a.limit > a.workers {
+		a.limit = a.workers
+	}
+	a.changed = a.changed.Add(time.Duration(steps) * a.interval)
+}
+
+// wait blocks until the given number of the running tasks, including the
+// task which is about to run, is allowed by the limit, or Shutdown is called.
+func (a *adaptive) wait(running func() int) {
+	for running() > a.limitAt(time.Now()) {
+		select {
+		case <-shutdownCh:
+			return
+		case <-time.After(minRampStep):
+		}
+	}
+}
+
+// ReportRequest records the outcome of a request for the adaptive concurrency
+// of the global manager. overloaded is set if the request is throttled or
+// failed with a 5xx error. It's a no-op unless the adaptive concurrency is
+// enabled.
+func ReportRequest(overloaded bool) {
+	if global == nil || global.adaptive == nil {
+		return
+	}
+	global.adaptive.record(overloaded, time.Now())
+}
@@ -0,0 +1,127 @@
+package parallel
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLimit(t *testing.T) {
+	const interval = time.Second
+
+	a := newAdaptive(8, interval)
+	now := time.Now()
+
+	record := func(n int, overloaded bool) {
+		for i := 0; i < n; i++ {
+			a.record(overloaded, now)
+		}
+	}
+	expectLimit := func(expected int) {
+		t.Helper()
+		if got := a.limitAt(now); got != expected {
+			t.Fatalf("expected limit %v, got %v", expected, got)
+		}
+	}
+
+	// a few overloaded requests in a window are tolerated.
+	record(adaptiveWindow-adaptiveMaxOverloaded, false)
+	record(adaptiveMaxOverloaded, true)
+	expectLimit(8)
+
+	// the limit is halved for each window with too many overloaded requests
+	// during the throttling.
+	record(adaptiveMaxOverloaded+1, true)
+	expectLimit(4)
+	record(adaptiveMaxOverloaded+1, true)
+	expectLimit(2)
+	record(adaptiveMaxOverloaded+1, true)
+	expectLimit(1)
+	record(adaptiveMaxOverloaded+1, true)
+	expectLimit(1)
+
+	// the limit is increased by one for each recovery interval once the
+	// throttling is over.
+	record(adaptiveWindow, false)
+	expectLimit(1)
+
+	now = now.Add(interval)
+	expectLimit(2)
+
+	now = now.Add(interval / 2)
+	expectLimit(2)
+
+	now = now.Add(interval / 2)
+	expectLimit(3)
+
+	// another throttling window interrupts the recovery.
+	record(adaptiveMaxOverloaded+1, true)
+	expectLimit(1)
+
+	now = now.Add(interval)
+	expectLimit(2)
+
+	now = now.Add(time.Minute)
+	expectLimit(8)
+}
+
+func TestManagerAdaptive(t *testing.T) {
+	const numWorkers = 4
+
+	manager := New(numWorkers)
+	manager.adaptive = newAdaptive(numWorkers, 200*time.Millisecond)
+	waiter := NewWaiter()
+
+	// the requests are throttled before the tasks are run.
+	for i := 0; i < 2*(adaptiveMaxOverloaded+1); i++ {
+		manager.adaptive.record(true, time.Now())
+	}
+	if got := manager.adaptive.limitAt(time.Now()); got != 1 {
+		t.Fatalf("expected limit 1 after throttling, got %v", got)
+	}
+
+	var running atomic.Int64
+	blockCh := make(chan struct{})
+	task := func() error {
+		running.Add(1)
+		defer running.Add(-1)
+		<-blockCh
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range waiter.Err() {
+		}
+	}()
+
+	start := time.Now()
+	go func() {
+		for i := 0; i < numWorkers; i++ {
+			manager.Run(task, waiter)
+		}
+	}()
+
+	// a single task is run while the requests are throttled.
+	time.Sleep(100 * time.Millisecond)
+	if got := running.Load(); got != 1 {
+		t.Fatalf("expected 1 task running during throttling, got %v", got)
+	}
+
+	// all the workers are used again once the limit is recovered.
+	for running.Load() < numWorkers {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("expected %v tasks running after the recovery, got %v", numWorkers, running.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("expected the workers to be recovered gradually, took %v", elapsed)
+	}
+
+	close(blockCh)
+	manager.Close()
+	waiter.Wait()
+	<-done
+}
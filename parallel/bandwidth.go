@@ -0,0 +1,185 @@
+package parallel
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// minBandwidthStep and maxBandwidthStep are the bounds of the interval of
+	// checking whether a transfer has got enough tokens to continue.
+	minBandwidthStep = 5 * time.Millisecond
+	maxBandwidthStep = 100 * time.Millisecond
+)
+
+var bandwidth *BandwidthLimiter
+
+// LimitBandwidth limits the number of the bytes transferred per second by all
+// the transfers started with StartTransfer to the given rate.
+func LimitBandwidth(rate int64) {
+	bandwidth = NewBandwidthLimiter(rate)
+}
+
+// StartTransfer starts a transfer of the global bandwidth limiter with the
+// given weight. It returns nil if the bandwidth is not limited, which never
+// waits.
+func StartTransfer(weight int) *Transfer {
+	if bandwidth == nil {
+		return nil
+	}
+	return bandwidth.Start(weight)
+}
+
+// BandwidthLimiter limits the number of the bytes transferred per second by
+// all of its transfers together. The bandwidth is shared between the
+// transfers which are waiting for it in proportion to their weights, so that
+// a huge transfer with many parts in flight does not starve the small ones,
+// or vice versa. The bandwidth which is not used by a transfer is shared by
+// the others.
+type BandwidthLimiter struct {
+	rate int64
+
+	mu        sync.Mutex
+	last      time.Time
+	transfers map[*Transfer]struct{}
+}
+
+// Transfer is a transfer of a BandwidthLimiter.
+type Transfer struct {
+	limiter *BandwidthLimiter
+	weight  int
+
+	// tokens is the number of the bytes which the transfer may transfer
+	// without waiting.
+	tokens float64
+	// waiting is the number of the goroutines of the transfer, e.g. of the
+	// parts of a multipart upload, which are waiting for tokens.
+	waiting int
+}
+
+// NewBandwidthLimiter creates a new BandwidthLimiter which allows the given
+// number of bytes per second.
+func NewBandwidthLimiter(rate int64) *BandwidthLimiter {
+	return &BandwidthLimiter{
+		rate:      rate,
+		last:      time.Now(),
+		transfers: map[*Transfer]struct{}{},
+	}
+}
+
+// Start starts a transfer with the given weight, which must be finished with
+// Done.
+func (l *BandwidthLimiter) Start(weight int) *Transfer {
+	if weight < 1 {
+		weight = 1
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	t := &Transfer{limiter: l, weight: weight}
+	l.transfers[t] = struct{}{}
+	return t
+}
+
+// refill distributes the tokens of the time elapsed since the last refill
+// between the waiting transfers in proportion to their weights. The tokens
+// are not accumulated while no transfer is waiting, so the bandwidth is not
+// exceeded by a burst after an idle period.
+func (l *BandwidthLimiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last)
+	if elapsed <= 0 {
+		return
+	}
+	l.last = now
+
+	weights := l.waitingWeights()
+	if weights == 0 {
+		return
+	}
+
+	tokens := float64(l.rate) * elapsed.Seconds()
+	for t := range l.transfers {
+		if t.waiting > 0 {
+			t.tokens += tokens * float64(t.weight) / float64(weights)
+		}
+	}
+}
+
+// waitingWeights returns the total weight of the waiting transfers.
+func (l *BandwidthLimiter) waitingWeights() int {
+	var weights int
+	for t := range l.transfers {
+		if t.waiting > 0 {
+			weights += t.weight
+		}
+	}
+	return weights
+}
+
+// Wait blocks until the transfer is allowed to transfer the given number of
+// bytes, or Shutdown is called.
+func (t *Transfer) Wait(n int64) {
+	if t == nil || n <= 0 {
+		return
+	}
+
+	l := t.limiter
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// the tokens of the time elapsed before the transfer started waiting
+	// belong to the other transfers.
+	l.refill(time.Now())
+	t.waiting++
+	defer func() { t.waiting-- }()
+
+	remaining := float64(n)
+	for {
+		take := t.tokens
+		if take > remaining {
+			take = remaining
+		}
+		t.tokens -= take
+		remaining -= take
+		if remaining <= 0 {
+			return
+		}
+
+		// the time it takes to get the remaining tokens with the current
+		// share of the transfer.
+		share := float64(l.rate) * float64(t.weight) / float64(l.waitingWeights())
+		step := time.Duration(remaining / share * float64(time.Second))
+		if step < minBandwidthStep {
+			step = minBandwidthStep
+		}
+		if step > maxBandwidthStep {
+			step = maxBandwidthStep
+		}
+
+		l.mu.Unlock()
+		select {
+		case <-shutdownCh:
+			l.mu.Lock()
+			return
+		case <-time.After(step):
+		}
+		l.mu.Lock()
+		l.refill(time.Now())
+	}
+}
+
+// Done finishes the transfer.
+func (t *Transfer) Done() {
+	if t == nil {
+		return
+	}
+
+	l := t.limiter
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	delete(l.transfers, t)
+}
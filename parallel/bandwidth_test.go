@@ -0,0 +1,115 @@
+package parallel
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBandwidthLimiterMixedSizes(t *testing.T) {
+	const (
+		rate      = 1024 * 1024
+		largeSize = 1024 * 1024
+		smallSize = 64 * 1024
+		numSmall  = 4
+	)
+
+	limiter := NewBandwidthLimiter(rate)
+
+	// transfer waits for the given size in chunks as a copy does, and returns
+	// the time it's finished.
+	transfer := func(size, chunk int64) time.Time {
+		tr := limiter.Start(1)
+		defer tr.Done()
+		for size > 0 {
+			n := chunk
+			if n > size {
+				n = size
+			}
+			tr.Wait(n)
+			size -= n
+		}
+		return time.Now()
+	}
+
+	start := time.Now()
+
+	var (
+		wg        sync.WaitGroup
+		largeDone time.Time
+		smallDone [numSmall]time.Time
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		largeDone = transfer(largeSize, 256*1024)
+	}()
+	for i := 0; i < numSmall; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			smallDone[i] = transfer(smallSize, 8*1024)
+		}(i)
+	}
+	wg.Wait()
+
+	// each of the small transfers makes progress with its share of the
+	// bandwidth while the large one is running.
+	for i, done := range smallDone {
+		if !done.Before(largeDone) {
+			t.Errorf("small transfer %d is finished after the large transfer", i)
+		}
+		if elapsed := done.Sub(start); elapsed > 800*time.Millisecond {
+			t.Errorf("small transfer %d took %v, expected it to take its share of the bandwidth", i, elapsed)
+		}
+	}
+
+	// the total is transferred at the rate, less the first chunks which are
+	// transferred without waiting.
+	total := float64(largeSize + numSmall*smallSize)
+	if elapsed := largeDone.Sub(start); elapsed < time.Duration(total/rate*0.8*float64(time.Second)) {
+		t.Errorf("transfers took %v, expected the bandwidth to be limited", elapsed)
+	}
+}
+
+func TestBandwidthLimiterWeights(t *testing.T) {
+	const rate = 1024 * 1024
+
+	limiter := NewBandwidthLimiter(rate)
+
+	var (
+		wg    sync.WaitGroup
+		stop  atomic.Bool
+		bytes [2]atomic.Int64
+	)
+	for i, weight := range []int{1, 3} {
+		wg.Add(1)
+		go func(i, weight int) {
+			defer wg.Done()
+			tr := limiter.Start(weight)
+			defer tr.Done()
+			for !stop.Load() {
+				tr.Wait(4 * 1024)
+				bytes[i].Add(4 * 1024)
+			}
+		}(i, weight)
+	}
+
+	time.Sleep(time.Second)
+	stop.Store(true)
+	wg.Wait()
+
+	light, heavy := float64(bytes[0].Load()), float64(bytes[1].Load())
+	if ratio := heavy / light; ratio < 2 || ratio > 4 {
+		t.Errorf("expected the bandwidth to be shared in proportion to the weights, got %v and %v bytes", light, heavy)
+	}
+}
+
+func TestNilTransfer(t *testing.T) {
+	var tr *Transfer
+
+	// the transfers of an unlimited bandwidth never wait.
+	tr.Wait(1024)
+	tr.Done()
+}
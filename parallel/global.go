@@ -1,14 +1,28 @@
 package parallel
 
-import "github.com/peak/s5cmd/v2/parallel/fdlimit"
+import (
+	"time"
+
+	"github.com/peak/s5cmd/v2/parallel/fdlimit"
+)
 
 var global *Manager
 
 // Init tries to increase the soft limit of open files and
-// creates new global ParallelManager.
-func Init(workercount int) {
+// creates new global ParallelManager. The concurrency of the global manager is
+// ramped up over the given duration, if it's positive. The concurrency is
+// reduced by the outcomes of the requests reported with ReportRequest if
+// adaptive is set.
+func Init(workercount int, rampDuration time.Duration, adaptive bool) {
 	_ = fdlimit.Raise()
 	global = New(workercount)
+	global.countCompleted = true
+	if rampDuration > 0 {
+		global.ramp = newRamp(cap(global.semaphore), rampDuration)
+	}
+	if adaptive {
+		global.adaptive = newAdaptive(cap(global.semaphore), adaptiveRecoveryInterval)
+	}
 }
 
 // Close waits all jobs to finish and
@@ -0,0 +1,87 @@
+package parallel
+
+import "sync"
+
+// HostLimiter limits the number of the tasks of each host which are run
+// concurrently by a Manager. The tasks of a host which has reached the limit
+// are queued without occupying a worker, so that a slow host does not starve
+// the tasks of the other hosts.
+type HostLimiter struct {
+	manager *Manager
+	limit   int
+
+	mu      sync.Mutex
+	running map[string]int
+	pending map[string][]hostTask
+}
+
+type hostTask struct {
+	fn     Task
+	waiter *Waiter
+}
+
+// NewHostLimiter creates a new HostLimiter which runs at most limit tasks of
+// each host concurrently on the given manager.
+func NewHostLimiter(manager *Manager, limit int) *HostLimiter {
+	return &HostLimiter{
+		manager: manager,
+		limit:   limit,
+		running: map[string]int{},
+		pending: map[string][]hostTask{},
+	}
+}
+
+// Run runs the given task of the given host on the manager if fewer than
+// limit tasks of the host are running. Otherwise the task is queued and run
+// once a running task of the host is finished.
+func (l *HostLimiter) Run(host string, fn Task, waiter *Waiter) {
+	// the queued task is waited as well.
+	waiter.wg.Add(1)
+
+	l.mu.Lock()
+	if l.running[host] >= l.limit {
+		l.pending[host] = append(l.pending[host], hostTask{fn: fn, waiter: waiter})
+		l.mu.Unlock()
+		return
+	}
+	l.running[host]++
+	l.mu.Unlock()
+
+	l.start(host, hostTask{fn: fn, waiter: waiter})
+}
+
+// start runs the given task on the manager, and starts the next queued task
+// of the host when it's finished. The slot of the task is freed even if it's
+// not run due to Shutdown, so that the queued tasks of the host are finished
+// as well.
+func (l *HostLimiter) start(host string, task hostTask) {
+	defer task.waiter.wg.Done()
+
+	// the queued tasks are not run once the managers are shut down.
+	if IsShutdown() {
+		numCanceled.Add(1)
+		task.waiter.errch <- ErrShutdown
+		l.done(host)
+		return
+	}
+
+	l.manager.run(task.fn, task.waiter, func() { l.done(host) })
+}
+
+// done starts the next queued task of the host, if any.
+func (l *HostLimiter) done(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	queue := l.pending[host]
+	if len(queue) == 0 {
+		l.running[host]--
+		return
+	}
+
+	next := queue[0]
+	l.pending[host] = queue[1:]
+	// the task is started by another goroutine since the manager may wait for
+	// the worker of the finishing task.
+	go l.start(host, next)
+}
@@ -0,0 +1,131 @@
+package parallel
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHostLimiter(t *testing.T) {
+	manager := New(2)
+	limiter := NewHostLimiter(manager, 1)
+	waiter := NewWaiter()
+
+	slowCh := make(chan struct{})
+	var slowRunning atomic.Int64
+	var slowExceeded atomic.Bool
+	slow := func() error {
+		if slowRunning.Add(1) > 1 {
+			slowExceeded.Store(true)
+		}
+		defer slowRunning.Add(-1)
+
+		<-slowCh
+		return nil
+	}
+
+	fastCh := make(chan struct{})
+	var fastCompleted atomic.Int64
+	fast := func() error {
+		if fastCompleted.Add(1) == 3 {
+			close(fastCh)
+		}
+		return nil
+	}
+
+	// the tasks of the slow host are queued before the tasks of the fast
+	// host, they should not occupy all the workers.
+	for i := 0; i < 3; i++ {
+		limiter.Run("slow.example.com", slow, waiter)
+	}
+	for i := 0; i < 3; i++ {
+		limiter.Run("fast.example.com", fast, waiter)
+	}
+
+	select {
+	case <-fastCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the tasks of the fast host are starved: %v of 3 completed", fastCompleted.Load())
+	}
+
+	close(slowCh)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range waiter.Err() {
+		}
+	}()
+	waiter.Wait()
+	<-done
+	manager.Close()
+
+	if slowExceeded.Load() {
+		t.Errorf("expected at most 1 concurrent task of the slow host")
+	}
+}
+
+func TestHostLimiterShutdown(t *testing.T) {
+	t.Cleanup(func() {
+		shutdownOnce = sync.Once{}
+		shutdownCh = make(chan struct{})
+	})
+
+	manager := New(2)
+	limiter := NewHostLimiter(manager, 1)
+	waiter := NewWaiter()
+
+	runningCh := make(chan struct{})
+	releaseCh := make(chan struct{})
+	var ran atomic.Int64
+	task := func() error {
+		if ran.Add(1) == 1 {
+			close(runningCh)
+			<-releaseCh
+		}
+		return nil
+	}
+
+	// the first task is running while the others are queued.
+	for i := 0; i < 3; i++ {
+		limiter.Run("example.com", task, waiter)
+	}
+	<-runningCh
+
+	Shutdown()
+	close(releaseCh)
+
+	var shutdownErrs atomic.Int64
+	errDone := make(chan struct{})
+	go func() {
+		defer close(errDone)
+		for err := range waiter.Err() {
+			if errors.Is(err, ErrShutdown) {
+				shutdownErrs.Add(1)
+			}
+		}
+	}()
+
+	waitDone := make(chan struct{})
+	go func() {
+		defer close(waitDone)
+		waiter.Wait()
+	}()
+
+	select {
+	case <-waitDone:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the queued tasks are not finished after shutdown")
+	}
+	<-errDone
+	manager.Close()
+
+	if got := ran.Load(); got != 1 {
+		t.Errorf("expected only the running task to run, got %v", got)
+	}
+	if got := shutdownErrs.Load(); got != 2 {
+		t.Errorf("expected 2 queued tasks to be canceled, got %v", got)
+	}
+}
@@ -1,14 +1,68 @@
 package parallel
 
 import (
+	"context"
+	"fmt"
 	"runtime"
 	"sync"
+	"sync/atomic"
 )
 
 const (
 	minNumWorkers = 2
 )
 
+// ErrShutdown is returned for the tasks which are not run since the managers
+// are shut down. It is a cancelation error.
+var ErrShutdown = fmt.Errorf("shutting down: %w", context.Canceled)
+
+var (
+	shutdownOnce sync.Once
+	shutdownCh   = make(chan struct{})
+
+	numCompleted atomic.Int64
+	numCanceled  atomic.Int64
+)
+
+// Shutdown stops all managers running new tasks. The tasks which are already
+// running are not affected and may finish.
+func Shutdown() {
+	shutdownOnce.Do(func() { close(shutdownCh) })
+}
+
+// IsShutdown reports whether Shutdown is called.
+func IsShutdown() bool {
+	select {
+	case <-shutdownCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// ShutdownContext returns a copy of ctx which is canceled once Shutdown is
+// called, so that the listings which produce the tasks are stopped on Shutdown
+// while the running tasks use ctx and may finish.
+func ShutdownContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-shutdownCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// Summary returns the number of the tasks which are completed by the global
+// manager and the number of the tasks which are canceled by any manager due to
+// Shutdown. The other managers only run the commands whose objects are
+// transferred by the global manager, so their completed tasks are not counted.
+func Summary() (completed, canceled int64) {
+	return numCompleted.Load(), numCanceled.Load()
+}
+
 // Task is a function type for parallel manager.
 type Task func() error
 
@@ -16,6 +70,16 @@ type Task func() error
 type Manager struct {
 	wg        *sync.WaitGroup
 	semaphore chan struct{}
+
+	// countCompleted is set if the completed tasks are counted in Summary.
+	countCompleted bool
+
+	// ramp is set if the concurrency is ramped up at the start of the run.
+	ramp *ramp
+
+	// adaptive is set if the concurrency is reduced when the requests are
+	// overloaded.
+	adaptive *adaptive
 }
 
 // New creates a new parallel.Manager.
@@ -38,6 +102,14 @@ func New(workercount int) *Manager {
 func (p *Manager) acquire() {
 	p.semaphore <- struct{}{}
 	p.wg.Add(1)
+
+	// the acquired semaphores include the task which is about to run.
+	if p.ramp != nil {
+		p.ramp.wait(func() int { return len(p.semaphore) })
+	}
+	if p.adaptive != nil {
+		p.adaptive.wait(func() int { return len(p.semaphore) })
+	}
 }
 
 // release releases the acquired semaphore to signal that a task is finished.
@@ -46,15 +118,36 @@ func (p *Manager) release() {
 	<-p.semaphore
 }
 
-// Run runs the given task while limiting the concurrency.
+// Run runs the given task while limiting the concurrency. The task is not run
+// and ErrShutdown is sent to the waiter if Shutdown is called before a worker
+// is available.
 func (p *Manager) Run(fn Task, waiter *Waiter) {
+	p.run(fn, waiter, nil)
+}
+
+// run runs the given task as Run does, and calls the given function, if any,
+// once the task is finished or skipped due to Shutdown.
+func (p *Manager) run(fn Task, waiter *Waiter, finish func()) {
 	waiter.wg.Add(1)
 	p.acquire()
 	go func() {
 		defer waiter.wg.Done()
 		defer p.release()
+		if finish != nil {
+			defer finish()
+		}
 
-		if err := fn(); err != nil {
+		if IsShutdown() {
+			numCanceled.Add(1)
+			waiter.errch <- ErrShutdown
+			return
+		}
+
+		err := fn()
+		if err == nil && p.countCompleted {
+			numCompleted.Add(1)
+		}
+		if err != nil {
 			waiter.errch <- err
 		}
 	}()
@@ -0,0 +1,38 @@
+package parallel
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdownContext(t *testing.T) {
+	t.Cleanup(func() {
+		shutdownOnce = sync.Once{}
+		shutdownCh = make(chan struct{})
+	})
+
+	parent, cancelParent := context.WithCancel(context.Background())
+	defer cancelParent()
+
+	ctx, cancel := ShutdownContext(parent)
+	defer cancel()
+
+	if err := ctx.Err(); err != nil {
+		t.Fatalf("expected the context not to be canceled before shutdown, got %v", err)
+	}
+
+	Shutdown()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the context is not canceled after shutdown")
+	}
+
+	// the parent context of the running tasks is not canceled.
+	if err := parent.Err(); err != nil {
+		t.Fatalf("expected the parent context not to be canceled, got %v", err)
+	}
+}
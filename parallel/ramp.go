@@ -0,0 +1,61 @@
+package parallel
+
+import (
+	"sync"
+	"time"
+)
+
+// minRampStep is the minimum interval of checking whether a worker is
+// available while the concurrency is ramped up.
+const minRampStep = 10 * time.Millisecond
+
+// ramp increases the number of the tasks which are run concurrently by a
+// Manager gradually, from a single task to all the workers, over the given
+// duration. A burst of requests to a cold prefix is throttled by S3 until it
+// scales its partitions, so the ramp avoids SlowDown errors at the start of
+// huge runs.
+type ramp struct {
+	workers  int
+	duration time.Duration
+
+	// start is the time when the first task is run.
+	startOnce sync.Once
+	start     time.Time
+}
+
+// newRamp creates a ramp up to the given number of workers over the given
+// duration.
+func newRamp(workers int, duration time.Duration) *ramp {
+	return &ramp{workers: workers, duration: duration}
+}
+
+// limit returns the number of the tasks which may run concurrently after the
+// given time is elapsed since the start of the ramp.
+func (r *ramp) limit(elapsed time.Duration) int {
+	if elapsed >= r.duration {
+		return r.workers
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return 1 + int(int64(r.workers-1)*int64(elapsed)/int64(r.duration))
+}
+
+// wait blocks until the given number of the running tasks, including the
+// task which is about to run, is allowed by the ramp, or Shutdown is called.
+func (r *ramp) wait(running func() int) {
+	r.startOnce.Do(func() { r.start = time.Now() })
+
+	step := r.duration / time.Duration(r.workers)
+	if step < minRampStep {
+		step = minRampStep
+	}
+
+	for running() > r.limit(time.Since(r.start)) {
+		select {
+		case <-shutdownCh:
+			return
+		case <-time.After(step):
+		}
+	}
+}
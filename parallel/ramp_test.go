@@ -0,0 +1,89 @@
+package parallel
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRampLimit(t *testing.T) {
+	r := newRamp(11, 10*time.Second)
+
+	testcases := []struct {
+		elapsed  time.Duration
+		expected int
+	}{
+		{elapsed: 0, expected: 1},
+		{elapsed: 999 * time.Millisecond, expected: 1},
+		{elapsed: time.Second, expected: 2},
+		{elapsed: 5 * time.Second, expected: 6},
+		{elapsed: 9999 * time.Millisecond, expected: 10},
+		{elapsed: 10 * time.Second, expected: 11},
+		{elapsed: time.Minute, expected: 11},
+	}
+
+	for _, tc := range testcases {
+		if got := r.limit(tc.elapsed); got != tc.expected {
+			t.Errorf("limit after %v: expected %v, got %v", tc.elapsed, tc.expected, got)
+		}
+	}
+}
+
+func TestManagerRamp(t *testing.T) {
+	const numWorkers = 4
+
+	manager := New(numWorkers)
+	manager.ramp = newRamp(numWorkers, time.Second)
+	waiter := NewWaiter()
+
+	var running, maxRunning atomic.Int64
+	blockCh := make(chan struct{})
+	task := func() error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			max := maxRunning.Load()
+			if n <= max || maxRunning.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		<-blockCh
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range waiter.Err() {
+		}
+	}()
+
+	start := time.Now()
+	go func() {
+		for i := 0; i < numWorkers; i++ {
+			manager.Run(task, waiter)
+		}
+	}()
+
+	// only a single task is run at the start of the ramp.
+	time.Sleep(100 * time.Millisecond)
+	if got := maxRunning.Load(); got != 1 {
+		t.Fatalf("expected 1 task running at the start of the ramp, got %v", got)
+	}
+
+	// all the workers are used once the ramp is completed.
+	for running.Load() < numWorkers {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("expected %v tasks running after the ramp, got %v", numWorkers, running.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if elapsed := time.Since(start); elapsed < 700*time.Millisecond {
+		t.Fatalf("expected the workers to be ramped up over a second, took %v", elapsed)
+	}
+
+	close(blockCh)
+	manager.Close()
+	waiter.Wait()
+	<-done
+}
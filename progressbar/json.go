@@ -0,0 +1,229 @@
+package progressbar
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileProgressBar is a ProgressBar which tracks the progress of each file as
+// well as the totals.
+type FileProgressBar interface {
+	ProgressBar
+	StartFile(name string, size int64)
+	AddFileBytes(name string, bytes int64)
+	FinishFile(name string)
+}
+
+// JSONProgressBar writes the progress as JSON events, one per line, instead
+// of drawing a progress bar, so that the wrappers can render their own. An
+// event of each file in progress and an event of the totals are written
+// periodically, and a final event is written once a file or the command is
+// done.
+type JSONProgressBar struct {
+	w        io.Writer
+	interval time.Duration
+
+	mu               sync.Mutex
+	start            time.Time
+	totalObjects     int64
+	completedObjects int64
+	totalBytes       int64
+	completedBytes   int64
+	files            map[string]*fileProgress
+
+	doneCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+var _ FileProgressBar = (*JSONProgressBar)(nil)
+
+type fileProgress struct {
+	start time.Time
+	size  int64
+	bytes int64
+}
+
+// ProgressEvent is the JSON event of the progress of a file or the totals.
+type ProgressEvent struct {
+	// Type is "file" for the events of a file and "progress" for the events
+	// of the totals.
+	Type string `json:"type"`
+	File string `json:"file,omitempty"`
+
+	Objects      *int64 `json:"objects,omitempty"`
+	TotalObjects *int64 `json:"total_objects,omitempty"`
+
+	Bytes int64 `json:"bytes"`
+	Total int64 `json:"total"`
+	// Rate is the average number of bytes transferred per second.
+	Rate int64 `json:"rate"`
+	// ETA is the estimated number of seconds left, if it's known.
+	ETA  int64 `json:"eta,omitempty"`
+	Done bool  `json:"done,omitempty"`
+}
+
+// NewJSON creates a progress bar which writes the events to the given writer
+// at each interval.
+func NewJSON(w io.Writer, interval time.Duration) *JSONProgressBar {
+	return &JSONProgressBar{
+		w:        w,
+		interval: interval,
+		files:    map[string]*fileProgress{},
+		doneCh:   make(chan struct{}),
+	}
+}
+
+func (jp *JSONProgressBar) Start() {
+	jp.mu.Lock()
+	jp.start = time.Now()
+	jp.mu.Unlock()
+
+	jp.wg.Add(1)
+	go func() {
+		defer jp.wg.Done()
+
+		ticker := time.NewTicker(jp.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-jp.doneCh:
+				return
+			case now := <-ticker.C:
+				jp.writeProgress(now)
+			}
+		}
+	}()
+}
+
+func (jp *JSONProgressBar) Finish() {
+	close(jp.doneCh)
+	jp.wg.Wait()
+
+	jp.mu.Lock()
+	defer jp.mu.Unlock()
+
+	event := jp.totalsEvent(time.Now())
+	event.Done = true
+	event.ETA = 0
+	jp.write(event)
+}
+
+func (jp *JSONProgressBar) IncrementCompletedObjects() {
+	jp.mu.Lock()
+	jp.completedObjects++
+	jp.mu.Unlock()
+}
+
+func (jp *JSONProgressBar) IncrementTotalObjects() {
+	jp.mu.Lock()
+	jp.totalObjects++
+	jp.mu.Unlock()
+}
+
+func (jp *JSONProgressBar) AddCompletedBytes(bytes int64) {
+	jp.mu.Lock()
+	jp.completedBytes += bytes
+	jp.mu.Unlock()
+}
+
+func (jp *JSONProgressBar) AddTotalBytes(bytes int64) {
+	jp.mu.Lock()
+	jp.totalBytes += bytes
+	jp.mu.Unlock()
+}
+
+// StartFile starts tracking the progress of the given file of the given size.
+func (jp *JSONProgressBar) StartFile(name string, size int64) {
+	jp.mu.Lock()
+	jp.files[name] = &fileProgress{start: time.Now(), size: size}
+	jp.mu.Unlock()
+}
+
+// AddFileBytes adds the given number of the transferred bytes to the given
+// file. The bytes are added to the totals by AddCompletedBytes.
+func (jp *JSONProgressBar) AddFileBytes(name string, bytes int64) {
+	jp.mu.Lock()
+	if file, ok := jp.files[name]; ok {
+		file.bytes += bytes
+	}
+	jp.mu.Unlock()
+}
+
+// FinishFile writes the final event of the given file and stops tracking it.
+func (jp *JSONProgressBar) FinishFile(name string) {
+	jp.mu.Lock()
+	defer jp.mu.Unlock()
+
+	file, ok := jp.files[name]
+	if !ok {
+		return
+	}
+	delete(jp.files, name)
+
+	event := file.event(name, time.Now())
+	event.Done = true
+	event.ETA = 0
+	jp.write(event)
+}
+
+// writeProgress writes the events of the files in progress, sorted by their
+// names, and the event of the totals.
+func (jp *JSONProgressBar) writeProgress(now time.Time) {
+	jp.mu.Lock()
+	defer jp.mu.Unlock()
+
+	names := make([]string, 0, len(jp.files))
+	for name := range jp.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		jp.write(jp.files[name].event(name, now))
+	}
+	jp.write(jp.totalsEvent(now))
+}
+
+func (jp *JSONProgressBar) totalsEvent(now time.Time) ProgressEvent {
+	objects, totalObjects := jp.completedObjects, jp.totalObjects
+	event := newProgressEvent(jp.completedBytes, jp.totalBytes, now.Sub(jp.start))
+	event.Type = "progress"
+	event.Objects = &objects
+	event.TotalObjects = &totalObjects
+	return event
+}
+
+func (fp *fileProgress) event(name string, now time.Time) ProgressEvent {
+	event := newProgressEvent(fp.bytes, fp.size, now.Sub(fp.start))
+	event.Type = "file"
+	event.File = name
+	return event
+}
+
+// newProgressEvent returns an event of the given number of bytes transferred
+// in the given duration.
+func newProgressEvent(bytes, total int64, elapsed time.Duration) ProgressEvent {
+	event := ProgressEvent{Bytes: bytes, Total: total}
+	if elapsed <= 0 {
+		return event
+	}
+
+	event.Rate = int64(float64(bytes) / elapsed.Seconds())
+	if event.Rate > 0 && total > bytes {
+		event.ETA = (total - bytes + event.Rate - 1) / event.Rate
+	}
+	return event
+}
+
+// write writes the given event. The caller must hold the lock, so that the
+// events are not interleaved.
+func (jp *JSONProgressBar) write(event ProgressEvent) {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	_, _ = jp.w.Write(append(b, '\n'))
+}
@@ -0,0 +1,117 @@
+package progressbar
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func readProgressEvents(t *testing.T, buf *bytes.Buffer) []ProgressEvent {
+	t.Helper()
+
+	var events []ProgressEvent
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var event ProgressEvent
+		assert.NilError(t, json.Unmarshal([]byte(line), &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestJSONProgress(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	// the periodic events are written explicitly.
+	jp := NewJSON(&buf, time.Hour)
+	jp.Start()
+
+	jp.AddTotalBytes(300)
+	jp.IncrementTotalObjects()
+	jp.IncrementTotalObjects()
+	jp.StartFile("s3://bucket/b.bin", 200)
+	jp.StartFile("s3://bucket/a.bin", 100)
+	jp.AddFileBytes("s3://bucket/a.bin", 40)
+	jp.AddCompletedBytes(40)
+
+	jp.writeProgress(time.Now().Add(time.Second))
+
+	jp.AddFileBytes("s3://bucket/a.bin", 60)
+	jp.AddCompletedBytes(60)
+	jp.FinishFile("s3://bucket/a.bin")
+	jp.IncrementCompletedObjects()
+	jp.Finish()
+
+	events := readProgressEvents(t, &buf)
+	assert.Equal(t, len(events), 5)
+
+	// the files in progress are sorted by their names.
+	a, b, progress := events[0], events[1], events[2]
+	assert.Equal(t, a.Type, "file")
+	assert.Equal(t, a.File, "s3://bucket/a.bin")
+	assert.Equal(t, a.Bytes, int64(40))
+	assert.Equal(t, a.Total, int64(100))
+	assert.Assert(t, a.Rate > 0)
+	assert.Assert(t, a.ETA > 0)
+	assert.Assert(t, !a.Done)
+
+	assert.Equal(t, b.File, "s3://bucket/b.bin")
+	assert.Equal(t, b.Bytes, int64(0))
+	assert.Equal(t, b.Rate, int64(0))
+
+	assert.Equal(t, progress.Type, "progress")
+	assert.Equal(t, *progress.Objects, int64(0))
+	assert.Equal(t, *progress.TotalObjects, int64(2))
+	assert.Equal(t, progress.Bytes, int64(40))
+	assert.Equal(t, progress.Total, int64(300))
+	assert.Assert(t, !progress.Done)
+
+	// the final events of the finished file and the totals.
+	fileDone, done := events[3], events[4]
+	assert.Equal(t, fileDone.Type, "file")
+	assert.Equal(t, fileDone.File, "s3://bucket/a.bin")
+	assert.Equal(t, fileDone.Bytes, int64(100))
+	assert.Equal(t, fileDone.ETA, int64(0))
+	assert.Assert(t, fileDone.Done)
+
+	assert.Equal(t, done.Type, "progress")
+	assert.Equal(t, *done.Objects, int64(1))
+	assert.Equal(t, done.Bytes, int64(100))
+	assert.Equal(t, done.ETA, int64(0))
+	assert.Assert(t, done.Done)
+}
+
+func TestJSONProgressPeriodic(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	jp := NewJSON(&buf, 10*time.Millisecond)
+	jp.Start()
+	time.Sleep(50 * time.Millisecond)
+	jp.Finish()
+
+	// the events of the totals are written at each interval.
+	events := readProgressEvents(t, &buf)
+	assert.Assert(t, len(events) > 2)
+	for _, event := range events {
+		assert.Equal(t, event.Type, "progress")
+	}
+	assert.Assert(t, events[len(events)-1].Done)
+}
+
+func TestNewProgressEvent(t *testing.T) {
+	t.Parallel()
+
+	event := newProgressEvent(25, 100, 5*time.Second)
+	assert.Equal(t, event.Rate, int64(5))
+	assert.Equal(t, event.ETA, int64(15))
+
+	// the rate and ETA are unknown before any time elapses.
+	event = newProgressEvent(0, 100, 0)
+	assert.Equal(t, event.Rate, int64(0))
+	assert.Equal(t, event.ETA, int64(0))
+}
@@ -76,3 +76,41 @@ func (cp *CommandProgressBar) AddCompletedBytes(bytes int64) {
 func (cp *CommandProgressBar) AddTotalBytes(bytes int64) {
 	cp.progressbar.AddTotal(bytes)
 }
+
+// ObjectProgressBar is a progress bar of the number of objects, for the
+// operations which don't transfer any bytes, e.g. deletes.
+type ObjectProgressBar struct {
+	progressbar *pb.ProgressBar
+}
+
+var _ ProgressBar = (*ObjectProgressBar)(nil)
+
+const objectProgressbarTemplate = `{{percent . | green}} {{bar . " " "━" "━" "─" " " | green}} {{counters . | green}} {{speed . "(%s objects/s)" | red}} {{rtime . "%s left" | blue}}`
+
+func NewObjectProgressBar() *ObjectProgressBar {
+	return &ObjectProgressBar{
+		progressbar: pb.New64(0).
+			SetWidth(128).
+			SetTemplateString(objectProgressbarTemplate),
+	}
+}
+
+func (op *ObjectProgressBar) Start() {
+	op.progressbar.Start()
+}
+
+func (op *ObjectProgressBar) Finish() {
+	op.progressbar.Finish()
+}
+
+func (op *ObjectProgressBar) IncrementCompletedObjects() {
+	op.progressbar.Increment()
+}
+
+func (op *ObjectProgressBar) IncrementTotalObjects() {
+	op.progressbar.AddTotal(1)
+}
+
+func (op *ObjectProgressBar) AddCompletedBytes(bytes int64) {}
+
+func (op *ObjectProgressBar) AddTotalBytes(bytes int64) {}
@@ -51,3 +51,17 @@ func TestCommandProgress_AddTotalBytes(t *testing.T) {
 	assert.Equal(t, bytes, cp.progressbar.Total())
 	assert.Equal(t, true, strings.Contains(cp.progressbar.String(), "102 B"))
 }
+
+func TestObjectProgress_IncrementObjects(t *testing.T) {
+	t.Parallel()
+	op := NewObjectProgressBar()
+	op.Start()
+	op.IncrementTotalObjects()
+	op.IncrementTotalObjects()
+	op.IncrementCompletedObjects()
+	op.AddCompletedBytes(101)
+	assert.Equal(t, int64(2), op.progressbar.Total())
+	assert.Equal(t, int64(1), op.progressbar.Current())
+	assert.Equal(t, true, strings.Contains(op.progressbar.String(), "1 / 2"))
+	op.Finish()
+}
@@ -0,0 +1,78 @@
+package storage
+
+import "strings"
+
+// Storage backends which the defaults of the multipart transfers are tuned
+// for. The backend is detected from the endpoint by default.
+const (
+	BackendAuto  = "auto"
+	BackendAWS   = "aws"
+	BackendGCS   = "gcs"
+	BackendMinIO = "minio"
+	BackendR2    = "r2"
+)
+
+const (
+	r2EndpointSuffix  = ".r2.cloudflarestorage.com"
+	awsEndpointSuffix = ".amazonaws.com"
+	minioDefaultPort  = "9000"
+)
+
+// TransferDefaults are the defaults of the multipart transfers of a backend.
+type TransferDefaults struct {
+	// PartSize is the size of each part, in MiB.
+	PartSize int64
+	// Concurrency is the number of parts transferred concurrently for each
+	// object.
+	Concurrency int
+}
+
+var backendTransferDefaults = map[string]TransferDefaults{
+	BackendAWS: {PartSize: 50, Concurrency: 5},
+	// GCS throttles the concurrent part uploads of an object, so fewer and
+	// larger parts are transferred.
+	BackendGCS: {PartSize: 128, Concurrency: 3},
+	// MinIO servers are usually in the same network, so the parts are
+	// smaller and more of them are transferred concurrently.
+	BackendMinIO: {PartSize: 16, Concurrency: 10},
+	// R2 charges each part upload as a separate operation, so the parts are
+	// larger.
+	BackendR2: {PartSize: 100, Concurrency: 5},
+}
+
+// DetectBackend returns the backend of the given endpoint. The endpoints
+// which are not recognized are treated as AWS.
+func DetectBackend(endpoint string) string {
+	u, err := parseEndpoint(endpoint)
+	if err != nil || u == sentinelURL {
+		return BackendAWS
+	}
+
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case IsGoogleEndpoint(u):
+		return BackendGCS
+	case strings.HasSuffix(host, r2EndpointSuffix):
+		return BackendR2
+	case strings.HasSuffix(host, awsEndpointSuffix):
+		return BackendAWS
+	case strings.Contains(host, BackendMinIO) || u.Port() == minioDefaultPort:
+		return BackendMinIO
+	}
+	return BackendAWS
+}
+
+// TransferDefaults returns the defaults of the multipart transfers of the
+// backend. The backend is detected from the endpoint unless it's given.
+func (o Options) TransferDefaults() TransferDefaults {
+	backend := o.Backend
+	if backend == "" || backend == BackendAuto {
+		backend = DetectBackend(o.Endpoint)
+	}
+
+	defaults, ok := backendTransferDefaults[backend]
+	if !ok {
+		return backendTransferDefaults[BackendAWS]
+	}
+	return defaults
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestDetectBackend(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		endpoint string
+		expected string
+	}{
+		{endpoint: "", expected: BackendAWS},
+		{endpoint: "https://s3.eu-west-1.amazonaws.com", expected: BackendAWS},
+		{endpoint: "https://storage.googleapis.com", expected: BackendGCS},
+		{endpoint: "https://0123456789abcdef.r2.cloudflarestorage.com", expected: BackendR2},
+		{endpoint: "http://minio.internal", expected: BackendMinIO},
+		{endpoint: "http://localhost:9000", expected: BackendMinIO},
+		{endpoint: "https://s3.example.com", expected: BackendAWS},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.endpoint, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, DetectBackend(tc.endpoint), tc.expected)
+		})
+	}
+}
+
+func TestOptionsTransferDefaults(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		opts     Options
+		expected TransferDefaults
+	}{
+		{
+			name:     "default",
+			opts:     Options{},
+			expected: TransferDefaults{PartSize: 50, Concurrency: 5},
+		},
+		{
+			name:     "detected from endpoint",
+			opts:     Options{Endpoint: "https://storage.googleapis.com", Backend: BackendAuto},
+			expected: TransferDefaults{PartSize: 128, Concurrency: 3},
+		},
+		{
+			name:     "backend overrides endpoint",
+			opts:     Options{Endpoint: "https://storage.googleapis.com", Backend: BackendMinIO},
+			expected: TransferDefaults{PartSize: 16, Concurrency: 10},
+		},
+		{
+			name:     "r2",
+			opts:     Options{Backend: BackendR2},
+			expected: TransferDefaults{PartSize: 100, Concurrency: 5},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.opts.TransferDefaults(), tc.expected)
+		})
+	}
+}
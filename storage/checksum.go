@@ -0,0 +1,288 @@
+package storage
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Checksum is an additional checksum of an object, which is calculated by one
+// of the CRC32, CRC32C, SHA1 and SHA256 algorithms when it is uploaded.
+type Checksum struct {
+	Algorithm string
+	// Value is the base64 encoded checksum.
+	Value string
+}
+
+// The types of the additional checksums of the multipart uploads. A full
+// object checksum is the checksum of the whole data, like the checksum of an
+// object uploaded with a single request, whereas a composite checksum is the
+// checksum of the checksums of the parts. Only the CRC algorithms support
+// full object checksums.
+const (
+	ChecksumTypeFullObject = "FULL_OBJECT"
+	ChecksumTypeComposite  = "COMPOSITE"
+)
+
+// ChecksumAlgorithmMD5 is the algorithm of the checksums derived from the
+// ETags of the objects, which are the MD5 digests of the contents of the
+// objects which are neither uploaded by parts nor encrypted with KMS or a
+// customer provided key.
+const ChecksumAlgorithmMD5 = "MD5"
+
+// checksumAlgorithms are the algorithms of the additional checksums, in the
+// order of preference.
+var checksumAlgorithms = []string{
+	s3.ChecksumAlgorithmCrc32c,
+	s3.ChecksumAlgorithmCrc32,
+	s3.ChecksumAlgorithmSha256,
+	s3.ChecksumAlgorithmSha1,
+}
+
+// newChecksum returns the checksum of the given algorithm, or nil if the
+// value is empty or it is a composite checksum of a multipart upload, which
+// is calculated from the checksums of the parts and can not be verified
+// against the whole data.
+func newChecksum(algorithm, value string) *Checksum {
+	if value == "" || strings.Contains(value, "-") {
+		return nil
+	}
+	return &Checksum{Algorithm: algorithm, Value: value}
+}
+
+// checksumFromHeader returns the additional checksum in the given response
+// headers, if there is one.
+func checksumFromHeader(header http.Header) *Checksum {
+	for _, algorithm := range checksumAlgorithms {
+		value := header.Get("X-Amz-Checksum-" + algorithm)
+		if checksum := newChecksum(algorithm, value); checksum != nil {
+			return checksum
+		}
+	}
+	return nil
+}
+
+// checksumFromETag returns the MD5 checksum of the object with the given ETag
+// and server-side encryption, or nil if the ETag is not the MD5 digest of the
+// content of the object, i.e. the object is uploaded by parts, e.g.
+// "d41d8cd98f00b204e9800998ecf8427e-2", or encrypted with KMS or a customer
+// provided key.
+func checksumFromETag(etag, sse, sseCustomerAlgorithm string) *Checksum {
+	if sseCustomerAlgorithm != "" || strings.HasPrefix(sse, s3.ServerSideEncryptionAwsKms) {
+		return nil
+	}
+
+	digest, err := hex.DecodeString(strings.Trim(etag, `"`))
+	if err != nil || len(digest) != md5.Size {
+		return nil
+	}
+	return &Checksum{
+		Algorithm: ChecksumAlgorithmMD5,
+		Value:     base64.StdEncoding.EncodeToString(digest),
+	}
+}
+
+// checksumFromETagHeader returns the MD5 checksum derived from the ETag in the
+// given response headers, if it is the MD5 digest of the content.
+func checksumFromETagHeader(header http.Header) *Checksum {
+	return checksumFromETag(
+		header.Get("ETag"),
+		header.Get("X-Amz-Server-Side-Encryption"),
+		header.Get("X-Amz-Server-Side-Encryption-Customer-Algorithm"),
+	)
+}
+
+// checksumFromHeadObject returns the additional checksum of the given
+// HeadObject response, if there is one.
+func checksumFromHeadObject(output *s3.HeadObjectOutput) *Checksum {
+	values := map[string]*string{
+		s3.ChecksumAlgorithmCrc32c: output.ChecksumCRC32C,
+		s3.ChecksumAlgorithmCrc32:  output.ChecksumCRC32,
+		s3.ChecksumAlgorithmSha256: output.ChecksumSHA256,
+		s3.ChecksumAlgorithmSha1:   output.ChecksumSHA1,
+	}
+	for _, algorithm := range checksumAlgorithms {
+		if checksum := newChecksum(algorithm, aws.StringValue(values[algorithm])); checksum != nil {
+			return checksum
+		}
+	}
+	return nil
+}
+
+func (c Checksum) newHash() hash.Hash {
+	switch c.Algorithm {
+	case s3.ChecksumAlgorithmCrc32c:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	case s3.ChecksumAlgorithmCrc32:
+		return crc32.NewIEEE()
+	case s3.ChecksumAlgorithmSha256:
+		return sha256.New()
+	case ChecksumAlgorithmMD5:
+		return md5.New()
+	default:
+		return sha1.New()
+	}
+}
+
+// String returns the checksum in "ALGORITHM:value" format, e.g.
+// "CRC32C:yZRlqg==".
+func (c Checksum) String() string {
+	return c.Algorithm + ":" + c.Value
+}
+
+// Verify returns an error if the checksum of the data read from r does not
+// match the checksum.
+func (c Checksum) Verify(r io.Reader) error {
+	h := c.newHash()
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+
+	return c.verifySum(h.Sum(nil))
+}
+
+// verifySum returns an error if the given sum of the data does not match the
+// checksum.
+func (c Checksum) verifySum(sum []byte) error {
+	got := base64.StdEncoding.EncodeToString(sum)
+	if got != c.Value {
+		return fmt.Errorf("%v checksum mismatch: object has %q, downloaded data has %q", c.Algorithm, c.Value, got)
+	}
+	return nil
+}
+
+// ChecksumWriter computes the checksums of the data written to it with all
+// the algorithms, so that the data can be verified against a checksum which
+// is known only after the data is written, e.g. when the data is streamed.
+type ChecksumWriter struct {
+	hashes map[string]hash.Hash
+}
+
+// NewChecksumWriter creates a ChecksumWriter.
+func NewChecksumWriter() *ChecksumWriter {
+	hashes := map[string]hash.Hash{}
+	for _, algorithm := range append(checksumAlgorithms, ChecksumAlgorithmMD5) {
+		hashes[algorithm] = Checksum{Algorithm: algorithm}.newHash()
+	}
+	return &ChecksumWriter{hashes: hashes}
+}
+
+// Write adds the given data to the checksums.
+func (w *ChecksumWriter) Write(p []byte) (int, error) {
+	for _, h := range w.hashes {
+		h.Write(p)
+	}
+	return len(p), nil
+}
+
+// Verify returns an error if the checksum of the data written so far does not
+// match the given checksum.
+func (w *ChecksumWriter) Verify(c Checksum) error {
+	h, ok := w.hashes[c.Algorithm]
+	if !ok {
+		return fmt.Errorf("unsupported checksum algorithm %q", c.Algorithm)
+	}
+	return c.verifySum(h.Sum(nil))
+}
+
+// computeChecksum returns the base64 encoded checksum of the given algorithm
+// of the data read from r. r is rewound to its initial position, so that it
+// can be sent afterwards.
+func computeChecksum(algorithm string, r io.ReadSeeker) (string, error) {
+	offset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+
+	h := Checksum{Algorithm: algorithm}.newHash()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+// checksumTypeOption sets the type of the additional checksum of the
+// multipart uploads. The SDK doesn't support the checksum types, so the
+// header is set on the requests which create and complete the uploads.
+func checksumTypeOption(checksumType string) request.Option {
+	return func(r *request.Request) {
+		switch r.Operation.Name {
+		case "CreateMultipartUpload", "CompleteMultipartUpload":
+			r.HTTPRequest.Header.Set("X-Amz-Checksum-Type", checksumType)
+		}
+	}
+}
+
+// checksumWriteOption computes the additional checksum of the given
+// algorithm of each PutObject and UploadPart request as the data is
+// uploaded, so that S3 verifies the data of each request. The checksums of
+// the parts are sent with the CompleteMultipartUpload request, which makes
+// S3 store the checksum of the whole object even when its size is not known
+// beforehand, e.g. when it's read from a stream.
+func checksumWriteOption(algorithm string) request.Option {
+	var (
+		mu    sync.Mutex
+		parts = map[int64]string{}
+	)
+
+	return func(r *request.Request) {
+		switch input := r.Params.(type) {
+		case *s3.PutObjectInput:
+			value, err := computeChecksum(algorithm, input.Body)
+			if err != nil {
+				r.Error = err
+				return
+			}
+			r.HTTPRequest.Header.Set("X-Amz-Checksum-"+algorithm, value)
+		case *s3.UploadPartInput:
+			value, err := computeChecksum(algorithm, input.Body)
+			if err != nil {
+				r.Error = err
+				return
+			}
+			r.HTTPRequest.Header.Set("X-Amz-Sdk-Checksum-Algorithm", algorithm)
+			r.HTTPRequest.Header.Set("X-Amz-Checksum-"+algorithm, value)
+
+			mu.Lock()
+			parts[aws.Int64Value(input.PartNumber)] = value
+			mu.Unlock()
+		case *s3.CompleteMultipartUploadInput:
+			mu.Lock()
+			defer mu.Unlock()
+
+			for _, part := range input.MultipartUpload.Parts {
+				value, ok := parts[aws.Int64Value(part.PartNumber)]
+				if !ok {
+					continue
+				}
+				switch algorithm {
+				case s3.ChecksumAlgorithmCrc32c:
+					part.ChecksumCRC32C = aws.String(value)
+				case s3.ChecksumAlgorithmCrc32:
+					part.ChecksumCRC32 = aws.String(value)
+				case s3.ChecksumAlgorithmSha256:
+					part.ChecksumSHA256 = aws.String(value)
+				case s3.ChecksumAlgorithmSha1:
+					part.ChecksumSHA1 = aws.String(value)
+				}
+			}
+		}
+	}
+}
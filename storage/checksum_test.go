@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"gotest.tools/v3/assert"
+)
+
+func TestChecksumVerify(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		algorithm string
+		value     string
+	}{
+		{algorithm: "CRC32C", value: "4waSgw=="},
+		{algorithm: "CRC32", value: "y/Q5Jg=="},
+		{algorithm: "SHA1", value: "98O8HYCOBHMq32eZZczDTKeuNEE="},
+		{algorithm: "SHA256", value: "FeKw08M4keuw8e9gnsQZQgwg4yDOlMZfvIwzEkSOsiU="},
+		{algorithm: "MD5", value: "JfnnlDI7RTiF9RgfG2JNCw=="},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.algorithm, func(t *testing.T) {
+			t.Parallel()
+
+			checksum := Checksum{Algorithm: tc.algorithm, Value: tc.value}
+			assert.NilError(t, checksum.Verify(strings.NewReader("123456789")))
+
+			err := checksum.Verify(strings.NewReader("12345678"))
+			assert.ErrorContains(t, err, tc.algorithm+" checksum mismatch")
+
+			// the data is written in pieces, as it's streamed.
+			w := NewChecksumWriter()
+			_, _ = w.Write([]byte("1234"))
+			_, _ = w.Write([]byte("56789"))
+			assert.NilError(t, w.Verify(checksum))
+
+			_, _ = w.Write([]byte("0"))
+			assert.ErrorContains(t, w.Verify(checksum), tc.algorithm+" checksum mismatch")
+		})
+	}
+}
+
+func TestChecksumFromETag(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		etag     string
+		sse      string
+		sseC     string
+		expected *Checksum
+	}{
+		{
+			name:     "md5 etag",
+			etag:     `"25f9e794323b453885f5181f1b624d0b"`,
+			expected: &Checksum{Algorithm: "MD5", Value: "JfnnlDI7RTiF9RgfG2JNCw=="},
+		},
+		{
+			name:     "sse-s3",
+			etag:     `"25f9e794323b453885f5181f1b624d0b"`,
+			sse:      "AES256",
+			expected: &Checksum{Algorithm: "MD5", Value: "JfnnlDI7RTiF9RgfG2JNCw=="},
+		},
+		{
+			name: "multipart etag",
+			etag: `"25f9e794323b453885f5181f1b624d0b-12"`,
+		},
+		{
+			name: "sse-kms",
+			etag: `"25f9e794323b453885f5181f1b624d0b"`,
+			sse:  "aws:kms",
+		},
+		{
+			name: "dsse-kms",
+			etag: `"25f9e794323b453885f5181f1b624d0b"`,
+			sse:  "aws:kms:dsse",
+		},
+		{
+			name: "sse-c",
+			etag: `"25f9e794323b453885f5181f1b624d0b"`,
+			sseC: "AES256",
+		},
+		{
+			name: "no etag",
+		},
+	}
+
+	for _, tc := range testcases {
+		assert.DeepEqual(t, checksumFromETag(tc.etag, tc.sse, tc.sseC), tc.expected)
+	}
+}
+
+func TestChecksumTypeOption(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		operation string
+		expected  string
+	}{
+		{operation: "CreateMultipartUpload", expected: ChecksumTypeFullObject},
+		{operation: "CompleteMultipartUpload", expected: ChecksumTypeFullObject},
+		{operation: "UploadPart", expected: ""},
+		{operation: "PutObject", expected: ""},
+	}
+
+	for _, tc := range testcases {
+		r := &request.Request{
+			Operation:   &request.Operation{Name: tc.operation},
+			HTTPRequest: &http.Request{Header: http.Header{}},
+		}
+		checksumTypeOption(ChecksumTypeFullObject)(r)
+		assert.Equal(t, r.HTTPRequest.Header.Get("X-Amz-Checksum-Type"), tc.expected, tc.operation)
+	}
+}
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/processcreds"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+
+	"github.com/peak/s5cmd/v2/log"
+)
+
+// credentialsExpiryWindow is the duration before the expiration of the
+// temporary credentials in which they are refreshed, so that the in-flight
+// requests are not signed with expired credentials.
+const credentialsExpiryWindow = 5 * time.Minute
+
+// setCredentialProcessOptions sets the options of the credential processes,
+// either given with the --credential-process flag or defined by the
+// credential_process directive of a profile.
+func setCredentialProcessOptions(p *processcreds.ProcessProvider) {
+	p.ExpiryWindow = credentialsExpiryWindow
+}
+
+// setWebIdentityOptions sets the options of the web identity credentials,
+// either given with the --web-identity-token-file flag or defined by the
+// AWS_WEB_IDENTITY_TOKEN_FILE environment variable.
+func setWebIdentityOptions(p *stscreds.WebIdentityRoleProvider) {
+	p.ExpiryWindow = credentialsExpiryWindow
+}
+
+// newWebIdentityCredentials returns the credentials of the given role which
+// is assumed with the web identity token in the given file. The token file
+// is read again each time the credentials are expired, so the rotated tokens
+// are used.
+func newWebIdentityCredentials(svc stsiface.STSAPI, roleARN, tokenFile string) *credentials.Credentials {
+	return credentials.NewCredentials(stscreds.NewWebIdentityRoleProviderWithOptions(
+		svc,
+		roleARN,
+		os.Getenv("AWS_ROLE_SESSION_NAME"),
+		stscreds.FetchTokenPath(tokenFile),
+		setWebIdentityOptions,
+	))
+}
+
+// newSTSClient returns the client of the STS service which the web identity
+// roles are assumed with. The endpoint of the storage is not used for STS.
+func newSTSClient(opts Options, httpClient *http.Client) (*sts.STS, error) {
+	cfg := aws.NewConfig().
+		WithHTTPClient(httpClient).
+		// AssumeRoleWithWebIdentity requests are not signed.
+		WithCredentials(credentials.AnonymousCredentials)
+
+	if opts.LogLevel == log.LevelTrace {
+		cfg = cfg.WithLogLevel(aws.LogDebug).
+			WithLogger(sdkLogger{})
+	}
+
+	sess, err := session.NewSession(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.region != "" {
+		sess.Config.Region = aws.String(opts.region)
+	}
+	if aws.StringValue(sess.Config.Region) == "" {
+		sess.Config.Region = aws.String(endpoints.UsEast1RegionID)
+	}
+	return sts.New(sess), nil
+}
+
+// newProcessCredentials returns the credentials of the given credential
+// process command. The command is executed, and its JSON output is parsed,
+// each time the credentials are expired.
+func newProcessCredentials(command string) *credentials.Credentials {
+	return credentials.NewCredentials(&credentialProcessErrorProvider{
+		command: command,
+		creds:   processcreds.NewCredentials(command, setCredentialProcessOptions),
+	})
+}
+
+// credentialProcessErrorProvider wraps the given credentials to replace the
+// errors of the credential processes with clear ones. The errors of the SDK
+// contain the output of the process, which may contain secrets.
+type credentialProcessErrorProvider struct {
+	command string
+	creds   *credentials.Credentials
+}
+
+// Retrieve returns the credentials.
+func (p *credentialProcessErrorProvider) Retrieve() (credentials.Value, error) {
+	value, err := p.creds.Get()
+	if err != nil {
+		return value, credentialProcessError(p.command, err)
+	}
+	return value, nil
+}
+
+// IsExpired reports whether the credentials are expired.
+func (p *credentialProcessErrorProvider) IsExpired() bool {
+	return p.creds.IsExpired()
+}
+
+// credentialProcessError returns a clear error for the errors of the
+// credential processes, and the other errors as is.
+func credentialProcessError(command string, err error) error {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return err
+	}
+
+	process := "credential process"
+	if command != "" {
+		process = fmt.Sprintf("credential process %q", command)
+	}
+
+	switch awsErr.Code() {
+	case processcreds.ErrCodeProcessProviderExecution:
+		var reasons []string
+		if batchErr, ok := awsErr.(awserr.BatchedErrors); ok {
+			for _, origErr := range batchErr.OrigErrs() {
+				reasons = append(reasons, origErr.Error())
+			}
+		}
+		if len(reasons) == 0 {
+			reasons = append(reasons, awsErr.Message())
+		}
+		return fmt.Errorf("%v failed: %v", process, strings.Join(reasons, ", "))
+	case processcreds.ErrCodeProcessProviderParse:
+		// the message contains the output of the process.
+		return fmt.Errorf("%v failed: output is not valid JSON", process)
+	case processcreds.ErrCodeProcessProviderVersion, processcreds.ErrCodeProcessProviderRequired:
+		return fmt.Errorf("%v failed: %v", process, awsErr.Message())
+	}
+	return err
+}
+
+var (
+	securityTokenRe = regexp.MustCompile(`(?i)(x-amz-security-token[:=]\s*)[^\s&]+`)
+	signatureRe     = regexp.MustCompile(`(?i)((?:x-amz-)?signature=)[0-9a-f]+`)
+)
+
+// redactSecrets replaces the session tokens and the signatures in the logs
+// of the SDK.
+func redactSecrets(msg string) string {
+	msg = securityTokenRe.ReplaceAllString(msg, "${1}[REDACTED]")
+	return signatureRe.ReplaceAllString(msg, "${1}[REDACTED]")
+}
@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"gotest.tools/v3/assert"
+)
+
+func writeCredentialProcess(t *testing.T, script string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "credential-process.sh")
+	assert.NilError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755))
+	return path
+}
+
+func TestNewSessionWithCredentialProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip()
+	}
+
+	expiration := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	testcases := []struct {
+		name           string
+		script         string
+		expectedKeyID  string
+		expectedErr    string
+		notExpectedErr string
+	}{
+		{
+			name:          "valid output",
+			script:        fmt.Sprintf(`echo '{"Version": 1, "AccessKeyId": "key-id", "SecretAccessKey": "secret", "SessionToken": "token", "Expiration": "%v"}'`, expiration),
+			expectedKeyID: "key-id",
+		},
+		{
+			name:        "nonzero exit",
+			script:      "echo 'not authorized' >&2; exit 3",
+			expectedErr: "failed: exit status 3",
+		},
+		{
+			name:           "invalid output",
+			script:         `echo '{"Version": 1, "SecretAccessKey": "secret"'`,
+			expectedErr:    "failed: output is not valid JSON",
+			notExpectedErr: "secret",
+		},
+		{
+			name:        "missing access key",
+			script:      `echo '{"Version": 1, "SecretAccessKey": "secret"}'`,
+			expectedErr: "failed: missing AccessKeyId",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			globalSessionCache.clear()
+
+			command := writeCredentialProcess(t, tc.script)
+			sess, err := globalSessionCache.newSession(context.Background(), Options{
+				CredentialProcess: command,
+			})
+			assert.NilError(t, err)
+
+			got, err := sess.Config.Credentials.Get()
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, fmt.Sprintf("credential process %q %v", command, tc.expectedErr))
+				if tc.notExpectedErr != "" {
+					assert.Assert(t, !strings.Contains(err.Error(), tc.notExpectedErr))
+				}
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Equal(t, got.AccessKeyID, tc.expectedKeyID)
+			assert.Equal(t, got.SessionToken, "token")
+		})
+	}
+}
+
+func TestNewSessionWithCredentialProcessFromProfile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip()
+	}
+
+	globalSessionCache.clear()
+
+	command := writeCredentialProcess(t, `echo '{"Version": 1, "AccessKeyId": "p1-key-id", "SecretAccessKey": "secret"}'`)
+	failing := writeCredentialProcess(t, "exit 1")
+
+	config := filepath.Join(t.TempDir(), "config")
+	content := fmt.Sprintf("[profile p1]\ncredential_process = %v\n\n[profile p2]\ncredential_process = %v\n", command, failing)
+	assert.NilError(t, os.WriteFile(config, []byte(content), 0644))
+
+	t.Setenv("AWS_CONFIG_FILE", config)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "env-key-id")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+
+	// the profile takes precedence over the environment variables.
+	sess, err := globalSessionCache.newSession(context.Background(), Options{Profile: "p1"})
+	assert.NilError(t, err)
+
+	got, err := sess.Config.Credentials.Get()
+	assert.NilError(t, err)
+	assert.Equal(t, got.AccessKeyID, "p1-key-id")
+
+	sess, err = globalSessionCache.newSession(context.Background(), Options{Profile: "p2"})
+	assert.NilError(t, err)
+
+	_, err = sess.Config.Credentials.Get()
+	assert.ErrorContains(t, err, "credential process failed: exit status 1")
+}
+
+func TestWebIdentityCredentials(t *testing.T) {
+	t.Parallel()
+
+	const roleARN = "arn:aws:iam::123456789012:role/s5cmd"
+
+	var (
+		mu     sync.Mutex
+		tokens []string
+	)
+
+	// the credentials are expired in the expiry window, so that they are
+	// refreshed on each retrieval.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Form.Get("Action") != "AssumeRoleWithWebIdentity" || r.Form.Get("RoleArn") != roleARN {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		tokens = append(tokens, r.Form.Get("WebIdentityToken"))
+		n := len(tokens)
+		mu.Unlock()
+
+		expiration := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+		fmt.Fprintf(w, `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>key-id-%d</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>%v</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`, n, expiration)
+	}))
+	defer srv.Close()
+
+	sess, err := session.NewSession(aws.NewConfig().
+		WithEndpoint(srv.URL).
+		WithRegion("us-east-1").
+		WithCredentials(credentials.AnonymousCredentials))
+	assert.NilError(t, err)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NilError(t, os.WriteFile(tokenFile, []byte("token-1"), 0600))
+
+	creds := newWebIdentityCredentials(sts.New(sess), roleARN, tokenFile)
+
+	got, err := creds.Get()
+	assert.NilError(t, err)
+	assert.Equal(t, got.AccessKeyID, "key-id-1")
+	assert.Equal(t, got.SessionToken, "token")
+
+	// the rotated token is used to refresh the credentials.
+	assert.NilError(t, os.WriteFile(tokenFile, []byte("token-2"), 0600))
+
+	assert.Assert(t, creds.IsExpired())
+	got, err = creds.Get()
+	assert.NilError(t, err)
+	assert.Equal(t, got.AccessKeyID, "key-id-2")
+
+	assert.DeepEqual(t, tokens, []string{"token-1", "token-2"})
+}
+
+func TestNewSessionWithWebIdentityTokenFile(t *testing.T) {
+	globalSessionCache.clear()
+
+	sess, err := globalSessionCache.newSession(context.Background(), Options{
+		WebIdentityTokenFile: filepath.Join(t.TempDir(), "non-existent-token"),
+		AssumeRoleARN:        "arn:aws:iam::123456789012:role/s5cmd",
+	})
+	assert.NilError(t, err)
+
+	_, err = sess.Config.Credentials.Get()
+	assert.ErrorContains(t, err, "failed fetching WebIdentity token")
+}
+
+func TestRedactSecrets(t *testing.T) {
+	t.Parallel()
+
+	msg := strings.Join([]string{
+		"Authorization: AWS4-HMAC-SHA256 Credential=key-id/20231001/us-east-1/s3/aws4_request, SignedHeaders=host, Signature=fd5fab8e9819d4e4",
+		"X-Amz-Security-Token: secret-token",
+		"GET /bucket/key?X-Amz-Security-Token=secret-token&X-Amz-Signature=2117aaff34cb HTTP/1.1",
+	}, "\n")
+
+	expected := strings.Join([]string{
+		"Authorization: AWS4-HMAC-SHA256 Credential=key-id/20231001/us-east-1/s3/aws4_request, SignedHeaders=host, Signature=[REDACTED]",
+		"X-Amz-Security-Token: [REDACTED]",
+		"GET /bucket/key?X-Amz-Security-Token=[REDACTED]&X-Amz-Signature=[REDACTED] HTTP/1.1",
+	}, "\n")
+
+	assert.Equal(t, redactSecrets(msg), expected)
+}
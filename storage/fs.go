@@ -17,11 +17,24 @@ import (
 // Filesystem is the Storage implementation of a local filesystem.
 type Filesystem struct {
 	dryRun bool
+
+	// symlinksAsObjects makes the symbolic links to be listed as they are,
+	// instead of the files they point to.
+	symlinksAsObjects bool
+
+	// emptyDirs makes the empty directories to be listed by the walker, so
+	// that directory markers can be created for them.
+	emptyDirs bool
 }
 
 // Stat returns the Object structure describing object.
 func (f *Filesystem) Stat(ctx context.Context, url *url.URL) (*Object, error) {
-	st, err := os.Stat(url.Absolute())
+	stat := os.Stat
+	if f.symlinksAsObjects {
+		stat = os.Lstat
+	}
+
+	st, err := stat(url.Absolute())
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
 			return nil, &ErrGivenObjectNotFound{ObjectAbsPath: url.Absolute()}
@@ -29,11 +42,17 @@ func (f *Filesystem) Stat(ctx context.Context, url *url.URL) (*Object, error) {
 		return nil, err
 	}
 
+	// symbolic links are stored as zero-byte objects.
+	size := st.Size()
+	if st.Mode()&os.ModeSymlink != 0 {
+		size = 0
+	}
+
 	mod := st.ModTime()
 	return &Object{
 		URL:     url,
 		Type:    ObjectType{st.Mode()},
-		Size:    st.Size(),
+		Size:    size,
 		ModTime: &mod,
 		Etag:    "",
 	}, nil
@@ -103,27 +122,51 @@ func (f *Filesystem) expandGlob(ctx context.Context, src *url.URL, followSymlink
 			}
 
 			if !obj.Type.IsDir() {
-				sendObject(ctx, obj, ch)
+				if !src.IsExcludedPrefix(filename) {
+					sendObject(ctx, obj, ch)
+				}
 				continue
 			}
 
-			walkDir(ctx, f, fileurl, followSymlinks, func(obj *Object) {
+			send := func(obj *Object) {
 				sendObject(ctx, obj, ch)
-			})
+			}
+
+			// the matched directory is not listed by the walker.
+			if f.emptyDirs {
+				if err := walkEmptyDir(ctx, f, src, filename, send); err != nil {
+					sendError(ctx, err, ch)
+					return
+				}
+			}
+
+			walkDir(ctx, f, fileurl, src, followSymlinks, send)
 		}
 	}()
 	return ch
 }
 
-func walkDir(ctx context.Context, fs *Filesystem, src *url.URL, followSymlinks bool, fn func(o *Object)) {
+// walkDir walks the given src directory. The excluded prefixes and
+// directories of the original source URL are not descended into.
+func walkDir(ctx context.Context, fs *Filesystem, src, origSrc *url.URL, followSymlinks bool, fn func(o *Object)) {
 	//skip if symlink is pointing to a dir and --no-follow-symlink
 	if !ShouldProcessURL(src, followSymlinks) {
 		return
 	}
 	err := godirwalk.Walk(src.Absolute(), &godirwalk.Options{
 		Callback: func(pathname string, dirent *godirwalk.Dirent) error {
-			// we're interested in files
+			// we're interested in files, and in empty directories if asked.
 			if dirent.IsDir() {
+				if origSrc.IsExcludedPrefix(pathname+string(filepath.Separator)) || origSrc.IsExcludedDir(pathname) {
+					return filepath.SkipDir
+				}
+				if fs.emptyDirs {
+					return walkEmptyDir(ctx, fs, src, pathname, fn)
+				}
+				return nil
+			}
+
+			if origSrc.IsExcludedPrefix(pathname) {
 				return nil
 			}
 
@@ -135,7 +178,7 @@ func walkDir(ctx context.Context, fs *Filesystem, src *url.URL, followSymlinks b
 			fileurl.SetRelative(src)
 
 			//skip if symlink is pointing to a file and --no-follow-symlink
-			if !ShouldProcessURL(fileurl, followSymlinks) {
+			if !fs.symlinksAsObjects && !ShouldProcessURL(fileurl, followSymlinks) {
 				return nil
 			}
 
@@ -147,7 +190,7 @@ func walkDir(ctx context.Context, fs *Filesystem, src *url.URL, followSymlinks b
 			fn(obj)
 			return nil
 		},
-		FollowSymbolicLinks: followSymlinks,
+		FollowSymbolicLinks: followSymlinks && !fs.symlinksAsObjects,
 	})
 	if err != nil {
 		obj := &Object{Err: err}
@@ -155,12 +198,41 @@ func walkDir(ctx context.Context, fs *Filesystem, src *url.URL, followSymlinks b
 	}
 }
 
+// walkEmptyDir calls fn with the given directory if it's empty, except the
+// walked directory itself. Its size is zero since it's uploaded as a
+// directory marker.
+func walkEmptyDir(ctx context.Context, fs *Filesystem, src *url.URL, pathname string, fn func(o *Object)) error {
+	if filepath.Clean(pathname) == filepath.Clean(src.Absolute()) {
+		return nil
+	}
+
+	entries, err := os.ReadDir(pathname)
+	if err != nil || len(entries) > 0 {
+		return err
+	}
+
+	dirurl, err := url.New(pathname)
+	if err != nil {
+		return err
+	}
+	dirurl.SetRelative(src)
+
+	obj, err := fs.Stat(ctx, dirurl)
+	if err != nil {
+		return err
+	}
+	obj.Size = 0
+
+	fn(obj)
+	return nil
+}
+
 func (f *Filesystem) walkDir(ctx context.Context, src *url.URL, followSymlinks bool) <-chan *Object {
 	ch := make(chan *Object)
 	go func() {
 		defer close(ch)
 
-		walkDir(ctx, f, src, followSymlinks, func(obj *Object) {
+		walkDir(ctx, f, src, src, followSymlinks, func(obj *Object) {
 			sendObject(ctx, obj, ch)
 		})
 	}()
@@ -224,6 +296,19 @@ func (f *Filesystem) Create(path string) (*os.File, error) {
 	return os.Create(path)
 }
 
+// Symlink creates a symbolic link at the given path pointing to the given
+// target. The existing file at the path, if any, is replaced.
+func (f *Filesystem) Symlink(target, path string) error {
+	if f.dryRun {
+		return nil
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return os.Symlink(target, path)
+}
+
 // Open opens the given source.
 func (f *Filesystem) Open(path string) (*os.File, error) {
 	file, err := os.OpenFile(path, os.O_RDONLY, 0644)
@@ -249,6 +334,26 @@ func (f *Filesystem) CreateTemp(dir, pattern string) (*os.File, error) {
 	return file, err
 }
 
+// OpenPartial opens the partially written file at the given path to continue
+// writing it, and returns its size. The file is created if it doesn't exist.
+func (f *Filesystem) OpenPartial(path string) (*os.File, int64, error) {
+	if f.dryRun {
+		return &os.File{}, 0, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, info.Size(), nil
+}
+
 // Rename a file
 func (f *Filesystem) Rename(file *os.File, newpath string) error {
 	if f.dryRun {
@@ -1,6 +1,17 @@
 package storage
 
-import "testing"
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/peak/s5cmd/v2/storage/url"
+)
 
 func TestFilesystemImplementsStorageInterface(t *testing.T) {
 	var i interface{} = new(Filesystem)
@@ -8,3 +19,128 @@ func TestFilesystemImplementsStorageInterface(t *testing.T) {
 		t.Errorf("expected %t to implement Storage interface", i)
 	}
 }
+
+func TestFilesystemListSymlinksAsObjects(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links are not supported on Windows")
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "a"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a", "file.txt"), []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("a/file.txt", filepath.Join(dir, "filelink")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("a", filepath.Join(dir, "dirlink")); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := url.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewLocalClient(Options{SymlinksAsObjects: true})
+
+	type object struct {
+		Path    string
+		Size    int64
+		Symlink bool
+	}
+
+	var got []object
+	for obj := range f.List(context.Background(), src, true) {
+		if obj.Err != nil {
+			t.Fatal(obj.Err)
+		}
+		path, err := filepath.Rel(dir, obj.URL.Absolute())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, object{
+			Path:    filepath.ToSlash(path),
+			Size:    obj.Size,
+			Symlink: obj.Type.IsSymlink(),
+		})
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].Path < got[j].Path })
+
+	// the links are listed as zero-byte objects and they are not followed.
+	expected := []object{
+		{Path: "a/file.txt", Size: 7},
+		{Path: "dirlink", Symlink: true},
+		{Path: "filelink", Symlink: true},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("(-want +got):\n%v", diff)
+	}
+}
+
+func TestFilesystemListEmptyDirs(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"a/empty", "b", "c"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b", "file.txt"), []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	type object struct {
+		Path string
+		Size int64
+		Dir  bool
+	}
+
+	list := func(path string, opts Options) []object {
+		src, err := url.New(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []object
+		for obj := range NewLocalClient(opts).List(context.Background(), src, true) {
+			if obj.Err != nil {
+				t.Fatal(obj.Err)
+			}
+			path, err := filepath.Rel(dir, obj.URL.Absolute())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, object{
+				Path: filepath.ToSlash(path),
+				Size: obj.Size,
+				Dir:  obj.Type.IsDir(),
+			})
+		}
+		sort.Slice(got, func(i, j int) bool { return got[i].Path < got[j].Path })
+		return got
+	}
+
+	// only the empty directories are listed, as zero-byte objects.
+	expected := []object{
+		{Path: "a/empty", Dir: true},
+		{Path: "b/file.txt", Size: 7},
+		{Path: "c", Dir: true},
+	}
+	if diff := cmp.Diff(expected, list(dir, Options{EmptyDirs: true})); diff != "" {
+		t.Errorf("(-want +got):\n%v", diff)
+	}
+
+	// the matched directories are listed as well.
+	if diff := cmp.Diff(expected, list(filepath.Join(dir, "*"), Options{EmptyDirs: true})); diff != "" {
+		t.Errorf("(-want +got):\n%v", diff)
+	}
+
+	expected = []object{
+		{Path: "b/file.txt", Size: 7},
+	}
+	if diff := cmp.Diff(expected, list(dir, Options{})); diff != "" {
+		t.Errorf("(-want +got):\n%v", diff)
+	}
+}
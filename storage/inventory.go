@@ -0,0 +1,439 @@
+package storage
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	urlpkg "net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/v2/storage/url"
+)
+
+// InventoryManifest is the manifest of an S3 Inventory report.
+type InventoryManifest struct {
+	SourceBucket      string          `json:"sourceBucket"`
+	DestinationBucket string          `json:"destinationBucket"`
+	FileFormat        string          `json:"fileFormat"`
+	FileSchema        string          `json:"fileSchema"`
+	Files             []InventoryFile `json:"files"`
+}
+
+// isParquet reports whether the data files of the report are Apache Parquet
+// files, instead of gzipped CSV files.
+func (m *InventoryManifest) isParquet() bool {
+	return strings.EqualFold(m.FileFormat, "Parquet")
+}
+
+// InventoryFile is a data file of an S3 Inventory report.
+type InventoryFile struct {
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	MD5Checksum string `json:"MD5checksum"`
+}
+
+// ListInventory returns the objects of the S3 Inventory report of the given
+// manifest which match any of the given source URLs. The objects are
+// matched as if the sources were listed, e.g. a prefix without a wildcard
+// only matches the objects directly under it. If the sources are for all
+// versions, all the versions and delete markers in the report are returned
+// with their version IDs. Only CSV and Parquet reports are supported, the
+// manifests of ORC reports are rejected with an error before any object is
+// returned.
+func (s *S3) ListInventory(ctx context.Context, manifestURL *url.URL, srcurls ...*url.URL) (<-chan *Object, error) {
+	manifest, err := s.readInventoryManifest(ctx, manifestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	objCh := make(chan *Object)
+
+	go func() {
+		defer close(objCh)
+
+		for _, srcurl := range srcurls {
+			if srcurl.Bucket != manifest.SourceBucket {
+				err := fmt.Errorf("inventory of bucket %q can not be used for %q", manifest.SourceBucket, srcurl)
+				sendError(ctx, err, objCh)
+				return
+			}
+		}
+
+		var (
+			schema *inventorySchema
+			err    error
+		)
+		if manifest.isParquet() {
+			schema, err = newParquetInventorySchema(manifest.FileSchema)
+		} else {
+			schema, err = newInventorySchema(manifest.FileSchema)
+		}
+		if err != nil {
+			sendError(ctx, err, objCh)
+			return
+		}
+
+		allVersions := false
+		for _, srcurl := range srcurls {
+			allVersions = allVersions || srcurl.AllVersions
+		}
+
+		if allVersions && schema.versionID < 0 {
+			err := fmt.Errorf("inventory file schema %q does not include VersionId field", manifest.FileSchema)
+			sendError(ctx, err, objCh)
+			return
+		}
+
+		dataBucket := strings.TrimPrefix(manifest.DestinationBucket, "arn:aws:s3:::")
+
+		objectFound := false
+		for _, file := range manifest.Files {
+			fileurl := manifestURL.Clone()
+			fileurl.Bucket = dataBucket
+			fileurl.Path = file.Key
+
+			err := s.readInventoryFile(ctx, fileurl, file, schema, func(record []string) error {
+				obj, key, err := schema.object(record, allVersions)
+				if err != nil {
+					return err
+				}
+				if obj == nil {
+					return nil
+				}
+
+				for _, srcurl := range srcurls {
+					if newurl, ok := matchInventoryKey(srcurl, key); ok {
+						if allVersions {
+							newurl.VersionID = schema.field(record, schema.versionID)
+						}
+						obj.URL = newurl
+						objectFound = true
+						sendObject(ctx, obj, objCh)
+						break
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				sendError(ctx, fmt.Errorf("inventory file %q: %w", fileurl, err), objCh)
+				return
+			}
+		}
+
+		if !objectFound {
+			sendError(ctx, ErrNoObjectFound, objCh)
+		}
+	}()
+
+	return objCh, nil
+}
+
+// readInventoryManifest reads the manifest, and validates it with the
+// "manifest.checksum" file next to it if there is one. The manifests of the
+// Apache ORC reports are rejected since their data files can't be read.
+func (s *S3) readInventoryManifest(ctx context.Context, manifestURL *url.URL) (*InventoryManifest, error) {
+	content, err := s.readAll(ctx, manifestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	checksumURL := manifestURL.Clone()
+	checksumURL.Path = path.Join(path.Dir(manifestURL.Path), "manifest.checksum")
+
+	checksum, err := s.readAll(ctx, checksumURL)
+	switch {
+	case err == nil:
+		sum := md5.Sum(content)
+		if !strings.EqualFold(strings.TrimSpace(string(checksum)), hex.EncodeToString(sum[:])) {
+			return nil, fmt.Errorf("inventory manifest %q does not match its checksum", manifestURL)
+		}
+	case !isNoSuchKey(err):
+		return nil, err
+	}
+
+	var manifest InventoryManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid inventory manifest %q: %w", manifestURL, err)
+	}
+	if !strings.EqualFold(manifest.FileFormat, "CSV") && !manifest.isParquet() {
+		return nil, fmt.Errorf("unsupported file format %q of inventory manifest %q: only CSV and Parquet inventory reports are supported", manifest.FileFormat, manifestURL)
+	}
+	return &manifest, nil
+}
+
+func (s *S3) readAll(ctx context.Context, src *url.URL) ([]byte, error) {
+	rc, err := s.Read(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+// readInventoryFile downloads the given data file, validates its checksum and
+// calls fn for each of its records. The data file is either a gzipped CSV
+// file, or a Parquet file whose records are the columns of the schema.
+func (s *S3) readInventoryFile(ctx context.Context, fileurl *url.URL, file InventoryFile, schema *inventorySchema, fn func(record []string) error) error {
+	rc, err := s.Read(ctx, fileurl)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// the data file is validated before reading any of its records.
+	tmp, err := os.CreateTemp("", "s5cmd-inventory-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), rc); err != nil {
+		return err
+	}
+
+	if file.MD5Checksum != "" && !strings.EqualFold(file.MD5Checksum, hex.EncodeToString(hash.Sum(nil))) {
+		return fmt.Errorf("checksum mismatch")
+	}
+
+	if schema.columns != nil {
+		info, err := tmp.Stat()
+		if err != nil {
+			return err
+		}
+		parquet, err := openParquetFile(tmp, info.Size())
+		if err != nil {
+			return err
+		}
+		return parquet.readRows(schema.columns, fn)
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	var r io.Reader = tmp
+	if strings.HasSuffix(file.Key, ".gz") {
+		gzr, err := gzip.NewReader(tmp)
+		if err != nil {
+			return err
+		}
+		defer gzr.Close()
+		r = gzr
+	}
+
+	csvr := csv.NewReader(r)
+	csvr.FieldsPerRecord = -1
+	csvr.ReuseRecord = true
+	for {
+		record, err := csvr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// inventorySchema holds the column indexes of the fields of an inventory
+// report.
+type inventorySchema struct {
+	bucket, key, versionID, size, lastModified, etag, storageClass, isLatest, isDeleteMarker int
+
+	// columns are the names of the columns of the Parquet reports, in the
+	// order of the fields. It's nil for the CSV reports.
+	columns []string
+	// encodedKeys is set if the keys are URL encoded, as in the CSV reports.
+	encodedKeys bool
+}
+
+// parquetInventoryFields maps the columns of the Parquet reports to the
+// fields of the CSV reports.
+var parquetInventoryFields = map[string]string{
+	"bucket":             "Bucket",
+	"key":                "Key",
+	"version_id":         "VersionId",
+	"size":               "Size",
+	"last_modified_date": "LastModifiedDate",
+	"e_tag":              "ETag",
+	"storage_class":      "StorageClass",
+	"is_latest":          "IsLatest",
+	"is_delete_marker":   "IsDeleteMarker",
+}
+
+// newInventorySchema creates the schema of a CSV report from the comma
+// separated fields of its manifest, e.g. "Bucket, Key, Size".
+func newInventorySchema(fileSchema string) (*inventorySchema, error) {
+	schema := inventorySchemaOf(strings.Split(fileSchema, ","))
+	if schema.bucket < 0 || schema.key < 0 {
+		return nil, fmt.Errorf("invalid inventory file schema %q: Bucket and Key fields are required", fileSchema)
+	}
+	schema.encodedKeys = true
+	return schema, nil
+}
+
+// newParquetInventorySchema creates the schema of a Parquet report from the
+// message of its manifest, e.g.
+// "message s3.inventory { required binary bucket; required binary key; }".
+func newParquetInventorySchema(fileSchema string) (*inventorySchema, error) {
+	start, end := strings.Index(fileSchema, "{"), strings.LastIndex(fileSchema, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("invalid inventory file schema %q", fileSchema)
+	}
+
+	var columns, fields []string
+	for _, column := range strings.Split(fileSchema[start+1:end], ";") {
+		// e.g. "optional int64 last_modified_date (TIMESTAMP_MILLIS)"
+		words := strings.Fields(column)
+		if len(words) < 3 {
+			continue
+		}
+		name := words[2]
+		columns = append(columns, name)
+		if field, ok := parquetInventoryFields[name]; ok {
+			name = field
+		}
+		fields = append(fields, name)
+	}
+
+	schema := inventorySchemaOf(fields)
+	if schema.bucket < 0 || schema.key < 0 {
+		return nil, fmt.Errorf("invalid inventory file schema %q: bucket and key fields are required", fileSchema)
+	}
+	schema.columns = columns
+	return schema, nil
+}
+
+// inventorySchemaOf returns the schema of the given fields of a CSV report.
+func inventorySchemaOf(fields []string) *inventorySchema {
+	schema := &inventorySchema{-1, -1, -1, -1, -1, -1, -1, -1, -1, nil, false}
+	for i, field := range fields {
+		switch strings.TrimSpace(field) {
+		case "Bucket":
+			schema.bucket = i
+		case "Key":
+			schema.key = i
+		case "VersionId":
+			schema.versionID = i
+		case "Size":
+			schema.size = i
+		case "LastModifiedDate":
+			schema.lastModified = i
+		case "ETag":
+			schema.etag = i
+		case "StorageClass":
+			schema.storageClass = i
+		case "IsLatest":
+			schema.isLatest = i
+		case "IsDeleteMarker":
+			schema.isDeleteMarker = i
+		}
+	}
+	return schema
+}
+
+// field returns the field of the given record at the given column index, or
+// an empty string if the report doesn't have the column.
+func (sc *inventorySchema) field(record []string, i int) string {
+	if i < 0 || i >= len(record) {
+		return ""
+	}
+	return record[i]
+}
+
+// object creates an object, without its URL, from the given record and
+// returns it with its key. It returns nil for the records of delete markers
+// and noncurrent versions, unless all versions are asked for.
+func (sc *inventorySchema) object(record []string, allVersions bool) (*Object, string, error) {
+	field := func(i int) string {
+		return sc.field(record, i)
+	}
+
+	isDeleteMarker := field(sc.isDeleteMarker) == "true"
+	if !allVersions && (field(sc.isLatest) == "false" || isDeleteMarker) {
+		return nil, "", nil
+	}
+
+	// keys are URL encoded in the CSV inventory reports.
+	key := field(sc.key)
+	var err error
+	if sc.encodedKeys {
+		key, err = urlpkg.QueryUnescape(key)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	obj := &Object{
+		Etag:           strings.Trim(field(sc.etag), `"`),
+		StorageClass:   StorageClass(field(sc.storageClass)),
+		IsDeleteMarker: isDeleteMarker,
+	}
+
+	if size := field(sc.size); size != "" {
+		obj.Size, err = strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	if lastModified := field(sc.lastModified); lastModified != "" {
+		mod, err := time.Parse(time.RFC3339Nano, lastModified)
+		if err != nil {
+			return nil, "", err
+		}
+		mod = mod.UTC()
+		obj.ModTime = &mod
+	}
+
+	if strings.HasSuffix(key, "/") {
+		obj.Type = ObjectType{os.ModeDir}
+	}
+
+	return obj, key, nil
+}
+
+// matchInventoryKey checks if the given key would be listed from the given
+// source URL, and returns the URL of the key relative to the source.
+func matchInventoryKey(srcurl *url.URL, key string) (*url.URL, bool) {
+	if srcurl.IsExcludedPrefix(key) || !srcurl.Match(key) {
+		return nil, false
+	}
+
+	// non-wildcard URLs are listed with a delimiter.
+	if srcurl.Delimiter != "" && strings.Contains(strings.TrimPrefix(key, srcurl.Prefix), srcurl.Delimiter) {
+		return nil, false
+	}
+
+	newurl := srcurl.Clone()
+	newurl.Path = key
+	return newurl, true
+}
+
+func isNoSuchKey(err error) bool {
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		return awsErr.Code() == s3.ErrCodeNoSuchKey
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/peak/s5cmd/v2/storage/url"
+)
+
+func TestInventorySchemaObject(t *testing.T) {
+	t.Parallel()
+
+	schema, err := newInventorySchema("Bucket, Key, VersionId, IsLatest, IsDeleteMarker, Size, LastModifiedDate, ETag")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		name     string
+		record   []string
+		wantKey  string
+		wantSize int64
+		wantNil  bool
+	}{
+		{
+			name:     "latest version",
+			record:   []string{"bucket", "dir/file+name%21.txt", "v1", "true", "false", "42", "2023-01-02T03:04:05.000Z", "d41d8cd98f00b204e9800998ecf8427e"},
+			wantKey:  "dir/file name!.txt",
+			wantSize: 42,
+		},
+		{
+			name:    "noncurrent version",
+			record:  []string{"bucket", "file.txt", "v1", "false", "false", "42", "2023-01-02T03:04:05.000Z", "etag"},
+			wantNil: true,
+		},
+		{
+			name:    "delete marker",
+			record:  []string{"bucket", "file.txt", "v2", "true", "true", "", "2023-01-02T03:04:05.000Z", ""},
+			wantNil: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			obj, key, err := schema.object(tc.record, false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.wantNil {
+				if obj != nil {
+					t.Fatalf("expected no object, got %+v", obj)
+				}
+				return
+			}
+
+			if key != tc.wantKey {
+				t.Errorf("expected key %q, got %q", tc.wantKey, key)
+			}
+			if obj.Size != tc.wantSize {
+				t.Errorf("expected size %v, got %v", tc.wantSize, obj.Size)
+			}
+			if obj.ModTime == nil || obj.ModTime.Year() != 2023 {
+				t.Errorf("unexpected modification time %v", obj.ModTime)
+			}
+		})
+	}
+}
+
+func TestInventorySchemaObjectAllVersions(t *testing.T) {
+	t.Parallel()
+
+	schema, err := newInventorySchema("Bucket, Key, VersionId, IsLatest, IsDeleteMarker, Size")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := [][]string{
+		{"bucket", "file.txt", "v1", "false", "false", "42"},
+		{"bucket", "file.txt", "v2", "true", "true", ""},
+	}
+
+	for _, record := range records {
+		obj, key, err := schema.object(record, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if obj == nil {
+			t.Fatalf("expected an object for version %q", record[2])
+		}
+		if key != "file.txt" {
+			t.Errorf("expected key %q, got %q", "file.txt", key)
+		}
+		if got := schema.field(record, schema.versionID); got != record[2] {
+			t.Errorf("expected version %q, got %q", record[2], got)
+		}
+		if expected := record[4] == "true"; obj.IsDeleteMarker != expected {
+			t.Errorf("expected delete marker %v, got %v", expected, obj.IsDeleteMarker)
+		}
+	}
+}
+
+func TestNewInventorySchemaRequiresKey(t *testing.T) {
+	t.Parallel()
+
+	if _, err := newInventorySchema("Bucket, Size"); err == nil {
+		t.Fatal("expected an error for a schema without Key field")
+	}
+}
+
+func TestNewParquetInventorySchema(t *testing.T) {
+	t.Parallel()
+
+	schema, err := newParquetInventorySchema("message s3.inventory { required binary bucket (UTF8); required binary key (UTF8); optional int64 size; optional int64 last_modified_date (TIMESTAMP_MILLIS); }")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"bucket", "key", "size", "last_modified_date"}
+	if !reflect.DeepEqual(schema.columns, expected) {
+		t.Errorf("expected columns %v, got %v", expected, schema.columns)
+	}
+
+	// keys are not URL encoded in the Parquet reports.
+	obj, key, err := schema.object([]string{"bucket", "dir/file+name%21.txt", "42", "2023-01-02T03:04:05Z"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "dir/file+name%21.txt" {
+		t.Errorf("expected key %q, got %q", "dir/file+name%21.txt", key)
+	}
+	if obj.Size != 42 {
+		t.Errorf("expected size %v, got %v", 42, obj.Size)
+	}
+
+	if _, err := newParquetInventorySchema("message s3.inventory { required binary bucket; }"); err == nil {
+		t.Fatal("expected an error for a schema without key column")
+	}
+}
+
+func TestMatchInventoryKey(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		src     string
+		key     string
+		matched bool
+	}{
+		{src: "s3://bucket/*", key: "a/b/c.txt", matched: true},
+		{src: "s3://bucket/a/*.txt", key: "a/b/c.txt", matched: true},
+		{src: "s3://bucket/a/*.txt", key: "b/c.txt", matched: false},
+		{src: "s3://bucket/a/", key: "a/c.txt", matched: true},
+		{src: "s3://bucket/a/", key: "a/b/c.txt", matched: false},
+		{src: "s3://bucket/a/c.txt", key: "a/c.txt", matched: true},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.src+" "+tc.key, func(t *testing.T) {
+			t.Parallel()
+
+			srcurl, err := url.New(tc.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			newurl, ok := matchInventoryKey(srcurl, tc.key)
+			if ok != tc.matched {
+				t.Fatalf("expected matched=%v, got %v", tc.matched, ok)
+			}
+			if ok && newurl.Path != tc.key {
+				t.Errorf("expected path %q, got %q", tc.key, newurl.Path)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// LatencyBuckets are the upper bounds of the buckets of the request latency
+// histogram. The latencies which exceed the last bound are counted in an
+// extra bucket.
+var LatencyBuckets = []time.Duration{
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// RequestMetrics is a snapshot of the metrics of the requests sent to the
+// remote storage.
+type RequestMetrics struct {
+	// Requests is the number of the request attempts, including the retries.
+	Requests int64
+	// Throttled is the number of the request attempts which are throttled by
+	// the remote storage.
+	Throttled int64
+	// MaxInFlight is the maximum number of the requests which are in flight at
+	// the same time.
+	MaxInFlight int64
+	// TotalLatency is the sum of the latencies of the requests.
+	TotalLatency time.Duration
+	MinLatency   time.Duration
+	MaxLatency   time.Duration
+	// Histogram is the number of the requests in each bucket of
+	// LatencyBuckets, and the requests exceeding the last bucket.
+	Histogram []int64
+}
+
+// Percentile returns the upper bound of the histogram bucket that the given
+// percentile of the request latencies falls into. The latencies exceeding the
+// last bucket are reported as the maximum latency.
+func (m RequestMetrics) Percentile(p float64) time.Duration {
+	if m.Requests == 0 {
+		return 0
+	}
+
+	rank := int64(math.Ceil(p / 100 * float64(m.Requests)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	var count int64
+	for i, n := range m.Histogram {
+		count += n
+		if count < rank {
+			continue
+		}
+		if i < len(LatencyBuckets) && LatencyBuckets[i] < m.MaxLatency {
+			return LatencyBuckets[i]
+		}
+		return m.MaxLatency
+	}
+	return m.MaxLatency
+}
+
+// requestMetrics collects the metrics of the requests when it's enabled. The
+// request handlers of all sessions record to the same collector, so the
+// metrics cover all the commands of the process.
+type requestMetrics struct {
+	enabled atomic.Bool
+
+	mu       sync.Mutex
+	inflight int64
+	metrics  RequestMetrics
+}
+
+var metrics requestMetrics
+
+// EnableRequestMetrics resets and starts collecting the metrics of the
+// requests sent to the remote storage.
+func EnableRequestMetrics() {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	metrics.inflight = 0
+	metrics.metrics = RequestMetrics{Histogram: make([]int64, len(LatencyBuckets)+1)}
+	metrics.enabled.Store(true)
+}
+
+// CollectRequestMetrics returns a snapshot of the metrics of the requests
+// sent since the metrics are enabled.
+func CollectRequestMetrics() RequestMetrics {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	m := metrics.metrics
+	m.Histogram = append([]int64(nil), metrics.metrics.Histogram...)
+	return m
+}
+
+// start is a send handler which marks the request attempt in flight.
+func (rm *requestMetrics) start(r *request.Request) {
+	if !rm.enabled.Load() {
+		return
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.inflight++
+	if rm.inflight > rm.metrics.MaxInFlight {
+		rm.metrics.MaxInFlight = rm.inflight
+	}
+}
+
+// complete is a complete attempt handler which records the latency of the
+// request attempt. The attempts which are completed before the metrics are
+// enabled are not recorded.
+func (rm *requestMetrics) complete(r *request.Request) {
+	if !rm.enabled.Load() {
+		return
+	}
+
+	latency := time.Since(r.AttemptTime)
+	throttled := r.Error != nil && retryClass(r) == RetryClassThrottle
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if rm.inflight == 0 {
+		return
+	}
+	rm.inflight--
+
+	m := &rm.metrics
+	m.Requests++
+	if throttled {
+		m.Throttled++
+	}
+
+	m.TotalLatency += latency
+	if m.MinLatency == 0 || latency < m.MinLatency {
+		m.MinLatency = latency
+	}
+	if latency > m.MaxLatency {
+		m.MaxLatency = latency
+	}
+
+	bucket := len(LatencyBuckets)
+	for i, bound := range LatencyBuckets {
+		if latency <= bound {
+			bucket = i
+			break
+		}
+	}
+	m.Histogram[bucket]++
+}
+
+// requestObserver is called with the outcome of each request attempt, if it's
+// set.
+var requestObserver atomic.Pointer[func(overloaded bool)]
+
+// ObserveRequests sets the function which is called after each request
+// attempt to the remote storage. overloaded is set if the attempt is throttled
+// or failed with a 5xx error.
+func ObserveRequests(fn func(overloaded bool)) {
+	requestObserver.Store(&fn)
+}
+
+// observeRequest is a complete attempt handler which reports the outcome of
+// the request attempt to the request observer.
+func observeRequest(r *request.Request) {
+	fn := requestObserver.Load()
+	if fn == nil {
+		return
+	}
+
+	var overloaded bool
+	if r.Error != nil {
+		switch retryClass(r) {
+		case RetryClassThrottle, RetryClass5xx:
+			overloaded = true
+		}
+	}
+	(*fn)(overloaded)
+}
@@ -0,0 +1,1078 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// The reader of the Apache Parquet files below supports only what is needed
+// to read the data files of the S3 Inventory reports: the flat columns of
+// primitive types, PLAIN and dictionary encodings, and uncompressed, SNAPPY
+// and GZIP compressed pages. The nested columns are skipped.
+//
+// See https://github.com/apache/parquet-format for the file format.
+
+var parquetMagic = []byte("PAR1")
+
+// physical types of the columns.
+const (
+	parquetBoolean           = 0
+	parquetInt32             = 1
+	parquetInt64             = 2
+	parquetInt96             = 3
+	parquetFloat             = 4
+	parquetDouble            = 5
+	parquetByteArray         = 6
+	parquetFixedLenByteArray = 7
+)
+
+// repetition types of the columns.
+const (
+	parquetRequired = 0
+	parquetOptional = 1
+)
+
+// converted types of the timestamp columns.
+const (
+	parquetTimestampMillis = 9
+	parquetTimestampMicros = 10
+)
+
+// compression codecs of the pages.
+const (
+	parquetUncompressed = 0
+	parquetSnappy       = 1
+	parquetGzip         = 2
+)
+
+// page types.
+const (
+	parquetDataPage       = 0
+	parquetDictionaryPage = 2
+	parquetDataPageV2     = 3
+)
+
+// encodings of the values and the levels.
+const (
+	parquetPlain           = 0
+	parquetPlainDictionary = 2
+	parquetRLE             = 3
+	parquetRLEDictionary   = 8
+)
+
+// parquetFile is an Apache Parquet file.
+type parquetFile struct {
+	r       io.ReaderAt
+	columns []parquetColumn
+	groups  []parquetRowGroup
+}
+
+// parquetColumn is a leaf column of the schema of a Parquet file.
+type parquetColumn struct {
+	name       string
+	typ        int32
+	typeLength int32
+	repetition int32
+	// timeUnit is the duration of a unit of the timestamp columns, or zero.
+	timeUnit time.Duration
+	// nested is set for the columns in a group, which are not supported.
+	nested bool
+}
+
+type parquetRowGroup struct {
+	numRows int64
+	chunks  []parquetColumnChunk
+}
+
+type parquetColumnChunk struct {
+	codec                int32
+	numValues            int64
+	totalCompressedSize  int64
+	dataPageOffset       int64
+	dictionaryPageOffset int64
+}
+
+// openParquetFile reads the metadata of the Parquet file of the given size.
+func openParquetFile(r io.ReaderAt, size int64) (*parquetFile, error) {
+	// the file ends with the length of the metadata and the magic number.
+	if size < int64(2*len(parquetMagic)+4) {
+		return nil, fmt.Errorf("not a parquet file")
+	}
+
+	footer := make([]byte, 8)
+	if _, err := r.ReadAt(footer, size-8); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(footer[4:], parquetMagic) {
+		return nil, fmt.Errorf("not a parquet file")
+	}
+
+	metaLength := int64(binary.LittleEndian.Uint32(footer))
+	if metaLength > size-8-int64(len(parquetMagic)) {
+		return nil, fmt.Errorf("invalid parquet metadata length %d", metaLength)
+	}
+
+	meta := make([]byte, metaLength)
+	if _, err := r.ReadAt(meta, size-8-metaLength); err != nil {
+		return nil, err
+	}
+
+	f := &parquetFile{r: r}
+	if err := f.readMetadata(&thriftReader{buf: meta}); err != nil {
+		return nil, fmt.Errorf("invalid parquet metadata: %w", err)
+	}
+	return f, nil
+}
+
+// readMetadata reads the FileMetaData structure.
+func (f *parquetFile) readMetadata(tr *thriftReader) error {
+	return tr.readStruct(func(id int16, typ byte) error {
+		switch id {
+		case 2:
+			var elements []parquetSchemaElement
+			err := tr.readList(func(byte) error {
+				element, err := readParquetSchemaElement(tr)
+				elements = append(elements, element)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			f.columns = parquetLeafColumns(elements)
+			return nil
+		case 4:
+			return tr.readList(func(byte) error {
+				group, err := readParquetRowGroup(tr)
+				f.groups = append(f.groups, group)
+				return err
+			})
+		default:
+			return tr.skip(typ)
+		}
+	})
+}
+
+type parquetSchemaElement struct {
+	column      parquetColumn
+	numChildren int32
+}
+
+// readParquetSchemaElement reads a SchemaElement structure.
+func readParquetSchemaElement(tr *thriftReader) (parquetSchemaElement, error) {
+	var element parquetSchemaElement
+	err := tr.readStruct(func(id int16, typ byte) error {
+		var err error
+		switch id {
+		case 1:
+			element.column.typ, err = tr.readI32()
+		case 2:
+			element.column.typeLength, err = tr.readI32()
+		case 3:
+			element.column.repetition, err = tr.readI32()
+		case 4:
+			var name []byte
+			name, err = tr.readBinary()
+			element.column.name = string(name)
+		case 5:
+			element.numChildren, err = tr.readI32()
+		case 6:
+			var convertedType int32
+			convertedType, err = tr.readI32()
+			switch convertedType {
+			case parquetTimestampMillis:
+				element.column.timeUnit = time.Millisecond
+			case parquetTimestampMicros:
+				element.column.timeUnit = time.Microsecond
+			}
+		case 10:
+			var unit time.Duration
+			unit, err = readParquetTimestampUnit(tr)
+			if unit != 0 {
+				element.column.timeUnit = unit
+			}
+		default:
+			err = tr.skip(typ)
+		}
+		return err
+	})
+	return element, err
+}
+
+// readParquetTimestampUnit reads the unit of the LogicalType union if it's a
+// timestamp, or returns zero.
+func readParquetTimestampUnit(tr *thriftReader) (time.Duration, error) {
+	var unit time.Duration
+	err := tr.readStruct(func(id int16, typ byte) error {
+		// TimestampType
+		if id != 8 {
+			return tr.skip(typ)
+		}
+		return tr.readStruct(func(id int16, typ byte) error {
+			// TimeUnit
+			if id != 2 {
+				return tr.skip(typ)
+			}
+			return tr.readStruct(func(id int16, typ byte) error {
+				switch id {
+				case 1:
+					unit = time.Millisecond
+				case 2:
+					unit = time.Microsecond
+				case 3:
+					unit = time.Nanosecond
+				}
+				return tr.skip(typ)
+			})
+		})
+	})
+	return unit, err
+}
+
+// parquetLeafColumns returns the leaf columns of the given flattened schema
+// tree, in the order of the column chunks of the row groups.
+func parquetLeafColumns(elements []parquetSchemaElement) []parquetColumn {
+	var (
+		columns []parquetColumn
+		walk    func(i int, nested bool) int
+	)
+
+	// walk adds the leaves of the element at the given index and returns the
+	// index of the next element.
+	walk = func(i int, nested bool) int {
+		if i >= len(elements) {
+			return i
+		}
+		element := elements[i]
+		if element.numChildren == 0 {
+			column := element.column
+			column.nested = nested
+			columns = append(columns, column)
+			return i + 1
+		}
+
+		next := i + 1
+		for c := int32(0); c < element.numChildren; c++ {
+			next = walk(next, true)
+		}
+		return next
+	}
+
+	if len(elements) == 0 {
+		return nil
+	}
+
+	// the first element is the root of the schema.
+	next := 1
+	for c := int32(0); c < elements[0].numChildren; c++ {
+		next = walk(next, false)
+	}
+	return columns
+}
+
+// readParquetRowGroup reads a RowGroup structure.
+func readParquetRowGroup(tr *thriftReader) (parquetRowGroup, error) {
+	var group parquetRowGroup
+	err := tr.readStruct(func(id int16, typ byte) error {
+		var err error
+		switch id {
+		case 1:
+			err = tr.readList(func(byte) error {
+				chunk, err := readParquetColumnChunk(tr)
+				group.chunks = append(group.chunks, chunk)
+				return err
+			})
+		case 3:
+			group.numRows, err = tr.readI64()
+		default:
+			err = tr.skip(typ)
+		}
+		return err
+	})
+	return group, err
+}
+
+// readParquetColumnChunk reads the ColumnMetaData of a ColumnChunk structure.
+func readParquetColumnChunk(tr *thriftReader) (parquetColumnChunk, error) {
+	var chunk parquetColumnChunk
+	err := tr.readStruct(func(id int16, typ byte) error {
+		if id != 3 {
+			return tr.skip(typ)
+		}
+		return tr.readStruct(func(id int16, typ byte) error {
+			var err error
+			switch id {
+			case 4:
+				chunk.codec, err = tr.readI32()
+			case 5:
+				chunk.numValues, err = tr.readI64()
+			case 7:
+				chunk.totalCompressedSize, err = tr.readI64()
+			case 9:
+				chunk.dataPageOffset, err = tr.readI64()
+			case 11:
+				chunk.dictionaryPageOffset, err = tr.readI64()
+			default:
+				err = tr.skip(typ)
+			}
+			return err
+		})
+	})
+	return chunk, err
+}
+
+// readRows calls fn for each row of the file with the values of the given
+// columns, in the order of the column names. The values are formatted as in
+// the CSV inventory reports, and the null values and the columns which are
+// not in the file are empty strings.
+func (f *parquetFile) readRows(names []string, fn func(record []string) error) error {
+	indexes := make([]int, len(names))
+	for i, name := range names {
+		indexes[i] = -1
+		for c, column := range f.columns {
+			if column.name != name || column.nested {
+				continue
+			}
+			if column.repetition != parquetRequired && column.repetition != parquetOptional {
+				return fmt.Errorf("repeated parquet column %q is not supported", name)
+			}
+			indexes[i] = c
+			break
+		}
+	}
+
+	record := make([]string, len(names))
+	for _, group := range f.groups {
+		if len(group.chunks) != len(f.columns) {
+			return fmt.Errorf("parquet row group has %d columns, expected %d", len(group.chunks), len(f.columns))
+		}
+
+		values := make([][]string, len(names))
+		for i, c := range indexes {
+			if c < 0 {
+				continue
+			}
+			column, err := f.readColumnChunk(f.columns[c], group.chunks[c], group.numRows)
+			if err != nil {
+				return fmt.Errorf("parquet column %q: %w", f.columns[c].name, err)
+			}
+			values[i] = column
+		}
+
+		for row := int64(0); row < group.numRows; row++ {
+			for i := range record {
+				record[i] = ""
+				if values[i] != nil {
+					record[i] = values[i][row]
+				}
+			}
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readColumnChunk reads the values of the given chunk of the given column.
+func (f *parquetFile) readColumnChunk(column parquetColumn, chunk parquetColumnChunk, numRows int64) ([]string, error) {
+	if chunk.numValues != numRows {
+		return nil, fmt.Errorf("column chunk has %d values, expected %d", chunk.numValues, numRows)
+	}
+
+	offset := chunk.dataPageOffset
+	if chunk.dictionaryPageOffset > 0 && chunk.dictionaryPageOffset < offset {
+		offset = chunk.dictionaryPageOffset
+	}
+
+	buf := make([]byte, chunk.totalCompressedSize)
+	if _, err := f.r.ReadAt(buf, offset); err != nil {
+		return nil, err
+	}
+
+	var (
+		values     = make([]string, 0, numRows)
+		dictionary []string
+	)
+	tr := &thriftReader{buf: buf}
+	for int64(len(values)) < numRows {
+		header, err := readParquetPageHeader(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		end := tr.pos + int(header.compressedSize)
+		if header.compressedSize < 0 || end > len(buf) {
+			return nil, fmt.Errorf("invalid page size %d", header.compressedSize)
+		}
+		page := buf[tr.pos:end]
+		tr.pos = end
+
+		switch header.typ {
+		case parquetDictionaryPage:
+			data, err := decompressParquetPage(chunk.codec, page, header.uncompressedSize)
+			if err != nil {
+				return nil, err
+			}
+			dictionary, err = decodeParquetPlain(column, data, int(header.numValues))
+			if err != nil {
+				return nil, err
+			}
+		case parquetDataPage, parquetDataPageV2:
+			values, err = readParquetDataPage(column, chunk.codec, header, page, dictionary, values)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// the other pages, e.g. the index pages, are skipped.
+	}
+	return values, nil
+}
+
+type parquetPageHeader struct {
+	typ              int32
+	uncompressedSize int32
+	compressedSize   int32
+
+	numValues int32
+	encoding  int32
+
+	// the fields of the data pages of version 2.
+	numNulls        int32
+	defLevelsLength int32
+	repLevelsLength int32
+	isCompressed    bool
+}
+
+// readParquetPageHeader reads a PageHeader structure.
+func readParquetPageHeader(tr *thriftReader) (parquetPageHeader, error) {
+	header := parquetPageHeader{isCompressed: true}
+	err := tr.readStruct(func(id int16, typ byte) error {
+		var err error
+		switch id {
+		case 1:
+			header.typ, err = tr.readI32()
+		case 2:
+			header.uncompressedSize, err = tr.readI32()
+		case 3:
+			header.compressedSize, err = tr.readI32()
+		case 5, 7:
+			// DataPageHeader and DictionaryPageHeader
+			err = tr.readStruct(func(id int16, typ byte) error {
+				var err error
+				switch id {
+				case 1:
+					header.numValues, err = tr.readI32()
+				case 2:
+					header.encoding, err = tr.readI32()
+				default:
+					err = tr.skip(typ)
+				}
+				return err
+			})
+		case 8:
+			// DataPageHeaderV2
+			err = tr.readStruct(func(id int16, typ byte) error {
+				var err error
+				switch id {
+				case 1:
+					header.numValues, err = tr.readI32()
+				case 2:
+					header.numNulls, err = tr.readI32()
+				case 4:
+					header.encoding, err = tr.readI32()
+				case 5:
+					header.defLevelsLength, err = tr.readI32()
+				case 6:
+					header.repLevelsLength, err = tr.readI32()
+				case 7:
+					header.isCompressed = typ == thriftTrue
+				default:
+					err = tr.skip(typ)
+				}
+				return err
+			})
+		default:
+			err = tr.skip(typ)
+		}
+		return err
+	})
+	return header, err
+}
+
+// readParquetDataPage decodes the values of the given data page and appends
+// them to the given values.
+func readParquetDataPage(
+	column parquetColumn,
+	codec int32,
+	header parquetPageHeader,
+	page []byte,
+	dictionary []string,
+	values []string,
+) ([]string, error) {
+	numValues := int(header.numValues)
+
+	var (
+		levels []byte
+		data   []byte
+		err    error
+	)
+	if header.typ == parquetDataPageV2 {
+		// the levels of the pages of version 2 are not compressed.
+		levelsLength := int(header.repLevelsLength) + int(header.defLevelsLength)
+		if levelsLength > len(page) {
+			return nil, fmt.Errorf("invalid levels length %d", levelsLength)
+		}
+		levels = page[header.repLevelsLength:levelsLength]
+		data = page[levelsLength:]
+		if header.isCompressed {
+			data, err = decompressParquetPage(codec, data, header.uncompressedSize-int32(levelsLength))
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		data, err = decompressParquetPage(codec, page, header.uncompressedSize)
+		if err != nil {
+			return nil, err
+		}
+		if column.repetition == parquetOptional {
+			// the levels of the pages of version 1 are prefixed by their length.
+			if len(data) < 4 {
+				return nil, fmt.Errorf("invalid data page")
+			}
+			length := int(binary.LittleEndian.Uint32(data))
+			if 4+length > len(data) {
+				return nil, fmt.Errorf("invalid levels length %d", length)
+			}
+			levels = data[4 : 4+length]
+			data = data[4+length:]
+		}
+	}
+
+	// the definition levels of an optional flat column are 1 for the values
+	// and 0 for the nulls.
+	var defined []uint64
+	numDefined := numValues
+	if column.repetition == parquetOptional {
+		defined, err = decodeParquetHybrid(levels, 1, numValues)
+		if err != nil {
+			return nil, err
+		}
+		numDefined = 0
+		for _, level := range defined {
+			numDefined += int(level)
+		}
+	}
+
+	var decoded []string
+	switch header.encoding {
+	case parquetPlain:
+		decoded, err = decodeParquetPlain(column, data, numDefined)
+	case parquetPlainDictionary, parquetRLEDictionary:
+		decoded, err = decodeParquetDictionary(data, dictionary, numDefined)
+	case parquetRLE:
+		decoded, err = decodeParquetRLEBoolean(column, data, numDefined)
+	default:
+		err = fmt.Errorf("unsupported encoding %d", header.encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if defined == nil {
+		return append(values, decoded...), nil
+	}
+
+	next := 0
+	for _, level := range defined {
+		if level == 0 {
+			values = append(values, "")
+			continue
+		}
+		values = append(values, decoded[next])
+		next++
+	}
+	return values, nil
+}
+
+// decompressParquetPage decompresses the given page with the given codec.
+func decompressParquetPage(codec int32, page []byte, uncompressedSize int32) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	switch codec {
+	case parquetUncompressed:
+		data = page
+	case parquetSnappy:
+		data, err = decodeSnappy(page)
+	case parquetGzip:
+		var gzr *gzip.Reader
+		gzr, err = gzip.NewReader(bytes.NewReader(page))
+		if err == nil {
+			data, err = io.ReadAll(gzr)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported compression codec %d", codec)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) != int(uncompressedSize) {
+		return nil, fmt.Errorf("page is %d bytes, expected %d", len(data), uncompressedSize)
+	}
+	return data, nil
+}
+
+// decodeParquetPlain decodes the given number of PLAIN encoded values.
+func decodeParquetPlain(column parquetColumn, data []byte, n int) ([]string, error) {
+	values := make([]string, 0, n)
+	errShort := errors.New("short plain encoded values")
+
+	pos := 0
+	for i := 0; i < n; i++ {
+		switch column.typ {
+		case parquetBoolean:
+			if pos/8 >= len(data) {
+				return nil, errShort
+			}
+			values = append(values, strconv.FormatBool(data[pos/8]&(1<<(pos%8)) != 0))
+			pos++
+			continue
+		case parquetInt32:
+			if pos+4 > len(data) {
+				return nil, errShort
+			}
+			v := int64(int32(binary.LittleEndian.Uint32(data[pos:])))
+			values = append(values, formatParquetInt(column, v))
+			pos += 4
+		case parquetInt64:
+			if pos+8 > len(data) {
+				return nil, errShort
+			}
+			v := int64(binary.LittleEndian.Uint64(data[pos:]))
+			values = append(values, formatParquetInt(column, v))
+			pos += 8
+		case parquetFloat:
+			if pos+4 > len(data) {
+				return nil, errShort
+			}
+			v := math.Float32frombits(binary.LittleEndian.Uint32(data[pos:]))
+			values = append(values, strconv.FormatFloat(float64(v), 'g', -1, 32))
+			pos += 4
+		case parquetDouble:
+			if pos+8 > len(data) {
+				return nil, errShort
+			}
+			v := math.Float64frombits(binary.LittleEndian.Uint64(data[pos:]))
+			values = append(values, strconv.FormatFloat(v, 'g', -1, 64))
+			pos += 8
+		case parquetByteArray:
+			if pos+4 > len(data) {
+				return nil, errShort
+			}
+			length := int(binary.LittleEndian.Uint32(data[pos:]))
+			pos += 4
+			if length < 0 || pos+length > len(data) {
+				return nil, errShort
+			}
+			values = append(values, string(data[pos:pos+length]))
+			pos += length
+		case parquetFixedLenByteArray:
+			length := int(column.typeLength)
+			if pos+length > len(data) {
+				return nil, errShort
+			}
+			values = append(values, string(data[pos:pos+length]))
+			pos += length
+		default:
+			return nil, fmt.Errorf("unsupported type %d", column.typ)
+		}
+	}
+	return values, nil
+}
+
+// formatParquetInt formats the given integer value of the given column, as
+// an RFC3339 time if it's a timestamp.
+func formatParquetInt(column parquetColumn, v int64) string {
+	if column.timeUnit == 0 {
+		return strconv.FormatInt(v, 10)
+	}
+	return time.Unix(0, v*int64(column.timeUnit)).UTC().Format(time.RFC3339Nano)
+}
+
+// decodeParquetDictionary decodes the given number of dictionary indexes,
+// which are prefixed by their bit width, and returns their values.
+func decodeParquetDictionary(data []byte, dictionary []string, n int) ([]string, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("short dictionary indexes")
+	}
+
+	indexes, err := decodeParquetHybrid(data[1:], int(data[0]), n)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, n)
+	for i, index := range indexes {
+		if index >= uint64(len(dictionary)) {
+			return nil, fmt.Errorf("dictionary index %d out of range", index)
+		}
+		values[i] = dictionary[index]
+	}
+	return values, nil
+}
+
+// decodeParquetRLEBoolean decodes the given number of RLE encoded booleans,
+// which are prefixed by their length.
+func decodeParquetRLEBoolean(column parquetColumn, data []byte, n int) ([]string, error) {
+	if column.typ != parquetBoolean {
+		return nil, fmt.Errorf("unsupported encoding %d", parquetRLE)
+	}
+	if len(data) < 4 {
+		return nil, fmt.Errorf("short boolean values")
+	}
+
+	bits, err := decodeParquetHybrid(data[4:], 1, n)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, n)
+	for i, bit := range bits {
+		values[i] = strconv.FormatBool(bit == 1)
+	}
+	return values, nil
+}
+
+// decodeParquetHybrid decodes the given number of values of the given bit
+// width which are encoded with the RLE/bit-packing hybrid encoding.
+func decodeParquetHybrid(data []byte, bitWidth, n int) ([]uint64, error) {
+	if bitWidth < 0 || bitWidth > 64 {
+		return nil, fmt.Errorf("invalid bit width %d", bitWidth)
+	}
+	errShort := errors.New("short hybrid encoded values")
+
+	values := make([]uint64, 0, n)
+	pos := 0
+	for len(values) < n {
+		header, size := binary.Uvarint(data[pos:])
+		if size <= 0 {
+			return nil, errShort
+		}
+		pos += size
+
+		if header&1 == 0 {
+			// a run of a value repeated the given number of times.
+			count := int(header >> 1)
+			width := (bitWidth + 7) / 8
+			if pos+width > len(data) {
+				return nil, errShort
+			}
+			var value uint64
+			for i := 0; i < width; i++ {
+				value |= uint64(data[pos+i]) << (8 * i)
+			}
+			pos += width
+
+			for i := 0; i < count && len(values) < n; i++ {
+				values = append(values, value)
+			}
+			continue
+		}
+
+		// groups of 8 values packed with the given bit width.
+		count := int(header>>1) * 8
+		length := count * bitWidth / 8
+		if pos+length > len(data) {
+			return nil, errShort
+		}
+		packed := data[pos : pos+length]
+		pos += length
+
+		for i := 0; i < count && len(values) < n; i++ {
+			var value uint64
+			for b := 0; b < bitWidth; b++ {
+				bit := i*bitWidth + b
+				if packed[bit/8]&(1<<(bit%8)) != 0 {
+					value |= 1 << b
+				}
+			}
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+// decodeSnappy decodes the given block of the snappy format.
+//
+// See https://github.com/google/snappy/blob/main/format_description.txt
+func decodeSnappy(src []byte) ([]byte, error) {
+	errCorrupt := errors.New("corrupt snappy block")
+
+	length, size := binary.Uvarint(src)
+	if size <= 0 || length > math.MaxInt32 {
+		return nil, errCorrupt
+	}
+	src = src[size:]
+
+	dst := make([]byte, 0, length)
+	for len(src) > 0 {
+		tag := src[0]
+		switch tag & 3 {
+		case 0:
+			// a literal, whose length is in the tag or in the next bytes.
+			n := int(tag >> 2)
+			src = src[1:]
+			if n >= 60 {
+				width := n - 59
+				if len(src) < width {
+					return nil, errCorrupt
+				}
+				n = 0
+				for i := 0; i < width; i++ {
+					n |= int(src[i]) << (8 * i)
+				}
+				src = src[width:]
+			}
+			n++
+			if n > len(src) {
+				return nil, errCorrupt
+			}
+			dst = append(dst, src[:n]...)
+			src = src[n:]
+			continue
+		}
+
+		// a copy of the given length from the given offset before the end.
+		var n, offset int
+		switch tag & 3 {
+		case 1:
+			if len(src) < 2 {
+				return nil, errCorrupt
+			}
+			n = 4 + int(tag>>2)&7
+			offset = int(tag&0xe0)<<3 | int(src[1])
+			src = src[2:]
+		case 2:
+			if len(src) < 3 {
+				return nil, errCorrupt
+			}
+			n = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[1:]))
+			src = src[3:]
+		case 3:
+			if len(src) < 5 {
+				return nil, errCorrupt
+			}
+			n = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[1:]))
+			src = src[5:]
+		}
+		if offset <= 0 || offset > len(dst) {
+			return nil, errCorrupt
+		}
+		// the copies may overlap with the bytes which they append.
+		start := len(dst) - offset
+		for i := 0; i < n; i++ {
+			dst = append(dst, dst[start+i])
+		}
+	}
+
+	if uint64(len(dst)) != length {
+		return nil, errCorrupt
+	}
+	return dst, nil
+}
+
+// types of the fields of the thrift compact protocol.
+const (
+	thriftStop   = 0
+	thriftTrue   = 1
+	thriftFalse  = 2
+	thriftByte   = 3
+	thriftI16    = 4
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftDouble = 7
+	thriftBinary = 8
+	thriftList   = 9
+	thriftSet    = 10
+	thriftMap    = 11
+	thriftStruct = 12
+)
+
+// thriftReader reads the structures encoded with the thrift compact protocol
+// which the metadata and the page headers of the Parquet files are encoded
+// with.
+//
+// See https://github.com/apache/thrift/blob/master/doc/specs/thrift-compact-protocol.md
+type thriftReader struct {
+	buf []byte
+	pos int
+}
+
+var errThriftShort = errors.New("short thrift structure")
+
+func (tr *thriftReader) readByte() (byte, error) {
+	if tr.pos >= len(tr.buf) {
+		return 0, errThriftShort
+	}
+	b := tr.buf[tr.pos]
+	tr.pos++
+	return b, nil
+}
+
+func (tr *thriftReader) readUvarint() (uint64, error) {
+	v, n := binary.Uvarint(tr.buf[tr.pos:])
+	if n <= 0 {
+		return 0, errThriftShort
+	}
+	tr.pos += n
+	return v, nil
+}
+
+func (tr *thriftReader) readI64() (int64, error) {
+	v, err := tr.readUvarint()
+	// the integers are zigzag encoded.
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+func (tr *thriftReader) readI32() (int32, error) {
+	v, err := tr.readI64()
+	return int32(v), err
+}
+
+func (tr *thriftReader) readBinary() ([]byte, error) {
+	length, err := tr.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(len(tr.buf)-tr.pos) {
+		return nil, errThriftShort
+	}
+	b := tr.buf[tr.pos : tr.pos+int(length)]
+	tr.pos += int(length)
+	return b, nil
+}
+
+// readStruct calls fn with the ID and the type of each field of a structure,
+// which must read or skip the field.
+func (tr *thriftReader) readStruct(fn func(id int16, typ byte) error) error {
+	var id int16
+	for {
+		header, err := tr.readByte()
+		if err != nil {
+			return err
+		}
+
+		typ := header & 0x0f
+		if typ == thriftStop {
+			return nil
+		}
+
+		// the ID is either a delta of the ID of the previous field, or
+		// follows the header.
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			v, err := tr.readI64()
+			if err != nil {
+				return err
+			}
+			id = int16(v)
+		}
+
+		if err := fn(id, typ); err != nil {
+			return err
+		}
+	}
+}
+
+// readList calls fn with the type of the elements for each element of a
+// list, which must read or skip the element.
+func (tr *thriftReader) readList(fn func(typ byte) error) error {
+	header, err := tr.readByte()
+	if err != nil {
+		return err
+	}
+
+	size := uint64(header >> 4)
+	if size == 15 {
+		size, err = tr.readUvarint()
+		if err != nil {
+			return err
+		}
+	}
+
+	typ := header & 0x0f
+	for i := uint64(0); i < size; i++ {
+		if err := fn(typ); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skip skips a field of the given type.
+func (tr *thriftReader) skip(typ byte) error {
+	var err error
+	switch typ {
+	case thriftTrue, thriftFalse:
+		// the values of the boolean fields are in their types.
+	case thriftByte:
+		_, err = tr.readByte()
+	case thriftI16, thriftI32, thriftI64:
+		_, err = tr.readUvarint()
+	case thriftDouble:
+		if tr.pos+8 > len(tr.buf) {
+			return errThriftShort
+		}
+		tr.pos += 8
+	case thriftBinary:
+		_, err = tr.readBinary()
+	case thriftList, thriftSet:
+		err = tr.readList(tr.skipElement)
+	case thriftMap:
+		var size uint64
+		size, err = tr.readUvarint()
+		if err != nil || size == 0 {
+			return err
+		}
+		var types byte
+		types, err = tr.readByte()
+		for i := uint64(0); i < size && err == nil; i++ {
+			if err = tr.skipElement(types >> 4); err == nil {
+				err = tr.skipElement(types & 0x0f)
+			}
+		}
+	case thriftStruct:
+		err = tr.readStruct(func(_ int16, typ byte) error {
+			return tr.skip(typ)
+		})
+	default:
+		err = fmt.Errorf("invalid thrift type %d", typ)
+	}
+	return err
+}
+
+// skipElement skips an element of a list or a map of the given type. Unlike
+// the fields, the booleans of the lists and the maps are encoded in a byte.
+func (tr *thriftReader) skipElement(typ byte) error {
+	if typ == thriftTrue || typ == thriftFalse {
+		_, err := tr.readByte()
+		return err
+	}
+	return tr.skip(typ)
+}
@@ -0,0 +1,401 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParquetReadRows(t *testing.T) {
+	t.Parallel()
+
+	// written by parquet-cpp-arrow, with snappy compressed and dictionary
+	// encoded optional columns.
+	f, err := os.Open("testdata/five_line_simple.parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parquet, err := openParquetFile(f, info.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got [][]string
+	err = parquet.readRows([]string{"id", "line", "missing"}, func(record []string) error {
+		got = append(got, append([]string(nil), record...))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{
+		{"id0", "0", ""},
+		{"id1", "1", ""},
+		{"id2", "2", ""},
+		{"id3", "3", ""},
+		{"id4", "4", ""},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestParquetReadRowsOfInventory(t *testing.T) {
+	t.Parallel()
+
+	columns := []testParquetColumn{
+		{name: "bucket", typ: parquetByteArray, repetition: parquetRequired, dictionary: true},
+		{name: "key", typ: parquetByteArray, repetition: parquetRequired},
+		{name: "version_id", typ: parquetByteArray, repetition: parquetOptional},
+		{name: "is_latest", typ: parquetBoolean, repetition: parquetOptional},
+		{name: "size", typ: parquetInt64, repetition: parquetOptional},
+		{name: "last_modified_date", typ: parquetInt64, repetition: parquetOptional, timestamp: true},
+	}
+	rows := [][]interface{}{
+		{"bucket", "dir/file name.txt", "v1", true, int64(42), int64(1672628645000)},
+		{"bucket", "dir/deleted.txt", "v2", false, nil, int64(1672628645123)},
+		{"bucket", "file.txt", nil, nil, int64(0), nil},
+	}
+	expected := [][]string{
+		{"bucket", "dir/file name.txt", "v1", "true", "42", "2023-01-02T03:04:05Z"},
+		{"bucket", "dir/deleted.txt", "v2", "false", "", "2023-01-02T03:04:05.123Z"},
+		{"bucket", "file.txt", "", "", "0", ""},
+	}
+
+	for _, codec := range []int32{parquetUncompressed, parquetSnappy, parquetGzip} {
+		data := writeTestParquet(t, columns, rows, codec)
+
+		parquet, err := openParquetFile(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("codec %d: %v", codec, err)
+		}
+
+		var got [][]string
+		names := []string{"bucket", "key", "version_id", "is_latest", "size", "last_modified_date"}
+		err = parquet.readRows(names, func(record []string) error {
+			got = append(got, append([]string(nil), record...))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("codec %d: %v", codec, err)
+		}
+
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("codec %d: expected %v, got %v", codec, expected, got)
+		}
+	}
+}
+
+func TestOpenParquetFileInvalid(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("bucket,key\nbucket,file.txt\n")
+	if _, err := openParquetFile(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Errorf("expected an error for a CSV file")
+	}
+}
+
+func TestDecodeSnappy(t *testing.T) {
+	t.Parallel()
+
+	// a literal of "abc" followed by an overlapping copy of 6 bytes from 3
+	// bytes before.
+	block := []byte{9, 2 << 2, 'a', 'b', 'c', (6-4)<<2 | 1, 3}
+
+	got, err := decodeSnappy(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "abcabcabc" {
+		t.Errorf("expected %q, got %q", "abcabcabc", got)
+	}
+
+	if _, err := decodeSnappy(block[:5]); err == nil {
+		t.Errorf("expected an error for a truncated block")
+	}
+}
+
+type testParquetColumn struct {
+	name       string
+	typ        int32
+	repetition int32
+	dictionary bool
+	timestamp  bool
+}
+
+// writeTestParquet writes the given rows in a Parquet file of a single row
+// group, with a data page of version 1 for each column.
+func writeTestParquet(t *testing.T, columns []testParquetColumn, rows [][]interface{}, codec int32) []byte {
+	t.Helper()
+
+	compress := func(data []byte) []byte {
+		switch codec {
+		case parquetSnappy:
+			// a single literal.
+			block := binary.AppendUvarint(nil, uint64(len(data)))
+			length := make([]byte, 4)
+			binary.LittleEndian.PutUint32(length, uint32(len(data)-1))
+			block = append(block, 63<<2)
+			block = append(block, length...)
+			return append(block, data...)
+		case parquetGzip:
+			var buf bytes.Buffer
+			gzw := gzip.NewWriter(&buf)
+			gzw.Write(data)
+			gzw.Close()
+			return buf.Bytes()
+		}
+		return data
+	}
+
+	plain := func(column testParquetColumn, values []interface{}) []byte {
+		var buf bytes.Buffer
+		var bits byte
+		for i, value := range values {
+			switch column.typ {
+			case parquetBoolean:
+				if value.(bool) {
+					bits |= 1 << (i % 8)
+				}
+				if i%8 == 7 || i == len(values)-1 {
+					buf.WriteByte(bits)
+					bits = 0
+				}
+			case parquetInt64:
+				binary.Write(&buf, binary.LittleEndian, value.(int64))
+			case parquetByteArray:
+				binary.Write(&buf, binary.LittleEndian, uint32(len(value.(string))))
+				buf.WriteString(value.(string))
+			}
+		}
+		return buf.Bytes()
+	}
+
+	// bitPacked encodes the given values with the bit-packing of the hybrid
+	// encoding.
+	bitPacked := func(values []uint64, bitWidth int) []byte {
+		groups := (len(values) + 7) / 8
+		packed := make([]byte, groups*bitWidth)
+		for i, value := range values {
+			for b := 0; b < bitWidth; b++ {
+				if value&(1<<b) != 0 {
+					bit := i*bitWidth + b
+					packed[bit/8] |= 1 << (bit % 8)
+				}
+			}
+		}
+		return append(binary.AppendUvarint(nil, uint64(groups<<1|1)), packed...)
+	}
+
+	pageHeader := func(typ int32, uncompressed, compressed []byte, numValues int, encoding int32) []byte {
+		var tw testThriftWriter
+		tw.i32(1, typ)
+		tw.i32(2, int32(len(uncompressed)))
+		tw.i32(3, int32(len(compressed)))
+		if typ == parquetDictionaryPage {
+			tw.beginStruct(7)
+		} else {
+			tw.beginStruct(5)
+		}
+		tw.i32(1, int32(numValues))
+		tw.i32(2, encoding)
+		if typ == parquetDataPage {
+			tw.i32(3, parquetRLE)
+			tw.i32(4, parquetRLE)
+		}
+		tw.endStruct()
+		tw.WriteByte(thriftStop)
+		return tw.Bytes()
+	}
+
+	type chunk struct {
+		size, dataOffset, dictionaryOffset int64
+	}
+	var chunks []chunk
+
+	file := bytes.NewBufferString("PAR1")
+	for c, column := range columns {
+		start := int64(file.Len())
+
+		var (
+			levels  []uint64
+			defined []interface{}
+		)
+		for _, row := range rows {
+			if row[c] == nil {
+				levels = append(levels, 0)
+				continue
+			}
+			levels = append(levels, 1)
+			defined = append(defined, row[c])
+		}
+
+		var dictionaryOffset int64
+		encoding := int32(parquetPlain)
+		values := plain(column, defined)
+		if column.dictionary {
+			var (
+				dictionary []interface{}
+				indexes    []uint64
+			)
+			seen := map[interface{}]uint64{}
+			for _, value := range defined {
+				index, ok := seen[value]
+				if !ok {
+					index = uint64(len(dictionary))
+					seen[value] = index
+					dictionary = append(dictionary, value)
+				}
+				indexes = append(indexes, index)
+			}
+
+			page := plain(column, dictionary)
+			compressed := compress(page)
+			dictionaryOffset = int64(file.Len())
+			file.Write(pageHeader(parquetDictionaryPage, page, compressed, len(dictionary), parquetPlain))
+			file.Write(compressed)
+
+			encoding = parquetRLEDictionary
+			values = append([]byte{8}, bitPacked(indexes, 8)...)
+		}
+
+		var page []byte
+		if column.repetition == parquetOptional {
+			encoded := bitPacked(levels, 1)
+			page = binary.LittleEndian.AppendUint32(page, uint32(len(encoded)))
+			page = append(page, encoded...)
+		}
+		page = append(page, values...)
+
+		compressed := compress(page)
+		dataOffset := int64(file.Len())
+		file.Write(pageHeader(parquetDataPage, page, compressed, len(rows), encoding))
+		file.Write(compressed)
+
+		chunks = append(chunks, chunk{
+			size:             int64(file.Len()) - start,
+			dataOffset:       dataOffset,
+			dictionaryOffset: dictionaryOffset,
+		})
+	}
+
+	var meta testThriftWriter
+	meta.i32(1, 1)
+	meta.beginList(2, thriftStruct, len(columns)+1)
+	meta.beginListStruct()
+	meta.binary(4, "schema")
+	meta.i32(5, int32(len(columns)))
+	meta.endStruct()
+	for _, column := range columns {
+		meta.beginListStruct()
+		meta.i32(1, column.typ)
+		meta.i32(3, column.repetition)
+		meta.binary(4, column.name)
+		if column.timestamp {
+			meta.i32(6, parquetTimestampMillis)
+		}
+		meta.endStruct()
+	}
+	meta.i64(3, int64(len(rows)))
+	meta.beginList(4, thriftStruct, 1)
+	meta.beginListStruct()
+	meta.beginList(1, thriftStruct, len(chunks))
+	for c, chunk := range chunks {
+		meta.beginListStruct()
+		meta.beginStruct(3)
+		meta.i32(1, columns[c].typ)
+		meta.i32(4, codec)
+		meta.i64(5, int64(len(rows)))
+		meta.i64(7, chunk.size)
+		meta.i64(9, chunk.dataOffset)
+		if chunk.dictionaryOffset > 0 {
+			meta.i64(11, chunk.dictionaryOffset)
+		}
+		meta.endStruct()
+		meta.endStruct()
+	}
+	meta.i64(3, int64(len(rows)))
+	meta.endStruct()
+	meta.WriteByte(thriftStop)
+
+	file.Write(meta.Bytes())
+	binary.Write(file, binary.LittleEndian, uint32(len(meta.Bytes())))
+	file.WriteString("PAR1")
+	return file.Bytes()
+}
+
+// testThriftWriter writes the structures with the thrift compact protocol.
+type testThriftWriter struct {
+	bytes.Buffer
+	last  int16
+	stack []int16
+}
+
+func (tw *testThriftWriter) field(id int16, typ byte) {
+	if delta := id - tw.last; delta > 0 && delta <= 15 {
+		tw.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		tw.WriteByte(typ)
+		tw.varint(int64(id))
+	}
+	tw.last = id
+}
+
+func (tw *testThriftWriter) varint(v int64) {
+	tw.Write(binary.AppendUvarint(nil, uint64(v<<1^v>>63)))
+}
+
+func (tw *testThriftWriter) i32(id int16, v int32) {
+	tw.field(id, thriftI32)
+	tw.varint(int64(v))
+}
+
+func (tw *testThriftWriter) i64(id int16, v int64) {
+	tw.field(id, thriftI64)
+	tw.varint(v)
+}
+
+func (tw *testThriftWriter) binary(id int16, v string) {
+	tw.field(id, thriftBinary)
+	tw.Write(binary.AppendUvarint(nil, uint64(len(v))))
+	tw.WriteString(v)
+}
+
+func (tw *testThriftWriter) beginStruct(id int16) {
+	tw.field(id, thriftStruct)
+	tw.beginListStruct()
+}
+
+// beginListStruct begins a structure which is an element of a list.
+func (tw *testThriftWriter) beginListStruct() {
+	tw.stack = append(tw.stack, tw.last)
+	tw.last = 0
+}
+
+// endStruct ends a structure begun by beginStruct or beginListStruct.
+func (tw *testThriftWriter) endStruct() {
+	tw.WriteByte(thriftStop)
+	tw.last = tw.stack[len(tw.stack)-1]
+	tw.stack = tw.stack[:len(tw.stack)-1]
+}
+
+func (tw *testThriftWriter) beginList(id int16, typ byte, size int) {
+	tw.field(id, thriftList)
+	if size < 15 {
+		tw.WriteByte(byte(size)<<4 | typ)
+		return
+	}
+	tw.WriteByte(0xf0 | typ)
+	tw.Write(binary.AppendUvarint(nil, uint64(size)))
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/request"
+
+	"github.com/peak/s5cmd/v2/log"
+)
+
+// requestLogger prints a line for each request attempt sent to the remote
+// storage when it's enabled. Like the request metrics, the request handlers
+// of all sessions print through the same logger.
+type requestLogger struct {
+	enabled atomic.Bool
+	// sample is the fraction of the requests which are logged.
+	sample float64
+}
+
+var requestLog requestLogger
+
+// untracedRequestKey is the context key of the requests which are left out
+// by the sampling.
+type untracedRequestKey struct{}
+
+// EnableRequestLog starts printing a line for each request attempt sent to
+// the remote storage. Only the given fraction of the requests, between 0 and
+// 1, are logged. All attempts of a logged request are printed, so that its
+// retries can be followed.
+func EnableRequestLog(sample float64) {
+	requestLog.sample = sample
+	requestLog.enabled.Store(true)
+}
+
+// build is a build handler which decides whether the request is logged. The
+// requests are built once, so the decision holds for all of their attempts.
+func (rl *requestLogger) build(r *request.Request) {
+	if !rl.enabled.Load() || rl.sample >= 1 || rand.Float64() < rl.sample {
+		return
+	}
+	r.SetContext(context.WithValue(r.Context(), untracedRequestKey{}, true))
+}
+
+// complete is a complete attempt handler which prints the request attempt.
+func (rl *requestLogger) complete(r *request.Request) {
+	if !rl.enabled.Load() || r.Context().Value(untracedRequestKey{}) != nil {
+		return
+	}
+
+	msg := log.RequestMessage{
+		Operation: r.Operation.Name,
+		Bucket:    requestParam(r, "Bucket"),
+		Key:       requestParam(r, "Key"),
+		RequestID: r.RequestID,
+		Duration:  time.Since(r.AttemptTime),
+		Retries:   r.RetryCount,
+	}
+	if r.HTTPResponse != nil {
+		msg.Status = r.HTTPResponse.StatusCode
+	}
+	if r.Error != nil {
+		msg.Err = r.Error.Error()
+		if aerr, ok := r.Error.(awserr.Error); ok {
+			msg.Err = aerr.Code()
+		}
+	}
+	log.Request(msg)
+}
+
+// requestParam returns the value of the given string parameter of the
+// request, e.g. the bucket or the key, if there is any.
+func requestParam(r *request.Request, name string) string {
+	values, _ := awsutil.ValuesAtPath(r.Params, name)
+	if len(values) == 0 {
+		return ""
+	}
+	if s, ok := values[0].(*string); ok {
+		return aws.StringValue(s)
+	}
+	return ""
+}
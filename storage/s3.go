@@ -1,22 +1,28 @@
 package storage
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
 	"crypto/rand"
 	"crypto/tls"
 	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"math"
 	"math/big"
+	"net"
 	"net/http"
 	urlpkg "net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -67,7 +73,16 @@ type S3 struct {
 	dryRun                 bool
 	useListObjectsV1       bool
 	noSuchUploadRetryCount int
+	fetchOwner             bool
 	requestPayer           string
+	expectedBucketOwner    string
+	leavePartsOnError      bool
+	partRetryCount         int
+	pageSize               int64
+
+	// listObjectsV1Fallback is set once the service is found not to support
+	// ListObjectsV2 API, so that the next listings use ListObjects API.
+	listObjectsV1Fallback atomic.Bool
 }
 
 func (s *S3) RequestPayer() *string {
@@ -77,6 +92,15 @@ func (s *S3) RequestPayer() *string {
 	return &s.requestPayer
 }
 
+// ExpectedBucketOwner returns the account ID the buckets are expected to be
+// owned by. Requests to buckets owned by other accounts fail with AccessDenied.
+func (s *S3) ExpectedBucketOwner() *string {
+	if s.expectedBucketOwner == "" {
+		return nil
+	}
+	return &s.expectedBucketOwner
+}
+
 func parseEndpoint(endpoint string) (urlpkg.URL, error) {
 	if endpoint == "" {
 		return sentinelURL, nil
@@ -90,6 +114,85 @@ func parseEndpoint(endpoint string) (urlpkg.URL, error) {
 	return *u, nil
 }
 
+// Schemes of the endpoint URLs.
+const (
+	EndpointSchemeHTTP  = "http"
+	EndpointSchemeHTTPS = "https"
+)
+
+// NormalizeEndpoint validates the given endpoint URL, and adds a scheme to it
+// if it's missing, e.g. "localhost:9000". The given scheme is added if it's
+// not empty. Otherwise, the scheme is inferred from the host: "http" for the
+// loopback hosts, e.g. localhost or 127.0.0.1, and "https" for the others.
+// The trailing slashes of the base path of the endpoint, e.g.
+// "https://host/s3/", are removed.
+func NormalizeEndpoint(endpoint, scheme string) (string, error) {
+	if endpoint == "" {
+		return "", nil
+	}
+
+	switch scheme {
+	case "", EndpointSchemeHTTP, EndpointSchemeHTTPS:
+	default:
+		return "", fmt.Errorf("unsupported endpoint scheme %q: (http, https)", scheme)
+	}
+
+	hasScheme := strings.Contains(endpoint, "://")
+
+	raw := endpoint
+	if !hasScheme {
+		// the host of an URL without a scheme is parsed as its path,
+		// unless it's given as a network-path reference.
+		raw = "//" + endpoint
+	}
+
+	u, err := urlpkg.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint: %v", err)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("hostname is missing. Must be of the form http://<hostname>/ or https://<hostname>/")
+	}
+
+	// the paths of the requests are appended to the base path of the
+	// endpoint, e.g. https://host/s3/bucket/key, but its query would be
+	// replaced with the queries of the requests.
+	if u.RawQuery != "" || u.ForceQuery || u.Fragment != "" {
+		return "", fmt.Errorf("endpoint can't have a query or a fragment, only a base path, e.g. https://<hostname>/<path>")
+	}
+	// the paths of the requests have a leading slash, so the trailing
+	// slashes of the base path would result in empty path segments.
+	endpoint = strings.TrimRight(endpoint, "/")
+
+	if hasScheme {
+		if u.Scheme != EndpointSchemeHTTP && u.Scheme != EndpointSchemeHTTPS {
+			return "", fmt.Errorf("unsupported scheme %q. Must be of the form http://<hostname>/ or https://<hostname>/", u.Scheme)
+		}
+		if scheme != "" && u.Scheme != scheme {
+			return "", fmt.Errorf("scheme %q conflicts with the endpoint scheme %q", u.Scheme, scheme)
+		}
+		return endpoint, nil
+	}
+
+	if scheme == "" {
+		scheme = EndpointSchemeHTTPS
+		if isLoopbackHost(u.Hostname()) {
+			scheme = EndpointSchemeHTTP
+		}
+	}
+	return scheme + "://" + endpoint, nil
+}
+
+// isLoopbackHost reports whether the given host refers to the local machine,
+// which is usually served without TLS.
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 // NewS3Storage creates new S3 session.
 func newS3Storage(ctx context.Context, opts Options) (*S3, error) {
 	endpointURL, err := parseEndpoint(opts.Endpoint)
@@ -110,16 +213,31 @@ func newS3Storage(ctx context.Context, opts Options) (*S3, error) {
 		dryRun:                 opts.DryRun,
 		useListObjectsV1:       opts.UseListObjectsV1,
 		requestPayer:           opts.RequestPayer,
+		expectedBucketOwner:    opts.ExpectedBucketOwner,
 		noSuchUploadRetryCount: opts.NoSuchUploadRetryCount,
+		leavePartsOnError:      opts.LeavePartsOnError,
+		partRetryCount:         opts.PartRetryCount,
+		fetchOwner:             opts.FetchOwner,
+		pageSize:               opts.PageSize,
 	}, nil
 }
 
+// maxKeys returns the maximum number of keys, or uploads, of a listing page,
+// or nil to use the default of the provider.
+func (s *S3) maxKeys() *int64 {
+	if s.pageSize <= 0 {
+		return nil
+	}
+	return aws.Int64(s.pageSize)
+}
+
 // Stat retrieves metadata from S3 object without returning the object itself.
 func (s *S3) Stat(ctx context.Context, url *url.URL) (*Object, error) {
 	input := &s3.HeadObjectInput{
-		Bucket:       aws.String(url.Bucket),
-		Key:          aws.String(url.Path),
-		RequestPayer: s.RequestPayer(),
+		Bucket:              aws.String(url.Bucket),
+		Key:                 aws.String(url.Path),
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
 	}
 	if url.VersionID != "" {
 		input.SetVersionId(url.VersionID)
@@ -141,6 +259,11 @@ func (s *S3) Stat(ctx context.Context, url *url.URL) (*Object, error) {
 		Etag:    strings.Trim(etag, `"`),
 		ModTime: &mod,
 		Size:    aws.Int64Value(output.ContentLength),
+		etagNotMD5: checksumFromETag(
+			etag,
+			aws.StringValue(output.ServerSideEncryption),
+			aws.StringValue(output.SSECustomerAlgorithm),
+		) == nil,
 	}
 
 	if s.noSuchUploadRetryCount > 0 {
@@ -152,6 +275,38 @@ func (s *S3) Stat(ctx context.Context, url *url.URL) (*Object, error) {
 	return obj, nil
 }
 
+// StatWithChecksum retrieves metadata of the object, with its additional
+// checksum if it has one.
+func (s *S3) StatWithChecksum(ctx context.Context, url *url.URL) (*Object, *Checksum, error) {
+	input := &s3.HeadObjectInput{
+		Bucket:              aws.String(url.Bucket),
+		Key:                 aws.String(url.Path),
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
+		ChecksumMode:        aws.String(s3.ChecksumModeEnabled),
+	}
+	if url.VersionID != "" {
+		input.SetVersionId(url.VersionID)
+	}
+
+	output, err := s.api.HeadObjectWithContext(ctx, input)
+	if err != nil {
+		if errHasCode(err, "NotFound") {
+			return nil, nil, &ErrGivenObjectNotFound{ObjectAbsPath: url.Absolute()}
+		}
+		return nil, nil, err
+	}
+
+	mod := aws.TimeValue(output.LastModified)
+	obj := &Object{
+		URL:     url,
+		Etag:    strings.Trim(aws.StringValue(output.ETag), `"`),
+		ModTime: &mod,
+		Size:    aws.Int64Value(output.ContentLength),
+	}
+	return obj, checksumFromHeadObject(output), nil
+}
+
 // List is a non-blocking S3 list operation which paginates and filters S3
 // keys. If no object found or an error is encountered during this period,
 // it sends these errors to object channel.
@@ -159,7 +314,7 @@ func (s *S3) List(ctx context.Context, url *url.URL, _ bool) <-chan *Object {
 	if url.VersionID != "" || url.AllVersions {
 		return s.listObjectVersions(ctx, url)
 	}
-	if s.useListObjectsV1 {
+	if s.useListObjectsV1 || s.listObjectsV1Fallback.Load() {
 		return s.listObjects(ctx, url)
 	}
 
@@ -168,8 +323,10 @@ func (s *S3) List(ctx context.Context, url *url.URL, _ bool) <-chan *Object {
 
 func (s *S3) listObjectVersions(ctx context.Context, url *url.URL) <-chan *Object {
 	listInput := s3.ListObjectVersionsInput{
-		Bucket: aws.String(url.Bucket),
-		Prefix: aws.String(url.Prefix),
+		Bucket:              aws.String(url.Bucket),
+		Prefix:              aws.String(url.Prefix),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
+		MaxKeys:             s.maxKeys(),
 	}
 
 	if url.Delimiter != "" {
@@ -191,6 +348,9 @@ func (s *S3) listObjectVersions(ctx context.Context, url *url.URL) <-chan *Objec
 					if !url.Match(prefix) {
 						continue
 					}
+					if url.IsExcludedPrefix(prefix) {
+						continue
+					}
 
 					newurl := url.Clone()
 					newurl.Path = prefix
@@ -213,6 +373,9 @@ func (s *S3) listObjectVersions(ctx context.Context, url *url.URL) <-chan *Objec
 					if !url.Match(key) {
 						continue
 					}
+					if url.IsExcludedPrefix(key) {
+						continue
+					}
 					if url.VersionID != "" && url.VersionID != aws.StringValue(v.VersionId) {
 						continue
 					}
@@ -234,12 +397,14 @@ func (s *S3) listObjectVersions(ctx context.Context, url *url.URL) <-chan *Objec
 					etag := aws.StringValue(v.ETag)
 
 					objCh <- &Object{
-						URL:          newurl,
-						Etag:         strings.Trim(etag, `"`),
-						ModTime:      &mod,
-						Type:         ObjectType{objtype},
-						Size:         aws.Int64Value(v.Size),
-						StorageClass: StorageClass(aws.StringValue(v.StorageClass)),
+						URL:               newurl,
+						Etag:              strings.Trim(etag, `"`),
+						ModTime:           &mod,
+						Type:              ObjectType{objtype},
+						Size:              aws.Int64Value(v.Size),
+						StorageClass:      StorageClass(aws.StringValue(v.StorageClass)),
+						ChecksumAlgorithm: checksumAlgorithm(v.ChecksumAlgorithm),
+						Owner:             newOwner(v.Owner),
 					}
 
 					objectFound = true
@@ -251,6 +416,9 @@ func (s *S3) listObjectVersions(ctx context.Context, url *url.URL) <-chan *Objec
 					if !url.Match(key) {
 						continue
 					}
+					if url.IsExcludedPrefix(key) {
+						continue
+					}
 					if url.VersionID != "" && url.VersionID != aws.StringValue(d.VersionId) {
 						continue
 					}
@@ -271,10 +439,11 @@ func (s *S3) listObjectVersions(ctx context.Context, url *url.URL) <-chan *Objec
 					newurl.VersionID = aws.StringValue(d.VersionId)
 
 					objCh <- &Object{
-						URL:     newurl,
-						ModTime: &mod,
-						Type:    ObjectType{objtype},
-						Size:    0,
+						URL:            newurl,
+						ModTime:        &mod,
+						Type:           ObjectType{objtype},
+						Size:           0,
+						IsDeleteMarker: true,
 					}
 
 					objectFound = true
@@ -296,14 +465,32 @@ func (s *S3) listObjectVersions(ctx context.Context, url *url.URL) <-chan *Objec
 }
 
 func (s *S3) listObjectsV2(ctx context.Context, url *url.URL) <-chan *Object {
-	listInput := s3.ListObjectsV2Input{
-		Bucket:       aws.String(url.Bucket),
-		Prefix:       aws.String(url.Prefix),
-		RequestPayer: s.RequestPayer(),
+	newListInput := func(prefix, delimiter string) *s3.ListObjectsV2Input {
+		listInput := &s3.ListObjectsV2Input{
+			Bucket:              aws.String(url.Bucket),
+			Prefix:              aws.String(prefix),
+			RequestPayer:        s.RequestPayer(),
+			ExpectedBucketOwner: s.ExpectedBucketOwner(),
+			MaxKeys:             s.maxKeys(),
+		}
+		if delimiter != "" {
+			listInput.SetDelimiter(delimiter)
+		}
+		if s.fetchOwner {
+			listInput.SetFetchOwner(true)
+		}
+		return listInput
 	}
 
-	if url.Delimiter != "" {
-		listInput.SetDelimiter(url.Delimiter)
+	// When there are excluded prefixes for a recursive listing, the listing
+	// is split into multiple prefix scoped listings. Only the directories
+	// containing an excluded prefix are listed with a delimiter, the rest are
+	// listed as a whole and the excluded ones are never descended.
+	prune := url.Delimiter == "" && url.HasExcludePrefixes()
+
+	delimiter := url.Delimiter
+	if prune {
+		delimiter = "/"
 	}
 
 	objCh := make(chan *Object)
@@ -311,40 +498,150 @@ func (s *S3) listObjectsV2(ctx context.Context, url *url.URL) <-chan *Object {
 	go func() {
 		defer close(objCh)
 		objectFound := false
+		listed := false
 
 		var now time.Time
 
-		err := s.api.ListObjectsV2PagesWithContext(ctx, &listInput, func(p *s3.ListObjectsV2Output, lastPage bool) bool {
-			for _, c := range p.CommonPrefixes {
-				prefix := aws.StringValue(c.Prefix)
-				if !url.Match(prefix) {
-					continue
+		listInputs := []*s3.ListObjectsV2Input{newListInput(url.Prefix, delimiter)}
+		for len(listInputs) > 0 {
+			listInput := listInputs[0]
+			listInputs = listInputs[1:]
+
+			isPruning := prune && aws.StringValue(listInput.Delimiter) != ""
+
+			err := s.api.ListObjectsV2PagesWithContext(ctx, listInput, func(p *s3.ListObjectsV2Output, lastPage bool) bool {
+				listed = true
+				for _, c := range p.CommonPrefixes {
+					prefix := aws.StringValue(c.Prefix)
+					if url.IsExcludedPrefix(prefix) {
+						continue
+					}
+
+					if isPruning {
+						subDelimiter := ""
+						if url.HasExcludedPrefixBelow(prefix) {
+							subDelimiter = "/"
+						}
+						listInputs = append(listInputs, newListInput(prefix, subDelimiter))
+						continue
+					}
+
+					if !url.Match(prefix) {
+						continue
+					}
+
+					newurl := url.Clone()
+					newurl.Path = prefix
+					objCh <- &Object{
+						URL:  newurl,
+						Type: ObjectType{os.ModeDir},
+					}
+
+					objectFound = true
+				}
+				// track the instant object iteration began,
+				// so it can be used to bypass objects created after this instant
+				if now.IsZero() {
+					now = time.Now().UTC()
 				}
 
-				newurl := url.Clone()
-				newurl.Path = prefix
-				objCh <- &Object{
-					URL:  newurl,
-					Type: ObjectType{os.ModeDir},
+				for _, c := range p.Contents {
+					key := aws.StringValue(c.Key)
+					if !url.Match(key) {
+						continue
+					}
+
+					if url.IsExcludedPrefix(key) {
+						continue
+					}
+
+					mod := aws.TimeValue(c.LastModified).UTC()
+					if mod.After(now) {
+						objectFound = true
+						continue
+					}
+
+					var objtype os.FileMode
+					if strings.HasSuffix(key, "/") {
+						objtype = os.ModeDir
+					}
+
+					newurl := url.Clone()
+					newurl.Path = aws.StringValue(c.Key)
+					etag := aws.StringValue(c.ETag)
+
+					objCh <- &Object{
+						URL:               newurl,
+						Etag:              strings.Trim(etag, `"`),
+						ModTime:           &mod,
+						Type:              ObjectType{objtype},
+						Size:              aws.Int64Value(c.Size),
+						StorageClass:      StorageClass(aws.StringValue(c.StorageClass)),
+						ChecksumAlgorithm: checksumAlgorithm(c.ChecksumAlgorithm),
+						Owner:             newOwner(c.Owner),
+					}
+
+					objectFound = true
 				}
 
-				objectFound = true
+				return !lastPage
+			})
+			// the legacy S3 compatible services may not implement
+			// ListObjectsV2 API, the objects are listed with ListObjects API
+			// instead.
+			if err != nil && !listed && isNotImplementedError(err) {
+				msg := log.DebugMessage{Err: fmt.Sprintf("ListObjectsV2 is not supported, falling back to ListObjects: %v", err)}
+				log.Debug(msg)
+
+				s.listObjectsV1Fallback.Store(true)
+				for obj := range s.listObjects(ctx, url) {
+					objCh <- obj
+				}
+				return
 			}
-			// track the instant object iteration began,
-			// so it can be used to bypass objects created after this instant
-			if now.IsZero() {
-				now = time.Now().UTC()
+			if err != nil {
+				objCh <- &Object{Err: err}
+				return
 			}
+		}
+
+		if !objectFound && !url.IsBucket() {
+			objCh <- &Object{Err: ErrNoObjectFound}
+		}
+	}()
+
+	return objCh
+}
 
+// ListPages lists the objects of the given remote URL, stopping after the
+// given number of pages. The last item has ErrListTruncated error if there
+// are more pages left.
+func (s *S3) ListPages(ctx context.Context, url *url.URL, pages int) <-chan *Object {
+	listInput := s3.ListObjectsV2Input{
+		Bucket:              aws.String(url.Bucket),
+		Prefix:              aws.String(url.Prefix),
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
+		MaxKeys:             s.maxKeys(),
+	}
+
+	if url.Delimiter != "" {
+		listInput.SetDelimiter(url.Delimiter)
+	}
+
+	objCh := make(chan *Object)
+
+	go func() {
+		defer close(objCh)
+
+		var (
+			listed    int
+			truncated bool
+		)
+		err := s.api.ListObjectsV2PagesWithContext(ctx, &listInput, func(p *s3.ListObjectsV2Output, lastPage bool) bool {
 			for _, c := range p.Contents {
 				key := aws.StringValue(c.Key)
-				if !url.Match(key) {
-					continue
-				}
-
-				mod := aws.TimeValue(c.LastModified).UTC()
-				if mod.After(now) {
-					objectFound = true
+				if !url.Match(key) || url.IsExcludedPrefix(key) {
 					continue
 				}
 
@@ -353,22 +650,26 @@ func (s *S3) listObjectsV2(ctx context.Context, url *url.URL) <-chan *Object {
 					objtype = os.ModeDir
 				}
 
+				mod := aws.TimeValue(c.LastModified).UTC()
 				newurl := url.Clone()
-				newurl.Path = aws.StringValue(c.Key)
-				etag := aws.StringValue(c.ETag)
+				newurl.Path = key
 
 				objCh <- &Object{
-					URL:          newurl,
-					Etag:         strings.Trim(etag, `"`),
-					ModTime:      &mod,
-					Type:         ObjectType{objtype},
-					Size:         aws.Int64Value(c.Size),
-					StorageClass: StorageClass(aws.StringValue(c.StorageClass)),
+					URL:               newurl,
+					Etag:              strings.Trim(aws.StringValue(c.ETag), `"`),
+					ModTime:           &mod,
+					Type:              ObjectType{objtype},
+					Size:              aws.Int64Value(c.Size),
+					StorageClass:      StorageClass(aws.StringValue(c.StorageClass)),
+					ChecksumAlgorithm: checksumAlgorithm(c.ChecksumAlgorithm),
 				}
-
-				objectFound = true
 			}
 
+			listed++
+			if !lastPage && listed >= pages {
+				truncated = true
+				return false
+			}
 			return !lastPage
 		})
 		if err != nil {
@@ -376,8 +677,8 @@ func (s *S3) listObjectsV2(ctx context.Context, url *url.URL) <-chan *Object {
 			return
 		}
 
-		if !objectFound && !url.IsBucket() {
-			objCh <- &Object{Err: ErrNoObjectFound}
+		if truncated {
+			objCh <- &Object{Err: ErrListTruncated}
 		}
 	}()
 
@@ -388,9 +689,11 @@ func (s *S3) listObjectsV2(ctx context.Context, url *url.URL) <-chan *Object {
 // ListObjectsV2 API. I'm looking at you GCS.
 func (s *S3) listObjects(ctx context.Context, url *url.URL) <-chan *Object {
 	listInput := s3.ListObjectsInput{
-		Bucket:       aws.String(url.Bucket),
-		Prefix:       aws.String(url.Prefix),
-		RequestPayer: s.RequestPayer(),
+		Bucket:              aws.String(url.Bucket),
+		Prefix:              aws.String(url.Prefix),
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
+		MaxKeys:             s.maxKeys(),
 	}
 
 	if url.Delimiter != "" {
@@ -411,6 +714,9 @@ func (s *S3) listObjects(ctx context.Context, url *url.URL) <-chan *Object {
 				if !url.Match(prefix) {
 					continue
 				}
+				if url.IsExcludedPrefix(prefix) {
+					continue
+				}
 
 				newurl := url.Clone()
 				newurl.Path = prefix
@@ -432,6 +738,9 @@ func (s *S3) listObjects(ctx context.Context, url *url.URL) <-chan *Object {
 				if !url.Match(key) {
 					continue
 				}
+				if url.IsExcludedPrefix(key) {
+					continue
+				}
 
 				mod := aws.TimeValue(c.LastModified).UTC()
 				if mod.After(now) {
@@ -449,12 +758,14 @@ func (s *S3) listObjects(ctx context.Context, url *url.URL) <-chan *Object {
 				etag := aws.StringValue(c.ETag)
 
 				objCh <- &Object{
-					URL:          newurl,
-					Etag:         strings.Trim(etag, `"`),
-					ModTime:      &mod,
-					Type:         ObjectType{objtype},
-					Size:         aws.Int64Value(c.Size),
-					StorageClass: StorageClass(aws.StringValue(c.StorageClass)),
+					URL:               newurl,
+					Etag:              strings.Trim(etag, `"`),
+					ModTime:           &mod,
+					Type:              ObjectType{objtype},
+					Size:              aws.Int64Value(c.Size),
+					StorageClass:      StorageClass(aws.StringValue(c.StorageClass)),
+					ChecksumAlgorithm: checksumAlgorithm(c.ChecksumAlgorithm),
+					Owner:             newOwner(c.Owner),
 				}
 
 				objectFound = true
@@ -475,6 +786,25 @@ func (s *S3) listObjects(ctx context.Context, url *url.URL) <-chan *Object {
 	return objCh
 }
 
+// newOwner converts the owner of a listed object. It returns nil if the
+// owner is not in the listing, e.g. ListObjectsV2 without FetchOwner.
+func newOwner(owner *s3.Owner) *Owner {
+	if owner == nil || (owner.ID == nil && owner.DisplayName == nil) {
+		return nil
+	}
+	return &Owner{
+		ID:          aws.StringValue(owner.ID),
+		DisplayName: aws.StringValue(owner.DisplayName),
+	}
+}
+
+// checksumAlgorithm returns the checksum algorithms of a listed object,
+// separated by commas. It returns an empty string if the object is uploaded
+// without an additional checksum, or the service doesn't return it.
+func checksumAlgorithm(algorithms []*string) string {
+	return strings.Join(aws.StringValueSlice(algorithms), ",")
+}
+
 // Copy is a single-object copy operation which copies objects to S3
 // destination from another S3 source.
 func (s *S3) Copy(ctx context.Context, from, to *url.URL, metadata Metadata) error {
@@ -486,10 +816,11 @@ func (s *S3) Copy(ctx context.Context, from, to *url.URL, metadata Metadata) err
 	copySource := from.EscapedPath()
 
 	input := &s3.CopyObjectInput{
-		Bucket:       aws.String(to.Bucket),
-		Key:          aws.String(to.Path),
-		CopySource:   aws.String(copySource),
-		RequestPayer: s.RequestPayer(),
+		Bucket:              aws.String(to.Bucket),
+		Key:                 aws.String(to.Path),
+		CopySource:          aws.String(copySource),
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
 	}
 	if from.VersionID != "" {
 		// Unlike many other *Input and *Output types version ID is not a field,
@@ -510,6 +841,20 @@ func (s *S3) Copy(ctx context.Context, from, to *url.URL, metadata Metadata) err
 		input.ACL = aws.String(acl)
 	}
 
+	grants := metadata.Grants
+	if grants.Read != "" {
+		input.GrantRead = aws.String(grants.Read)
+	}
+	if grants.ReadACP != "" {
+		input.GrantReadACP = aws.String(grants.ReadACP)
+	}
+	if grants.WriteACP != "" {
+		input.GrantWriteACP = aws.String(grants.WriteACP)
+	}
+	if grants.FullControl != "" {
+		input.GrantFullControl = aws.String(grants.FullControl)
+	}
+
 	cacheControl := metadata.CacheControl
 	if cacheControl != "" {
 		input.CacheControl = aws.String(cacheControl)
@@ -543,6 +888,11 @@ func (s *S3) Copy(ctx context.Context, from, to *url.URL, metadata Metadata) err
 		input.ContentDisposition = aws.String(contentDisposition)
 	}
 
+	contentLanguage := metadata.ContentLanguage
+	if contentLanguage != "" {
+		input.ContentLanguage = aws.String(contentLanguage)
+	}
+
 	// add retry ID to the object metadata
 	if s.noSuchUploadRetryCount > 0 {
 		input.Metadata[metadataKeyRetryID] = generateRetryID()
@@ -571,14 +921,42 @@ func (s *S3) Copy(ctx context.Context, from, to *url.URL, metadata Metadata) err
 // Read fetches the remote object and returns its contents as an io.ReadCloser.
 func (s *S3) Read(ctx context.Context, src *url.URL) (io.ReadCloser, error) {
 	input := &s3.GetObjectInput{
-		Bucket:       aws.String(src.Bucket),
-		Key:          aws.String(src.Path),
-		RequestPayer: s.RequestPayer(),
+		Bucket:              aws.String(src.Bucket),
+		Key:                 aws.String(src.Path),
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
+	}
+	if src.VersionID != "" {
+		input.SetVersionId(src.VersionID)
+	}
+
+	resp, err := s.api.GetObjectWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ReadRange fetches the given byte range of the remote object and returns it
+// as an io.ReadCloser. Both start and end offsets are inclusive, the range
+// extends to the end of the object if end is negative.
+func (s *S3) ReadRange(ctx context.Context, src *url.URL, start, end int64) (io.ReadCloser, error) {
+	input := &s3.GetObjectInput{
+		Bucket:              aws.String(src.Bucket),
+		Key:                 aws.String(src.Path),
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
 	}
 	if src.VersionID != "" {
 		input.SetVersionId(src.VersionID)
 	}
 
+	if end < 0 {
+		input.SetRange(fmt.Sprintf("bytes=%d-", start))
+	} else {
+		input.SetRange(fmt.Sprintf("bytes=%d-%d", start, end))
+	}
+
 	resp, err := s.api.GetObjectWithContext(ctx, input)
 	if err != nil {
 		return nil, err
@@ -586,11 +964,19 @@ func (s *S3) Read(ctx context.Context, src *url.URL) (io.ReadCloser, error) {
 	return resp.Body, nil
 }
 
+// Presign returns a URL of the remote object which is valid for the given
+// duration. The URL is signed for the endpoint and the addressing style of
+// the session, so it works with the S3-compatible services as well, e.g.
+// MinIO. The version ID of the object is a part of the signed URL.
 func (s *S3) Presign(ctx context.Context, from *url.URL, expire time.Duration) (string, error) {
 	input := &s3.GetObjectInput{
-		Bucket:       aws.String(from.Bucket),
-		Key:          aws.String(from.Path),
-		RequestPayer: s.RequestPayer(),
+		Bucket:              aws.String(from.Bucket),
+		Key:                 aws.String(from.Path),
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
+	}
+	if from.VersionID != "" {
+		input.SetVersionId(from.VersionID)
 	}
 
 	req, _ := s.api.GetObjectRequest(input)
@@ -601,30 +987,163 @@ func (s *S3) Presign(ctx context.Context, from *url.URL, expire time.Duration) (
 // Get is a multipart download operation which downloads S3 objects into any
 // destination that implements io.WriterAt interface.
 // Makes a single 'GetObject' call if 'concurrency' is 1 and ignores 'partSize'.
+// If the conditions don't hold, the object is not downloaded and an error
+// satisfying IsNotModifiedError is returned.
 func (s *S3) Get(
 	ctx context.Context,
 	from *url.URL,
 	to io.WriterAt,
 	concurrency int,
 	partSize int64,
+	conditions DownloadConditions,
+) (int64, error) {
+	return s.GetFrom(ctx, from, to, 0, concurrency, partSize, conditions)
+}
+
+// GetFrom is like Get, but it downloads the object starting from the given
+// offset. The bytes are written to the same offsets of the writer as in the
+// object. The downloads which start from a non-zero offset are not split into
+// parts.
+func (s *S3) GetFrom(
+	ctx context.Context,
+	from *url.URL,
+	to io.WriterAt,
+	offset int64,
+	concurrency int,
+	partSize int64,
+	conditions DownloadConditions,
 ) (int64, error) {
 	if s.dryRun {
 		return 0, nil
 	}
 
+	input := s.getObjectInput(from, conditions)
+	if offset > 0 {
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-", offset))
+		// the downloader writes the ranges starting from the beginning of
+		// the writer.
+		to = offsetWriterAt{w: to, offset: offset}
+	}
+
+	return s.downloader.DownloadWithContext(ctx, to, input, func(u *s3manager.Downloader) {
+		u.PartSize = partSize
+		u.Concurrency = concurrency
+		s.setDownloaderPartRetry(u)
+	})
+}
+
+// getObjectInput returns the input of the GetObject requests of the given
+// object.
+func (s *S3) getObjectInput(from *url.URL, conditions DownloadConditions) *s3.GetObjectInput {
 	input := &s3.GetObjectInput{
-		Bucket:       aws.String(from.Bucket),
-		Key:          aws.String(from.Path),
-		RequestPayer: s.RequestPayer(),
+		Bucket:              aws.String(from.Bucket),
+		Key:                 aws.String(from.Path),
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
 	}
 	if from.VersionID != "" {
 		input.VersionId = aws.String(from.VersionID)
 	}
+	if conditions.IfModifiedSince != nil {
+		input.IfModifiedSince = conditions.IfModifiedSince
+	}
+	if conditions.IfMatch != "" {
+		input.IfMatch = aws.String(quoteETag(conditions.IfMatch))
+	}
+	if conditions.IfNoneMatch != "" {
+		input.IfNoneMatch = aws.String(quoteETag(conditions.IfNoneMatch))
+	}
+	return input
+}
 
-	return s.downloader.DownloadWithContext(ctx, to, input, func(u *s3manager.Downloader) {
+// offsetWriterAt shifts the writes to the underlying writer by the given
+// offset.
+type offsetWriterAt struct {
+	w      io.WriterAt
+	offset int64
+}
+
+func (o offsetWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	return o.w.WriteAt(p, off+o.offset)
+}
+
+// GetWithChecksum is like Get, but it enables the checksum mode of the
+// requests and also returns the additional checksum of the object. If etag is
+// set, the ETag of an object without an additional checksum is returned as an
+// MD5 checksum if it is the MD5 digest of the content. The checksum is nil if
+// the object does not have one.
+func (s *S3) GetWithChecksum(
+	ctx context.Context,
+	from *url.URL,
+	to io.WriterAt,
+	concurrency int,
+	partSize int64,
+	conditions DownloadConditions,
+	etag bool,
+) (int64, *Checksum, error) {
+	if s.dryRun {
+		return 0, nil, nil
+	}
+
+	input := s.getObjectInput(from, conditions)
+	input.ChecksumMode = aws.String(s3.ChecksumModeEnabled)
+
+	var (
+		mu           sync.Mutex
+		checksum     *Checksum
+		etagChecksum *Checksum
+	)
+	// the checksums are returned in the responses of the parts only if they
+	// cover the whole object, whereas the ETag is the ETag of the whole
+	// object.
+	captureChecksum := func(r *request.Request) {
+		r.Handlers.Complete.PushBack(func(r *request.Request) {
+			if r.Error != nil || r.HTTPResponse == nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if c := checksumFromHeader(r.HTTPResponse.Header); c != nil {
+				checksum = c
+			}
+			if c := checksumFromETagHeader(r.HTTPResponse.Header); c != nil {
+				etagChecksum = c
+			}
+		})
+	}
+
+	n, err := s.downloader.DownloadWithContext(ctx, to, input, func(u *s3manager.Downloader) {
 		u.PartSize = partSize
 		u.Concurrency = concurrency
+		u.RequestOptions = append(u.RequestOptions, captureChecksum)
+		s.setDownloaderPartRetry(u)
+	})
+	if err != nil || checksum != nil {
+		return n, checksum, err
+	}
+	if etag && etagChecksum != nil {
+		return n, etagChecksum, nil
+	}
+
+	output, err := s.api.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket:              input.Bucket,
+		Key:                 input.Key,
+		VersionId:           input.VersionId,
+		RequestPayer:        input.RequestPayer,
+		ExpectedBucketOwner: input.ExpectedBucketOwner,
+		ChecksumMode:        input.ChecksumMode,
 	})
+	if err != nil {
+		return n, nil, err
+	}
+	if checksum := checksumFromHeadObject(output); checksum != nil || !etag {
+		return n, checksum, nil
+	}
+	return n, checksumFromETag(
+		aws.StringValue(output.ETag),
+		aws.StringValue(output.ServerSideEncryption),
+		aws.StringValue(output.SSECustomerAlgorithm),
+	), nil
 }
 
 type SelectQuery struct {
@@ -746,6 +1265,7 @@ func (s *S3) Select(ctx context.Context, url *url.URL, query *SelectQuery, resul
 		Expression:          aws.String(query.Expression),
 		InputSerialization:  inputFormat,
 		OutputSerialization: outputFormat,
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
 	}
 
 	resp, err := s.api.SelectObjectContentWithContext(ctx, input)
@@ -808,114 +1328,582 @@ func (s *S3) Put(
 		contentType = "application/octet-stream"
 	}
 
-	input := &s3manager.UploadInput{
-		Bucket:       aws.String(to.Bucket),
-		Key:          aws.String(to.Path),
-		Body:         reader,
-		ContentType:  aws.String(contentType),
-		Metadata:     make(map[string]*string),
-		RequestPayer: s.RequestPayer(),
+	input := &s3manager.UploadInput{
+		Bucket:              aws.String(to.Bucket),
+		Key:                 aws.String(to.Path),
+		Body:                reader,
+		ContentType:         aws.String(contentType),
+		Metadata:            make(map[string]*string),
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
+	}
+
+	storageClass := metadata.StorageClass
+	if storageClass != "" {
+		input.StorageClass = aws.String(storageClass)
+	}
+
+	acl := metadata.ACL
+	if acl != "" {
+		input.ACL = aws.String(acl)
+	}
+
+	grants := metadata.Grants
+	if grants.Read != "" {
+		input.GrantRead = aws.String(grants.Read)
+	}
+	if grants.ReadACP != "" {
+		input.GrantReadACP = aws.String(grants.ReadACP)
+	}
+	if grants.WriteACP != "" {
+		input.GrantWriteACP = aws.String(grants.WriteACP)
+	}
+	if grants.FullControl != "" {
+		input.GrantFullControl = aws.String(grants.FullControl)
+	}
+
+	cacheControl := metadata.CacheControl
+	if cacheControl != "" {
+		input.CacheControl = aws.String(cacheControl)
+	}
+
+	expires := metadata.Expires
+	if expires != "" {
+		t, err := time.Parse(time.RFC3339, expires)
+		if err != nil {
+			return err
+		}
+		input.Expires = aws.Time(t)
+	}
+
+	sseEncryption := metadata.EncryptionMethod
+	if sseEncryption != "" {
+		input.ServerSideEncryption = aws.String(sseEncryption)
+		sseKmsKeyID := metadata.EncryptionKeyID
+		if sseKmsKeyID != "" {
+			input.SSEKMSKeyId = aws.String(sseKmsKeyID)
+		}
+	}
+
+	contentEncoding := metadata.ContentEncoding
+	if contentEncoding != "" {
+		input.ContentEncoding = aws.String(contentEncoding)
+	}
+
+	contentDisposition := metadata.ContentDisposition
+	if contentDisposition != "" {
+		input.ContentDisposition = aws.String(contentDisposition)
+	}
+
+	contentLanguage := metadata.ContentLanguage
+	if contentLanguage != "" {
+		input.ContentLanguage = aws.String(contentLanguage)
+	}
+
+	if metadata.ChecksumAlgorithm != "" {
+		input.ChecksumAlgorithm = aws.String(metadata.ChecksumAlgorithm)
+	}
+
+	// add retry ID to the object metadata
+	if s.noSuchUploadRetryCount > 0 {
+		input.Metadata[metadataKeyRetryID] = generateRetryID()
+	}
+
+	if len(metadata.UserDefined) != 0 {
+		m := make(map[string]*string)
+		for k, v := range metadata.UserDefined {
+			m[k] = aws.String(v)
+		}
+		input.Metadata = m
+	}
+
+	uploaderOptsFn := func(u *s3manager.Uploader) {
+		u.PartSize = partSize
+		u.Concurrency = concurrency
+		u.LeavePartsOnError = s.leavePartsOnError
+		if src := metadata.ResumeSource; src != nil && s.leavePartsOnError {
+			partSize := uploadPartSize(src.Size, partSize)
+			numParts := (src.Size + partSize - 1) / partSize
+			if src.Size > partSize && numParts < ResumeMarkerPartNumber {
+				u.RequestOptions = append(u.RequestOptions, s.resumeMarkerOption(to, ResumeMarker(src, metadata, partSize)))
+			}
+		}
+		if metadata.IfMatch != "" || metadata.IfNoneMatch != "" {
+			u.RequestOptions = append(u.RequestOptions, conditionalWriteOption(metadata.IfMatch, metadata.IfNoneMatch))
+		}
+		if metadata.ChecksumAlgorithm != "" {
+			u.RequestOptions = append(u.RequestOptions, checksumWriteOption(metadata.ChecksumAlgorithm))
+		}
+		if metadata.ChecksumType != "" {
+			u.RequestOptions = append(u.RequestOptions, checksumTypeOption(metadata.ChecksumType))
+		}
+		u.RequestOptions = append(u.RequestOptions, s.partRetryOptions()...)
+	}
+	_, err := s.uploader.UploadWithContext(ctx, input, uploaderOptsFn)
+
+	if errHasCode(err, s3.ErrCodeNoSuchUpload) && s.noSuchUploadRetryCount > 0 {
+		return s.retryOnNoSuchUpload(ctx, to, input, err, uploaderOptsFn)
+	}
+
+	return err
+}
+
+func (s *S3) retryOnNoSuchUpload(ctx aws.Context, to *url.URL, input *s3manager.UploadInput,
+	err error, uploaderOpts ...func(*s3manager.Uploader),
+) error {
+	var expectedRetryID string
+	if ID, ok := input.Metadata[metadataKeyRetryID]; ok {
+		expectedRetryID = *ID
+	}
+
+	attempts := 0
+	for ; errHasCode(err, s3.ErrCodeNoSuchUpload) && attempts < s.noSuchUploadRetryCount; attempts++ {
+		// check if object exists and has the retry ID we provided, if it does
+		// then it means that one of previous uploads was succesfull despite the received error.
+		obj, sErr := s.Stat(ctx, to)
+		if sErr == nil && obj.retryID == expectedRetryID {
+			err = nil
+			break
+		}
+
+		msg := log.DebugMessage{Err: fmt.Sprintf("Retrying to upload %v upon error: %q", to, err.Error())}
+		log.Debug(msg)
+
+		_, err = s.uploader.UploadWithContext(ctx, input, uploaderOpts...)
+	}
+
+	if errHasCode(err, s3.ErrCodeNoSuchUpload) && s.noSuchUploadRetryCount > 0 {
+		err = awserr.New(s3.ErrCodeNoSuchUpload, fmt.Sprintf(
+			"RetryOnNoSuchUpload: %v attempts to retry resulted in %v", attempts,
+			s3.ErrCodeNoSuchUpload), err)
+	}
+	return err
+}
+
+// MultipartUpload is an in-progress multipart upload.
+type MultipartUpload struct {
+	URL       *url.URL  `json:"key"`
+	UploadID  string    `json:"upload_id"`
+	Initiated time.Time `json:"initiated"`
+	Err       error     `json:"error,omitempty"`
+}
+
+// ListMultipartUploads is a non-blocking operation which lists the
+// in-progress multipart uploads of the keys that match with given url.
+func (s *S3) ListMultipartUploads(ctx context.Context, url *url.URL) <-chan *MultipartUpload {
+	listInput := s3.ListMultipartUploadsInput{
+		Bucket:              aws.String(url.Bucket),
+		Prefix:              aws.String(url.Prefix),
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
+		MaxUploads:          s.maxKeys(),
+	}
+
+	uploadCh := make(chan *MultipartUpload)
+
+	go func() {
+		defer close(uploadCh)
+
+		err := s.api.ListMultipartUploadsPagesWithContext(ctx, &listInput, func(p *s3.ListMultipartUploadsOutput, lastPage bool) bool {
+			for _, u := range p.Uploads {
+				key := aws.StringValue(u.Key)
+				// uploads are listed recursively, the delimiter of the url is
+				// ignored.
+				if url.IsWildcard() && !url.Match(key) {
+					continue
+				}
+
+				newurl := url.Clone()
+				newurl.Path = key
+				uploadCh <- &MultipartUpload{
+					URL:       newurl,
+					UploadID:  aws.StringValue(u.UploadId),
+					Initiated: aws.TimeValue(u.Initiated),
+				}
+			}
+			return !lastPage
+		})
+		if err != nil {
+			uploadCh <- &MultipartUpload{Err: err}
+		}
+	}()
+
+	return uploadCh
+}
+
+// MultipartUploadSize returns the total size of the uploaded parts of given
+// multipart upload.
+func (s *S3) MultipartUploadSize(ctx context.Context, upload *MultipartUpload) (int64, error) {
+	var size int64
+	err := s.api.ListPartsPagesWithContext(ctx, &s3.ListPartsInput{
+		Bucket:              aws.String(upload.URL.Bucket),
+		Key:                 aws.String(upload.URL.Path),
+		UploadId:            aws.String(upload.UploadID),
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
+	}, func(p *s3.ListPartsOutput, lastPage bool) bool {
+		for _, part := range p.Parts {
+			size += aws.Int64Value(part.Size)
+		}
+		return !lastPage
+	})
+	return size, err
+}
+
+// AbortMultipartUpload aborts given multipart upload and frees the storage
+// consumed by its uploaded parts.
+func (s *S3) AbortMultipartUpload(ctx context.Context, upload *MultipartUpload) error {
+	if s.dryRun {
+		return nil
+	}
+
+	_, err := s.api.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:              aws.String(upload.URL.Bucket),
+		Key:                 aws.String(upload.URL.Path),
+		UploadId:            aws.String(upload.UploadID),
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
+	})
+	return err
+}
+
+// ResumeUpload continues an interrupted multipart upload of the given file to
+// the destination. Only the uploads which are marked with the resume marker
+// of the source, the metadata and the part size are resumed, i.e. the uploads
+// started by another writer or with other settings are not. Only the parts
+// that are missing or whose ETag does not match the local content are
+// uploaded, and the remaining parts are canceled once a part fails. It returns
+// false if there is no upload to resume.
+func (s *S3) ResumeUpload(
+	ctx context.Context,
+	file io.ReaderAt,
+	src *Object,
+	to *url.URL,
+	metadata Metadata,
+	concurrency int,
+	partSize int64,
+) (bool, error) {
+	size := src.Size
+	partSize = uploadPartSize(size, partSize)
+	if size <= partSize {
+		return false, nil
+	}
+
+	marker := ResumeMarker(src, metadata, partSize)
+	uploadID, uploadedParts, err := s.findResumableUpload(ctx, to, size, partSize, marker)
+	if err != nil || uploadID == "" {
+		return false, err
+	}
+
+	if s.dryRun {
+		return true, nil
+	}
+
+	numParts := (size + partSize - 1) / partSize
+	completedParts := make([]*s3.CompletedPart, numParts)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		merr     error
+		partNums = make(chan int64)
+	)
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for partNum := range partNums {
+				// the remaining parts are not uploaded once a part fails.
+				if ctx.Err() != nil {
+					continue
+				}
+
+				offset := (partNum - 1) * partSize
+				length := partSize
+				if offset+length > size {
+					length = size - offset
+				}
+
+				etag, err := s.uploadMissingPart(ctx, file, offset, length, to, uploadID, partNum, uploadedParts[partNum])
+				mu.Lock()
+				if err != nil && merr == nil {
+					merr = err
+					cancel()
+				}
+				completedParts[partNum-1] = &s3.CompletedPart{
+					ETag:       aws.String(etag),
+					PartNumber: aws.Int64(partNum),
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for partNum := int64(1); partNum <= numParts; partNum++ {
+		partNums <- partNum
 	}
+	close(partNums)
+	wg.Wait()
 
-	storageClass := metadata.StorageClass
-	if storageClass != "" {
-		input.StorageClass = aws.String(storageClass)
+	if merr != nil {
+		return true, merr
 	}
 
-	acl := metadata.ACL
-	if acl != "" {
-		input.ACL = aws.String(acl)
-	}
+	_, err = s.api.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:              aws.String(to.Bucket),
+		Key:                 aws.String(to.Path),
+		UploadId:            aws.String(uploadID),
+		MultipartUpload:     &s3.CompletedMultipartUpload{Parts: completedParts},
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
+	})
+	return true, err
+}
 
-	cacheControl := metadata.CacheControl
-	if cacheControl != "" {
-		input.CacheControl = aws.String(cacheControl)
+// findResumableUpload returns the ID and the uploaded parts of the most
+// recent in-progress multipart upload of the given key which is marked with
+// the given resume marker, and whose parts fit to the part layout of the size
+// and the part size. The user metadata of the in-progress uploads can't be
+// read, so the marker is stored as a part of the upload instead.
+func (s *S3) findResumableUpload(ctx context.Context, to *url.URL, size, partSize int64, marker []byte) (string, map[int64]*s3.Part, error) {
+	var uploads []*s3.MultipartUpload
+	err := s.api.ListMultipartUploadsPagesWithContext(ctx, &s3.ListMultipartUploadsInput{
+		Bucket:              aws.String(to.Bucket),
+		Prefix:              aws.String(to.Path),
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
+	}, func(p *s3.ListMultipartUploadsOutput, lastPage bool) bool {
+		for _, upload := range p.Uploads {
+			if aws.StringValue(upload.Key) == to.Path {
+				uploads = append(uploads, upload)
+			}
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return "", nil, err
 	}
 
-	expires := metadata.Expires
-	if expires != "" {
-		t, err := time.Parse(time.RFC3339, expires)
+	sort.Slice(uploads, func(i, j int) bool {
+		return aws.TimeValue(uploads[i].Initiated).After(aws.TimeValue(uploads[j].Initiated))
+	})
+
+	markerSum := md5.Sum(marker)
+	markerETag := hex.EncodeToString(markerSum[:])
+
+	numParts := (size + partSize - 1) / partSize
+	for _, upload := range uploads {
+		uploadID := aws.StringValue(upload.UploadId)
+
+		parts := map[int64]*s3.Part{}
+		isLayoutMatched := true
+		isMarked := false
+
+		err := s.api.ListPartsPagesWithContext(ctx, &s3.ListPartsInput{
+			Bucket:              aws.String(to.Bucket),
+			Key:                 aws.String(to.Path),
+			UploadId:            aws.String(uploadID),
+			RequestPayer:        s.RequestPayer(),
+			ExpectedBucketOwner: s.ExpectedBucketOwner(),
+		}, func(p *s3.ListPartsOutput, lastPage bool) bool {
+			for _, part := range p.Parts {
+				partNum := aws.Int64Value(part.PartNumber)
+				if partNum == ResumeMarkerPartNumber {
+					isMarked = strings.Trim(aws.StringValue(part.ETag), `"`) == markerETag
+					continue
+				}
+				expectedSize := partSize
+				if partNum == numParts {
+					expectedSize = size - (numParts-1)*partSize
+				}
+				if partNum < 1 || partNum > numParts || aws.Int64Value(part.Size) != expectedSize {
+					isLayoutMatched = false
+					return false
+				}
+				parts[partNum] = part
+			}
+			return !lastPage
+		})
 		if err != nil {
-			return err
+			if errHasCode(err, s3.ErrCodeNoSuchUpload) {
+				continue
+			}
+			return "", nil, err
 		}
-		input.Expires = aws.Time(t)
-	}
 
-	sseEncryption := metadata.EncryptionMethod
-	if sseEncryption != "" {
-		input.ServerSideEncryption = aws.String(sseEncryption)
-		sseKmsKeyID := metadata.EncryptionKeyID
-		if sseKmsKeyID != "" {
-			input.SSEKMSKeyId = aws.String(sseKmsKeyID)
+		if !isMarked {
+			msg := log.DebugMessage{Err: fmt.Sprintf("Skipping upload %q of %v since it's not started by the same transfer", uploadID, to)}
+			log.Debug(msg)
+			continue
 		}
-	}
 
-	contentEncoding := metadata.ContentEncoding
-	if contentEncoding != "" {
-		input.ContentEncoding = aws.String(contentEncoding)
+		if isLayoutMatched {
+			msg := log.DebugMessage{Err: fmt.Sprintf("Resuming upload %q of %v with %d uploaded parts", uploadID, to, len(parts))}
+			log.Debug(msg)
+			return uploadID, parts, nil
+		}
 	}
+	return "", nil, nil
+}
 
-	contentDisposition := metadata.ContentDisposition
-	if contentDisposition != "" {
-		input.ContentDisposition = aws.String(contentDisposition)
-	}
+// ResumeMarkerPartNumber is the number of the part which marks the multipart
+// uploads that can be resumed. It's the last part number, so it's never used
+// by the marked uploads, which are completed without it.
+const ResumeMarkerPartNumber = s3manager.MaxUploadParts
 
-	// add retry ID to the object metadata
-	if s.noSuchUploadRetryCount > 0 {
-		input.Metadata[metadataKeyRetryID] = generateRetryID()
+// ResumeMarker returns the content of the part which marks a resumable
+// multipart upload of the given source. It's the fingerprint of the size and
+// the modification time of the source, the part size and the settings of the
+// upload, so that an upload is only resumed by the same transfer.
+func ResumeMarker(src *Object, metadata Metadata, partSize int64) []byte {
+	contentType := metadata.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
 	}
 
-	if len(metadata.UserDefined) != 0 {
-		m := make(map[string]*string)
-		for k, v := range metadata.UserDefined {
-			m[k] = aws.String(v)
+	var modTime string
+	if src.ModTime != nil {
+		modTime = src.ModTime.UTC().Format(time.RFC3339Nano)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "s5cmd-resume-marker: 1\n")
+	fields := [][2]string{
+		{"size", strconv.FormatInt(src.Size, 10)},
+		{"mtime", modTime},
+		{"part-size", strconv.FormatInt(partSize, 10)},
+		{"content-type", contentType},
+		{"acl", metadata.ACL},
+		{"grant-read", metadata.Grants.Read},
+		{"grant-read-acp", metadata.Grants.ReadACP},
+		{"grant-write-acp", metadata.Grants.WriteACP},
+		{"grant-full-control", metadata.Grants.FullControl},
+		{"cache-control", metadata.CacheControl},
+		{"expires", metadata.Expires},
+		{"storage-class", metadata.StorageClass},
+		{"content-encoding", metadata.ContentEncoding},
+		{"content-disposition", metadata.ContentDisposition},
+		{"content-language", metadata.ContentLanguage},
+		{"sse", metadata.EncryptionMethod},
+		{"sse-kms-key-id", metadata.EncryptionKeyID},
+	}
+	for _, field := range fields {
+		fmt.Fprintf(&buf, "%s: %q\n", field[0], field[1])
+	}
+
+	keys := make([]string, 0, len(metadata.UserDefined))
+	for key := range metadata.UserDefined {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&buf, "metadata: %q=%q\n", key, metadata.UserDefined[key])
+	}
+	return buf.Bytes()
+}
+
+// resumeMarkerOption uploads the given resume marker as a part of the
+// multipart upload once the upload is created. The upload is not marked, i.e.
+// it can't be resumed, if the marker can't be uploaded.
+func (s *S3) resumeMarkerOption(to *url.URL, marker []byte) request.Option {
+	return func(r *request.Request) {
+		if r.Operation.Name != "CreateMultipartUpload" {
+			return
 		}
-		input.Metadata = m
-	}
 
-	uploaderOptsFn := func(u *s3manager.Uploader) {
-		u.PartSize = partSize
-		u.Concurrency = concurrency
-	}
-	_, err := s.uploader.UploadWithContext(ctx, input, uploaderOptsFn)
+		r.Handlers.Complete.PushBack(func(r *request.Request) {
+			output, ok := r.Data.(*s3.CreateMultipartUploadOutput)
+			if r.Error != nil || !ok {
+				return
+			}
 
-	if errHasCode(err, s3.ErrCodeNoSuchUpload) && s.noSuchUploadRetryCount > 0 {
-		return s.retryOnNoSuchUpload(ctx, to, input, err, uploaderOptsFn)
+			_, err := s.api.UploadPartWithContext(r.Context(), &s3.UploadPartInput{
+				Bucket:              aws.String(to.Bucket),
+				Key:                 aws.String(to.Path),
+				UploadId:            output.UploadId,
+				PartNumber:          aws.Int64(ResumeMarkerPartNumber),
+				Body:                bytes.NewReader(marker),
+				RequestPayer:        s.RequestPayer(),
+				ExpectedBucketOwner: s.ExpectedBucketOwner(),
+			})
+			if err != nil {
+				msg := log.DebugMessage{Err: fmt.Sprintf("Could not mark upload %q of %v as resumable: %v", aws.StringValue(output.UploadId), to, err)}
+				log.Debug(msg)
+			}
+		})
 	}
-
-	return err
 }
 
-func (s *S3) retryOnNoSuchUpload(ctx aws.Context, to *url.URL, input *s3manager.UploadInput,
-	err error, uploaderOpts ...func(*s3manager.Uploader),
-) error {
-	var expectedRetryID string
-	if ID, ok := input.Metadata[metadataKeyRetryID]; ok {
-		expectedRetryID = *ID
+// uploadMissingPart uploads the part of the file at given offset unless the
+// already uploaded part has the same content. It returns the ETag of the part.
+func (s *S3) uploadMissingPart(
+	ctx context.Context,
+	file io.ReaderAt,
+	offset, length int64,
+	to *url.URL,
+	uploadID string,
+	partNum int64,
+	uploaded *s3.Part,
+) (string, error) {
+	if uploaded != nil {
+		hash := md5.New()
+		if _, err := io.Copy(hash, io.NewSectionReader(file, offset, length)); err != nil {
+			return "", err
+		}
+		etag := aws.StringValue(uploaded.ETag)
+		if strings.Trim(etag, `"`) == hex.EncodeToString(hash.Sum(nil)) {
+			return etag, nil
+		}
 	}
 
-	attempts := 0
-	for ; errHasCode(err, s3.ErrCodeNoSuchUpload) && attempts < s.noSuchUploadRetryCount; attempts++ {
-		// check if object exists and has the retry ID we provided, if it does
-		// then it means that one of previous uploads was succesfull despite the received error.
-		obj, sErr := s.Stat(ctx, to)
-		if sErr == nil && obj.retryID == expectedRetryID {
-			err = nil
-			break
-		}
+	output, err := s.api.UploadPartWithContext(ctx, &s3.UploadPartInput{
+		Bucket:              aws.String(to.Bucket),
+		Key:                 aws.String(to.Path),
+		UploadId:            aws.String(uploadID),
+		PartNumber:          aws.Int64(partNum),
+		Body:                io.NewSectionReader(file, offset, length),
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
+	}, s.partRetryOptions()...)
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(output.ETag), nil
+}
 
-		msg := log.DebugMessage{Err: fmt.Sprintf("Retrying to upload %v upon error: %q", to, err.Error())}
-		log.Debug(msg)
+// AutoPartSize returns the smallest part size, rounded up to MiB, which keeps
+// a multipart transfer of given size under the maximum number of parts
+// without going below the minimum part size.
+func AutoPartSize(size int64) int64 {
+	const mib = 1024 * 1024
 
-		_, err = s.uploader.UploadWithContext(ctx, input, uploaderOpts...)
+	partSize := size/int64(s3manager.MaxUploadParts) + 1
+	partSize = (partSize + mib - 1) / mib * mib
+	if partSize < s3manager.MinUploadPartSize {
+		partSize = s3manager.MinUploadPartSize
 	}
+	return partSize
+}
 
-	if errHasCode(err, s3.ErrCodeNoSuchUpload) && s.noSuchUploadRetryCount > 0 {
-		err = awserr.New(s3.ErrCodeNoSuchUpload, fmt.Sprintf(
-			"RetryOnNoSuchUpload: %v attempts to retry resulted in %v", attempts,
-			s3.ErrCodeNoSuchUpload), err)
+// uploadPartSize returns the part size the upload manager uses for an upload
+// of given size. Part size is increased if the upload would exceed the
+// maximum number of parts.
+func uploadPartSize(size, partSize int64) int64 {
+	if partSize <= 0 {
+		partSize = s3manager.DefaultUploadPartSize
 	}
-	return err
+	if size/partSize >= int64(s3manager.MaxUploadParts) {
+		partSize = (size / int64(s3manager.MaxUploadParts)) + 1
+	}
+	return partSize
 }
 
 // chunk is an object identifier container which is used on MultiDelete
@@ -1009,9 +1997,10 @@ func (s *S3) doDelete(ctx context.Context, chunk chunk, resultch chan *Object) {
 	if IsGoogleEndpoint(s.endpointURL) {
 		for _, k := range chunk.Keys {
 			_, err := s.api.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
-				Bucket:       aws.String(chunk.Bucket),
-				Key:          k.Key,
-				RequestPayer: s.RequestPayer(),
+				Bucket:              aws.String(chunk.Bucket),
+				Key:                 k.Key,
+				RequestPayer:        s.RequestPayer(),
+				ExpectedBucketOwner: s.ExpectedBucketOwner(),
 			})
 			if err != nil {
 				resultch <- &Object{Err: err}
@@ -1026,9 +2015,10 @@ func (s *S3) doDelete(ctx context.Context, chunk chunk, resultch chan *Object) {
 
 	bucket := chunk.Bucket
 	o, err := s.api.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
-		Bucket:       aws.String(bucket),
-		Delete:       &s3.Delete{Objects: chunk.Keys},
-		RequestPayer: s.RequestPayer(),
+		Bucket:              aws.String(bucket),
+		Delete:              &s3.Delete{Objects: chunk.Keys},
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
 	})
 	if err != nil {
 		resultch <- &Object{Err: err}
@@ -1039,7 +2029,14 @@ func (s *S3) doDelete(ctx context.Context, chunk chunk, resultch chan *Object) {
 		key := fmt.Sprintf("s3://%v/%v", bucket, aws.StringValue(d.Key))
 		url, _ := url.New(key)
 		url.VersionID = aws.StringValue(d.VersionId)
-		resultch <- &Object{URL: url}
+
+		obj := &Object{URL: url}
+		// a delete marker is created instead of removing the object if it's
+		// deleted from a versioned bucket without a version ID.
+		if aws.BoolValue(d.DeleteMarker) && url.VersionID == "" {
+			obj.DeleteMarkerVersionID = aws.StringValue(d.DeleteMarkerVersionId)
+		}
+		resultch <- obj
 	}
 
 	for _, e := range o.Errors {
@@ -1089,6 +2086,38 @@ func (s *S3) MultiDelete(ctx context.Context, urlch <-chan *url.URL) <-chan *Obj
 	return resultch
 }
 
+// LatestDeleteMarker returns the version ID of the delete marker of the given
+// object if it's the latest version of the object. An empty string is returned
+// if the latest version is not a delete marker. It's used to find the delete
+// markers created by the endpoints which don't return them on deletion.
+func (s *S3) LatestDeleteMarker(ctx context.Context, url *url.URL) (string, error) {
+	var (
+		versionID string
+		found     bool
+	)
+	err := s.api.ListObjectVersionsPagesWithContext(ctx, &s3.ListObjectVersionsInput{
+		Bucket:              aws.String(url.Bucket),
+		Prefix:              aws.String(url.Path),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
+	}, func(p *s3.ListObjectVersionsOutput, lastPage bool) bool {
+		for _, d := range p.DeleteMarkers {
+			if aws.StringValue(d.Key) == url.Path && aws.BoolValue(d.IsLatest) {
+				versionID, found = aws.StringValue(d.VersionId), true
+			}
+		}
+		for _, v := range p.Versions {
+			if aws.StringValue(v.Key) == url.Path && aws.BoolValue(v.IsLatest) {
+				found = true
+			}
+		}
+		return !found
+	})
+	if err != nil {
+		return "", err
+	}
+	return versionID, nil
+}
+
 // ListBuckets is a blocking list-operation which gets bucket list and returns
 // the buckets that match with given prefix.
 func (s *S3) ListBuckets(ctx context.Context, prefix string) ([]Bucket, error) {
@@ -1129,7 +2158,8 @@ func (s *S3) RemoveBucket(ctx context.Context, name string) error {
 	}
 
 	_, err := s.api.DeleteBucketWithContext(ctx, &s3.DeleteBucketInput{
-		Bucket: aws.String(name),
+		Bucket:              aws.String(name),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
 	})
 	return err
 }
@@ -1145,6 +2175,7 @@ func (s *S3) SetBucketVersioning(ctx context.Context, versioningStatus, bucket s
 		VersioningConfiguration: &s3.VersioningConfiguration{
 			Status: aws.String(versioningStatus),
 		},
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
 	})
 	return err
 }
@@ -1152,7 +2183,8 @@ func (s *S3) SetBucketVersioning(ctx context.Context, versioningStatus, bucket s
 // GetBucketVersioning returnsversioning property of the bucket
 func (s *S3) GetBucketVersioning(ctx context.Context, bucket string) (string, error) {
 	output, err := s.api.GetBucketVersioningWithContext(ctx, &s3.GetBucketVersioningInput{
-		Bucket: aws.String(bucket),
+		Bucket:              aws.String(bucket),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
 	})
 	if err != nil || output.Status == nil {
 		return "", err
@@ -1163,16 +2195,18 @@ func (s *S3) GetBucketVersioning(ctx context.Context, bucket string) (string, er
 
 func (s *S3) HeadBucket(ctx context.Context, url *url.URL) error {
 	_, err := s.api.HeadBucketWithContext(ctx, &s3.HeadBucketInput{
-		Bucket: aws.String(url.Bucket),
+		Bucket:              aws.String(url.Bucket),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
 	})
 	return err
 }
 
 func (s *S3) HeadObject(ctx context.Context, url *url.URL) (*Object, *Metadata, error) {
 	input := &s3.HeadObjectInput{
-		Bucket:       aws.String(url.Bucket),
-		Key:          aws.String(url.Path),
-		RequestPayer: s.RequestPayer(),
+		Bucket:              aws.String(url.Bucket),
+		Key:                 aws.String(url.Path),
+		RequestPayer:        s.RequestPayer(),
+		ExpectedBucketOwner: s.ExpectedBucketOwner(),
 	}
 
 	if url.VersionID != "" {
@@ -1203,9 +2237,19 @@ func (s *S3) HeadObject(ctx context.Context, url *url.URL) (*Object, *Metadata,
 	}
 
 	metadata := &Metadata{
-		ContentType:      aws.StringValue(output.ContentType),
-		EncryptionMethod: aws.StringValue(output.ServerSideEncryption),
-		UserDefined:      aws.StringValueMap(output.Metadata),
+		CacheControl:       aws.StringValue(output.CacheControl),
+		ContentType:        aws.StringValue(output.ContentType),
+		ContentEncoding:    aws.StringValue(output.ContentEncoding),
+		ContentDisposition: aws.StringValue(output.ContentDisposition),
+		ContentLanguage:    aws.StringValue(output.ContentLanguage),
+		EncryptionMethod:   aws.StringValue(output.ServerSideEncryption),
+		UserDefined:        aws.StringValueMap(output.Metadata),
+	}
+
+	// the expires header is in HTTP date format, but the metadata of the
+	// copied objects are expected in RFC3339 format.
+	if expires, err := http.ParseTime(aws.StringValue(output.Expires)); err == nil {
+		metadata.Expires = expires.UTC().Format(time.RFC3339)
 	}
 
 	return obj, metadata, nil
@@ -1215,7 +2259,7 @@ type sdkLogger struct{}
 
 func (l sdkLogger) Log(args ...interface{}) {
 	msg := log.TraceMessage{
-		Message: fmt.Sprint(args...),
+		Message: redactSecrets(fmt.Sprint(args...)),
 	}
 	log.Trace(msg)
 }
@@ -1225,6 +2269,9 @@ func (l sdkLogger) Log(args ...interface{}) {
 type SessionCache struct {
 	sync.Mutex
 	sessions map[Options]*session.Session
+	// retryBudget is shared by the sessions, so that it caps the retries of
+	// the whole run.
+	retryBudget *retryBudget
 }
 
 // newSession initializes a new AWS session with region fallback and custom
@@ -1239,13 +2286,39 @@ func (sc *SessionCache) newSession(ctx context.Context, opts Options) (*session.
 
 	awsCfg := aws.NewConfig()
 
+	// the profile is resolved by the SDK if no credentials file is given,
+	// so that the profiles defined in the config file, e.g. the ones with
+	// credential_process, can be used.
+	var httpClient *http.Client
+	if opts.NoVerifySSL {
+		httpClient = insecureHTTPClient
+	} else {
+		// the SDK sets the transport of the client if a custom CA bundle is
+		// given, so each session has its own client rather than sharing
+		// http.DefaultClient with the sessions which are in use.
+		httpClient = &http.Client{}
+	}
+
+	var profile string
 	if opts.NoSignRequest {
 		// do not sign requests when making service API calls
 		awsCfg = awsCfg.WithCredentials(credentials.AnonymousCredentials)
-	} else if opts.CredentialFile != "" || opts.Profile != "" {
+	} else if opts.CredentialProcess != "" {
+		awsCfg = awsCfg.WithCredentials(newProcessCredentials(opts.CredentialProcess))
+	} else if opts.WebIdentityTokenFile != "" {
+		svc, err := newSTSClient(opts, httpClient)
+		if err != nil {
+			return nil, err
+		}
+		awsCfg = awsCfg.WithCredentials(
+			newWebIdentityCredentials(svc, opts.AssumeRoleARN, opts.WebIdentityTokenFile),
+		)
+	} else if opts.CredentialFile != "" {
 		awsCfg = awsCfg.WithCredentials(
 			credentials.NewSharedCredentials(opts.CredentialFile, opts.Profile),
 		)
+	} else {
+		profile = opts.Profile
 	}
 
 	endpointURL, err := parseEndpoint(opts.Endpoint)
@@ -1256,6 +2329,12 @@ func (sc *SessionCache) newSession(ctx context.Context, opts Options) (*session.
 	// use virtual-host-style if the endpoint is known to support it,
 	// otherwise use the path-style approach.
 	isVirtualHostStyle := isVirtualHostStyle(endpointURL)
+	switch opts.AddressingStyle {
+	case AddressingStylePath:
+		isVirtualHostStyle = false
+	case AddressingStyleVirtual:
+		isVirtualHostStyle = true
+	}
 
 	useAccelerate := supportsTransferAcceleration(endpointURL)
 	// AWS SDK handles transfer acceleration automatically. Setting the
@@ -1265,14 +2344,13 @@ func (sc *SessionCache) newSession(ctx context.Context, opts Options) (*session.
 		endpointURL = sentinelURL
 	}
 
-	var httpClient *http.Client
-	if opts.NoVerifySSL {
-		httpClient = insecureHTTPClient
-	}
 	awsCfg = awsCfg.
 		WithEndpoint(endpointURL.String()).
 		WithS3ForcePathStyle(!isVirtualHostStyle).
 		WithS3UseAccelerate(useAccelerate).
+		// the requests to access points are sent to the region in their
+		// ARNs, regardless of the region of the session.
+		WithS3UseARNRegion(true).
 		WithHTTPClient(httpClient).
 		// TODO WithLowerCaseHeaderMaps and WithDisableRestProtocolURICleaning options
 		// are going to be unnecessary and unsupported in AWS-SDK version 2.
@@ -1286,7 +2364,17 @@ func (sc *SessionCache) newSession(ctx context.Context, opts Options) (*session.
 			WithLogger(sdkLogger{})
 	}
 
-	awsCfg.Retryer = newCustomRetryer(opts.MaxRetries)
+	// the value is validated by the caller, and empty value means the
+	// default classes.
+	retryOn, _ := ParseRetryOn(opts.RetryOn)
+	retryer := newCustomRetryer(opts.MaxRetries, retryOn)
+	if opts.RetryBudget > 0 {
+		if sc.retryBudget == nil {
+			sc.retryBudget = newRetryBudget(opts.RetryBudget)
+		}
+		retryer.retryBudget = sc.retryBudget
+	}
+	awsCfg.Retryer = retryer
 
 	useSharedConfig := session.SharedConfigEnable
 	{
@@ -1303,13 +2391,40 @@ func (sc *SessionCache) newSession(ctx context.Context, opts Options) (*session.
 	sess, err := session.NewSessionWithOptions(
 		session.Options{
 			Config:            *awsCfg,
+			Profile:           profile,
 			SharedConfigState: useSharedConfig,
+			CredentialsProviderOptions: &session.CredentialsProviderOptions{
+				ProcessProviderOptions:         setCredentialProcessOptions,
+				WebIdentityRoleProviderOptions: setWebIdentityOptions,
+			},
 		},
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	if !opts.NoSignRequest && opts.CredentialProcess == "" && opts.WebIdentityTokenFile == "" {
+		// the credentials may be of the credential_process of a profile.
+		sess.Config.Credentials = credentials.NewCredentials(&credentialProcessErrorProvider{
+			creds: sess.Config.Credentials,
+		})
+	}
+
+	if opts.ExpectedBucketOwner != "" {
+		sess.Handlers.UnmarshalError.PushBack(expectedBucketOwnerErrorHandler(opts.ExpectedBucketOwner))
+	}
+
+	if opts.ChecksumCalculation == RequestChecksumWhenRequired {
+		// the checksums are removed before the requests are signed.
+		sess.Handlers.Sign.PushFront(removeOptionalChecksums)
+	}
+
+	sess.Handlers.Send.PushFront(metrics.start)
+	sess.Handlers.CompleteAttempt.PushBack(metrics.complete)
+	sess.Handlers.CompleteAttempt.PushBack(observeRequest)
+	sess.Handlers.Build.PushBack(requestLog.build)
+	sess.Handlers.CompleteAttempt.PushBack(requestLog.complete)
+
 	// get region of the bucket and create session accordingly. if the region
 	// is not provided, it means we want region-independent session
 	// for operations such as listing buckets, making a new bucket etc.
@@ -1327,10 +2442,35 @@ func (sc *SessionCache) newSession(ctx context.Context, opts Options) (*session.
 	return sess, nil
 }
 
+// expectedBucketOwnerErrorHandler returns a handler which explains the access
+// denied errors of the sessions with an expected bucket owner, since they are
+// also returned when the bucket is not owned by the expected bucket owner.
+func expectedBucketOwnerErrorHandler(owner string) func(*request.Request) {
+	return func(r *request.Request) {
+		var reqErr awserr.RequestFailure
+		if !errors.As(r.Error, &reqErr) || reqErr.StatusCode() != http.StatusForbidden {
+			return
+		}
+
+		message := reqErr.Message()
+		if message == "" {
+			message = http.StatusText(http.StatusForbidden)
+		}
+		message = fmt.Sprintf("%v: the bucket may not be owned by the expected bucket owner %q", message, owner)
+
+		r.Error = awserr.NewRequestFailure(
+			awserr.New(reqErr.Code(), message, reqErr.OrigErr()),
+			reqErr.StatusCode(),
+			reqErr.RequestID(),
+		)
+	}
+}
+
 func (sc *SessionCache) clear() {
 	sc.Lock()
 	defer sc.Unlock()
 	sc.sessions = map[Options]*session.Session{}
+	sc.retryBudget = nil
 }
 
 func setSessionRegion(ctx context.Context, sess *session.Session, bucket string) error {
@@ -1371,17 +2511,55 @@ func setSessionRegion(ctx context.Context, sess *session.Session, bucket string)
 	return nil
 }
 
+// Error classes which can be retried.
+const (
+	RetryClassThrottle = "throttle"
+	RetryClass5xx      = "5xx"
+	RetryClassNetwork  = "network"
+	RetryClassTimeout  = "timeout"
+)
+
+// DefaultRetryOn is the default comma separated list of the error classes
+// which are retried.
+const DefaultRetryOn = "throttle,5xx,network,timeout"
+
+// ParseRetryOn parses the given comma separated list of error classes. Empty
+// value means the default classes.
+func ParseRetryOn(value string) (map[string]bool, error) {
+	if strings.TrimSpace(value) == "" {
+		value = DefaultRetryOn
+	}
+
+	classes := map[string]bool{}
+	for _, class := range strings.Split(value, ",") {
+		class = strings.ToLower(strings.TrimSpace(class))
+		switch class {
+		case RetryClassThrottle, RetryClass5xx, RetryClassNetwork, RetryClassTimeout:
+			classes[class] = true
+		default:
+			return nil, fmt.Errorf("unknown error class %q: valid classes are throttle, 5xx, network and timeout", class)
+		}
+	}
+	return classes, nil
+}
+
 // customRetryer wraps the SDK's built in DefaultRetryer adding additional
-// error codes. Such as, retry for S3 InternalError code.
+// error codes. Such as, retry for S3 InternalError code. Only the errors of the
+// given classes are retried.
 type customRetryer struct {
 	client.DefaultRetryer
+	retryOn map[string]bool
+	// retryBudget is the number of retries left for the whole run, if it's
+	// capped.
+	retryBudget *retryBudget
 }
 
-func newCustomRetryer(maxRetries int) *customRetryer {
+func newCustomRetryer(maxRetries int, retryOn map[string]bool) *customRetryer {
 	return &customRetryer{
 		DefaultRetryer: client.DefaultRetryer{
 			NumMaxRetries: maxRetries,
 		},
+		retryOn: retryOn,
 	}
 }
 
@@ -1399,6 +2577,19 @@ func (c *customRetryer) ShouldRetry(req *request.Request) bool {
 	}
 
 	if shouldRetry && req.Error != nil {
+		if class := retryClass(req); !c.retryOn[class] {
+			msg := log.DebugMessage{Err: fmt.Sprintf("not retrying %v error: %v", class, req.Error)}
+			log.Debug(msg)
+			return false
+		}
+
+		// the budget is only spent if the request is going to be retried.
+		if req.RetryCount < req.MaxRetries() && !c.retryBudget.take() {
+			msg := log.DebugMessage{Err: fmt.Sprintf("not retrying, retry budget is exhausted: %v", req.Error)}
+			log.Debug(msg)
+			return false
+		}
+
 		err := fmt.Errorf("retryable error: %v", req.Error)
 		msg := log.DebugMessage{Err: err.Error()}
 		log.Debug(msg)
@@ -1407,6 +2598,141 @@ func (c *customRetryer) ShouldRetry(req *request.Request) bool {
 	return shouldRetry
 }
 
+const retryBudgetWarning = `WARNING: the retry budget of %d retries is exhausted, the failed requests are not retried anymore.`
+
+// retryBudget is the number of retries left for all the requests. Once it's
+// exhausted, the failed requests are not retried, so that a run fails fast
+// rather than retrying for a long time, e.g. during an outage.
+type retryBudget struct {
+	limit int64
+	left  atomic.Int64
+	once  sync.Once
+}
+
+func newRetryBudget(limit int) *retryBudget {
+	b := &retryBudget{limit: int64(limit)}
+	b.left.Store(int64(limit))
+	return b
+}
+
+// take reports whether there is a retry left in the budget, taking it if
+// there is. It warns once when the budget is exhausted. A nil budget is
+// never exhausted.
+func (b *retryBudget) take() bool {
+	if b == nil {
+		return true
+	}
+
+	if b.left.Add(-1) >= 0 {
+		return true
+	}
+
+	b.once.Do(func() {
+		fmt.Fprintf(log.Warnings(), retryBudgetWarning+"\n", b.limit)
+	})
+	return false
+}
+
+// partRetryOptions returns the request options which set the number of
+// retries of the part requests of the multipart transfers, if it's different
+// from the number of retries of the other requests.
+func (s *S3) partRetryOptions() []request.Option {
+	if s.partRetryCount < 0 {
+		return nil
+	}
+	return []request.Option{partRetryOption(s.partRetryCount)}
+}
+
+// setDownloaderPartRetry sets the number of retries of the part requests of
+// the downloads.
+func (s *S3) setDownloaderPartRetry(u *s3manager.Downloader) {
+	u.RequestOptions = append(u.RequestOptions, s.partRetryOptions()...)
+}
+
+// partRetryOption sets the retryer of the UploadPart requests and the
+// GetObject requests of the downloader to retry each part the given number
+// of times, independently from the other requests.
+func partRetryOption(maxRetries int) request.Option {
+	return func(r *request.Request) {
+		if r.Operation.Name != "UploadPart" && r.Operation.Name != "GetObject" {
+			return
+		}
+		r.Retryer = &partRetryer{Retryer: r.Retryer, maxRetries: maxRetries}
+	}
+}
+
+// partRetryer retries the requests of the parts up to the given number of
+// times, logging the retried parts.
+type partRetryer struct {
+	request.Retryer
+	maxRetries int
+}
+
+func (p *partRetryer) MaxRetries() int {
+	return p.maxRetries
+}
+
+func (p *partRetryer) ShouldRetry(req *request.Request) bool {
+	shouldRetry := p.Retryer.ShouldRetry(req)
+	if shouldRetry && req.RetryCount < p.maxRetries {
+		msg := log.DebugMessage{Err: fmt.Sprintf("retrying %v (attempt %d/%d): %v", partName(req), req.RetryCount+1, p.maxRetries, req.Error)}
+		log.Debug(msg)
+	}
+	return shouldRetry
+}
+
+// partName returns the name of the part of the given request in the log
+// messages.
+func partName(req *request.Request) string {
+	switch input := req.Params.(type) {
+	case *s3.UploadPartInput:
+		return fmt.Sprintf("part %d of s3://%v/%v", aws.Int64Value(input.PartNumber), aws.StringValue(input.Bucket), aws.StringValue(input.Key))
+	case *s3.GetObjectInput:
+		return fmt.Sprintf("range %v of s3://%v/%v", aws.StringValue(input.Range), aws.StringValue(input.Bucket), aws.StringValue(input.Key))
+	default:
+		return req.Operation.Name
+	}
+}
+
+// retryClass classifies the error of the given request. The errors which are
+// not known to be throttling, server or timeout errors are considered network
+// errors, e.g. connection resets.
+func retryClass(req *request.Request) string {
+	err := req.Error
+
+	var statusCode int
+	if req.HTTPResponse != nil {
+		statusCode = req.HTTPResponse.StatusCode
+	}
+
+	switch {
+	case req.IsErrorThrottle() || errHasCode(err, "SlowDown"):
+		return RetryClassThrottle
+	case errHasCode(err, "RequestTimeout") ||
+		errHasCode(err, "RequestTimeoutException") ||
+		errHasCode(err, request.ErrCodeResponseTimeout) ||
+		errHasCode(err, "RequestTimeTooSkewed") ||
+		isTimeoutError(err):
+		return RetryClassTimeout
+	case statusCode >= 500 || errHasCode(err, "InternalError"):
+		return RetryClass5xx
+	default:
+		return RetryClassNetwork
+	}
+}
+
+// isTimeoutError checks if the given error, or any of the errors it wraps, is
+// a timeout error.
+func isTimeoutError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "timed out") || strings.Contains(msg, "i/o timeout") || strings.Contains(msg, "Timeout exceeded")
+}
+
 var insecureHTTPClient = &http.Client{
 	Transport: &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
@@ -1422,6 +2748,34 @@ func IsGoogleEndpoint(endpoint urlpkg.URL) bool {
 	return endpoint.Hostname() == gcsEndpoint
 }
 
+// Addressing styles of the bucket names in request URLs. The style is
+// resolved from the endpoint by default.
+const (
+	AddressingStyleAuto    = "auto"
+	AddressingStylePath    = "path"
+	AddressingStyleVirtual = "virtual"
+)
+
+// Request checksum calculation modes. The checksums which are not required
+// by the operations, e.g. the Content-MD5 header of the uploads, are only
+// sent when they are supported, since some S3 compatible services reject
+// them.
+const (
+	RequestChecksumWhenSupported = "when_supported"
+	RequestChecksumWhenRequired  = "when_required"
+)
+
+// removeOptionalChecksums removes the checksum headers which are computed by
+// the SDK for the uploads, although the operations don't require them. The
+// additional checksums which are explicitly asked for, e.g. by
+// --checksum-algorithm flag, are kept.
+func removeOptionalChecksums(r *request.Request) {
+	switch r.Operation.Name {
+	case "PutObject", "UploadPart":
+		r.HTTPRequest.Header.Del("Content-Md5")
+	}
+}
+
 // isVirtualHostStyle reports whether the given endpoint supports S3 virtual
 // host style bucket name resolving. If a custom S3 API compatible endpoint is
 // given, resolve the bucketname from the URL path.
@@ -1449,6 +2803,60 @@ func errHasCode(err error, code string) bool {
 	return false
 }
 
+// conditionalWriteOption sets the conditional headers of the requests which
+// create the object, i.e. PutObject and CompleteMultipartUpload. The SDK
+// doesn't have input fields for them.
+func conditionalWriteOption(ifMatch, ifNoneMatch string) request.Option {
+	return func(r *request.Request) {
+		switch r.Operation.Name {
+		case "PutObject", "CompleteMultipartUpload":
+		default:
+			return
+		}
+
+		if ifMatch != "" {
+			r.HTTPRequest.Header.Set("If-Match", quoteETag(ifMatch))
+		}
+		if ifNoneMatch != "" {
+			r.HTTPRequest.Header.Set("If-None-Match", quoteETag(ifNoneMatch))
+		}
+	}
+}
+
+// quoteETag quotes the given ETag as required by the conditional headers.
+func quoteETag(etag string) string {
+	if etag == "*" || strings.HasPrefix(etag, `"`) {
+		return etag
+	}
+	return strconv.Quote(etag)
+}
+
+// isNotImplementedError reports whether the error is returned by a service
+// which doesn't implement the requested API.
+func isNotImplementedError(err error) bool {
+	var reqErr awserr.RequestFailure
+	if errors.As(err, &reqErr) && reqErr.StatusCode() == http.StatusNotImplemented {
+		return true
+	}
+	return errHasCode(err, "NotImplemented")
+}
+
+// IsPreconditionFailedError reports whether given error is returned because
+// the condition of a conditional request did not hold.
+func IsPreconditionFailedError(err error) bool {
+	return errHasCode(err, "PreconditionFailed")
+}
+
+// IsNotModifiedError reports whether given error is returned because the
+// object is not modified according to the conditions of the request.
+func IsNotModifiedError(err error) bool {
+	var reqErr awserr.RequestFailure
+	if errors.As(err, &reqErr) && reqErr.StatusCode() == http.StatusNotModified {
+		return true
+	}
+	return errHasCode(err, "NotModified")
+}
+
 // IsCancelationError reports whether given error is a storage related
 // cancelation error.
 func IsCancelationError(err error) bool {
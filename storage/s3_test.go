@@ -3,8 +3,11 @@ package storage
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"math/rand"
 	"net/http"
@@ -12,7 +15,10 @@ import (
 	urlpkg "net/url"
 	"os"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -95,6 +101,218 @@ func TestNewSessionPathStyle(t *testing.T) {
 	}
 }
 
+func TestNewSessionRequestChecksumCalculation(t *testing.T) {
+	testcases := []struct {
+		name                string
+		checksumCalculation string
+		expectContentMD5    bool
+	}{
+		{
+			name:                "when supported",
+			checksumCalculation: RequestChecksumWhenSupported,
+			expectContentMD5:    true,
+		},
+		{
+			name:                "when required",
+			checksumCalculation: RequestChecksumWhenRequired,
+			expectContentMD5:    false,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var contentMD5 string
+			// a MinIO-style endpoint which is addressed in path style.
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contentMD5 = r.Header.Get("Content-Md5")
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			opts := Options{
+				Endpoint:            server.URL,
+				NoSignRequest:       true,
+				ChecksumCalculation: tc.checksumCalculation,
+				LogLevel:            log.LevelError,
+				region:              "us-east-1",
+			}
+
+			sess, err := globalSessionCache.newSession(context.Background(), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = s3.New(sess).PutObject(&s3.PutObjectInput{
+				Bucket: aws.String("bucket"),
+				Key:    aws.String("key"),
+				Body:   strings.NewReader("content"),
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			assert.Equal(t, contentMD5 != "", tc.expectContentMD5)
+		})
+	}
+}
+
+func TestNewSessionRetryBudget(t *testing.T) {
+	log.Init("error", false)
+
+	var requests atomic.Int64
+	// a backend which always fails.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sc := &SessionCache{sessions: map[Options]*session.Session{}}
+
+	headObject := func(opts Options) {
+		t.Helper()
+
+		sess, err := sc.newSession(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = s3.New(sess).HeadObject(&s3.HeadObjectInput{
+			Bucket: aws.String("bucket"),
+			Key:    aws.String("key"),
+		})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+
+	opts := Options{
+		Endpoint:      server.URL,
+		NoSignRequest: true,
+		MaxRetries:    5,
+		RetryBudget:   3,
+		LogLevel:      log.LevelError,
+		region:        "us-east-1",
+	}
+
+	// the first request is retried until the budget is exhausted.
+	headObject(opts)
+	assert.Equal(t, requests.Load(), int64(1+3))
+
+	// the budget is shared by the sessions, so the requests of another
+	// session are not retried either.
+	opts.region = "us-west-2"
+	headObject(opts)
+	assert.Equal(t, requests.Load(), int64(1+3+1))
+
+	// the requests are retried as usual without a budget.
+	opts.RetryBudget = 0
+	headObject(opts)
+	assert.Equal(t, requests.Load(), int64(1+3+1+1+5))
+}
+
+func TestS3Presign(t *testing.T) {
+	const content = "object content"
+
+	var requestURL *urlpkg.URL
+	// a MinIO-style endpoint which is addressed in path style.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestURL = r.URL
+		io.WriteString(w, content)
+	}))
+	defer server.Close()
+
+	testcases := []struct {
+		name            string
+		endpoint        string
+		addressingStyle string
+		versionID       string
+		expectedHost    string
+		expectedPath    string
+	}{
+		{
+			name:         "path style endpoint",
+			endpoint:     server.URL,
+			expectedHost: strings.TrimPrefix(server.URL, "http://"),
+			expectedPath: "/bucket/dir/key",
+		},
+		{
+			name:         "path style endpoint with version id",
+			endpoint:     server.URL,
+			versionID:    "v1",
+			expectedHost: strings.TrimPrefix(server.URL, "http://"),
+			expectedPath: "/bucket/dir/key",
+		},
+		{
+			name:            "virtual addressing style",
+			endpoint:        "https://storage.example.com",
+			addressingStyle: AddressingStyleVirtual,
+			expectedHost:    "bucket.storage.example.com",
+			expectedPath:    "/dir/key",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			opts := Options{
+				Endpoint:        tc.endpoint,
+				AddressingStyle: tc.addressingStyle,
+				LogLevel:        log.LevelError,
+				region:          "us-east-1",
+			}
+
+			sess, err := globalSessionCache.newSession(context.Background(), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sess = sess.Copy(&aws.Config{
+				Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+			})
+
+			src, err := url.New("s3://bucket/dir/key", url.WithVersion(tc.versionID))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s3client := &S3{api: s3.New(sess)}
+			presigned, err := s3client.Presign(context.Background(), src, time.Hour)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			u, err := urlpkg.Parse(presigned)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, u.Host, tc.expectedHost)
+			assert.Equal(t, u.Path, tc.expectedPath)
+			assert.Equal(t, u.Query().Get("versionId"), tc.versionID)
+			assert.Assert(t, u.Query().Get("X-Amz-Signature") != "")
+
+			if tc.endpoint != server.URL {
+				return
+			}
+
+			resp, err := http.Get(presigned)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, string(body), content)
+			assert.Equal(t, requestURL.RawQuery, u.RawQuery)
+		})
+	}
+}
+
 func TestNewSessionWithRegionSetViaEnv(t *testing.T) {
 	globalSessionCache.clear()
 
@@ -132,6 +350,70 @@ func TestNewSessionWithNoSignRequest(t *testing.T) {
 	}
 }
 
+func TestNewRemoteClientWithAccessPoint(t *testing.T) {
+	testcases := []struct {
+		name         string
+		url          string
+		expectedHost string
+	}{
+		{
+			name:         "access point",
+			url:          "s3://arn:aws:s3:us-west-2:123456789012:accesspoint/my-access-point/key",
+			expectedHost: "my-access-point-123456789012.s3-accesspoint.us-west-2.amazonaws.com",
+		},
+		{
+			name:         "outposts access point",
+			url:          "s3://arn:aws:s3-outposts:us-west-2:123456789012:outpost/op-01ac5d28a6a232904/accesspoint/my-access-point/key",
+			expectedHost: "my-access-point-123456789012.op-01ac5d28a6a232904.s3-outposts.us-west-2.amazonaws.com",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			globalSessionCache.clear()
+
+			u, err := url.New(tc.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the region is taken from the ARN instead of being fetched.
+			client, err := NewRemoteClient(context.Background(), u, Options{NoSignRequest: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			api := client.api.(*s3.S3)
+			if got := aws.StringValue(api.Config.Region); got != "us-west-2" {
+				t.Errorf("expected region us-west-2, got %v", got)
+			}
+
+			var host string
+			api.Handlers.Send.Clear()
+			api.Handlers.Unmarshal.Clear()
+			api.Handlers.UnmarshalMeta.Clear()
+			api.Handlers.ValidateResponse.Clear()
+			api.Handlers.Send.PushBack(func(r *request.Request) {
+				host = r.HTTPRequest.URL.Host
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{},
+					Body:       io.NopCloser(strings.NewReader("")),
+				}
+			})
+
+			if _, err := client.Stat(context.Background(), u); err != nil {
+				t.Fatal(err)
+			}
+
+			if host != tc.expectedHost {
+				t.Errorf("expected host %v, got %v", tc.expectedHost, host)
+			}
+		})
+	}
+}
+
 func TestNewSessionWithProfileFromFile(t *testing.T) {
 	// create a temporary credentials file
 	file, err := os.CreateTemp("", "")
@@ -289,6 +571,82 @@ func TestS3ListURL(t *testing.T) {
 	}
 }
 
+func TestS3ListFallbackToListObjectsV1(t *testing.T) {
+	log.Init("error", false)
+
+	url, err := url.New("s3://bucket/key/*")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	mockAPI := s3.New(unit.Session)
+	mockS3 := &S3{
+		api: mockAPI,
+	}
+
+	// the mock service only implements ListObjects API, and paginates by the
+	// key of the last object of the pages.
+	var operations []string
+	mockAPI.Handlers.Send.Clear()
+	mockAPI.Handlers.Unmarshal.Clear()
+	mockAPI.Handlers.UnmarshalMeta.Clear()
+	mockAPI.Handlers.ValidateResponse.Clear()
+	mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+		operations = append(operations, r.Operation.Name)
+		if r.Operation.Name != "ListObjects" {
+			r.Error = awserr.NewRequestFailure(
+				awserr.New("NotImplemented", "A header you provided implies functionality that is not implemented", nil),
+				http.StatusNotImplemented,
+				"",
+			)
+		}
+	})
+	mockAPI.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		input := r.Params.(*s3.ListObjectsInput)
+		if aws.StringValue(input.Marker) == "" {
+			r.Data = &s3.ListObjectsOutput{
+				IsTruncated: aws.Bool(true),
+				Contents: []*s3.Object{
+					{Key: aws.String("key/a.txt")},
+					{Key: aws.String("key/b.txt")},
+				},
+			}
+			return
+		}
+		if marker := aws.StringValue(input.Marker); marker != "key/b.txt" {
+			t.Errorf("expected marker key/b.txt, got %v", marker)
+		}
+		r.Data = &s3.ListObjectsOutput{
+			IsTruncated: aws.Bool(false),
+			Contents: []*s3.Object{
+				{Key: aws.String("key/c.txt")},
+			},
+		}
+	})
+
+	var got []string
+	for obj := range mockS3.List(context.Background(), url, true) {
+		if obj.Err != nil {
+			t.Fatalf("unexpected error: %v", obj.Err)
+		}
+		got = append(got, obj.URL.Absolute())
+	}
+
+	expected := []string{"s3://bucket/key/a.txt", "s3://bucket/key/b.txt", "s3://bucket/key/c.txt"}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("(-want +got):\n%v", diff)
+	}
+
+	// the next listings don't try ListObjectsV2 API again.
+	for range mockS3.List(context.Background(), url, true) {
+	}
+
+	expectedOperations := []string{"ListObjectsV2", "ListObjects", "ListObjects", "ListObjects", "ListObjects"}
+	if diff := cmp.Diff(expectedOperations, operations); diff != "" {
+		t.Errorf("(-want +got):\n%v", diff)
+	}
+}
+
 func TestS3ListError(t *testing.T) {
 	url, err := url.New("s3://bucket/key")
 	if err != nil {
@@ -537,7 +895,8 @@ func TestS3Retry(t *testing.T) {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
 			sess := unit.Session
-			sess.Config.Retryer = newCustomRetryer(expectedRetry)
+			retryOn, _ := ParseRetryOn(DefaultRetryOn)
+			sess.Config.Retryer = newCustomRetryer(expectedRetry, retryOn)
 
 			mockAPI := s3.New(sess)
 			mockS3 := &S3{
@@ -573,27 +932,60 @@ func TestS3Retry(t *testing.T) {
 	}
 }
 
-func TestS3RetryOnNoSuchUpload(t *testing.T) {
+func TestS3RetryOn(t *testing.T) {
 	log.Init("debug", false)
 
-	noSuchUploadError := awserr.New(s3.ErrCodeNoSuchUpload, "The specified upload does not exist. The upload ID may be invalid, or the upload may have been aborted or completed. status code: 404, request id: PJXXXXX, host id: HOSTIDXX", nil)
 	testcases := []struct {
-		name       string
-		err        error
-		retryCount int32
+		name          string
+		retryOn       string
+		err           error
+		statusCode    int
+		expectedRetry int
 	}{
 		{
-			name:       "Don't retry",
-			err:        noSuchUploadError,
-			retryCount: 0,
-		}, {
-			name:       "Retry 5 times on NoSuchUpload error",
-			err:        noSuchUploadError,
-			retryCount: 5,
-		}, {
-			name:       "No error",
-			err:        nil,
-			retryCount: 0,
+			name:          "throttle is retried",
+			retryOn:       "throttle",
+			err:           awserr.New("SlowDown", "Please reduce your request rate.", nil),
+			statusCode:    http.StatusServiceUnavailable,
+			expectedRetry: 5,
+		},
+		{
+			name:          "5xx is not retried",
+			retryOn:       "throttle",
+			err:           awserr.New("InternalError", "internal error", nil),
+			statusCode:    http.StatusInternalServerError,
+			expectedRetry: 0,
+		},
+		{
+			name:          "network is not retried",
+			retryOn:       "throttle,5xx",
+			err:           awserr.New(request.ErrCodeRequestError, "connection reset by peer", nil),
+			expectedRetry: 0,
+		},
+		{
+			name:          "throttle is not retried",
+			retryOn:       "5xx,network",
+			err:           awserr.New("Throttling", "throttling", nil),
+			expectedRetry: 0,
+		},
+		{
+			name:          "timeout is retried",
+			retryOn:       "timeout",
+			err:           awserr.New(request.ErrCodeRequestError, "", tempError{err: errors.New("connection timed out")}),
+			expectedRetry: 5,
+		},
+		{
+			name:          "timeout is not retried",
+			retryOn:       "throttle,5xx,network",
+			err:           awserr.New("RequestTimeout", "request timeout", nil),
+			expectedRetry: 0,
+		},
+		{
+			name:          "access denied is never retried",
+			retryOn:       DefaultRetryOn,
+			err:           awserr.New("AccessDenied", "access denied", nil),
+			statusCode:    http.StatusForbidden,
+			expectedRetry: 0,
 		},
 	}
 
@@ -602,100 +994,1994 @@ func TestS3RetryOnNoSuchUpload(t *testing.T) {
 		t.Errorf("unexpected error: %v", err)
 	}
 
+	const expectedRetry = 5
 	for _, tc := range testcases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			mockAPI := s3.New(unit.Session)
+			retryOn, err := ParseRetryOn(tc.retryOn)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			sess := unit.Session
+			sess.Config.Retryer = newCustomRetryer(expectedRetry, retryOn)
+
+			mockAPI := s3.New(sess)
 			mockS3 := &S3{
 				api: mockAPI,
-				uploader: &s3manager.Uploader{
-					S3:                mockAPI,
-					PartSize:          s3manager.DefaultUploadPartSize,
-					Concurrency:       s3manager.DefaultUploadConcurrency,
-					LeavePartsOnError: false,
-					MaxUploadParts:    s3manager.MaxUploadParts,
-				},
-				noSuchUploadRetryCount: int(tc.retryCount),
 			}
 
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 
-			atomicCounter := new(int32)
-			atomic.StoreInt32(atomicCounter, 0)
-
 			mockAPI.Handlers.Send.Clear()
 			mockAPI.Handlers.Unmarshal.Clear()
 			mockAPI.Handlers.UnmarshalMeta.Clear()
 			mockAPI.Handlers.ValidateResponse.Clear()
 			mockAPI.Handlers.Unmarshal.PushBack(func(r *request.Request) {
 				r.Error = tc.err
-				r.HTTPResponse = &http.Response{}
+				r.HTTPResponse = &http.Response{StatusCode: tc.statusCode}
 			})
-			mockAPI.Handlers.Unmarshal.PushBack(func(r *request.Request) {
-				atomic.AddInt32(atomicCounter, 1)
+
+			retried := -1
+			mockAPI.Handlers.AfterRetry.PushBack(func(_ *request.Request) {
+				retried++
 			})
 
-			mockS3.Put(ctx, strings.NewReader(""), url, Metadata{}, s3manager.DefaultUploadConcurrency, s3manager.DefaultUploadPartSize)
+			for range mockS3.List(ctx, url, true) {
+			}
 
-			// +1 is for the original request
-			// *2 is to account for the "Stat" requests that are made to obtain
-			// retry code from object metada.
-			want := 2*tc.retryCount + 1
-			counter := atomic.LoadInt32(atomicCounter)
-			if counter != want {
+			if retried != tc.expectedRetry {
+				t.Errorf("expected retry %v, got %v", tc.expectedRetry, retried)
+			}
+		})
+	}
+}
+
+func TestParseRetryOn(t *testing.T) {
+	t.Parallel()
+
+	classes, err := ParseRetryOn("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(classes) != 4 {
+		t.Errorf("expected all of the classes by default, got %v", classes)
+	}
+
+	classes, err = ParseRetryOn(" Throttle, 5xx ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !classes[RetryClassThrottle] || !classes[RetryClass5xx] || classes[RetryClassNetwork] || classes[RetryClassTimeout] {
+		t.Errorf("unexpected classes: %v", classes)
+	}
+
+	_, err = ParseRetryOn("throttle,4xx")
+	if err == nil || !strings.Contains(err.Error(), `unknown error class "4xx"`) {
+		t.Errorf("expected unknown error class error, got %v", err)
+	}
+}
+
+func TestS3RetryOnNoSuchUpload(t *testing.T) {
+	log.Init("debug", false)
+
+	noSuchUploadError := awserr.New(s3.ErrCodeNoSuchUpload, "The specified upload does not exist. The upload ID may be invalid, or the upload may have been aborted or completed. status code: 404, request id: PJXXXXX, host id: HOSTIDXX", nil)
+	testcases := []struct {
+		name       string
+		err        error
+		retryCount int32
+	}{
+		{
+			name:       "Don't retry",
+			err:        noSuchUploadError,
+			retryCount: 0,
+		}, {
+			name:       "Retry 5 times on NoSuchUpload error",
+			err:        noSuchUploadError,
+			retryCount: 5,
+		}, {
+			name:       "No error",
+			err:        nil,
+			retryCount: 0,
+		},
+	}
+
+	url, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := s3.New(unit.Session)
+			mockS3 := &S3{
+				api: mockAPI,
+				uploader: &s3manager.Uploader{
+					S3:                mockAPI,
+					PartSize:          s3manager.DefaultUploadPartSize,
+					Concurrency:       s3manager.DefaultUploadConcurrency,
+					LeavePartsOnError: false,
+					MaxUploadParts:    s3manager.MaxUploadParts,
+				},
+				noSuchUploadRetryCount: int(tc.retryCount),
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			atomicCounter := new(int32)
+			atomic.StoreInt32(atomicCounter, 0)
+
+			mockAPI.Handlers.Send.Clear()
+			mockAPI.Handlers.Unmarshal.Clear()
+			mockAPI.Handlers.UnmarshalMeta.Clear()
+			mockAPI.Handlers.ValidateResponse.Clear()
+			mockAPI.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+				r.Error = tc.err
+				r.HTTPResponse = &http.Response{}
+			})
+			mockAPI.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+				atomic.AddInt32(atomicCounter, 1)
+			})
+
+			mockS3.Put(ctx, strings.NewReader(""), url, Metadata{}, s3manager.DefaultUploadConcurrency, s3manager.DefaultUploadPartSize)
+
+			// +1 is for the original request
+			// *2 is to account for the "Stat" requests that are made to obtain
+			// retry code from object metada.
+			want := 2*tc.retryCount + 1
+			counter := atomic.LoadInt32(atomicCounter)
+			if counter != want {
 				t.Errorf("expected retry request count %d, got %d", want, counter)
 			}
 		})
 	}
 }
 
-func TestS3CopyEncryptionRequest(t *testing.T) {
+func TestS3ResumeUpload(t *testing.T) {
+	log.Init("debug", false)
+
+	const partSize = 5
+
+	content := []byte("0123456789abcdefghij-")
+	partETag := func(b []byte) string {
+		return fmt.Sprintf(`"%x"`, md5.Sum(b))
+	}
+
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockAPI := s3.New(unit.Session)
+	mockS3 := &S3{api: mockAPI}
+
+	mockAPI.Handlers.Unmarshal.Clear()
+	mockAPI.Handlers.UnmarshalMeta.Clear()
+	mockAPI.Handlers.UnmarshalError.Clear()
+	mockAPI.Handlers.Send.Clear()
+
+	src := &Object{Size: int64(len(content)), ModTime: aws.Time(time.Now().Add(-2 * time.Hour))}
+	metadata := Metadata{ContentType: "text/plain"}
+	marker := ResumeMarker(src, metadata, partSize)
+
+	var (
+		mu            sync.Mutex
+		uploadedParts []int64
+		completed     []*s3.CompletedPart
+	)
+	mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}
+
+		switch r.Operation.Name {
+		case "ListMultipartUploads":
+			r.Data = &s3.ListMultipartUploadsOutput{
+				Uploads: []*s3.MultipartUpload{
+					{Key: aws.String("key-other"), UploadId: aws.String("other")},
+					{Key: aws.String("key"), UploadId: aws.String("interrupted"), Initiated: aws.Time(time.Now().Add(-time.Hour))},
+					{Key: aws.String("key"), UploadId: aws.String("other-writer"), Initiated: aws.Time(time.Now())},
+				},
+			}
+		case "ListParts":
+			input := r.Params.(*s3.ListPartsInput)
+			switch aws.StringValue(input.UploadId) {
+			case "interrupted":
+				r.Data = &s3.ListPartsOutput{
+					Parts: []*s3.Part{
+						{PartNumber: aws.Int64(1), Size: aws.Int64(partSize), ETag: aws.String(partETag(content[0:5]))},
+						// the content of the third part has changed, it must be
+						// uploaded again.
+						{PartNumber: aws.Int64(3), Size: aws.Int64(partSize), ETag: aws.String(partETag([]byte("00000")))},
+						{PartNumber: aws.Int64(ResumeMarkerPartNumber), Size: aws.Int64(int64(len(marker))), ETag: aws.String(partETag(marker))},
+					},
+				}
+			case "other-writer":
+				// the more recent upload of another writer has the same
+				// layout, but not the marker of the transfer.
+				otherMarker := ResumeMarker(src, Metadata{ContentType: "image/png"}, partSize)
+				r.Data = &s3.ListPartsOutput{
+					Parts: []*s3.Part{
+						{PartNumber: aws.Int64(1), Size: aws.Int64(partSize), ETag: aws.String(partETag([]byte("abcde")))},
+						{PartNumber: aws.Int64(ResumeMarkerPartNumber), Size: aws.Int64(int64(len(otherMarker))), ETag: aws.String(partETag(otherMarker))},
+					},
+				}
+			default:
+				t.Errorf("unexpected upload id %q", aws.StringValue(input.UploadId))
+			}
+		case "UploadPart":
+			input := r.Params.(*s3.UploadPartInput)
+			body, _ := io.ReadAll(input.Body)
+			mu.Lock()
+			uploadedParts = append(uploadedParts, aws.Int64Value(input.PartNumber))
+			mu.Unlock()
+			r.Data.(*s3.UploadPartOutput).ETag = aws.String(partETag(body))
+		case "CompleteMultipartUpload":
+			input := r.Params.(*s3.CompleteMultipartUploadInput)
+			if aws.StringValue(input.UploadId) != "interrupted" {
+				t.Errorf("unexpected upload id %q", aws.StringValue(input.UploadId))
+			}
+			completed = input.MultipartUpload.Parts
+			// skip the customized response handling of the operation.
+			r.Handlers.Unmarshal.Clear()
+		default:
+			t.Errorf("unexpected operation %q", r.Operation.Name)
+		}
+	})
+
+	resumed, err := mockS3.ResumeUpload(context.Background(), bytes.NewReader(content), src, u, metadata, 2, partSize)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Assert(t, resumed)
+
+	sort.Slice(uploadedParts, func(i, j int) bool { return uploadedParts[i] < uploadedParts[j] })
+	if diff := cmp.Diff([]int64{2, 3, 4, 5}, uploadedParts); diff != "" {
+		t.Errorf("(-want +got):\n%v", diff)
+	}
+
+	var gotETags []string
+	for i, part := range completed {
+		assert.Equal(t, int64(i+1), aws.Int64Value(part.PartNumber))
+		gotETags = append(gotETags, aws.StringValue(part.ETag))
+	}
+	expectedETags := []string{
+		partETag(content[0:5]),
+		partETag(content[5:10]),
+		partETag(content[10:15]),
+		partETag(content[15:20]),
+		partETag(content[20:]),
+	}
+	if diff := cmp.Diff(expectedETags, gotETags); diff != "" {
+		t.Errorf("(-want +got):\n%v", diff)
+	}
+}
+
+func TestS3ResumeUploadNoMatchingUpload(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockAPI := s3.New(unit.Session)
+	mockS3 := &S3{api: mockAPI}
+
+	mockAPI.Handlers.Unmarshal.Clear()
+	mockAPI.Handlers.UnmarshalMeta.Clear()
+	mockAPI.Handlers.UnmarshalError.Clear()
+	mockAPI.Handlers.Send.Clear()
+
+	mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}
+
+		switch r.Operation.Name {
+		case "ListMultipartUploads":
+			r.Data = &s3.ListMultipartUploadsOutput{
+				Uploads: []*s3.MultipartUpload{
+					{Key: aws.String("key"), UploadId: aws.String("different-part-size")},
+				},
+			}
+		case "ListParts":
+			r.Data = &s3.ListPartsOutput{
+				Parts: []*s3.Part{
+					{PartNumber: aws.Int64(1), Size: aws.Int64(8)},
+				},
+			}
+		default:
+			t.Errorf("unexpected operation %q", r.Operation.Name)
+		}
+	})
+
+	resumed, err := mockS3.ResumeUpload(context.Background(), strings.NewReader("0123456789abcdefghij"), &Object{Size: 20}, u, Metadata{}, 1, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Assert(t, !resumed)
+}
+
+func TestS3ResumeUploadOfAnotherTransfer(t *testing.T) {
+	modTime := time.Now().Add(-time.Hour)
+	src := &Object{Size: 20, ModTime: aws.Time(modTime)}
+	metadata := Metadata{ContentType: "text/plain", UserDefined: map[string]string{"team": "web"}}
+
+	testcases := []struct {
+		name   string
+		marker []byte
+	}{
+		{
+			name: "not marked",
+		},
+		{
+			name:   "different metadata",
+			marker: ResumeMarker(src, Metadata{ContentType: "text/plain", UserDefined: map[string]string{"team": "data"}}, 5),
+		},
+		{
+			name:   "different storage class",
+			marker: ResumeMarker(src, Metadata{ContentType: "text/plain", UserDefined: map[string]string{"team": "web"}, StorageClass: "GLACIER"}, 5),
+		},
+		{
+			name:   "modified source",
+			marker: ResumeMarker(&Object{Size: 20, ModTime: aws.Time(modTime.Add(-time.Minute))}, metadata, 5),
+		},
+	}
+
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := s3.New(unit.Session)
+			mockS3 := &S3{api: mockAPI}
+
+			mockAPI.Handlers.Unmarshal.Clear()
+			mockAPI.Handlers.UnmarshalMeta.Clear()
+			mockAPI.Handlers.UnmarshalError.Clear()
+			mockAPI.Handlers.Send.Clear()
+
+			mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("")),
+				}
+
+				switch r.Operation.Name {
+				case "ListMultipartUploads":
+					r.Data = &s3.ListMultipartUploadsOutput{
+						Uploads: []*s3.MultipartUpload{
+							{Key: aws.String("key"), UploadId: aws.String("another-transfer"), Initiated: aws.Time(time.Now())},
+						},
+					}
+				case "ListParts":
+					parts := []*s3.Part{
+						{PartNumber: aws.Int64(1), Size: aws.Int64(5), ETag: aws.String(fmt.Sprintf(`"%x"`, md5.Sum([]byte("01234"))))},
+					}
+					if tc.marker != nil {
+						parts = append(parts, &s3.Part{
+							PartNumber: aws.Int64(ResumeMarkerPartNumber),
+							Size:       aws.Int64(int64(len(tc.marker))),
+							ETag:       aws.String(fmt.Sprintf(`"%x"`, md5.Sum(tc.marker))),
+						})
+					}
+					r.Data = &s3.ListPartsOutput{Parts: parts}
+				default:
+					// the parts of the upload are not uploaded.
+					t.Errorf("unexpected operation %q", r.Operation.Name)
+				}
+			})
+
+			resumed, err := mockS3.ResumeUpload(context.Background(), strings.NewReader("0123456789abcdefghij"), src, u, metadata, 1, 5)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assert.Assert(t, !resumed)
+		})
+	}
+}
+
+func TestS3ResumeUploadCancelsPartsOnError(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockAPI := s3.New(unit.Session)
+	mockS3 := &S3{api: mockAPI}
+
+	mockAPI.Handlers.Unmarshal.Clear()
+	mockAPI.Handlers.UnmarshalMeta.Clear()
+	mockAPI.Handlers.UnmarshalError.Clear()
+	mockAPI.Handlers.Send.Clear()
+
+	src := &Object{Size: 20, ModTime: aws.Time(time.Now().Add(-time.Hour))}
+	marker := ResumeMarker(src, Metadata{}, 5)
+
+	var uploadedParts []int64
+	mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}
+
+		switch r.Operation.Name {
+		case "ListMultipartUploads":
+			r.Data = &s3.ListMultipartUploadsOutput{
+				Uploads: []*s3.MultipartUpload{
+					{Key: aws.String("key"), UploadId: aws.String("interrupted"), Initiated: aws.Time(time.Now())},
+				},
+			}
+		case "ListParts":
+			r.Data = &s3.ListPartsOutput{
+				Parts: []*s3.Part{
+					{PartNumber: aws.Int64(ResumeMarkerPartNumber), Size: aws.Int64(int64(len(marker))), ETag: aws.String(fmt.Sprintf(`"%x"`, md5.Sum(marker)))},
+				},
+			}
+		case "UploadPart":
+			partNum := aws.Int64Value(r.Params.(*s3.UploadPartInput).PartNumber)
+			uploadedParts = append(uploadedParts, partNum)
+			if partNum == 2 {
+				r.HTTPResponse.StatusCode = http.StatusForbidden
+				r.Error = awserr.New("AccessDenied", "Access Denied", nil)
+			}
+		default:
+			t.Errorf("unexpected operation %q", r.Operation.Name)
+		}
+	})
+
+	resumed, err := mockS3.ResumeUpload(context.Background(), strings.NewReader("0123456789abcdefghij"), src, u, Metadata{}, 1, 5)
+	assert.Assert(t, resumed)
+	assert.ErrorContains(t, err, "AccessDenied")
+
+	// the parts after the failed one are not uploaded.
+	if diff := cmp.Diff([]int64{1, 2}, uploadedParts); diff != "" {
+		t.Errorf("(-want +got):\n%v", diff)
+	}
+}
+
+func TestS3PutMarksResumableUpload(t *testing.T) {
+	const partSize = s3manager.MinUploadPartSize
+
+	content := bytes.Repeat([]byte("0123456789"), 11*1024*1024/10)
+
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockAPI := s3.New(unit.Session)
+	mockS3 := &S3{
+		api: mockAPI,
+		uploader: &s3manager.Uploader{
+			S3:                mockAPI,
+			PartSize:          s3manager.DefaultUploadPartSize,
+			Concurrency:       s3manager.DefaultUploadConcurrency,
+			LeavePartsOnError: true,
+			MaxUploadParts:    s3manager.MaxUploadParts,
+		},
+		leavePartsOnError: true,
+	}
+
+	mockAPI.Handlers.Unmarshal.Clear()
+	mockAPI.Handlers.UnmarshalMeta.Clear()
+	mockAPI.Handlers.UnmarshalError.Clear()
+	mockAPI.Handlers.Send.Clear()
+
+	var (
+		mu        sync.Mutex
+		parts     = map[int64][]byte{}
+		completed []*s3.CompletedPart
+	)
+	mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}
+
+		switch r.Operation.Name {
+		case "CreateMultipartUpload":
+			r.Data.(*s3.CreateMultipartUploadOutput).UploadId = aws.String("upload")
+		case "UploadPart":
+			input := r.Params.(*s3.UploadPartInput)
+			body, _ := io.ReadAll(input.Body)
+			mu.Lock()
+			parts[aws.Int64Value(input.PartNumber)] = body
+			mu.Unlock()
+			r.Data.(*s3.UploadPartOutput).ETag = aws.String(fmt.Sprintf(`"%x"`, md5.Sum(body)))
+		case "CompleteMultipartUpload":
+			completed = r.Params.(*s3.CompleteMultipartUploadInput).MultipartUpload.Parts
+			r.Handlers.Unmarshal.Clear()
+		default:
+			t.Errorf("unexpected operation %q", r.Operation.Name)
+		}
+	})
+
+	src := &Object{Size: int64(len(content)), ModTime: aws.Time(time.Now())}
+	metadata := Metadata{ContentType: "text/plain", ResumeSource: src}
+	err = mockS3.Put(context.Background(), bytes.NewReader(content), u, metadata, 1, partSize)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff(ResumeMarker(src, metadata, partSize), parts[ResumeMarkerPartNumber]); diff != "" {
+		t.Errorf("(-want +got):\n%v", diff)
+	}
+
+	// the marker is not a part of the object.
+	var completedParts []int64
+	for _, part := range completed {
+		completedParts = append(completedParts, aws.Int64Value(part.PartNumber))
+	}
+	if diff := cmp.Diff([]int64{1, 2, 3}, completedParts); diff != "" {
+		t.Errorf("(-want +got):\n%v", diff)
+	}
+}
+
+func TestS3CopyEncryptionRequest(t *testing.T) {
+	testcases := []struct {
+		name     string
+		sse      string
+		sseKeyID string
+		acl      string
+
+		expectedSSE      string
+		expectedSSEKeyID string
+		expectedACL      string
+	}{
+		{
+			name: "no encryption/no acl, by default",
+		},
+		{
+			name: "aws:kms encryption with server side generated keys",
+			sse:  "aws:kms",
+
+			expectedSSE: "aws:kms",
+		},
+		{
+			name:     "aws:kms encryption with user provided key",
+			sse:      "aws:kms",
+			sseKeyID: "sdkjn12SDdci#@#EFRFERTqW/ke",
+
+			expectedSSE:      "aws:kms",
+			expectedSSEKeyID: "sdkjn12SDdci#@#EFRFERTqW/ke",
+		},
+		{
+			name:     "provide key without encryption flag, shall be ignored",
+			sseKeyID: "1234567890",
+		},
+		{
+			name:        "acl flag with a value",
+			acl:         "bucket-owner-full-control",
+			expectedACL: "bucket-owner-full-control",
+		},
+	}
+
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := s3.New(unit.Session)
+
+			mockAPI.Handlers.Unmarshal.Clear()
+			mockAPI.Handlers.UnmarshalMeta.Clear()
+			mockAPI.Handlers.UnmarshalError.Clear()
+			mockAPI.Handlers.Send.Clear()
+
+			mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("")),
+				}
+
+				params := r.Params
+				sse := valueAtPath(params, "ServerSideEncryption")
+				key := valueAtPath(params, "SSEKMSKeyId")
+
+				if !(sse == nil && tc.expectedSSE == "") {
+					assert.Equal(t, sse, tc.expectedSSE)
+				}
+				if !(key == nil && tc.expectedSSEKeyID == "") {
+					assert.Equal(t, key, tc.expectedSSEKeyID)
+				}
+
+				aclVal := valueAtPath(r.Params, "ACL")
+
+				if aclVal == nil && tc.expectedACL == "" {
+					return
+				}
+				assert.Equal(t, aclVal, tc.expectedACL)
+			})
+			mockAPI.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+				if r.Error != nil {
+					if awsErr, ok := r.Error.(awserr.Error); ok {
+						if awsErr.Code() == request.ErrCodeSerialization {
+							r.Error = nil
+						}
+					}
+				}
+			})
+
+			mockS3 := &S3{
+				api: mockAPI,
+			}
+
+			metadata := Metadata{}
+			metadata.EncryptionMethod = tc.sse
+			metadata.EncryptionKeyID = tc.sseKeyID
+			metadata.ACL = tc.acl
+
+			err = mockS3.Copy(context.Background(), u, u, metadata)
+			if err != nil {
+				t.Errorf("Expected %v, but received %q", nil, err)
+			}
+		})
+	}
+}
+
+func TestS3PutEncryptionRequest(t *testing.T) {
+	testcases := []struct {
+		name     string
+		sse      string
+		sseKeyID string
+		acl      string
+
+		expectedSSE      string
+		expectedSSEKeyID string
+		expectedACL      string
+	}{
+		{
+			name: "no encryption, no acl flag",
+		},
+		{
+			name:        "aws:kms encryption with server side generated keys",
+			sse:         "aws:kms",
+			expectedSSE: "aws:kms",
+		},
+		{
+			name:     "aws:kms encryption with user provided key",
+			sse:      "aws:kms",
+			sseKeyID: "sdkjn12SDdci#@#EFRFERTqW/ke",
+
+			expectedSSE:      "aws:kms",
+			expectedSSEKeyID: "sdkjn12SDdci#@#EFRFERTqW/ke",
+		},
+		{
+			name:     "provide key without encryption flag, shall be ignored",
+			sseKeyID: "1234567890",
+		},
+		{
+			name:        "acl flag with a value",
+			acl:         "bucket-owner-full-control",
+			expectedACL: "bucket-owner-full-control",
+		},
+	}
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := s3.New(unit.Session)
+
+			mockAPI.Handlers.Unmarshal.Clear()
+			mockAPI.Handlers.UnmarshalMeta.Clear()
+			mockAPI.Handlers.UnmarshalError.Clear()
+			mockAPI.Handlers.Send.Clear()
+
+			mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("")),
+				}
+
+				params := r.Params
+				sse := valueAtPath(params, "ServerSideEncryption")
+				key := valueAtPath(params, "SSEKMSKeyId")
+
+				if !(sse == nil && tc.expectedSSE == "") {
+					assert.Equal(t, sse, tc.expectedSSE)
+				}
+				if !(key == nil && tc.expectedSSEKeyID == "") {
+					assert.Equal(t, key, tc.expectedSSEKeyID)
+				}
+
+				aclVal := valueAtPath(r.Params, "ACL")
+
+				if aclVal == nil && tc.expectedACL == "" {
+					return
+				}
+				assert.Equal(t, aclVal, tc.expectedACL)
+			})
+
+			mockS3 := &S3{
+				uploader: s3manager.NewUploaderWithClient(mockAPI),
+			}
+
+			metadata := Metadata{}
+			metadata.EncryptionMethod = tc.sse
+			metadata.EncryptionKeyID = tc.sseKeyID
+			metadata.ACL = tc.acl
+
+			err = mockS3.Put(context.Background(), bytes.NewReader([]byte("")), u, metadata, 1, 5242880)
+			if err != nil {
+				t.Errorf("Expected %v, but received %q", nil, err)
+			}
+		})
+	}
+}
+
+func TestS3GrantsRequest(t *testing.T) {
+	grants := Grants{
+		Read:        `id="111122223333", uri="http://acs.amazonaws.com/groups/global/AllUsers"`,
+		FullControl: `emailAddress="user@example.com"`,
+	}
+	expected := map[string]string{
+		"X-Amz-Grant-Read":         grants.Read,
+		"X-Amz-Grant-Read-Acp":     "",
+		"X-Amz-Grant-Write-Acp":    "",
+		"X-Amz-Grant-Full-Control": grants.FullControl,
+	}
+
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	testcases := []struct {
+		name string
+		call func(*S3) error
+	}{
+		{
+			name: "put",
+			call: func(s *S3) error {
+				return s.Put(context.Background(), bytes.NewReader([]byte("")), u, Metadata{Grants: grants}, 1, 5242880)
+			},
+		},
+		{
+			name: "copy",
+			call: func(s *S3) error {
+				return s.Copy(context.Background(), u, u, Metadata{Grants: grants})
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := s3.New(unit.Session)
+
+			mockAPI.Handlers.Unmarshal.Clear()
+			mockAPI.Handlers.UnmarshalMeta.Clear()
+			mockAPI.Handlers.UnmarshalError.Clear()
+			mockAPI.Handlers.Send.Clear()
+
+			var requests int
+			mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+				requests++
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("")),
+				}
+
+				for header, value := range expected {
+					assert.Equal(t, r.HTTPRequest.Header.Get(header), value, header)
+				}
+			})
+			mockAPI.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+				if awsErr, ok := r.Error.(awserr.Error); ok && awsErr.Code() == request.ErrCodeSerialization {
+					r.Error = nil
+				}
+			})
+
+			mockS3 := &S3{
+				api:      mockAPI,
+				uploader: s3manager.NewUploaderWithClient(mockAPI),
+			}
+
+			assert.NilError(t, tc.call(mockS3))
+			assert.Equal(t, requests, 1)
+		})
+	}
+}
+
+func TestS3PutConditional(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testcases := []struct {
+		name        string
+		size        int
+		ifMatch     string
+		ifNoneMatch string
+
+		expectedHeaders map[string]map[string]string
+	}{
+		{
+			name:        "put object if none match",
+			size:        1,
+			ifNoneMatch: "*",
+			expectedHeaders: map[string]map[string]string{
+				"PutObject": {"If-None-Match": "*"},
+			},
+		},
+		{
+			name:    "put object if match",
+			size:    1,
+			ifMatch: "5d41402abc4b2a76b9719d911017c592",
+			expectedHeaders: map[string]map[string]string{
+				"PutObject": {"If-Match": `"5d41402abc4b2a76b9719d911017c592"`},
+			},
+		},
+		{
+			name:        "multipart upload if none match",
+			size:        6 * 1024 * 1024,
+			ifNoneMatch: "*",
+			expectedHeaders: map[string]map[string]string{
+				"CreateMultipartUpload":   {"If-None-Match": ""},
+				"UploadPart":              {"If-None-Match": ""},
+				"CompleteMultipartUpload": {"If-None-Match": "*"},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := s3.New(unit.Session)
+
+			mockAPI.Handlers.Unmarshal.Clear()
+			mockAPI.Handlers.UnmarshalMeta.Clear()
+			mockAPI.Handlers.UnmarshalError.Clear()
+			mockAPI.Handlers.Send.Clear()
+
+			var mu sync.Mutex
+			seen := map[string]bool{}
+			mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("")),
+				}
+
+				switch output := r.Data.(type) {
+				case *s3.CreateMultipartUploadOutput:
+					output.UploadId = aws.String("upload-id")
+				case *s3.UploadPartOutput:
+					output.ETag = aws.String("etag")
+				case *s3.CompleteMultipartUploadOutput:
+					// the SDK expects a response body for 200 OK responses.
+					r.HTTPResponse.Body = io.NopCloser(strings.NewReader("<CompleteMultipartUploadResult/>"))
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				name := r.Operation.Name
+				seen[name] = true
+				for header, expected := range tc.expectedHeaders[name] {
+					if got := r.HTTPRequest.Header.Get(header); got != expected {
+						t.Errorf("%v: expected %v header %q, got %q", name, header, expected, got)
+					}
+				}
+			})
+
+			mockS3 := &S3{
+				uploader: s3manager.NewUploaderWithClient(mockAPI),
+			}
+
+			metadata := Metadata{
+				IfMatch:     tc.ifMatch,
+				IfNoneMatch: tc.ifNoneMatch,
+			}
+
+			reader := bytes.NewReader(make([]byte, tc.size))
+			err := mockS3.Put(context.Background(), reader, u, metadata, 1, 5*1024*1024)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for name := range tc.expectedHeaders {
+				if !seen[name] {
+					t.Errorf("expected %v request", name)
+				}
+			}
+		})
+	}
+}
+
+func TestS3PutConditionalPreconditionFailed(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockAPI := s3.New(unit.Session)
+
+	mockAPI.Handlers.Unmarshal.Clear()
+	mockAPI.Handlers.UnmarshalMeta.Clear()
+	mockAPI.Handlers.UnmarshalError.Clear()
+	mockAPI.Handlers.Send.Clear()
+
+	// the object is created by another writer after the first upload.
+	var created bool
+	mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}
+
+		if created && r.HTTPRequest.Header.Get("If-None-Match") == "*" {
+			r.HTTPResponse.StatusCode = http.StatusPreconditionFailed
+			r.Error = awserr.NewRequestFailure(
+				awserr.New("PreconditionFailed", "At least one of the pre-conditions you specified did not hold", nil),
+				http.StatusPreconditionFailed,
+				"id",
+			)
+			return
+		}
+		created = true
+	})
+
+	mockS3 := &S3{
+		uploader: s3manager.NewUploaderWithClient(mockAPI),
+	}
+
+	metadata := Metadata{IfNoneMatch: "*"}
+
+	err = mockS3.Put(context.Background(), strings.NewReader("first"), u, metadata, 1, 5*1024*1024)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = mockS3.Put(context.Background(), strings.NewReader("second"), u, metadata, 1, 5*1024*1024)
+	if !IsPreconditionFailedError(err) {
+		t.Fatalf("expected precondition failed error, got %v", err)
+	}
+}
+
+func TestS3PutChecksumAlgorithm(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	const partSize = 5 * 1024 * 1024
+
+	crc32c := func(b []byte) string {
+		h := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+		h.Write(b)
+		return base64.StdEncoding.EncodeToString(h.Sum(nil))
+	}
+
+	testcases := []struct {
+		name string
+		size int
+	}{
+		{name: "single part", size: 1024},
+		{name: "multipart", size: 2*partSize + 1},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			content := make([]byte, tc.size)
+			rand.Read(content)
+
+			mockAPI := s3.New(unit.Session)
+
+			mockAPI.Handlers.Unmarshal.Clear()
+			mockAPI.Handlers.UnmarshalMeta.Clear()
+			mockAPI.Handlers.UnmarshalError.Clear()
+			mockAPI.Handlers.Send.Clear()
+
+			var (
+				mu        sync.Mutex
+				checksums = map[string]string{}
+				completed []*s3.CompletedPart
+			)
+			mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("")),
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				header := r.HTTPRequest.Header
+				switch input := r.Params.(type) {
+				case *s3.PutObjectInput:
+					assert.Equal(t, header.Get("X-Amz-Sdk-Checksum-Algorithm"), "CRC32C")
+					checksums["object"] = header.Get("X-Amz-Checksum-Crc32c")
+				case *s3.CreateMultipartUploadInput:
+					assert.Equal(t, header.Get("X-Amz-Checksum-Algorithm"), "CRC32C")
+					r.Data.(*s3.CreateMultipartUploadOutput).UploadId = aws.String("upload-id")
+				case *s3.UploadPartInput:
+					assert.Equal(t, header.Get("X-Amz-Sdk-Checksum-Algorithm"), "CRC32C")
+					checksums[fmt.Sprint(aws.Int64Value(input.PartNumber))] = header.Get("X-Amz-Checksum-Crc32c")
+					r.Data.(*s3.UploadPartOutput).ETag = aws.String("etag")
+				case *s3.CompleteMultipartUploadInput:
+					completed = input.MultipartUpload.Parts
+					// the SDK expects a response body for 200 OK responses.
+					r.HTTPResponse.Body = io.NopCloser(strings.NewReader("<CompleteMultipartUploadResult/>"))
+				}
+			})
+
+			mockS3 := &S3{
+				uploader:       s3manager.NewUploaderWithClient(mockAPI),
+				partRetryCount: -1,
+			}
+
+			// the data is streamed, its size is not known beforehand.
+			reader := struct{ io.Reader }{bytes.NewReader(content)}
+			metadata := Metadata{ChecksumAlgorithm: "CRC32C"}
+
+			err := mockS3.Put(context.Background(), reader, u, metadata, 2, partSize)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.size < partSize {
+				assert.DeepEqual(t, checksums, map[string]string{"object": crc32c(content)})
+				return
+			}
+
+			expected := map[string]string{
+				"1": crc32c(content[:partSize]),
+				"2": crc32c(content[partSize : 2*partSize]),
+				"3": crc32c(content[2*partSize:]),
+			}
+			assert.DeepEqual(t, checksums, expected)
+
+			assert.Equal(t, len(completed), 3)
+			for _, part := range completed {
+				partNumber := fmt.Sprint(aws.Int64Value(part.PartNumber))
+				assert.Equal(t, aws.StringValue(part.ChecksumCRC32C), expected[partNumber])
+			}
+		})
+	}
+}
+
+func TestS3PutPartRetry(t *testing.T) {
+	log.Init("debug", false)
+
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testcases := []struct {
+		name           string
+		partRetryCount int
+		expectErr      bool
+	}{
+		{
+			name:           "part is retried",
+			partRetryCount: 2,
+		},
+		{
+			name:           "part is not retried",
+			partRetryCount: 0,
+			expectErr:      true,
+		},
+		{
+			name:           "part retry defaults to max retries",
+			partRetryCount: -1,
+			expectErr:      true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			retryOn, _ := ParseRetryOn(DefaultRetryOn)
+			// the requests other than the parts are never retried.
+			sess := unit.Session.Copy(&aws.Config{Retryer: newCustomRetryer(0, retryOn)})
+
+			mockAPI := s3.New(sess)
+
+			mockAPI.Handlers.Unmarshal.Clear()
+			mockAPI.Handlers.UnmarshalMeta.Clear()
+			mockAPI.Handlers.UnmarshalError.Clear()
+			mockAPI.Handlers.Send.Clear()
+
+			var (
+				mu       sync.Mutex
+				attempts = map[int64]int{}
+			)
+			mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("")),
+				}
+
+				switch output := r.Data.(type) {
+				case *s3.CreateMultipartUploadOutput:
+					output.UploadId = aws.String("upload-id")
+				case *s3.UploadPartOutput:
+					partNumber := aws.Int64Value(r.Params.(*s3.UploadPartInput).PartNumber)
+
+					mu.Lock()
+					attempts[partNumber]++
+					attempt := attempts[partNumber]
+					mu.Unlock()
+
+					// the first attempt of the second part fails transiently.
+					if partNumber == 2 && attempt == 1 {
+						r.HTTPResponse.StatusCode = http.StatusInternalServerError
+						r.Error = awserr.NewRequestFailure(
+							awserr.New("InternalError", "internal error", nil),
+							http.StatusInternalServerError,
+							"id",
+						)
+						return
+					}
+					output.ETag = aws.String("etag")
+				case *s3.CompleteMultipartUploadOutput:
+					// the SDK expects a response body for 200 OK responses.
+					r.HTTPResponse.Body = io.NopCloser(strings.NewReader("<CompleteMultipartUploadResult/>"))
+				}
+			})
+
+			mockS3 := &S3{
+				uploader:       s3manager.NewUploaderWithClient(mockAPI),
+				partRetryCount: tc.partRetryCount,
+			}
+
+			reader := bytes.NewReader(make([]byte, 6*1024*1024))
+			err := mockS3.Put(context.Background(), reader, u, Metadata{}, 1, 5*1024*1024)
+			if tc.expectErr {
+				assert.ErrorContains(t, err, "InternalError")
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assert.Equal(t, attempts[2], 2)
+		})
+	}
+}
+
+func TestS3GetConditional(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	modTime := time.Date(2024, 10, 1, 20, 30, 0, 0, time.UTC)
+
+	testcases := []struct {
+		name       string
+		conditions DownloadConditions
+		modified   bool
+
+		expectedNotModified bool
+	}{
+		{
+			name:     "no conditions",
+			modified: false,
+		},
+		{
+			name:       "modified",
+			conditions: DownloadConditions{IfModifiedSince: &modTime},
+			modified:   true,
+		},
+		{
+			name:                "not modified since",
+			conditions:          DownloadConditions{IfModifiedSince: &modTime},
+			expectedNotModified: true,
+		},
+		{
+			name:                "etag matches",
+			conditions:          DownloadConditions{IfNoneMatch: "etag"},
+			expectedNotModified: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := s3.New(unit.Session)
+
+			mockAPI.Handlers.Unmarshal.Clear()
+			mockAPI.Handlers.UnmarshalMeta.Clear()
+			mockAPI.Handlers.Send.Clear()
+
+			mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+				header := r.HTTPRequest.Header
+				if tc.conditions.IfModifiedSince != nil {
+					assert.Equal(t, header.Get("If-Modified-Since"), "Tue, 01 Oct 2024 20:30:00 GMT")
+				}
+				if tc.conditions.IfNoneMatch != "" {
+					assert.Equal(t, header.Get("If-None-Match"), `"etag"`)
+				}
+
+				conditional := header.Get("If-Modified-Since") != "" || header.Get("If-None-Match") != ""
+				if conditional && !tc.modified {
+					r.HTTPResponse = &http.Response{
+						StatusCode: http.StatusNotModified,
+						Header:     http.Header{},
+						Body:       io.NopCloser(strings.NewReader("")),
+					}
+					return
+				}
+
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{},
+					Body:       io.NopCloser(strings.NewReader("content")),
+				}
+				output := r.Data.(*s3.GetObjectOutput)
+				output.Body = r.HTTPResponse.Body
+				output.ContentLength = aws.Int64(7)
+				output.ContentRange = aws.String("bytes 0-6/7")
+			})
+
+			mockS3 := &S3{
+				api:        mockAPI,
+				downloader: s3manager.NewDownloaderWithClient(mockAPI),
+			}
+
+			buf := aws.NewWriteAtBuffer(nil)
+			_, err := mockS3.Get(context.Background(), u, buf, 1, 5*1024*1024, tc.conditions)
+
+			if tc.expectedNotModified {
+				if !IsNotModifiedError(err) {
+					t.Fatalf("expected not modified error, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assert.Equal(t, string(buf.Bytes()), "content")
+		})
+	}
+}
+
+func TestS3GetWithChecksum(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// CRC32C checksum and MD5 digest of "123456789".
+	const (
+		crc32c = "4waSgw=="
+		md5sum = "JfnnlDI7RTiF9RgfG2JNCw=="
+		etag   = `"25f9e794323b453885f5181f1b624d0b"`
+	)
+
+	testcases := []struct {
+		name             string
+		etag             bool
+		getHeader        http.Header
+		headChecksum     *string
+		headETag         *string
+		expectedChecksum *Checksum
+		expectedHeads    int
+	}{
+		{
+			name:             "checksum in get response",
+			getHeader:        http.Header{"X-Amz-Checksum-Crc32c": []string{crc32c}},
+			expectedChecksum: &Checksum{Algorithm: "CRC32C", Value: crc32c},
+		},
+		{
+			name:             "checksum in head response",
+			getHeader:        http.Header{},
+			headChecksum:     aws.String(crc32c),
+			expectedChecksum: &Checksum{Algorithm: "CRC32C", Value: crc32c},
+			expectedHeads:    1,
+		},
+		{
+			name:          "composite checksum",
+			getHeader:     http.Header{"X-Amz-Checksum-Crc32c": []string{crc32c + "-2"}},
+			headChecksum:  aws.String(crc32c + "-2"),
+			expectedHeads: 1,
+		},
+		{
+			name:          "no checksum",
+			getHeader:     http.Header{},
+			expectedHeads: 1,
+		},
+		{
+			name:          "etag not requested",
+			getHeader:     http.Header{"Etag": []string{etag}},
+			headETag:      aws.String(etag),
+			expectedHeads: 1,
+		},
+		{
+			name:             "etag in get response",
+			etag:             true,
+			getHeader:        http.Header{"Etag": []string{etag}},
+			expectedChecksum: &Checksum{Algorithm: "MD5", Value: md5sum},
+		},
+		{
+			name: "checksum preferred to etag",
+			etag: true,
+			getHeader: http.Header{
+				"Etag":                  []string{etag},
+				"X-Amz-Checksum-Crc32c": []string{crc32c},
+			},
+			expectedChecksum: &Checksum{Algorithm: "CRC32C", Value: crc32c},
+		},
+		{
+			name:             "etag in head response",
+			etag:             true,
+			getHeader:        http.Header{},
+			headETag:         aws.String(etag),
+			expectedChecksum: &Checksum{Algorithm: "MD5", Value: md5sum},
+			expectedHeads:    1,
+		},
+		{
+			name:          "multipart etag",
+			etag:          true,
+			getHeader:     http.Header{"Etag": []string{`"25f9e794323b453885f5181f1b624d0b-2"`}},
+			headETag:      aws.String(`"25f9e794323b453885f5181f1b624d0b-2"`),
+			expectedHeads: 1,
+		},
+		{
+			name: "kms encrypted etag",
+			etag: true,
+			getHeader: http.Header{
+				"Etag":                         []string{etag},
+				"X-Amz-Server-Side-Encryption": []string{"aws:kms"},
+			},
+			expectedHeads: 1,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := s3.New(unit.Session)
+
+			mockAPI.Handlers.Unmarshal.Clear()
+			mockAPI.Handlers.UnmarshalMeta.Clear()
+			mockAPI.Handlers.Send.Clear()
+
+			var heads int
+			mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+				assert.Equal(t, r.HTTPRequest.Header.Get("X-Amz-Checksum-Mode"), "ENABLED")
+
+				switch output := r.Data.(type) {
+				case *s3.GetObjectOutput:
+					r.HTTPResponse = &http.Response{
+						StatusCode: http.StatusOK,
+						Header:     tc.getHeader,
+						Body:       io.NopCloser(strings.NewReader("123456789")),
+					}
+					output.Body = r.HTTPResponse.Body
+					output.ContentLength = aws.Int64(9)
+					output.ContentRange = aws.String("bytes 0-8/9")
+				case *s3.HeadObjectOutput:
+					heads++
+					r.HTTPResponse = &http.Response{
+						StatusCode: http.StatusOK,
+						Header:     http.Header{},
+						Body:       io.NopCloser(strings.NewReader("")),
+					}
+					output.ChecksumCRC32C = tc.headChecksum
+					output.ETag = tc.headETag
+				}
+			})
+
+			mockS3 := &S3{
+				api:        mockAPI,
+				downloader: s3manager.NewDownloaderWithClient(mockAPI),
+			}
+
+			buf := aws.NewWriteAtBuffer(nil)
+			_, checksum, err := mockS3.GetWithChecksum(context.Background(), u, buf, 1, 5*1024*1024, DownloadConditions{}, tc.etag)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			assert.DeepEqual(t, checksum, tc.expectedChecksum)
+			assert.Equal(t, heads, tc.expectedHeads)
+		})
+	}
+}
+
+func TestS3GetFrom(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockAPI := s3.New(unit.Session)
+
+	mockAPI.Handlers.Unmarshal.Clear()
+	mockAPI.Handlers.UnmarshalMeta.Clear()
+	mockAPI.Handlers.Send.Clear()
+
+	mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+		assert.Equal(t, r.HTTPRequest.Header.Get("Range"), "bytes=5-")
+		assert.Equal(t, r.HTTPRequest.Header.Get("If-Match"), `"etag"`)
+
+		output := r.Data.(*s3.GetObjectOutput)
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusPartialContent,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader("6789")),
+		}
+		output.Body = r.HTTPResponse.Body
+		output.ContentLength = aws.Int64(4)
+		output.ContentRange = aws.String("bytes 5-8/9")
+	})
+
+	mockS3 := &S3{
+		api:        mockAPI,
+		downloader: s3manager.NewDownloaderWithClient(mockAPI),
+	}
+
+	buf := aws.NewWriteAtBuffer([]byte("12345"))
+	n, err := mockS3.GetFrom(context.Background(), u, buf, 5, 1, 5*1024*1024, DownloadConditions{IfMatch: "etag"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assert.Equal(t, n, int64(4))
+	assert.Equal(t, string(buf.Bytes()), "123456789")
+}
+
+func TestS3listObjectsV2(t *testing.T) {
+	const (
+		numObjectsToReturn = 10100
+		numObjectsToIgnore = 1127
+
+		pre = "s3://bucket/key"
+	)
+
+	u, err := url.New(pre)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	mapReturnObjNameToModtime := map[string]time.Time{}
+	mapIgnoreObjNameToModtime := map[string]time.Time{}
+
+	s3objs := make([]*s3.Object, 0, numObjectsToIgnore+numObjectsToReturn)
+
+	for i := 0; i < numObjectsToReturn; i++ {
+		fname := fmt.Sprintf("%s/%d", pre, i)
+		now := time.Now()
+
+		mapReturnObjNameToModtime[pre+"/"+fname] = now
+		s3objs = append(s3objs, &s3.Object{
+			Key:          aws.String("key/" + fname),
+			LastModified: aws.Time(now),
+		})
+	}
+
+	for i := 0; i < numObjectsToIgnore; i++ {
+		fname := fmt.Sprintf("%s/%d", pre, numObjectsToReturn+i)
+		later := time.Now().Add(time.Second * 10)
+
+		mapIgnoreObjNameToModtime[pre+"/"+fname] = later
+		s3objs = append(s3objs, &s3.Object{
+			Key:          aws.String("key/" + fname),
+			LastModified: aws.Time(later),
+		})
+	}
+
+	// shuffle the objects array to remove possible assumptions about how objects
+	// are stored.
+	rand.Shuffle(len(s3objs), func(i, j int) {
+		s3objs[i], s3objs[j] = s3objs[j], s3objs[i]
+	})
+
+	mockAPI := s3.New(unit.Session)
+
+	mockAPI.Handlers.Unmarshal.Clear()
+	mockAPI.Handlers.UnmarshalMeta.Clear()
+	mockAPI.Handlers.UnmarshalError.Clear()
+	mockAPI.Handlers.Send.Clear()
+
+	mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}
+
+		r.Data = &s3.ListObjectsV2Output{
+			Contents: s3objs,
+		}
+	})
+
+	mockS3 := &S3{
+		api: mockAPI,
+	}
+
+	ouputCh := mockS3.listObjectsV2(context.Background(), u)
+
+	for obj := range ouputCh {
+		if _, ok := mapReturnObjNameToModtime[obj.String()]; ok {
+			delete(mapReturnObjNameToModtime, obj.String())
+			continue
+		}
+		t.Errorf("%v should not have been returned\n", obj)
+	}
+	assert.Equal(t, len(mapReturnObjNameToModtime), 0)
+}
+
+func TestS3ListFetchOwner(t *testing.T) {
+	testcases := []struct {
+		name          string
+		fetchOwner    bool
+		owner         *s3.Owner
+		expectedOwner *Owner
+	}{
+		{
+			name: "owner is not fetched",
+		},
+		{
+			name:          "owner is fetched",
+			fetchOwner:    true,
+			owner:         &s3.Owner{ID: aws.String("id"), DisplayName: aws.String("name")},
+			expectedOwner: &Owner{ID: "id", DisplayName: "name"},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.New("s3://bucket/key")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			mockAPI := s3.New(unit.Session)
+
+			mockAPI.Handlers.Unmarshal.Clear()
+			mockAPI.Handlers.UnmarshalMeta.Clear()
+			mockAPI.Handlers.UnmarshalError.Clear()
+			mockAPI.Handlers.Send.Clear()
+
+			mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+				input := r.Params.(*s3.ListObjectsV2Input)
+				assert.Equal(t, aws.BoolValue(input.FetchOwner), tc.fetchOwner)
+
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("")),
+				}
+
+				r.Data = &s3.ListObjectsV2Output{
+					Contents: []*s3.Object{
+						{
+							Key:          aws.String("key"),
+							LastModified: aws.Time(time.Now()),
+							Owner:        tc.owner,
+						},
+					},
+				}
+			})
+
+			mockS3 := &S3{
+				api:        mockAPI,
+				fetchOwner: tc.fetchOwner,
+			}
+
+			for obj := range mockS3.listObjectsV2(context.Background(), u) {
+				if obj.Err != nil {
+					t.Fatalf("unexpected error: %v", obj.Err)
+				}
+				assert.DeepEqual(t, obj.Owner, tc.expectedOwner)
+			}
+		})
+	}
+}
+
+func TestS3ListChecksumAlgorithm(t *testing.T) {
+	testcases := []struct {
+		name             string
+		useListObjectsV1 bool
+		allVersions      bool
+	}{
+		{name: "ListObjectsV2"},
+		{name: "ListObjects", useListObjectsV1: true},
+		{name: "ListObjectVersions", allVersions: true},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.New("s3://bucket/*", url.WithAllVersions(tc.allVersions))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			mockAPI := s3.New(unit.Session)
+
+			mockAPI.Handlers.Unmarshal.Clear()
+			mockAPI.Handlers.UnmarshalMeta.Clear()
+			mockAPI.Handlers.UnmarshalError.Clear()
+			mockAPI.Handlers.Send.Clear()
+
+			mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("")),
+				}
+
+				now := aws.Time(time.Now().Add(-time.Minute))
+				checksummed := []*string{aws.String(s3.ChecksumAlgorithmCrc32c)}
+				switch r.Operation.Name {
+				case "ListObjectsV2":
+					r.Data = &s3.ListObjectsV2Output{
+						Contents: []*s3.Object{
+							{Key: aws.String("crc32c"), LastModified: now, ChecksumAlgorithm: checksummed},
+							{Key: aws.String("none"), LastModified: now},
+						},
+					}
+				case "ListObjects":
+					r.Data = &s3.ListObjectsOutput{
+						Contents: []*s3.Object{
+							{Key: aws.String("crc32c"), LastModified: now, ChecksumAlgorithm: checksummed},
+							{Key: aws.String("none"), LastModified: now},
+						},
+					}
+				case "ListObjectVersions":
+					r.Data = &s3.ListObjectVersionsOutput{
+						Versions: []*s3.ObjectVersion{
+							{Key: aws.String("crc32c"), LastModified: now, ChecksumAlgorithm: checksummed},
+							{Key: aws.String("none"), LastModified: now},
+						},
+					}
+				default:
+					t.Errorf("unexpected operation %v", r.Operation.Name)
+				}
+			})
+
+			mockS3 := &S3{
+				api:              mockAPI,
+				useListObjectsV1: tc.useListObjectsV1,
+			}
+
+			algorithms := map[string]string{}
+			for obj := range mockS3.List(context.Background(), u, false) {
+				if obj.Err != nil {
+					t.Fatalf("unexpected error: %v", obj.Err)
+				}
+				algorithms[obj.URL.Path] = obj.ChecksumAlgorithm
+			}
+
+			assert.DeepEqual(t, algorithms, map[string]string{"crc32c": "CRC32C", "none": ""})
+		})
+	}
+}
+
+func TestS3ListDirMarkers(t *testing.T) {
+	u, err := url.New("s3://bucket/*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockAPI := s3.New(unit.Session)
+
+	mockAPI.Handlers.Unmarshal.Clear()
+	mockAPI.Handlers.UnmarshalMeta.Clear()
+	mockAPI.Handlers.UnmarshalError.Clear()
+	mockAPI.Handlers.Send.Clear()
+
+	mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}
+
+		now := aws.Time(time.Now().Add(-time.Minute))
+		r.Data = &s3.ListObjectsV2Output{
+			Contents: []*s3.Object{
+				{Key: aws.String("dir/"), Size: aws.Int64(0), LastModified: now},
+				{Key: aws.String("dir/file.txt"), Size: aws.Int64(7), LastModified: now},
+				{Key: aws.String("dir/empty.txt"), Size: aws.Int64(0), LastModified: now},
+				{Key: aws.String("data/"), Size: aws.Int64(3), LastModified: now},
+			},
+		}
+	})
+
+	mockS3 := &S3{api: mockAPI}
+
+	// only the zero-byte keys ending with a slash are directory markers.
+	markers := map[string]bool{}
+	for obj := range mockS3.List(context.Background(), u, false) {
+		if obj.Err != nil {
+			t.Fatalf("unexpected error: %v", obj.Err)
+		}
+		markers[obj.URL.Path] = obj.IsDirMarker()
+	}
+
+	assert.DeepEqual(t, markers, map[string]bool{
+		"dir/":          true,
+		"dir/file.txt":  false,
+		"dir/empty.txt": false,
+		"data/":         false,
+	})
+}
+
+func TestS3MultiDeleteDeleteMarkers(t *testing.T) {
+	mockAPI := s3.New(unit.Session)
+
+	mockAPI.Handlers.Unmarshal.Clear()
+	mockAPI.Handlers.UnmarshalMeta.Clear()
+	mockAPI.Handlers.UnmarshalError.Clear()
+	mockAPI.Handlers.Send.Clear()
+
+	mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}
+
+		// the output is returned by the pointer of the request, it's not
+		// replaced.
+		*r.Data.(*s3.DeleteObjectsOutput) = s3.DeleteObjectsOutput{
+			Deleted: []*s3.DeletedObject{
+				// a delete marker is created.
+				{Key: aws.String("created"), DeleteMarker: aws.Bool(true), DeleteMarkerVersionId: aws.String("marker")},
+				// an existing delete marker is removed.
+				{Key: aws.String("removed"), VersionId: aws.String("old"), DeleteMarker: aws.Bool(true), DeleteMarkerVersionId: aws.String("old")},
+				// the bucket is not versioned.
+				{Key: aws.String("unversioned")},
+			},
+		}
+	})
+
+	mockS3 := &S3{api: mockAPI}
+
+	urlch := make(chan *url.URL, 3)
+	for _, key := range []string{"created", "removed", "unversioned"} {
+		u, err := url.New("s3://bucket/" + key)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		urlch <- u
+	}
+	close(urlch)
+
+	markers := map[string]string{}
+	for obj := range mockS3.MultiDelete(context.Background(), urlch) {
+		if obj.Err != nil {
+			t.Fatalf("unexpected error: %v", obj.Err)
+		}
+		markers[obj.URL.Path] = obj.DeleteMarkerVersionID
+	}
+
+	assert.DeepEqual(t, markers, map[string]string{"created": "marker", "removed": "", "unversioned": ""})
+}
+
+func TestS3LatestDeleteMarker(t *testing.T) {
 	testcases := []struct {
 		name     string
-		sse      string
-		sseKeyID string
-		acl      string
+		key      string
+		expected string
+	}{
+		{name: "latest version is a delete marker", key: "deleted", expected: "latest"},
+		{name: "latest version is an object", key: "restored"},
+		{name: "no versions", key: "missing"},
+	}
 
-		expectedSSE      string
-		expectedSSEKeyID string
-		expectedACL      string
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := s3.New(unit.Session)
+
+			mockAPI.Handlers.Unmarshal.Clear()
+			mockAPI.Handlers.UnmarshalMeta.Clear()
+			mockAPI.Handlers.UnmarshalError.Clear()
+			mockAPI.Handlers.Send.Clear()
+
+			mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("")),
+				}
+
+				r.Data = &s3.ListObjectVersionsOutput{
+					DeleteMarkers: []*s3.DeleteMarkerEntry{
+						{Key: aws.String("deleted"), VersionId: aws.String("latest"), IsLatest: aws.Bool(true)},
+						{Key: aws.String("deleted"), VersionId: aws.String("older"), IsLatest: aws.Bool(false)},
+						{Key: aws.String("deleted/nested"), VersionId: aws.String("nested"), IsLatest: aws.Bool(true)},
+						{Key: aws.String("restored"), VersionId: aws.String("marker"), IsLatest: aws.Bool(false)},
+					},
+					Versions: []*s3.ObjectVersion{
+						{Key: aws.String("deleted"), VersionId: aws.String("object"), IsLatest: aws.Bool(false)},
+						{Key: aws.String("restored"), VersionId: aws.String("object"), IsLatest: aws.Bool(true)},
+					},
+				}
+			})
+
+			mockS3 := &S3{api: mockAPI}
+
+			u, err := url.New("s3://bucket/" + tc.key)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := mockS3.LatestDeleteMarker(context.Background(), u)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assert.Equal(t, got, tc.expected)
+		})
+	}
+}
+
+func TestNormalizeEndpoint(t *testing.T) {
+	testcases := []struct {
+		name     string
+		endpoint string
+		scheme   string
+		expected string
+		err      string
 	}{
+		{name: "empty endpoint"},
 		{
-			name: "no encryption/no acl, by default",
+			name:     "http endpoint",
+			endpoint: "http://minio.example.com:9000",
+			expected: "http://minio.example.com:9000",
 		},
 		{
-			name: "aws:kms encryption with server side generated keys",
-			sse:  "aws:kms",
-
-			expectedSSE: "aws:kms",
+			name:     "https endpoint",
+			endpoint: "https://storage.googleapis.com",
+			expected: "https://storage.googleapis.com",
 		},
 		{
-			name:     "aws:kms encryption with user provided key",
-			sse:      "aws:kms",
-			sseKeyID: "sdkjn12SDdci#@#EFRFERTqW/ke",
-
-			expectedSSE:      "aws:kms",
-			expectedSSEKeyID: "sdkjn12SDdci#@#EFRFERTqW/ke",
+			name:     "schemeless endpoint",
+			endpoint: "storage.googleapis.com",
+			expected: "https://storage.googleapis.com",
 		},
 		{
-			name:     "provide key without encryption flag, shall be ignored",
-			sseKeyID: "1234567890",
+			name:     "schemeless localhost",
+			endpoint: "localhost:9000",
+			expected: "http://localhost:9000",
 		},
 		{
-			name:        "acl flag with a value",
-			acl:         "bucket-owner-full-control",
-			expectedACL: "bucket-owner-full-control",
+			name:     "schemeless loopback address",
+			endpoint: "127.0.0.1:9000",
+			expected: "http://127.0.0.1:9000",
+		},
+		{
+			name:     "schemeless ipv6 loopback address",
+			endpoint: "[::1]:9000",
+			expected: "http://[::1]:9000",
+		},
+		{
+			name:     "schemeless endpoint with path",
+			endpoint: "minio.example.com:9000/s3",
+			expected: "https://minio.example.com:9000/s3",
+		},
+		{
+			name:     "endpoint with path",
+			endpoint: "https://gateway.example.com/s3",
+			expected: "https://gateway.example.com/s3",
+		},
+		{
+			name:     "endpoint with trailing slashes",
+			endpoint: "https://gateway.example.com/s3//",
+			expected: "https://gateway.example.com/s3",
+		},
+		{
+			name:     "schemeless endpoint with trailing slash",
+			endpoint: "localhost:9000/",
+			expected: "http://localhost:9000",
+		},
+		{
+			name:     "endpoint with query",
+			endpoint: "https://gateway.example.com/s3?region=eu",
+			err:      "endpoint can't have a query or a fragment",
+		},
+		{
+			name:     "endpoint with fragment",
+			endpoint: "https://gateway.example.com/s3#bucket",
+			err:      "endpoint can't have a query or a fragment",
+		},
+		{
+			name:     "schemeless endpoint with scheme",
+			endpoint: "minio.example.com:9000",
+			scheme:   "http",
+			expected: "http://minio.example.com:9000",
+		},
+		{
+			name:     "schemeless localhost with scheme",
+			endpoint: "localhost:9000",
+			scheme:   "https",
+			expected: "https://localhost:9000",
+		},
+		{
+			name:     "endpoint with the same scheme",
+			endpoint: "https://minio.example.com",
+			scheme:   "https",
+			expected: "https://minio.example.com",
+		},
+		{
+			name:     "endpoint with a conflicting scheme",
+			endpoint: "http://minio.example.com",
+			scheme:   "https",
+			err:      `scheme "http" conflicts with the endpoint scheme "https"`,
+		},
+		{
+			name:     "unsupported scheme",
+			endpoint: "ftp://minio.example.com",
+			err:      `unsupported scheme "ftp"`,
+		},
+		{
+			name:     "unsupported endpoint scheme",
+			endpoint: "minio.example.com",
+			scheme:   "ftp",
+			err:      `unsupported endpoint scheme "ftp"`,
+		},
+		{
+			name:     "missing hostname",
+			endpoint: "http://",
+			err:      "hostname is missing",
+		},
+		{
+			name:     "invalid port",
+			endpoint: "localhost:port",
+			err:      "invalid endpoint",
 		},
 	}
 
-	u, err := url.New("s3://bucket/key")
-	if err != nil {
-		t.Errorf("unexpected error: %v", err)
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NormalizeEndpoint(tc.endpoint, tc.scheme)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, got, tc.expected)
+		})
+	}
+}
+
+func TestS3ListPageSize(t *testing.T) {
+	testcases := []struct {
+		name             string
+		pageSize         int64
+		useListObjectsV1 bool
+		expectedMaxKeys  *int64
+	}{
+		{
+			name: "default page size",
+		},
+		{
+			name:            "page size",
+			pageSize:        100,
+			expectedMaxKeys: aws.Int64(100),
+		},
+		{
+			name:             "page size with list objects v1",
+			pageSize:         100,
+			useListObjectsV1: true,
+			expectedMaxKeys:  aws.Int64(100),
+		},
 	}
+
 	for _, tc := range testcases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.New("s3://bucket/key")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
 			mockAPI := s3.New(unit.Session)
 
 			mockAPI.Handlers.Unmarshal.Clear()
@@ -704,100 +2990,68 @@ func TestS3CopyEncryptionRequest(t *testing.T) {
 			mockAPI.Handlers.Send.Clear()
 
 			mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
-				r.HTTPResponse = &http.Response{
-					StatusCode: http.StatusOK,
-					Body:       io.NopCloser(strings.NewReader("")),
+				contents := []*s3.Object{
+					{Key: aws.String("key"), LastModified: aws.Time(time.Now())},
 				}
 
-				params := r.Params
-				sse := valueAtPath(params, "ServerSideEncryption")
-				key := valueAtPath(params, "SSEKMSKeyId")
-
-				if !(sse == nil && tc.expectedSSE == "") {
-					assert.Equal(t, sse, tc.expectedSSE)
-				}
-				if !(key == nil && tc.expectedSSEKeyID == "") {
-					assert.Equal(t, key, tc.expectedSSEKeyID)
+				switch input := r.Params.(type) {
+				case *s3.ListObjectsV2Input:
+					assert.DeepEqual(t, input.MaxKeys, tc.expectedMaxKeys)
+					r.Data = &s3.ListObjectsV2Output{Contents: contents}
+				case *s3.ListObjectsInput:
+					assert.DeepEqual(t, input.MaxKeys, tc.expectedMaxKeys)
+					r.Data = &s3.ListObjectsOutput{Contents: contents}
 				}
 
-				aclVal := valueAtPath(r.Params, "ACL")
-
-				if aclVal == nil && tc.expectedACL == "" {
-					return
-				}
-				assert.Equal(t, aclVal, tc.expectedACL)
-			})
-			mockAPI.Handlers.Unmarshal.PushBack(func(r *request.Request) {
-				if r.Error != nil {
-					if awsErr, ok := r.Error.(awserr.Error); ok {
-						if awsErr.Code() == request.ErrCodeSerialization {
-							r.Error = nil
-						}
-					}
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("")),
 				}
 			})
 
 			mockS3 := &S3{
-				api: mockAPI,
+				api:              mockAPI,
+				pageSize:         tc.pageSize,
+				useListObjectsV1: tc.useListObjectsV1,
 			}
 
-			metadata := Metadata{}
-			metadata.EncryptionMethod = tc.sse
-			metadata.EncryptionKeyID = tc.sseKeyID
-			metadata.ACL = tc.acl
-
-			err = mockS3.Copy(context.Background(), u, u, metadata)
-			if err != nil {
-				t.Errorf("Expected %v, but received %q", nil, err)
+			for obj := range mockS3.List(context.Background(), u, false) {
+				if obj.Err != nil {
+					t.Fatalf("unexpected error: %v", obj.Err)
+				}
 			}
 		})
 	}
 }
 
-func TestS3PutEncryptionRequest(t *testing.T) {
+func TestS3ListPages(t *testing.T) {
 	testcases := []struct {
-		name     string
-		sse      string
-		sseKeyID string
-		acl      string
-
-		expectedSSE      string
-		expectedSSEKeyID string
-		expectedACL      string
+		name              string
+		pages             int
+		expectedKeys      []string
+		expectedTruncated bool
 	}{
 		{
-			name: "no encryption, no acl flag",
-		},
-		{
-			name:        "aws:kms encryption with server side generated keys",
-			sse:         "aws:kms",
-			expectedSSE: "aws:kms",
-		},
-		{
-			name:     "aws:kms encryption with user provided key",
-			sse:      "aws:kms",
-			sseKeyID: "sdkjn12SDdci#@#EFRFERTqW/ke",
-
-			expectedSSE:      "aws:kms",
-			expectedSSEKeyID: "sdkjn12SDdci#@#EFRFERTqW/ke",
-		},
-		{
-			name:     "provide key without encryption flag, shall be ignored",
-			sseKeyID: "1234567890",
+			name:              "stops after the given pages",
+			pages:             2,
+			expectedKeys:      []string{"key/0", "key/1", "key/2", "key/3"},
+			expectedTruncated: true,
 		},
 		{
-			name:        "acl flag with a value",
-			acl:         "bucket-owner-full-control",
-			expectedACL: "bucket-owner-full-control",
+			name:         "lists all pages",
+			pages:        5,
+			expectedKeys: []string{"key/0", "key/1", "key/2", "key/3", "key/4", "key/5"},
 		},
 	}
-	u, err := url.New("s3://bucket/key")
-	if err != nil {
-		t.Errorf("unexpected error: %v", err)
-	}
+
 	for _, tc := range testcases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.New("s3://bucket/key/*")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
 			mockAPI := s3.New(unit.Session)
 
 			mockAPI.Handlers.Unmarshal.Clear()
@@ -805,94 +3059,71 @@ func TestS3PutEncryptionRequest(t *testing.T) {
 			mockAPI.Handlers.UnmarshalError.Clear()
 			mockAPI.Handlers.Send.Clear()
 
+			// each page has 2 keys, and there are 3 pages.
 			mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+				input := r.Params.(*s3.ListObjectsV2Input)
+
+				page := 0
+				if token := aws.StringValue(input.ContinuationToken); token != "" {
+					page, _ = strconv.Atoi(token)
+				}
+
 				r.HTTPResponse = &http.Response{
 					StatusCode: http.StatusOK,
 					Body:       io.NopCloser(strings.NewReader("")),
 				}
 
-				params := r.Params
-				sse := valueAtPath(params, "ServerSideEncryption")
-				key := valueAtPath(params, "SSEKMSKeyId")
-
-				if !(sse == nil && tc.expectedSSE == "") {
-					assert.Equal(t, sse, tc.expectedSSE)
+				output := &s3.ListObjectsV2Output{
+					Contents: []*s3.Object{
+						{Key: aws.String(fmt.Sprintf("key/%d", 2*page)), LastModified: aws.Time(time.Now())},
+						{Key: aws.String(fmt.Sprintf("key/%d", 2*page+1)), LastModified: aws.Time(time.Now())},
+					},
+					IsTruncated: aws.Bool(page < 2),
 				}
-				if !(key == nil && tc.expectedSSEKeyID == "") {
-					assert.Equal(t, key, tc.expectedSSEKeyID)
+				if page < 2 {
+					output.NextContinuationToken = aws.String(strconv.Itoa(page + 1))
 				}
+				r.Data = output
+			})
 
-				aclVal := valueAtPath(r.Params, "ACL")
+			mockS3 := &S3{api: mockAPI}
 
-				if aclVal == nil && tc.expectedACL == "" {
-					return
+			var (
+				keys      []string
+				truncated bool
+			)
+			for obj := range mockS3.ListPages(context.Background(), u, tc.pages) {
+				if obj.Err == ErrListTruncated {
+					truncated = true
+					continue
 				}
-				assert.Equal(t, aclVal, tc.expectedACL)
-			})
-
-			mockS3 := &S3{
-				uploader: s3manager.NewUploaderWithClient(mockAPI),
+				if obj.Err != nil {
+					t.Fatalf("unexpected error: %v", obj.Err)
+				}
+				keys = append(keys, obj.URL.Path)
 			}
 
-			metadata := Metadata{}
-			metadata.EncryptionMethod = tc.sse
-			metadata.EncryptionKeyID = tc.sseKeyID
-			metadata.ACL = tc.acl
-
-			err = mockS3.Put(context.Background(), bytes.NewReader([]byte("")), u, metadata, 1, 5242880)
-			if err != nil {
-				t.Errorf("Expected %v, but received %q", nil, err)
-			}
+			assert.DeepEqual(t, keys, tc.expectedKeys)
+			assert.Equal(t, truncated, tc.expectedTruncated)
 		})
 	}
 }
 
-func TestS3listObjectsV2(t *testing.T) {
-	const (
-		numObjectsToReturn = 10100
-		numObjectsToIgnore = 1127
-
-		pre = "s3://bucket/key"
-	)
-
-	u, err := url.New(pre)
-	if err != nil {
-		t.Errorf("unexpected error: %v", err)
-	}
-
-	mapReturnObjNameToModtime := map[string]time.Time{}
-	mapIgnoreObjNameToModtime := map[string]time.Time{}
-
-	s3objs := make([]*s3.Object, 0, numObjectsToIgnore+numObjectsToReturn)
-
-	for i := 0; i < numObjectsToReturn; i++ {
-		fname := fmt.Sprintf("%s/%d", pre, i)
-		now := time.Now()
-
-		mapReturnObjNameToModtime[pre+"/"+fname] = now
-		s3objs = append(s3objs, &s3.Object{
-			Key:          aws.String("key/" + fname),
-			LastModified: aws.Time(now),
-		})
+func TestS3ListExcludePrefixes(t *testing.T) {
+	keys := []string{
+		"key/a.txt",
+		"key/data/tmp/y.txt",
+		"key/data/x.txt",
+		"key/logs/1.txt",
+		"key/logs/2/3.txt",
+		"key/other/z.txt",
 	}
 
-	for i := 0; i < numObjectsToIgnore; i++ {
-		fname := fmt.Sprintf("%s/%d", pre, numObjectsToReturn+i)
-		later := time.Now().Add(time.Second * 10)
-
-		mapIgnoreObjNameToModtime[pre+"/"+fname] = later
-		s3objs = append(s3objs, &s3.Object{
-			Key:          aws.String("key/" + fname),
-			LastModified: aws.Time(later),
-		})
+	u, err := url.New("s3://bucket/key/*", url.WithExcludePrefixes([]string{"logs/", "data/tmp/"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
 
-	// shuffle the objects array to remove possible assumptions about how objects
-	// are stored.
-	rand.Shuffle(len(s3objs), func(i, j int) {
-		s3objs[i], s3objs[j] = s3objs[j], s3objs[i]
-	})
-
 	mockAPI := s3.New(unit.Session)
 
 	mockAPI.Handlers.Unmarshal.Clear()
@@ -900,31 +3131,57 @@ func TestS3listObjectsV2(t *testing.T) {
 	mockAPI.Handlers.UnmarshalError.Clear()
 	mockAPI.Handlers.Send.Clear()
 
+	var listedPrefixes []string
 	mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
 		r.HTTPResponse = &http.Response{
 			StatusCode: http.StatusOK,
 			Body:       io.NopCloser(strings.NewReader("")),
 		}
 
-		r.Data = &s3.ListObjectsV2Output{
-			Contents: s3objs,
+		input := r.Params.(*s3.ListObjectsV2Input)
+		prefix, delimiter := aws.StringValue(input.Prefix), aws.StringValue(input.Delimiter)
+		listedPrefixes = append(listedPrefixes, prefix+"|"+delimiter)
+
+		output := &s3.ListObjectsV2Output{}
+		seen := map[string]bool{}
+		for _, key := range keys {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			if i := strings.Index(key[len(prefix):], delimiter); delimiter != "" && i >= 0 {
+				commonPrefix := key[:len(prefix)+i+1]
+				if !seen[commonPrefix] {
+					seen[commonPrefix] = true
+					output.CommonPrefixes = append(output.CommonPrefixes, &s3.CommonPrefix{Prefix: aws.String(commonPrefix)})
+				}
+				continue
+			}
+			output.Contents = append(output.Contents, &s3.Object{Key: aws.String(key)})
 		}
+		r.Data = output
 	})
 
 	mockS3 := &S3{
 		api: mockAPI,
 	}
 
-	ouputCh := mockS3.listObjectsV2(context.Background(), u)
-
-	for obj := range ouputCh {
-		if _, ok := mapReturnObjNameToModtime[obj.String()]; ok {
-			delete(mapReturnObjNameToModtime, obj.String())
-			continue
+	var got []string
+	for obj := range mockS3.List(context.Background(), u, false) {
+		if obj.Err != nil {
+			t.Fatalf("unexpected error: %v", obj.Err)
 		}
-		t.Errorf("%v should not have been returned\n", obj)
+		got = append(got, obj.URL.Path)
+	}
+
+	expected := []string{"key/a.txt", "key/data/x.txt", "key/other/z.txt"}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("(-want +got):\n%v", diff)
+	}
+
+	expectedListings := []string{"key/|/", "key/data/|/", "key/other/|"}
+	if diff := cmp.Diff(expectedListings, listedPrefixes); diff != "" {
+		t.Errorf("(-want +got):\n%v", diff)
 	}
-	assert.Equal(t, len(mapReturnObjNameToModtime), 0)
 }
 
 func TestSessionCreateAndCachingWithDifferentBuckets(t *testing.T) {
@@ -1303,6 +3560,113 @@ func TestS3HeadObject(t *testing.T) {
 	}
 }
 
+func TestS3StatEtagIsMD5(t *testing.T) {
+	const etag = `"9a0364b9e99bb480dd25e1f0284c8555"`
+
+	testcases := []struct {
+		name     string
+		etag     string
+		sse      string
+		sseC     string
+		expected bool
+	}{
+		{name: "plain object", etag: etag, expected: true},
+		{name: "AES256 encrypted object", etag: etag, sse: "AES256", expected: true},
+		{name: "multipart upload", etag: `"9a0364b9e99bb480dd25e1f0284c8555-2"`},
+		{name: "KMS encrypted object", etag: etag, sse: "aws:kms"},
+		{name: "customer key encrypted object", etag: etag, sseC: "AES256"},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.New("s3://bucket/key")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			mockAPI := s3.New(unit.Session)
+			mockS3 := &S3{api: mockAPI}
+
+			mockAPI.Handlers.Send.Clear()
+			mockAPI.Handlers.Unmarshal.Clear()
+			mockAPI.Handlers.UnmarshalMeta.Clear()
+			mockAPI.Handlers.ValidateResponse.Clear()
+
+			mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("")),
+				}
+
+				output := r.Data.(*s3.HeadObjectOutput)
+				output.ETag = aws.String(tc.etag)
+				if tc.sse != "" {
+					output.ServerSideEncryption = aws.String(tc.sse)
+				}
+				if tc.sseC != "" {
+					output.SSECustomerAlgorithm = aws.String(tc.sseC)
+				}
+			})
+
+			obj, err := mockS3.Stat(context.Background(), u)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assert.Equal(t, obj.EtagIsMD5(), tc.expected)
+		})
+	}
+}
+
+func TestS3HeadObjectMetadata(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockAPI := s3.New(unit.Session)
+	mockS3 := &S3{api: mockAPI}
+
+	mockAPI.Handlers.Send.Clear()
+	mockAPI.Handlers.Unmarshal.Clear()
+	mockAPI.Handlers.UnmarshalMeta.Clear()
+	mockAPI.Handlers.ValidateResponse.Clear()
+
+	mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}
+
+		output := r.Data.(*s3.HeadObjectOutput)
+		output.CacheControl = aws.String("public, max-age=345600")
+		output.ContentType = aws.String("text/html")
+		output.ContentEncoding = aws.String("gzip")
+		output.ContentDisposition = aws.String("inline")
+		output.ContentLanguage = aws.String("en-US")
+		output.Expires = aws.String("Tue, 01 Oct 2024 20:30:00 GMT")
+		output.Metadata = map[string]*string{"Key": aws.String("value")}
+	})
+
+	_, metadata, err := mockS3.HeadObject(context.Background(), u)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := &Metadata{
+		CacheControl:       "public, max-age=345600",
+		Expires:            "2024-10-01T20:30:00Z",
+		ContentType:        "text/html",
+		ContentEncoding:    "gzip",
+		ContentDisposition: "inline",
+		ContentLanguage:    "en-US",
+		UserDefined:        map[string]string{"Key": "value"},
+	}
+	if diff := cmp.Diff(expected, metadata); diff != "" {
+		t.Errorf("(-want +got):\n%v", diff)
+	}
+}
+
 func valueAtPath(i interface{}, s string) interface{} {
 	v, err := awsutil.ValuesAtPath(i, s)
 	if err != nil || len(v) == 0 {
@@ -1319,6 +3683,188 @@ func valueAtPath(i interface{}, s string) interface{} {
 	return v[0]
 }
 
+func TestS3ExpectedBucketOwner(t *testing.T) {
+	const owner = "111122223333"
+
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testcases := []struct {
+		name string
+		fn   func(s *S3)
+	}{
+		{
+			name: "HeadObject",
+			fn:   func(s *S3) { _, _ = s.Stat(context.Background(), u) },
+		},
+		{
+			name: "GetObject",
+			fn:   func(s *S3) { _, _ = s.Read(context.Background(), u) },
+		},
+		{
+			name: "ListObjectsV2",
+			fn: func(s *S3) {
+				for range s.List(context.Background(), u, false) {
+				}
+			},
+		},
+		{
+			name: "CopyObject",
+			fn:   func(s *S3) { _ = s.Copy(context.Background(), u, u, Metadata{}) },
+		},
+		{
+			name: "PutObject",
+			fn: func(s *S3) {
+				_ = s.Put(context.Background(), bytes.NewReader([]byte("")), u, Metadata{}, 1, 5242880)
+			},
+		},
+		{
+			name: "DeleteObjects",
+			fn:   func(s *S3) { _ = s.Delete(context.Background(), u) },
+		},
+		{
+			name: "HeadBucket",
+			fn:   func(s *S3) { _ = s.HeadBucket(context.Background(), u) },
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		for _, expected := range []string{owner, ""} {
+			expected := expected
+			t.Run(fmt.Sprintf("%v/owner=%q", tc.name, expected), func(t *testing.T) {
+				mockAPI := s3.New(unit.Session)
+
+				mockAPI.Handlers.Unmarshal.Clear()
+				mockAPI.Handlers.UnmarshalMeta.Clear()
+				mockAPI.Handlers.UnmarshalError.Clear()
+				mockAPI.Handlers.Send.Clear()
+
+				var operations []string
+				mockAPI.Handlers.Send.PushBack(func(r *request.Request) {
+					r.HTTPResponse = &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader("")),
+					}
+
+					// deletion results are expected for each of the keys.
+					if output, ok := r.Data.(*s3.DeleteObjectsOutput); ok {
+						output.Deleted = []*s3.DeletedObject{{Key: aws.String(u.Path)}}
+					}
+
+					operations = append(operations, r.Operation.Name)
+					got := r.HTTPRequest.Header.Get("x-amz-expected-bucket-owner")
+					if got != expected {
+						t.Errorf("%v: expected bucket owner header %q, got %q", r.Operation.Name, expected, got)
+					}
+				})
+
+				mockS3 := &S3{
+					api:                 mockAPI,
+					uploader:            s3manager.NewUploaderWithClient(mockAPI),
+					expectedBucketOwner: expected,
+				}
+
+				tc.fn(mockS3)
+
+				if len(operations) == 0 || operations[0] != tc.name {
+					t.Errorf("expected %v request, got %v", tc.name, operations)
+				}
+			})
+		}
+	}
+}
+
+func TestExpectedBucketOwnerErrorHandler(t *testing.T) {
+	testcases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "access denied",
+			err:      awserr.NewRequestFailure(awserr.New("AccessDenied", "Access Denied", nil), http.StatusForbidden, "id"),
+			expected: `Access Denied: the bucket may not be owned by the expected bucket owner "111122223333"`,
+		},
+		{
+			name:     "forbidden without body",
+			err:      awserr.NewRequestFailure(awserr.New("Forbidden", "", nil), http.StatusForbidden, "id"),
+			expected: `Forbidden: the bucket may not be owned by the expected bucket owner "111122223333"`,
+		},
+		{
+			name:     "not found",
+			err:      awserr.NewRequestFailure(awserr.New("NoSuchKey", "The specified key does not exist.", nil), http.StatusNotFound, "id"),
+			expected: "The specified key does not exist.",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := &request.Request{Error: tc.err}
+			expectedBucketOwnerErrorHandler("111122223333")(r)
+
+			var reqErr awserr.RequestFailure
+			if !errors.As(r.Error, &reqErr) {
+				t.Fatalf("expected a request failure, got %T", r.Error)
+			}
+			assert.Equal(t, reqErr.Message(), tc.expected)
+			assert.Equal(t, reqErr.StatusCode(), tc.err.(awserr.RequestFailure).StatusCode())
+		})
+	}
+}
+
+func TestAutoPartSize(t *testing.T) {
+	const mib = 1024 * 1024
+
+	testcases := []struct {
+		name     string
+		size     int64
+		expected int64
+	}{
+		{
+			name:     "empty object",
+			size:     0,
+			expected: 5 * mib,
+		},
+		{
+			name:     "small object",
+			size:     100 * mib,
+			expected: 5 * mib,
+		},
+		{
+			name:     "object at the minimum part size limit",
+			size:     5 * mib * 9999,
+			expected: 5 * mib,
+		},
+		{
+			name:     "object exceeding the minimum part size limit",
+			size:     5 * mib * 10000,
+			expected: 6 * mib,
+		},
+		{
+			name:     "5TiB object",
+			size:     5 * 1024 * 1024 * mib,
+			expected: 525 * mib,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := AutoPartSize(tc.size)
+			if got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+			if got > 0 && tc.size/got >= s3manager.MaxUploadParts {
+				t.Errorf("%v parts exceed the maximum number of parts", tc.size/got)
+			}
+		})
+	}
+}
+
 // tempError is a wrapper error type that implements anonymous
 // interface getting checked in url.Error.Temporary;
 //
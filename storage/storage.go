@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/lanrat/extsort"
@@ -21,6 +22,9 @@ import (
 // ErrNoObjectFound indicates there are no objects found from a given directory.
 var ErrNoObjectFound = fmt.Errorf("no object found")
 
+// ErrListTruncated indicates there are more objects than the listed pages.
+var ErrListTruncated = fmt.Errorf("listing is truncated")
+
 // ErrGivenObjectNotFound indicates a specified object is not found.
 type ErrGivenObjectNotFound struct {
 	ObjectAbsPath string
@@ -53,25 +57,47 @@ type Storage interface {
 }
 
 func NewLocalClient(opts Options) *Filesystem {
-	return &Filesystem{dryRun: opts.DryRun}
+	return &Filesystem{
+		dryRun:            opts.DryRun,
+		symlinksAsObjects: opts.SymlinksAsObjects,
+		emptyDirs:         opts.EmptyDirs,
+	}
 }
 
 func NewRemoteClient(ctx context.Context, url *url.URL, opts Options) (*S3, error) {
 	newOpts := Options{
 		MaxRetries:             opts.MaxRetries,
+		RetryOn:                opts.RetryOn,
+		RetryBudget:            opts.RetryBudget,
 		NoSuchUploadRetryCount: opts.NoSuchUploadRetryCount,
 		Endpoint:               opts.Endpoint,
+		AddressingStyle:        opts.AddressingStyle,
 		NoVerifySSL:            opts.NoVerifySSL,
 		DryRun:                 opts.DryRun,
 		NoSignRequest:          opts.NoSignRequest,
 		UseListObjectsV1:       opts.UseListObjectsV1,
 		RequestPayer:           opts.RequestPayer,
+		ExpectedBucketOwner:    opts.ExpectedBucketOwner,
 		Profile:                opts.Profile,
 		CredentialFile:         opts.CredentialFile,
+		CredentialProcess:      opts.CredentialProcess,
+		WebIdentityTokenFile:   opts.WebIdentityTokenFile,
+		AssumeRoleARN:          opts.AssumeRoleARN,
 		LogLevel:               opts.LogLevel,
+		LeavePartsOnError:      opts.LeavePartsOnError,
+		PartRetryCount:         opts.PartRetryCount,
+		ChecksumCalculation:    opts.ChecksumCalculation,
+		FetchOwner:             opts.FetchOwner,
+		PageSize:               opts.PageSize,
 		bucket:                 url.Bucket,
 		region:                 opts.region,
 	}
+
+	// the region of an access point is known from its ARN, it doesn't need
+	// to be fetched.
+	if newOpts.region == "" && url.IsAccessPoint() {
+		newOpts.region = url.AccessPointRegion()
+	}
 	return newS3Storage(ctx, newOpts)
 }
 
@@ -85,16 +111,31 @@ func NewClient(ctx context.Context, url *url.URL, opts Options) (Storage, error)
 // Options stores configuration for storage.
 type Options struct {
 	MaxRetries             int
+	RetryOn                string
+	RetryBudget            int
 	NoSuchUploadRetryCount int
 	Endpoint               string
+	AddressingStyle        string
+	Backend                string
+	ChecksumCalculation    string
 	NoVerifySSL            bool
 	DryRun                 bool
 	NoSignRequest          bool
 	UseListObjectsV1       bool
 	LogLevel               log.LogLevel
 	RequestPayer           string
+	ExpectedBucketOwner    string
 	Profile                string
 	CredentialFile         string
+	CredentialProcess      string
+	WebIdentityTokenFile   string
+	AssumeRoleARN          string
+	LeavePartsOnError      bool
+	PartRetryCount         int
+	FetchOwner             bool
+	SymlinksAsObjects      bool
+	EmptyDirs              bool
+	PageSize               int64
 	bucket                 string
 	region                 string
 }
@@ -111,15 +152,55 @@ type Object struct {
 	Type         ObjectType   `json:"type,omitempty"`
 	Size         int64        `json:"size,omitempty"`
 	StorageClass StorageClass `json:"storage_class,omitempty"`
+	Owner        *Owner       `json:"owner,omitempty"`
 	Err          error        `json:"error,omitempty"`
 	retryID      string
 
+	// ChecksumAlgorithm is the algorithm of the additional checksum of the
+	// object, e.g. CRC32C, if it's returned by the listing.
+	ChecksumAlgorithm string `json:"checksum_algorithm,omitempty"`
+
+	// IsDeleteMarker reports whether the object is a delete marker of a
+	// versioned bucket, which is listed only with all versions.
+	IsDeleteMarker bool `json:"delete_marker,omitempty"`
+
+	// DeleteMarkerVersionID is the version ID of the delete marker which is
+	// created by deleting the object from a versioned bucket without a
+	// version ID, if it's returned by the delete operation.
+	DeleteMarkerVersionID string `json:"delete_marker_version_id,omitempty"`
+
 	// the VersionID field exist only for JSON Marshall, it must not be used for
 	// any other purpose. URL.VersionID must be used instead.
 	VersionID string `json:"version_id,omitempty"`
+
+	// etagNotMD5 is set if the object is known to be encrypted with KMS or a
+	// customer provided key, whose ETag is not the MD5 of its content.
+	etagNotMD5 bool
+}
+
+// Owner is the owner of an object.
+type Owner struct {
+	ID          string `json:"id,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+// IsDirMarker reports whether the object is a zero-byte directory marker
+// object, e.g. "dir/", which is created by tools like Hadoop to keep empty
+// directories. The common prefixes of a listing are not directory markers.
+func (o *Object) IsDirMarker() bool {
+	return o.URL != nil && o.URL.IsRemote() && o.ModTime != nil && o.Size == 0 &&
+		strings.HasSuffix(o.URL.Path, "/")
 }
 
 // String returns the string representation of Object.
+// EtagIsMD5 reports whether the ETag of the object is the MD5 digest of its
+// content, i.e. the object is not uploaded by parts or encrypted with KMS or
+// a customer provided key. The encryption is only known for the objects
+// returned by Stat.
+func (o *Object) EtagIsMD5() bool {
+	return !o.etagNotMD5 && checksumFromETag(o.Etag, "", "") != nil
+}
+
 func (o *Object) String() string {
 	return o.URL.String()
 }
@@ -216,14 +297,26 @@ func (s StorageClass) IsGlacier() bool {
 	return s == "GLACIER"
 }
 
+// Grants are the explicit grants of the permissions on an object, in the
+// format of the grant headers, e.g. `id="111122223333", uri="..."`. An empty
+// grant is not set.
+type Grants struct {
+	Read        string
+	ReadACP     string
+	WriteACP    string
+	FullControl string
+}
+
 type Metadata struct {
 	ACL                string
+	Grants             Grants
 	CacheControl       string
 	Expires            string
 	StorageClass       string
 	ContentType        string
 	ContentEncoding    string
 	ContentDisposition string
+	ContentLanguage    string
 	EncryptionMethod   string
 	EncryptionKeyID    string
 
@@ -233,6 +326,34 @@ type Metadata struct {
 	// the source object or replaced with metadata provided when copying S3
 	// objects. If MetadataDirective is not set, it defaults to "COPY".
 	Directive string
+
+	// IfMatch and IfNoneMatch make the uploads conditional. Objects are only
+	// overwritten if their ETag matches IfMatch, and only created if they
+	// don't exist when IfNoneMatch is "*".
+	IfMatch     string
+	IfNoneMatch string
+
+	// ChecksumAlgorithm is the algorithm of the additional checksum which is
+	// computed while the object is uploaded, and stored with the object.
+	ChecksumAlgorithm string
+	// ChecksumType is the type of the additional checksum of the multipart
+	// uploads, FULL_OBJECT or COMPOSITE. It is COMPOSITE if it's empty.
+	ChecksumType string
+
+	// ResumeSource is the source of a resumable upload. The multipart uploads
+	// of it are marked with its resume marker, so that they can be resumed
+	// if they are interrupted.
+	ResumeSource *Object
+}
+
+// DownloadConditions make the downloads conditional. The objects which are
+// not modified since IfModifiedSince, or whose ETag matches IfNoneMatch, are
+// not downloaded. The downloads of the objects whose ETag doesn't match
+// IfMatch fail with a precondition failed error.
+type DownloadConditions struct {
+	IfModifiedSince *time.Time
+	IfMatch         string
+	IfNoneMatch     string
 }
 
 func (o Object) ToBytes() []byte {
@@ -242,6 +363,7 @@ func (o Object) ToBytes() []byte {
 	enc.Encode(o.ModTime.Format(time.RFC3339Nano))
 	enc.Encode(o.Type.mode)
 	enc.Encode(o.Size)
+	enc.Encode(o.Etag)
 
 	return buf.Bytes()
 }
@@ -260,6 +382,7 @@ func FromBytes(data []byte) extsort.SortType {
 	o.ModTime = &tmp
 	dec.Decode(&o.Type.mode)
 	dec.Decode(&o.Size)
+	dec.Decode(&o.Etag)
 	return o
 }
 
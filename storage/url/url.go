@@ -13,6 +13,7 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/lanrat/extsort"
 	"github.com/peak/s5cmd/v2/strutil"
 )
@@ -53,6 +54,10 @@ type URL struct {
 	filter       string
 	filterRegex  *regexp.Regexp
 	raw          bool
+	delimiter    string
+
+	excludePrefixes []string
+	excludeDirs     []*regexp.Regexp
 }
 
 type Option func(u *URL)
@@ -69,12 +74,42 @@ func WithVersion(versionID string) Option {
 	}
 }
 
+// WithDelimiter sets the delimiter which groups the keys of the non-wildcard
+// remote URLs into common prefixes while listing. It is "/" by default.
+func WithDelimiter(delimiter string) Option {
+	return func(u *URL) {
+		u.delimiter = delimiter
+	}
+}
+
 func WithAllVersions(isAllVersions bool) Option {
 	return func(u *URL) {
 		u.AllVersions = isAllVersions
 	}
 }
 
+// WithExcludePrefixes sets the prefixes, relative to the source, of the
+// subtrees that are not descended into while listing.
+func WithExcludePrefixes(prefixes []string) Option {
+	return func(u *URL) {
+		for _, prefix := range prefixes {
+			if prefix == "" {
+				continue
+			}
+			u.excludePrefixes = append(u.excludePrefixes, filepath.ToSlash(prefix))
+		}
+	}
+}
+
+// WithExcludeDirs sets the patterns of the local directories, relative to
+// the source, whose contents are excluded as a whole. They are not descended
+// into while walking.
+func WithExcludeDirs(patterns []*regexp.Regexp) Option {
+	return func(u *URL) {
+		u.excludeDirs = patterns
+	}
+}
+
 // New creates a new URL from given path string.
 func New(s string, opts ...Option) (*URL, error) {
 	scheme, rest, isFound := strings.Cut(s, "://")
@@ -103,12 +138,19 @@ func New(s string, opts ...Option) (*URL, error) {
 		return nil, fmt.Errorf("s3 url should start with %q", s3Scheme)
 	}
 
-	parts := strings.SplitN(rest, s3Separator, 2)
-
-	key := ""
-	bucket := parts[0]
-	if len(parts) == 2 {
-		key = parts[1]
+	var bucket, key string
+	if strings.HasPrefix(rest, arnPrefix) {
+		var err error
+		bucket, key, err = splitAccessPointARN(rest)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		parts := strings.SplitN(rest, s3Separator, 2)
+		bucket = parts[0]
+		if len(parts) == 2 {
+			key = parts[1]
+		}
 	}
 
 	if bucket == "" {
@@ -136,6 +178,86 @@ func New(s string, opts ...Option) (*URL, error) {
 	return url, nil
 }
 
+// arnPrefix is the prefix of the access point ARNs which are used in place of
+// the bucket names.
+const arnPrefix = "arn:"
+
+// splitAccessPointARN splits the given string, which starts with an access
+// point or an Outposts access point ARN, into the ARN and the key. The
+// supported ARN formats are:
+//
+//	arn:aws:s3:region:account-id:accesspoint/name
+//	arn:aws:s3-outposts:region:account-id:outpost/outpost-id/accesspoint/name
+func splitAccessPointARN(s string) (bucket, key string, err error) {
+	parsed, err := arn.Parse(s)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid access point ARN %q: %v", s, err)
+	}
+
+	if parsed.Region == "" {
+		return "", "", fmt.Errorf("invalid access point ARN %q: region is missing", s)
+	}
+	if parsed.AccountID == "" {
+		return "", "", fmt.Errorf("invalid access point ARN %q: account ID is missing", s)
+	}
+
+	// the number of the resource fields which belong to the ARN, the rest
+	// of the resource is the key.
+	var n int
+	switch parsed.Service {
+	case "s3":
+		n = 2
+	case "s3-outposts":
+		n = 4
+	default:
+		return "", "", fmt.Errorf("invalid access point ARN %q: unsupported service %q", s, parsed.Service)
+	}
+
+	fields := strings.SplitN(parsed.Resource, s3Separator, n+1)
+	if len(fields) < n {
+		return "", "", fmt.Errorf("invalid access point ARN %q: malformed resource %q", s, parsed.Resource)
+	}
+	for _, field := range fields[:n] {
+		if field == "" {
+			return "", "", fmt.Errorf("invalid access point ARN %q: malformed resource %q", s, parsed.Resource)
+		}
+	}
+
+	switch {
+	case parsed.Service == "s3" && fields[0] == "accesspoint":
+	case parsed.Service == "s3-outposts" && fields[0] == "outpost" && fields[2] == "accesspoint":
+	default:
+		return "", "", fmt.Errorf("invalid access point ARN %q: malformed resource %q", s, parsed.Resource)
+	}
+
+	if len(fields) == n+1 {
+		key = fields[n]
+	}
+	resource := strings.Join(fields[:n], s3Separator)
+	bucket = strings.TrimSuffix(s, parsed.Resource) + resource
+	return bucket, key, nil
+}
+
+// IsAccessPoint reports whether the bucket of the remote URL is an access
+// point or an Outposts access point ARN.
+func (u *URL) IsAccessPoint() bool {
+	return u.IsRemote() && strings.HasPrefix(u.Bucket, arnPrefix)
+}
+
+// AccessPointRegion returns the region of the access point ARN of the remote
+// URL. It returns an empty string if the bucket is not an access point ARN.
+func (u *URL) AccessPointRegion() string {
+	if !u.IsAccessPoint() {
+		return ""
+	}
+
+	parsed, err := arn.Parse(u.Bucket)
+	if err != nil {
+		return ""
+	}
+	return parsed.Region
+}
+
 // IsRemote reports whether the object is stored on a remote storage system.
 func (u *URL) IsRemote() bool {
 	return u.Type == remoteObject
@@ -263,6 +385,9 @@ func (u *URL) setPrefixAndFilter() error {
 
 	if loc := strings.IndexAny(u.Path, globCharacters); loc < 0 {
 		u.Delimiter = s3Separator
+		if u.delimiter != "" {
+			u.Delimiter = u.delimiter
+		}
 		u.Prefix = u.Path
 	} else {
 		u.Prefix = u.Path[:loc]
@@ -300,6 +425,10 @@ func (u *URL) Clone() *URL {
 		filter:       u.filter,
 		filterRegex:  u.filterRegex,
 		raw:          u.raw,
+		delimiter:    u.delimiter,
+
+		excludePrefixes: u.excludePrefixes,
+		excludeDirs:     u.excludeDirs,
 	}
 }
 
@@ -354,7 +483,7 @@ func (u *URL) Match(key string) bool {
 		return true
 	}
 
-	v := parseNonBatch(u.Prefix, key)
+	v := parseNonBatch(u.Prefix, key, u.Delimiter)
 	u.relativePath = v
 	return true
 }
@@ -403,6 +532,147 @@ func (u *URL) IsRaw() bool {
 	return u.raw
 }
 
+// HasExcludePrefixes reports whether any prefix is set to be pruned while
+// listing.
+func (u *URL) HasExcludePrefixes() bool {
+	return len(u.excludePrefixes) > 0
+}
+
+// IsExcludedPrefix reports whether the given key or path falls under one of
+// the excluded prefixes. Directory paths must end with a separator.
+func (u *URL) IsExcludedPrefix(key string) bool {
+	if !u.HasExcludePrefixes() {
+		return false
+	}
+	rel := u.excludeRelative(key)
+	for _, prefix := range u.excludePrefixes {
+		if strings.HasPrefix(rel, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasExcludedPrefixBelow reports whether any of the excluded prefixes is
+// located under the given directory, which means the directory must be
+// descended selectively instead of being listed as a whole.
+func (u *URL) HasExcludedPrefixBelow(dir string) bool {
+	if !u.HasExcludePrefixes() {
+		return false
+	}
+	rel := u.excludeRelative(dir)
+	for _, prefix := range u.excludePrefixes {
+		if prefix != rel && strings.HasPrefix(prefix, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExcludedDir reports whether the given local directory matches one of the
+// excluded directory patterns. The source directory itself is never
+// excluded.
+func (u *URL) IsExcludedDir(dir string) bool {
+	if len(u.excludeDirs) == 0 {
+		return false
+	}
+	rel, ok := u.relativeKey(dir)
+	if !ok || rel == "" {
+		return false
+	}
+	if !strings.HasSuffix(rel, s3Separator) {
+		rel += s3Separator
+	}
+	for _, pattern := range u.excludeDirs {
+		if pattern.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// RelativeKey returns the given key or path relative to the root of the
+// source, which the exclude and include patterns are matched against. The
+// root is the parent directory of the wildcard part for wildcard URLs, and
+// the URL itself for prefixes and local directories. The key is returned as
+// is if it is not under the root, e.g. the source is a single object.
+//
+// Example:
+//
+//	url: ./project
+//	key: project/node_modules/a.js
+//	output: node_modules/a.js
+func (u *URL) RelativeKey(key string) string {
+	if rel, ok := u.relativeKey(key); ok {
+		return rel
+	}
+	return filepath.ToSlash(key)
+}
+
+func (u *URL) relativeKey(key string) (string, bool) {
+	root := u.Prefix
+	if u.IsWildcard() {
+		root = root[:strings.LastIndex(root, s3Separator)+1]
+	}
+
+	if u.IsRemote() {
+		if root != "" && !strings.HasSuffix(root, s3Separator) {
+			root += s3Separator
+		}
+		if !strings.HasPrefix(key, root) {
+			return "", false
+		}
+		return strings.TrimPrefix(key, root), true
+	}
+
+	if root == "" {
+		root = "."
+	}
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(key))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if strings.HasSuffix(key, s3Separator) || strings.HasSuffix(key, string(filepath.Separator)) {
+		rel += s3Separator
+	}
+	return rel, true
+}
+
+// excludeRelative returns the given key or path relative to the directory
+// the excluded prefixes are defined against. It is the parent directory of
+// the wildcard part for wildcard URLs and the URL itself for local
+// directories.
+//
+// Example:
+//
+//	url: s3://bucket/a/b/*.txt
+//	key: a/b/c/d.txt
+//	output: c/d.txt
+func (u *URL) excludeRelative(key string) string {
+	root := u.Prefix
+	if u.IsRemote() || u.IsWildcard() {
+		root = root[:strings.LastIndex(root, s3Separator)+1]
+	}
+
+	if u.IsRemote() {
+		return strings.TrimPrefix(key, root)
+	}
+
+	if root == "" {
+		root = "."
+	}
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(key))
+	if err != nil || rel == "." {
+		return ""
+	}
+	rel = filepath.ToSlash(rel)
+	if strings.HasSuffix(key, s3Separator) || strings.HasSuffix(key, string(filepath.Separator)) {
+		rel += s3Separator
+	}
+	return rel
+}
+
 // parseBatch parses keys for wildcard operations.
 // It cuts the key starting from first directory before the
 // wildcard part (filter)
@@ -424,29 +694,33 @@ func parseBatch(prefix string, key string) string {
 
 // parseNonBatch parses keys for non-wildcard operations.
 // It subtracts prefix part from the key and gets first
-// path.
+// path, which ends with the given delimiter.
 //
 // Example:
 //
 //	key: a/b/c/d
 //	prefix: a/b
+//	delimiter: /
 //	output: c/
-func parseNonBatch(prefix string, key string) string {
+func parseNonBatch(prefix string, key string, delimiter string) string {
+	if delimiter == "" {
+		delimiter = s3Separator
+	}
 	if key == prefix || !strings.HasPrefix(key, prefix) {
 		return key
 	}
-	parsedKey := strings.TrimSuffix(key, s3Separator)
-	if loc := strings.LastIndex(parsedKey, s3Separator); loc < len(prefix) {
+	parsedKey := strings.TrimSuffix(key, delimiter)
+	if loc := strings.LastIndex(parsedKey, delimiter); loc < len(prefix) {
 		if loc < 0 {
 			return key
 		}
 		parsedKey = key[loc:]
-		return strings.TrimPrefix(parsedKey, s3Separator)
+		return strings.TrimPrefix(parsedKey, delimiter)
 	}
 	parsedKey = strings.TrimPrefix(key, prefix)
-	parsedKey = strings.TrimPrefix(parsedKey, s3Separator)
-	index := strings.Index(parsedKey, s3Separator) + 1
-	if index <= 0 || index >= len(parsedKey) {
+	parsedKey = strings.TrimPrefix(parsedKey, delimiter)
+	index := strings.Index(parsedKey, delimiter) + len(delimiter)
+	if index < len(delimiter) || index >= len(parsedKey) {
 		return parsedKey
 	}
 	trimmedKey := parsedKey[:index]
@@ -460,6 +734,11 @@ func hasGlobCharacter(s string) bool {
 
 func (u *URL) EscapedPath() string {
 	sourceKey := strings.TrimPrefix(u.String(), "s3://")
+	// the objects of the access points are referred as
+	// "<access-point-arn>/object/<key>".
+	if u.IsAccessPoint() {
+		sourceKey = u.Bucket + "/object/" + u.Path
+	}
 	sourceKeyElements := strings.Split(sourceKey, "/")
 	for i, element := range sourceKeyElements {
 		sourceKeyElements[i] = url.QueryEscape(element)
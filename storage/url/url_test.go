@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -413,10 +414,11 @@ func TestParseBatch(t *testing.T) {
 
 func TestParseNonBatch(t *testing.T) {
 	tests := []struct {
-		name   string
-		prefix string
-		key    string
-		want   string
+		name      string
+		prefix    string
+		key       string
+		delimiter string
+		want      string
 	}{
 		{
 			name:   "do_nothing_if_key_does_not_include_prefix",
@@ -454,11 +456,46 @@ func TestParseNonBatch(t *testing.T) {
 			key:    "testdir/",
 			want:   "testdir/",
 		},
+		{
+			name:      "parse_key_and_return_first_group_after_prefix_with_custom_delimiter",
+			prefix:    "a|b|",
+			key:       "a|b|c|d",
+			delimiter: "|",
+			want:      "c|",
+		},
+		{
+			name:      "parse_key_and_return_asset_after_prefix_with_custom_delimiter",
+			prefix:    "a|b",
+			key:       "a|b|asset.txt",
+			delimiter: "|",
+			want:      "asset.txt",
+		},
+		{
+			name:      "parse_key_and_return_current_group_if_prefix_is_not_group_with_custom_delimiter",
+			prefix:    "te",
+			key:       "test|",
+			delimiter: "|",
+			want:      "test|",
+		},
+		{
+			name:      "parse_key_and_return_first_group_after_prefix_with_multi_character_delimiter",
+			prefix:    "a--",
+			key:       "a--b--c",
+			delimiter: "--",
+			want:      "b--",
+		},
+		{
+			name:      "slash_is_not_a_delimiter_with_custom_delimiter",
+			prefix:    "",
+			key:       "a/b|c/d",
+			delimiter: "|",
+			want:      "a/b|",
+		},
 	}
 	for _, tc := range tests {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			if got := parseNonBatch(tc.prefix, tc.key); got != tc.want {
+			if got := parseNonBatch(tc.prefix, tc.key, tc.delimiter); got != tc.want {
 				t.Errorf("parseNonBatch() = %v, want %v", got, tc.want)
 			}
 		})
@@ -497,6 +534,37 @@ func TestURLIsBucket(t *testing.T) {
 	}
 }
 
+func TestURLWithDelimiter(t *testing.T) {
+	tests := []struct {
+		input             string
+		delimiter         string
+		delimiterExpected string
+		relativeExpected  string
+	}{
+		{"s3://bucket/a|b|", "|", "|", "c|"},
+		{"s3://bucket/a|b|", "", "/", "a|b|c|d.txt"},
+		{"s3://bucket/a|b|*", "|", "", "a|b|c|d.txt"},
+	}
+	for _, tc := range tests {
+		url, err := New(tc.input, WithDelimiter(tc.delimiter))
+		if err != nil {
+			t.Fatalf("unexpected error: %v for input %s", err, tc.input)
+		}
+
+		// wildcard URLs are listed without a delimiter.
+		if url.Delimiter != tc.delimiterExpected {
+			t.Errorf("%s: url delimiter %q does not match with expected %q", tc.input, url.Delimiter, tc.delimiterExpected)
+		}
+
+		if !url.Match("a|b|c|d.txt") {
+			t.Fatalf("%s: expected to match the key", tc.input)
+		}
+		if got := url.Relative(); got != tc.relativeExpected {
+			t.Errorf("%s: relative path %q does not match with expected %q", tc.input, got, tc.relativeExpected)
+		}
+	}
+}
+
 func TestURLWithMode(t *testing.T) {
 	tests := []struct {
 		input          string
@@ -644,3 +712,308 @@ func TestToFromBytes(t *testing.T) {
 		})
 	}
 }
+
+func TestURLIsExcludedPrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		key     string
+		isDir   bool
+		want    bool
+		descend bool
+	}{
+		{
+			name: "remote_key_under_excluded_prefix",
+			url:  "s3://bucket/a/*",
+			key:  "a/logs/file.txt",
+			want: true,
+		},
+		{
+			name: "remote_key_with_similar_name",
+			url:  "s3://bucket/a/*",
+			key:  "a/logs.txt",
+			want: false,
+		},
+		{
+			name:    "remote_parent_of_excluded_prefix",
+			url:     "s3://bucket/a/*",
+			key:     "a/data/",
+			want:    false,
+			descend: true,
+		},
+		{
+			name: "remote_excluded_prefix_of_nested_wildcard",
+			url:  "s3://bucket/a/b*.txt",
+			key:  "a/data/tmp/",
+			want: true,
+		},
+		{
+			name: "local_excluded_directory",
+			url:  "dir/",
+			key:  filepath.Join("dir", "logs") + string(filepath.Separator),
+			want: true,
+		},
+		{
+			name:    "local_parent_of_excluded_directory",
+			url:     "dir",
+			key:     filepath.Join("dir", "data") + string(filepath.Separator),
+			want:    false,
+			descend: true,
+		},
+		{
+			name:    "local_root_directory",
+			url:     "dir",
+			key:     "dir",
+			want:    false,
+			descend: true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := New(tc.url, WithExcludePrefixes([]string{"logs/", "data/tmp/"}))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := u.IsExcludedPrefix(tc.key); got != tc.want {
+				t.Errorf("IsExcludedPrefix() = %v, want %v", got, tc.want)
+			}
+			if got := u.HasExcludedPrefixBelow(tc.key); got != tc.descend {
+				t.Errorf("HasExcludedPrefixBelow() = %v, want %v", got, tc.descend)
+			}
+		})
+	}
+}
+
+func TestURLIsExcludedDir(t *testing.T) {
+	excludeDirs := []*regexp.Regexp{regexp.MustCompile(`^(?s)(.*/)?node_modules/`)}
+
+	tests := []struct {
+		name string
+		url  string
+		dir  string
+		want bool
+	}{
+		{
+			name: "excluded_directory",
+			url:  "project",
+			dir:  filepath.Join("project", "node_modules"),
+			want: true,
+		},
+		{
+			name: "nested_excluded_directory",
+			url:  "./project/",
+			dir:  filepath.Join("project", "web", "node_modules"),
+			want: true,
+		},
+		{
+			name: "excluded_directory_of_wildcard",
+			url:  "project/*",
+			dir:  filepath.Join("project", "node_modules"),
+			want: true,
+		},
+		{
+			name: "directory_with_similar_name",
+			url:  "project",
+			dir:  filepath.Join("project", "node_modules_old"),
+			want: false,
+		},
+		{
+			name: "source_directory",
+			url:  "node_modules",
+			dir:  "node_modules",
+			want: false,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := New(tc.url, WithExcludeDirs(excludeDirs))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := u.IsExcludedDir(tc.dir); got != tc.want {
+				t.Errorf("IsExcludedDir() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewAccessPoint(t *testing.T) {
+	const (
+		accessPointARN = "arn:aws:s3:us-west-2:123456789012:accesspoint/my-access-point"
+		outpostsARN    = "arn:aws:s3-outposts:us-west-2:123456789012:outpost/op-01ac5d28a6a232904/accesspoint/my-access-point"
+	)
+
+	tests := []struct {
+		name       string
+		object     string
+		wantBucket string
+		wantPath   string
+		wantRegion string
+		wantErr    bool
+	}{
+		{
+			name:       "access_point",
+			object:     "s3://" + accessPointARN,
+			wantBucket: accessPointARN,
+			wantRegion: "us-west-2",
+		},
+		{
+			name:       "access_point_with_trailing_slash",
+			object:     "s3://" + accessPointARN + "/",
+			wantBucket: accessPointARN,
+			wantRegion: "us-west-2",
+		},
+		{
+			name:       "access_point_with_key",
+			object:     "s3://" + accessPointARN + "/prefix/key.txt",
+			wantBucket: accessPointARN,
+			wantPath:   "prefix/key.txt",
+			wantRegion: "us-west-2",
+		},
+		{
+			name:       "access_point_with_wildcard",
+			object:     "s3://" + accessPointARN + "/prefix/*.txt",
+			wantBucket: accessPointARN,
+			wantPath:   "prefix/*.txt",
+			wantRegion: "us-west-2",
+		},
+		{
+			name:       "access_point_of_other_partition",
+			object:     "s3://arn:aws-cn:s3:cn-north-1:123456789012:accesspoint/my-access-point/key",
+			wantBucket: "arn:aws-cn:s3:cn-north-1:123456789012:accesspoint/my-access-point",
+			wantPath:   "key",
+			wantRegion: "cn-north-1",
+		},
+		{
+			name:       "outposts_access_point",
+			object:     "s3://" + outpostsARN,
+			wantBucket: outpostsARN,
+			wantRegion: "us-west-2",
+		},
+		{
+			name:       "outposts_access_point_with_key",
+			object:     "s3://" + outpostsARN + "/prefix/key.txt",
+			wantBucket: outpostsARN,
+			wantPath:   "prefix/key.txt",
+			wantRegion: "us-west-2",
+		},
+		{
+			name:    "error_if_not_enough_sections",
+			object:  "s3://arn:aws:s3:us-west-2:accesspoint/my-access-point",
+			wantErr: true,
+		},
+		{
+			name:    "error_if_region_is_missing",
+			object:  "s3://arn:aws:s3::123456789012:accesspoint/my-access-point",
+			wantErr: true,
+		},
+		{
+			name:    "error_if_account_id_is_missing",
+			object:  "s3://arn:aws:s3:us-west-2::accesspoint/my-access-point",
+			wantErr: true,
+		},
+		{
+			name:    "error_if_bucket_arn",
+			object:  "s3://arn:aws:s3:::bucket/key",
+			wantErr: true,
+		},
+		{
+			name:    "error_if_unsupported_service",
+			object:  "s3://arn:aws:s3-object-lambda:us-west-2:123456789012:accesspoint/my-access-point",
+			wantErr: true,
+		},
+		{
+			name:    "error_if_unsupported_resource",
+			object:  "s3://arn:aws:s3:us-west-2:123456789012:bucket/my-bucket",
+			wantErr: true,
+		},
+		{
+			name:    "error_if_access_point_name_is_missing",
+			object:  "s3://arn:aws:s3:us-west-2:123456789012:accesspoint/",
+			wantErr: true,
+		},
+		{
+			name:    "error_if_outposts_access_point_is_missing",
+			object:  "s3://arn:aws:s3-outposts:us-west-2:123456789012:outpost/op-01ac5d28a6a232904",
+			wantErr: true,
+		},
+		{
+			name:    "error_if_outposts_resource_is_malformed",
+			object:  "s3://arn:aws:s3-outposts:us-west-2:123456789012:outpost/op-01ac5d28a6a232904/bucket/my-bucket",
+			wantErr: true,
+		},
+		{
+			name:    "error_if_access_point_name_has_wildcard",
+			object:  "s3://arn:aws:s3:us-west-2:123456789012:accesspoint/my-*",
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := New(tc.object)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			if !got.IsAccessPoint() {
+				t.Errorf("expected %q to be an access point", tc.object)
+			}
+			if diff := cmp.Diff(tc.wantBucket, got.Bucket); diff != "" {
+				t.Errorf("bucket mismatch (-want +got):\n%v", diff)
+			}
+			if diff := cmp.Diff(tc.wantPath, got.Path); diff != "" {
+				t.Errorf("path mismatch (-want +got):\n%v", diff)
+			}
+			if diff := cmp.Diff(tc.wantRegion, got.AccessPointRegion()); diff != "" {
+				t.Errorf("region mismatch (-want +got):\n%v", diff)
+			}
+			// the trailing slash of a bucket is dropped, as in bucket names.
+			if diff := cmp.Diff(strings.TrimSuffix(tc.object, "/"), got.Absolute()); diff != "" {
+				t.Errorf("absolute mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestURLIsAccessPoint(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"s3://arn:aws:s3:us-west-2:123456789012:accesspoint/my-access-point/key", true},
+		{"s3://bucket/arn:aws:s3:us-west-2:123456789012:accesspoint/my-access-point", false},
+		{"s3://bucket", false},
+		{"arn:aws:s3:us-west-2:123456789012:accesspoint/my-access-point", false},
+	}
+	for _, tc := range tests {
+		url, err := New(tc.input)
+		if err != nil {
+			t.Errorf("unexpected error: %v for input %s", err, tc.input)
+			continue
+		}
+
+		if url.IsAccessPoint() != tc.want {
+			t.Errorf("IsAccessPoint should return %v for %s", tc.want, tc.input)
+		}
+	}
+}
+
+func TestURLEscapedPathOfAccessPoint(t *testing.T) {
+	u, err := New("s3://arn:aws:s3:us-west-2:123456789012:accesspoint/my-access-point/reports/january 2024.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "arn%3Aaws%3As3%3Aus-west-2%3A123456789012%3Aaccesspoint/my-access-point/object/reports/january+2024.pdf"
+	if diff := cmp.Diff(want, u.EscapedPath()); diff != "" {
+		t.Errorf("(-want +got):\n%v", diff)
+	}
+}
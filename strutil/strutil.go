@@ -37,6 +37,29 @@ func HumanizeBytes(b int64) string {
 	return fmt.Sprintf("%.1f%s", float64(b)/float64(div), suffix)
 }
 
+// ParseBytes parses a human-readable byte-size, e.g. "512", "100K", "10GB"
+// or "1.5TiB". The units are powers of 1024 as in HumanizeBytes.
+func ParseBytes(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	value = strings.TrimSuffix(value, "B")
+	value = strings.TrimSuffix(value, "I")
+
+	multiplier := int64(1)
+	for _, f := range humanDivisors {
+		if strings.HasSuffix(value, f.suffix) {
+			value = strings.TrimSuffix(value, f.suffix)
+			multiplier = f.div
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
 // JSON is a helper function for creating JSON-encoded strings.
 func JSON(v interface{}) string {
 	bytes, _ := json.Marshal(v)
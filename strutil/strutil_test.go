@@ -74,3 +74,34 @@ func Test_WildCardToRegexp(t *testing.T) {
 		})
 	}
 }
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    int64
+		wantErr bool
+	}{
+		{arg: "512", want: 512},
+		{arg: "512B", want: 512},
+		{arg: "100K", want: 100 << 10},
+		{arg: "100kb", want: 100 << 10},
+		{arg: "10GB", want: 10 << 30},
+		{arg: "10 GiB", want: 10 << 30},
+		{arg: "1.5T", want: 3 << 39},
+		{arg: "", wantErr: true},
+		{arg: "GB", wantErr: true},
+		{arg: "-1G", wantErr: true},
+		{arg: "10XB", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, err := ParseBytes(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBytes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseBytes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}